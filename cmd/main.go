@@ -1,246 +1,932 @@
-package main
-
-import (
-	"context"
-	"fmt"
-	"net/http"
-	"os"
-	"os/signal"
-	"strconv"
-	"syscall"
-	"time"
-
-	"github.com/gin-gonic/gin"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
-	"github.com/vhvplatform/go-notification-service/internal/consumer"
-	"github.com/vhvplatform/go-notification-service/internal/dlq"
-	"github.com/vhvplatform/go-notification-service/internal/handler"
-	"github.com/vhvplatform/go-notification-service/internal/middleware"
-	"github.com/vhvplatform/go-notification-service/internal/repository"
-	"github.com/vhvplatform/go-notification-service/internal/scheduler"
-	"github.com/vhvplatform/go-notification-service/internal/service"
-	"github.com/vhvplatform/go-notification-service/internal/shared/config"
-	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
-	"github.com/vhvplatform/go-notification-service/internal/shared/mongodb"
-	"github.com/vhvplatform/go-notification-service/internal/shared/rabbitmq"
-	"github.com/vhvplatform/go-notification-service/internal/webhook"
-)
-
-func main() {
-	// Initialize logger
-	log := logger.NewLogger()
-	defer log.Sync()
-
-	log.Info("Starting Notification Service...")
-
-	// Load configuration
-	cfg, err := config.LoadConfig()
-	if err != nil {
-		log.Fatal("Failed to load configuration", "error", err)
-	}
-
-	// Initialize MongoDB
-	mongoClient, err := mongodb.NewMongoClient(cfg.MongoDB.URI, cfg.MongoDB.Database)
-	if err != nil {
-		log.Fatal("Failed to connect to MongoDB", "error", err)
-	}
-	defer mongoClient.Disconnect(context.Background())
-
-	// Initialize RabbitMQ
-	rabbitMQClient, err := rabbitmq.NewRabbitMQClient(cfg.RabbitMQ.URL)
-	if err != nil {
-		log.Fatal("Failed to connect to RabbitMQ", "error", err)
-	}
-	defer rabbitMQClient.Close()
-
-	// Initialize repositories
-	notificationRepo := repository.NewNotificationRepository(mongoClient)
-	templateRepo := repository.NewTemplateRepository(mongoClient)
-	failedNotificationRepo := repository.NewFailedNotificationRepository(mongoClient)
-	scheduledNotificationRepo := repository.NewScheduledNotificationRepository(mongoClient)
-	preferencesRepo := repository.NewPreferencesRepository(mongoClient)
-	bounceRepo := repository.NewBounceRepository(mongoClient)
-
-	// Get configuration from environment
-	smtpPoolSize, _ := strconv.Atoi(getEnv("SMTP_POOL_SIZE", "10"))
-	emailWorkers, _ := strconv.Atoi(getEnv("EMAIL_WORKERS", "5"))
-	rateLimitPerTenant, _ := strconv.ParseFloat(getEnv("RATE_LIMIT_PER_TENANT", "100"), 64)
-	rateLimitBurst, _ := strconv.Atoi(getEnv("RATE_LIMIT_BURST", "200"))
-
-	// Initialize services
-	emailConfig := service.EmailConfig{
-		SMTPHost:     cfg.SMTP.Host,
-		SMTPPort:     cfg.SMTP.Port,
-		SMTPUsername: cfg.SMTP.Username,
-		SMTPPassword: cfg.SMTP.Password,
-		FromEmail:    cfg.SMTP.FromEmail,
-		FromName:     cfg.SMTP.FromName,
-		PoolSize:     smtpPoolSize,
-	}
-	emailService := service.NewEmailService(emailConfig, notificationRepo, templateRepo, log)
-	defer emailService.Close()
-
-	smsConfig := service.SMSConfig{
-		Provider:    getEnv("SMS_PROVIDER", "twilio"),
-		TwilioSID:   getEnv("TWILIO_SID", ""),
-		TwilioToken: getEnv("TWILIO_TOKEN", ""),
-		TwilioFrom:  getEnv("TWILIO_FROM", ""),
-		AWSSNSARN:   getEnv("AWS_SNS_ARN", ""),
-		AWSRegion:   getEnv("AWS_REGION", ""),
-	}
-	smsService := service.NewSMSService(smsConfig, notificationRepo, log)
-
-	webhookService := service.NewWebhookService(notificationRepo, log)
-	notificationService := service.NewNotificationService(notificationRepo, emailService, webhookService, smsService, log)
-
-	// Initialize Dead Letter Queue
-	deadLetterQueue := dlq.NewDeadLetterQueue(failedNotificationRepo, log)
-
-	// Initialize Bounce Checker (can be integrated into email service if needed)
-	_ = service.NewBounceChecker(bounceRepo)
-
-	// Initialize Bulk Email Service
-	bulkEmailService := service.NewBulkEmailService(emailService, emailWorkers, log)
-	bulkEmailService.Start()
-	defer bulkEmailService.Stop()
-
-	// Initialize Scheduler
-	notificationScheduler := scheduler.NewNotificationScheduler(notificationService, scheduledNotificationRepo, log)
-	if err := notificationScheduler.Start(); err != nil {
-		log.Error("Failed to start scheduler", "error", err)
-	}
-	defer notificationScheduler.Stop()
-
-	// Initialize HTTP handlers
-	notificationHandler := handler.NewNotificationHandler(notificationService, log)
-	smsHandler := handler.NewSMSHandler(notificationService, log)
-	bulkHandler := handler.NewBulkHandler(bulkEmailService, log)
-	preferencesHandler := handler.NewPreferencesHandler(preferencesRepo, log)
-	scheduleHandler := handler.NewScheduleHandler(scheduledNotificationRepo, notificationScheduler, log)
-	dlqHandler := handler.NewDLQHandler(deadLetterQueue, notificationService, log)
-	bounceHandler := webhook.NewBounceHandler(bounceRepo, log)
-
-	// Initialize rate limiter
-	rateLimiter := middleware.NewTenantRateLimiter(rateLimitPerTenant, rateLimitBurst)
-
-	// Setup Gin router
-	gin.SetMode(gin.ReleaseMode)
-	router := gin.New()
-	router.Use(gin.Recovery())
-	router.Use(gin.Logger())
-
-	// Health check endpoints
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "healthy"})
-	})
-	router.GET("/ready", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "ready"})
-	})
-
-	// Metrics endpoint
-	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
-
-	// API routes with tenancy and rate limiting
-	v1 := router.Group("/api/v1")
-	v1.Use(middleware.TenancyMiddleware())
-	v1.Use(middleware.RateLimitMiddleware(rateLimiter))
-	{
-		// Notifications
-		notifications := v1.Group("/notifications")
-		{
-			notifications.POST("/email", notificationHandler.SendEmail)
-			notifications.POST("/webhook", notificationHandler.SendWebhook)
-			notifications.POST("/sms", smsHandler.SendSMS)
-			notifications.GET("", notificationHandler.GetNotifications)
-			notifications.GET("/:id", notificationHandler.GetNotification)
-		}
-
-		// Bulk operations
-		bulk := v1.Group("/notifications/bulk")
-		{
-			bulk.POST("/email", bulkHandler.SendBulkEmail)
-		}
-
-		// Preferences
-		preferences := v1.Group("/preferences")
-		{
-			preferences.GET("/:user_id", preferencesHandler.GetPreferences)
-			preferences.PUT("/:user_id", preferencesHandler.UpdatePreferences)
-		}
-
-		// Scheduled notifications
-		scheduled := v1.Group("/scheduled")
-		{
-			scheduled.GET("", scheduleHandler.GetSchedules)
-			scheduled.POST("", scheduleHandler.CreateSchedule)
-			scheduled.PUT("/:id", scheduleHandler.UpdateSchedule)
-			scheduled.DELETE("/:id", scheduleHandler.DeleteSchedule)
-		}
-
-		// Dead Letter Queue
-		dlqRoutes := v1.Group("/dlq")
-		{
-			dlqRoutes.GET("", dlqHandler.GetFailedNotifications)
-			dlqRoutes.POST("/:id/retry", dlqHandler.RetryNotification)
-		}
-	}
-
-	// Webhooks (no rate limiting for external providers)
-	webhooks := router.Group("/webhooks")
-	{
-		webhooks.POST("/ses", bounceHandler.HandleSESWebhook)
-		webhooks.POST("/sendgrid", bounceHandler.HandleSendGridWebhook)
-	}
-
-	// Start RabbitMQ consumer
-	eventConsumer := consumer.NewEventConsumer(rabbitMQClient, notificationService, log)
-	go func() {
-		if err := eventConsumer.Start(); err != nil {
-			log.Error("Failed to start event consumer", "error", err)
-		}
-	}()
-
-	// Start HTTP server
-	port := os.Getenv("NOTIFICATION_SERVICE_PORT")
-	if port == "" {
-		port = "8084"
-	}
-
-	srv := &http.Server{
-		Addr:    fmt.Sprintf(":%s", port),
-		Handler: router,
-	}
-
-	// Start server in goroutine
-	go func() {
-		log.Info("Notification Service started", "port", port)
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatal("Failed to start server", "error", err)
-		}
-	}()
-
-	// Graceful shutdown
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-
-	log.Info("Shutting down Notification Service...")
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	if err := srv.Shutdown(ctx); err != nil {
-		log.Error("Server forced to shutdown", "error", err)
-	}
-
-	log.Info("Notification Service stopped")
-}
-
-// getEnv retrieves environment variable or returns default value
-func getEnv(key, defaultValue string) string {
-	value := os.Getenv(key)
-	if value == "" {
-		return defaultValue
-	}
-	return value
-}
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/vhvplatform/go-notification-service/internal/ack"
+	"github.com/vhvplatform/go-notification-service/internal/apitoken"
+	"github.com/vhvplatform/go-notification-service/internal/attachments"
+	"github.com/vhvplatform/go-notification-service/internal/audit"
+	"github.com/vhvplatform/go-notification-service/internal/cache"
+	"github.com/vhvplatform/go-notification-service/internal/consumer"
+	"github.com/vhvplatform/go-notification-service/internal/contentcheck"
+	"github.com/vhvplatform/go-notification-service/internal/dedup"
+	"github.com/vhvplatform/go-notification-service/internal/digest"
+	"github.com/vhvplatform/go-notification-service/internal/dlq"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/email"
+	"github.com/vhvplatform/go-notification-service/internal/escalation"
+	"github.com/vhvplatform/go-notification-service/internal/experiment"
+	"github.com/vhvplatform/go-notification-service/internal/footer"
+	"github.com/vhvplatform/go-notification-service/internal/handler"
+	"github.com/vhvplatform/go-notification-service/internal/health"
+	"github.com/vhvplatform/go-notification-service/internal/importer"
+	"github.com/vhvplatform/go-notification-service/internal/inbound"
+	"github.com/vhvplatform/go-notification-service/internal/maintenance"
+	"github.com/vhvplatform/go-notification-service/internal/metrics"
+	"github.com/vhvplatform/go-notification-service/internal/middleware"
+	"github.com/vhvplatform/go-notification-service/internal/mjml"
+	"github.com/vhvplatform/go-notification-service/internal/mxcheck"
+	"github.com/vhvplatform/go-notification-service/internal/orchestration"
+	"github.com/vhvplatform/go-notification-service/internal/outbox"
+	"github.com/vhvplatform/go-notification-service/internal/pause"
+	"github.com/vhvplatform/go-notification-service/internal/queue"
+	"github.com/vhvplatform/go-notification-service/internal/quota"
+	"github.com/vhvplatform/go-notification-service/internal/recipients"
+	"github.com/vhvplatform/go-notification-service/internal/render"
+	"github.com/vhvplatform/go-notification-service/internal/repository"
+	"github.com/vhvplatform/go-notification-service/internal/reputation"
+	"github.com/vhvplatform/go-notification-service/internal/retry"
+	"github.com/vhvplatform/go-notification-service/internal/scheduler"
+	"github.com/vhvplatform/go-notification-service/internal/selftest"
+	"github.com/vhvplatform/go-notification-service/internal/sender"
+	"github.com/vhvplatform/go-notification-service/internal/sendtime"
+	"github.com/vhvplatform/go-notification-service/internal/service"
+	"github.com/vhvplatform/go-notification-service/internal/shared/config"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+	"github.com/vhvplatform/go-notification-service/internal/shared/mongodb"
+	"github.com/vhvplatform/go-notification-service/internal/shared/rabbitmq"
+	"github.com/vhvplatform/go-notification-service/internal/sizelimit"
+	"github.com/vhvplatform/go-notification-service/internal/sms"
+	"github.com/vhvplatform/go-notification-service/internal/tenancy"
+	"github.com/vhvplatform/go-notification-service/internal/throttle"
+	"github.com/vhvplatform/go-notification-service/internal/webhook"
+	"github.com/vhvplatform/go-notification-service/internal/webview"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+func main() {
+	// Initialize logger
+	log := logger.NewLogger()
+	defer log.Sync()
+
+	log.Info("Starting Notification Service...")
+
+	// Load configuration
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatal("Failed to load configuration", "error", err)
+	}
+
+	// Configure PII redaction for log fields
+	redactionMode, err := logger.ParseRedactionMode(cfg.Logging.PIIRedaction)
+	if err != nil {
+		log.Fatal("Invalid logging configuration", "error", err)
+	}
+	log.SetRedactionMode(redactionMode)
+
+	// Configure how tenant IDs are exposed in metric labels
+	tenantLabelMode, err := metrics.ParseTenantLabelMode(cfg.Metrics.TenantLabelMode)
+	if err != nil {
+		log.Fatal("Invalid metrics configuration", "error", err)
+	}
+	metrics.SetTenantLabelMode(tenantLabelMode)
+	metrics.SetTenantLabelAllowlist(cfg.Metrics.TenantAllowlist)
+
+	// Root context for background workers, cancelled on SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Track dependency error rates; send endpoints return 503 while a dependency is degraded
+	var degradation *health.DegradationController
+	var mongoMonitor *event.CommandMonitor
+	var mongoServerMonitor *event.ServerMonitor
+	if cfg.Degraded.Enabled {
+		degradation = health.NewDegradationController(health.DegradationConfig{
+			Window:      cfg.Degraded.Window,
+			Threshold:   cfg.Degraded.Threshold,
+			MinRequests: cfg.Degraded.MinRequests,
+			Cooldown:    cfg.Degraded.Cooldown,
+		})
+		mongoMonitor = degradation.MongoMonitor()
+		mongoServerMonitor = degradation.MongoServerMonitor()
+	}
+
+	// Initialize MongoDB
+	mongoClient, err := mongodb.NewMongoClient(&mongodb.Config{
+		URI:                    cfg.MongoDB.URI,
+		Database:               cfg.MongoDB.Database,
+		MaxPoolSize:            uint64(cfg.MongoDB.MaxPoolSize),
+		MinPoolSize:            uint64(cfg.MongoDB.MinPoolSize),
+		MaxConnIdleTime:        cfg.MongoDB.MaxConnIdleTime,
+		ConnectTimeout:         cfg.MongoDB.ConnectTimeout,
+		ServerSelectionTimeout: cfg.MongoDB.ServerSelectionTimeout,
+		ReadConcern:            cfg.MongoDB.ReadConcern,
+		WriteConcern:           cfg.MongoDB.WriteConcern,
+		CriticalReadConcern:    cfg.MongoDB.CriticalReadConcern,
+		CriticalWriteConcern:   cfg.MongoDB.CriticalWriteConcern,
+		Monitor:                mongoMonitor,
+		ServerMonitor:          mongoServerMonitor,
+	})
+	if err != nil {
+		log.Fatal("Failed to connect to MongoDB", "error", err)
+	}
+	defer mongoClient.Disconnect(context.Background())
+	if !mongoClient.SupportsTransactions() {
+		log.Warn("MongoDB is not a replica set; notification writes with outbox events will fail", "error", mongodb.ErrTransactionsNotSupported)
+	}
+
+	// Initialize RabbitMQ
+	rabbitMQClient, err := rabbitmq.NewRabbitMQClient(cfg.RabbitMQ.URL)
+	if err != nil {
+		log.Fatal("Failed to connect to RabbitMQ", "error", err)
+	}
+	defer rabbitMQClient.Close()
+
+	// Initialize repositories
+	outboxEventRepo := repository.NewOutboxEventRepository(mongoClient)
+	outboxEventRepo.SetPayloadOptions(domain.OutboxPayloadOptions{
+		Mode:     domain.OutboxPayloadMode(cfg.Outbox.PayloadMode),
+		MaxBytes: cfg.Outbox.PayloadMaxBytes,
+		Compress: cfg.Outbox.PayloadCompress,
+	})
+	notificationRepo := repository.NewNotificationRepository(mongoClient, outboxEventRepo)
+	notificationRepo.SetIdempotencyTTL(cfg.Idempotency.TTL)
+	notificationEventRepo := repository.NewNotificationEventRepository(mongoClient)
+	notificationRepo.SetEventRepository(notificationEventRepo)
+	templateRepo := repository.NewTemplateRepository(mongoClient)
+	failedNotificationRepo := repository.NewFailedNotificationRepository(mongoClient)
+	scheduledNotificationRepo := repository.NewScheduledNotificationRepository(mongoClient)
+	scheduleExecutionRepo := repository.NewScheduleExecutionRepository(mongoClient, outboxEventRepo)
+	preferencesRepo := repository.NewPreferencesRepository(mongoClient)
+	preferenceCategoryRepo := repository.NewPreferenceCategoryRepository(mongoClient)
+	quotaRepo := repository.NewQuotaRepository(mongoClient)
+	bounceRepo := repository.NewBounceRepository(mongoClient)
+	attachmentPolicyRepo := repository.NewAttachmentPolicyRepository(mongoClient)
+	sendPauseRepo := repository.NewSendPauseRepository(mongoClient)
+	auditLogRepo := repository.NewAuditLogRepository(mongoClient)
+	retryPolicyRepo := repository.NewRetryPolicyRepository(mongoClient)
+	recipientListRepo := repository.NewRecipientListRepository(mongoClient)
+	webhookAllowlistRepo := repository.NewWebhookAllowlistRepository(mongoClient)
+	webhookFallbackRepo := repository.NewWebhookFallbackRepository(mongoClient)
+	emailFooterRepo := repository.NewEmailFooterRepository(mongoClient)
+	sendDedupRepo := repository.NewSendDedupRepository(mongoClient)
+	recipientThrottleRepo := repository.NewRecipientThrottleRepository(mongoClient)
+	digestRepo := repository.NewDigestRepository(mongoClient)
+	orchestrationRepo := repository.NewOrchestrationRepository(mongoClient)
+	escalationPolicyRepo := repository.NewEscalationPolicyRepository(mongoClient)
+	escalationRecordRepo := repository.NewEscalationRecordRepository(mongoClient)
+	reputationRepo := repository.NewReputationRepository(mongoClient)
+	webhookRetryRepo := repository.NewWebhookRetryRepository(mongoClient)
+
+	// Templates may carry MJML source, compiled to their HTML body when saved
+	var mjmlCompiler mjml.Compiler
+	if cfg.MJML.Enabled {
+		mjmlCompiler = mjml.NewCompiler(mjml.Config{
+			Path:      cfg.MJML.Path,
+			Timeout:   cfg.MJML.Timeout,
+			CacheSize: cfg.MJML.CacheSize,
+		})
+		templateRepo.SetMJMLCompiler(mjmlCompiler)
+	}
+
+	// Queries rely on these indexes, and creating preferences detects existing ones through the
+	// unique tenant and user index. A failure is logged rather than fatal, since queries still work.
+	if err := repository.EnsureIndexes(ctx,
+		outboxEventRepo, notificationRepo, notificationEventRepo, templateRepo, failedNotificationRepo,
+		scheduledNotificationRepo, scheduleExecutionRepo, preferencesRepo, preferenceCategoryRepo,
+		quotaRepo, bounceRepo, attachmentPolicyRepo, sendPauseRepo, auditLogRepo, retryPolicyRepo,
+		recipientListRepo, webhookAllowlistRepo, webhookFallbackRepo, sendDedupRepo, digestRepo,
+		orchestrationRepo, escalationPolicyRepo, escalationRecordRepo, recipientThrottleRepo,
+		emailFooterRepo, webhookRetryRepo,
+	); err != nil {
+		log.Warn("Failed to create some MongoDB indexes", "error", err)
+	}
+
+	// Preference, suppression and tenant config lookups on the send path are cached in Redis when
+	// it is configured; lookups fall back to MongoDB while Redis is unavailable
+	if cfg.Cache.RedisAddr != "" {
+		redisStore := cache.NewRedisStore(cache.RedisConfig{
+			Addr:     cfg.Cache.RedisAddr,
+			Password: cfg.Cache.RedisPassword,
+			DB:       cfg.Cache.RedisDB,
+			Timeout:  cfg.Cache.Timeout,
+			PoolSize: cfg.Cache.PoolSize,
+		})
+		defer redisStore.Close()
+		if err := redisStore.Ping(ctx); err != nil {
+			log.Warn("Redis cache unavailable at startup, falling back to MongoDB until it recovers", "error", err)
+		}
+		lookups := cache.New(redisStore, cache.Config{TTL: cfg.Cache.TTL}, log)
+		preferencesRepo.SetCache(lookups)
+		bounceRepo.SetCache(lookups)
+		attachmentPolicyRepo.SetCache(lookups)
+		retryPolicyRepo.SetCache(lookups)
+		webhookAllowlistRepo.SetCache(lookups)
+		webhookFallbackRepo.SetCache(lookups)
+		emailFooterRepo.SetCache(lookups)
+	}
+
+	// Initialize services
+	// Email providers are looked up by name in the email registry; tenants can be assigned their own
+	emailSenders, err := email.NewRouter(email.Config{
+		Provider:        cfg.Email.Provider,
+		TenantProviders: cfg.Email.TenantProviders,
+		Timeout:         cfg.Email.Timeout,
+		SMTP: email.SMTPConfig{
+			Host:        cfg.SMTP.Host,
+			Port:        cfg.SMTP.Port,
+			Username:    cfg.SMTP.Username,
+			Password:    cfg.SMTP.Password,
+			PoolSize:    cfg.SMTP.PoolSize,
+			MaxMessages: cfg.SMTP.MaxMessages,
+		},
+		SendGrid: email.SendGridConfig{
+			APIKey:  cfg.Email.SendGridAPIKey,
+			BaseURL: cfg.Email.SendGridBaseURL,
+		},
+	})
+	if err != nil {
+		log.Fatal("Invalid email provider configuration", "error", err)
+	}
+	defer emailSenders.Close()
+	if degradation != nil {
+		emailSenders.SetHealth(degradation)
+	}
+	// Partially delivered emails record each recipient's outcome on their notification
+	emailSenders.SetRecipients(notificationRepo)
+	// Every email the router sends gets its tenant's footer, unless the request skips it
+	emailSenders.Use(footer.NewResolver(emailFooterRepo))
+
+	// View in browser links are only issued and served when a signing secret is configured
+	var viewSigner *webview.Signer
+	if cfg.View.Secret != "" {
+		viewSigner = webview.NewSigner(cfg.View.Secret, cfg.View.LinkTTL, cfg.View.BaseURL)
+		emailSenders.Use(webview.NewLinkComposer(viewSigner))
+	}
+
+	emailConfig := service.EmailConfig{
+		FromEmail: cfg.SMTP.FromEmail,
+		FromName:  cfg.SMTP.FromName,
+	}
+	emailService := service.NewEmailService(emailConfig, emailSenders, notificationRepo, templateRepo, log)
+
+	// SMS providers are looked up by name in the sms registry, so the service only sees the Sender interface
+	smsSender, err := sms.New(sms.Config{
+		Provider: cfg.SMS.Provider,
+		Timeout:  cfg.SMS.Timeout,
+		Twilio: sms.TwilioConfig{
+			AccountSID: cfg.SMS.TwilioSID,
+			AuthToken:  cfg.SMS.TwilioToken,
+			From:       cfg.SMS.TwilioFrom,
+		},
+		SNS: sms.SNSConfig{
+			Region:          cfg.SMS.AWSRegion,
+			TopicARN:        cfg.SMS.AWSSNSARN,
+			AccessKeyID:     cfg.SMS.AWSAccessKeyID,
+			SecretAccessKey: cfg.SMS.AWSSecretAccessKey,
+			SessionToken:    cfg.SMS.AWSSessionToken,
+		},
+	})
+	if err != nil {
+		log.Fatal("Invalid SMS provider configuration", "error", err)
+	}
+	smsService := service.NewSMSService(smsSender, notificationRepo, log)
+
+	webhookService := service.NewWebhookService(notificationRepo, log)
+	notificationService := service.NewNotificationService(notificationRepo, emailService, webhookService, smsService, log)
+
+	// Send retries follow the channel default from config, overridden per tenant in MongoDB
+	retryPolicy := func(c config.RetryPolicyConfig) retry.Policy {
+		return retry.Policy{
+			MaxAttempts: c.MaxAttempts,
+			BaseDelay:   c.BaseDelay,
+			Multiplier:  c.Multiplier,
+			MaxDelay:    c.MaxDelay,
+			Jitter:      c.Jitter,
+		}
+	}
+	retryPolicies := retry.NewResolver(retryPolicyRepo, map[domain.NotificationType]retry.Policy{
+		domain.NotificationTypeEmail:   retryPolicy(cfg.Retry.Email),
+		domain.NotificationTypeSMS:     retryPolicy(cfg.Retry.SMS),
+		domain.NotificationTypeWebhook: retryPolicy(cfg.Retry.Webhook),
+	})
+	// Urgent single emails are sent through the priority queue, so they are taken ahead of
+	// queued bulk email; each retry attempt is queued again
+	emailQueue := queue.NewPriorityQueue()
+	emailQueue.SetAging(cfg.BulkEmail.PriorityAging)
+	emailQueue.SetMaxDepth(cfg.BulkEmail.MaxQueueDepth)
+	// Templated emails are rendered last, so A/B variants and every retry attempt render with
+	// the request's locale and variables
+	templateSender := render.NewSender(templateRepo, notificationService)
+	var deliverySender sender.Sender = templateSender
+	if cfg.BulkEmail.FastLaneWorkers > 0 {
+		fastLanePriority, _ := domain.ParseNotificationPriority(cfg.BulkEmail.FastLanePriority) // Validated by LoadConfig
+		prioritySender := queue.NewPrioritySender(emailQueue, queue.PriorityFor(fastLanePriority), templateSender, log)
+		prioritySender.SetQueueWait(cfg.BulkEmail.QueueWait)
+		prioritySender.Start(cfg.BulkEmail.FastLaneWorkers)
+		deliverySender = prioritySender
+	}
+	retryingSender := retry.NewSender(retryPolicies, deliverySender, log)
+	if cfg.Retry.WebhookQueue {
+		// Failed webhooks wait for their next attempt in MongoDB, so requests don't block on
+		// backoff and retries survive restarts
+		retryingSender.SetWebhookStore(webhookRetryRepo)
+	}
+
+	// Webhooks that still fail after their retries notify the request's or tenant's fallback channel
+	fallbackSender := webhook.NewFallbackSender(webhookFallbackRepo, webhook.FallbackConfig{
+		MaxPerHour: cfg.Fallback.MaxPerHour,
+	}, retryingSender, log)
+
+	// Each recipient receives at most the configured number of emails and SMS per window
+	throttleSender := throttle.NewSender(recipientThrottleRepo, throttle.Config{
+		Limits: map[domain.NotificationType]int{
+			domain.NotificationTypeEmail: cfg.Throttle.EmailLimit,
+			domain.NotificationTypeSMS:   cfg.Throttle.SMSLimit,
+		},
+		Window:        cfg.Throttle.Window,
+		AllowCritical: cfg.Throttle.AllowCritical,
+	}, fallbackSender, log)
+
+	// Tenants that opt in have identical sends to the same recipients suppressed within their window.
+	// Suppressed duplicates don't count against recipients' rate limits.
+	dedupSender := dedup.NewSender(sendDedupRepo, dedup.Config{
+		DefaultWindow: cfg.Dedup.Window,
+		TenantWindows: cfg.Dedup.TenantWindows,
+	}, throttleSender, log)
+
+	// Recipient lists are expanded at send time, so held and scheduled sends see current members
+	recipientExpander := recipients.NewExpander(recipientListRepo, bounceRepo, preferencesRepo, recipients.Config{
+		MaxRecipients:     cfg.Recipients.MaxRecipients,
+		SuppressionWindow: cfg.Recipients.SuppressionWindow,
+	}, log)
+	// Emails with send_at_local are scheduled for each recipient's local time of day, after list
+	// expansion so members' timezones are known
+	sendTimeLocation, _ := time.LoadLocation(cfg.SendTime.DefaultTimezone) // Validated by LoadConfig
+	sendTimePlanner := sendtime.NewPlanner(preferencesRepo, sendTimeLocation)
+	localTimeSender := sendtime.NewSender(sendTimePlanner, dedupSender, log)
+	// A/B tested emails are split between their template variants after list expansion, so
+	// list members are assigned variants too
+	variantSender := experiment.NewSender(localTimeSender, log)
+	expandingSender := recipients.NewSender(recipientExpander, variantSender)
+
+	// Webhooks are only sent to destinations on the tenant's allowlist, when it has one
+	webhookAllowlist := webhook.NewAllowlist(webhookAllowlistRepo)
+	allowlistSender := webhook.NewAllowlistSender(webhookAllowlist, expandingSender)
+
+	// Emails marked for a digest are collected per recipient and sent combined by the dispatcher
+	digestConfig := digest.Config{
+		MaxItems:     cfg.Digest.MaxItems,
+		PollInterval: cfg.Digest.PollInterval,
+	}
+	digestSender := digest.NewSender(digestRepo, templateRepo, digestConfig, allowlistSender, log)
+
+	// Email of tenants with a high bounce or complaint rate is paused until the rate recovers
+	reputationMonitor := reputation.NewMonitor(reputationRepo, bounceRepo, notificationRepo, reputation.Config{
+		Window:             cfg.Reputation.Window,
+		Interval:           cfg.Reputation.Interval,
+		BounceThreshold:    cfg.Reputation.BounceThreshold,
+		ComplaintThreshold: cfg.Reputation.ComplaintThreshold,
+		MinVolume:          cfg.Reputation.MinVolume,
+		AllowCritical:      cfg.Reputation.AllowCritical,
+	}, log)
+	var reputationSender sender.Sender = digestSender
+	if cfg.Reputation.Enabled {
+		reputationSender = reputation.NewSender(reputationMonitor, digestSender)
+		reputationMonitor.Start(ctx)
+	}
+
+	// Global and per-channel send pause; paused sends are held and sent on resume
+	initialPause := domain.SendPause{All: cfg.Pause.All, Reason: "paused by configuration", UpdatedBy: "config"}
+	for _, channel := range cfg.Pause.Channels {
+		initialPause.Channels = append(initialPause.Channels, domain.NotificationType(channel))
+	}
+	pauseController := pause.NewController(sendPauseRepo, pause.Config{
+		AllowCritical:   cfg.Pause.AllowCritical,
+		RefreshInterval: cfg.Pause.RefreshInterval,
+	}, initialPause, log)
+	// Oversized content is rejected, never truncated, before it is held, digested or recorded
+	sizeLimits := sizelimit.NewChecker(sizelimit.Limits{
+		EmailBodyBytes:      cfg.SizeLimits.EmailBodyBytes,
+		SMSMessageLength:    cfg.SizeLimits.SMSMessageLength,
+		WebhookPayloadBytes: cfg.SizeLimits.WebhookPayloadBytes,
+	})
+	// Every send resolves its tenant once, so a request can't act for a tenant other than its caller's
+	tenantSender := tenancy.NewSender(sizelimit.NewSender(sizeLimits, reputationSender))
+	sendGate := pause.NewGate(pauseController, sendPauseRepo, tenantSender, log)
+	// Fallback notifications take the full send path, so pauses and deduplication apply to them
+	fallbackSender.SetAlertSender(sendGate)
+	if err := pauseController.Start(ctx); err != nil {
+		log.Error("Failed to load send pause state", "error", err)
+	}
+
+	// Initialize Dead Letter Queue
+	deadLetterQueue := dlq.NewDeadLetterQueueWithPolicies(failedNotificationRepo, log, retryPolicies)
+
+	// Initialize Bounce Checker (can be integrated into email service if needed)
+	_ = service.NewBounceChecker(bounceRepo)
+
+	// Initialize Bulk Email Service
+	bulkEmailService := service.NewBulkEmailService(emailService, cfg.BulkEmail.Workers, log)
+	bulkPriority, _ := domain.ParseNotificationPriority(cfg.BulkEmail.DefaultPriority) // Validated by LoadConfig
+	bulkEmailService.Start()
+
+	// Initialize Outbox Monitor
+	outbox.NewMonitor(outboxEventRepo, cfg.Outbox.MonitorInterval, log).Start(ctx)
+
+	// Publish outbox events from the service when Debezium doesn't; one instance should run it
+	if cfg.Outbox.RelayEnabled {
+		if err := rabbitMQClient.DeclareExchange(cfg.Outbox.RelayExchange, "topic"); err != nil {
+			log.Fatal("Failed to declare outbox relay exchange", "error", err)
+		}
+		outbox.NewRelay(outboxEventRepo, outbox.NewExchangePublisher(rabbitMQClient, cfg.Outbox.RelayExchange), outbox.RelayConfig{
+			Workers:     cfg.Outbox.RelayWorkers,
+			BatchSize:   cfg.Outbox.RelayBatchSize,
+			Interval:    cfg.Outbox.RelayInterval,
+			MaxAttempts: cfg.Outbox.RelayMaxAttempts,
+		}, log).Start(ctx)
+	}
+
+	// Queued webhook retries are held while webhooks are paused, and have their tenant and
+	// allowlist checked again since either may change while they wait; webhooks that fail
+	// their last attempt notify their fallback channel
+	if cfg.Retry.WebhookQueue {
+		retrySender := tenancy.NewSender(webhook.NewAllowlistSender(webhookAllowlist, deliverySender))
+		webhookRetryDispatcher := retry.NewWebhookDispatcher(webhookRetryRepo, retryPolicies, retrySender, retry.WebhookDispatcherConfig{
+			PollInterval: cfg.Retry.WebhookQueueInterval,
+			Rate:         cfg.Retry.WebhookQueueRate,
+		}, log)
+		webhookRetryDispatcher.SetPauses(pauseController)
+		webhookRetryDispatcher.SetExhaustedHandler(fallbackSender.Notify)
+		webhookRetryDispatcher.Start(ctx)
+	}
+
+	// Initialize Digest Dispatcher; digests go through the send gate so pauses apply to them
+	digest.NewDispatcher(digestRepo, templateRepo, preferencesRepo, sendGate, digestConfig, log).Start(ctx)
+
+	// Initialize Scheduler
+	notificationScheduler := scheduler.NewNotificationScheduler(sendGate, scheduledNotificationRepo, scheduleExecutionRepo, notificationRepo, scheduler.Syntax{
+		Seconds:     cfg.Scheduler.CronSeconds,
+		Descriptors: cfg.Scheduler.CronDescriptors,
+	}, log)
+	notificationScheduler.SetMaxConcurrent(cfg.Scheduler.MaxConcurrent)
+	if err := notificationScheduler.Start(ctx); err != nil {
+		log.Error("Failed to start scheduler", "error", err)
+	}
+
+	// Initialize HTTP handlers
+	// Per-tenant daily/monthly send quotas; nil disables quota checks
+	var quotaEnforcer *quota.Enforcer
+	if cfg.Quota.Enabled {
+		quotaEnforcer = quota.NewEnforcer(quotaRepo, map[domain.NotificationType]quota.Limits{
+			domain.NotificationTypeEmail:   {Daily: cfg.Quota.Email.Daily, Monthly: cfg.Quota.Email.Monthly},
+			domain.NotificationTypeSMS:     {Daily: cfg.Quota.SMS.Daily, Monthly: cfg.Quota.SMS.Monthly},
+			domain.NotificationTypeWebhook: {Daily: cfg.Quota.Webhook.Daily, Monthly: cfg.Quota.Webhook.Monthly},
+		}, domain.QuotaPolicy(cfg.Quota.Policy))
+	}
+
+	// Multi-channel notifications; escalations go through the send gate so pauses apply to them
+	orchestrator := orchestration.NewOrchestrator(orchestrationRepo, sendGate, notificationRepo, quotaEnforcer, orchestration.Config{
+		PollInterval: cfg.Escalation.PollInterval,
+	}, log)
+	orchestrator.Start(ctx)
+
+	// Acknowledgment links are only issued and accepted when a signing secret is configured
+	var ackSigner *ack.Signer
+	var ackLinks escalation.Linker
+	if cfg.Ack.Secret != "" {
+		ackSigner = ack.NewSigner(cfg.Ack.Secret, cfg.Ack.LinkTTL, cfg.Ack.BaseURL)
+		ackLinks = ackSigner
+	}
+
+	// API requests are authenticated with tenant-bound tokens once a signing secret is configured
+	var apiTokens *apitoken.Signer
+	if len(cfg.Auth.TokenSecrets) > 0 {
+		apiTokens = apitoken.NewSigner(cfg.Auth.TokenSecrets...)
+	} else {
+		log.Warn("API authentication is disabled; set API_TOKEN_SECRETS to require tenant-bound tokens")
+	}
+
+	// Unacknowledged critical notifications are escalated along their tenant's policy
+	escalationChecker := escalation.NewChecker(escalationPolicyRepo, notificationRepo, escalationRecordRepo, sendGate, ackLinks, escalation.Config{
+		PollInterval: cfg.Escalation.PollInterval,
+	}, log)
+	escalationChecker.Start(ctx)
+
+	// Default attachment policy from config; tenants may override it in MongoDB
+	attachmentValidator := attachments.NewValidator(attachmentPolicyRepo, attachments.Policy{
+		AllowedMIMETypes:  cfg.Attachments.AllowedMIMETypes,
+		DeniedMIMETypes:   cfg.Attachments.DeniedMIMETypes,
+		AllowedExtensions: cfg.Attachments.AllowedExtensions,
+		DeniedExtensions:  cfg.Attachments.DeniedExtensions,
+	})
+
+	// Recipient domain MX checks run when a request or tenant opts in
+	domainVerifier := mxcheck.NewVerifier(net.DefaultResolver, mxcheck.Config{
+		Timeout:     cfg.MXCheck.Timeout,
+		PositiveTTL: cfg.MXCheck.PositiveTTL,
+		NegativeTTL: cfg.MXCheck.NegativeTTL,
+		Tenants:     cfg.MXCheck.Tenants,
+	})
+
+	// Content spam checks warn on every email and reject only enforced tenants' marketing emails
+	contentChecker := contentcheck.NewChecker(contentcheck.Config{
+		Threshold:           cfg.Content.Threshold,
+		MarketingCategories: cfg.Content.MarketingCategories,
+		EnforcedTenants:     cfg.Content.EnforcedTenants,
+	}, log)
+
+	notificationHandler := handler.NewNotificationHandler(notificationService, sendGate, notificationEventRepo, quotaEnforcer, attachmentValidator, domainVerifier, contentChecker, webhookAllowlist, log)
+	smsHandler := handler.NewSMSHandler(sendGate, log)
+	bulkHandler := handler.NewBulkHandler(bulkEmailService, sendTimePlanner, quotaEnforcer, bulkPriority, log)
+	batchHandler := handler.NewBatchHandler(sendGate, notificationRepo, quotaEnforcer, attachmentValidator, domainVerifier, contentChecker, webhookAllowlist, log)
+	orchestrationHandler := handler.NewOrchestrationHandler(orchestrator, orchestrationRepo, attachmentValidator, domainVerifier, contentChecker, webhookAllowlist, log)
+	quotaHandler := handler.NewQuotaHandler(quotaEnforcer, log)
+	preferencesHandler := handler.NewPreferencesHandler(preferencesRepo, preferenceCategoryRepo, log)
+	scheduleHandler := handler.NewScheduleHandler(scheduledNotificationRepo, scheduleExecutionRepo, notificationScheduler, log)
+	dlqHandler := handler.NewDLQHandler(deadLetterQueue, sendGate, log)
+	pauseHandler := handler.NewPauseHandler(pauseController, sendPauseRepo, log)
+	auditHandler := handler.NewAuditHandler(auditLogRepo, log)
+	retryPolicyHandler := handler.NewRetryPolicyHandler(retryPolicies, log)
+	recipientListHandler := handler.NewRecipientListHandler(recipientListRepo, log)
+	webhookAllowlistHandler := handler.NewWebhookAllowlistHandler(webhookAllowlistRepo, log)
+	webhookFallbackHandler := handler.NewWebhookFallbackHandler(webhookFallbackRepo, log)
+	emailFooterHandler := handler.NewEmailFooterHandler(emailFooterRepo, log)
+	experimentHandler := handler.NewExperimentHandler(notificationRepo, log)
+	ackHandler := handler.NewAckHandler(notificationRepo, ackSigner, log)
+	externalHandler := handler.NewExternalHandler(notificationRepo, log)
+	viewHandler := handler.NewViewHandler(notificationRepo, viewSigner, log)
+	templateImporter := importer.NewImporter(templateRepo, preferencesRepo)
+	templateImporter.SetMJMLCompiler(mjmlCompiler)
+	importHandler := handler.NewImportHandler(templateImporter, log)
+	statusHandler := handler.NewStatusHandler(notificationRepo, log)
+	smtpPoolHandler := handler.NewSMTPPoolHandler(emailSenders, log)
+	apiTokenHandler := handler.NewAPITokenHandler(apiTokens, cfg.Auth.MaxTokenTTL, log)
+	selfTestHandler := handler.NewSelfTestHandler(selftest.NewRunner(selftest.Config{
+		To:        cfg.Admin.SelfTestTo,
+		TenantID:  cfg.Admin.SelfTestTenant,
+		FromEmail: cfg.SMTP.FromEmail,
+		FromName:  cfg.SMTP.FromName,
+	}, emailSenders, notificationRepo), log)
+
+	// Inbound replies are only accepted from providers whose webhooks can be verified
+	var sendGridInbound *inbound.SendGridVerifier
+	if cfg.Inbound.SendGridPublicKey != "" {
+		sendGridInbound, err = inbound.NewSendGridVerifier(cfg.Inbound.SendGridPublicKey)
+		if err != nil {
+			log.Fatal("Invalid SendGrid inbound public key", "error", err)
+		}
+	}
+	var sesInbound *inbound.SNSVerifier
+	if len(cfg.Inbound.SESTopicARNs) > 0 {
+		sesInbound = inbound.NewSNSVerifier(&http.Client{Timeout: 10 * time.Second}, cfg.Inbound.SESTopicARNs)
+	}
+	inboundHandler := handler.NewInboundHandler(notificationRepo, sendGridInbound, sesInbound, log)
+	escalationPolicyHandler := handler.NewEscalationPolicyHandler(escalationPolicyRepo, escalationRecordRepo, log)
+	deletionHandler := handler.NewDeletionHandler(notificationRepo, failedNotificationRepo, recipientListRepo, notificationScheduler, sendPauseRepo, digestRepo, log)
+	dataExportHandler := handler.NewDataExportHandler(notificationRepo, notificationEventRepo, failedNotificationRepo, bounceRepo, preferencesRepo, recipientListRepo, log)
+	bounceHandler := webhook.NewBounceHandler(bounceRepo, log)
+
+	// Initialize rate limiter
+	rateLimiter := middleware.NewTenantRateLimiter(cfg.RateLimit.PerTenant, cfg.RateLimit.Burst)
+	limitsHandler := handler.NewLimitsHandler(rateLimiter, quotaEnforcer, log)
+	reputationHandler := handler.NewReputationHandler(reputationMonitor, log)
+	replayHandler := handler.NewReplayHandler(consumer.NewReplayer(notificationService, pauseController, log), log)
+
+	// Initialize audit logging for sends and administrative actions
+	auditRecorder := audit.NewRecorder(auditLogRepo, log)
+	auditAction := func(action, resourceType, idParam string) gin.HandlerFunc {
+		return middleware.AuditMiddleware(auditRecorder, action, resourceType, idParam)
+	}
+
+	// Initialize maintenance jobs
+	maintenanceCfg := cfg.Maintenance
+	maintenanceRunner := maintenance.NewRunner(log)
+	maintenanceJobs := []struct {
+		enabled bool
+		job     maintenance.Job
+	}{
+		{maintenanceCfg.OutboxPurge.Enabled, maintenance.OutboxPurgeJob(outboxEventRepo, maintenanceCfg.OutboxPurge.Schedule, maintenanceCfg.OutboxPurge.RetentionDays)},
+		{maintenanceCfg.NotificationPurge.Enabled, maintenance.NotificationPurgeJob(notificationRepo, maintenanceCfg.NotificationPurge.Schedule, maintenanceCfg.NotificationPurge.RetentionDays)},
+		{maintenanceCfg.FailedNotificationPurge.Enabled, maintenance.FailedNotificationPurgeJob(failedNotificationRepo, maintenanceCfg.FailedNotificationPurge.Schedule, maintenanceCfg.FailedNotificationPurge.RetentionDays)},
+		{maintenanceCfg.ExpiredNotifications.Enabled, maintenance.ExpiredNotificationsJob(notificationRepo, maintenanceCfg.ExpiredNotifications.Schedule)},
+		{maintenanceCfg.RateLimiterCleanup.Enabled, maintenance.RateLimiterCleanupJob(rateLimiter, maintenanceCfg.RateLimiterCleanup.Schedule, maintenanceCfg.RateLimiterMaxIdle)},
+		{maintenanceCfg.IdempotencyKeyRelease.Enabled, maintenance.IdempotencyKeyReleaseJob(notificationRepo, maintenanceCfg.IdempotencyKeyRelease.Schedule)},
+		{maintenanceCfg.SoftDeletePurge.Enabled, maintenance.SoftDeletePurgeJob(notificationRepo, maintenanceCfg.SoftDeletePurge.Schedule, maintenance.RetentionPolicy{
+			DefaultDays: maintenanceCfg.SoftDeletePurge.RetentionDays,
+			TenantDays:  maintenanceCfg.SoftDeleteTenantDays,
+		}, maintenanceCfg.SoftDeletePurgeDryRun)},
+		{maintenanceCfg.ContentRedaction.Enabled, maintenance.ContentRedactionJob(notificationRepo, maintenanceCfg.ContentRedaction.Schedule, maintenance.ContentRetentionPolicy{
+			DefaultDays:  maintenanceCfg.ContentRedaction.RetentionDays,
+			TenantDays:   maintenanceCfg.ContentTenantDays,
+			CategoryDays: maintenanceCfg.ContentCategoryDays,
+			Drop:         maintenanceCfg.ContentRedactionMode == "drop",
+		})},
+		{maintenanceCfg.StuckNotifications.Enabled, maintenance.StuckNotificationsJob(notificationRepo, deadLetterQueue, sendGate, maintenanceCfg.StuckNotifications.Schedule, maintenance.StuckNotificationPolicy{
+			Timeout: maintenanceCfg.StuckTimeout,
+			Requeue: maintenanceCfg.StuckAction == "requeue",
+		})},
+	}
+	for _, m := range maintenanceJobs {
+		if !m.enabled {
+			continue
+		}
+		if err := maintenanceRunner.Register(m.job); err != nil {
+			log.Fatal("Invalid maintenance job schedule", "job", m.job.Name, "error", err)
+		}
+	}
+	maintenanceRunner.Start(ctx)
+
+	// Setup Gin router
+	gin.SetMode(gin.ReleaseMode)
+	handler.RegisterJSONFieldNames()
+	router := gin.New()
+	router.Use(gin.Recovery())
+	router.Use(gin.Logger())
+	router.Use(middleware.ErrorHandlerMiddleware())
+
+	// Health check endpoints
+	router.GET("/health", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "healthy"})
+	})
+	router.GET("/ready", func(c *gin.Context) {
+		// Paused sends are reported without failing readiness; requests are still accepted and held
+		sends := pauseController.Status()
+		if degradation == nil {
+			c.JSON(http.StatusOK, gin.H{"status": "ready", "sends": sends})
+			return
+		}
+
+		// Degraded dependencies are reported without failing readiness, so reads keep being served
+		dependencies := degradation.Status()
+		status := "ready"
+		for _, dependency := range dependencies {
+			if dependency.Degraded {
+				status = "degraded"
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{"status": status, "dependencies": dependencies, "sends": sends})
+	})
+
+	// Metrics endpoint (unauthenticated unless a token or basic auth is configured)
+	metricsAuth := middleware.MetricsAuthMiddleware(cfg.Metrics.AuthToken, cfg.Metrics.BasicAuthUsername, cfg.Metrics.BasicAuthPassword)
+	router.GET("/metrics", metricsAuth, gin.WrapH(promhttp.Handler()))
+
+	// API routes with body limits, tenancy, authentication and rate limiting
+	v1 := router.Group("/api/v1")
+	v1.Use(middleware.BodyLimitMiddleware(cfg.Server.MaxRequestBodyBytes, cfg.Server.MaxJSONDepth))
+	v1.Use(middleware.TenancyMiddleware())
+	if apiTokens != nil {
+		v1.Use(middleware.APIAuthMiddleware(apiTokens))
+	}
+	v1.Use(middleware.RateLimitMiddleware(rateLimiter))
+	{
+		// Send endpoints are rejected while the dependencies they need are degraded. SMTP
+		// outcomes are recorded by the SMTP provider rather than inferred from responses.
+		emailGuard := middleware.DegradedModeMiddleware(degradation, "", health.DependencySMTP, health.DependencyMongoDB)
+		sendGuard := middleware.DegradedModeMiddleware(degradation, "", health.DependencyMongoDB)
+		batchGuard := middleware.DegradedModeMiddleware(degradation, "", health.DependencySMTP, health.DependencyMongoDB)
+
+		// Notifications
+		notifications := v1.Group("/notifications")
+		{
+			notifications.POST("/email", auditAction("notification.send_email", "notification", ""), emailGuard, middleware.QuotaMiddleware(quotaEnforcer, domain.NotificationTypeEmail), notificationHandler.SendEmail)
+			notifications.POST("/email/check", notificationHandler.CheckEmail)
+			// Webhooks count against their quota in the handler, once for each URL of a fan-out
+			notifications.POST("/webhook", auditAction("notification.send_webhook", "notification", ""), sendGuard, notificationHandler.SendWebhook)
+			notifications.POST("/sms", auditAction("notification.send_sms", "notification", ""), sendGuard, middleware.QuotaMiddleware(quotaEnforcer, domain.NotificationTypeSMS), smsHandler.SendSMS)
+			notifications.POST("/batch", auditAction("notification.send_batch", "notification", ""), batchGuard, batchHandler.SendBatch)
+			notifications.POST("/orchestrate", auditAction("notification.orchestrate", "notification", ""), batchGuard, orchestrationHandler.Orchestrate)
+			notifications.POST("/erase", auditAction("notification.erase_recipient", "notification", ""), deletionHandler.EraseRecipient)
+			notifications.POST("/export", auditAction("notification.export_recipient", "notification", ""), dataExportHandler.ExportRecipient)
+			notifications.POST("/status", statusHandler.GetStatuses)
+			notifications.PUT("/external/:external_id", auditAction("notification.upsert_external", "notification", ""), externalHandler.UpsertNotification)
+			notifications.GET("", notificationHandler.GetNotifications)
+			notifications.GET("/unacknowledged", ackHandler.GetUnacknowledged)
+			notifications.GET("/:id", notificationHandler.GetNotification)
+			notifications.GET("/:id/timeline", notificationHandler.GetTimeline)
+			notifications.GET("/:id/escalations", escalationPolicyHandler.GetEscalations)
+			notifications.GET("/:id/replies", inboundHandler.GetReplies)
+			notifications.POST("/:id/acknowledge", auditAction("notification.acknowledge", "notification", "id"), ackHandler.Acknowledge)
+			if ackSigner != nil {
+				notifications.GET("/:id/ack-link", ackHandler.GetAckLink)
+			}
+			if viewSigner != nil {
+				notifications.GET("/:id/view-link", viewHandler.GetViewLink)
+			}
+			notifications.DELETE("/:id", auditAction("notification.delete", "notification", "id"), deletionHandler.DeleteNotification)
+		}
+
+		// Bulk operations
+		bulk := v1.Group("/notifications/bulk")
+		{
+			bulk.POST("/email", auditAction("notification.send_bulk_email", "notification", ""), batchGuard, middleware.SendPauseMiddleware(pauseController, domain.NotificationTypeEmail), bulkHandler.SendBulkEmail)
+		}
+
+		// Preferences
+		preferences := v1.Group("/preferences")
+		{
+			preferences.GET("/:user_id", preferencesHandler.GetPreferences)
+			preferences.POST("/:user_id", auditAction("preferences.create", "preferences", "user_id"), preferencesHandler.CreatePreferences)
+			preferences.PUT("/:user_id", auditAction("preferences.update", "preferences", "user_id"), preferencesHandler.UpdatePreferences)
+			preferences.PATCH("/:user_id", auditAction("preferences.patch", "preferences", "user_id"), preferencesHandler.PatchPreferences)
+			preferences.POST("/bulk/categories", auditAction("preferences.bulk_update_category", "preferences", ""), preferencesHandler.BulkUpdateCategory)
+			preferences.POST("/bulk/import", auditAction("preferences.import", "preferences", ""), importHandler.ImportPreferences)
+		}
+
+		// Templates
+		templates := v1.Group("/templates")
+		{
+			templates.POST("/import", auditAction("templates.import", "template", ""), importHandler.ImportTemplates)
+		}
+
+		// Tenant preference categories
+		preferenceCategories := v1.Group("/preference-categories")
+		{
+			preferenceCategories.GET("", preferencesHandler.GetCategories)
+			preferenceCategories.PUT("", auditAction("preference_categories.update", "preference_categories", ""), preferencesHandler.UpdateCategories)
+		}
+
+		// Scheduled notifications
+		scheduled := v1.Group("/scheduled")
+		{
+			scheduled.GET("", scheduleHandler.GetSchedules)
+			scheduled.POST("", auditAction("schedule.create", "schedule", ""), scheduleHandler.CreateSchedule)
+			scheduled.POST("/validate", scheduleHandler.ValidateSchedule)
+			scheduled.GET("/:id/history", scheduleHandler.GetScheduleHistory)
+			scheduled.PUT("/:id", auditAction("schedule.update", "schedule", "id"), scheduleHandler.UpdateSchedule)
+			scheduled.DELETE("/:id", auditAction("schedule.delete", "schedule", "id"), scheduleHandler.DeleteSchedule)
+		}
+
+		// Multi-channel orchestrations
+		orchestrations := v1.Group("/orchestrations")
+		{
+			orchestrations.GET("/:id", orchestrationHandler.GetOrchestration)
+			orchestrations.POST("/:id/acknowledge", auditAction("orchestration.acknowledge", "orchestration", "id"), orchestrationHandler.AcknowledgeOrchestration)
+		}
+
+		// A/B test reports
+		v1.GET("/experiments/:experiment/report", experimentHandler.GetReport)
+
+		// Quota usage
+		if quotaEnforcer != nil {
+			v1.GET("/quotas/usage", quotaHandler.GetUsage)
+		}
+
+		// Rate limit and quota state
+		v1.GET("/tenants/:id/limits", limitsHandler.GetLimits)
+
+		// Bounce and complaint rates and the automatic email pause
+		v1.GET("/reputation", reputationHandler.GetReputation)
+
+		// Recipient lists
+		recipientLists := v1.Group("/recipient-lists")
+		{
+			recipientLists.GET("", recipientListHandler.GetRecipientLists)
+			recipientLists.POST("", auditAction("recipient_list.create", "recipient_list", ""), recipientListHandler.CreateRecipientList)
+			recipientLists.GET("/:id", recipientListHandler.GetRecipientList)
+			recipientLists.PUT("/:id", auditAction("recipient_list.update", "recipient_list", "id"), recipientListHandler.UpdateRecipientList)
+			recipientLists.DELETE("/:id", auditAction("recipient_list.delete", "recipient_list", "id"), recipientListHandler.DeleteRecipientList)
+			recipientLists.POST("/:id/members", auditAction("recipient_list.add_members", "recipient_list", "id"), recipientListHandler.AddMembers)
+			recipientLists.DELETE("/:id/members", auditAction("recipient_list.remove_members", "recipient_list", "id"), recipientListHandler.RemoveMembers)
+			recipientLists.POST("/:id/subscribe", auditAction("recipient_list.subscribe", "recipient_list", "id"), recipientListHandler.Subscribe)
+			recipientLists.POST("/:id/unsubscribe", auditAction("recipient_list.unsubscribe", "recipient_list", "id"), recipientListHandler.Unsubscribe)
+		}
+
+		// Webhook destination allowlist
+		v1.GET("/webhook-allowlist", webhookAllowlistHandler.GetAllowlist)
+		v1.PUT("/webhook-allowlist", auditAction("webhook_allowlist.update", "webhook_allowlist", ""), webhookAllowlistHandler.UpdateAllowlist)
+
+		// Fallback channel for failed webhooks
+		v1.GET("/webhook-fallback", webhookFallbackHandler.GetFallback)
+		v1.PUT("/webhook-fallback", auditAction("webhook_fallback.update", "webhook_fallback", ""), webhookFallbackHandler.UpdateFallback)
+		v1.DELETE("/webhook-fallback", auditAction("webhook_fallback.delete", "webhook_fallback", ""), webhookFallbackHandler.DeleteFallback)
+
+		// Footer appended to the tenant's emails
+		v1.GET("/email-footer", emailFooterHandler.GetFooter)
+		v1.PUT("/email-footer", auditAction("email_footer.update", "email_footer", ""), emailFooterHandler.UpdateFooter)
+		v1.DELETE("/email-footer", auditAction("email_footer.delete", "email_footer", ""), emailFooterHandler.DeleteFooter)
+
+		// Escalation policy for unacknowledged critical notifications
+		v1.GET("/escalation-policy", escalationPolicyHandler.GetPolicy)
+		v1.PUT("/escalation-policy", auditAction("escalation_policy.update", "escalation_policy", ""), escalationPolicyHandler.UpdatePolicy)
+		v1.DELETE("/escalation-policy", auditAction("escalation_policy.delete", "escalation_policy", ""), escalationPolicyHandler.DeletePolicy)
+
+		// Effective retry policies
+		v1.GET("/retry-policies", retryPolicyHandler.GetRetryPolicies)
+
+		// Audit log
+		v1.GET("/audit-logs", auditHandler.GetAuditLogs)
+
+		// Dead Letter Queue
+		dlqRoutes := v1.Group("/dlq")
+		{
+			dlqRoutes.GET("", dlqHandler.GetFailedNotifications)
+			dlqRoutes.POST("/:id/retry", auditAction("dlq.retry", "failed_notification", "id"), dlqHandler.RetryNotification)
+		}
+	}
+
+	// Administrative endpoints, enabled when an admin token is configured
+	if cfg.Admin.Token != "" {
+		admin := router.Group("/admin")
+		admin.Use(middleware.AdminAuthMiddleware(cfg.Admin.Token))
+		{
+			admin.GET("/pause", pauseHandler.GetStatus)
+			admin.GET("/tenants/:id/limits", limitsHandler.GetLimits)
+			admin.POST("/tenants/:id/events/replay", auditAction("events.replay", "tenant", "id"), replayHandler.ReplayEvents)
+			admin.POST("/pause", auditAction("sends.pause", "send_pause", ""), pauseHandler.Pause)
+			admin.POST("/resume", auditAction("sends.resume", "send_pause", ""), pauseHandler.Resume)
+			admin.POST("/selftest", auditAction("selftest.run", "selftest", ""), selfTestHandler.RunSelfTest)
+			admin.GET("/smtp-pool", smtpPoolHandler.GetPool)
+			admin.GET("/reputation", reputationHandler.ListReputations)
+			admin.POST("/tenants/:id/reputation", auditAction("reputation.override", "tenant", "id"), reputationHandler.OverrideReputation)
+			if apiTokens != nil {
+				admin.POST("/tenants/:id/tokens", auditAction("api_token.issue", "api_token", ""), apiTokenHandler.IssueToken)
+			}
+			admin.PUT("/smtp-pool", auditAction("smtp_pool.resize", "smtp_pool", ""), smtpPoolHandler.ResizePool)
+		}
+	}
+
+	// Signed acknowledgment links, used by recipients without API credentials
+	if ackSigner != nil {
+		router.POST("/ack/:notificationID", middleware.BodyLimitMiddleware(cfg.Server.MaxRequestBodyBytes, cfg.Server.MaxJSONDepth), ackHandler.AcknowledgeLink)
+	}
+
+	// Signed view in browser links, opened by recipients from their email
+	if viewSigner != nil {
+		router.GET("/view/:notificationID", viewHandler.View)
+	}
+
+	// Webhooks (no rate limiting for external providers)
+	webhooks := router.Group("/webhooks")
+	{
+		webhooks.POST("/ses", bounceHandler.HandleSESWebhook)
+		webhooks.POST("/sendgrid", bounceHandler.HandleSendGridWebhook)
+
+		// Inbound emails carry attachments, so they get their own body limit
+		inboundLimit := middleware.BodyLimitMiddleware(cfg.Inbound.MaxBodyBytes, cfg.Server.MaxJSONDepth)
+		if sendGridInbound != nil {
+			webhooks.POST("/inbound/sendgrid", inboundLimit, inboundHandler.HandleSendGrid)
+		}
+		if sesInbound != nil {
+			webhooks.POST("/inbound/ses", inboundLimit, inboundHandler.HandleSES)
+		}
+	}
+
+	// Start RabbitMQ consumer
+	eventConsumer := consumer.NewEventConsumer(rabbitMQClient, notificationService, pauseController, consumer.Config{
+		DeadLetterExchange: cfg.RabbitMQ.DeadLetterExchange,
+		MaxDeliveries:      cfg.RabbitMQ.MaxDeliveries,
+		QueueType:          cfg.RabbitMQ.QueueType,
+		Concurrency:        cfg.RabbitMQ.Concurrency,
+		Prefetch:           cfg.RabbitMQ.Prefetch,
+	}, log)
+	if err := eventConsumer.Start(ctx); err != nil {
+		log.Error("Failed to start event consumer", "error", err)
+	}
+
+	// Start HTTP server
+	port := cfg.Server.Port
+
+	srv := &http.Server{
+		Addr:    fmt.Sprintf(":%s", port),
+		Handler: router,
+	}
+
+	// Start server in goroutine
+	go func() {
+		log.Info("Notification Service started", "port", port)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal("Failed to start server", "error", err)
+		}
+	}()
+
+	// Graceful shutdown
+	<-ctx.Done()
+	stop()
+
+	log.Info("Shutting down Notification Service...")
+
+	// All components share a single drain deadline
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Error("Server forced to shutdown", "error", err)
+	}
+
+	if err := eventConsumer.Shutdown(shutdownCtx); err != nil {
+		log.Error("Event consumer did not drain before deadline", "error", err)
+	}
+
+	if err := notificationScheduler.Shutdown(shutdownCtx); err != nil {
+		log.Error("Scheduler did not drain before deadline", "error", err)
+	}
+
+	if err := maintenanceRunner.Shutdown(shutdownCtx); err != nil {
+		log.Error("Maintenance runner did not drain before deadline", "error", err)
+	}
+
+	bulkEmailService.Stop()
+
+	log.Info("Notification Service stopped")
+}
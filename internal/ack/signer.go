@@ -0,0 +1,17 @@
+package ack
+
+import (
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/signedlink"
+)
+
+// Signer signs and verifies acknowledgment links, so a recipient's app can acknowledge a
+// notification without API credentials
+type Signer = signedlink.Signer
+
+// NewSigner creates a signer of /ack links that expire after ttl. Links are relative paths
+// unless baseURL, the service's public address, is set.
+func NewSigner(secret string, ttl time.Duration, baseURL string) *Signer {
+	return signedlink.NewSigner(secret, "ack", "/ack", ttl, baseURL)
+}
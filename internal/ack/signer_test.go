@@ -0,0 +1,30 @@
+package ack
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/signedlink"
+)
+
+func TestSigner(t *testing.T) {
+	signer := NewSigner("test-secret", time.Hour, "https://notify.example.com/")
+
+	link, _ := signer.Link("tenant-1", "65f0c0ffee0000000000abcd")
+	if !strings.HasPrefix(link, "https://notify.example.com/ack/65f0c0ffee0000000000abcd?") {
+		t.Fatalf("Link() = %s", link)
+	}
+	parsed, err := url.Parse(link)
+	if err != nil {
+		t.Fatalf("Link() is not a URL: %v", err)
+	}
+	query := parsed.Query()
+	expires, _ := strconv.ParseInt(query.Get("expires"), 10, 64)
+
+	if err := signer.Verify("tenant-1", "65f0c0ffee0000000000abcd", expires, query.Get(signedlink.SignatureParam)); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package apitoken
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIssueAndVerify(t *testing.T) {
+	signer := NewSigner("new-secret-0123456789abcdef0123456789", "old-secret-0123456789abcdef0123456789")
+
+	token, issued, err := signer.Issue("tenant-1", []string{ScopeSend}, time.Hour)
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+	claims, err := signer.Verify(token)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if claims.TenantID != "tenant-1" || claims.ID != issued.ID {
+		t.Errorf("claims = %+v, want tenant-1 with ID %s", claims, issued.ID)
+	}
+	if !claims.HasScope(ScopeSend) || claims.HasScope(ScopeRead) {
+		t.Errorf("scopes = %v, want only send", claims.Scopes())
+	}
+
+	// Tokens signed with the previous secret verify until it is removed
+	old := NewSigner("old-secret-0123456789abcdef0123456789")
+	oldToken, _, _ := old.Issue("tenant-1", []string{ScopeAdmin}, time.Hour)
+	claims, err = signer.Verify(oldToken)
+	if err != nil || !claims.HasScope(ScopeRead) {
+		t.Errorf("Verify() of a token signed with the previous secret = %+v, %v, want admin granting read", claims, err)
+	}
+	if _, err := NewSigner("another-secret-0123456789abcdef012345").Verify(token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Verify() with an unknown secret error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestVerifyRejectsTamperedAndExpiredTokens(t *testing.T) {
+	signer := NewSigner("secret-0123456789abcdef0123456789abcd")
+	token, _, err := signer.Issue("tenant-1", []string{ScopeRead}, time.Minute)
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+
+	parts := strings.Split(token, ".")
+	forged := parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"tenant_id":"tenant-2","scope":"admin","exp":9999999999}`)) + "." + parts[2]
+	unsigned := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." + parts[1] + "."
+	for name, bad := range map[string]string{"forged claims": forged, "alg none": unsigned, "garbage": "not-a-token"} {
+		if _, err := signer.Verify(bad); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("Verify(%s) error = %v, want ErrInvalidToken", name, err)
+		}
+	}
+
+	signer.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	if _, err := signer.Verify(token); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("Verify() after expiry error = %v, want ErrTokenExpired", err)
+	}
+}
+
+func TestIssueRejectsUnknownScopes(t *testing.T) {
+	signer := NewSigner("secret-0123456789abcdef0123456789abcd")
+	if _, _, err := signer.Issue("tenant-1", []string{"superuser"}, time.Hour); err == nil {
+		t.Error("Issue() with an unknown scope error = nil, want an error")
+	}
+	if _, _, err := signer.Issue("tenant-1", nil, time.Hour); err == nil {
+		t.Error("Issue() without scopes error = nil, want an error")
+	}
+}
@@ -0,0 +1,272 @@
+package attachments
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+)
+
+// ErrAttachmentNotAllowed is returned when an attachment violates the attachment policy
+var ErrAttachmentNotAllowed = errors.New("attachment not allowed")
+
+// DefaultDeniedExtensions are file extensions rejected unless a tenant explicitly allows them
+var DefaultDeniedExtensions = []string{
+	".app", ".bat", ".cmd", ".com", ".cpl", ".dll", ".exe", ".hta", ".jar", ".js", ".jse",
+	".lnk", ".msi", ".pif", ".ps1", ".reg", ".scr", ".sh", ".vbe", ".vbs", ".wsf", ".wsh",
+}
+
+// DefaultDeniedMIMETypes are executable and script content types rejected by default
+var DefaultDeniedMIMETypes = []string{
+	"application/java-archive",
+	"application/javascript",
+	"application/x-executable",
+	"application/x-mach-binary",
+	"application/x-msdownload",
+	"application/x-msi",
+	"application/x-sh",
+	"text/javascript",
+	"text/x-shellscript",
+}
+
+// signatures identify executable content that http.DetectContentType reports as generic binary
+var signatures = []struct {
+	prefix   string
+	mimeType string
+}{
+	{"MZ", "application/x-msdownload"},
+	{"\x7fELF", "application/x-executable"},
+	{"\xcf\xfa\xed\xfe", "application/x-mach-binary"},
+	{"\xce\xfa\xed\xfe", "application/x-mach-binary"},
+	{"\xfe\xed\xfa\xcf", "application/x-mach-binary"},
+	{"\xfe\xed\xfa\xce", "application/x-mach-binary"},
+	{"#!", "text/x-shellscript"},
+}
+
+// Store interface for per-tenant attachment policy storage
+type Store interface {
+	FindPolicy(ctx context.Context, tenantID string) (*domain.TenantAttachmentPolicy, error)
+}
+
+// Policy lists the attachment MIME types and file extensions that are allowed or denied.
+// Empty allowlists allow anything not denied. MIME entries may use a wildcard subtype (image/*).
+type Policy struct {
+	AllowedMIMETypes  []string
+	DeniedMIMETypes   []string
+	AllowedExtensions []string
+	DeniedExtensions  []string
+}
+
+// DefaultPolicy denies common executable and script attachments
+func DefaultPolicy() Policy {
+	return Policy{
+		DeniedMIMETypes:  DefaultDeniedMIMETypes,
+		DeniedExtensions: DefaultDeniedExtensions,
+	}
+}
+
+// RejectedError describes why an attachment was rejected
+type RejectedError struct {
+	Filename string
+	Reason   string
+}
+
+// Error implements the error interface
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("attachment %q not allowed: %s", e.Filename, e.Reason)
+}
+
+// Unwrap allows errors.Is(err, ErrAttachmentNotAllowed)
+func (e *RejectedError) Unwrap() error {
+	return ErrAttachmentNotAllowed
+}
+
+// AsRejected returns the *RejectedError in err's chain, if any
+func AsRejected(err error) (*RejectedError, bool) {
+	var rejected *RejectedError
+	ok := errors.As(err, &rejected)
+	return rejected, ok
+}
+
+// Check validates an attachment's filename, declared MIME type and sniffed content type.
+// Content is sniffed so an executable renamed to an allowed extension is still rejected.
+func (p Policy) Check(attachment domain.Attachment) error {
+	reject := func(format string, args ...interface{}) error {
+		return &RejectedError{Filename: attachment.Filename, Reason: fmt.Sprintf(format, args...)}
+	}
+
+	// Windows ignores trailing dots and spaces, so "run.exe." is still an executable
+	name := strings.TrimRight(strings.TrimSpace(attachment.Filename), ". ")
+	if name == "" {
+		return reject("filename is required")
+	}
+	if strings.ContainsAny(name, "/\\") || strings.IndexFunc(name, isControl) >= 0 {
+		return reject("filename contains path separators or control characters")
+	}
+
+	ext := strings.ToLower(path.Ext(name))
+	if containsExtension(p.DeniedExtensions, ext) {
+		return reject("extension %s is not allowed", ext)
+	}
+	if len(p.AllowedExtensions) > 0 && !containsExtension(p.AllowedExtensions, ext) {
+		return reject("extension %q is not in the allowed list", ext)
+	}
+
+	declared := attachment.MimeType
+	if declared == "" {
+		declared = mime.TypeByExtension(ext)
+	}
+	if declared != "" {
+		mediaType, _, err := mime.ParseMediaType(declared)
+		if err != nil {
+			return reject("invalid MIME type %q", attachment.MimeType)
+		}
+		declared = mediaType
+	}
+	if declared != "" && matchesMIMEType(p.DeniedMIMETypes, declared) {
+		return reject("MIME type %s is not allowed", declared)
+	}
+	if len(p.AllowedMIMETypes) > 0 && !matchesMIMEType(p.AllowedMIMETypes, declared) {
+		return reject("MIME type %q is not in the allowed list", declared)
+	}
+
+	if sniffed := sniff(attachment.Content); matchesMIMEType(p.DeniedMIMETypes, sniffed) {
+		return reject("content is %s, which is not allowed", sniffed)
+	}
+	return nil
+}
+
+// withOverride applies a tenant's policy on top of p. Tenant allowlists replace the defaults
+// and lift matching default denies; tenant denylists are added to the defaults.
+func (p Policy) withOverride(override *domain.TenantAttachmentPolicy) Policy {
+	if override == nil {
+		return p
+	}
+
+	merged := p
+	if len(override.AllowedMIMETypes) > 0 {
+		merged.AllowedMIMETypes = override.AllowedMIMETypes
+	}
+	if len(override.AllowedExtensions) > 0 {
+		merged.AllowedExtensions = override.AllowedExtensions
+	}
+	merged.DeniedMIMETypes = mergeDenied(p.DeniedMIMETypes, override.AllowedMIMETypes, override.DeniedMIMETypes, normalizeMIMEType)
+	merged.DeniedExtensions = mergeDenied(p.DeniedExtensions, override.AllowedExtensions, override.DeniedExtensions, normalizeExtension)
+	return merged
+}
+
+// Validator checks attachments against the default policy and per-tenant overrides
+type Validator struct {
+	store    Store // Optional; nil applies the defaults to every tenant
+	defaults Policy
+}
+
+// NewValidator creates a new attachment validator
+func NewValidator(store Store, defaults Policy) *Validator {
+	return &Validator{
+		store:    store,
+		defaults: defaults,
+	}
+}
+
+// Validate checks every attachment against the tenant's effective policy
+func (v *Validator) Validate(ctx context.Context, tenantID string, attachments []domain.Attachment) error {
+	if len(attachments) == 0 {
+		return nil
+	}
+
+	policy := v.defaults
+	if v.store != nil {
+		override, err := v.store.FindPolicy(ctx, tenantID)
+		if err != nil {
+			return fmt.Errorf("failed to load attachment policy: %w", err)
+		}
+		policy = policy.withOverride(override)
+	}
+
+	for _, attachment := range attachments {
+		if err := policy.Check(attachment); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sniff detects the content type of an attachment, recognising executables first
+func sniff(content []byte) string {
+	for _, sig := range signatures {
+		if bytes.HasPrefix(content, []byte(sig.prefix)) {
+			return sig.mimeType
+		}
+	}
+	mediaType, _, _ := mime.ParseMediaType(http.DetectContentType(content))
+	return mediaType
+}
+
+// mergeDenied returns base without entries in allowed, followed by extra
+func mergeDenied(base, allowed, extra []string, normalize func(string) string) []string {
+	lifted := make(map[string]bool, len(allowed))
+	for _, entry := range allowed {
+		lifted[normalize(entry)] = true
+	}
+
+	merged := make([]string, 0, len(base)+len(extra))
+	for _, entry := range base {
+		if !lifted[normalize(entry)] {
+			merged = append(merged, entry)
+		}
+	}
+	return append(merged, extra...)
+}
+
+// containsExtension reports whether ext matches an entry; entries may omit the leading dot
+func containsExtension(list []string, ext string) bool {
+	for _, entry := range list {
+		if normalizeExtension(entry) == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesMIMEType reports whether mediaType matches an entry exactly or by wildcard subtype
+func matchesMIMEType(list []string, mediaType string) bool {
+	if mediaType == "" {
+		return false
+	}
+	for _, entry := range list {
+		entry = normalizeMIMEType(entry)
+		if entry == mediaType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(entry, "/*"); ok && strings.HasPrefix(mediaType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeExtension lowercases an extension and adds the leading dot
+func normalizeExtension(ext string) string {
+	ext = strings.ToLower(strings.TrimSpace(ext))
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
+
+// normalizeMIMEType lowercases a MIME type
+func normalizeMIMEType(mimeType string) string {
+	return strings.ToLower(strings.TrimSpace(mimeType))
+}
+
+// isControl reports whether r is an ASCII control character
+func isControl(r rune) bool {
+	return r < 0x20 || r == 0x7f
+}
@@ -0,0 +1,138 @@
+package attachments
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+)
+
+var pdfContent = []byte("%PDF-1.7\n%...")
+
+func TestPolicyCheck(t *testing.T) {
+	defaults := DefaultPolicy()
+
+	tests := []struct {
+		name       string
+		policy     Policy
+		attachment domain.Attachment
+		wantErr    bool
+	}{
+		{
+			name:       "pdf allowed",
+			policy:     defaults,
+			attachment: domain.Attachment{Filename: "invoice.pdf", MimeType: "application/pdf", Content: pdfContent},
+		},
+		{
+			name:       "denied extension",
+			policy:     defaults,
+			attachment: domain.Attachment{Filename: "setup.EXE", Content: []byte("MZ\x90\x00")},
+			wantErr:    true,
+		},
+		{
+			name:       "double extension",
+			policy:     defaults,
+			attachment: domain.Attachment{Filename: "invoice.pdf.js", MimeType: "application/pdf", Content: []byte("alert(1)")},
+			wantErr:    true,
+		},
+		{
+			name:       "trailing dot",
+			policy:     defaults,
+			attachment: domain.Attachment{Filename: "run.exe.", Content: []byte("MZ")},
+			wantErr:    true,
+		},
+		{
+			name:       "executable renamed to pdf",
+			policy:     defaults,
+			attachment: domain.Attachment{Filename: "invoice.pdf", MimeType: "application/pdf", Content: []byte("MZ\x90\x00\x03")},
+			wantErr:    true,
+		},
+		{
+			name:       "shell script renamed to txt",
+			policy:     defaults,
+			attachment: domain.Attachment{Filename: "notes.txt", Content: []byte("#!/bin/sh\nrm -rf /")},
+			wantErr:    true,
+		},
+		{
+			name:       "denied declared MIME type",
+			policy:     defaults,
+			attachment: domain.Attachment{Filename: "report", MimeType: "application/x-msdownload", Content: []byte("data")},
+			wantErr:    true,
+		},
+		{
+			name:       "path in filename",
+			policy:     defaults,
+			attachment: domain.Attachment{Filename: "../etc/passwd", Content: []byte("x")},
+			wantErr:    true,
+		},
+		{
+			name:       "wildcard MIME allowlist",
+			policy:     Policy{AllowedMIMETypes: []string{"image/*"}},
+			attachment: domain.Attachment{Filename: "logo.png", MimeType: "image/png", Content: []byte("\x89PNG\r\n\x1a\n")},
+		},
+		{
+			name:       "not in MIME allowlist",
+			policy:     Policy{AllowedMIMETypes: []string{"image/*"}},
+			attachment: domain.Attachment{Filename: "invoice.pdf", MimeType: "application/pdf", Content: pdfContent},
+			wantErr:    true,
+		},
+		{
+			name:       "extension allowlist without dot",
+			policy:     Policy{AllowedExtensions: []string{"pdf", "CSV"}},
+			attachment: domain.Attachment{Filename: "export.csv", MimeType: "text/csv", Content: []byte("a,b\n1,2")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Check(tt.attachment)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrAttachmentNotAllowed) {
+				t.Errorf("Check() error = %v, want ErrAttachmentNotAllowed", err)
+			}
+		})
+	}
+}
+
+// staticStore returns the same policy override for every tenant
+type staticStore struct {
+	policy *domain.TenantAttachmentPolicy
+}
+
+func (s *staticStore) FindPolicy(ctx context.Context, tenantID string) (*domain.TenantAttachmentPolicy, error) {
+	return s.policy, nil
+}
+
+func TestValidatorTenantOverride(t *testing.T) {
+	ctx := context.Background()
+	script := []domain.Attachment{{Filename: "deploy.js", MimeType: "text/javascript", Content: []byte("console.log(1)")}}
+	pdf := []domain.Attachment{{Filename: "invoice.pdf", MimeType: "application/pdf", Content: pdfContent}}
+
+	defaults := NewValidator(nil, DefaultPolicy())
+	if err := defaults.Validate(ctx, "tenant-1", script); err == nil {
+		t.Error("Expected default policy to reject .js attachments")
+	}
+
+	// The tenant explicitly allows JavaScript, which lifts the default deny
+	lenient := NewValidator(&staticStore{policy: &domain.TenantAttachmentPolicy{
+		AllowedExtensions: []string{".js", ".pdf"},
+		AllowedMIMETypes:  []string{"text/javascript", "application/pdf"},
+	}}, DefaultPolicy())
+	if err := lenient.Validate(ctx, "tenant-1", script); err != nil {
+		t.Errorf("Validate() with tenant allowlist error = %v", err)
+	}
+
+	// The tenant adds PDFs to its denylist
+	strict := NewValidator(&staticStore{policy: &domain.TenantAttachmentPolicy{
+		DeniedExtensions: []string{"pdf"},
+	}}, DefaultPolicy())
+	if err := strict.Validate(ctx, "tenant-1", pdf); !errors.Is(err, ErrAttachmentNotAllowed) {
+		t.Errorf("Validate() with tenant denylist error = %v, want ErrAttachmentNotAllowed", err)
+	}
+	if err := strict.Validate(ctx, "tenant-1", script); err == nil {
+		t.Error("Tenant denylist should keep the default denies")
+	}
+}
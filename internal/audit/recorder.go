@@ -0,0 +1,45 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/metrics"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// writeTimeout bounds how long recording an entry may delay a response
+const writeTimeout = 5 * time.Second
+
+// Store interface for append-only audit log storage
+type Store interface {
+	Create(ctx context.Context, entry *domain.AuditLog) error
+}
+
+// Recorder writes audit log entries. Failures are logged and counted rather than
+// failing the audited request, which has already completed.
+type Recorder struct {
+	store Store
+	log   *logger.Logger
+}
+
+// NewRecorder creates a new audit recorder
+func NewRecorder(store Store, log *logger.Logger) *Recorder {
+	return &Recorder{
+		store: store,
+		log:   log,
+	}
+}
+
+// Record appends entry to the audit log. The write outlives cancellation of ctx,
+// so entries are kept for requests whose client disconnected.
+func (r *Recorder) Record(ctx context.Context, entry *domain.AuditLog) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), writeTimeout)
+	defer cancel()
+
+	if err := r.store.Create(ctx, entry); err != nil {
+		metrics.AuditWriteFailures.Inc()
+		r.log.Error("Failed to write audit log", "error", err, "action", entry.Action, "actor", entry.Actor, "tenant_id", entry.TenantID)
+	}
+}
@@ -0,0 +1,145 @@
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/metrics"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// Kinds of cached data, used as key namespaces and metric labels
+const (
+	KindPreferences  = "preferences"
+	KindSuppression  = "suppression"
+	KindTenantConfig = "tenant_config"
+)
+
+// DefaultTTL is how long entries are cached when no TTL is configured
+const DefaultTTL = time.Minute
+
+// DefaultRetryAfter is how long the store is bypassed after it fails when no interval is configured
+const DefaultRetryAfter = 5 * time.Second
+
+// keyPrefix keeps this service's entries apart from others sharing the store
+const keyPrefix = "notification-service:"
+
+// Store is a shared key-value store whose entries expire
+type Store interface {
+	// Get returns the value of each key, or nil for keys that aren't stored
+	Get(ctx context.Context, keys ...string) ([][]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// Config holds cache settings
+type Config struct {
+	TTL        time.Duration // How long entries are cached
+	RetryAfter time.Duration // How long lookups bypass the store after it fails
+}
+
+// Cache caches lookups in a shared store so that every instance sees the same entries and
+// an update on one instance invalidates them for all. A failing store is bypassed for a
+// while rather than slowing every lookup down, so callers fall back to their own source.
+// Entries also expire after the TTL, which bounds how stale a missed invalidation can leave them.
+// A nil Cache caches nothing.
+type Cache struct {
+	store      Store
+	ttl        time.Duration
+	retryAfter time.Duration
+	downUntil  atomic.Int64 // Unix nanoseconds until which lookups bypass the store
+	log        *logger.Logger
+}
+
+// New creates a new cache backed by store
+func New(store Store, config Config, log *logger.Logger) *Cache {
+	ttl := config.TTL
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	retryAfter := config.RetryAfter
+	if retryAfter <= 0 {
+		retryAfter = DefaultRetryAfter
+	}
+	return &Cache{store: store, ttl: ttl, retryAfter: retryAfter, log: log}
+}
+
+// Get returns the cached value of each key of kind, or nil for keys that aren't cached.
+// An empty, non-nil value is a cached absence.
+func (c *Cache) Get(ctx context.Context, kind string, keys ...string) [][]byte {
+	values := make([][]byte, len(keys))
+	if c == nil || len(keys) == 0 {
+		return values
+	}
+	if c.bypassed() {
+		metrics.CacheLookups.WithLabelValues(kind, "bypassed").Add(float64(len(keys)))
+		return values
+	}
+
+	stored, err := c.store.Get(ctx, c.keys(kind, keys)...)
+	if err != nil || len(stored) != len(keys) {
+		c.failed(err, "get", kind)
+		metrics.CacheLookups.WithLabelValues(kind, "error").Add(float64(len(keys)))
+		return values
+	}
+
+	var hits int
+	for i, value := range stored {
+		if value != nil {
+			values[i] = value
+			hits++
+		}
+	}
+	metrics.CacheLookups.WithLabelValues(kind, "hit").Add(float64(hits))
+	metrics.CacheLookups.WithLabelValues(kind, "miss").Add(float64(len(keys) - hits))
+	return values
+}
+
+// Set caches value under key of kind. An empty value caches that there is nothing to find.
+func (c *Cache) Set(ctx context.Context, kind, key string, value []byte) {
+	if c == nil || c.bypassed() {
+		return
+	}
+	if err := c.store.Set(ctx, c.key(kind, key), value, c.ttl); err != nil {
+		c.failed(err, "set", kind)
+	}
+}
+
+// Delete invalidates keys of kind. It is tried even while the store is bypassed, since a
+// missed invalidation leaves a stale entry until it expires.
+func (c *Cache) Delete(ctx context.Context, kind string, keys ...string) {
+	if c == nil || len(keys) == 0 {
+		return
+	}
+	if err := c.store.Delete(ctx, c.keys(kind, keys)...); err != nil {
+		c.failed(err, "delete", kind)
+	}
+}
+
+// bypassed reports whether the store recently failed
+func (c *Cache) bypassed() bool {
+	return time.Now().UnixNano() < c.downUntil.Load()
+}
+
+// failed bypasses the store for a while after it fails, logging once per outage
+func (c *Cache) failed(err error, op, kind string) {
+	now := time.Now()
+	if previous := c.downUntil.Swap(now.Add(c.retryAfter).UnixNano()); previous < now.UnixNano() {
+		c.log.Warn("Cache unavailable, falling back to the database", "error", err, "op", op, "kind", kind, "retry_after", c.retryAfter)
+	}
+}
+
+// key returns the store key of key of kind
+func (c *Cache) key(kind, key string) string {
+	return keyPrefix + kind + ":" + key
+}
+
+// keys returns the store keys of keys of kind
+func (c *Cache) keys(kind string, keys []string) []string {
+	storeKeys := make([]string, len(keys))
+	for i, key := range keys {
+		storeKeys[i] = c.key(kind, key)
+	}
+	return storeKeys
+}
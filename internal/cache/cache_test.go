@@ -0,0 +1,209 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// memoryStore is a Store that can be made to fail
+type memoryStore struct {
+	values map[string][]byte
+	gets   int
+	fail   bool
+}
+
+func (s *memoryStore) Get(ctx context.Context, keys ...string) ([][]byte, error) {
+	s.gets++
+	if s.fail {
+		return nil, errors.New("connection refused")
+	}
+	values := make([][]byte, len(keys))
+	for i, key := range keys {
+		values[i] = s.values[key]
+	}
+	return values, nil
+}
+
+func (s *memoryStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if s.fail {
+		return errors.New("connection refused")
+	}
+	s.values[key] = value
+	return nil
+}
+
+func (s *memoryStore) Delete(ctx context.Context, keys ...string) error {
+	if s.fail {
+		return errors.New("connection refused")
+	}
+	for _, key := range keys {
+		delete(s.values, key)
+	}
+	return nil
+}
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryStore{values: map[string][]byte{}}
+	c := New(store, Config{RetryAfter: time.Hour}, logger.NewLogger())
+
+	c.Set(ctx, KindPreferences, "tenant-1:user-1", []byte("prefs"))
+	c.Set(ctx, KindPreferences, "tenant-1:user-2", []byte{})
+	values := c.Get(ctx, KindPreferences, "tenant-1:user-1", "tenant-1:user-2", "tenant-1:user-3")
+	if string(values[0]) != "prefs" || values[1] == nil || len(values[1]) != 0 || values[2] != nil {
+		t.Fatalf("Get() = %q, want a value, a cached absence and a miss", values)
+	}
+	if _, ok := store.values["notification-service:preferences:tenant-1:user-1"]; !ok {
+		t.Errorf("stored keys %v are not namespaced", store.values)
+	}
+
+	c.Delete(ctx, KindPreferences, "tenant-1:user-1")
+	if values := c.Get(ctx, KindPreferences, "tenant-1:user-1"); values[0] != nil {
+		t.Errorf("Get() after Delete() = %q", values[0])
+	}
+
+	// A failing store is bypassed rather than asked on every lookup
+	store.fail = true
+	c.Get(ctx, KindPreferences, "tenant-1:user-2")
+	gets := store.gets
+	if values := c.Get(ctx, KindPreferences, "tenant-1:user-2"); values[0] != nil || store.gets != gets {
+		t.Errorf("Get() while bypassed = %q after %d store gets, want a miss without asking the store", values[0], store.gets-gets)
+	}
+
+	var nilCache *Cache
+	nilCache.Set(ctx, KindPreferences, "key", []byte("value"))
+	nilCache.Delete(ctx, KindPreferences, "key")
+	if values := nilCache.Get(ctx, KindPreferences, "key"); len(values) != 1 || values[0] != nil {
+		t.Errorf("nil Cache Get() = %q", values)
+	}
+}
+
+// fakeRedis serves GET-family commands from memory over the Redis protocol
+func fakeRedis(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on loopback: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	var mu sync.Mutex
+	values := map[string]string{}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				for {
+					args, err := readCommand(reader)
+					if err != nil {
+						return
+					}
+					mu.Lock()
+					var reply string
+					switch strings.ToUpper(args[0]) {
+					case "AUTH":
+						if args[1] != "secret" {
+							reply = "-WRONGPASS invalid password\r\n"
+						} else {
+							reply = "+OK\r\n"
+						}
+					case "PING":
+						reply = "+PONG\r\n"
+					case "SET":
+						values[args[1]] = args[2]
+						reply = "+OK\r\n"
+					case "MGET":
+						reply = "*" + strconv.Itoa(len(args)-1) + "\r\n"
+						for _, key := range args[1:] {
+							if value, ok := values[key]; ok {
+								reply += "$" + strconv.Itoa(len(value)) + "\r\n" + value + "\r\n"
+							} else {
+								reply += "$-1\r\n"
+							}
+						}
+					case "DEL":
+						for _, key := range args[1:] {
+							delete(values, key)
+						}
+						reply = ":" + strconv.Itoa(len(args)-1) + "\r\n"
+					default:
+						reply = "-ERR unknown command\r\n"
+					}
+					mu.Unlock()
+					if _, err := io.WriteString(conn, reply); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// readCommand reads a command sent as an array of bulk strings
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	args := make([]string, count)
+	for i := range args {
+		if line, err = reader.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		size, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+		arg := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, arg); err != nil {
+			return nil, err
+		}
+		args[i] = string(arg[:size])
+	}
+	return args, nil
+}
+
+func TestRedisStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewRedisStore(RedisConfig{Addr: fakeRedis(t), Password: "secret", Timeout: time.Second})
+	defer store.Close()
+
+	if err := store.Ping(ctx); err != nil {
+		t.Fatalf("Ping() error = %v", err)
+	}
+	if err := store.Set(ctx, "a", []byte("value"), time.Minute); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := store.Set(ctx, "b", []byte{}, time.Minute); err != nil {
+		t.Fatalf("Set() empty error = %v", err)
+	}
+	values, err := store.Get(ctx, "a", "b", "c")
+	if err != nil || string(values[0]) != "value" || values[1] == nil || len(values[1]) != 0 || values[2] != nil {
+		t.Fatalf("Get() = %q, %v", values, err)
+	}
+	if err := store.Delete(ctx, "a"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if values, err := store.Get(ctx, "a"); err != nil || values[0] != nil {
+		t.Errorf("Get() after Delete() = %q, %v", values, err)
+	}
+
+	wrong := NewRedisStore(RedisConfig{Addr: store.config.Addr, Password: "wrong", Timeout: time.Second})
+	var replyErr redisError
+	if err := wrong.Ping(ctx); !errors.As(err, &replyErr) {
+		t.Errorf("Ping() with wrong password error = %v, want a Redis error reply", err)
+	}
+}
@@ -0,0 +1,118 @@
+// Package charset converts text between UTF-8, which the service stores, and the legacy
+// character sets emails may be sent in or import files may be saved in
+package charset
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/ianaindex"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// UTF8 is the canonical name of the default charset
+const UTF8 = "utf-8"
+
+// sniffBytes is how much of a file is checked for UTF-8 before falling back to Windows-1252
+const sniffBytes = 64 * 1024
+
+var (
+	// ErrUnsupported is returned for a charset name that isn't known or can't be converted
+	ErrUnsupported = errors.New("unsupported charset")
+
+	// ErrUnrepresentable is returned when text has characters the charset can't encode
+	ErrUnrepresentable = errors.New("text not representable in charset")
+)
+
+// Canonical returns the lowercase IANA name of a charset, e.g. iso-8859-1 for latin1.
+// An empty name is UTF-8.
+func Canonical(name string) (string, error) {
+	_, canonical, err := lookup(name)
+	return canonical, err
+}
+
+// lookup returns a charset's encoding and canonical name; UTF-8 has a nil encoding
+func lookup(name string) (encoding.Encoding, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || strings.EqualFold(name, UTF8) || strings.EqualFold(name, "utf8") {
+		return nil, UTF8, nil
+	}
+
+	// IANA names first, since the WHATWG labels read latin1 as windows-1252
+	enc, err := ianaindex.MIME.Encoding(name)
+	if err != nil || enc == nil {
+		if enc, err = htmlindex.Get(name); err != nil {
+			return nil, "", fmt.Errorf("%w: %q", ErrUnsupported, name)
+		}
+	}
+	canonical, err := ianaindex.MIME.Name(enc)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %q", ErrUnsupported, name)
+	}
+	canonical = strings.ToLower(canonical)
+	if canonical == UTF8 {
+		return nil, UTF8, nil
+	}
+	return enc, canonical, nil
+}
+
+// Encode converts UTF-8 text to the named charset. Characters the charset has no code for
+// are an error rather than being replaced.
+func Encode(text, name string) ([]byte, error) {
+	enc, canonical, err := lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	if enc == nil {
+		return []byte(text), nil
+	}
+
+	encoded, err := enc.NewEncoder().Bytes([]byte(text))
+	if err != nil {
+		return nil, fmt.Errorf("%w %s: %v", ErrUnrepresentable, canonical, err)
+	}
+	return encoded, nil
+}
+
+// NewUTF8Reader returns a reader of r's content as UTF-8. A declared charset, such as the
+// charset parameter of the file's Content-Type, is trusted. Otherwise a UTF-8 or UTF-16 byte
+// order mark decides, then content that starts as valid UTF-8 is read as is, and anything
+// else is read as Windows-1252, a superset of ISO-8859-1 used by most legacy exports.
+// A UTF-8 byte order mark is removed.
+func NewUTF8Reader(r io.Reader, declared string) (io.Reader, error) {
+	enc, _, err := lookup(declared)
+	if err != nil {
+		return nil, err
+	}
+	if enc != nil {
+		return transform.NewReader(r, enc.NewDecoder()), nil
+	}
+
+	buffered := bufio.NewReaderSize(r, sniffBytes)
+	fallback := encoding.Nop.NewDecoder()
+	if declared == "" {
+		prefix, _ := buffered.Peek(sniffBytes)
+		if !validUTF8Prefix(prefix) {
+			fallback = charmap.Windows1252.NewDecoder()
+		}
+	}
+	return transform.NewReader(buffered, unicode.BOMOverride(fallback)), nil
+}
+
+// validUTF8Prefix reports whether b is valid UTF-8, allowing its last character to be cut off
+func validUTF8Prefix(b []byte) bool {
+	for cut := 0; cut < utf8.UTFMax && cut <= len(b); cut++ {
+		if utf8.Valid(b[:len(b)-cut]) {
+			return cut == 0 || !utf8.FullRune(b[len(b)-cut:])
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package charset
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestCanonical(t *testing.T) {
+	tests := map[string]string{
+		"":             UTF8,
+		"UTF-8":        UTF8,
+		"latin1":       "iso-8859-1",
+		" ISO-8859-15": "iso-8859-15",
+		"cp1252":       "windows-1252",
+		"sjis":         "shift_jis",
+	}
+	for name, want := range tests {
+		if got, err := Canonical(name); err != nil || got != want {
+			t.Errorf("Canonical(%q) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+
+	if _, err := Canonical("klingon"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Canonical(klingon) error = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	got, err := Encode("Grüße", "iso-8859-1")
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if string(got) != "Gr\xfc\xdfe" {
+		t.Errorf("Encode() = %q, want Latin-1 bytes", got)
+	}
+
+	if got, err := Encode("Grüße", ""); err != nil || string(got) != "Grüße" {
+		t.Errorf("Encode() to UTF-8 = %q, %v, want the text unchanged", got, err)
+	}
+	if _, err := Encode("日本", "iso-8859-1"); !errors.Is(err, ErrUnrepresentable) {
+		t.Errorf("Encode() error = %v, want ErrUnrepresentable", err)
+	}
+}
+
+func TestNewUTF8Reader(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		declared string
+		want     string
+	}{
+		{name: "utf-8", content: "Grüße", want: "Grüße"},
+		{name: "utf-8 byte order mark", content: "\xef\xbb\xbfGrüße", want: "Grüße"},
+		{name: "utf-16 byte order mark", content: "\xff\xfeG\x00r\x00\xfc\x00", want: "Grü"},
+		{name: "legacy bytes", content: "Gr\xfc\xdfe \x80", want: "Grüße €"},
+		{name: "declared charset", content: "\x82\xb1\x82\xf1", declared: "shift_jis", want: "こん"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewUTF8Reader(strings.NewReader(tt.content), tt.declared)
+			if err != nil {
+				t.Fatalf("NewUTF8Reader() error = %v", err)
+			}
+			got, err := io.ReadAll(r)
+			if err != nil || string(got) != tt.want {
+				t.Errorf("read %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+
+	if _, err := NewUTF8Reader(strings.NewReader(""), "klingon"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("NewUTF8Reader() error = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestValidUTF8Prefix(t *testing.T) {
+	if !validUTF8Prefix([]byte("caf\xc3")) {
+		t.Error("a character cut off at the end is valid")
+	}
+	if validUTF8Prefix([]byte("caf\xe9 au lait")) {
+		t.Error("a Latin-1 byte at the end is invalid")
+	}
+}
@@ -1,151 +1,340 @@
-package consumer
-
-import (
-	"context"
-	"encoding/json"
-	"time"
-
-	"github.com/vhvplatform/go-notification-service/internal/domain"
-	"github.com/vhvplatform/go-notification-service/internal/service"
-	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
-	"github.com/vhvplatform/go-notification-service/internal/shared/rabbitmq"
-)
-
-const (
-	notificationExchange   = "notifications"
-	notificationQueue      = "notification_queue"
-	notificationRoutingKey = "notification.*"
-)
-
-// EventConsumer consumes events from RabbitMQ
-type EventConsumer struct {
-	client        *rabbitmq.RabbitMQClient
-	service       *service.NotificationService
-	log           *logger.Logger
-	stopChan      chan struct{}
-	maxRetries    int
-	retryDelay    time.Duration
-	maxRetryDelay time.Duration
-}
-
-// NewEventConsumer creates a new event consumer
-func NewEventConsumer(client *rabbitmq.RabbitMQClient, service *service.NotificationService, log *logger.Logger) *EventConsumer {
-	return &EventConsumer{
-		client:        client,
-		service:       service,
-		log:           log,
-		stopChan:      make(chan struct{}),
-		maxRetries:    5,
-		retryDelay:    1 * time.Second,
-		maxRetryDelay: 60 * time.Second,
-	}
-}
-
-// Start starts consuming events from RabbitMQ with auto-restart
-func (c *EventConsumer) Start() error {
-	c.log.Info("Starting event consumer with auto-restart", "queue", notificationQueue)
-
-	// Run consumer with exponential backoff retry
-	go c.runWithRetry()
-
-	return nil
-}
-
-// Stop stops the event consumer
-func (c *EventConsumer) Stop() {
-	close(c.stopChan)
-}
-
-// runWithRetry runs the consumer with exponential backoff retry
-func (c *EventConsumer) runWithRetry() {
-	retryCount := 0
-	currentDelay := c.retryDelay
-
-	for {
-		select {
-		case <-c.stopChan:
-			c.log.Info("Event consumer stopped")
-			return
-		default:
-			err := c.consume()
-			if err != nil {
-				retryCount++
-				c.log.Error("Consumer failed, retrying", "error", err, "retry_count", retryCount, "delay", currentDelay)
-
-				// Wait before retry
-				time.Sleep(currentDelay)
-
-				// Calculate next delay with exponential backoff
-				currentDelay = currentDelay * 2
-				if currentDelay > c.maxRetryDelay {
-					currentDelay = c.maxRetryDelay
-				}
-			} else {
-				// Reset retry count on successful run
-				retryCount = 0
-				currentDelay = c.retryDelay
-			}
-		}
-	}
-}
-
-// consume performs the actual consumption of messages
-func (c *EventConsumer) consume() error {
-	c.log.Info("Starting event consumer", "queue", notificationQueue)
-
-	// Declare exchange
-	if err := c.client.DeclareExchange(notificationExchange, "topic"); err != nil {
-		c.log.Error("Failed to declare exchange", "error", err)
-		return err
-	}
-
-	// Declare queue
-	if err := c.client.DeclareQueue(notificationQueue); err != nil {
-		c.log.Error("Failed to declare queue", "error", err)
-		return err
-	}
-
-	// Bind queue to exchange
-	if err := c.client.BindQueue(notificationQueue, notificationRoutingKey, notificationExchange); err != nil {
-		c.log.Error("Failed to bind queue", "error", err)
-		return err
-	}
-
-	// Start consuming
-	messages, err := c.client.Consume(notificationQueue, notificationRoutingKey)
-	if err != nil {
-		c.log.Error("Failed to start consuming", "error", err)
-		return err
-	}
-
-	// Process messages
-	for msg := range messages {
-		select {
-		case <-c.stopChan:
-			return nil
-		default:
-			c.log.Info("Received message", "routing_key", msg.RoutingKey)
-
-			var event domain.Event
-			if err := json.Unmarshal(msg.Body, &event); err != nil {
-				c.log.Error("Failed to unmarshal event", "error", err)
-				msg.Nack(false, false) // Don't requeue invalid messages
-				continue
-			}
-
-			// Process event
-			ctx := context.Background()
-			if err := c.service.ProcessEvent(ctx, &event); err != nil {
-				c.log.Error("Failed to process event", "error", err, "type", event.Type)
-				msg.Nack(false, true) // Requeue for retry
-				continue
-			}
-
-			// Acknowledge message
-			msg.Ack(false)
-			c.log.Info("Event processed successfully", "type", event.Type)
-		}
-	}
-
-	return nil
-}
+package consumer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/metrics"
+	"github.com/vhvplatform/go-notification-service/internal/middleware"
+	"github.com/vhvplatform/go-notification-service/internal/pause"
+	"github.com/vhvplatform/go-notification-service/internal/service"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+	"github.com/vhvplatform/go-notification-service/internal/shared/rabbitmq"
+)
+
+const (
+	notificationExchange   = "notifications"
+	notificationQueue      = "notification_queue"
+	notificationRoutingKey = "notification.*"
+	deadLetterQueue        = notificationQueue + ".dlq"
+)
+
+// Queue types supported for the notification queue
+const (
+	QueueTypeQuorum  = "quorum"
+	QueueTypeClassic = "classic"
+)
+
+// Config holds event consumer settings
+type Config struct {
+	DeadLetterExchange string // Receives rejected and poison messages; empty discards them
+	MaxDeliveries      int    // Delivery attempts before a failing event is rejected; 0 requeues forever
+	QueueType          string // Quorum queues count deliveries; failed events on classic queues are republished with a count
+	Concurrency        int    // Number of events processed in parallel; defaults to 1
+	Prefetch           int    // Unacknowledged deliveries held by the consumer; defaults to Concurrency
+}
+
+// EventConsumer consumes events from RabbitMQ
+type EventConsumer struct {
+	client        *rabbitmq.RabbitMQClient
+	service       *service.NotificationService
+	pauses        *pause.Controller // Optional; processing waits while every channel is paused
+	config        Config
+	log           *logger.Logger
+	maxRetries    int
+	retryDelay    time.Duration
+	maxRetryDelay time.Duration
+
+	cancel       context.CancelFunc // Stops consuming new messages
+	processCtx   context.Context    // Context for in-flight event processing
+	abortProcess context.CancelFunc // Cancels in-flight processing when the drain deadline passes
+	done         chan struct{}
+	inFlight     sync.WaitGroup
+	inFlightN    atomic.Int64
+}
+
+// NewEventConsumer creates a new event consumer
+func NewEventConsumer(client *rabbitmq.RabbitMQClient, service *service.NotificationService, pauses *pause.Controller, config Config, log *logger.Logger) *EventConsumer {
+	return &EventConsumer{
+		client:        client,
+		service:       service,
+		pauses:        pauses,
+		config:        config,
+		log:           log,
+		maxRetries:    5,
+		retryDelay:    1 * time.Second,
+		maxRetryDelay: 60 * time.Second,
+		done:          make(chan struct{}),
+	}
+}
+
+// Start starts consuming events from RabbitMQ with auto-restart
+// Consumption stops when ctx is cancelled; call Shutdown to wait for in-flight events.
+func (c *EventConsumer) Start(ctx context.Context) error {
+	c.log.Info("Starting event consumer with auto-restart", "queue", notificationQueue)
+
+	consumeCtx, cancel := context.WithCancel(ctx)
+	c.cancel = cancel
+
+	// In-flight events keep running after shutdown starts, until the drain deadline
+	c.processCtx, c.abortProcess = context.WithCancel(context.WithoutCancel(ctx))
+
+	// Run consumer with exponential backoff retry
+	go func() {
+		defer close(c.done)
+		c.runWithRetry(consumeCtx)
+	}()
+
+	return nil
+}
+
+// Shutdown stops consuming new events and waits for in-flight events to finish
+// If ctx expires first, in-flight processing is cancelled and ctx.Err() is returned.
+func (c *EventConsumer) Shutdown(ctx context.Context) error {
+	if c.cancel == nil {
+		return nil
+	}
+	c.cancel()
+
+	drained := make(chan struct{})
+	go func() {
+		<-c.done
+		c.inFlight.Wait()
+		close(drained)
+	}()
+
+	c.log.Info("Draining event consumer", "in_flight", c.inFlightN.Load())
+
+	select {
+	case <-drained:
+		c.log.Info("Event consumer stopped")
+		return nil
+	case <-ctx.Done():
+		c.log.Warn("Event consumer drain deadline exceeded", "in_flight", c.inFlightN.Load())
+		c.abortProcess()
+		return ctx.Err()
+	}
+}
+
+// runWithRetry runs the consumer with exponential backoff retry
+func (c *EventConsumer) runWithRetry(ctx context.Context) {
+	retryCount := 0
+	currentDelay := c.retryDelay
+
+	for {
+		err := c.consume(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err != nil {
+			retryCount++
+			metrics.ConsumerRestarts.Inc()
+			c.log.Error("Consumer failed, retrying", "error", err, "retry_count", retryCount, "delay", currentDelay)
+
+			// Wait before retry
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(currentDelay):
+			}
+
+			// Calculate next delay with exponential backoff
+			currentDelay = currentDelay * 2
+			if currentDelay > c.maxRetryDelay {
+				currentDelay = c.maxRetryDelay
+			}
+		} else {
+			// Reset retry count on successful run
+			retryCount = 0
+			currentDelay = c.retryDelay
+		}
+	}
+}
+
+// consume performs the actual consumption of messages
+func (c *EventConsumer) consume(ctx context.Context) error {
+	c.log.Info("Starting event consumer", "queue", notificationQueue)
+
+	// Declare exchange
+	if err := c.client.DeclareExchange(notificationExchange, "topic"); err != nil {
+		c.log.Error("Failed to declare exchange", "error", err)
+		return err
+	}
+
+	// Declare the dead-letter exchange and queue before the queue that references them
+	if c.config.DeadLetterExchange != "" {
+		if err := c.client.DeclareExchange(c.config.DeadLetterExchange, "fanout"); err != nil {
+			c.log.Error("Failed to declare dead-letter exchange", "error", err)
+			return err
+		}
+		if err := c.client.DeclareQueue(deadLetterQueue, nil); err != nil {
+			c.log.Error("Failed to declare dead-letter queue", "error", err)
+			return err
+		}
+		if err := c.client.BindQueue(deadLetterQueue, "", c.config.DeadLetterExchange); err != nil {
+			c.log.Error("Failed to bind dead-letter queue", "error", err)
+			return err
+		}
+	}
+
+	// Declare queue
+	if err := c.client.DeclareQueue(notificationQueue, c.queueArgs()); err != nil {
+		c.log.Error("Failed to declare queue", "error", err)
+		return err
+	}
+
+	// Bind queue to exchange
+	if err := c.client.BindQueue(notificationQueue, notificationRoutingKey, notificationExchange); err != nil {
+		c.log.Error("Failed to bind queue", "error", err)
+		return err
+	}
+
+	// Limit unacknowledged deliveries so idle workers don't leave messages parked here
+	workers := c.workers()
+	if err := c.client.SetQoS(c.prefetch()); err != nil {
+		c.log.Error("Failed to set prefetch", "error", err)
+		return err
+	}
+
+	// Start consuming
+	messages, err := c.client.Consume(ctx, notificationQueue, notificationRoutingKey)
+	if err != nil {
+		c.log.Error("Failed to start consuming", "error", err)
+		return err
+	}
+
+	// Process messages until the delivery channel closes, which also happens on shutdown
+	c.log.Info("Processing events", "workers", workers, "prefetch", c.prefetch())
+	runWorkers(messages, workers, c.handleMessage)
+
+	if ctx.Err() != nil {
+		return nil
+	}
+	return errors.New("delivery channel closed")
+}
+
+// workers returns the configured worker count
+func (c *EventConsumer) workers() int {
+	if c.config.Concurrency < 1 {
+		return 1
+	}
+	return c.config.Concurrency
+}
+
+// prefetch returns the configured prefetch count, matching the worker count by default
+func (c *EventConsumer) prefetch() int {
+	if c.config.Prefetch < 1 {
+		return c.workers()
+	}
+	return c.config.Prefetch
+}
+
+// runWorkers handles messages with a fixed number of goroutines until the channel closes
+// With more than one worker, events are no longer processed in queue order.
+func runWorkers(messages <-chan rabbitmq.Message, workers int, handle func(rabbitmq.Message)) {
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for msg := range messages {
+				handle(msg)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// handleMessage processes a single delivery and acknowledges it
+func (c *EventConsumer) handleMessage(msg rabbitmq.Message) {
+	c.inFlight.Add(1)
+	c.inFlightN.Add(1)
+	defer func() {
+		c.inFlightN.Add(-1)
+		c.inFlight.Done()
+	}()
+
+	c.log.Info("Received message", "routing_key", msg.RoutingKey)
+
+	event, err := DecodeEvent(msg.Body)
+	if err != nil {
+		c.log.Error("Rejecting undecodable event", "error", err, "routing_key", msg.RoutingKey)
+		c.reject(msg, rejectReason(err))
+		return
+	}
+
+	// While every channel is paused the event stays unacknowledged and the rest stay queued
+	if c.pauses != nil {
+		if err := c.pauses.Wait(c.processCtx); err != nil {
+			msg.Nack(false, true)
+			return
+		}
+	}
+
+	// Process event; the event's tenant is carried in the context, as for HTTP requests
+	if err := c.service.ProcessEvent(middleware.WithTenantID(c.processCtx, event.TenantID), event); err != nil {
+		attempts := msg.DeliveryCount + 1
+		if c.exhausted(attempts) {
+			c.log.Error("Event failed on final attempt, rejecting", "error", err, "type", event.Type, "attempts", attempts, "dead_letter_exchange", c.config.DeadLetterExchange)
+			c.reject(msg, rejectMaxDeliveries)
+			return
+		}
+
+		c.log.Error("Failed to process event", "error", err, "type", event.Type, "attempts", attempts)
+		c.retry(msg)
+		return
+	}
+
+	// Acknowledge message
+	msg.Ack(false)
+	c.log.Info("Event processed successfully", "type", event.Type)
+}
+
+// retry returns a failed msg to the queue for another attempt. Classic queues don't count
+// redeliveries, so with a delivery limit the message is republished with its attempt count
+// instead of requeued; if that fails it is requeued.
+func (c *EventConsumer) retry(msg rabbitmq.Message) {
+	if c.config.QueueType != QueueTypeQuorum && c.config.MaxDeliveries > 0 {
+		err := c.client.Republish(notificationQueue, msg)
+		if err == nil {
+			return
+		}
+		c.log.Error("Failed to republish event, requeueing", "error", err)
+	}
+	msg.Nack(false, true)
+}
+
+// reject rejects msg without requeue, routing it to the dead-letter exchange when there is one
+func (c *EventConsumer) reject(msg rabbitmq.Message, reason string) {
+	metrics.EventsRejected.WithLabelValues(reason).Inc()
+	if c.config.DeadLetterExchange != "" {
+		metrics.EventsDeadLettered.WithLabelValues(reason).Inc()
+	}
+	msg.Nack(false, false)
+}
+
+// queueArgs returns the x-arguments for the notification queue
+// Changing them requires deleting and re-declaring an existing queue.
+func (c *EventConsumer) queueArgs() map[string]interface{} {
+	args := map[string]interface{}{}
+	if c.config.DeadLetterExchange != "" {
+		args["x-dead-letter-exchange"] = c.config.DeadLetterExchange
+	}
+	if c.config.QueueType == QueueTypeQuorum {
+		args["x-queue-type"] = QueueTypeQuorum
+		// Broker-side backstop in case the consumer crashes before rejecting a poison message
+		if c.config.MaxDeliveries > 0 {
+			args["x-delivery-limit"] = c.config.MaxDeliveries
+		}
+	}
+	return args
+}
+
+// exhausted reports whether a failed event has used all of its delivery attempts
+func (c *EventConsumer) exhausted(attempts int64) bool {
+	return c.config.MaxDeliveries > 0 && attempts >= int64(c.config.MaxDeliveries)
+}
@@ -0,0 +1,237 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/vhvplatform/go-notification-service/internal/metrics"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+	"github.com/vhvplatform/go-notification-service/internal/shared/rabbitmq"
+)
+
+func TestQueueArgs(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   map[string]interface{}
+	}{
+		{
+			name:   "quorum with dead-lettering",
+			config: Config{DeadLetterExchange: "notifications.dlx", MaxDeliveries: 5, QueueType: QueueTypeQuorum},
+			want: map[string]interface{}{
+				"x-dead-letter-exchange": "notifications.dlx",
+				"x-queue-type":           QueueTypeQuorum,
+				"x-delivery-limit":       5,
+			},
+		},
+		{
+			name:   "classic ignores delivery limit",
+			config: Config{DeadLetterExchange: "notifications.dlx", MaxDeliveries: 5, QueueType: QueueTypeClassic},
+			want:   map[string]interface{}{"x-dead-letter-exchange": "notifications.dlx"},
+		},
+		{
+			name:   "no dead-lettering",
+			config: Config{QueueType: QueueTypeClassic},
+			want:   map[string]interface{}{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &EventConsumer{config: tt.config}
+			if got := c.queueArgs(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("queueArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExhausted(t *testing.T) {
+	c := &EventConsumer{config: Config{DeadLetterExchange: "notifications.dlx", MaxDeliveries: 3}}
+	if c.exhausted(2) {
+		t.Error("exhausted(2) = true, want false")
+	}
+	if !c.exhausted(3) {
+		t.Error("exhausted(3) = false, want true")
+	}
+
+	unlimited := &EventConsumer{config: Config{DeadLetterExchange: "notifications.dlx"}}
+	if unlimited.exhausted(100) {
+		t.Error("exhausted() with no delivery limit = true, want false")
+	}
+
+	// Without a dead-letter exchange exhausted events are discarded rather than retried forever
+	noDLX := &EventConsumer{config: Config{MaxDeliveries: 3, QueueType: QueueTypeClassic}}
+	if !noDLX.exhausted(3) {
+		t.Error("exhausted(3) without a dead-letter exchange = false, want true")
+	}
+}
+
+func TestWorkersAndPrefetch(t *testing.T) {
+	tests := []struct {
+		config       Config
+		wantWorkers  int
+		wantPrefetch int
+	}{
+		{config: Config{}, wantWorkers: 1, wantPrefetch: 1},
+		{config: Config{Concurrency: 8}, wantWorkers: 8, wantPrefetch: 8},
+		{config: Config{Concurrency: 8, Prefetch: 20}, wantWorkers: 8, wantPrefetch: 20},
+	}
+
+	for _, tt := range tests {
+		c := &EventConsumer{config: tt.config}
+		if got := c.workers(); got != tt.wantWorkers {
+			t.Errorf("workers() with %+v = %d, want %d", tt.config, got, tt.wantWorkers)
+		}
+		if got := c.prefetch(); got != tt.wantPrefetch {
+			t.Errorf("prefetch() with %+v = %d, want %d", tt.config, got, tt.wantPrefetch)
+		}
+	}
+}
+
+func TestRunWorkers(t *testing.T) {
+	const workers = 4
+	messages := make(chan rabbitmq.Message)
+
+	var handled atomic.Int64
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	done := make(chan struct{})
+	go func() {
+		runWorkers(messages, workers, func(rabbitmq.Message) {
+			started <- struct{}{}
+			<-release
+			handled.Add(1)
+		})
+		close(done)
+	}()
+
+	// Every worker must be blocked in a handler at the same time
+	for i := 0; i < workers; i++ {
+		messages <- rabbitmq.Message{}
+	}
+	for i := 0; i < workers; i++ {
+		select {
+		case <-started:
+		case <-time.After(time.Second):
+			t.Fatalf("only %d of %d workers running concurrently", i, workers)
+		}
+	}
+
+	close(release)
+	go func() {
+		for range started {
+		}
+	}()
+	for i := 0; i < 6; i++ {
+		messages <- rabbitmq.Message{}
+	}
+	close(messages)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("runWorkers() did not return after the channel closed")
+	}
+	close(started)
+
+	if handled.Load() != workers+6 {
+		t.Errorf("handled = %d, want %d", handled.Load(), workers+6)
+	}
+}
+
+func TestRejectCountsMetrics(t *testing.T) {
+	rejected := func() float64 { return testutil.ToFloat64(metrics.EventsRejected.WithLabelValues(rejectMalformed)) }
+	deadLettered := func() float64 { return testutil.ToFloat64(metrics.EventsDeadLettered.WithLabelValues(rejectMalformed)) }
+
+	c := &EventConsumer{config: Config{QueueType: QueueTypeClassic}}
+	rejectedBefore, deadLetteredBefore := rejected(), deadLettered()
+	c.reject(rabbitmq.Message{}, rejectMalformed)
+	if got := rejected() - rejectedBefore; got != 1 {
+		t.Errorf("events rejected grew by %v, want 1", got)
+	}
+	if got := deadLettered() - deadLetteredBefore; got != 0 {
+		t.Errorf("events dead-lettered without an exchange grew by %v, want 0", got)
+	}
+
+	c.config.DeadLetterExchange = "notifications.dlx"
+	rejectedBefore, deadLetteredBefore = rejected(), deadLettered()
+	c.reject(rabbitmq.Message{}, rejectMalformed)
+	if got := rejected() - rejectedBefore; got != 1 {
+		t.Errorf("events rejected grew by %v, want 1", got)
+	}
+	if got := deadLettered() - deadLetteredBefore; got != 1 {
+		t.Errorf("events dead-lettered grew by %v, want 1", got)
+	}
+}
+
+// startedConsumer returns a consumer in the state Start leaves it in, with a consume loop that
+// returns once consumption is cancelled and one event in flight
+func startedConsumer() *EventConsumer {
+	c := &EventConsumer{log: logger.NewLogger(), done: make(chan struct{})}
+	consumeCtx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	c.processCtx, c.abortProcess = context.WithCancel(context.Background())
+	go func() {
+		defer close(c.done)
+		<-consumeCtx.Done()
+	}()
+
+	c.inFlight.Add(1)
+	c.inFlightN.Add(1)
+	return c
+}
+
+func TestShutdownWaitsForInFlightEvents(t *testing.T) {
+	c := startedConsumer()
+
+	result := make(chan error, 1)
+	go func() { result <- c.Shutdown(context.Background()) }()
+
+	select {
+	case err := <-result:
+		t.Fatalf("Shutdown() = %v before the in-flight event finished", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	c.inFlightN.Add(-1)
+	c.inFlight.Done()
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Errorf("Shutdown() error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Shutdown() did not return after the in-flight event finished")
+	}
+	if c.processCtx.Err() != nil {
+		t.Error("in-flight processing was cancelled by a shutdown within its deadline")
+	}
+}
+
+func TestShutdownCancelsProcessingAfterDeadline(t *testing.T) {
+	c := startedConsumer()
+	defer c.inFlight.Done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := c.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if c.processCtx.Err() == nil {
+		t.Error("in-flight processing was not cancelled after the drain deadline")
+	}
+}
+
+func TestShutdownBeforeStart(t *testing.T) {
+	c := &EventConsumer{log: logger.NewLogger()}
+	if err := c.Shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown() error = %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package consumer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+)
+
+// Reasons an event is dead-lettered, used as the metric label
+const (
+	rejectMalformed          = "malformed"
+	rejectUnsupportedVersion = "unsupported_version"
+	rejectInvalid            = "invalid"
+	rejectMaxDeliveries      = "max_deliveries"
+)
+
+var (
+	// ErrMalformedEvent is returned when a message body is not a valid event payload
+	ErrMalformedEvent = errors.New("malformed event")
+
+	// ErrUnsupportedSchemaVersion is returned for schema versions this consumer cannot decode
+	ErrUnsupportedSchemaVersion = errors.New("unsupported event schema version")
+
+	// ErrInvalidEvent is returned when a decoded event is missing required fields
+	ErrInvalidEvent = errors.New("invalid event")
+)
+
+// eventV1 is the legacy event payload without schema_version or event_id
+type eventV1 struct {
+	Type      domain.EventType `json:"type"`
+	TenantID  string           `json:"tenant_id"`
+	UserID    string           `json:"user_id,omitempty"`
+	Email     string           `json:"email,omitempty"`
+	Data      map[string]any   `json:"data,omitempty"`
+	Timestamp time.Time        `json:"timestamp"`
+}
+
+// DecodeEvent decodes a message body into the current Event representation
+// Legacy v1 payloads are upgraded in place; unknown versions are rejected.
+func DecodeEvent(body []byte) (*domain.Event, error) {
+	var header struct {
+		SchemaVersion *int `json:"schema_version"`
+	}
+	if err := json.Unmarshal(body, &header); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedEvent, err)
+	}
+
+	version := domain.EventSchemaV1
+	if header.SchemaVersion != nil {
+		version = *header.SchemaVersion
+	}
+
+	var event *domain.Event
+	switch version {
+	case domain.EventSchemaV1:
+		var legacy eventV1
+		if err := json.Unmarshal(body, &legacy); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrMalformedEvent, err)
+		}
+		event = &domain.Event{
+			Type:      legacy.Type,
+			TenantID:  legacy.TenantID,
+			UserID:    legacy.UserID,
+			Email:     legacy.Email,
+			Data:      legacy.Data,
+			Timestamp: legacy.Timestamp,
+		}
+	case domain.EventSchemaV2:
+		event = &domain.Event{}
+		if err := json.Unmarshal(body, event); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrMalformedEvent, err)
+		}
+	default:
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedSchemaVersion, version)
+	}
+
+	event.SchemaVersion = domain.CurrentEventSchemaVersion
+
+	if event.Type == "" || event.TenantID == "" {
+		return nil, fmt.Errorf("%w: type and tenant_id are required", ErrInvalidEvent)
+	}
+
+	return event, nil
+}
+
+// rejectReason maps a decode error to its metric label
+func rejectReason(err error) string {
+	switch {
+	case errors.Is(err, ErrUnsupportedSchemaVersion):
+		return rejectUnsupportedVersion
+	case errors.Is(err, ErrInvalidEvent):
+		return rejectInvalid
+	default:
+		return rejectMalformed
+	}
+}
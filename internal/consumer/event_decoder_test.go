@@ -0,0 +1,109 @@
+package consumer
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+)
+
+func TestDecodeEvent(t *testing.T) {
+	occurredAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		body    string
+		want    *domain.Event
+		wantErr error
+	}{
+		{
+			name: "v1 without schema version",
+			body: `{"type":"user.registered","tenant_id":"tenant-1","user_id":"u1","email":"a@example.com","data":{"name":"Ann"},"timestamp":"2024-03-01T12:00:00Z"}`,
+			want: &domain.Event{
+				SchemaVersion: domain.CurrentEventSchemaVersion,
+				Type:          domain.EventUserRegistered,
+				TenantID:      "tenant-1",
+				UserID:        "u1",
+				Email:         "a@example.com",
+				Data:          map[string]any{"name": "Ann"},
+				Timestamp:     occurredAt,
+			},
+		},
+		{
+			name: "v1 with explicit schema version",
+			body: `{"schema_version":1,"type":"tenant.created","tenant_id":"tenant-1","timestamp":"2024-03-01T12:00:00Z"}`,
+			want: &domain.Event{
+				SchemaVersion: domain.CurrentEventSchemaVersion,
+				Type:          domain.EventTenantCreated,
+				TenantID:      "tenant-1",
+				Timestamp:     occurredAt,
+			},
+		},
+		{
+			name: "v2",
+			body: `{"schema_version":2,"event_id":"evt-1","type":"user.password_reset","tenant_id":"tenant-1","email":"a@example.com","occurred_at":"2024-03-01T12:00:00Z"}`,
+			want: &domain.Event{
+				SchemaVersion: domain.EventSchemaV2,
+				ID:            "evt-1",
+				Type:          domain.EventUserPasswordReset,
+				TenantID:      "tenant-1",
+				Email:         "a@example.com",
+				Timestamp:     occurredAt,
+			},
+		},
+		{
+			name:    "unknown version",
+			body:    `{"schema_version":9,"type":"user.registered","tenant_id":"tenant-1"}`,
+			wantErr: ErrUnsupportedSchemaVersion,
+		},
+		{
+			name:    "not json",
+			body:    `not json`,
+			wantErr: ErrMalformedEvent,
+		},
+		{
+			name:    "non-numeric version",
+			body:    `{"schema_version":"2","type":"user.registered","tenant_id":"tenant-1"}`,
+			wantErr: ErrMalformedEvent,
+		},
+		{
+			name:    "missing tenant",
+			body:    `{"schema_version":2,"type":"user.registered"}`,
+			wantErr: ErrInvalidEvent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeEvent([]byte(tt.body))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("DecodeEvent() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DecodeEvent() error = %v", err)
+			}
+
+			if got.SchemaVersion != tt.want.SchemaVersion || got.ID != tt.want.ID || got.Type != tt.want.Type ||
+				got.TenantID != tt.want.TenantID || got.UserID != tt.want.UserID || got.Email != tt.want.Email ||
+				!got.Timestamp.Equal(tt.want.Timestamp) || len(got.Data) != len(tt.want.Data) {
+				t.Errorf("DecodeEvent() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRejectReason(t *testing.T) {
+	_, err := DecodeEvent([]byte(`{"schema_version":3}`))
+	if got := rejectReason(err); got != rejectUnsupportedVersion {
+		t.Errorf("rejectReason() = %q, want %q", got, rejectUnsupportedVersion)
+	}
+
+	_, err = DecodeEvent([]byte(`{`))
+	if got := rejectReason(err); got != rejectMalformed {
+		t.Errorf("rejectReason() = %q, want %q", got, rejectMalformed)
+	}
+}
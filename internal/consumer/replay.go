@@ -0,0 +1,178 @@
+package consumer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/metrics"
+	"github.com/vhvplatform/go-notification-service/internal/middleware"
+	"github.com/vhvplatform/go-notification-service/internal/pause"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// maxReplayLineBytes bounds one event in a newline-delimited source
+const maxReplayLineBytes = 1024 * 1024
+
+// EventProcessor processes a decoded event, as the consumer does for each delivery
+type EventProcessor interface {
+	ProcessEvent(ctx context.Context, event *domain.Event) error
+}
+
+// EventSource yields raw event payloads to replay
+// Next returns io.EOF once the source is exhausted.
+type EventSource interface {
+	Next(ctx context.Context) ([]byte, error)
+}
+
+// readerSource reads newline-delimited event payloads, skipping blank lines
+type readerSource struct {
+	scanner *bufio.Scanner
+}
+
+// NewReaderSource creates a source of newline-delimited JSON events, such as a file of
+// messages exported from the dead-letter queue
+func NewReaderSource(r io.Reader) EventSource {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxReplayLineBytes)
+	return &readerSource{scanner: scanner}
+}
+
+// Next returns the next non-blank line
+func (s *readerSource) Next(ctx context.Context) ([]byte, error) {
+	for s.scanner.Scan() {
+		if line := bytes.TrimSpace(s.scanner.Bytes()); len(line) > 0 {
+			return append([]byte(nil), line...), nil
+		}
+	}
+	if err := s.scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read events: %w", err)
+	}
+	return nil, io.EOF
+}
+
+// payloadSource yields a fixed list of event payloads
+type payloadSource struct {
+	payloads []json.RawMessage
+}
+
+// NewPayloadSource creates a source of the given event payloads
+func NewPayloadSource(payloads []json.RawMessage) EventSource {
+	return &payloadSource{payloads: payloads}
+}
+
+// Next returns the next payload
+func (s *payloadSource) Next(ctx context.Context) ([]byte, error) {
+	if len(s.payloads) == 0 {
+		return nil, io.EOF
+	}
+	payload := s.payloads[0]
+	s.payloads = s.payloads[1:]
+	return payload, nil
+}
+
+// ReplayOptions controls an event replay
+type ReplayOptions struct {
+	TenantID  string // When set, events for other tenants are rejected as invalid
+	DryRun    bool   // Decode and validate events without processing them
+	MaxEvents int    // Events read before the replay stops with an error; 0 is unlimited
+}
+
+// Replayer processes events from a source directly, bypassing RabbitMQ. Operators use it to
+// recover events the consumer mishandled and to test event handling.
+type Replayer struct {
+	processor EventProcessor
+	pauses    *pause.Controller // Optional; events aren't processed while email sends are paused
+	log       *logger.Logger
+}
+
+// NewReplayer creates an event replayer
+func NewReplayer(processor EventProcessor, pauses *pause.Controller, log *logger.Logger) *Replayer {
+	return &Replayer{
+		processor: processor,
+		pauses:    pauses,
+		log:       log,
+	}
+}
+
+// ReplayEvents decodes every event in source and processes it, reporting each event's outcome.
+// Events are decoded as consumed messages are, so the source may mix schema versions. A failed
+// event doesn't stop the replay; an error is only returned when the source can't be read or
+// ctx is done, along with the report so far.
+func (r *Replayer) ReplayEvents(ctx context.Context, source EventSource, opts ReplayOptions) (*domain.EventReplayReport, error) {
+	report := &domain.EventReplayReport{DryRun: opts.DryRun, Results: []domain.EventReplayResult{}}
+	for index := 0; ; index++ {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		payload, err := source.Next(ctx)
+		if err == io.EOF {
+			return report, nil
+		}
+		if err != nil {
+			return report, err
+		}
+		if opts.MaxEvents > 0 && index >= opts.MaxEvents {
+			return report, fmt.Errorf("replay is limited to %d events", opts.MaxEvents)
+		}
+
+		result := r.replay(ctx, index, payload, opts)
+		metrics.EventsReplayed.WithLabelValues(result.Status).Inc()
+		switch result.Status {
+		case domain.ReplayStatusProcessed:
+			report.Processed++
+		case domain.ReplayStatusFailed:
+			report.Failed++
+		case domain.ReplayStatusInvalid:
+			report.Invalid++
+		case domain.ReplayStatusPaused:
+			report.Paused++
+		}
+		report.Total++
+		report.Results = append(report.Results, result)
+	}
+}
+
+// replay decodes and, unless this is a dry run, processes one event
+func (r *Replayer) replay(ctx context.Context, index int, payload []byte, opts ReplayOptions) domain.EventReplayResult {
+	result := domain.EventReplayResult{Index: index}
+	event, err := DecodeEvent(payload)
+	if err != nil {
+		result.Status = domain.ReplayStatusInvalid
+		result.Error = err.Error()
+		return result
+	}
+	result.EventID = event.ID
+	result.Type = event.Type
+
+	if opts.TenantID != "" && event.TenantID != opts.TenantID {
+		result.Status = domain.ReplayStatusInvalid
+		result.Error = fmt.Sprintf("%s: event belongs to another tenant", ErrInvalidEvent)
+		return result
+	}
+	if opts.DryRun {
+		result.Status = domain.ReplayStatusValid
+		return result
+	}
+	// Events are sent as emails, and processing bypasses the send gate, so an event replayed
+	// while email is paused would be sent anyway; it is left for the operator to replay again
+	if r.pauses != nil && r.pauses.Paused(domain.NotificationTypeEmail, domain.NotificationPriorityNormal) {
+		result.Status = domain.ReplayStatusPaused
+		result.Error = "email sends are paused"
+		return result
+	}
+
+	if err := r.processor.ProcessEvent(middleware.WithTenantID(ctx, event.TenantID), event); err != nil {
+		r.log.Error("Failed to process replayed event", "error", err, "type", event.Type, "tenant_id", event.TenantID, "event_id", event.ID)
+		result.Status = domain.ReplayStatusFailed
+		result.Error = err.Error()
+		return result
+	}
+	r.log.Info("Replayed event", "type", event.Type, "tenant_id", event.TenantID, "event_id", event.ID)
+	result.Status = domain.ReplayStatusProcessed
+	return result
+}
@@ -0,0 +1,118 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/pause"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// recordingProcessor records processed events, failing those of type fail
+type recordingProcessor struct {
+	events []*domain.Event
+	fail   domain.EventType
+}
+
+func (p *recordingProcessor) ProcessEvent(ctx context.Context, event *domain.Event) error {
+	if event.Type == p.fail {
+		return errors.New("template missing")
+	}
+	p.events = append(p.events, event)
+	return nil
+}
+
+const replayEvents = `{"schema_version":2,"event_id":"e1","type":"user.registered","tenant_id":"tenant-1"}
+
+{"schema_version":2,"event_id":"e2","type":"user.password_reset","tenant_id":"tenant-1"}
+not json
+{"schema_version":2,"event_id":"e3","type":"user.registered","tenant_id":"tenant-2"}
+`
+
+func TestReplayEvents(t *testing.T) {
+	processor := &recordingProcessor{fail: domain.EventUserPasswordReset}
+	replayer := NewReplayer(processor, nil, logger.NewLogger())
+
+	report, err := replayer.ReplayEvents(context.Background(), NewReaderSource(strings.NewReader(replayEvents)), ReplayOptions{TenantID: "tenant-1"})
+	if err != nil {
+		t.Fatalf("ReplayEvents() error = %v", err)
+	}
+
+	if report.Total != 4 || report.Processed != 1 || report.Failed != 1 || report.Invalid != 2 {
+		t.Errorf("report = %+v, want 4 total, 1 processed, 1 failed, 2 invalid", report)
+	}
+	want := []string{domain.ReplayStatusProcessed, domain.ReplayStatusFailed, domain.ReplayStatusInvalid, domain.ReplayStatusInvalid}
+	for i, result := range report.Results {
+		if result.Index != i || result.Status != want[i] {
+			t.Errorf("result %d = %+v, want status %s", i, result, want[i])
+		}
+	}
+	if len(processor.events) != 1 || processor.events[0].ID != "e1" {
+		t.Errorf("processed events = %v, want only e1", processor.events)
+	}
+}
+
+func TestReplayEventsDryRun(t *testing.T) {
+	processor := &recordingProcessor{}
+	replayer := NewReplayer(processor, nil, logger.NewLogger())
+
+	payloads := []json.RawMessage{
+		json.RawMessage(`{"type":"user.registered","tenant_id":"tenant-1","timestamp":"2024-03-01T12:00:00Z"}`),
+		json.RawMessage(`{"schema_version":9,"type":"user.registered","tenant_id":"tenant-1"}`),
+	}
+	report, err := replayer.ReplayEvents(context.Background(), NewPayloadSource(payloads), ReplayOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("ReplayEvents() error = %v", err)
+	}
+
+	if !report.DryRun || report.Results[0].Status != domain.ReplayStatusValid || report.Results[1].Status != domain.ReplayStatusInvalid {
+		t.Errorf("report = %+v, want the v1 event valid and the v9 event invalid", report)
+	}
+	if len(processor.events) != 0 {
+		t.Errorf("dry run processed %d events", len(processor.events))
+	}
+}
+
+func TestReplayEventsMaxEvents(t *testing.T) {
+	processor := &recordingProcessor{}
+	replayer := NewReplayer(processor, nil, logger.NewLogger())
+
+	report, err := replayer.ReplayEvents(context.Background(), NewReaderSource(strings.NewReader(replayEvents)), ReplayOptions{MaxEvents: 2})
+	if err == nil {
+		t.Fatal("ReplayEvents() error = nil, want the event limit")
+	}
+	if report.Total != 2 {
+		t.Errorf("replayed %d events before the limit, want 2", report.Total)
+	}
+}
+
+func TestReplayEventsPausedEmail(t *testing.T) {
+	processor := &recordingProcessor{}
+	log := logger.NewLogger()
+	paused := domain.SendPause{Channels: []domain.NotificationType{domain.NotificationTypeEmail}}
+	pauses := pause.NewController(nil, pause.Config{}, paused, log)
+	replayer := NewReplayer(processor, pauses, log)
+
+	report, err := replayer.ReplayEvents(context.Background(), NewReaderSource(strings.NewReader(replayEvents)), ReplayOptions{TenantID: "tenant-1"})
+	if err != nil {
+		t.Fatalf("ReplayEvents() error = %v", err)
+	}
+	if report.Paused != 2 || report.Processed != 0 || report.Results[0].Status != domain.ReplayStatusPaused {
+		t.Errorf("report = %+v, want both tenant-1 events paused", report)
+	}
+	if len(processor.events) != 0 {
+		t.Errorf("processed %d events while email is paused", len(processor.events))
+	}
+
+	if err := pauses.Resume(context.Background(), []domain.NotificationType{domain.NotificationTypeEmail}, "ops"); err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+	report, err = replayer.ReplayEvents(context.Background(), NewReaderSource(strings.NewReader(replayEvents)), ReplayOptions{TenantID: "tenant-1"})
+	if err != nil || report.Processed != 2 {
+		t.Errorf("report after resume = %+v, %v; want both tenant-1 events processed", report, err)
+	}
+}
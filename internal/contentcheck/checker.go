@@ -0,0 +1,328 @@
+package contentcheck
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/metrics"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// ErrLikelySpam is returned when an enforced tenant's marketing email scores at or above the threshold
+var ErrLikelySpam = errors.New("content likely to be marked as spam")
+
+// DefaultThreshold is the score at which an email is considered likely spam when none is configured
+const DefaultThreshold = 5.0
+
+// DefaultMarketingCategories are the categories held to marketing rules unless configured otherwise
+var DefaultMarketingCategories = []string{"marketing", "newsletter", "promotional"}
+
+// Rules and what each adds to the score
+const (
+	RuleSubjectCaps        = "subject_caps"
+	RuleBodyCaps           = "body_caps"
+	RuleExclamation        = "exclamation"
+	RuleSpamPhrase         = "spam_phrase"
+	RuleTooManyLinks       = "too_many_links"
+	RuleIPLink             = "ip_link"
+	RuleShortenedLink      = "shortened_link"
+	RuleScriptLink         = "script_link"
+	RuleMismatchedLink     = "mismatched_link"
+	RuleMalformedLink      = "malformed_link"
+	RuleMissingUnsubscribe = "missing_unsubscribe"
+)
+
+var ruleScores = map[string]float64{
+	RuleSubjectCaps:        1.5,
+	RuleBodyCaps:           1.5,
+	RuleExclamation:        1,
+	RuleSpamPhrase:         1, // Per phrase, up to maxPhraseScore
+	RuleTooManyLinks:       1.5,
+	RuleIPLink:             2,
+	RuleShortenedLink:      1,
+	RuleScriptLink:         3,
+	RuleMismatchedLink:     2,
+	RuleMalformedLink:      1,
+	RuleMissingUnsubscribe: 3,
+}
+
+const (
+	maxPhraseScore  = 3.0
+	maxLinks        = 15
+	subjectCapsRate = 0.5 // Share of uppercase letters above which a subject is shouting
+	bodyCapsRate    = 0.3
+	minSubjectCaps  = 8 // Letters needed before the caps rate is judged
+	minBodyCaps     = 50
+)
+
+// spamPhrases are phrases common in spam, matched case-insensitively
+var spamPhrases = []string{
+	"100% free", "act now", "apply now", "cash bonus", "click here", "double your",
+	"earn money", "free money", "guaranteed", "limited time offer", "no credit check",
+	"risk-free", "urgent response", "winner", "you have been selected",
+}
+
+// shorteners are URL shortening hosts, which hide where a link goes
+var shorteners = map[string]bool{
+	"bit.ly": true, "goo.gl": true, "is.gd": true, "ow.ly": true, "t.co": true,
+	"tinyurl.com": true, "buff.ly": true, "rebrand.ly": true, "cutt.ly": true,
+}
+
+var (
+	anchorPattern  = regexp.MustCompile(`(?is)<a\s[^>]*href\s*=\s*(?:"([^"]*)"|'([^']*)')[^>]*>(.*?)</a>`)
+	urlPattern     = regexp.MustCompile(`(?i)\bhttps?://[^\s<>"']+`)
+	tagPattern     = regexp.MustCompile(`(?s)<[^>]*>`)
+	displayPattern = regexp.MustCompile(`(?i)^\s*(?:https?://|www\.)\S+\s*$`)
+)
+
+// Config holds content check settings
+type Config struct {
+	Threshold           float64  // Score at or above which an email is likely spam
+	MarketingCategories []string // Categories that need an unsubscribe header and can be rejected
+	EnforcedTenants     []string // Tenants whose marketing emails at or above Threshold are rejected; others are only warned
+}
+
+// SpamError describes an email rejected for likely spam content
+type SpamError struct {
+	Report *domain.ContentReport
+}
+
+// Error implements the error interface
+func (e *SpamError) Error() string {
+	rules := make([]string, 0, len(e.Report.Warnings))
+	for _, warning := range e.Report.Warnings {
+		rules = append(rules, warning.Rule)
+	}
+	return fmt.Sprintf("%s: scored %.1f, threshold %.1f (%s)", ErrLikelySpam, e.Report.Score, e.Report.Threshold, strings.Join(rules, ", "))
+}
+
+// Unwrap allows errors.Is(err, ErrLikelySpam)
+func (e *SpamError) Unwrap() error {
+	return ErrLikelySpam
+}
+
+// AsSpamError returns the *SpamError in err's chain, if any
+func AsSpamError(err error) (*SpamError, bool) {
+	var spamErr *SpamError
+	ok := errors.As(err, &spamErr)
+	return spamErr, ok
+}
+
+// Checker scores email content for traits that hurt deliverability: shouting, spammy
+// phrases, many or suspicious links, and marketing mail without an unsubscribe header.
+// Scores are advisory unless the tenant is enforced and the email is marketing.
+type Checker struct {
+	threshold  float64
+	categories map[string]bool
+	enforced   map[string]bool
+	log        *logger.Logger
+}
+
+// NewChecker creates a new content checker
+func NewChecker(config Config, log *logger.Logger) *Checker {
+	threshold := config.Threshold
+	if threshold <= 0 {
+		threshold = DefaultThreshold
+	}
+	categories := config.MarketingCategories
+	if categories == nil {
+		categories = DefaultMarketingCategories
+	}
+
+	c := &Checker{
+		threshold:  threshold,
+		categories: make(map[string]bool, len(categories)),
+		enforced:   make(map[string]bool, len(config.EnforcedTenants)),
+		log:        log,
+	}
+	for _, category := range categories {
+		c.categories[strings.ToLower(strings.TrimSpace(category))] = true
+	}
+	for _, tenantID := range config.EnforcedTenants {
+		c.enforced[tenantID] = true
+	}
+	return c
+}
+
+// Check analyses an email and returns a *SpamError if it must be rejected
+func (c *Checker) Check(tenantID string, req *domain.SendEmailRequest) (*domain.ContentReport, error) {
+	report := c.Analyze(tenantID, req)
+	switch {
+	case report.Blocked:
+		metrics.ContentChecks.WithLabelValues("blocked").Inc()
+		return report, &SpamError{Report: report}
+	case report.Score >= report.Threshold:
+		metrics.ContentChecks.WithLabelValues("warned").Inc()
+		c.log.Warn("Email content is likely spam", "tenant_id", tenantID, "score", report.Score, "category", req.Category, "warnings", len(report.Warnings))
+	default:
+		metrics.ContentChecks.WithLabelValues("passed").Inc()
+	}
+	return report, nil
+}
+
+// Analyze scores an email's subject, body and headers without rejecting it
+func (c *Checker) Analyze(tenantID string, req *domain.SendEmailRequest) *domain.ContentReport {
+	report := &domain.ContentReport{
+		Threshold: c.threshold,
+		Marketing: c.categories[strings.ToLower(strings.TrimSpace(req.Category))],
+		Enforced:  c.enforced[tenantID],
+		Warnings:  []domain.ContentWarning{},
+	}
+	warn := func(rule string, score float64, format string, args ...any) {
+		report.Warnings = append(report.Warnings, domain.ContentWarning{Rule: rule, Message: fmt.Sprintf(format, args...), Score: score})
+		report.Score += score
+	}
+
+	text := req.Body
+	if req.IsHTML {
+		text = tagPattern.ReplaceAllString(req.Body, " ")
+	}
+
+	if rate, letters := capsRate(req.Subject); letters >= minSubjectCaps && rate > subjectCapsRate {
+		warn(RuleSubjectCaps, ruleScores[RuleSubjectCaps], "%.0f%% of the subject is capital letters", rate*100)
+	}
+	if rate, letters := capsRate(text); letters >= minBodyCaps && rate > bodyCapsRate {
+		warn(RuleBodyCaps, ruleScores[RuleBodyCaps], "%.0f%% of the body is capital letters", rate*100)
+	}
+	if strings.Count(req.Subject, "!") >= 3 || strings.Contains(req.Subject, "!!") {
+		warn(RuleExclamation, ruleScores[RuleExclamation], "subject has repeated exclamation marks")
+	}
+
+	content := strings.ToLower(req.Subject + "\n" + text)
+	var phraseScore float64
+	for _, phrase := range spamPhrases {
+		if strings.Contains(content, phrase) && phraseScore < maxPhraseScore {
+			phraseScore += ruleScores[RuleSpamPhrase]
+			warn(RuleSpamPhrase, ruleScores[RuleSpamPhrase], "contains %q", phrase)
+		}
+	}
+
+	c.checkLinks(req, warn)
+
+	if report.Marketing && !hasHeader(req.Headers, "List-Unsubscribe") {
+		warn(RuleMissingUnsubscribe, ruleScores[RuleMissingUnsubscribe], "marketing email has no List-Unsubscribe header")
+	}
+
+	report.Blocked = report.Enforced && report.Marketing && report.Score >= report.Threshold
+	return report
+}
+
+// link is a link found in an email body, with its visible text for HTML anchors
+type link struct {
+	href    string
+	display string
+}
+
+// checkLinks warns once per rule about the body's links
+func (c *Checker) checkLinks(req *domain.SendEmailRequest, warn func(rule string, score float64, format string, args ...any)) {
+	var links []link
+	if req.IsHTML {
+		for _, match := range anchorPattern.FindAllStringSubmatch(req.Body, -1) {
+			href := match[1]
+			if href == "" {
+				href = match[2]
+			}
+			links = append(links, link{href: href, display: strings.TrimSpace(tagPattern.ReplaceAllString(match[3], ""))})
+		}
+	} else {
+		for _, href := range urlPattern.FindAllString(req.Body, -1) {
+			links = append(links, link{href: href})
+		}
+	}
+
+	if len(links) > maxLinks {
+		warn(RuleTooManyLinks, ruleScores[RuleTooManyLinks], "body has %d links, more than %d", len(links), maxLinks)
+	}
+
+	found := make(map[string]string)
+	for _, l := range links {
+		href := strings.TrimSpace(l.href)
+		if strings.HasPrefix(href, "#") || strings.HasPrefix(strings.ToLower(href), "mailto:") || strings.HasPrefix(strings.ToLower(href), "tel:") {
+			continue
+		}
+
+		parsed, err := url.Parse(href)
+		if err != nil || strings.ContainsAny(href, " \t\r\n<>\"") {
+			found[RuleMalformedLink] = href
+			continue
+		}
+		scheme := strings.ToLower(parsed.Scheme)
+		if scheme == "javascript" || scheme == "data" || scheme == "vbscript" {
+			found[RuleScriptLink] = href
+			continue
+		}
+		host := strings.ToLower(parsed.Hostname())
+		if net.ParseIP(host) != nil {
+			found[RuleIPLink] = href
+		}
+		if shorteners[strings.TrimPrefix(host, "www.")] {
+			found[RuleShortenedLink] = href
+		}
+		if displayPattern.MatchString(l.display) && host != "" {
+			display := l.display
+			if !strings.Contains(display, "://") {
+				display = "http://" + display
+			}
+			if shown, err := url.Parse(strings.TrimSpace(display)); err == nil && !sameSite(shown.Hostname(), host) {
+				found[RuleMismatchedLink] = href
+			}
+		}
+	}
+
+	for _, rule := range []string{RuleMalformedLink, RuleScriptLink, RuleIPLink, RuleShortenedLink, RuleMismatchedLink} {
+		href, ok := found[rule]
+		if !ok {
+			continue
+		}
+		switch rule {
+		case RuleMalformedLink:
+			warn(rule, ruleScores[rule], "link %q is not a valid, escaped URL", href)
+		case RuleScriptLink:
+			warn(rule, ruleScores[rule], "link %q runs a script", href)
+		case RuleIPLink:
+			warn(rule, ruleScores[rule], "link %q points at an IP address", href)
+		case RuleShortenedLink:
+			warn(rule, ruleScores[rule], "link %q uses a URL shortener", href)
+		case RuleMismatchedLink:
+			warn(rule, ruleScores[rule], "link %q shows a different address than it opens", href)
+		}
+	}
+}
+
+// capsRate returns the share of letters in s that are uppercase, and how many letters it has
+func capsRate(s string) (float64, int) {
+	var letters, upper int
+	for _, r := range s {
+		if unicode.IsLetter(r) {
+			letters++
+			if unicode.IsUpper(r) {
+				upper++
+			}
+		}
+	}
+	if letters == 0 {
+		return 0, 0
+	}
+	return float64(upper) / float64(letters), letters
+}
+
+// sameSite reports whether two hosts are the same, ignoring a leading www.
+func sameSite(a, b string) bool {
+	return strings.TrimPrefix(strings.ToLower(a), "www.") == strings.TrimPrefix(strings.ToLower(b), "www.")
+}
+
+// hasHeader reports whether headers has name, compared case-insensitively, with a value
+func hasHeader(headers map[string]string, name string) bool {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) && strings.TrimSpace(value) != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package contentcheck
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+func rules(report *domain.ContentReport) map[string]bool {
+	found := make(map[string]bool, len(report.Warnings))
+	for _, warning := range report.Warnings {
+		found[warning.Rule] = true
+	}
+	return found
+}
+
+func TestAnalyze(t *testing.T) {
+	checker := NewChecker(Config{}, logger.NewLogger())
+
+	tests := []struct {
+		name string
+		req  domain.SendEmailRequest
+		want []string
+	}{
+		{
+			name: "clean transactional email",
+			req:  domain.SendEmailRequest{Subject: "Your receipt", Body: "Thanks for your order. View it at https://shop.example.com/orders/42"},
+		},
+		{
+			name: "shouting subject",
+			req:  domain.SendEmailRequest{Subject: "LAST CHANCE TO SAVE!!!", Body: "Sale ends today."},
+			want: []string{RuleSubjectCaps, RuleExclamation},
+		},
+		{
+			name: "spam phrases",
+			req:  domain.SendEmailRequest{Subject: "You're a winner", Body: "Act now, it's 100% free. Click here."},
+			want: []string{RuleSpamPhrase},
+		},
+		{
+			name: "suspicious links",
+			req: domain.SendEmailRequest{
+				Subject: "Account notice",
+				IsHTML:  true,
+				Body: `<a href="http://192.0.2.10/login">Sign in</a> <a href="https://bit.ly/x">here</a>` +
+					` <a href="https://evil.example.net/pay">https://bank.example.com</a> <a href="javascript:alert(1)">open</a>` +
+					` <a href="https://example.com/a path">docs</a>`,
+			},
+			want: []string{RuleIPLink, RuleShortenedLink, RuleMismatchedLink, RuleScriptLink, RuleMalformedLink},
+		},
+		{
+			name: "marketing without unsubscribe",
+			req:  domain.SendEmailRequest{Subject: "Spring collection", Body: "New arrivals are in.", Category: "Newsletter"},
+			want: []string{RuleMissingUnsubscribe},
+		},
+		{
+			name: "marketing with unsubscribe",
+			req: domain.SendEmailRequest{
+				Subject:  "Spring collection",
+				Body:     "New arrivals are in.",
+				Category: "newsletter",
+				Headers:  map[string]string{"list-unsubscribe": "<https://shop.example.com/unsubscribe>"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := checker.Analyze("tenant-1", &tt.req)
+			found := rules(report)
+			for _, rule := range tt.want {
+				if !found[rule] {
+					t.Errorf("Analyze() warnings %+v, missing %s", report.Warnings, rule)
+				}
+			}
+			if len(tt.want) == 0 && len(report.Warnings) > 0 {
+				t.Errorf("Analyze() warnings %+v, want none", report.Warnings)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	checker := NewChecker(Config{Threshold: 4, EnforcedTenants: []string{"strict"}}, logger.NewLogger())
+	spammy := func(category string) *domain.SendEmailRequest {
+		return &domain.SendEmailRequest{Subject: "FREE MONEY INSIDE!!!", Body: "Click here to earn money", Category: category}
+	}
+
+	report, err := checker.Check("strict", spammy("marketing"))
+	spamErr, ok := AsSpamError(err)
+	if !ok || !errors.Is(err, ErrLikelySpam) || !report.Blocked || spamErr.Report.Score < 4 {
+		t.Fatalf("Check() enforced marketing = %+v, %v", report, err)
+	}
+
+	// Other tenants and non-marketing categories are only warned
+	if report, err := checker.Check("relaxed", spammy("marketing")); err != nil || report.Blocked || report.Score < 4 {
+		t.Errorf("Check() unenforced tenant = %+v, %v", report, err)
+	}
+	if report, err := checker.Check("strict", spammy("billing")); err != nil || report.Blocked {
+		t.Errorf("Check() transactional = %+v, %v", report, err)
+	}
+}
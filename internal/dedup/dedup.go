@@ -0,0 +1,129 @@
+package dedup
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/smtp"
+)
+
+// ErrDuplicate is returned when an identical notification was sent to the same recipients within the window
+var ErrDuplicate = errors.New("duplicate notification")
+
+// Store interface for short-lived content fingerprints
+// Claim records the fingerprint until expiresAt, returning false if an unexpired claim exists.
+type Store interface {
+	Claim(ctx context.Context, tenantID, fingerprint string, expiresAt time.Time) (bool, error)
+	Release(ctx context.Context, tenantID, fingerprint string) error
+}
+
+// Config selects the tenants whose sends are deduplicated and for how long
+type Config struct {
+	DefaultWindow time.Duration            // Window for tenants not in TenantWindows; 0 disables deduplication
+	TenantWindows map[string]time.Duration // Per-tenant windows; 0 disables deduplication for the tenant
+}
+
+// Window returns the deduplication window for tenantID, or 0 when the tenant hasn't opted in
+func (c Config) Window(tenantID string) time.Duration {
+	if window, ok := c.TenantWindows[tenantID]; ok {
+		return window
+	}
+	return c.DefaultWindow
+}
+
+// DuplicateError describes a send suppressed because an identical one was sent recently
+type DuplicateError struct {
+	Channel domain.NotificationType
+	Window  time.Duration
+}
+
+// Error implements the error interface
+func (e *DuplicateError) Error() string {
+	return fmt.Sprintf("an identical %s was sent to the same recipients within the last %s", e.Channel, e.Window)
+}
+
+// Unwrap allows errors.Is(err, ErrDuplicate)
+func (e *DuplicateError) Unwrap() error {
+	return ErrDuplicate
+}
+
+// AsDuplicate returns the *DuplicateError in err's chain, if any
+func AsDuplicate(err error) (*DuplicateError, bool) {
+	var duplicate *DuplicateError
+	ok := errors.As(err, &duplicate)
+	return duplicate, ok
+}
+
+// EmailFingerprint hashes an email's recipients and content
+// Recipients are normalized and sorted, so reordering or re-casing domains gives the same hash.
+func EmailFingerprint(req *domain.SendEmailRequest) string {
+	to, cc, bcc := smtp.DedupeRecipients(req.To, req.CC, req.BCC)
+	recipients := make([]string, 0, len(to)+len(cc)+len(bcc))
+	recipients = append(recipients, to...)
+	recipients = append(recipients, cc...)
+	recipients = append(recipients, bcc...)
+	sort.Strings(recipients)
+
+	h := newHasher(domain.NotificationTypeEmail)
+	h.field(strings.Join(recipients, ","))
+	h.field(req.Subject)
+	h.field(req.Body)
+	h.field(fmt.Sprint(req.IsHTML))
+	h.field(req.AMPHTML)
+	h.field(req.TemplateID)
+	h.variables(req.Variables)
+	for _, attachment := range req.Attachments {
+		sum := sha256.Sum256(attachment.Content)
+		h.field(attachment.Filename + ":" + hex.EncodeToString(sum[:]))
+	}
+	return h.sum()
+}
+
+// SMSFingerprint hashes an SMS's recipient and content
+func SMSFingerprint(req *domain.SendSMSRequest) string {
+	h := newHasher(domain.NotificationTypeSMS)
+	h.field(strings.TrimSpace(req.To))
+	h.field(req.Message)
+	h.field(req.TemplateID)
+	h.variables(req.Variables)
+	return h.sum()
+}
+
+// hasher writes length-prefixed fields so that no two field lists hash the same input
+type hasher struct {
+	b strings.Builder
+}
+
+func newHasher(channel domain.NotificationType) *hasher {
+	h := &hasher{}
+	h.field(string(channel))
+	return h
+}
+
+func (h *hasher) field(value string) {
+	fmt.Fprintf(&h.b, "%d:%s;", len(value), value)
+}
+
+func (h *hasher) variables(vars map[string]string) {
+	keys := make([]string, 0, len(vars))
+	for key := range vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		h.field(key)
+		h.field(vars[key])
+	}
+}
+
+func (h *hasher) sum() string {
+	sum := sha256.Sum256([]byte(h.b.String()))
+	return hex.EncodeToString(sum[:])
+}
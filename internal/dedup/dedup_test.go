@@ -0,0 +1,112 @@
+package dedup
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+type memoryStore struct {
+	claims map[string]time.Time
+}
+
+func (s *memoryStore) Claim(ctx context.Context, tenantID, fingerprint string, expiresAt time.Time) (bool, error) {
+	key := tenantID + ":" + fingerprint
+	if until, ok := s.claims[key]; ok && until.After(time.Now()) {
+		return false, nil
+	}
+	s.claims[key] = expiresAt
+	return true, nil
+}
+
+func (s *memoryStore) Release(ctx context.Context, tenantID, fingerprint string) error {
+	delete(s.claims, tenantID+":"+fingerprint)
+	return nil
+}
+
+type countingSender struct {
+	emails int
+	sms    int
+	err    error
+}
+
+func (s *countingSender) SendEmail(ctx context.Context, req *domain.SendEmailRequest) error {
+	s.emails++
+	return s.err
+}
+
+func (s *countingSender) SendSMS(ctx context.Context, req *domain.SendSMSRequest) error {
+	s.sms++
+	return s.err
+}
+
+func (s *countingSender) SendWebhook(ctx context.Context, req *domain.SendWebhookRequest) error {
+	return s.err
+}
+
+func TestEmailFingerprint(t *testing.T) {
+	base := domain.SendEmailRequest{To: []string{"a@example.com", "b@Example.COM"}, Subject: "Hi", Body: "Hello"}
+	reordered := base
+	reordered.To = []string{"b@example.com", "a@example.com"}
+	if EmailFingerprint(&base) != EmailFingerprint(&reordered) {
+		t.Error("reordered recipients have different fingerprints")
+	}
+
+	for name, change := range map[string]func(*domain.SendEmailRequest){
+		"subject":   func(r *domain.SendEmailRequest) { r.Subject = "Hi!" },
+		"body":      func(r *domain.SendEmailRequest) { r.Body = "Hello!" },
+		"recipient": func(r *domain.SendEmailRequest) { r.To = []string{"a@example.com"} },
+		"variables": func(r *domain.SendEmailRequest) { r.Variables = map[string]string{"name": "Ann"} },
+		"boundary":  func(r *domain.SendEmailRequest) { r.Subject, r.Body = "HiHello", "" },
+	} {
+		changed := base
+		change(&changed)
+		if EmailFingerprint(&base) == EmailFingerprint(&changed) {
+			t.Errorf("changing %s kept the same fingerprint", name)
+		}
+	}
+}
+
+func TestDedupSender(t *testing.T) {
+	next := &countingSender{}
+	sender := NewSender(&memoryStore{claims: map[string]time.Time{}}, Config{
+		TenantWindows: map[string]time.Duration{"tenant-a": time.Minute},
+	}, next, logger.NewLogger())
+	ctx := context.Background()
+
+	sms := &domain.SendSMSRequest{TenantID: "tenant-a", To: "+15550100", Message: "code 1234"}
+	if err := sender.SendSMS(ctx, sms); err != nil {
+		t.Fatalf("first SendSMS() error = %v", err)
+	}
+	err := sender.SendSMS(ctx, sms)
+	if duplicate, ok := AsDuplicate(err); !ok || duplicate.Window != time.Minute {
+		t.Fatalf("second SendSMS() error = %v, want DuplicateError", err)
+	}
+	if next.sms != 1 {
+		t.Errorf("sent %d SMS, want 1", next.sms)
+	}
+
+	// Tenants that haven't opted in are never deduplicated
+	other := &domain.SendSMSRequest{TenantID: "tenant-b", To: "+15550100", Message: "code 1234"}
+	sender.SendSMS(ctx, other)
+	sender.SendSMS(ctx, other)
+	if next.sms != 3 {
+		t.Errorf("sent %d SMS, want 3", next.sms)
+	}
+
+	// A failed send releases its claim so the caller can retry
+	next.err = errors.New("smtp down")
+	email := &domain.SendEmailRequest{TenantID: "tenant-a", To: []string{"a@example.com"}, Subject: "Hi", Body: "Hello"}
+	sender.SendEmail(ctx, email)
+	next.err = nil
+	if err := sender.SendEmail(ctx, email); err != nil {
+		t.Errorf("SendEmail() after a failed send error = %v, want nil", err)
+	}
+	if next.emails != 2 {
+		t.Errorf("sent %d emails, want 2", next.emails)
+	}
+}
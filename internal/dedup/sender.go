@@ -0,0 +1,80 @@
+package dedup
+
+import (
+	"context"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/metrics"
+	"github.com/vhvplatform/go-notification-service/internal/sender"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// DedupSender suppresses emails and SMS identical to one sent to the same recipients within
+// the tenant's window before passing them to next. Webhooks are not deduplicated.
+type DedupSender struct {
+	store  Store
+	config Config
+	next   sender.Sender
+	log    *logger.Logger
+}
+
+// NewSender creates a sender that suppresses repeated identical sends
+func NewSender(store Store, config Config, next sender.Sender, log *logger.Logger) *DedupSender {
+	return &DedupSender{
+		store:  store,
+		config: config,
+		next:   next,
+		log:    log,
+	}
+}
+
+// SendEmail sends an email unless an identical one was sent within the window
+func (s *DedupSender) SendEmail(ctx context.Context, req *domain.SendEmailRequest) error {
+	if s.config.Window(req.TenantID) <= 0 {
+		return s.next.SendEmail(ctx, req)
+	}
+	return s.do(ctx, req.TenantID, domain.NotificationTypeEmail, EmailFingerprint(req), func() error {
+		return s.next.SendEmail(ctx, req)
+	})
+}
+
+// SendSMS sends an SMS unless an identical one was sent within the window
+func (s *DedupSender) SendSMS(ctx context.Context, req *domain.SendSMSRequest) error {
+	if s.config.Window(req.TenantID) <= 0 {
+		return s.next.SendSMS(ctx, req)
+	}
+	return s.do(ctx, req.TenantID, domain.NotificationTypeSMS, SMSFingerprint(req), func() error {
+		return s.next.SendSMS(ctx, req)
+	})
+}
+
+// SendWebhook sends a webhook
+func (s *DedupSender) SendWebhook(ctx context.Context, req *domain.SendWebhookRequest) error {
+	return s.next.SendWebhook(ctx, req)
+}
+
+// do claims fingerprint for the tenant's window and sends
+// A failed send releases the claim so the caller can retry at once. If the store is unavailable
+// the send goes ahead: deduplication is a safety net, not a reason to drop notifications.
+func (s *DedupSender) do(ctx context.Context, tenantID string, channel domain.NotificationType, fingerprint string, send func() error) error {
+	window := s.config.Window(tenantID)
+	claimed, err := s.store.Claim(ctx, tenantID, fingerprint, time.Now().Add(window))
+	if err != nil {
+		s.log.Error("Failed to check for duplicate notification, sending anyway", "error", err, "tenant_id", tenantID, "type", channel)
+		return send()
+	}
+	if !claimed {
+		metrics.NotificationsDeduplicated.WithLabelValues(string(channel), metrics.TenantLabel(tenantID)).Inc()
+		s.log.Debug("Suppressed duplicate notification", "tenant_id", tenantID, "type", channel, "window", window, "fingerprint", fingerprint)
+		return &DuplicateError{Channel: channel, Window: window}
+	}
+
+	if err := send(); err != nil {
+		if releaseErr := s.store.Release(context.WithoutCancel(ctx), tenantID, fingerprint); releaseErr != nil {
+			s.log.Error("Failed to release duplicate check after failed send", "error", releaseErr, "tenant_id", tenantID, "type", channel)
+		}
+		return err
+	}
+	return nil
+}
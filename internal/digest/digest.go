@@ -0,0 +1,171 @@
+package digest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/metrics"
+	"github.com/vhvplatform/go-notification-service/internal/sender"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrInvalidRequest is returned when an email can't be collected into a digest
+var ErrInvalidRequest = errors.New("invalid digest request")
+
+// Store interface for staging digests until they are sent
+type Store interface {
+	Stage(ctx context.Context, digest *domain.Digest, item domain.DigestItem, maxItems int) (*domain.Digest, error)
+	ClaimDue(ctx context.Context, now time.Time, staleAfter time.Duration) (*domain.Digest, error)
+	Reschedule(ctx context.Context, id primitive.ObjectID, dueAt time.Time, failure string) error
+	Complete(ctx context.Context, id primitive.ObjectID, status domain.DigestStatus, failure string) error
+}
+
+// TemplateStore interface for loading digest templates
+type TemplateStore interface {
+	FindByID(ctx context.Context, id string, tenantID string) (*domain.Template, error)
+}
+
+// Config holds digest settings
+type Config struct {
+	MaxItems     int           // Items per digest; a full digest is sent at once
+	PollInterval time.Duration // How often due digests are looked for
+}
+
+// RequestError describes an email that can't be collected into a digest
+type RequestError struct {
+	Reason string
+}
+
+// Error implements the error interface
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrInvalidRequest, e.Reason)
+}
+
+// Unwrap allows errors.Is(err, ErrInvalidRequest)
+func (e *RequestError) Unwrap() error {
+	return ErrInvalidRequest
+}
+
+// AsRequestError returns the *RequestError in err's chain, if any
+func AsRequestError(err error) (*RequestError, bool) {
+	var requestErr *RequestError
+	ok := errors.As(err, &requestErr)
+	return requestErr, ok
+}
+
+// DigestSender collects emails marked for a digest into each recipient's digest instead of
+// passing them to next. Other sends pass straight through.
+type DigestSender struct {
+	store     Store
+	templates TemplateStore
+	config    Config
+	next      sender.Sender
+	log       *logger.Logger
+}
+
+// NewSender creates a sender that collects digest emails
+func NewSender(store Store, templates TemplateStore, config Config, next sender.Sender, log *logger.Logger) *DigestSender {
+	return &DigestSender{
+		store:     store,
+		templates: templates,
+		config:    config,
+		next:      next,
+		log:       log,
+	}
+}
+
+// SendEmail adds the email to each recipient's digest if it is marked for one, or sends it
+func (s *DigestSender) SendEmail(ctx context.Context, req *domain.SendEmailRequest) error {
+	if req.Digest == nil {
+		return s.next.SendEmail(ctx, req)
+	}
+	if err := s.check(ctx, req); err != nil {
+		return err
+	}
+
+	item := domain.DigestItem{
+		Subject:   req.Subject,
+		Body:      req.Body,
+		Variables: req.Variables,
+		Category:  req.Category,
+		Metadata:  req.Metadata,
+		CreatedAt: time.Now(),
+	}
+	for _, recipient := range req.To {
+		digest := &domain.Digest{
+			TenantID:   req.TenantID,
+			Recipient:  strings.ToLower(strings.TrimSpace(recipient)),
+			Key:        req.Digest.Key,
+			UserID:     req.Digest.UserID,
+			TemplateID: req.Digest.TemplateID,
+			Category:   req.Category,
+			Interval:   req.Digest.Interval,
+			Threshold:  req.Digest.Threshold,
+		}
+		staged, err := s.store.Stage(ctx, digest, item, s.config.MaxItems)
+		if err != nil {
+			return fmt.Errorf("failed to add email to digest: %w", err)
+		}
+		metrics.NotificationsDigested.WithLabelValues(metrics.TenantLabel(req.TenantID)).Inc()
+		s.log.Debug("Added email to digest", "tenant_id", req.TenantID, "digest_id", staged.ID.Hex(), "key", staged.Key, "items", staged.ItemCount, "due_at", staged.DueAt)
+	}
+	return nil
+}
+
+// SendSMS sends an SMS
+func (s *DigestSender) SendSMS(ctx context.Context, req *domain.SendSMSRequest) error {
+	return s.next.SendSMS(ctx, req)
+}
+
+// SendWebhook sends a webhook
+func (s *DigestSender) SendWebhook(ctx context.Context, req *domain.SendWebhookRequest) error {
+	return s.next.SendWebhook(ctx, req)
+}
+
+// check rejects digest emails that can't be combined with others
+// Requests accepted over the API were validated already; held and scheduled ones are checked again here.
+func (s *DigestSender) check(ctx context.Context, req *domain.SendEmailRequest) error {
+	options := req.Digest
+	switch {
+	case options.Key == "":
+		return &RequestError{Reason: "key is required"}
+	case options.Interval.Duration() == 0:
+		return &RequestError{Reason: fmt.Sprintf("interval must be hourly or daily, got %q", options.Interval)}
+	case options.Threshold < 0:
+		return &RequestError{Reason: "threshold must not be negative"}
+	case req.RecipientListID != "":
+		return &RequestError{Reason: "recipient lists cannot be sent as a digest"}
+	case len(req.CC) > 0 || len(req.BCC) > 0:
+		return &RequestError{Reason: "cc and bcc cannot be sent as a digest"}
+	case len(req.Attachments) > 0:
+		return &RequestError{Reason: "attachments cannot be sent as a digest"}
+	case req.SendAtLocal != nil:
+		return &RequestError{Reason: "send_at_local cannot be combined with a digest"}
+	case req.ABTest != nil:
+		return &RequestError{Reason: "ab_test cannot be combined with a digest"}
+	case req.TemplateID != "":
+		return &RequestError{Reason: "set digest.template_id to render the digest; item templates are not supported"}
+	case options.UserID != "" && len(req.To) != 1:
+		return &RequestError{Reason: "user_id requires exactly one recipient"}
+	}
+
+	if options.TemplateID != "" {
+		tmpl, err := s.templates.FindByID(ctx, options.TemplateID, req.TenantID)
+		if errors.Is(err, mongo.ErrNoDocuments) || errors.Is(err, primitive.ErrInvalidHex) {
+			return &RequestError{Reason: fmt.Sprintf("template %s not found", options.TemplateID)}
+		}
+		if err != nil {
+			return fmt.Errorf("failed to load digest template: %w", err)
+		}
+		if tmpl.Channel != "" && tmpl.Channel != domain.NotificationTypeEmail {
+			return &RequestError{Reason: fmt.Sprintf("template %s is a %s template, not an email template", options.TemplateID, tmpl.Channel)}
+		}
+	}
+	return nil
+}
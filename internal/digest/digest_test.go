@@ -0,0 +1,224 @@
+package digest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type memoryStore struct {
+	digests     []*domain.Digest
+	rescheduled map[primitive.ObjectID]time.Time
+	completed   map[primitive.ObjectID]domain.DigestStatus
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		rescheduled: map[primitive.ObjectID]time.Time{},
+		completed:   map[primitive.ObjectID]domain.DigestStatus{},
+	}
+}
+
+func (s *memoryStore) Stage(ctx context.Context, digest *domain.Digest, item domain.DigestItem, maxItems int) (*domain.Digest, error) {
+	for _, open := range s.digests {
+		if open.TenantID == digest.TenantID && open.Recipient == digest.Recipient && open.Key == digest.Key && open.ItemCount < maxItems {
+			open.Items = append(open.Items, item)
+			open.ItemCount++
+			return open, nil
+		}
+	}
+	digest.ID = primitive.NewObjectID()
+	digest.Items = []domain.DigestItem{item}
+	digest.ItemCount = 1
+	s.digests = append(s.digests, digest)
+	return digest, nil
+}
+
+func (s *memoryStore) ClaimDue(ctx context.Context, now time.Time, staleAfter time.Duration) (*domain.Digest, error) {
+	return nil, nil
+}
+
+func (s *memoryStore) Reschedule(ctx context.Context, id primitive.ObjectID, dueAt time.Time, failure string) error {
+	s.rescheduled[id] = dueAt
+	return nil
+}
+
+func (s *memoryStore) Complete(ctx context.Context, id primitive.ObjectID, status domain.DigestStatus, failure string) error {
+	s.completed[id] = status
+	return nil
+}
+
+type templateStore map[string]*domain.Template
+
+func (s templateStore) FindByID(ctx context.Context, id string, tenantID string) (*domain.Template, error) {
+	if t, ok := s[id]; ok {
+		return t, nil
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+type preferenceStore map[string]*domain.NotificationPreferences
+
+func (s preferenceStore) GetByUserID(ctx context.Context, tenantID, userID string) (*domain.NotificationPreferences, error) {
+	return s[userID], nil
+}
+
+type recordingSender struct {
+	emails []*domain.SendEmailRequest
+	err    error
+}
+
+func (s *recordingSender) SendEmail(ctx context.Context, req *domain.SendEmailRequest) error {
+	s.emails = append(s.emails, req)
+	return s.err
+}
+
+func (s *recordingSender) SendSMS(ctx context.Context, req *domain.SendSMSRequest) error {
+	return s.err
+}
+
+func (s *recordingSender) SendWebhook(ctx context.Context, req *domain.SendWebhookRequest) error {
+	return s.err
+}
+
+func TestDigestSenderCollectsPerRecipient(t *testing.T) {
+	store := newMemoryStore()
+	next := &recordingSender{}
+	sender := NewSender(store, templateStore{}, Config{MaxItems: 2}, next, logger.NewLogger())
+	ctx := context.Background()
+
+	comment := func(subject string) *domain.SendEmailRequest {
+		return &domain.SendEmailRequest{
+			TenantID: "tenant-a",
+			To:       []string{"Ann@Example.com", "bob@example.com"},
+			Subject:  subject,
+			Body:     "New comment",
+			Digest:   &domain.DigestOptions{Key: "comments", Interval: domain.DigestIntervalHourly},
+		}
+	}
+	for _, subject := range []string{"First", "Second", "Third"} {
+		if err := sender.SendEmail(ctx, comment(subject)); err != nil {
+			t.Fatalf("SendEmail(%s) error = %v", subject, err)
+		}
+	}
+
+	if len(next.emails) != 0 {
+		t.Errorf("sent %d emails, want none until the digest is due", len(next.emails))
+	}
+	// Two recipients, and the third item overflows each recipient's first digest
+	if len(store.digests) != 4 {
+		t.Fatalf("staged %d digests, want 4", len(store.digests))
+	}
+	if got := store.digests[0]; got.Recipient != "ann@example.com" || got.ItemCount != 2 || got.Items[1].Subject != "Second" {
+		t.Errorf("first digest = %+v", got)
+	}
+
+	plain := &domain.SendEmailRequest{TenantID: "tenant-a", To: []string{"ann@example.com"}, Subject: "Now", Body: "Now"}
+	if err := sender.SendEmail(ctx, plain); err != nil || len(next.emails) != 1 {
+		t.Errorf("SendEmail() without digest error = %v, sent %d", err, len(next.emails))
+	}
+
+	for name, change := range map[string]func(*domain.SendEmailRequest){
+		"interval":       func(r *domain.SendEmailRequest) { r.Digest.Interval = "weekly" },
+		"cc":             func(r *domain.SendEmailRequest) { r.CC = []string{"cc@example.com"} },
+		"recipient list": func(r *domain.SendEmailRequest) { r.RecipientListID = "list-1" },
+		"user_id":        func(r *domain.SendEmailRequest) { r.Digest.UserID = "user-1" },
+		"template":       func(r *domain.SendEmailRequest) { r.Digest.TemplateID = "missing" },
+	} {
+		req := comment("Invalid")
+		change(req)
+		if _, ok := AsRequestError(sender.SendEmail(ctx, req)); !ok {
+			t.Errorf("SendEmail() with invalid %s did not return a RequestError", name)
+		}
+	}
+}
+
+func TestDispatcherDeliver(t *testing.T) {
+	store := newMemoryStore()
+	next := &recordingSender{}
+	tmpls := templateStore{
+		"comments": {Name: "comments", Subject: "{{.Count}} comments", Body: "{{range .Items}}[{{.Subject}}]{{end}}"},
+		"broken":   {Name: "broken", Subject: "{{.Missing}}", Body: "x"},
+	}
+	prefs := preferenceStore{
+		"night-owl": {QuietHoursStart: "22:00", QuietHoursEnd: "08:00", Timezone: "UTC"},
+	}
+	dispatcher := NewDispatcher(store, tmpls, prefs, next, Config{}, logger.NewLogger())
+	ctx := context.Background()
+	now := time.Date(2024, 1, 10, 23, 0, 0, 0, time.UTC)
+
+	digest := &domain.Digest{
+		ID:         primitive.NewObjectID(),
+		TenantID:   "tenant-a",
+		Recipient:  "ann@example.com",
+		Key:        "comments",
+		TemplateID: "comments",
+		Items:      []domain.DigestItem{{Subject: "First"}, {Subject: "Second"}},
+		ItemCount:  2,
+	}
+	dispatcher.deliver(ctx, digest, now)
+	if len(next.emails) != 1 || store.completed[digest.ID] != domain.DigestStatusSent {
+		t.Fatalf("sent %d emails, status %q", len(next.emails), store.completed[digest.ID])
+	}
+	if sent := next.emails[0]; sent.Subject != "2 comments" || sent.Body != "[First][Second]" || sent.Digest != nil || !strings.HasPrefix(sent.IdempotencyKey, "digest:") {
+		t.Errorf("sent email = %+v", sent)
+	}
+
+	quiet := &domain.Digest{ID: primitive.NewObjectID(), UserID: "night-owl", Items: digest.Items}
+	dispatcher.deliver(ctx, quiet, now)
+	if until := store.rescheduled[quiet.ID]; !until.Equal(time.Date(2024, 1, 11, 8, 0, 0, 0, time.UTC)) || len(next.emails) != 1 {
+		t.Errorf("digest in quiet hours rescheduled to %v, sent %d emails", until, len(next.emails))
+	}
+
+	broken := &domain.Digest{ID: primitive.NewObjectID(), TemplateID: "broken", Items: digest.Items}
+	dispatcher.deliver(ctx, broken, now)
+	if store.completed[broken.ID] != domain.DigestStatusFailed {
+		t.Errorf("digest with a broken template status = %q, want failed", store.completed[broken.ID])
+	}
+
+	next.err = errors.New("smtp down")
+	failing := &domain.Digest{ID: primitive.NewObjectID(), Items: digest.Items}
+	dispatcher.deliver(ctx, failing, now)
+	if _, ok := store.rescheduled[failing.ID]; !ok {
+		t.Error("digest whose send failed was not rescheduled")
+	}
+	failing.Attempts = maxAttempts - 1
+	dispatcher.deliver(ctx, failing, now)
+	if store.completed[failing.ID] != domain.DigestStatusFailed {
+		t.Errorf("digest failing its last attempt status = %q, want failed", store.completed[failing.ID])
+	}
+}
+
+func TestQuietHoursEnd(t *testing.T) {
+	overnight := &domain.NotificationPreferences{QuietHoursStart: "22:00", QuietHoursEnd: "08:00", Timezone: "Europe/Berlin"}
+	tests := []struct {
+		name  string
+		prefs *domain.NotificationPreferences
+		now   time.Time
+		until string
+	}{
+		{"before midnight", overnight, time.Date(2024, 1, 10, 22, 30, 0, 0, time.UTC), "2024-01-11T08:00:00+01:00"},
+		{"after midnight", overnight, time.Date(2024, 1, 10, 5, 0, 0, 0, time.UTC), "2024-01-10T08:00:00+01:00"},
+		{"daytime", overnight, time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC), ""},
+		{"same day window", &domain.NotificationPreferences{QuietHoursStart: "12:00", QuietHoursEnd: "13:30"}, time.Date(2024, 1, 10, 12, 15, 0, 0, time.UTC), "2024-01-10T13:30:00Z"},
+		{"none", &domain.NotificationPreferences{}, time.Date(2024, 1, 10, 23, 0, 0, 0, time.UTC), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			until, quiet := QuietHoursEnd(tt.prefs, tt.now)
+			if quiet != (tt.until != "") {
+				t.Fatalf("QuietHoursEnd() quiet = %v, want %v", quiet, tt.until != "")
+			}
+			if quiet && until.Format(time.RFC3339) != tt.until {
+				t.Errorf("QuietHoursEnd() = %s, want %s", until.Format(time.RFC3339), tt.until)
+			}
+		})
+	}
+}
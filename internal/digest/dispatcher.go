@@ -0,0 +1,261 @@
+package digest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/metrics"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+	"github.com/vhvplatform/go-notification-service/internal/templates"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// DefaultPollInterval is how often due digests are looked for when no interval is configured
+	DefaultPollInterval = time.Minute
+
+	// retryDelay is how long a digest waits after a failed delivery
+	retryDelay = 5 * time.Minute
+
+	// claimTimeout is how long a claimed digest may go unfinished before it is claimed again
+	claimTimeout = 10 * time.Minute
+
+	// maxAttempts is how many failed deliveries a digest gets before it is marked failed
+	maxAttempts = 5
+)
+
+// PreferenceStore interface for loading the quiet hours of a digest's user
+type PreferenceStore interface {
+	GetByUserID(ctx context.Context, tenantID, userID string) (*domain.NotificationPreferences, error)
+}
+
+// EmailSender interface for sending rendered digests
+type EmailSender interface {
+	SendEmail(ctx context.Context, req *domain.SendEmailRequest) error
+}
+
+// Dispatcher sends digests that are due as one combined email each
+type Dispatcher struct {
+	store       Store
+	templates   TemplateStore
+	preferences PreferenceStore
+	sender      EmailSender
+	interval    time.Duration
+	log         *logger.Logger
+}
+
+// NewDispatcher creates a new digest dispatcher
+func NewDispatcher(store Store, templates TemplateStore, preferences PreferenceStore, sender EmailSender, config Config, log *logger.Logger) *Dispatcher {
+	interval := config.PollInterval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	return &Dispatcher{
+		store:       store,
+		templates:   templates,
+		preferences: preferences,
+		sender:      sender,
+		interval:    interval,
+		log:         log,
+	}
+}
+
+// Start sends due digests until ctx is cancelled
+func (d *Dispatcher) Start(ctx context.Context) {
+	d.log.Info("Starting digest dispatcher", "interval", d.interval)
+
+	go func() {
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				d.log.Info("Digest dispatcher stopped")
+				return
+			case <-ticker.C:
+				d.dispatchDue(ctx)
+			}
+		}
+	}()
+}
+
+// dispatchDue delivers digests until none are due
+func (d *Dispatcher) dispatchDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		digest, err := d.store.ClaimDue(ctx, time.Now(), claimTimeout)
+		if err != nil {
+			if ctx.Err() == nil {
+				d.log.Error("Failed to claim due digest", "error", err)
+			}
+			return
+		}
+		if digest == nil {
+			return
+		}
+		d.deliver(ctx, digest, time.Now())
+	}
+}
+
+// deliver sends a claimed digest, or reschedules it if its user is in quiet hours or the send fails
+func (d *Dispatcher) deliver(ctx context.Context, digest *domain.Digest, now time.Time) {
+	if until, quiet := d.quietUntil(ctx, digest, now); quiet {
+		d.log.Debug("Digest delayed by quiet hours", "digest_id", digest.ID.Hex(), "until", until)
+		if err := d.store.Reschedule(ctx, digest.ID, until, ""); err != nil {
+			d.log.Error("Failed to reschedule digest", "error", err, "digest_id", digest.ID.Hex())
+		}
+		return
+	}
+
+	tmpl, err := d.template(ctx, digest)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) && !errors.Is(err, primitive.ErrInvalidHex) {
+		d.log.Error("Failed to load digest template", "error", err, "digest_id", digest.ID.Hex(), "tenant_id", digest.TenantID)
+		d.retry(ctx, digest, now, err)
+		return
+	}
+	var req *domain.SendEmailRequest
+	if err == nil {
+		req, err = render(digest, tmpl)
+	}
+	if err != nil {
+		// A missing or broken template won't fix itself, so the digest is not retried
+		d.log.Error("Failed to render digest", "error", err, "digest_id", digest.ID.Hex(), "tenant_id", digest.TenantID)
+		d.fail(ctx, digest, err)
+		return
+	}
+
+	if err := d.sender.SendEmail(ctx, req); err != nil {
+		d.log.Error("Failed to send digest", "error", err, "digest_id", digest.ID.Hex(), "tenant_id", digest.TenantID, "attempts", digest.Attempts+1)
+		d.retry(ctx, digest, now, err)
+		return
+	}
+
+	metrics.DigestsSent.WithLabelValues(string(domain.DigestStatusSent)).Inc()
+	if err := d.store.Complete(ctx, digest.ID, domain.DigestStatusSent, ""); err != nil {
+		d.log.Error("Failed to mark digest sent", "error", err, "digest_id", digest.ID.Hex())
+	}
+	d.log.Info("Sent digest", "digest_id", digest.ID.Hex(), "tenant_id", digest.TenantID, "key", digest.Key, "items", digest.ItemCount)
+}
+
+// fail marks a digest failed; it won't be sent
+func (d *Dispatcher) fail(ctx context.Context, digest *domain.Digest, cause error) {
+	metrics.DigestsSent.WithLabelValues(string(domain.DigestStatusFailed)).Inc()
+	if err := d.store.Complete(ctx, digest.ID, domain.DigestStatusFailed, cause.Error()); err != nil {
+		d.log.Error("Failed to mark digest failed", "error", err, "digest_id", digest.ID.Hex())
+	}
+}
+
+// retry reschedules a digest after a failed delivery, or marks it failed after maxAttempts
+func (d *Dispatcher) retry(ctx context.Context, digest *domain.Digest, now time.Time, cause error) {
+	if digest.Attempts+1 >= maxAttempts {
+		d.fail(ctx, digest, cause)
+		return
+	}
+	if err := d.store.Reschedule(ctx, digest.ID, now.Add(retryDelay), cause.Error()); err != nil {
+		d.log.Error("Failed to reschedule digest", "error", err, "digest_id", digest.ID.Hex())
+	}
+}
+
+// template loads the digest's template, or returns the default one if it names none
+func (d *Dispatcher) template(ctx context.Context, digest *domain.Digest) (*domain.Template, error) {
+	if digest.TemplateID == "" {
+		return templates.DefaultDigest, nil
+	}
+	tmpl, err := d.templates.FindByID(ctx, digest.TemplateID, digest.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load template %s: %w", digest.TemplateID, err)
+	}
+	return tmpl, nil
+}
+
+// render builds the combined email for a digest
+func render(digest *domain.Digest, tmpl *domain.Template) (*domain.SendEmailRequest, error) {
+	subject, body, err := templates.RenderDigest(tmpl, &domain.DigestData{
+		Key:       digest.Key,
+		Recipient: digest.Recipient,
+		Count:     len(digest.Items),
+		Items:     digest.Items,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.SendEmailRequest{
+		TenantID: digest.TenantID,
+		To:       []string{digest.Recipient},
+		Subject:  subject,
+		Body:     body,
+		IsHTML:   tmpl.IsHTML,
+		Category: digest.Category,
+		Tags:     []string{"digest"},
+		Metadata: map[string]string{
+			"digest_id":  digest.ID.Hex(),
+			"digest_key": digest.Key,
+		},
+		// A digest reclaimed after a crash mid-send keeps its key; a retry after a failed send gets a new one
+		IdempotencyKey: fmt.Sprintf("digest:%s:%d", digest.ID.Hex(), digest.Attempts),
+	}, nil
+}
+
+// quietUntil reports whether now is within the quiet hours of the digest's user, and when they end
+func (d *Dispatcher) quietUntil(ctx context.Context, digest *domain.Digest, now time.Time) (time.Time, bool) {
+	if digest.UserID == "" {
+		return time.Time{}, false
+	}
+	prefs, err := d.preferences.GetByUserID(ctx, digest.TenantID, digest.UserID)
+	if err != nil {
+		// Quiet hours are a courtesy; failing to load them shouldn't hold the digest back
+		d.log.Error("Failed to load quiet hours for digest", "error", err, "digest_id", digest.ID.Hex())
+		return time.Time{}, false
+	}
+	return QuietHoursEnd(prefs, now)
+}
+
+// QuietHoursEnd reports whether now falls within prefs' quiet hours, and if so when they end
+// Quiet hours may span midnight, such as 22:00 to 08:00. They are read in the preferences'
+// timezone, UTC if unset.
+func QuietHoursEnd(prefs *domain.NotificationPreferences, now time.Time) (time.Time, bool) {
+	if prefs == nil || prefs.QuietHoursStart == "" || prefs.QuietHoursEnd == "" {
+		return time.Time{}, false
+	}
+	start, err := time.Parse("15:04", prefs.QuietHoursStart)
+	if err != nil {
+		return time.Time{}, false
+	}
+	end, err := time.Parse("15:04", prefs.QuietHoursEnd)
+	if err != nil {
+		return time.Time{}, false
+	}
+	location := time.UTC
+	if prefs.Timezone != "" {
+		if loc, err := time.LoadLocation(prefs.Timezone); err == nil {
+			location = loc
+		}
+	}
+
+	local := now.In(location)
+	minute := local.Hour()*60 + local.Minute()
+	startMinute := start.Hour()*60 + start.Minute()
+	endMinute := end.Hour()*60 + end.Minute()
+
+	var quiet bool
+	switch {
+	case startMinute < endMinute:
+		quiet = minute >= startMinute && minute < endMinute
+	case startMinute > endMinute:
+		quiet = minute >= startMinute || minute < endMinute
+	}
+	if !quiet {
+		return time.Time{}, false
+	}
+
+	until := time.Date(local.Year(), local.Month(), local.Day(), end.Hour(), end.Minute(), 0, 0, location)
+	if !until.After(local) {
+		until = until.AddDate(0, 0, 1)
+	}
+	return until, true
+}
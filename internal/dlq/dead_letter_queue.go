@@ -1,120 +1,203 @@
-package dlq
-
-import (
-	"context"
-	"fmt"
-
-	"github.com/vhvplatform/go-notification-service/internal/domain"
-	"github.com/vhvplatform/go-notification-service/internal/repository"
-	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
-)
-
-// Default maximum retry attempts before sending to DLQ
-const defaultMaxRetries = 3
-
-// DeadLetterQueue handles failed notifications
-type DeadLetterQueue struct {
-	repo       *repository.FailedNotificationRepository
-	log        *logger.Logger
-	maxRetries int
-}
-
-// NewDeadLetterQueue creates a new dead letter queue
-func NewDeadLetterQueue(repo *repository.FailedNotificationRepository, log *logger.Logger) *DeadLetterQueue {
-	return &DeadLetterQueue{
-		repo:       repo,
-		log:        log,
-		maxRetries: defaultMaxRetries,
-	}
-}
-
-// NewDeadLetterQueueWithRetries creates a new dead letter queue with custom max retries
-func NewDeadLetterQueueWithRetries(repo *repository.FailedNotificationRepository, log *logger.Logger, maxRetries int) *DeadLetterQueue {
-	return &DeadLetterQueue{
-		repo:       repo,
-		log:        log,
-		maxRetries: maxRetries,
-	}
-}
-
-// Add adds a failed notification to the DLQ
-func (dlq *DeadLetterQueue) Add(ctx context.Context, notification *domain.Notification, err error) error {
-	dlq.log.Warn("Adding notification to DLQ", "id", notification.ID.Hex(), "error", err)
-
-	failed := &domain.FailedNotification{
-		OriginalID: notification.ID,
-		TenantID:   notification.TenantID,
-		Type:       notification.Type,
-		Recipient:  notification.Recipient,
-		Subject:    notification.Subject,
-		Body:       notification.Body,
-		Payload:    notification.Payload,
-		Error:      err.Error(),
-		FailedAt:   notification.UpdatedAt,
-		RetryCount: notification.RetryCount,
-	}
-
-	return dlq.repo.Create(ctx, failed)
-}
-
-// GetAll retrieves all failed notifications for a specific tenant
-func (dlq *DeadLetterQueue) GetAll(ctx context.Context, tenantID string, page, pageSize int) ([]*domain.FailedNotification, int64, error) {
-	return dlq.repo.FindAll(ctx, tenantID, page, pageSize)
-}
-
-// Retry retries a failed notification with tenant isolation
-func (dlq *DeadLetterQueue) Retry(ctx context.Context, id string, tenantID string, notificationService NotificationService) error {
-	failed, err := dlq.repo.FindByID(ctx, id, tenantID)
-	if err != nil {
-		return fmt.Errorf("failed to find notification: %w", err)
-	}
-
-	dlq.log.Info("Retrying failed notification", "id", id, "type", failed.Type)
-
-	// Attempt to resend based on type
-	switch failed.Type {
-	case domain.NotificationTypeEmail:
-		req := &domain.SendEmailRequest{
-			TenantID: failed.TenantID,
-			To:       []string{failed.Recipient},
-			Subject:  failed.Subject,
-			Body:     failed.Body,
-		}
-		err = notificationService.SendEmail(ctx, req)
-	case domain.NotificationTypeSMS:
-		req := &domain.SendSMSRequest{
-			TenantID: failed.TenantID,
-			To:       failed.Recipient,
-			Message:  failed.Body,
-		}
-		err = notificationService.SendSMS(ctx, req)
-	case domain.NotificationTypeWebhook:
-		req := &domain.SendWebhookRequest{
-			TenantID: failed.TenantID,
-			URL:      failed.Recipient,
-			Payload:  failed.Payload,
-		}
-		err = notificationService.SendWebhook(ctx, req)
-	default:
-		return fmt.Errorf("unsupported notification type: %s", failed.Type)
-	}
-
-	if err != nil {
-		return fmt.Errorf("retry failed: %w", err)
-	}
-
-	// Remove from DLQ on success
-	return dlq.repo.Delete(ctx, id)
-}
-
-// ShouldSendToDLQ checks if a notification should be sent to DLQ
-func (dlq *DeadLetterQueue) ShouldSendToDLQ(notification *domain.Notification) bool {
-	return notification.RetryCount >= dlq.maxRetries
-}
-
-// NotificationService interface for retry functionality
-type NotificationService interface {
-	SendEmail(ctx context.Context, req *domain.SendEmailRequest) error
-	SendSMS(ctx context.Context, req *domain.SendSMSRequest) error
-	SendWebhook(ctx context.Context, req *domain.SendWebhookRequest) error
-}
+package dlq
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/repository"
+	"github.com/vhvplatform/go-notification-service/internal/retry"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// Default maximum retry attempts before sending to DLQ
+const defaultMaxRetries = 3
+
+// DeadLetterQueue handles failed notifications
+type DeadLetterQueue struct {
+	repo       *repository.FailedNotificationRepository
+	log        *logger.Logger
+	maxRetries int
+	policies   *retry.Resolver // Optional; when set, MaxAttempts of the tenant's policy replaces maxRetries
+}
+
+// NewDeadLetterQueue creates a new dead letter queue
+func NewDeadLetterQueue(repo *repository.FailedNotificationRepository, log *logger.Logger) *DeadLetterQueue {
+	return &DeadLetterQueue{
+		repo:       repo,
+		log:        log,
+		maxRetries: defaultMaxRetries,
+	}
+}
+
+// NewDeadLetterQueueWithRetries creates a new dead letter queue with custom max retries
+func NewDeadLetterQueueWithRetries(repo *repository.FailedNotificationRepository, log *logger.Logger, maxRetries int) *DeadLetterQueue {
+	return &DeadLetterQueue{
+		repo:       repo,
+		log:        log,
+		maxRetries: maxRetries,
+	}
+}
+
+// NewDeadLetterQueueWithPolicies creates a new dead letter queue that uses the tenant's
+// retry policy for each channel to decide when a notification has exhausted its retries
+func NewDeadLetterQueueWithPolicies(repo *repository.FailedNotificationRepository, log *logger.Logger, policies *retry.Resolver) *DeadLetterQueue {
+	return &DeadLetterQueue{
+		repo:       repo,
+		log:        log,
+		maxRetries: defaultMaxRetries,
+		policies:   policies,
+	}
+}
+
+// Add adds a failed notification to the DLQ
+func (dlq *DeadLetterQueue) Add(ctx context.Context, notification *domain.Notification, err error) error {
+	return dlq.AddWithRequest(ctx, notification, nil, err)
+}
+
+// AddWithRequest adds a failed notification to the DLQ along with the request that sent it.
+// Retrying the entry resends request as stored; callers should narrow it to the recipients
+// that failed. A nil request falls back to resending the notification's recipient and content.
+func (dlq *DeadLetterQueue) AddWithRequest(ctx context.Context, notification *domain.Notification, request *domain.FailedRequest, err error) error {
+	_, err = dlq.add(ctx, notification, request, err)
+	return err
+}
+
+// Requeue adds a failed notification to the DLQ and retries it at once, as Retry does.
+// The entry is kept in the DLQ, for a later retry, when the resend fails.
+func (dlq *DeadLetterQueue) Requeue(ctx context.Context, notification *domain.Notification, cause error, notificationService NotificationService) error {
+	failed, err := dlq.add(ctx, notification, nil, cause)
+	if err != nil {
+		return err
+	}
+	return dlq.Retry(ctx, failed.ID.Hex(), failed.TenantID, notificationService)
+}
+
+// add stores a failed notification in the DLQ
+func (dlq *DeadLetterQueue) add(ctx context.Context, notification *domain.Notification, request *domain.FailedRequest, err error) (*domain.FailedNotification, error) {
+	dlq.log.Warn("Adding notification to DLQ", "id", notification.ID.Hex(), "error", err)
+
+	failed := &domain.FailedNotification{
+		OriginalID: notification.ID,
+		TenantID:   notification.TenantID,
+		Type:       notification.Type,
+		Recipient:  notification.Recipient,
+		Subject:    notification.Subject,
+		Body:       notification.Body,
+		Payload:    notification.Payload,
+		Request:    request,
+		Error:      err.Error(),
+		FailedAt:   notification.UpdatedAt,
+		RetryCount: notification.RetryCount,
+	}
+
+	return failed, dlq.repo.Create(ctx, failed)
+}
+
+// GetAll retrieves all failed notifications for a specific tenant
+func (dlq *DeadLetterQueue) GetAll(ctx context.Context, tenantID string, page, pageSize int) ([]*domain.FailedNotification, int64, error) {
+	return dlq.repo.FindAll(ctx, tenantID, page, pageSize)
+}
+
+// Retry retries a failed notification with tenant isolation
+func (dlq *DeadLetterQueue) Retry(ctx context.Context, id string, tenantID string, notificationService NotificationService) error {
+	failed, err := dlq.repo.FindByID(ctx, id, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to find notification: %w", err)
+	}
+
+	dlq.log.Info("Retrying failed notification", "id", id, "type", failed.Type)
+
+	// Attempt to resend based on type
+	switch failed.Type {
+	case domain.NotificationTypeEmail:
+		err = notificationService.SendEmail(ctx, emailRetry(failed))
+	case domain.NotificationTypeSMS:
+		err = notificationService.SendSMS(ctx, smsRetry(failed))
+	case domain.NotificationTypeWebhook:
+		err = notificationService.SendWebhook(ctx, webhookRetry(failed))
+	default:
+		return fmt.Errorf("unsupported notification type: %s", failed.Type)
+	}
+
+	if err != nil {
+		return fmt.Errorf("retry failed: %w", err)
+	}
+
+	// Remove from DLQ on success
+	return dlq.repo.Delete(ctx, id)
+}
+
+// emailRetry builds the email to resend for a failed notification, preferring the stored request
+func emailRetry(failed *domain.FailedNotification) *domain.SendEmailRequest {
+	if failed.Request != nil && failed.Request.Email != nil {
+		req := *failed.Request.Email
+		req.TenantID = failed.TenantID
+		req.IdempotencyKey = "" // The original key still covers the failed send
+		return &req
+	}
+	return &domain.SendEmailRequest{
+		TenantID: failed.TenantID,
+		To:       []string{failed.Recipient},
+		Subject:  failed.Subject,
+		Body:     failed.Body,
+	}
+}
+
+// smsRetry builds the SMS to resend for a failed notification, preferring the stored request
+func smsRetry(failed *domain.FailedNotification) *domain.SendSMSRequest {
+	if failed.Request != nil && failed.Request.SMS != nil {
+		req := *failed.Request.SMS
+		req.TenantID = failed.TenantID
+		req.IdempotencyKey = ""
+		return &req
+	}
+	return &domain.SendSMSRequest{
+		TenantID: failed.TenantID,
+		To:       failed.Recipient,
+		Message:  failed.Body,
+	}
+}
+
+// webhookRetry builds the webhook to resend for a failed notification, preferring the stored request.
+// The stored request keeps its delivery ID, so receivers see the retry as the same delivery.
+func webhookRetry(failed *domain.FailedNotification) *domain.SendWebhookRequest {
+	if failed.Request != nil && failed.Request.Webhook != nil {
+		req := *failed.Request.Webhook
+		req.TenantID = failed.TenantID
+		req.IdempotencyKey = ""
+		return &req
+	}
+	return &domain.SendWebhookRequest{
+		TenantID: failed.TenantID,
+		URL:      failed.Recipient,
+		Payload:  failed.Payload,
+	}
+}
+
+// ShouldSendToDLQ checks if a notification should be sent to DLQ
+func (dlq *DeadLetterQueue) ShouldSendToDLQ(ctx context.Context, notification *domain.Notification) bool {
+	return notification.RetryCount >= dlq.maxAttempts(ctx, notification)
+}
+
+// maxAttempts returns the retry limit for a notification, falling back to maxRetries when
+// no policy is configured or it can't be loaded
+func (dlq *DeadLetterQueue) maxAttempts(ctx context.Context, notification *domain.Notification) int {
+	if dlq.policies == nil {
+		return dlq.maxRetries
+	}
+
+	policy, err := dlq.policies.Policy(ctx, notification.TenantID, notification.Type)
+	if err != nil {
+		dlq.log.Error("Failed to load retry policy", "error", err, "tenant_id", notification.TenantID, "type", notification.Type)
+		return dlq.maxRetries
+	}
+	return policy.MaxAttempts
+}
+
+// NotificationService interface for retry functionality
+type NotificationService interface {
+	SendEmail(ctx context.Context, req *domain.SendEmailRequest) error
+	SendSMS(ctx context.Context, req *domain.SendSMSRequest) error
+	SendWebhook(ctx context.Context, req *domain.SendWebhookRequest) error
+}
@@ -0,0 +1,78 @@
+package dlq
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestEmailRetryReplaysStoredRequest(t *testing.T) {
+	original := &domain.SendEmailRequest{
+		TenantID:       "tenant-1",
+		To:             []string{"a@example.com"},
+		CC:             []string{"cc@example.com"},
+		BCC:            []string{"audit@example.com"},
+		Subject:        "Invoice",
+		Body:           "See attached",
+		Attachments:    []domain.Attachment{{Filename: "invoice.pdf", Content: []byte("%PDF-1.7"), MimeType: "application/pdf"}},
+		IdempotencyKey: "invoice-42",
+		ReplyTo:        "billing@example.com",
+	}
+
+	// The entry is read back from MongoDB before it is retried
+	raw, err := bson.Marshal(&domain.FailedNotification{
+		TenantID:  "tenant-1",
+		Type:      domain.NotificationTypeEmail,
+		Recipient: "a@example.com",
+		Request:   &domain.FailedRequest{Email: original},
+	})
+	if err != nil {
+		t.Fatalf("bson.Marshal() error = %v", err)
+	}
+	var failed domain.FailedNotification
+	if err := bson.Unmarshal(raw, &failed); err != nil {
+		t.Fatalf("bson.Unmarshal() error = %v", err)
+	}
+
+	got := emailRetry(&failed)
+	want := *original
+	want.IdempotencyKey = ""
+	if !reflect.DeepEqual(*got, want) {
+		t.Errorf("emailRetry() = %+v, want %+v", *got, want)
+	}
+}
+
+func TestRetryFallsBackWithoutStoredRequest(t *testing.T) {
+	failed := &domain.FailedNotification{
+		TenantID:  "tenant-1",
+		Recipient: "a@example.com",
+		Subject:   "Hello",
+		Body:      "World",
+		Payload:   map[string]any{"event": "ping"},
+	}
+
+	email := emailRetry(failed)
+	if !reflect.DeepEqual(email.To, []string{"a@example.com"}) || email.Subject != "Hello" || email.Body != "World" || email.CC != nil {
+		t.Errorf("emailRetry() = %+v", email)
+	}
+	if sms := smsRetry(failed); sms.To != "a@example.com" || sms.Message != "World" {
+		t.Errorf("smsRetry() = %+v", sms)
+	}
+	if webhook := webhookRetry(failed); webhook.URL != "a@example.com" || webhook.Payload["event"] != "ping" {
+		t.Errorf("webhookRetry() = %+v", webhook)
+	}
+}
+
+func TestRetryUsesEntryTenant(t *testing.T) {
+	failed := &domain.FailedNotification{
+		TenantID: "tenant-1",
+		Request: &domain.FailedRequest{
+			Webhook: &domain.SendWebhookRequest{TenantID: "tenant-2", URL: "https://hooks.example.com"},
+		},
+	}
+	if got := webhookRetry(failed); got.TenantID != "tenant-1" || got.URL != "https://hooks.example.com" {
+		t.Errorf("webhookRetry() = %+v", got)
+	}
+}
@@ -0,0 +1,17 @@
+package domain
+
+import "time"
+
+// NotificationEventAcknowledged is the timeline event type recorded when a notification is acknowledged
+const NotificationEventAcknowledged = "acknowledged"
+
+// AcknowledgeRequest represents an acknowledgment of a notification by its recipient or their app
+type AcknowledgeRequest struct {
+	Metadata map[string]string `json:"metadata,omitempty" binding:"max=20"` // Stored on the notification, e.g. who acknowledged it
+}
+
+// AckLink is a signed URL that acknowledges one notification without API credentials
+type AckLink struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,21 @@
+package domain
+
+import "time"
+
+// DefaultAPITokenTTL is how long an API token lasts when the request doesn't say
+const DefaultAPITokenTTL = 90 * 24 * time.Hour
+
+// IssueAPITokenRequest represents a request to issue an API token for a tenant
+type IssueAPITokenRequest struct {
+	Scopes     []string `json:"scopes" binding:"required,min=1,dive,oneof=send read admin"`
+	TTLSeconds int      `json:"ttl_seconds,omitempty" binding:"omitempty,min=60"` // Defaults to DefaultAPITokenTTL, capped at API_TOKEN_MAX_TTL_HOURS
+}
+
+// APIToken is a newly issued API token. The token itself is only returned when it is issued.
+type APIToken struct {
+	ID        string    `json:"id"`
+	TenantID  string    `json:"tenant_id"`
+	Scopes    []string  `json:"scopes"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,23 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TenantAttachmentPolicy adjusts the service-wide attachment policy for a tenant.
+// Allowed lists replace the defaults when set, and an explicitly allowed type or
+// extension lifts a default deny. Denied lists add to the defaults.
+type TenantAttachmentPolicy struct {
+	ID                primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TenantID          string             `json:"tenant_id" bson:"tenantId"`
+	AllowedMIMETypes  []string           `json:"allowed_mime_types,omitempty" bson:"allowedMimeTypes,omitempty"`
+	DeniedMIMETypes   []string           `json:"denied_mime_types,omitempty" bson:"deniedMimeTypes,omitempty"`
+	AllowedExtensions []string           `json:"allowed_extensions,omitempty" bson:"allowedExtensions,omitempty"`
+	DeniedExtensions  []string           `json:"denied_extensions,omitempty" bson:"deniedExtensions,omitempty"`
+	Version           int                `json:"version" bson:"version"`
+	CreatedAt         time.Time          `json:"created_at" bson:"createdAt"`
+	UpdatedAt         time.Time          `json:"updated_at" bson:"updatedAt"`
+	DeletedAt         *time.Time         `json:"deleted_at,omitempty" bson:"deletedAt,omitempty"`
+}
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AuditResult is the outcome of an audited action
+type AuditResult string
+
+const (
+	AuditResultSuccess AuditResult = "success"
+	AuditResultFailure AuditResult = "failure"
+)
+
+// AuditLog is an append-only record of who performed an action on which resource
+type AuditLog struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TenantID     string             `json:"tenant_id,omitempty" bson:"tenantId,omitempty"` // Empty for service-wide admin actions
+	Actor        string             `json:"actor" bson:"actor"`
+	Action       string             `json:"action" bson:"action"`
+	ResourceType string             `json:"resource_type" bson:"resourceType"`
+	ResourceID   string             `json:"resource_id,omitempty" bson:"resourceId,omitempty"`
+	Result       AuditResult        `json:"result" bson:"result"`
+	StatusCode   int                `json:"status_code" bson:"statusCode"`
+	ClientIP     string             `json:"client_ip,omitempty" bson:"clientIp,omitempty"`
+	Timestamp    time.Time          `json:"timestamp" bson:"timestamp"`
+}
+
+// AuditLogQuery holds filters for listing a tenant's audit log
+type AuditLogQuery struct {
+	TenantID     string     `form:"-"` // Injected from auth context
+	Actor        string     `form:"actor"`
+	Action       string     `form:"action"`
+	ResourceType string     `form:"resource_type"`
+	ResourceID   string     `form:"resource_id"`
+	From         *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To           *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Page         int        `form:"page"`
+	PageSize     int        `form:"page_size"`
+}
@@ -0,0 +1,18 @@
+package domain
+
+// ContentWarning is one content-analysis rule an email tripped, with what it added to the score
+type ContentWarning struct {
+	Rule    string  `json:"rule"`
+	Message string  `json:"message"`
+	Score   float64 `json:"score"`
+}
+
+// ContentReport is the outcome of analysing an email's content for likely spam
+type ContentReport struct {
+	Score     float64          `json:"score"`
+	Threshold float64          `json:"threshold"`
+	Marketing bool             `json:"marketing"` // The category is a marketing category
+	Enforced  bool             `json:"enforced"`  // The tenant's marketing emails at or above the threshold are rejected
+	Blocked   bool             `json:"blocked"`   // The email would be rejected
+	Warnings  []ContentWarning `json:"warnings"`
+}
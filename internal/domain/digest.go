@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DigestInterval is how long a digest collects notifications before it is sent
+type DigestInterval string
+
+const (
+	DigestIntervalHourly DigestInterval = "hourly"
+	DigestIntervalDaily  DigestInterval = "daily"
+)
+
+// Duration returns the interval's length, or 0 if the interval is unknown
+func (i DigestInterval) Duration() time.Duration {
+	switch i {
+	case DigestIntervalHourly:
+		return time.Hour
+	case DigestIntervalDaily:
+		return 24 * time.Hour
+	default:
+		return 0
+	}
+}
+
+// DigestStatus represents the state of a digest
+type DigestStatus string
+
+const (
+	DigestStatusOpen    DigestStatus = "open"    // Collecting notifications
+	DigestStatusSending DigestStatus = "sending" // Claimed for delivery
+	DigestStatusSent    DigestStatus = "sent"
+	DigestStatusFailed  DigestStatus = "failed" // Could not be rendered or sent; will not be retried
+)
+
+// DigestOptions marks an email for a recipient's digest instead of immediate delivery
+type DigestOptions struct {
+	Key        string         `json:"key" binding:"required,max=100"`                         // Groups notifications into one digest, e.g. "comments"
+	Interval   DigestInterval `json:"interval" binding:"required,oneof=hourly daily"`         // Sent this long after the first notification
+	Threshold  int            `json:"threshold,omitempty" binding:"omitempty,min=1,max=1000"` // Sent early once this many notifications are collected
+	TemplateID string         `json:"template_id,omitempty"`                                  // Email template rendered with the collected items
+	UserID     string         `json:"user_id,omitempty"`                                      // Recipient's user, whose quiet hours delay delivery
+}
+
+// DigestItem is one notification collected into a digest
+type DigestItem struct {
+	Subject   string            `json:"subject" bson:"subject"`
+	Body      string            `json:"body" bson:"body"`
+	Variables map[string]string `json:"variables,omitempty" bson:"variables,omitempty"`
+	Category  string            `json:"category,omitempty" bson:"category,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty" bson:"metadata,omitempty"`
+	CreatedAt time.Time         `json:"created_at" bson:"createdAt"`
+}
+
+// Digest collects a recipient's notifications for one digest key until it is sent
+// Settings other than the key come from the digest's first notification.
+type Digest struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TenantID    string             `json:"tenant_id" bson:"tenantId"`
+	Recipient   string             `json:"recipient" bson:"recipient"`
+	Key         string             `json:"key" bson:"key"`
+	UserID      string             `json:"user_id,omitempty" bson:"userId,omitempty"`
+	TemplateID  string             `json:"template_id,omitempty" bson:"templateId,omitempty"`
+	Category    string             `json:"category,omitempty" bson:"category,omitempty"`
+	Interval    DigestInterval     `json:"interval" bson:"interval"`
+	Threshold   int                `json:"threshold,omitempty" bson:"threshold,omitempty"`
+	Items       []DigestItem       `json:"items" bson:"items"`
+	ItemCount   int                `json:"item_count" bson:"itemCount"`
+	Status      DigestStatus       `json:"status" bson:"status"`
+	DueAt       time.Time          `json:"due_at" bson:"dueAt"`
+	Attempts    int                `json:"attempts" bson:"attempts"` // Failed deliveries so far
+	LastError   string             `json:"last_error,omitempty" bson:"lastError,omitempty"`
+	ClaimedAt   *time.Time         `json:"claimed_at,omitempty" bson:"claimedAt,omitempty"`
+	CompletedAt *time.Time         `json:"completed_at,omitempty" bson:"completedAt,omitempty"`
+	CreatedAt   time.Time          `json:"created_at" bson:"createdAt"`
+	UpdatedAt   time.Time          `json:"updated_at" bson:"updatedAt"`
+}
+
+// DigestData is what a digest template is rendered with
+// Templates iterate the items with {{range .Items}}{{.Subject}}{{end}}.
+type DigestData struct {
+	Key       string
+	Recipient string
+	Count     int
+	Items     []DigestItem
+}
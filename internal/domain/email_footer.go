@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MaxEmailFooterBytes bounds a footer's variants together
+const MaxEmailFooterBytes = 16 * 1024
+
+// UnsubscribeURLPlaceholder is replaced in a footer's unsubscribe variants with the URL from
+// the email's List-Unsubscribe header
+const UnsubscribeURLPlaceholder = "{{unsubscribe_url}}"
+
+// EmailFooter is a tenant's footer, such as a legal notice or signature, appended to every
+// email after its template is rendered. The unsubscribe variants are only added to emails
+// with a List-Unsubscribe URL, such as marketing emails.
+type EmailFooter struct {
+	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TenantID        string             `json:"tenant_id" bson:"tenantId"`
+	HTML            string             `json:"html,omitempty" bson:"html,omitempty"`
+	Text            string             `json:"text,omitempty" bson:"text,omitempty"`
+	UnsubscribeHTML string             `json:"unsubscribe_html,omitempty" bson:"unsubscribeHtml,omitempty"`
+	UnsubscribeText string             `json:"unsubscribe_text,omitempty" bson:"unsubscribeText,omitempty"`
+	CreatedAt       time.Time          `json:"created_at" bson:"createdAt"`
+	UpdatedAt       time.Time          `json:"updated_at" bson:"updatedAt"`
+}
+
+// SetEmailFooterRequest replaces a tenant's email footer
+// At least one of HTML and Text is required; the variants together may be at most MaxEmailFooterBytes.
+type SetEmailFooterRequest struct {
+	HTML            string `json:"html,omitempty" binding:"required_without=Text"`
+	Text            string `json:"text,omitempty" binding:"required_without=HTML"`
+	UnsubscribeHTML string `json:"unsubscribe_html,omitempty"` // Must contain UnsubscribeURLPlaceholder
+	UnsubscribeText string `json:"unsubscribe_text,omitempty"` // Must contain UnsubscribeURLPlaceholder
+}
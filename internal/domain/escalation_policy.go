@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EscalationMetadataKey is the request metadata key tagging escalation notices with the notification they escalate
+const EscalationMetadataKey = "escalation_of"
+
+// EscalationStep is one step of a tenant's escalation chain
+type EscalationStep struct {
+	After   int             `json:"after_seconds" bson:"afterSeconds" binding:"required,min=60,max=604800"` // Since the notification was sent
+	Channel FallbackChannel `json:"channel" bson:"channel" binding:"required"`
+}
+
+// EscalationPolicy is a tenant's chain of steps for critical notifications that aren't
+// acknowledged in time. Each step notifies its channel once the notification has gone
+// unacknowledged for the step's time since it was sent.
+type EscalationPolicy struct {
+	ID             primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TenantID       string             `json:"tenant_id" bson:"tenantId"`
+	Steps          []EscalationStep   `json:"steps" bson:"steps"`
+	StopOnDelivery bool               `json:"stop_on_delivery" bson:"stopOnDelivery"` // Delivery counts as acknowledgment
+	CreatedAt      time.Time          `json:"created_at" bson:"createdAt"`            // Notifications sent earlier aren't escalated
+	UpdatedAt      time.Time          `json:"updated_at" bson:"updatedAt"`
+}
+
+// UpdateEscalationPolicyRequest represents a request to replace a tenant's escalation policy
+type UpdateEscalationPolicyRequest struct {
+	Steps          []EscalationStep `json:"steps" binding:"required,min=1,max=10,dive"`
+	StopOnDelivery bool             `json:"stop_on_delivery"`
+}
+
+// EscalationRecord records one escalation step taken for a notification
+type EscalationRecord struct {
+	ID             primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TenantID       string             `json:"tenant_id" bson:"tenantId"`
+	NotificationID string             `json:"notification_id" bson:"notificationId"`
+	Step           int                `json:"step" bson:"step"` // 1-based position in the policy
+	Channel        FallbackChannel    `json:"channel" bson:"channel"`
+	Status         NotificationStatus `json:"status" bson:"status"` // sent or failed
+	Error          string             `json:"error,omitempty" bson:"error,omitempty"`
+	CreatedAt      time.Time          `json:"created_at" bson:"createdAt"`
+}
@@ -0,0 +1,41 @@
+package domain
+
+// Request metadata keys tagging notifications with the A/B test variant they were sent with
+const (
+	ExperimentMetadataKey = "ab_experiment"
+	VariantMetadataKey    = "ab_variant"
+)
+
+// TemplateVariant is one template tried in an A/B test
+type TemplateVariant struct {
+	Name       string `json:"name" binding:"required,max=64"`
+	TemplateID string `json:"template_id" binding:"required"`
+	Weight     int    `json:"weight" binding:"required,min=1,max=1000"` // Share of recipients relative to the other variants
+}
+
+// ABTestOptions splits an email's recipients between template variants by weight.
+// Recipients are assigned by hashing the experiment and their address, so every send in
+// the same experiment reaches a recipient with the same variant.
+type ABTestOptions struct {
+	Experiment string            `json:"experiment" binding:"required,max=128"`
+	Variants   []TemplateVariant `json:"variants" binding:"required,min=2,max=10,dive"`
+}
+
+// VariantStats counts the notifications sent with one A/B test variant and how recipients engaged with them
+type VariantStats struct {
+	Variant        string  `json:"variant" bson:"_id"`
+	Total          int64   `json:"total" bson:"total"`
+	TotalSent      int64   `json:"total_sent" bson:"totalSent"`
+	TotalDelivered int64   `json:"total_delivered" bson:"totalDelivered"`
+	TotalFailed    int64   `json:"total_failed" bson:"totalFailed"`
+	TotalRead      int64   `json:"total_read" bson:"totalRead"`
+	TotalClicked   int64   `json:"total_clicked" bson:"totalClicked"`
+	OpenRate       float64 `json:"open_rate" bson:"-"`  // Read or clicked, of those sent
+	ClickRate      float64 `json:"click_rate" bson:"-"` // Clicked, of those sent
+}
+
+// ExperimentReport compares the variants of an A/B test
+type ExperimentReport struct {
+	Experiment string         `json:"experiment"`
+	Variants   []VariantStats `json:"variants"`
+}
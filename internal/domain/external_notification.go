@@ -0,0 +1,27 @@
+package domain
+
+// MaxExternalIDLength is the longest external ID, in characters, a notification may be keyed by
+const MaxExternalIDLength = 200
+
+// UpsertNotificationRequest creates or replaces the notification a tenant keys by its own
+// external ID. Every content field is replaced on update; Status and Priority are kept when omitted.
+type UpsertNotificationRequest struct {
+	Type      NotificationType     `json:"type" binding:"required,oneof=email webhook sms"`
+	Recipient string               `json:"recipient" binding:"required"`
+	Subject   string               `json:"subject,omitempty"`
+	Body      string               `json:"body,omitempty"`
+	IsHTML    bool                 `json:"is_html,omitempty"`
+	Payload   map[string]any       `json:"payload,omitempty"`
+	Status    NotificationStatus   `json:"status,omitempty" binding:"omitempty,oneof=pending queued sending sent delivered failed bounced read clicked"` // Defaults to pending on create
+	Priority  NotificationPriority `json:"priority,omitempty" binding:"omitempty,oneof=critical high normal low"`                                        // Defaults to normal on create
+	Tags      []string             `json:"tags,omitempty"`
+	Category  string               `json:"category,omitempty"`
+	GroupID   string               `json:"group_id,omitempty"`
+	Metadata  map[string]string    `json:"metadata,omitempty"`
+}
+
+// UpsertNotificationResponse is the notification after an upsert and whether it was created
+type UpsertNotificationResponse struct {
+	Notification *Notification `json:"notification"`
+	Created      bool          `json:"created"`
+}
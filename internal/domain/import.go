@@ -0,0 +1,28 @@
+package domain
+
+// MaxImportRows bounds the rows imported by one request
+const MaxImportRows = 10000
+
+// Outcomes of importing one row
+const (
+	ImportStatusCreated = "created" // No record had the row's key, so one was created
+	ImportStatusUpdated = "updated" // The record with the row's key was replaced
+	ImportStatusFailed  = "failed"  // The row was invalid or couldn't be written
+)
+
+// ImportRowResult reports the outcome of importing one row
+type ImportRowResult struct {
+	Row    int    `json:"row"`           // 1 is the file's first record; a CSV header isn't counted
+	Key    string `json:"key,omitempty"` // Template name or user ID
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ImportReport summarizes a bulk import of templates or preferences
+type ImportReport struct {
+	Total   int               `json:"total"`
+	Created int               `json:"created"`
+	Updated int               `json:"updated"`
+	Failed  int               `json:"failed"`
+	Results []ImportRowResult `json:"results"`
+}
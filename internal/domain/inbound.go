@@ -0,0 +1,8 @@
+package domain
+
+// Metadata keys set on inbound email replies
+const (
+	InboundProviderMetadataKey  = "inbound_provider"   // Provider whose inbound webhook delivered the reply
+	InboundMessageIDMetadataKey = "inbound_message_id" // The reply's Message-ID
+	InboundHTMLMetadataKey      = "inbound_html"       // "true" when the body is HTML because the reply had no text part
+)
@@ -1,140 +1,214 @@
-package domain
-
-import (
-	"time"
-
-	"go.mongodb.org/mongo-driver/bson/primitive"
-)
-
-// NotificationType represents the type of notification
-type NotificationType string
-
-const (
-	NotificationTypeEmail   NotificationType = "email"
-	NotificationTypeWebhook NotificationType = "webhook"
-	NotificationTypeSMS     NotificationType = "sms"
-)
-
-// NotificationPriority represents the priority level of a notification
-type NotificationPriority string
-
-const (
-	NotificationPriorityCritical NotificationPriority = "critical" // Immediate delivery, bypass rate limits
-	NotificationPriorityHigh     NotificationPriority = "high"     // High priority, fast processing
-	NotificationPriorityNormal   NotificationPriority = "normal"   // Standard priority
-	NotificationPriorityLow      NotificationPriority = "low"      // Low priority, can be delayed
-)
-
-// NotificationStatus represents the status of a notification
-type NotificationStatus string
-
-const (
-	NotificationStatusPending   NotificationStatus = "pending"
-	NotificationStatusQueued    NotificationStatus = "queued"    // Queued for processing
-	NotificationStatusSending   NotificationStatus = "sending"   // Currently being sent
-	NotificationStatusSent      NotificationStatus = "sent"      // Successfully sent to provider
-	NotificationStatusDelivered NotificationStatus = "delivered" // Confirmed delivered to recipient
-	NotificationStatusFailed    NotificationStatus = "failed"    // Failed to send
-	NotificationStatusBounced   NotificationStatus = "bounced"   // Email bounced
-	NotificationStatusRead      NotificationStatus = "read"      // Recipient opened/read the notification
-	NotificationStatusClicked   NotificationStatus = "clicked"   // Recipient clicked links in notification
-)
-
-// Notification represents a notification record
-type Notification struct {
-	ID             primitive.ObjectID   `json:"id" bson:"_id,omitempty"`
-	TenantID       string               `json:"tenant_id" bson:"tenantId"`
-	Type           NotificationType     `json:"type" bson:"type"`
-	Status         NotificationStatus   `json:"status" bson:"status"`
-	Priority       NotificationPriority `json:"priority" bson:"priority"`
-	Recipient      string               `json:"recipient" bson:"recipient"`
-	Subject        string               `json:"subject,omitempty" bson:"subject,omitempty"`
-	Body           string               `json:"body,omitempty" bson:"body,omitempty"`
-	Payload        map[string]any       `json:"payload,omitempty" bson:"payload,omitempty"`
-	Error          string               `json:"error,omitempty" bson:"error,omitempty"`
-	RetryCount     int                  `json:"retry_count" bson:"retryCount"`
-	IdempotencyKey string               `json:"idempotency_key,omitempty" bson:"idempotencyKey,omitempty"`
-	Tags           []string             `json:"tags,omitempty" bson:"tags,omitempty"`
-	Category       string               `json:"category,omitempty" bson:"category,omitempty"`
-	GroupID        string               `json:"group_id,omitempty" bson:"groupId,omitempty"`
-	ParentID       string               `json:"parent_id,omitempty" bson:"parentId,omitempty"`
-	Metadata       map[string]string    `json:"metadata,omitempty" bson:"metadata,omitempty"`
-	SentAt         *time.Time           `json:"sent_at,omitempty" bson:"sentAt,omitempty"`
-	DeliveredAt    *time.Time           `json:"delivered_at,omitempty" bson:"deliveredAt,omitempty"`
-	ReadAt         *time.Time           `json:"read_at,omitempty" bson:"readAt,omitempty"`
-	ClickedAt      *time.Time           `json:"clicked_at,omitempty" bson:"clickedAt,omitempty"`
-	ExpiresAt      *time.Time           `json:"expires_at,omitempty" bson:"expiresAt,omitempty"`
-	ScheduledFor   *time.Time           `json:"scheduled_for,omitempty" bson:"scheduledFor,omitempty"`
-	Version        int                  `json:"version" bson:"version"`
-	CreatedAt      time.Time            `json:"created_at" bson:"createdAt"`
-	UpdatedAt      time.Time            `json:"updated_at" bson:"updatedAt"`
-	DeletedAt      *time.Time           `json:"deleted_at,omitempty" bson:"deletedAt,omitempty"`
-}
-
-// EmailTemplate represents an email template
-type EmailTemplate struct {
-	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	TenantID  string             `json:"tenant_id" bson:"tenantId"`
-	Name      string             `json:"name" bson:"name"`
-	Subject   string             `json:"subject" bson:"subject"`
-	Body      string             `json:"body" bson:"body"`
-	IsHTML    bool               `json:"is_html" bson:"isHtml"`
-	Variables []string           `json:"variables,omitempty" bson:"variables,omitempty"`
-	Version   int                `json:"version" bson:"version"`
-	CreatedAt time.Time          `json:"created_at" bson:"createdAt"`
-	UpdatedAt time.Time          `json:"updated_at" bson:"updatedAt"`
-	DeletedAt *time.Time         `json:"deleted_at,omitempty" bson:"deletedAt,omitempty"`
-}
-
-// EventType represents the type of event
-type EventType string
-
-const (
-	EventUserRegistered    EventType = "user.registered"
-	EventUserPasswordReset EventType = "user.password_reset"
-	EventTenantCreated     EventType = "tenant.created"
-	EventPaymentCompleted  EventType = "payment.completed"
-)
-
-// Event represents an event from RabbitMQ
-type Event struct {
-	Type      EventType      `json:"type"`
-	TenantID  string         `json:"tenant_id"`
-	UserID    string         `json:"user_id,omitempty"`
-	Email     string         `json:"email,omitempty"`
-	Data      map[string]any `json:"data,omitempty"`
-	Timestamp time.Time      `json:"timestamp"`
-}
-
-// FailedNotification represents a notification that failed after all retries
-type FailedNotification struct {
-	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	OriginalID primitive.ObjectID `json:"original_id" bson:"originalId"`
-	TenantID   string             `json:"tenant_id" bson:"tenantId"`
-	Type       NotificationType   `json:"type" bson:"type"`
-	Recipient  string             `json:"recipient" bson:"recipient"`
-	Subject    string             `json:"subject,omitempty" bson:"subject,omitempty"`
-	Body       string             `json:"body,omitempty" bson:"body,omitempty"`
-	Payload    map[string]any     `json:"payload,omitempty" bson:"payload,omitempty"`
-	Error      string             `json:"error" bson:"error"`
-	FailedAt   time.Time          `json:"failed_at" bson:"failedAt"`
-	RetryCount int                `json:"retry_count" bson:"retryCount"`
-	Version    int                `json:"version" bson:"version"`
-	CreatedAt  time.Time          `json:"created_at" bson:"createdAt"`
-	UpdatedAt  time.Time          `json:"updated_at" bson:"updatedAt"`
-	DeletedAt  *time.Time         `json:"deleted_at,omitempty" bson:"deletedAt,omitempty"`
-}
-
-// EmailBounce represents an email bounce record
-type EmailBounce struct {
-	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	TenantID  string             `json:"tenant_id" bson:"tenantId"`
-	Email     string             `json:"email" bson:"email"`
-	Type      string             `json:"type" bson:"type"` // hard, soft, complaint
-	Reason    string             `json:"reason" bson:"reason"`
-	Timestamp time.Time          `json:"timestamp" bson:"timestamp"`
-	Version   int                `json:"version" bson:"version"`
-	CreatedAt time.Time          `json:"created_at" bson:"createdAt"`
-	UpdatedAt time.Time          `json:"updated_at" bson:"updatedAt"`
-	DeletedAt *time.Time         `json:"deleted_at,omitempty" bson:"deletedAt,omitempty"`
-}
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// NotificationType represents the type of notification
+type NotificationType string
+
+const (
+	NotificationTypeEmail   NotificationType = "email"
+	NotificationTypeWebhook NotificationType = "webhook"
+	NotificationTypeSMS     NotificationType = "sms"
+)
+
+// NotificationPriority represents the priority level of a notification
+type NotificationPriority string
+
+const (
+	NotificationPriorityCritical NotificationPriority = "critical" // Immediate delivery, bypass rate limits
+	NotificationPriorityHigh     NotificationPriority = "high"     // High priority, fast processing
+	NotificationPriorityNormal   NotificationPriority = "normal"   // Standard priority
+	NotificationPriorityLow      NotificationPriority = "low"      // Low priority, can be delayed
+)
+
+// ParseNotificationPriority parses a priority name (critical, high, normal, low)
+func ParseNotificationPriority(value string) (NotificationPriority, error) {
+	switch priority := NotificationPriority(strings.ToLower(strings.TrimSpace(value))); priority {
+	case NotificationPriorityCritical, NotificationPriorityHigh, NotificationPriorityNormal, NotificationPriorityLow:
+		return priority, nil
+	default:
+		return "", fmt.Errorf("invalid priority: %s (must be critical, high, normal or low)", value)
+	}
+}
+
+// NotificationStatus represents the status of a notification
+type NotificationStatus string
+
+const (
+	NotificationStatusPending   NotificationStatus = "pending"
+	NotificationStatusQueued    NotificationStatus = "queued"    // Queued for processing
+	NotificationStatusSending   NotificationStatus = "sending"   // Currently being sent
+	NotificationStatusSent      NotificationStatus = "sent"      // Successfully sent to provider
+	NotificationStatusDelivered NotificationStatus = "delivered" // Confirmed delivered to recipient
+	NotificationStatusFailed    NotificationStatus = "failed"    // Failed to send
+	NotificationStatusBounced   NotificationStatus = "bounced"   // Email bounced
+	NotificationStatusRead      NotificationStatus = "read"      // Recipient opened/read the notification
+	NotificationStatusClicked   NotificationStatus = "clicked"   // Recipient clicked links in notification
+	NotificationStatusReceived  NotificationStatus = "received"  // Inbound reply from the recipient, linked to the original by ParentID
+
+	// NotificationStatusPartial reports a send result for an email that was sent to some recipients
+	// and rejected for others. It is never stored; the notification is recorded as sent.
+	NotificationStatusPartial NotificationStatus = "partial"
+)
+
+// Notification represents a notification record
+type Notification struct {
+	ID                   primitive.ObjectID     `json:"id" bson:"_id,omitempty"`
+	TenantID             string                 `json:"tenant_id" bson:"tenantId"`
+	Type                 NotificationType       `json:"type" bson:"type"`
+	Status               NotificationStatus     `json:"status" bson:"status"`
+	Priority             NotificationPriority   `json:"priority" bson:"priority"`
+	Recipient            string                 `json:"recipient" bson:"recipient"`
+	Subject              string                 `json:"subject,omitempty" bson:"subject,omitempty"`
+	Body                 string                 `json:"body,omitempty" bson:"body,omitempty"`
+	IsHTML               bool                   `json:"is_html,omitempty" bson:"isHtml,omitempty"` // Body is HTML; only recorded for emails
+	Payload              map[string]any         `json:"payload,omitempty" bson:"payload,omitempty"`
+	Error                string                 `json:"error,omitempty" bson:"error,omitempty"`
+	Response             *WebhookResponse       `json:"response,omitempty" bson:"response,omitempty"`                     // Last webhook receiver response
+	Provider             string                 `json:"provider,omitempty" bson:"provider,omitempty"`                     // Provider that accepted the send, e.g. smtp or sendgrid
+	ProviderMessageID    string                 `json:"provider_message_id,omitempty" bson:"providerMessageId,omitempty"` // Provider's id for the message, matched against its delivery events
+	DeliveryID           string                 `json:"delivery_id,omitempty" bson:"deliveryId,omitempty"`                // Webhook delivery ID sent as X-Webhook-Id, the same for every retry
+	Recipients           []EmailRecipientResult `json:"recipients,omitempty" bson:"recipients,omitempty"`                 // Each recipient's outcome when the email was rejected for some recipients
+	RetryCount           int                    `json:"retry_count" bson:"retryCount"`
+	IdempotencyKey       string                 `json:"idempotency_key,omitempty" bson:"idempotencyKey,omitempty"`
+	IdempotencyExpiresAt *time.Time             `json:"idempotency_expires_at,omitempty" bson:"idempotencyExpiresAt,omitempty"` // End of the deduplication window; the key may be reused afterwards
+	ExternalID           string                 `json:"external_id,omitempty" bson:"externalId,omitempty"`                      // Caller's own identifier, unique per tenant; see UpsertByExternalID
+	Tags                 []string               `json:"tags,omitempty" bson:"tags,omitempty"`
+	Category             string                 `json:"category,omitempty" bson:"category,omitempty"`
+	GroupID              string                 `json:"group_id,omitempty" bson:"groupId,omitempty"`
+	ParentID             string                 `json:"parent_id,omitempty" bson:"parentId,omitempty"`
+	Metadata             map[string]string      `json:"metadata,omitempty" bson:"metadata,omitempty"`
+	SentAt               *time.Time             `json:"sent_at,omitempty" bson:"sentAt,omitempty"`
+	DeliveredAt          *time.Time             `json:"delivered_at,omitempty" bson:"deliveredAt,omitempty"`
+	ReadAt               *time.Time             `json:"read_at,omitempty" bson:"readAt,omitempty"`
+	ClickedAt            *time.Time             `json:"clicked_at,omitempty" bson:"clickedAt,omitempty"`
+	AcknowledgedAt       *time.Time             `json:"acknowledged_at,omitempty" bson:"acknowledgedAt,omitempty"` // Explicitly confirmed by the recipient or their app
+	AckMetadata          map[string]string      `json:"ack_metadata,omitempty" bson:"ackMetadata,omitempty"`       // Sent with the acknowledgment
+	EscalationStep       int                    `json:"escalation_step,omitempty" bson:"escalationStep,omitempty"` // Steps of the tenant's escalation policy taken so far
+	EscalatedAt          *time.Time             `json:"escalated_at,omitempty" bson:"escalatedAt,omitempty"`
+	ExpiresAt            *time.Time             `json:"expires_at,omitempty" bson:"expiresAt,omitempty"`
+	ContentRedactedAt    *time.Time             `json:"content_redacted_at,omitempty" bson:"contentRedactedAt,omitempty"` // Subject, body and payload were removed by the content redaction job
+	ScheduledFor         *time.Time             `json:"scheduled_for,omitempty" bson:"scheduledFor,omitempty"`
+	Version              int                    `json:"version" bson:"version"`
+	CreatedAt            time.Time              `json:"created_at" bson:"createdAt"`
+	UpdatedAt            time.Time              `json:"updated_at" bson:"updatedAt"`
+	DeletedAt            *time.Time             `json:"deleted_at,omitempty" bson:"deletedAt,omitempty"`
+}
+
+// WebhookResponse captures a bounded snippet of a webhook receiver's response for diagnostics
+type WebhookResponse struct {
+	StatusCode int    `json:"status_code" bson:"statusCode"`
+	Body       string `json:"body,omitempty" bson:"body,omitempty"`
+	Truncated  bool   `json:"truncated,omitempty" bson:"truncated,omitempty"`
+}
+
+// MaxPreheaderLength is the longest preheader, in characters, a template or email may set
+const MaxPreheaderLength = 150
+
+// Template represents a stored notification template for one channel.
+// Email templates use Subject, Body, IsHTML and optionally LocalizedSubjects, Preheader and Charset;
+// SMS templates use Body as the message; webhook templates use Payload, whose string values
+// are rendered with the variables.
+type Template struct {
+	ID                primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TenantID          string             `json:"tenant_id" bson:"tenantId"`
+	Name              string             `json:"name" bson:"name"`
+	Channel           NotificationType   `json:"channel,omitempty" bson:"channel,omitempty"` // Empty for templates created before channels, treated as email
+	Subject           string             `json:"subject" bson:"subject"`
+	LocalizedSubjects map[string]string  `json:"localized_subjects,omitempty" bson:"localizedSubjects,omitempty"` // Locale (e.g. de or pt-BR) to subject, used in place of Subject
+	Preheader         string             `json:"preheader,omitempty" bson:"preheader,omitempty"`                  // Inbox preview text, hidden at the top of HTML emails
+	Body              string             `json:"body" bson:"body"`
+	MJML              string             `json:"mjml,omitempty" bson:"mjml,omitempty"`       // Email source compiled into Body when the template is saved
+	Charset           string             `json:"charset,omitempty" bson:"charset,omitempty"` // Charset emails are sent in, e.g. iso-8859-1; empty is UTF-8
+	IsHTML            bool               `json:"is_html" bson:"isHtml"`
+	Payload           map[string]any     `json:"payload,omitempty" bson:"payload,omitempty"`
+	Variables         []string           `json:"variables,omitempty" bson:"variables,omitempty"`
+	Version           int                `json:"version" bson:"version"`
+	CreatedAt         time.Time          `json:"created_at" bson:"createdAt"`
+	UpdatedAt         time.Time          `json:"updated_at" bson:"updatedAt"`
+	DeletedAt         *time.Time         `json:"deleted_at,omitempty" bson:"deletedAt,omitempty"`
+}
+
+// EmailTemplate is the original name of Template, kept for existing email callers
+type EmailTemplate = Template
+
+// EventType represents the type of event
+type EventType string
+
+const (
+	EventUserRegistered    EventType = "user.registered"
+	EventUserPasswordReset EventType = "user.password_reset"
+	EventTenantCreated     EventType = "tenant.created"
+	EventPaymentCompleted  EventType = "payment.completed"
+)
+
+// Event schema versions
+// Version 1 payloads predate the schema_version field, so a missing version is treated as 1.
+const (
+	EventSchemaV1 = 1
+	EventSchemaV2 = 2 // Adds event_id and renames timestamp to occurred_at
+
+	CurrentEventSchemaVersion = EventSchemaV2
+)
+
+// Event represents an event from RabbitMQ
+// JSON tags describe the current schema version; older versions are upgraded on decode.
+type Event struct {
+	SchemaVersion int            `json:"schema_version"`
+	ID            string         `json:"event_id,omitempty"`
+	Type          EventType      `json:"type"`
+	TenantID      string         `json:"tenant_id"`
+	UserID        string         `json:"user_id,omitempty"`
+	Email         string         `json:"email,omitempty"`
+	Data          map[string]any `json:"data,omitempty"`
+	Timestamp     time.Time      `json:"occurred_at"`
+}
+
+// FailedNotification represents a notification that failed after all retries
+type FailedNotification struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	OriginalID primitive.ObjectID `json:"original_id" bson:"originalId"`
+	TenantID   string             `json:"tenant_id" bson:"tenantId"`
+	Type       NotificationType   `json:"type" bson:"type"`
+	Recipient  string             `json:"recipient" bson:"recipient"`
+	Subject    string             `json:"subject,omitempty" bson:"subject,omitempty"`
+	Body       string             `json:"body,omitempty" bson:"body,omitempty"`
+	Payload    map[string]any     `json:"payload,omitempty" bson:"payload,omitempty"`
+	Request    *FailedRequest     `json:"request,omitempty" bson:"request,omitempty"` // Full send request to replay on retry, when captured
+	Error      string             `json:"error" bson:"error"`
+	FailedAt   time.Time          `json:"failed_at" bson:"failedAt"`
+	RetryCount int                `json:"retry_count" bson:"retryCount"`
+	Version    int                `json:"version" bson:"version"`
+	CreatedAt  time.Time          `json:"created_at" bson:"createdAt"`
+	UpdatedAt  time.Time          `json:"updated_at" bson:"updatedAt"`
+	DeletedAt  *time.Time         `json:"deleted_at,omitempty" bson:"deletedAt,omitempty"`
+}
+
+// FailedRequest holds the send request behind a failed notification, so a retry
+// resends CC/BCC recipients, attachments and options rather than only the
+// recipient, subject and body. Exactly one field is set, matching the notification type.
+type FailedRequest struct {
+	Email   *SendEmailRequest   `json:"email,omitempty" bson:"email,omitempty"`
+	SMS     *SendSMSRequest     `json:"sms,omitempty" bson:"sms,omitempty"`
+	Webhook *SendWebhookRequest `json:"webhook,omitempty" bson:"webhook,omitempty"`
+}
+
+// EmailBounce represents an email bounce record
+type EmailBounce struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TenantID  string             `json:"tenant_id" bson:"tenantId"`
+	Email     string             `json:"email" bson:"email"`
+	Type      string             `json:"type" bson:"type"` // hard, soft, complaint
+	Reason    string             `json:"reason" bson:"reason"`
+	Timestamp time.Time          `json:"timestamp" bson:"timestamp"`
+	Version   int                `json:"version" bson:"version"`
+	CreatedAt time.Time          `json:"created_at" bson:"createdAt"`
+	UpdatedAt time.Time          `json:"updated_at" bson:"updatedAt"`
+	DeletedAt *time.Time         `json:"deleted_at,omitempty" bson:"deletedAt,omitempty"`
+}
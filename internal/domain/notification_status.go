@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MaxStatusBatchSize is the most notifications whose status can be polled in one request
+const MaxStatusBatchSize = 500
+
+// NotificationStatusRequest polls the status of several notifications at once
+type NotificationStatusRequest struct {
+	IDs []string `json:"ids" binding:"required,min=1,max=500,dive,required"`
+}
+
+// NotificationStatusResult is a notification's current delivery status and its timestamps
+type NotificationStatusResult struct {
+	ID             primitive.ObjectID     `json:"id" bson:"_id"`
+	Type           NotificationType       `json:"type" bson:"type"`
+	Status         NotificationStatus     `json:"status" bson:"status"`
+	Error          string                 `json:"error,omitempty" bson:"error,omitempty"`
+	RetryCount     int                    `json:"retry_count" bson:"retryCount"`
+	SentAt         *time.Time             `json:"sent_at,omitempty" bson:"sentAt,omitempty"`
+	DeliveredAt    *time.Time             `json:"delivered_at,omitempty" bson:"deliveredAt,omitempty"`
+	ReadAt         *time.Time             `json:"read_at,omitempty" bson:"readAt,omitempty"`
+	ClickedAt      *time.Time             `json:"clicked_at,omitempty" bson:"clickedAt,omitempty"`
+	AcknowledgedAt *time.Time             `json:"acknowledged_at,omitempty" bson:"acknowledgedAt,omitempty"`
+	CreatedAt      time.Time              `json:"created_at" bson:"createdAt"`
+	UpdatedAt      time.Time              `json:"updated_at" bson:"updatedAt"`
+	Recipients     []EmailRecipientResult `json:"recipients,omitempty" bson:"recipients,omitempty"` // Each recipient's outcome when the email was rejected for some recipients
+}
+
+// NotificationStatusResponse holds the statuses found, in request order, and the IDs that weren't
+type NotificationStatusResponse struct {
+	Data     []*NotificationStatusResult `json:"data"`
+	NotFound []string                    `json:"not_found"`
+}
@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OrchestrationStrategy decides which of an orchestrated notification's channels are sent
+type OrchestrationStrategy string
+
+const (
+	OrchestrationStrategyAll          OrchestrationStrategy = "all"           // Every channel is sent at once
+	OrchestrationStrategyFirstSuccess OrchestrationStrategy = "first_success" // Channels are tried in order until one is sent
+	OrchestrationStrategyEscalate     OrchestrationStrategy = "escalate"      // The next channel is sent if the last one isn't delivered in time
+)
+
+// OrchestrationStatus represents the state of an orchestrated notification
+type OrchestrationStatus string
+
+const (
+	OrchestrationStatusActive       OrchestrationStatus = "active"       // Waiting to escalate to the next channel
+	OrchestrationStatusEscalating   OrchestrationStatus = "escalating"   // Claimed for escalation
+	OrchestrationStatusAcknowledged OrchestrationStatus = "acknowledged" // A channel was delivered or the caller acknowledged it
+	OrchestrationStatusCompleted    OrchestrationStatus = "completed"    // Every channel the strategy calls for was sent
+	OrchestrationStatusFailed       OrchestrationStatus = "failed"       // No channel could be sent
+)
+
+// OrchestrateRequest sends one notification over several channels according to a strategy
+// Channels are listed in order; each holds the content for its channel.
+type OrchestrateRequest struct {
+	TenantID       string                `json:"tenant_id,omitempty"` // Injected from auth context
+	Strategy       OrchestrationStrategy `json:"strategy" binding:"required,oneof=all first_success escalate"`
+	Channels       []BatchSendItem       `json:"channels" binding:"required,min=1,max=5,dive"`
+	EscalateAfter  int                   `json:"escalate_after_seconds,omitempty" binding:"omitempty,min=30,max=86400"` // Required for escalate
+	GroupID        string                `json:"group_id,omitempty"`                                                    // Shared by every channel's notification; generated if omitted
+	IdempotencyKey string                `json:"idempotency_key,omitempty"`                                             // Channel keys are derived as "<key>:<index>"
+}
+
+// Orchestration tracks an escalating notification until a channel is delivered or acknowledged,
+// or it runs out of channels
+type Orchestration struct {
+	ID             primitive.ObjectID    `json:"id" bson:"_id,omitempty"`
+	TenantID       string                `json:"tenant_id" bson:"tenantId"`
+	GroupID        string                `json:"group_id" bson:"groupId"`
+	IdempotencyKey string                `json:"idempotency_key" bson:"idempotencyKey"`
+	Strategy       OrchestrationStrategy `json:"strategy" bson:"strategy"`
+	Channels       []BatchSendItem       `json:"-" bson:"channels"`
+	Results        []BatchItemResult     `json:"results" bson:"results"` // One per channel tried so far
+	Next           int                   `json:"next" bson:"next"`       // Index of the channel escalated to next
+	EscalateAfter  int                   `json:"escalate_after_seconds" bson:"escalateAfterSeconds"`
+	Status         OrchestrationStatus   `json:"status" bson:"status"`
+	DueAt          time.Time             `json:"due_at" bson:"dueAt"`
+	ClaimedAt      *time.Time            `json:"claimed_at,omitempty" bson:"claimedAt,omitempty"`
+	CompletedAt    *time.Time            `json:"completed_at,omitempty" bson:"completedAt,omitempty"`
+	CreatedAt      time.Time             `json:"created_at" bson:"createdAt"`
+	UpdatedAt      time.Time             `json:"updated_at" bson:"updatedAt"`
+}
+
+// OrchestrationResponse reports the channels an orchestrated notification was sent over
+type OrchestrationResponse struct {
+	ID             string                `json:"id,omitempty"` // Set when the notification may still escalate
+	GroupID        string                `json:"group_id"`
+	IdempotencyKey string                `json:"idempotency_key"`
+	Strategy       OrchestrationStrategy `json:"strategy"`
+	Status         OrchestrationStatus   `json:"status"`
+	Results        []BatchItemResult     `json:"results"`
+	EscalateAt     *time.Time            `json:"escalate_at,omitempty"`
+}
@@ -15,6 +15,36 @@ const (
 	OutboxEventStatusFailed    OutboxEventStatus = "failed"
 )
 
+// OutboxPayloadMode selects how much of an entity an outbox event's payload carries
+type OutboxPayloadMode string
+
+const (
+	OutboxPayloadFull    OutboxPayloadMode = "full"    // The entity's fields as of the event
+	OutboxPayloadCompact OutboxPayloadMode = "compact" // IDs and the values that changed
+)
+
+// OutboxPayloadEncoding describes how an outbox event's payload is stored
+type OutboxPayloadEncoding string
+
+const (
+	OutboxPayloadPlain OutboxPayloadEncoding = ""     // A document
+	OutboxPayloadGzip  OutboxPayloadEncoding = "gzip" // The payload's JSON, gzip-compressed, as binary
+)
+
+// OutboxPayloadRef points to the document an oversized payload was left out for
+type OutboxPayloadRef struct {
+	Collection string `bson:"collection" json:"collection"`
+	ID         string `bson:"id" json:"id"`
+	Size       int    `bson:"size" json:"size"` // Bytes of the omitted payload, as JSON
+}
+
+// OutboxPayloadOptions controls how outbox event payloads are stored
+type OutboxPayloadOptions struct {
+	Mode     OutboxPayloadMode
+	MaxBytes int  // Payloads larger than this, as stored, are replaced by a PayloadRef; 0 is unlimited
+	Compress bool // Store payloads gzip-compressed when that makes them smaller
+}
+
 // OutboxEventType represents the type of domain event
 type OutboxEventType string
 
@@ -56,6 +86,11 @@ type OutboxEvent struct {
 	EventType OutboxEventType `bson:"eventType" json:"eventType"` // "notification.created", etc.
 	Payload   interface{}     `bson:"payload" json:"payload"`     // JSON payload with event data
 
+	// Payload Storage
+	PayloadMode     OutboxPayloadMode     `bson:"payloadMode,omitempty" json:"payloadMode,omitempty"`         // Empty is full
+	PayloadEncoding OutboxPayloadEncoding `bson:"payloadEncoding,omitempty" json:"payloadEncoding,omitempty"` // Empty is a plain document
+	PayloadRef      *OutboxPayloadRef     `bson:"payloadRef,omitempty" json:"payloadRef,omitempty"`           // Set, and Payload left empty, for oversized payloads
+
 	// Distributed Tracing (Phase 2 - CRITICAL for OpenTelemetry)
 	TraceID string `bson:"traceId" json:"traceId"` // OpenTelemetry trace ID
 	SpanID  string `bson:"spanId" json:"spanId"`   // OpenTelemetry span ID
@@ -67,6 +102,13 @@ type OutboxEvent struct {
 	LastError   string            `bson:"lastError,omitempty" json:"lastError"` // Last error message
 }
 
+// OutboxPendingStats summarizes pending outbox events for a tenant
+type OutboxPendingStats struct {
+	TenantID        string    `bson:"_id" json:"tenantId"`
+	Count           int64     `bson:"count" json:"count"`
+	OldestCreatedAt time.Time `bson:"oldestCreatedAt" json:"oldestCreatedAt"`
+}
+
 // NotificationCreatedPayload represents the payload for notification.created event
 type NotificationCreatedPayload struct {
 	NotificationID string             `json:"notificationId"`
@@ -101,6 +143,7 @@ type NotificationDeletedPayload struct {
 	NotificationID string    `json:"notificationId"`
 	TenantID       string    `json:"tenantId"`
 	DeletedAt      time.Time `json:"deletedAt"`
+	Erased         bool      `json:"erased,omitempty"` // Permanently deleted for an erasure request; consumers should purge their copies
 }
 
 // TemplateCreatedPayload represents the payload for template.created event
@@ -140,10 +183,14 @@ type ScheduledNotificationCreatedPayload struct {
 
 // ScheduledNotificationExecutedPayload represents the payload for scheduled_notification.executed event
 type ScheduledNotificationExecutedPayload struct {
-	ScheduleID     string    `json:"scheduleId"`
-	TenantID       string    `json:"tenantId"`
-	NotificationID string    `json:"notificationId"`
-	ExecutedAt     time.Time `json:"executedAt"`
+	ScheduleID      string                  `json:"scheduleId"`
+	TenantID        string                  `json:"tenantId"`
+	ExecutionID     string                  `json:"executionId"`
+	Status          ScheduleExecutionStatus `json:"status"`
+	Error           string                  `json:"error,omitempty"`
+	NotificationID  string                  `json:"notificationId"` // First resulting notification, if any
+	NotificationIDs []string                `json:"notificationIds,omitempty"`
+	ExecutedAt      time.Time               `json:"executedAt"`
 }
 
 // ScheduledNotificationCanceledPayload represents the payload for scheduled_notification.canceled event
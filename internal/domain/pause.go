@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SendPause is the service-wide send pause state, shared by every instance.
+// While All is set every channel is paused; otherwise only the listed channels are.
+type SendPause struct {
+	All       bool               `json:"all" bson:"all"`
+	Channels  []NotificationType `json:"channels,omitempty" bson:"channels,omitempty"`
+	Reason    string             `json:"reason,omitempty" bson:"reason,omitempty"`
+	UpdatedBy string             `json:"updated_by,omitempty" bson:"updatedBy,omitempty"`
+	UpdatedAt time.Time          `json:"updated_at" bson:"updatedAt"`
+}
+
+// HeldStatus represents the state of a held notification
+type HeldStatus string
+
+const (
+	HeldStatusHeld    HeldStatus = "held"    // Waiting for its channel to resume; also assumed when unset
+	HeldStatusSending HeldStatus = "sending" // Claimed by a drain; deleted once sent
+)
+
+// HeldNotification is a send request accepted while its channel was paused.
+// Held notifications are sent in creation order once the channel resumes.
+type HeldNotification struct {
+	ID        primitive.ObjectID   `json:"id" bson:"_id,omitempty"`
+	TenantID  string               `json:"tenant_id" bson:"tenantId"`
+	Type      NotificationType     `json:"type" bson:"type"`
+	Priority  NotificationPriority `json:"priority,omitempty" bson:"priority,omitempty"`
+	Request   json.RawMessage      `json:"request" bson:"request"` // JSON-encoded SendEmailRequest, SendSMSRequest or SendWebhookRequest
+	Status    HeldStatus           `json:"status,omitempty" bson:"status,omitempty"`
+	Attempts  int                  `json:"attempts,omitempty" bson:"attempts,omitempty"` // Failed sends so far
+	LastError string               `json:"last_error,omitempty" bson:"lastError,omitempty"`
+	ClaimedAt *time.Time           `json:"claimed_at,omitempty" bson:"claimedAt,omitempty"`
+	FailedAt  *time.Time           `json:"failed_at,omitempty" bson:"failedAt,omitempty"` // Last failed send
+	CreatedAt time.Time            `json:"created_at" bson:"createdAt"`
+}
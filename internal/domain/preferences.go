@@ -1,42 +1,84 @@
-package domain
-
-import (
-	"time"
-
-	"go.mongodb.org/mongo-driver/bson/primitive"
-)
-
-// ScheduledNotification represents a scheduled notification
-type ScheduledNotification struct {
-	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	TenantID  string             `json:"tenant_id" bson:"tenantId"`
-	Type      NotificationType   `json:"type" bson:"type"`         // email, sms, webhook
-	Schedule  string             `json:"schedule" bson:"schedule"` // cron expression
-	Request   interface{}        `json:"request" bson:"request"`
-	NextRunAt time.Time          `json:"next_run_at" bson:"nextRunAt"`
-	LastRunAt *time.Time         `json:"last_run_at,omitempty" bson:"lastRunAt,omitempty"`
-	IsActive  bool               `json:"is_active" bson:"isActive"`
-	Version   int                `json:"version" bson:"version"`
-	CreatedAt time.Time          `json:"created_at" bson:"createdAt"`
-	UpdatedAt time.Time          `json:"updated_at" bson:"updatedAt"`
-	DeletedAt *time.Time         `json:"deleted_at,omitempty" bson:"deletedAt,omitempty"`
-}
-
-// NotificationPreferences represents user notification preferences
-type NotificationPreferences struct {
-	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	TenantID        string             `json:"tenant_id" bson:"tenantId"`
-	UserID          string             `json:"user_id" bson:"userId"`
-	EmailEnabled    bool               `json:"email_enabled" bson:"emailEnabled"`
-	SMSEnabled      bool               `json:"sms_enabled" bson:"smsEnabled"`
-	WebhookEnabled  bool               `json:"webhook_enabled" bson:"webhookEnabled"`
-	EmailCategories map[string]bool    `json:"email_categories" bson:"emailCategories"` // marketing: false, alerts: true
-	SMSCategories   map[string]bool    `json:"sms_categories" bson:"smsCategories"`
-	QuietHoursStart string             `json:"quiet_hours_start" bson:"quietHoursStart"` // "22:00"
-	QuietHoursEnd   string             `json:"quiet_hours_end" bson:"quietHoursEnd"`     // "08:00"
-	Timezone        string             `json:"timezone" bson:"timezone"`
-	Version         int                `json:"version" bson:"version"`
-	CreatedAt       time.Time          `json:"created_at" bson:"createdAt"`
-	UpdatedAt       time.Time          `json:"updated_at" bson:"updatedAt"`
-	DeletedAt       *time.Time         `json:"deleted_at,omitempty" bson:"deletedAt,omitempty"`
-}
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ScheduledNotification represents a scheduled notification
+type ScheduledNotification struct {
+	ID           primitive.ObjectID      `json:"id" bson:"_id,omitempty"`
+	TenantID     string                  `json:"tenant_id" bson:"tenantId"`
+	Type         NotificationType        `json:"type" bson:"type"`                             // email, sms, webhook
+	Schedule     string                  `json:"schedule" bson:"schedule"`                     // cron expression
+	Timezone     string                  `json:"timezone,omitempty" bson:"timezone,omitempty"` // IANA timezone the schedule runs in; empty means server local time
+	Request      interface{}             `json:"request" bson:"request"`
+	NextRunAt    time.Time               `json:"next_run_at" bson:"nextRunAt"`
+	LastRunAt    *time.Time              `json:"last_run_at,omitempty" bson:"lastRunAt,omitempty"`
+	LastStatus   ScheduleExecutionStatus `json:"last_status,omitempty" bson:"lastStatus,omitempty"`
+	LastError    string                  `json:"last_error,omitempty" bson:"lastError,omitempty"` // Error of the last run, cleared when a run succeeds
+	RunCount     int                     `json:"run_count" bson:"runCount"`
+	FailureCount int                     `json:"failure_count" bson:"failureCount"`
+	IsActive     bool                    `json:"is_active" bson:"isActive"`
+	Version      int                     `json:"version" bson:"version"`
+	CreatedAt    time.Time               `json:"created_at" bson:"createdAt"`
+	UpdatedAt    time.Time               `json:"updated_at" bson:"updatedAt"`
+	DeletedAt    *time.Time              `json:"deleted_at,omitempty" bson:"deletedAt,omitempty"`
+}
+
+// NotificationPreferences represents user notification preferences
+type NotificationPreferences struct {
+	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TenantID        string             `json:"tenant_id" bson:"tenantId"`
+	UserID          string             `json:"user_id" bson:"userId"`
+	EmailEnabled    bool               `json:"email_enabled" bson:"emailEnabled"`
+	SMSEnabled      bool               `json:"sms_enabled" bson:"smsEnabled"`
+	WebhookEnabled  bool               `json:"webhook_enabled" bson:"webhookEnabled"`
+	EmailCategories map[string]bool    `json:"email_categories" bson:"emailCategories"` // marketing: false, alerts: true
+	SMSCategories   map[string]bool    `json:"sms_categories" bson:"smsCategories"`
+	QuietHoursStart string             `json:"quiet_hours_start" bson:"quietHoursStart"` // "22:00"
+	QuietHoursEnd   string             `json:"quiet_hours_end" bson:"quietHoursEnd"`     // "08:00"
+	Timezone        string             `json:"timezone" bson:"timezone"`
+	IsDefault       bool               `json:"is_default" bson:"-"` // The user hasn't set preferences; these are the defaults. Never stored
+	Version         int                `json:"version" bson:"version"`
+	CreatedAt       time.Time          `json:"created_at" bson:"createdAt"`
+	UpdatedAt       time.Time          `json:"updated_at" bson:"updatedAt"`
+	DeletedAt       *time.Time         `json:"deleted_at,omitempty" bson:"deletedAt,omitempty"`
+}
+
+// PreferenceCategories holds the notification categories a tenant allows in user preferences
+type PreferenceCategories struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TenantID   string             `json:"tenant_id" bson:"tenantId"`
+	Categories []string           `json:"categories" bson:"categories"`
+	Version    int                `json:"version" bson:"version"`
+	CreatedAt  time.Time          `json:"created_at" bson:"createdAt"`
+	UpdatedAt  time.Time          `json:"updated_at" bson:"updatedAt"`
+	DeletedAt  *time.Time         `json:"deleted_at,omitempty" bson:"deletedAt,omitempty"`
+}
+
+// PreferencesPatchRequest represents a partial preferences update; only set fields are changed
+type PreferencesPatchRequest struct {
+	EmailEnabled    *bool           `json:"email_enabled,omitempty"`
+	SMSEnabled      *bool           `json:"sms_enabled,omitempty"`
+	WebhookEnabled  *bool           `json:"webhook_enabled,omitempty"`
+	EmailCategories map[string]bool `json:"email_categories,omitempty"` // Only listed categories are changed
+	SMSCategories   map[string]bool `json:"sms_categories,omitempty"`
+	QuietHoursStart *string         `json:"quiet_hours_start,omitempty"`
+	QuietHoursEnd   *string         `json:"quiet_hours_end,omitempty"`
+	Timezone        *string         `json:"timezone,omitempty"`
+}
+
+// BulkCategoryUpdateRequest toggles a single category for many users
+type BulkCategoryUpdateRequest struct {
+	UserIDs  []string         `json:"user_ids" binding:"required,min=1,max=1000"`
+	Channel  NotificationType `json:"channel" binding:"required,oneof=email sms"`
+	Category string           `json:"category" binding:"required"`
+	Enabled  *bool            `json:"enabled" binding:"required"`
+}
+
+// UpdatePreferenceCategoriesRequest replaces a tenant's preference category list
+type UpdatePreferenceCategoriesRequest struct {
+	Categories []string `json:"categories" binding:"required,max=200"`
+}
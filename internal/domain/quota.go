@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// QuotaPolicy controls what happens when a tenant exceeds a send quota
+type QuotaPolicy string
+
+const (
+	QuotaPolicyHard QuotaPolicy = "hard" // Reject sends over the quota
+	QuotaPolicySoft QuotaPolicy = "soft" // Allow sends over the quota with a warning
+)
+
+// QuotaPeriod identifies the window a quota applies to
+type QuotaPeriod string
+
+const (
+	QuotaPeriodDaily   QuotaPeriod = "daily"
+	QuotaPeriodMonthly QuotaPeriod = "monthly"
+)
+
+// TenantQuota overrides the default send quota for a tenant and channel
+// Records are managed by the billing/plan system; a limit of 0 means unlimited.
+type TenantQuota struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TenantID     string             `json:"tenant_id" bson:"tenantId"`
+	Channel      NotificationType   `json:"channel" bson:"channel"`
+	DailyLimit   int64              `json:"daily_limit" bson:"dailyLimit"`
+	MonthlyLimit int64              `json:"monthly_limit" bson:"monthlyLimit"`
+	Policy       QuotaPolicy        `json:"policy,omitempty" bson:"policy,omitempty"` // Empty uses the service default
+	Version      int                `json:"version" bson:"version"`
+	CreatedAt    time.Time          `json:"created_at" bson:"createdAt"`
+	UpdatedAt    time.Time          `json:"updated_at" bson:"updatedAt"`
+	DeletedAt    *time.Time         `json:"deleted_at,omitempty" bson:"deletedAt,omitempty"`
+}
+
+// QuotaUsage counts sends for a tenant, channel and period
+type QuotaUsage struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TenantID  string             `json:"tenant_id" bson:"tenantId"`
+	Channel   NotificationType   `json:"channel" bson:"channel"`
+	Period    QuotaPeriod        `json:"period" bson:"period"`
+	PeriodKey string             `json:"period_key" bson:"periodKey"` // e.g. "2024-03-01" or "2024-03"
+	Count     int64              `json:"count" bson:"count"`
+	ExpiresAt time.Time          `json:"expires_at" bson:"expiresAt"`
+}
+
+// QuotaPeriodUsage reports usage against a quota for one period
+type QuotaPeriodUsage struct {
+	Used     int64     `json:"used"`
+	Limit    int64     `json:"limit"` // 0 means unlimited
+	ResetsAt time.Time `json:"resets_at"`
+}
+
+// QuotaUsageReport reports a tenant's usage and quotas for one channel
+type QuotaUsageReport struct {
+	Channel NotificationType `json:"channel"`
+	Policy  QuotaPolicy      `json:"policy"`
+	Daily   QuotaPeriodUsage `json:"daily"`
+	Monthly QuotaPeriodUsage `json:"monthly"`
+}
+
+// RateLimitStatus reports a tenant's API rate limit and how many requests it can make immediately
+type RateLimitStatus struct {
+	RequestsPerSecond float64 `json:"requests_per_second"`
+	Burst             int     `json:"burst"`
+	TokensAvailable   float64 `json:"tokens_available"`
+}
+
+// TenantLimitsReport reports a tenant's rate limit state and quota usage
+type TenantLimitsReport struct {
+	TenantID  string             `json:"tenant_id"`
+	RateLimit RateLimitStatus    `json:"rate_limit"`
+	Quotas    []QuotaUsageReport `json:"quotas,omitempty"` // Omitted when quotas are disabled
+}
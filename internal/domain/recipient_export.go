@@ -0,0 +1,18 @@
+package domain
+
+import "time"
+
+// RecipientExport is everything a tenant's notification data holds about one recipient,
+// returned for data subject access requests
+type RecipientExport struct {
+	TenantID            string                     `json:"tenant_id"`
+	Recipient           string                     `json:"recipient"`
+	GeneratedAt         time.Time                  `json:"generated_at"`
+	Notifications       []*Notification            `json:"notifications"` // Includes stored subject, body and payload
+	Truncated           bool                       `json:"truncated"`     // More notifications exist than the export limit
+	Events              []*NotificationEvent       `json:"events"`
+	FailedNotifications []*FailedNotification      `json:"failed_notifications"`
+	Bounces             []*EmailBounce             `json:"bounces"` // Suppression entries for the address
+	Preferences         []*NotificationPreferences `json:"preferences"`
+	RecipientLists      []*RecipientList           `json:"recipient_lists"` // Lists containing the recipient, with only their member entry
+}
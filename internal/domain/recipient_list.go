@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RecipientList is a tenant's named list of email recipients that sends can reference by ID
+type RecipientList struct {
+	ID          primitive.ObjectID    `json:"id" bson:"_id,omitempty"`
+	TenantID    string                `json:"tenant_id" bson:"tenantId"`
+	Name        string                `json:"name" bson:"name"`
+	Description string                `json:"description,omitempty" bson:"description,omitempty"`
+	Members     []RecipientListMember `json:"members,omitempty" bson:"members"`
+	MemberCount int                   `json:"member_count" bson:"memberCount"`
+	Version     int                   `json:"version" bson:"version"`
+	CreatedAt   time.Time             `json:"created_at" bson:"createdAt"`
+	UpdatedAt   time.Time             `json:"updated_at" bson:"updatedAt"`
+	DeletedAt   *time.Time            `json:"deleted_at,omitempty" bson:"deletedAt,omitempty"`
+}
+
+// RecipientListMember is one address on a recipient list
+// Unsubscribed members stay on the list but are skipped when it is expanded.
+type RecipientListMember struct {
+	Address        string     `json:"address" bson:"address"`
+	UserID         string     `json:"user_id,omitempty" bson:"userId,omitempty"` // Links the member to user preferences
+	Subscribed     bool       `json:"subscribed" bson:"subscribed"`
+	AddedAt        time.Time  `json:"added_at" bson:"addedAt"`
+	UnsubscribedAt *time.Time `json:"unsubscribed_at,omitempty" bson:"unsubscribedAt,omitempty"`
+}
+
+// RecipientListMemberInput is a member to add to a recipient list
+type RecipientListMemberInput struct {
+	Address string `json:"address" binding:"required,email"`
+	UserID  string `json:"user_id,omitempty"`
+}
+
+// CreateRecipientListRequest represents a request to create a recipient list
+type CreateRecipientListRequest struct {
+	Name        string                     `json:"name" binding:"required,max=200"`
+	Description string                     `json:"description,omitempty" binding:"max=1000"`
+	Members     []RecipientListMemberInput `json:"members,omitempty" binding:"dive"`
+}
+
+// UpdateRecipientListRequest represents a request to rename or describe a recipient list
+type UpdateRecipientListRequest struct {
+	Name        string `json:"name" binding:"required,max=200"`
+	Description string `json:"description,omitempty" binding:"max=1000"`
+	Version     int    `json:"version" binding:"required,min=1"` // Version the change is based on, for optimistic locking
+}
+
+// AddRecipientListMembersRequest represents a request to add members to a recipient list
+// Members already on the list keep their subscription state.
+type AddRecipientListMembersRequest struct {
+	Members []RecipientListMemberInput `json:"members" binding:"required,min=1,dive"`
+}
+
+// RecipientListMembersRequest represents a request naming existing members of a recipient list
+type RecipientListMembersRequest struct {
+	Addresses []string `json:"addresses" binding:"required,min=1,dive,email"`
+}
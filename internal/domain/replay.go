@@ -0,0 +1,41 @@
+package domain
+
+import "encoding/json"
+
+// MaxReplayEvents bounds the events replayed by one request
+const MaxReplayEvents = 1000
+
+// Outcomes of replaying one event
+const (
+	ReplayStatusProcessed = "processed" // Passed to event processing, which succeeded
+	ReplayStatusFailed    = "failed"    // Event processing returned an error
+	ReplayStatusValid     = "valid"     // Dry run: the event decoded and would be processed
+	ReplayStatusInvalid   = "invalid"   // Undecodable, or for another tenant
+	ReplayStatusPaused    = "paused"    // Not processed because email sends are paused; replay it once they resume
+)
+
+// ReplayEventsRequest submits raw event payloads to process directly, bypassing RabbitMQ
+type ReplayEventsRequest struct {
+	Events []json.RawMessage `json:"events" binding:"required,min=1,max=1000"`
+	DryRun bool              `json:"dry_run"` // Decode and validate the events without processing them
+}
+
+// EventReplayResult reports the outcome of replaying one event
+type EventReplayResult struct {
+	Index   int       `json:"index"`
+	EventID string    `json:"event_id,omitempty"`
+	Type    EventType `json:"type,omitempty"`
+	Status  string    `json:"status"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// EventReplayReport summarizes an event replay
+type EventReplayReport struct {
+	DryRun    bool                `json:"dry_run"`
+	Total     int                 `json:"total"`
+	Processed int                 `json:"processed"`
+	Failed    int                 `json:"failed"`
+	Invalid   int                 `json:"invalid"`
+	Paused    int                 `json:"paused"`
+	Results   []EventReplayResult `json:"results"`
+}
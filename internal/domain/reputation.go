@@ -0,0 +1,41 @@
+package domain
+
+import "time"
+
+// Operator overrides of a tenant's automatic bounce pause
+const (
+	ReputationOverridePause  = "pause"  // Keep the tenant's email paused whatever its rates
+	ReputationOverrideResume = "resume" // Keep the tenant's email sending whatever its rates
+)
+
+// TenantReputation is a tenant's recent email bounce and complaint rates and whether its email is
+// paused because of them. Rates are over the monitor's rolling window; a tenant is paused
+// automatically when either rate passes its threshold, unless an operator overrides it.
+type TenantReputation struct {
+	TenantID      string     `json:"tenant_id" bson:"_id"`
+	Sent          int64      `json:"sent" bson:"sent"`
+	Bounces       int64      `json:"bounces" bson:"bounces"` // Hard bounces; soft bounces are retried and not counted
+	Complaints    int64      `json:"complaints" bson:"complaints"`
+	BounceRate    float64    `json:"bounce_rate" bson:"bounceRate"`
+	ComplaintRate float64    `json:"complaint_rate" bson:"complaintRate"`
+	Paused        bool       `json:"paused" bson:"paused"` // In effect, overrides included
+	PausedReason  string     `json:"paused_reason,omitempty" bson:"pausedReason,omitempty"`
+	PausedAt      *time.Time `json:"paused_at,omitempty" bson:"pausedAt,omitempty"`
+	Override      string     `json:"override,omitempty" bson:"override,omitempty"` // pause or resume
+	OverrideBy    string     `json:"override_by,omitempty" bson:"overrideBy,omitempty"`
+	OverrideUntil *time.Time `json:"override_until,omitempty" bson:"overrideUntil,omitempty"`
+	UpdatedAt     time.Time  `json:"updated_at" bson:"updatedAt"`
+}
+
+// BounceCounts counts a tenant's hard bounces and complaints
+type BounceCounts struct {
+	TenantID   string `bson:"_id"`
+	Bounces    int64  `bson:"bounces"`
+	Complaints int64  `bson:"complaints"`
+}
+
+// OverrideReputationRequest represents an operator's override of a tenant's automatic bounce pause
+type OverrideReputationRequest struct {
+	Action string `json:"action" binding:"required,oneof=pause resume clear"` // clear returns the tenant to automatic control
+	Hours  int    `json:"hours,omitempty" binding:"omitempty,min=1,max=720"`  // How long the override lasts; defaults to 24
+}
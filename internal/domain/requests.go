@@ -1,129 +1,291 @@
-package domain
-
-import (
-	"time"
-)
-
-// SendEmailRequest represents a request to send an email
-type SendEmailRequest struct {
-	TenantID       string               `json:"tenant_id,omitempty"` // Injected from auth context
-	To             []string             `json:"to" binding:"required,min=1"`
-	CC             []string             `json:"cc,omitempty"`
-	BCC            []string             `json:"bcc,omitempty"`
-	Subject        string               `json:"subject" binding:"required"`
-	Body           string               `json:"body" binding:"required"`
-	IsHTML         bool                 `json:"is_html"`
-	TemplateID     string               `json:"template_id,omitempty"`
-	Variables      map[string]string    `json:"variables,omitempty"`
-	Attachments    []Attachment         `json:"attachments,omitempty"`
-	Priority       NotificationPriority `json:"priority,omitempty"`
-	IdempotencyKey string               `json:"idempotency_key,omitempty"`
-	Tags           []string             `json:"tags,omitempty"`
-	Category       string               `json:"category,omitempty"`
-	GroupID        string               `json:"group_id,omitempty"`
-	ParentID       string               `json:"parent_id,omitempty"`
-	Metadata       map[string]string    `json:"metadata,omitempty"`
-	ExpiresAt      *time.Time           `json:"expires_at,omitempty"`
-	ScheduledFor   *time.Time           `json:"scheduled_for,omitempty"`
-	TrackOpens     bool                 `json:"track_opens,omitempty"`
-	TrackClicks    bool                 `json:"track_clicks,omitempty"`
-}
-
-// Attachment represents an email attachment
-type Attachment struct {
-	Filename string `json:"filename" binding:"required"`
-	Content  []byte `json:"content" binding:"required"`
-	MimeType string `json:"mime_type"`
-}
-
-// SendWebhookRequest represents a request to send a webhook
-type SendWebhookRequest struct {
-	TenantID       string               `json:"tenant_id,omitempty"` // Injected from auth context
-	URL            string               `json:"url" binding:"required,url"`
-	Method         string               `json:"method"`
-	Headers        map[string]string    `json:"headers,omitempty"`
-	Payload        map[string]any       `json:"payload" binding:"required"`
-	Timeout        int                  `json:"timeout,omitempty"`
-	Priority       NotificationPriority `json:"priority,omitempty"`
-	IdempotencyKey string               `json:"idempotency_key,omitempty"`
-	Tags           []string             `json:"tags,omitempty"`
-	Category       string               `json:"category,omitempty"`
-	GroupID        string               `json:"group_id,omitempty"`
-	Metadata       map[string]string    `json:"metadata,omitempty"`
-	RetryAttempts  int                  `json:"retry_attempts,omitempty"`
-}
-
-// GetNotificationsRequest represents a request to get notifications
-type GetNotificationsRequest struct {
-	TenantID string               `form:"tenant_id,omitempty"` // Injected from auth context
-	Type     NotificationType     `form:"type"`
-	Status   NotificationStatus   `form:"status"`
-	Priority NotificationPriority `form:"priority"`
-	Category string               `form:"category"`
-	GroupID  string               `form:"group_id"`
-	Tags     []string             `form:"tags"`
-	Page     int                  `form:"page"`
-	PageSize int                  `form:"page_size"`
-}
-
-// SendSMSRequest represents a request to send an SMS
-type SendSMSRequest struct {
-	TenantID       string               `json:"tenant_id,omitempty"` // Injected from auth context
-	To             string               `json:"to" binding:"required"`
-	Message        string               `json:"message" binding:"required"`
-	Priority       NotificationPriority `json:"priority,omitempty"`
-	IdempotencyKey string               `json:"idempotency_key,omitempty"`
-	Tags           []string             `json:"tags,omitempty"`
-	Category       string               `json:"category,omitempty"`
-	GroupID        string               `json:"group_id,omitempty"`
-	Metadata       map[string]string    `json:"metadata,omitempty"`
-	ScheduledFor   *time.Time           `json:"scheduled_for,omitempty"`
-}
-
-// BulkEmailRequest represents a request to send bulk emails
-type BulkEmailRequest struct {
-	TenantID       string            `json:"tenant_id,omitempty"` // Injected from auth context
-	Recipients     []string          `json:"recipients" binding:"required,min=1"`
-	Subject        string            `json:"subject" binding:"required"`
-	Body           string            `json:"body" binding:"required"`
-	IsHTML         bool              `json:"is_html"`
-	TemplateID     string            `json:"template_id,omitempty"`
-	Variables      map[string]string `json:"variables,omitempty"`
-	Priority       int               `json:"priority"`
-	IdempotencyKey string            `json:"idempotency_key,omitempty"`
-	Tags           []string          `json:"tags,omitempty"`
-	Category       string            `json:"category,omitempty"`
-	GroupID        string            `json:"group_id,omitempty"`
-	Metadata       map[string]string `json:"metadata,omitempty"`
-	TrackOpens     bool              `json:"track_opens,omitempty"`
-	TrackClicks    bool              `json:"track_clicks,omitempty"`
-}
-
-// NotificationStatusUpdate represents a status update for a notification
-type NotificationStatusUpdate struct {
-	NotificationID string             `json:"notification_id" binding:"required"`
-	Status         NotificationStatus `json:"status" binding:"required"`
-	Timestamp      time.Time          `json:"timestamp"`
-	IPAddress      string             `json:"ip_address,omitempty"`
-	UserAgent      string             `json:"user_agent,omitempty"`
-	LinkClicked    string             `json:"link_clicked,omitempty"`
-}
-
-// NotificationSearchRequest represents advanced search criteria
-type NotificationSearchRequest struct {
-	TenantID  string               `form:"tenant_id" binding:"required"`
-	Type      NotificationType     `form:"type"`
-	Status    NotificationStatus   `form:"status"`
-	Priority  NotificationPriority `form:"priority"`
-	Category  string               `form:"category"`
-	GroupID   string               `form:"group_id"`
-	Tags      []string             `form:"tags"`
-	Recipient string               `form:"recipient"`
-	Subject   string               `form:"subject"`
-	FromDate  *time.Time           `form:"from_date"`
-	ToDate    *time.Time           `form:"to_date"`
-	Page      int                  `form:"page"`
-	PageSize  int                  `form:"page_size"`
-	SortBy    string               `form:"sort_by"`    // created_at, sent_at, priority
-	SortOrder string               `form:"sort_order"` // asc, desc
-}
+package domain
+
+import (
+	"time"
+)
+
+// SendEmailRequest represents a request to send an email
+type SendEmailRequest struct {
+	TenantID        string               `json:"tenant_id,omitempty"` // Injected from auth context
+	To              []string             `json:"to" binding:"required_without=RecipientListID,omitempty,min=1"`
+	RecipientListID string               `json:"recipient_list_id,omitempty"` // Adds the list's subscribed members to To at send time
+	CC              []string             `json:"cc,omitempty"`
+	BCC             []string             `json:"bcc,omitempty"`
+	Subject         string               `json:"subject" binding:"required"`
+	Body            string               `json:"body" binding:"required"`
+	IsHTML          bool                 `json:"is_html"`
+	AMPHTML         string               `json:"amp_html,omitempty"`                              // AMP for Email alternative; requires an HTML body
+	Preheader       string               `json:"preheader,omitempty" binding:"omitempty,max=150"` // Inbox preview text for HTML bodies; at most MaxPreheaderLength characters
+	TemplateID      string               `json:"template_id,omitempty"`
+	Locale          string               `json:"locale,omitempty" binding:"omitempty,max=35"` // Picks the template's subject for this locale, e.g. de or pt-BR
+	Variables       map[string]string    `json:"variables,omitempty"`
+	Attachments     []Attachment         `json:"attachments,omitempty"`
+	Priority        NotificationPriority `json:"priority,omitempty" binding:"omitempty,oneof=critical high normal low"`
+	IdempotencyKey  string               `json:"idempotency_key,omitempty"` // Deduplicates sends within the idempotency window (IDEMPOTENCY_KEY_TTL_HOURS)
+	Tags            []string             `json:"tags,omitempty"`
+	Category        string               `json:"category,omitempty"`
+	GroupID         string               `json:"group_id,omitempty"`
+	ParentID        string               `json:"parent_id,omitempty"`
+	Metadata        map[string]string    `json:"metadata,omitempty"`
+	ExpiresAt       *time.Time           `json:"expires_at,omitempty"`
+	ScheduledFor    *time.Time           `json:"scheduled_for,omitempty"`
+	TrackOpens      bool                 `json:"track_opens,omitempty"`
+	TrackClicks     bool                 `json:"track_clicks,omitempty"`
+	ReplyTo         string               `json:"reply_to,omitempty" binding:"omitempty,email"`
+	Headers         map[string]string    `json:"headers,omitempty"`        // Custom X- headers, merged after validation
+	VerifyDomains   bool                 `json:"verify_domains,omitempty"` // Reject recipients whose domain has no MX or address record
+	Digest          *DigestOptions       `json:"digest,omitempty"`         // Collects the email into each recipient's digest instead of sending it now
+	SendAtLocal     *LocalSendOptions    `json:"send_at_local,omitempty"`  // Schedules the email for a local time of day in each recipient's timezone
+	ABTest          *ABTestOptions       `json:"ab_test,omitempty"`        // Sends each recipient one of several template variants
+	SkipFooter      bool                 `json:"skip_footer,omitempty"`    // Sends without the tenant's email footer
+	Charset         string               `json:"-"`                        // Charset of the body, from the email's template; empty is UTF-8
+}
+
+// Attachment represents an email attachment
+type Attachment struct {
+	Filename  string `json:"filename" binding:"required"`
+	Content   []byte `json:"content" binding:"required"`
+	MimeType  string `json:"mime_type"`
+	Inline    bool   `json:"inline,omitempty"`     // Embedded in the HTML body instead of offered as a download
+	ContentID string `json:"content_id,omitempty"` // Referenced from the HTML body as cid:<content_id>; required when inline
+}
+
+// SendWebhookRequest represents a request to send a webhook
+type SendWebhookRequest struct {
+	TenantID       string               `json:"tenant_id,omitempty"` // Injected from auth context
+	URL            string               `json:"url" binding:"required_without=URLs,excluded_with=URLs,omitempty,url"`
+	URLs           []string             `json:"urls,omitempty" binding:"omitempty,max=20,dive,url"` // Fans the webhook out to each URL independently
+	Method         string               `json:"method"`
+	Headers        map[string]string    `json:"headers,omitempty"`
+	Payload        map[string]any       `json:"payload" binding:"required_without=TemplateID"`
+	TemplateID     string               `json:"template_id,omitempty"` // Webhook template rendered in place of Payload
+	Variables      map[string]string    `json:"variables,omitempty"`
+	Encoding       string               `json:"encoding,omitempty" binding:"omitempty,oneof=json form xml"` // Payload serialization, defaults to json
+	ContentType    string               `json:"content_type,omitempty"`                                     // Overrides the Content-Type implied by Encoding
+	Timeout        int                  `json:"timeout,omitempty"`
+	Priority       NotificationPriority `json:"priority,omitempty" binding:"omitempty,oneof=critical high normal low"`
+	IdempotencyKey string               `json:"idempotency_key,omitempty"`
+	Tags           []string             `json:"tags,omitempty"`
+	Category       string               `json:"category,omitempty"`
+	GroupID        string               `json:"group_id,omitempty"`
+	Metadata       map[string]string    `json:"metadata,omitempty"`
+	RetryAttempts  int                  `json:"retry_attempts,omitempty"`
+	Fallback       *FallbackChannel     `json:"fallback,omitempty"`            // Notified if delivery fails after retries; overrides the tenant's fallback
+	DeliveryID     string               `json:"-" bson:"deliveryId,omitempty"` // Assigned before the first attempt and sent with every retry; see webhook.EnsureDeliveryID
+	RetryQueued    bool                 `json:"-" bson:"-"`                    // Set when the first attempt failed and a retry was queued; the webhook is pending, not sent
+}
+
+// GetNotificationsRequest represents a request to get notifications
+type GetNotificationsRequest struct {
+	TenantID string               `form:"tenant_id,omitempty"` // Injected from auth context
+	Type     NotificationType     `form:"type"`
+	Status   NotificationStatus   `form:"status"`
+	Priority NotificationPriority `form:"priority"`
+	Category string               `form:"category"`
+	GroupID  string               `form:"group_id"`
+	Tags     []string             `form:"tags"`
+	Page     int                  `form:"page"`
+	PageSize int                  `form:"page_size"`
+}
+
+// SendSMSRequest represents a request to send an SMS
+type SendSMSRequest struct {
+	TenantID       string               `json:"tenant_id,omitempty"` // Injected from auth context
+	To             string               `json:"to" binding:"required"`
+	Message        string               `json:"message" binding:"required_without=TemplateID"`
+	TemplateID     string               `json:"template_id,omitempty"` // SMS template rendered in place of Message
+	Variables      map[string]string    `json:"variables,omitempty"`
+	Priority       NotificationPriority `json:"priority,omitempty" binding:"omitempty,oneof=critical high normal low"`
+	IdempotencyKey string               `json:"idempotency_key,omitempty"`
+	Tags           []string             `json:"tags,omitempty"`
+	Category       string               `json:"category,omitempty"`
+	GroupID        string               `json:"group_id,omitempty"`
+	Metadata       map[string]string    `json:"metadata,omitempty"`
+	ScheduledFor   *time.Time           `json:"scheduled_for,omitempty"`
+}
+
+// BulkEmailRequest represents a request to send bulk emails
+type BulkEmailRequest struct {
+	TenantID       string               `json:"tenant_id,omitempty"` // Injected from auth context
+	Recipients     []string             `json:"recipients" binding:"required,min=1"`
+	Subject        string               `json:"subject" binding:"required"`
+	Body           string               `json:"body" binding:"required"`
+	IsHTML         bool                 `json:"is_html"`
+	TemplateID     string               `json:"template_id,omitempty"`
+	Variables      map[string]string    `json:"variables,omitempty"`
+	Priority       NotificationPriority `json:"priority,omitempty" binding:"omitempty,oneof=critical high normal low"` // Defaults to BULK_EMAIL_DEFAULT_PRIORITY
+	IdempotencyKey string               `json:"idempotency_key,omitempty"`
+	Tags           []string             `json:"tags,omitempty"`
+	Category       string               `json:"category,omitempty"`
+	GroupID        string               `json:"group_id,omitempty"`
+	Metadata       map[string]string    `json:"metadata,omitempty"`
+	TrackOpens     bool                 `json:"track_opens,omitempty"`
+	TrackClicks    bool                 `json:"track_clicks,omitempty"`
+	ScheduledFor   *time.Time           `json:"scheduled_for,omitempty"`
+	SendAtLocal    *LocalSendOptions    `json:"send_at_local,omitempty"` // Schedules each recipient's email for a local time of day; excludes scheduled_for
+}
+
+// BatchSendRequest represents a batch of mixed email, SMS and webhook send requests
+type BatchSendRequest struct {
+	TenantID       string          `json:"tenant_id,omitempty"`       // Injected from auth context
+	IdempotencyKey string          `json:"idempotency_key,omitempty"` // Item keys are derived as "<key>:<index>"
+	Items          []BatchSendItem `json:"items" binding:"required,min=1,max=100,dive"`
+}
+
+// BatchSendItem is a single send request within a batch; the payload must match Type
+type BatchSendItem struct {
+	Type    NotificationType    `json:"type" binding:"required,oneof=email sms webhook"`
+	Email   *SendEmailRequest   `json:"email,omitempty"`
+	SMS     *SendSMSRequest     `json:"sms,omitempty"`
+	Webhook *SendWebhookRequest `json:"webhook,omitempty"`
+}
+
+// WebhookTargetResult reports the outcome of a webhook fan-out to a single URL
+type WebhookTargetResult struct {
+	URL            string             `json:"url"`
+	IdempotencyKey string             `json:"idempotency_key"`
+	Status         NotificationStatus `json:"status"` // sent, pending (held while paused) or failed
+	Error          string             `json:"error,omitempty"`
+}
+
+// WebhookFanoutResponse is returned when a webhook is sent to multiple URLs
+type WebhookFanoutResponse struct {
+	GroupID        string                `json:"group_id"`
+	IdempotencyKey string                `json:"idempotency_key"`
+	Total          int                   `json:"total"`
+	Succeeded      int                   `json:"succeeded"`
+	Failed         int                   `json:"failed"`
+	Results        []WebhookTargetResult `json:"results"`
+}
+
+// EmailRecipientResult reports whether an email was sent to one of its recipients
+type EmailRecipientResult struct {
+	Recipient string             `json:"recipient" bson:"recipient"`
+	Status    NotificationStatus `json:"status" bson:"status"` // sent or failed
+	Error     string             `json:"error,omitempty" bson:"error,omitempty"`
+}
+
+// SendEmailResponse reports each recipient's outcome when an email was sent to some recipients
+// but rejected for others
+type SendEmailResponse struct {
+	Message   string                 `json:"message"`
+	Total     int                    `json:"total"`
+	Succeeded int                    `json:"succeeded"`
+	Failed    int                    `json:"failed"`
+	Results   []EmailRecipientResult `json:"results"`
+}
+
+// BatchItemResult reports the outcome of a single batch item
+type BatchItemResult struct {
+	Index          int                    `json:"index" bson:"index"`
+	Type           NotificationType       `json:"type" bson:"type"`
+	ID             string                 `json:"id,omitempty" bson:"id,omitempty"`
+	IdempotencyKey string                 `json:"idempotency_key" bson:"idempotencyKey"`
+	Status         NotificationStatus     `json:"status" bson:"status"` // sent, partial (rejected for some recipients), pending (held while paused) or failed
+	Error          string                 `json:"error,omitempty" bson:"error,omitempty"`
+	Recipients     []EmailRecipientResult `json:"recipients,omitempty" bson:"recipients,omitempty"` // Each recipient's outcome when the status is partial
+}
+
+// BatchSendResponse represents the per-item results of a batch send
+type BatchSendResponse struct {
+	IdempotencyKey string            `json:"idempotency_key"`
+	Total          int               `json:"total"`
+	Succeeded      int               `json:"succeeded"`
+	Failed         int               `json:"failed"`
+	Results        []BatchItemResult `json:"results"`
+}
+
+// Erasure modes for EraseRecipientRequest
+const (
+	ErasureModeSoft = "soft" // Soft delete; records are purged by the soft-delete retention job
+	ErasureModeHard = "hard" // Permanently delete and scrub related records (right to be forgotten)
+)
+
+// EraseRecipientRequest deletes every notification a tenant sent to one email address or phone number
+type EraseRecipientRequest struct {
+	Recipient string `json:"recipient" binding:"required,max=320"`
+	Mode      string `json:"mode,omitempty" binding:"omitempty,oneof=soft hard"` // Defaults to soft
+}
+
+// EraseRecipientResponse reports how many records a recipient erasure removed
+type EraseRecipientResponse struct {
+	Mode                string `json:"mode"`
+	Notifications       int64  `json:"notifications"`
+	FailedNotifications int64  `json:"failed_notifications"` // Dead letter queue entries; hard mode only
+	RecipientLists      int64  `json:"recipient_lists"`      // Lists the recipient was removed from; hard mode only
+	Schedules           int64  `json:"schedules"`            // Scheduled notifications changed or deleted; hard mode only
+	HeldNotifications   int64  `json:"held_notifications"`   // Sends held by a pause, changed or deleted; hard mode only
+	Digests             int64  `json:"digests"`              // Pending digests deleted; hard mode only
+}
+
+// RecipientExportRequest asks for everything stored about one email address or phone number
+type RecipientExportRequest struct {
+	Recipient string `json:"recipient" binding:"required,max=320"`
+	UserID    string `json:"user_id,omitempty"` // Also exports this user's notification preferences
+}
+
+// NotificationStatusUpdate represents a status update for a notification
+type NotificationStatusUpdate struct {
+	NotificationID string             `json:"notification_id" binding:"required"`
+	Status         NotificationStatus `json:"status" binding:"required"`
+	Timestamp      time.Time          `json:"timestamp"`
+	IPAddress      string             `json:"ip_address,omitempty"`
+	UserAgent      string             `json:"user_agent,omitempty"`
+	LinkClicked    string             `json:"link_clicked,omitempty"`
+}
+
+// NotificationSearchRequest represents advanced search criteria
+type NotificationSearchRequest struct {
+	TenantID  string               `form:"tenant_id" binding:"required"`
+	Type      NotificationType     `form:"type"`
+	Status    NotificationStatus   `form:"status"`
+	Priority  NotificationPriority `form:"priority"`
+	Category  string               `form:"category"`
+	GroupID   string               `form:"group_id"`
+	Tags      []string             `form:"tags"`
+	Recipient string               `form:"recipient"`
+	Subject   string               `form:"subject"`
+	FromDate  *time.Time           `form:"from_date"`
+	ToDate    *time.Time           `form:"to_date"`
+	Page      int                  `form:"page"`
+	PageSize  int                  `form:"page_size"`
+	SortBy    string               `form:"sort_by"`    // created_at, sent_at, priority
+	SortOrder string               `form:"sort_order"` // asc, desc
+}
+
+// PauseSendsRequest represents a request to pause sends; no channels pauses every channel
+type PauseSendsRequest struct {
+	Channels []NotificationType `json:"channels,omitempty" binding:"omitempty,dive,oneof=email sms webhook"`
+	Reason   string             `json:"reason,omitempty"`
+}
+
+// ResumeSendsRequest represents a request to resume sends; no channels resumes every channel
+type ResumeSendsRequest struct {
+	Channels []NotificationType `json:"channels,omitempty" binding:"omitempty,dive,oneof=email sms webhook"`
+}
+
+// MaxSMTPPoolSize bounds the SMTP connection pool size set at runtime
+const MaxSMTPPoolSize = 500
+
+// ResizeSMTPPoolRequest represents a request to change the number of pooled SMTP connections
+type ResizeSMTPPoolRequest struct {
+	Size int `json:"size" binding:"required,min=1,max=500"`
+}
+
+// ValidateScheduleRequest represents a request to check a schedule expression and preview its runs
+type ValidateScheduleRequest struct {
+	Expression string `json:"expression" binding:"required"`
+	Timezone   string `json:"timezone,omitempty"`                               // IANA timezone, e.g. Europe/Berlin; empty means server local time
+	Count      int    `json:"count,omitempty" binding:"omitempty,min=1,max=50"` // Number of run times to preview; defaults to 5
+}
+
+// ValidateScheduleResponse reports whether a schedule expression is valid and when it would run
+type ValidateScheduleResponse struct {
+	Valid      bool        `json:"valid"`
+	Expression string      `json:"expression"`
+	Format     string      `json:"format,omitempty"` // 5-field, 6-field (leading seconds) or descriptor
+	HasSeconds bool        `json:"has_seconds"`
+	Timezone   string      `json:"timezone,omitempty"`
+	NextRuns   []time.Time `json:"next_runs,omitempty"`
+	Error      string      `json:"error,omitempty"`
+}
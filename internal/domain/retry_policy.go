@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TenantRetryPolicy overrides the default retry policy for a tenant and channel
+// Records are managed by the billing/plan system; zero fields use the channel default.
+type TenantRetryPolicy struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TenantID    string             `json:"tenant_id" bson:"tenantId"`
+	Channel     NotificationType   `json:"channel" bson:"channel"`
+	MaxAttempts int                `json:"max_attempts,omitempty" bson:"maxAttempts,omitempty"` // Including the first attempt
+	BaseDelayMs int64              `json:"base_delay_ms,omitempty" bson:"baseDelayMs,omitempty"`
+	Multiplier  float64            `json:"multiplier,omitempty" bson:"multiplier,omitempty"`
+	MaxDelayMs  int64              `json:"max_delay_ms,omitempty" bson:"maxDelayMs,omitempty"`
+	Jitter      float64            `json:"jitter,omitempty" bson:"jitter,omitempty"` // Fraction of each delay randomized, 0 to 1
+	Version     int                `json:"version" bson:"version"`
+	CreatedAt   time.Time          `json:"created_at" bson:"createdAt"`
+	UpdatedAt   time.Time          `json:"updated_at" bson:"updatedAt"`
+	DeletedAt   *time.Time         `json:"deleted_at,omitempty" bson:"deletedAt,omitempty"`
+}
+
+// RetryPolicyReport describes the retry policy in effect for a tenant and channel
+type RetryPolicyReport struct {
+	Channel     NotificationType `json:"channel"`
+	MaxAttempts int              `json:"max_attempts"`
+	BaseDelayMs int64            `json:"base_delay_ms"`
+	Multiplier  float64          `json:"multiplier"`
+	MaxDelayMs  int64            `json:"max_delay_ms"`
+	Jitter      float64          `json:"jitter"`
+	Overridden  bool             `json:"overridden"` // Whether a tenant override applies
+}
@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ScheduleExecutionStatus represents the outcome of one run of a schedule
+type ScheduleExecutionStatus string
+
+const (
+	ScheduleExecutionSucceeded ScheduleExecutionStatus = "succeeded"
+	ScheduleExecutionFailed    ScheduleExecutionStatus = "failed"
+	ScheduleExecutionHeld      ScheduleExecutionStatus = "held" // Accepted while the channel was paused; sent on resume
+)
+
+// ScheduleExecutionMetadataKey is the request metadata key tagging notifications with the run that sent them
+const ScheduleExecutionMetadataKey = "schedule_execution_id"
+
+// ScheduleExecution records one run of a scheduled notification
+type ScheduleExecution struct {
+	ID              primitive.ObjectID      `json:"id" bson:"_id,omitempty"`
+	ScheduleID      string                  `json:"schedule_id" bson:"scheduleId"`
+	TenantID        string                  `json:"tenant_id" bson:"tenantId"`
+	Type            NotificationType        `json:"type" bson:"type"`
+	Status          ScheduleExecutionStatus `json:"status" bson:"status"`
+	Error           string                  `json:"error,omitempty" bson:"error,omitempty"`
+	NotificationIDs []string                `json:"notification_ids,omitempty" bson:"notificationIds,omitempty"`
+	Delayed         bool                    `json:"delayed,omitempty" bson:"delayed,omitempty"`  // Waited for a free slot under the concurrency limit
+	DelayMs         int64                   `json:"delay_ms,omitempty" bson:"delayMs,omitempty"` // Time spent waiting, before StartedAt
+	StartedAt       time.Time               `json:"started_at" bson:"startedAt"`
+	DurationMs      int64                   `json:"duration_ms" bson:"durationMs"`
+	CreatedAt       time.Time               `json:"created_at" bson:"createdAt"`
+}
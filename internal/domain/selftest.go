@@ -0,0 +1,37 @@
+package domain
+
+// SelfTestTag marks notifications sent by the self-test, so they can be told apart from tenant sends
+const SelfTestTag = "selftest"
+
+// Self-test step names, in the order they run
+const (
+	SelfTestStepConfig   = "config_resolved"
+	SelfTestStepTemplate = "template_rendered"
+	SelfTestStepDatabase = "notification_recorded"
+	SelfTestStepConnect  = "provider_connected"
+	SelfTestStepSend     = "message_accepted"
+)
+
+// Self-test step statuses
+const (
+	SelfTestPassed  = "passed"
+	SelfTestFailed  = "failed"
+	SelfTestSkipped = "skipped" // An earlier step failed, or the provider has no such check
+)
+
+// SelfTestStep is the outcome of one step of the send path self-test
+type SelfTestStep struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	DurationMs int64  `json:"duration_ms"`
+	Detail     string `json:"detail,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// SelfTestReport describes a self-test email sent through the real send path
+type SelfTestReport struct {
+	Passed         bool           `json:"passed"`
+	Provider       string         `json:"provider,omitempty"`
+	NotificationID string         `json:"notification_id,omitempty"`
+	Steps          []SelfTestStep `json:"steps"`
+}
@@ -0,0 +1,9 @@
+package domain
+
+// LocalSendOptions delivers an email at a time of day in each recipient's own timezone
+// Timezones come from the preferences of each recipient's user.
+type LocalSendOptions struct {
+	Time            string            `json:"time" binding:"required"`               // Local time of day as HH:MM, e.g. "09:00"
+	DefaultTimezone string            `json:"default_timezone,omitempty"`            // IANA timezone of recipients without a known one; defaults to SEND_TIME_DEFAULT_TIMEZONE
+	UserIDs         map[string]string `json:"user_ids,omitempty" binding:"max=1000"` // Recipient address to user ID; recipient list members use their own
+}
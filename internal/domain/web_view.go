@@ -0,0 +1,10 @@
+package domain
+
+import "time"
+
+// ViewLink is a signed URL of a sent email's hosted copy, for recipients whose mail client
+// can't render it
+type ViewLink struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
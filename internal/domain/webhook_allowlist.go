@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// WebhookAllowlist restricts a tenant's webhook destinations to approved domains.
+// An empty list allows any destination. A "*.example.com" entry matches any
+// subdomain of example.com but not example.com itself.
+type WebhookAllowlist struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TenantID  string             `json:"tenant_id" bson:"tenantId"`
+	Domains   []string           `json:"domains" bson:"domains"`
+	Version   int                `json:"version" bson:"version"`
+	CreatedAt time.Time          `json:"created_at" bson:"createdAt"`
+	UpdatedAt time.Time          `json:"updated_at" bson:"updatedAt"`
+	DeletedAt *time.Time         `json:"deleted_at,omitempty" bson:"deletedAt,omitempty"`
+}
+
+// UpdateWebhookAllowlistRequest replaces a tenant's webhook destination allowlist
+type UpdateWebhookAllowlistRequest struct {
+	Domains []string `json:"domains" binding:"required,max=500"`
+}
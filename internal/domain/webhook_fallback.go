@@ -0,0 +1,22 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// FallbackChannel is where a summary of a webhook is sent when its delivery fails for good
+type FallbackChannel struct {
+	Type NotificationType `json:"type" bson:"type" binding:"required,oneof=email sms"`
+	To   []string         `json:"to" bson:"to" binding:"required,min=1,max=10"` // Email addresses or phone numbers, by Type
+}
+
+// WebhookFallback is a tenant's fallback for webhooks that don't set their own
+type WebhookFallback struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TenantID  string             `json:"tenant_id" bson:"tenantId"`
+	Channel   FallbackChannel    `json:"channel" bson:"channel"`
+	CreatedAt time.Time          `json:"created_at" bson:"createdAt"`
+	UpdatedAt time.Time          `json:"updated_at" bson:"updatedAt"`
+}
@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// WebhookRetryStatus represents the state of a queued webhook retry
+type WebhookRetryStatus string
+
+const (
+	WebhookRetryStatusPending WebhookRetryStatus = "pending" // Waiting for its next attempt
+	WebhookRetryStatusSending WebhookRetryStatus = "sending" // Claimed for an attempt
+	WebhookRetryStatusSent    WebhookRetryStatus = "sent"
+	WebhookRetryStatusFailed  WebhookRetryStatus = "failed" // Attempts used up or failed permanently; will not be retried
+)
+
+// WebhookRetry is a failed webhook waiting to be attempted again by the retry dispatcher
+// The request is stored as JSON so it decodes exactly as the API decoded it.
+type WebhookRetry struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TenantID    string             `json:"tenant_id" bson:"tenantId"`
+	URL         string             `json:"url" bson:"url"`
+	DeliveryID  string             `json:"delivery_id" bson:"deliveryId"`
+	Request     []byte             `json:"-" bson:"request"`
+	Status      WebhookRetryStatus `json:"status" bson:"status"`
+	DueAt       time.Time          `json:"due_at" bson:"dueAt"`
+	Attempts    int                `json:"attempts" bson:"attempts"`        // Failed attempts so far, including the first
+	MaxAttempts int                `json:"max_attempts" bson:"maxAttempts"` // Including the first attempt
+	LastError   string             `json:"last_error,omitempty" bson:"lastError,omitempty"`
+	ClaimedAt   *time.Time         `json:"claimed_at,omitempty" bson:"claimedAt,omitempty"`
+	CompletedAt *time.Time         `json:"completed_at,omitempty" bson:"completedAt,omitempty"`
+	CreatedAt   time.Time          `json:"created_at" bson:"createdAt"`
+	UpdatedAt   time.Time          `json:"updated_at" bson:"updatedAt"`
+}
@@ -0,0 +1,395 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/retry"
+	"github.com/vhvplatform/go-notification-service/internal/smtp"
+)
+
+var (
+	// ErrUnknownProvider is returned when a configured email provider has not been registered
+	ErrUnknownProvider = errors.New("unknown email provider")
+	// ErrPartialDelivery is returned when an email was sent but some of its recipients were rejected
+	ErrPartialDelivery = errors.New("email rejected for some recipients")
+	// ErrNoConnectionPool is returned when resizing pools but no configured provider has one
+	ErrNoConnectionPool = errors.New("no email provider has a connection pool")
+)
+
+// defaultTimeout bounds a single provider API call when Config.Timeout is unset
+const defaultTimeout = 10 * time.Second
+
+// Message is an outgoing email with its envelope-only Bcc recipients
+type Message struct {
+	smtp.Message
+	BCC            []string
+	NotificationID string // Notification the email is sent for, if any
+	SkipFooter     bool   // Sent without the tenant's footer
+}
+
+// Composer adds tenant content, such as a footer, to each message the Router sends
+type Composer interface {
+	Compose(ctx context.Context, tenantID string, msg *Message) error
+}
+
+// NewMessage builds the outgoing message for a send request from the smtp message builder,
+// so every send path encodes, folds and sanitizes headers the same way. The Reply-To, category
+// and custom headers come from the request, and X-Entity-Ref-ID carries notificationID. The
+// body is encoded in the request's Charset.
+func NewMessage(from mail.Address, req *domain.SendEmailRequest, notificationID string) (*Message, error) {
+	headers, err := smtp.BuildExtraHeaders(req, notificationID)
+	if err != nil {
+		return nil, err
+	}
+	msg := &Message{
+		Message: smtp.Message{
+			From:        from,
+			To:          req.To,
+			CC:          req.CC,
+			Subject:     req.Subject,
+			Headers:     headers,
+			Attachments: req.Attachments,
+			Charset:     req.Charset,
+		},
+		BCC:            req.BCC,
+		NotificationID: notificationID,
+		SkipFooter:     req.SkipFooter,
+	}
+	if req.IsHTML {
+		msg.HTML = req.Body
+		msg.AMP = req.AMPHTML
+		msg.Preheader = req.Preheader
+	} else {
+		msg.Text = req.Body
+	}
+	return msg, nil
+}
+
+// Sender interface for email provider transports
+// Send delivers msg and returns the provider's message id, used to match later delivery events.
+// When only some recipients are rejected it returns the message id with a *PartialDeliveryError.
+type Sender interface {
+	Send(ctx context.Context, msg *Message) (providerID string, err error)
+}
+
+// Checker is implemented by Senders that can verify their connection to the provider without
+// sending, such as SMTP, which opens an authenticated connection
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// PoolResizer is implemented by Senders that keep a resizable pool of connections, such as SMTP
+type PoolResizer interface {
+	PoolSize() int
+	ResizePool(size int) error
+}
+
+// HealthRecorder records the outcomes of calls to a dependency, such as
+// health.DegradationController
+type HealthRecorder interface {
+	Record(dependency string, failed bool)
+}
+
+// HealthReporter is implemented by Senders that record their provider's health, such as SMTP
+type HealthReporter interface {
+	SetHealth(recorder HealthRecorder)
+}
+
+// RecipientRecorder records each recipient's outcome of an email that was rejected for some
+// recipients, such as repository.NotificationRepository
+type RecipientRecorder interface {
+	UpdateRecipients(ctx context.Context, id string, tenantID string, recipients []domain.EmailRecipientResult) error
+}
+
+// Config holds the settings of every built-in provider; only the selected providers' are used
+type Config struct {
+	Provider        string            // Default provider
+	TenantProviders map[string]string // Tenant ID to provider, overriding Provider
+	Timeout         time.Duration
+	SMTP            SMTPConfig
+	SendGrid        SendGridConfig
+}
+
+// Factory builds a Sender from config, sending provider API calls through client
+type Factory func(cfg Config, client *http.Client) (Sender, error)
+
+// ProviderError describes a send the provider's API rejected
+type ProviderError struct {
+	Provider   string
+	StatusCode int
+	Message    string
+}
+
+// Error implements the error interface
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("%s: send failed with status %d: %s", e.Provider, e.StatusCode, e.Message)
+}
+
+// PartialDeliveryError describes an email the provider accepted for some recipients and
+// rejected for others. The email was sent, so it is never retried: that would send it to the
+// accepted recipients again.
+type PartialDeliveryError struct {
+	Rejected []RecipientError // In envelope order
+}
+
+// RecipientError is the provider's reason for rejecting one recipient
+type RecipientError struct {
+	Recipient string
+	Err       error
+}
+
+// Error implements the error interface
+func (e *PartialDeliveryError) Error() string {
+	reasons := make([]string, 0, len(e.Rejected))
+	for _, rejected := range e.Rejected {
+		reasons = append(reasons, fmt.Sprintf("%s: %v", rejected.Recipient, rejected.Err))
+	}
+	return fmt.Sprintf("%s: %s", ErrPartialDelivery, strings.Join(reasons, "; "))
+}
+
+// Unwrap allows errors.Is(err, ErrPartialDelivery) and marks the error as not retryable
+func (e *PartialDeliveryError) Unwrap() []error {
+	return []error{ErrPartialDelivery, retry.ErrPermanent}
+}
+
+// RejectedError returns the reason recipient was rejected, or nil if it was accepted
+// Recipients are compared without regard to case.
+func (e *PartialDeliveryError) RejectedError(recipient string) error {
+	for _, rejected := range e.Rejected {
+		if strings.EqualFold(rejected.Recipient, recipient) {
+			return rejected.Err
+		}
+	}
+	return nil
+}
+
+// Results reports every recipient in lists as sent, except those the provider rejected, which
+// are reported failed with their reason. Recipients are listed once, in order, followed by any
+// rejected recipients not in lists.
+func (e *PartialDeliveryError) Results(lists ...[]string) []domain.EmailRecipientResult {
+	var results []domain.EmailRecipientResult
+	seen := make(map[string]bool)
+	add := func(recipient string) {
+		key := strings.ToLower(recipient)
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+
+		result := domain.EmailRecipientResult{Recipient: recipient, Status: domain.NotificationStatusSent}
+		if err := e.RejectedError(recipient); err != nil {
+			result.Status = domain.NotificationStatusFailed
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+	}
+
+	for _, list := range lists {
+		for _, recipient := range list {
+			add(recipient)
+		}
+	}
+	for _, rejected := range e.Rejected {
+		add(rejected.Recipient)
+	}
+	return results
+}
+
+// AsPartialDeliveryError returns the *PartialDeliveryError in err's chain, if any
+func AsPartialDeliveryError(err error) (*PartialDeliveryError, bool) {
+	var partialErr *PartialDeliveryError
+	ok := errors.As(err, &partialErr)
+	return partialErr, ok
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{
+		"smtp":     newSMTPSender,
+		"sendgrid": newSendGridSender,
+	}
+)
+
+// Register makes a provider available under name, so it can be selected with EMAIL_PROVIDER
+// It panics if name is empty or already registered.
+func Register(name string, factory Factory) {
+	name = normalizeName(name)
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if name == "" || factory == nil {
+		panic("email: Register called with an empty name or nil factory")
+	}
+	if _, exists := registry[name]; exists {
+		panic(fmt.Sprintf("email: provider %q already registered", name))
+	}
+	registry[name] = factory
+}
+
+// Providers returns the names of the registered providers in sorted order
+func Providers() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// Router picks the email provider for each tenant
+type Router struct {
+	defaultProvider string
+	tenants         map[string]string
+	senders         map[string]Sender
+	composers       []Composer
+	recipients      RecipientRecorder
+}
+
+// NewRouter creates the default provider and every provider a tenant is assigned to
+// Providers no tenant uses are not created, so an SMTP pool is only opened when needed.
+func NewRouter(cfg Config) (*Router, error) {
+	router := &Router{
+		defaultProvider: normalizeName(cfg.Provider),
+		tenants:         make(map[string]string, len(cfg.TenantProviders)),
+		senders:         make(map[string]Sender),
+	}
+	for tenantID, name := range cfg.TenantProviders {
+		router.tenants[tenantID] = normalizeName(name)
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	client := &http.Client{Timeout: timeout}
+
+	names := []string{router.defaultProvider}
+	for _, name := range router.tenants {
+		names = append(names, name)
+	}
+	for _, name := range names {
+		if _, ok := router.senders[name]; ok {
+			continue
+		}
+		registryMu.RLock()
+		factory, ok := registry[name]
+		registryMu.RUnlock()
+		if !ok {
+			router.Close()
+			return nil, fmt.Errorf("%w: %q (available: %s)", ErrUnknownProvider, name, strings.Join(Providers(), ", "))
+		}
+		sender, err := factory(cfg, client)
+		if err != nil {
+			router.Close()
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		router.senders[name] = sender
+	}
+	return router, nil
+}
+
+// For returns the name and Sender of the tenant's provider
+func (r *Router) For(tenantID string) (string, Sender) {
+	name, ok := r.tenants[tenantID]
+	if !ok {
+		name = r.defaultProvider
+	}
+	return name, r.senders[name]
+}
+
+// Use adds composers that Send applies, in order, to every message before delivering it
+// It must be called before the Router sends.
+func (r *Router) Use(composers ...Composer) {
+	r.composers = append(r.composers, composers...)
+}
+
+// SetRecipients has the Router record each recipient's outcome on the notification of every
+// email the provider rejects for some recipients
+// It must be called before the Router sends.
+func (r *Router) SetRecipients(recorder RecipientRecorder) {
+	r.recipients = recorder
+}
+
+// Send composes msg for the tenant and delivers it through the tenant's provider, returning
+// the provider name and message id
+func (r *Router) Send(ctx context.Context, tenantID string, msg *Message) (provider, providerID string, err error) {
+	provider, sender := r.For(tenantID)
+	for _, composer := range r.composers {
+		if err := composer.Compose(ctx, tenantID, msg); err != nil {
+			return provider, "", err
+		}
+	}
+	providerID, err = sender.Send(ctx, msg)
+	if partialErr, ok := AsPartialDeliveryError(err); ok && r.recipients != nil && msg.NotificationID != "" {
+		results := partialErr.Results(msg.To, msg.CC, msg.BCC)
+		if recordErr := r.recipients.UpdateRecipients(ctx, msg.NotificationID, tenantID, results); recordErr != nil {
+			// The email was still sent, so the partial delivery stays the outcome
+			err = errors.Join(err, fmt.Errorf("failed to record recipient results: %w", recordErr))
+		}
+	}
+	return provider, providerID, err
+}
+
+// SetHealth has every provider that records its health record it to recorder
+// It must be called before the Router sends.
+func (r *Router) SetHealth(recorder HealthRecorder) {
+	for _, sender := range r.senders {
+		if reporter, ok := sender.(HealthReporter); ok {
+			reporter.SetHealth(recorder)
+		}
+	}
+}
+
+// PoolSizes returns the pool size of each provider with a connection pool
+func (r *Router) PoolSizes() map[string]int {
+	sizes := make(map[string]int)
+	for name, sender := range r.senders {
+		if resizer, ok := sender.(PoolResizer); ok {
+			sizes[name] = resizer.PoolSize()
+		}
+	}
+	return sizes
+}
+
+// ResizePools resizes the connection pool of every provider that has one, returning the new
+// sizes. It returns ErrNoConnectionPool when no provider has a pool.
+func (r *Router) ResizePools(size int) (map[string]int, error) {
+	var errs []error
+	resized := false
+	for name, sender := range r.senders {
+		if resizer, ok := sender.(PoolResizer); ok {
+			resized = true
+			if err := resizer.ResizePool(size); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			}
+		}
+	}
+	if !resized {
+		return nil, ErrNoConnectionPool
+	}
+	return r.PoolSizes(), errors.Join(errs...)
+}
+
+// Close releases providers that hold connections, such as the SMTP pool
+func (r *Router) Close() {
+	for _, sender := range r.senders {
+		if closer, ok := sender.(io.Closer); ok {
+			closer.Close()
+		}
+	}
+}
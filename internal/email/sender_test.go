@@ -0,0 +1,484 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/retry"
+	"github.com/vhvplatform/go-notification-service/internal/smtp"
+)
+
+type fakeSender struct {
+	id string
+}
+
+func (s fakeSender) Send(ctx context.Context, msg *Message) (string, error) {
+	return s.id, nil
+}
+
+func TestRouterSelectsTenantProvider(t *testing.T) {
+	Register("fake-default", func(cfg Config, client *http.Client) (Sender, error) {
+		return fakeSender{id: "default-1"}, nil
+	})
+	Register("fake-api", func(cfg Config, client *http.Client) (Sender, error) {
+		return fakeSender{id: "api-1"}, nil
+	})
+
+	router, err := NewRouter(Config{
+		Provider:        "fake-default",
+		TenantProviders: map[string]string{"tenant-a": " Fake-API "},
+	})
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+
+	for tenantID, want := range map[string][2]string{
+		"tenant-a": {"fake-api", "api-1"},
+		"tenant-b": {"fake-default", "default-1"},
+	} {
+		provider, id, err := router.Send(context.Background(), tenantID, &Message{})
+		if err != nil || provider != want[0] || id != want[1] {
+			t.Errorf("Send(%s) = %q, %q, %v, want %q, %q", tenantID, provider, id, err, want[0], want[1])
+		}
+	}
+
+	_, err = NewRouter(Config{Provider: "fake-default", TenantProviders: map[string]string{"tenant-a": "pigeon"}})
+	if !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("NewRouter() with unknown tenant provider error = %v, want ErrUnknownProvider", err)
+	}
+}
+
+// composerFunc adapts a function to the Composer interface
+type composerFunc func(ctx context.Context, tenantID string, msg *Message) error
+
+func (f composerFunc) Compose(ctx context.Context, tenantID string, msg *Message) error {
+	return f(ctx, tenantID, msg)
+}
+
+func TestRouterAppliesComposers(t *testing.T) {
+	Register("fake-composed", func(cfg Config, client *http.Client) (Sender, error) {
+		return fakeSender{id: "composed-1"}, nil
+	})
+	router, err := NewRouter(Config{Provider: "fake-composed"})
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+	var composed []string
+	router.Use(composerFunc(func(ctx context.Context, tenantID string, msg *Message) error {
+		composed = append(composed, tenantID+":"+msg.NotificationID)
+		return nil
+	}))
+
+	msg := &Message{NotificationID: "notification-1"}
+	if _, _, err := router.Send(context.Background(), "tenant-a", msg); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if len(composed) != 1 || composed[0] != "tenant-a:notification-1" {
+		t.Errorf("composed = %v, want the message composed once for tenant-a", composed)
+	}
+
+	failure := errors.New("footer store unavailable")
+	router.Use(composerFunc(func(ctx context.Context, tenantID string, msg *Message) error {
+		return failure
+	}))
+	if _, id, err := router.Send(context.Background(), "tenant-a", msg); !errors.Is(err, failure) || id != "" {
+		t.Errorf("Send() = %q, %v, want the composer's error and nothing sent", id, err)
+	}
+}
+
+// partialSender rejects the recipients in rejected and accepts the rest
+type partialSender struct {
+	rejected []RecipientError
+}
+
+func (s partialSender) Send(ctx context.Context, msg *Message) (string, error) {
+	return "partial-1", &PartialDeliveryError{Rejected: s.rejected}
+}
+
+// recipientRecorder records the recipient results of each notification
+type recipientRecorder map[string][]domain.EmailRecipientResult
+
+func (r recipientRecorder) UpdateRecipients(ctx context.Context, id string, tenantID string, recipients []domain.EmailRecipientResult) error {
+	r[tenantID+"/"+id] = recipients
+	return nil
+}
+
+func TestRouterRecordsRecipients(t *testing.T) {
+	Register("fake-partial", func(cfg Config, client *http.Client) (Sender, error) {
+		return partialSender{rejected: []RecipientError{{Recipient: "bob@example.com", Err: errors.New("550 mailbox unavailable")}}}, nil
+	})
+	router, err := NewRouter(Config{Provider: "fake-partial"})
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+	recorded := recipientRecorder{}
+	router.SetRecipients(recorded)
+
+	msg := &Message{NotificationID: "notification-1", BCC: []string{"Bob@example.com"}}
+	msg.To = []string{"ada@example.com"}
+	if _, _, err := router.Send(context.Background(), "tenant-1", msg); !errors.Is(err, ErrPartialDelivery) {
+		t.Fatalf("Send() error = %v, want the partial delivery", err)
+	}
+
+	results := recorded["tenant-1/notification-1"]
+	if len(results) != 2 || results[0].Status != domain.NotificationStatusSent || results[1].Recipient != "Bob@example.com" || results[1].Status != domain.NotificationStatusFailed || !strings.Contains(results[1].Error, "550") {
+		t.Errorf("recorded results = %+v, want ada sent and bob failed", results)
+	}
+
+	// Emails without a notification have nowhere to record results
+	if _, _, err := router.Send(context.Background(), "tenant-1", &Message{}); !errors.Is(err, ErrPartialDelivery) || len(recorded) != 1 {
+		t.Errorf("Send() without a notification = %v, recorded %d, want only the partial delivery", err, len(recorded))
+	}
+}
+
+// fakePoolSender is a sender with a resizable connection pool
+type fakePoolSender struct {
+	fakeSender
+	size int
+}
+
+func (s *fakePoolSender) PoolSize() int { return s.size }
+
+func (s *fakePoolSender) ResizePool(size int) error {
+	s.size = size
+	return nil
+}
+
+func TestRouterResizePools(t *testing.T) {
+	pooled := &fakePoolSender{size: 10}
+	Register("fake-pooled", func(cfg Config, client *http.Client) (Sender, error) {
+		return pooled, nil
+	})
+	Register("fake-unpooled", func(cfg Config, client *http.Client) (Sender, error) {
+		return fakeSender{}, nil
+	})
+
+	router, err := NewRouter(Config{Provider: "fake-pooled", TenantProviders: map[string]string{"tenant-a": "fake-unpooled"}})
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+	sizes, err := router.ResizePools(4)
+	if err != nil {
+		t.Fatalf("ResizePools() error = %v", err)
+	}
+	if len(sizes) != 1 || sizes["fake-pooled"] != 4 || pooled.size != 4 {
+		t.Errorf("ResizePools() = %v, want only fake-pooled resized to 4", sizes)
+	}
+
+	router, err = NewRouter(Config{Provider: "fake-unpooled"})
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+	if _, err := router.ResizePools(4); !errors.Is(err, ErrNoConnectionPool) {
+		t.Errorf("ResizePools() without a pool error = %v, want ErrNoConnectionPool", err)
+	}
+}
+
+func TestSendGridSender(t *testing.T) {
+	var got sendGridMail
+	var gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		if r.URL.Path != "/mail/send" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		if got.Subject == "bad" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors":[{"message":"The subject is not allowed.","field":"subject"}]}`))
+			return
+		}
+		w.Header().Set("X-Message-Id", "sg-123")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	sender, err := NewSendGridSender(SendGridConfig{APIKey: "key", BaseURL: server.URL + "/"}, server.Client())
+	if err != nil {
+		t.Fatalf("NewSendGridSender() error = %v", err)
+	}
+
+	msg := &Message{
+		Message: smtp.Message{
+			From:      mail.Address{Name: "Acme", Address: "noreply@acme.test"},
+			To:        []string{"Jane <jane@example.com>"},
+			CC:        []string{"JANE@example.com", "ops@example.com"},
+			Subject:   "Hello",
+			MessageID: "abc@acme.test",
+			Headers: []smtp.Header{
+				{Name: smtp.HeaderReplyTo, Value: "support@acme.test"},
+				{Name: smtp.HeaderEntityRefID, Value: "n-1"},
+			},
+			Text:        "hi",
+			HTML:        "<p>hi</p>",
+			Attachments: []domain.Attachment{{Filename: "a.txt", Content: []byte("data"), MimeType: "text/plain"}},
+		},
+		BCC: []string{"audit@example.com", "jane@example.com"},
+	}
+	id, err := sender.Send(context.Background(), msg)
+	if err != nil || id != "sg-123" {
+		t.Fatalf("Send() = %q, %v, want sg-123", id, err)
+	}
+
+	if gotAuth != "Bearer key" {
+		t.Errorf("Authorization = %q", gotAuth)
+	}
+	p := got.Personalizations[0]
+	if len(p.To) != 1 || p.To[0].Email != "jane@example.com" || p.To[0].Name != "Jane" {
+		t.Errorf("to = %+v", p.To)
+	}
+	if len(p.CC) != 2 || len(p.BCC) != 1 || p.BCC[0].Email != "audit@example.com" {
+		t.Errorf("cc = %+v, bcc = %+v, want duplicates of jane removed", p.CC, p.BCC)
+	}
+	if got.From.Email != "noreply@acme.test" || got.From.Name != "Acme" {
+		t.Errorf("from = %+v", got.From)
+	}
+	if got.ReplyTo == nil || got.ReplyTo.Email != "support@acme.test" || got.Headers["Reply-To"] != "" {
+		t.Errorf("reply_to = %+v, headers = %v", got.ReplyTo, got.Headers)
+	}
+	if got.CustomArgs["notification_id"] != "n-1" {
+		t.Errorf("custom_args = %v", got.CustomArgs)
+	}
+	if len(got.Content) != 2 || got.Content[0].Type != "text/plain" || got.Content[1].Type != "text/html" {
+		t.Errorf("content = %+v", got.Content)
+	}
+	if len(got.Attachments) != 1 || string(got.Attachments[0].Content) != "data" || got.Attachments[0].Disposition != "attachment" {
+		t.Errorf("attachments = %+v", got.Attachments)
+	}
+
+	msg.Subject = "bad"
+	_, err = sender.Send(context.Background(), msg)
+	var providerErr *ProviderError
+	if !errors.As(err, &providerErr) || providerErr.StatusCode != http.StatusBadRequest || providerErr.Message != "subject: The subject is not allowed." {
+		t.Errorf("Send() error = %v, want sendgrid 400", err)
+	}
+}
+
+type fakeSMTPClient struct {
+	from   string
+	rcpt   []string
+	reject map[string]error // Recipients the relay rejects
+	data   bytes.Buffer
+}
+
+func (c *fakeSMTPClient) Mail(from string) error {
+	c.from = from
+	return nil
+}
+
+func (c *fakeSMTPClient) Rcpt(to string) error {
+	if err := c.reject[to]; err != nil {
+		return err
+	}
+	c.rcpt = append(c.rcpt, to)
+	return nil
+}
+
+func (c *fakeSMTPClient) Data() (io.WriteCloser, error) {
+	return nopCloser{&c.data}, nil
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+func TestSMTPDeliverIncludesBccInEnvelopeOnly(t *testing.T) {
+	msg := &Message{
+		Message: smtp.Message{
+			From:      mail.Address{Address: "noreply@acme.test"},
+			To:        []string{"jane@example.com"},
+			Subject:   "Hello",
+			MessageID: newMessageID("noreply@acme.test"),
+			Text:      "hi",
+		},
+		BCC: []string{"audit@example.com"},
+	}
+	data, err := msg.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes() error = %v", err)
+	}
+
+	client := &fakeSMTPClient{}
+	if err := (&SMTPSender{}).deliver(client, msg, data); err != nil {
+		t.Fatalf("deliver() error = %v", err)
+	}
+	if client.from != "noreply@acme.test" || len(client.rcpt) != 2 || client.rcpt[1] != "audit@example.com" {
+		t.Errorf("envelope from=%s rcpt=%v", client.from, client.rcpt)
+	}
+	if bytes.Contains(client.data.Bytes(), []byte("audit@example.com")) {
+		t.Error("message data contains the Bcc recipient")
+	}
+}
+
+func TestSMTPDeliverPartiallyRejected(t *testing.T) {
+	msg := &Message{
+		Message: smtp.Message{
+			From:      mail.Address{Address: "noreply@acme.test"},
+			To:        []string{"jane@example.com", "gone@example.com"},
+			Subject:   "Hello",
+			MessageID: newMessageID("noreply@acme.test"),
+			Text:      "hi",
+		},
+	}
+	data, err := msg.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes() error = %v", err)
+	}
+
+	client := &fakeSMTPClient{reject: map[string]error{"gone@example.com": errors.New("550 no such user")}}
+	err = (&SMTPSender{}).deliver(client, msg, data)
+	partialErr, ok := AsPartialDeliveryError(err)
+	if !ok {
+		t.Fatalf("deliver() error = %v, want a partial delivery", err)
+	}
+	if client.data.Len() == 0 || len(client.rcpt) != 1 {
+		t.Errorf("message not sent to the accepted recipient: rcpt=%v", client.rcpt)
+	}
+	if partialErr.RejectedError("GONE@example.com") == nil || partialErr.RejectedError("jane@example.com") != nil {
+		t.Errorf("Rejected = %v", partialErr.Rejected)
+	}
+	if !errors.Is(err, ErrPartialDelivery) || retry.Retryable(err) {
+		t.Errorf("partial delivery should match ErrPartialDelivery and not be retried")
+	}
+}
+
+func TestSMTPDeliverAllRejected(t *testing.T) {
+	msg := &Message{
+		Message: smtp.Message{
+			From:      mail.Address{Address: "noreply@acme.test"},
+			To:        []string{"gone@example.com"},
+			Subject:   "Hello",
+			MessageID: newMessageID("noreply@acme.test"),
+			Text:      "hi",
+		},
+	}
+	data, err := msg.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes() error = %v", err)
+	}
+
+	client := &fakeSMTPClient{reject: map[string]error{"gone@example.com": errors.New("550 no such user")}}
+	err = (&SMTPSender{}).deliver(client, msg, data)
+	if err == nil {
+		t.Fatal("deliver() error = nil, want an error")
+	}
+	if _, ok := AsPartialDeliveryError(err); ok {
+		t.Errorf("deliver() error = %v, want a complete failure", err)
+	}
+	if client.data.Len() != 0 {
+		t.Error("message sent with no accepted recipients")
+	}
+}
+
+func TestNewMessage(t *testing.T) {
+	msg, err := NewMessage(mail.Address{Name: "Acme, Inc.", Address: "noreply@example.com"}, &domain.SendEmailRequest{
+		To:        []string{"user@example.com"},
+		BCC:       []string{"audit@example.com"},
+		Subject:   "Grüße\r\nBcc: victim@example.com",
+		Body:      "<p>Hello</p>",
+		IsHTML:    true,
+		Preheader: "Preview",
+		ReplyTo:   "support@example.com",
+		Category:  "receipts",
+		Headers:   map[string]string{"x-campaign": "spring"},
+	}, "notification-1")
+	if err != nil {
+		t.Fatalf("NewMessage() error = %v", err)
+	}
+	if msg.HTML != "<p>Hello</p>" || msg.Text != "" || msg.Preheader != "Preview" {
+		t.Errorf("NewMessage() body = html %q, text %q, preheader %q", msg.HTML, msg.Text, msg.Preheader)
+	}
+
+	data, err := msg.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes() error = %v", err)
+	}
+	headers, _, _ := bytes.Cut(data, []byte("\r\n\r\n"))
+	for _, want := range []string{
+		`From: "Acme, Inc." <noreply@example.com>`,
+		"Subject: =?utf-8?q?Gr=C3=BC=C3=9Fe",
+		"Reply-To: <support@example.com>",
+		"X-Entity-Ref-Id: notification-1",
+		"X-Category: receipts",
+		"X-Campaign: spring",
+	} {
+		if !bytes.Contains(headers, []byte(want)) {
+			t.Errorf("headers missing %q:\n%s", want, headers)
+		}
+	}
+	if bytes.Contains(headers, []byte("\r\nBcc:")) || bytes.Contains(headers, []byte("audit@example.com")) {
+		t.Errorf("headers expose Bcc:\n%s", headers)
+	}
+
+	plain, err := NewMessage(mail.Address{Address: "noreply@example.com"}, &domain.SendEmailRequest{
+		To:        []string{"user@example.com"},
+		Subject:   "Hello",
+		Body:      "Hello",
+		Preheader: "Ignored without HTML",
+	}, "")
+	if err != nil {
+		t.Fatalf("NewMessage() error = %v", err)
+	}
+	if plain.Text != "Hello" || plain.HTML != "" || plain.Preheader != "" {
+		t.Errorf("NewMessage() plain body = text %q, html %q, preheader %q", plain.Text, plain.HTML, plain.Preheader)
+	}
+
+	if _, err := NewMessage(mail.Address{Address: "noreply@example.com"}, &domain.SendEmailRequest{
+		To:      []string{"user@example.com"},
+		Headers: map[string]string{"Bcc": "victim@example.com"},
+	}, ""); err == nil {
+		t.Error("NewMessage() with a protected header error = nil, want error")
+	}
+}
+
+// healthRecorder records the outcomes recorded for each dependency
+type healthRecorder map[string][]bool
+
+func (r healthRecorder) Record(dependency string, failed bool) {
+	r[dependency] = append(r[dependency], failed)
+}
+
+func TestSMTPSenderRecordsRelayHealth(t *testing.T) {
+	recorder := healthRecorder{}
+	s := &SMTPSender{}
+	s.SetHealth(recorder)
+
+	outcomes := []struct {
+		name   string
+		err    error
+		failed bool
+	}{
+		{"sent", nil, false},
+		{"recipient rejected", fmt.Errorf("smtp RCPT TO failed: %w", &textproto.Error{Code: 550, Msg: "no such user"}), false},
+		{"partial delivery", &PartialDeliveryError{Rejected: []RecipientError{{Recipient: "a@example.com", Err: errors.New("550")}}}, false},
+		{"relay unavailable", fmt.Errorf("smtp MAIL FROM failed: %w", &textproto.Error{Code: 421, Msg: "shutting down"}), true},
+		{"connection refused", errors.New("dial tcp 10.0.0.1:587: connection refused"), true},
+	}
+	for _, outcome := range outcomes {
+		s.record(outcome.err)
+	}
+
+	recorded := recorder["smtp"]
+	if len(recorded) != len(outcomes) {
+		t.Fatalf("recorded %d smtp outcomes, want %d", len(recorded), len(outcomes))
+	}
+	for i, outcome := range outcomes {
+		if recorded[i] != outcome.failed {
+			t.Errorf("%s recorded failed = %v, want %v", outcome.name, recorded[i], outcome.failed)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"net/textproto"
+	"strings"
+
+	"github.com/vhvplatform/go-notification-service/internal/smtp"
+)
+
+// sendGridBaseURL is the SendGrid v3 API root
+const sendGridBaseURL = "https://api.sendgrid.com/v3"
+
+// maxResponseBytes bounds how much of a provider response is read
+const maxResponseBytes = 64 << 10
+
+// SendGridConfig holds SendGrid API settings
+type SendGridConfig struct {
+	APIKey  string
+	BaseURL string // Defaults to the global API; set https://api.eu.sendgrid.com/v3 for EU data residency
+}
+
+// SendGridSender sends email through the SendGrid v3 Mail Send API
+// The provider message id is SendGrid's X-Message-Id, which prefixes sg_message_id in its events.
+type SendGridSender struct {
+	apiKey  string
+	baseURL string
+	client  *http.Client
+}
+
+// NewSendGridSender creates a SendGrid sender
+func NewSendGridSender(config SendGridConfig, client *http.Client) (*SendGridSender, error) {
+	if config.APIKey == "" {
+		return nil, errors.New("sendgrid requires SENDGRID_API_KEY")
+	}
+	baseURL := strings.TrimSuffix(config.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = sendGridBaseURL
+	}
+	return &SendGridSender{
+		apiKey:  config.APIKey,
+		baseURL: baseURL,
+		client:  client,
+	}, nil
+}
+
+func newSendGridSender(cfg Config, client *http.Client) (Sender, error) {
+	return NewSendGridSender(cfg.SendGrid, client)
+}
+
+type sendGridAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+type sendGridPersonalization struct {
+	To  []sendGridAddress `json:"to"`
+	CC  []sendGridAddress `json:"cc,omitempty"`
+	BCC []sendGridAddress `json:"bcc,omitempty"`
+}
+
+type sendGridContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type sendGridAttachment struct {
+	Content     []byte `json:"content"` // Base64 encoded by encoding/json
+	Filename    string `json:"filename"`
+	Type        string `json:"type,omitempty"`
+	Disposition string `json:"disposition"`
+	ContentID   string `json:"content_id,omitempty"`
+}
+
+type sendGridMail struct {
+	Personalizations []sendGridPersonalization `json:"personalizations"`
+	From             sendGridAddress           `json:"from"`
+	ReplyTo          *sendGridAddress          `json:"reply_to,omitempty"`
+	Subject          string                    `json:"subject"`
+	Content          []sendGridContent         `json:"content"`
+	Attachments      []sendGridAttachment      `json:"attachments,omitempty"`
+	Headers          map[string]string         `json:"headers,omitempty"`
+	CustomArgs       map[string]string         `json:"custom_args,omitempty"`
+}
+
+// Send posts msg to the Mail Send API and returns SendGrid's message id
+func (s *SendGridSender) Send(ctx context.Context, msg *Message) (string, error) {
+	payload, err := buildSendGridMail(msg)
+	if err != nil {
+		return "", err
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("sendgrid: failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+"/mail/send", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("sendgrid: failed to build request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("sendgrid: request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var failure struct {
+			Errors []struct {
+				Message string `json:"message"`
+				Field   string `json:"field"`
+			} `json:"errors"`
+		}
+		_ = json.Unmarshal(data, &failure)
+		messages := make([]string, 0, len(failure.Errors))
+		for _, e := range failure.Errors {
+			if e.Field != "" {
+				messages = append(messages, e.Field+": "+e.Message)
+			} else {
+				messages = append(messages, e.Message)
+			}
+		}
+		message := strings.Join(messages, "; ")
+		if message == "" {
+			message = http.StatusText(resp.StatusCode)
+		}
+		return "", &ProviderError{Provider: "sendgrid", StatusCode: resp.StatusCode, Message: message}
+	}
+
+	id := resp.Header.Get("X-Message-Id")
+	if id == "" {
+		return "", errors.New("sendgrid: response has no X-Message-Id")
+	}
+	return id, nil
+}
+
+// buildSendGridMail maps msg onto a Mail Send request
+// Reply-To moves from the headers to reply_to, which SendGrid requires, and the notification ID
+// header is also sent as a custom arg so it comes back on delivery events.
+func buildSendGridMail(msg *Message) (*sendGridMail, error) {
+	from, err := sendGridParseAddress(msg.From.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid from address: %w", err)
+	}
+	if msg.From.Name != "" {
+		from.Name = smtp.SanitizeHeaderValue(msg.From.Name)
+	}
+
+	to, cc, bcc := smtp.DedupeRecipients(msg.To, msg.CC, msg.BCC)
+	var personalization sendGridPersonalization
+	for _, list := range []struct {
+		addresses []string
+		into      *[]sendGridAddress
+	}{{to, &personalization.To}, {cc, &personalization.CC}, {bcc, &personalization.BCC}} {
+		for _, raw := range list.addresses {
+			address, err := sendGridParseAddress(raw)
+			if err != nil {
+				return nil, fmt.Errorf("invalid recipient %q: %w", raw, err)
+			}
+			*list.into = append(*list.into, address)
+		}
+	}
+	if len(personalization.To) == 0 {
+		return nil, errors.New("sendgrid requires at least one To recipient")
+	}
+
+	mailBody := &sendGridMail{
+		Personalizations: []sendGridPersonalization{personalization},
+		From:             from,
+		Subject:          smtp.SanitizeHeaderValue(msg.Subject),
+	}
+
+	// SendGrid requires text/plain first and text/html last, with AMP between them
+	if text := msg.TextBody(); text != "" {
+		mailBody.Content = append(mailBody.Content, sendGridContent{Type: "text/plain", Value: text})
+	}
+	if html := msg.HTMLBody(); html != "" {
+		if msg.AMP != "" {
+			mailBody.Content = append(mailBody.Content, sendGridContent{Type: "text/x-amp-html", Value: msg.AMP})
+		}
+		mailBody.Content = append(mailBody.Content, sendGridContent{Type: "text/html", Value: html})
+	}
+	if len(mailBody.Content) == 0 {
+		return nil, errors.New("email has no text or html body")
+	}
+
+	for _, header := range msg.Headers {
+		name := textproto.CanonicalMIMEHeaderKey(header.Name)
+		value := smtp.SanitizeHeaderValue(header.Value)
+		switch name {
+		case smtp.HeaderReplyTo:
+			replyTo, err := sendGridParseAddress(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid reply-to address: %w", err)
+			}
+			mailBody.ReplyTo = &replyTo
+			continue
+		case textproto.CanonicalMIMEHeaderKey(smtp.HeaderEntityRefID):
+			mailBody.CustomArgs = map[string]string{"notification_id": value}
+		}
+		if mailBody.Headers == nil {
+			mailBody.Headers = make(map[string]string)
+		}
+		mailBody.Headers[name] = value
+	}
+	if msg.MessageID != "" {
+		// SendGrid assigns its own Message-ID; keep ours for threading and reconciliation
+		if mailBody.Headers == nil {
+			mailBody.Headers = make(map[string]string)
+		}
+		mailBody.Headers["X-Original-Message-Id"] = "<" + strings.Trim(msg.MessageID, "<>") + ">"
+	}
+
+	for _, attachment := range msg.Attachments {
+		disposition := "attachment"
+		if attachment.Inline {
+			disposition = "inline"
+		}
+		mailBody.Attachments = append(mailBody.Attachments, sendGridAttachment{
+			Content:     attachment.Content,
+			Filename:    attachment.Filename,
+			Type:        attachment.MimeType,
+			Disposition: disposition,
+			ContentID:   strings.Trim(attachment.ContentID, "<>"),
+		})
+	}
+	return mailBody, nil
+}
+
+func sendGridParseAddress(raw string) (sendGridAddress, error) {
+	parsed, err := mail.ParseAddress(smtp.SanitizeHeaderValue(raw))
+	if err != nil {
+		return sendGridAddress{}, err
+	}
+	return sendGridAddress{Email: parsed.Address, Name: parsed.Name}, nil
+}
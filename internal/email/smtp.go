@@ -0,0 +1,199 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/vhvplatform/go-notification-service/internal/health"
+	"github.com/vhvplatform/go-notification-service/internal/smtp"
+)
+
+// replyServiceUnavailable is the SMTP reply of a relay that is shutting down or overloaded
+const replyServiceUnavailable = 421
+
+// SMTPConfig holds SMTP relay settings
+type SMTPConfig struct {
+	Host        string
+	Port        int
+	Username    string
+	Password    string
+	UseTLS      bool
+	PoolSize    int
+	MaxMessages int // Messages sent on a pooled connection before it is replaced; 0 never replaces it
+}
+
+// smtpClient is the part of *net/smtp.Client used for one mail transaction
+type smtpClient interface {
+	Mail(from string) error
+	Rcpt(to string) error
+	Data() (io.WriteCloser, error)
+}
+
+// SMTPSender sends email through a pooled SMTP relay connection
+// The provider message id is the Message-ID header, generated when the message has none.
+type SMTPSender struct {
+	pool   *smtp.SMTPPool
+	health HealthRecorder // Optional; records whether the relay could be reached
+}
+
+// NewSMTPSender creates an SMTP sender, opening the connection pool
+func NewSMTPSender(config SMTPConfig) (*SMTPSender, error) {
+	pool, err := smtp.NewSMTPPool(smtp.SMTPConfig{
+		Host:        config.Host,
+		Port:        config.Port,
+		Username:    config.Username,
+		Password:    config.Password,
+		UseTLS:      config.UseTLS,
+		MaxMessages: config.MaxMessages,
+	}, config.PoolSize)
+	if err != nil {
+		return nil, err
+	}
+	return &SMTPSender{pool: pool}, nil
+}
+
+func newSMTPSender(cfg Config, _ *http.Client) (Sender, error) {
+	return NewSMTPSender(cfg.SMTP)
+}
+
+// Send writes msg to the relay and returns its Message-ID
+func (s *SMTPSender) Send(ctx context.Context, msg *Message) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	if msg.MessageID == "" {
+		msg.MessageID = newMessageID(msg.From.Address)
+	}
+	data, err := msg.Bytes()
+	if err != nil {
+		return "", err
+	}
+
+	conn, err := s.pool.Get()
+	if err != nil {
+		s.record(err)
+		return "", err
+	}
+	err = s.deliver(conn, msg, data)
+	s.record(err)
+	if err != nil {
+		if partialErr, ok := AsPartialDeliveryError(err); ok {
+			// The message was sent to the accepted recipients
+			conn.Delivered()
+			s.pool.Put(conn)
+			return msg.MessageID, partialErr
+		}
+		// The connection may be mid-transaction; only pool it again if it resets cleanly
+		if resetErr := conn.Reset(); resetErr != nil {
+			s.pool.Discard(conn)
+		} else {
+			s.pool.Put(conn)
+		}
+		return "", err
+	}
+	conn.Delivered()
+	s.pool.Put(conn)
+	return msg.MessageID, nil
+}
+
+// SetHealth records the outcome of each send to recorder as health.DependencySMTP
+func (s *SMTPSender) SetHealth(recorder HealthRecorder) {
+	s.health = recorder
+}
+
+// record records whether a send reached a working relay. Partial deliveries and replies
+// such as rejected recipients come from a working relay; connection errors and 421 replies
+// don't.
+func (s *SMTPSender) record(err error) {
+	if s.health == nil {
+		return
+	}
+	var reply *textproto.Error
+	_, partial := AsPartialDeliveryError(err)
+	failed := err != nil && !partial && (!errors.As(err, &reply) || reply.Code == replyServiceUnavailable)
+	s.health.Record(health.DependencySMTP, failed)
+}
+
+// Check opens, or checks with NOOP, an authenticated connection to the relay and returns it
+// to the pool
+func (s *SMTPSender) Check(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	conn, err := s.pool.Get()
+	if err != nil {
+		return err
+	}
+	s.pool.Put(conn)
+	return nil
+}
+
+// deliver runs one SMTP mail transaction for every To, Cc and Bcc recipient. Recipients the
+// relay rejects are skipped; the message is sent to the rest and a *PartialDeliveryError lists
+// the rejected ones. It fails outright when every recipient is rejected.
+func (s *SMTPSender) deliver(client smtpClient, msg *Message, data []byte) error {
+	if err := client.Mail(msg.From.Address); err != nil {
+		return fmt.Errorf("smtp MAIL FROM failed: %w", err)
+	}
+	accepted := 0
+	var rejected []RecipientError
+	for _, list := range [][]string{msg.To, msg.CC, msg.BCC} {
+		for _, rcpt := range list {
+			if err := client.Rcpt(rcpt); err != nil {
+				rejected = append(rejected, RecipientError{Recipient: rcpt, Err: err})
+				continue
+			}
+			accepted++
+		}
+	}
+	if accepted == 0 && len(rejected) > 0 {
+		return fmt.Errorf("smtp RCPT TO %s failed: %w", rejected[0].Recipient, rejected[0].Err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp DATA failed: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return fmt.Errorf("smtp write failed: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp message rejected: %w", err)
+	}
+	if len(rejected) > 0 {
+		return &PartialDeliveryError{Rejected: rejected}
+	}
+	return nil
+}
+
+// PoolSize returns the number of pooled connections
+func (s *SMTPSender) PoolSize() int {
+	return s.pool.Size()
+}
+
+// ResizePool changes the number of pooled connections without interrupting sends in flight
+func (s *SMTPSender) ResizePool(size int) error {
+	return s.pool.Resize(size)
+}
+
+// Close closes the connection pool
+func (s *SMTPSender) Close() error {
+	s.pool.Close()
+	return nil
+}
+
+// newMessageID returns a unique Message-ID in the sender's domain
+func newMessageID(from string) string {
+	domain := "localhost"
+	if _, host, ok := strings.Cut(from, "@"); ok && host != "" {
+		domain = host
+	}
+	return uuid.NewString() + "@" + domain
+}
@@ -0,0 +1,261 @@
+package escalation
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/metrics"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+	"github.com/vhvplatform/go-notification-service/internal/webhook"
+)
+
+// DefaultPollInterval is how often overdue notifications are looked for when no interval is configured
+const DefaultPollInterval = 30 * time.Second
+
+// PolicyStore interface for reading tenants' escalation policies
+type PolicyStore interface {
+	FindAll(ctx context.Context) ([]*domain.EscalationPolicy, error)
+}
+
+// NotificationStore interface for claiming overdue critical notifications
+type NotificationStore interface {
+	ClaimOverdue(ctx context.Context, tenantID string, step int, sentAfter, sentBefore time.Time, undelivered bool) (*domain.Notification, error)
+}
+
+// RecordStore interface for recording escalation steps taken
+type RecordStore interface {
+	Create(ctx context.Context, record *domain.EscalationRecord) error
+}
+
+// Sender interface for sending escalation notices
+type Sender interface {
+	SendEmail(ctx context.Context, req *domain.SendEmailRequest) error
+	SendSMS(ctx context.Context, req *domain.SendSMSRequest) error
+}
+
+// Linker interface for signed acknowledgment links included in escalation notices
+type Linker interface {
+	Link(tenantID, notificationID string) (string, time.Time)
+}
+
+// Config holds escalation settings
+type Config struct {
+	PollInterval time.Duration // How often overdue notifications are looked for
+}
+
+// NormalizePolicy checks a policy's steps, which must wait longer each, and trims their recipients
+func NormalizePolicy(req *domain.UpdateEscalationPolicyRequest) error {
+	if len(req.Steps) == 0 {
+		return fmt.Errorf("at least one step is required")
+	}
+	for i := range req.Steps {
+		step := &req.Steps[i]
+		if step.After <= 0 {
+			return fmt.Errorf("step %d: after_seconds must be positive", i+1)
+		}
+		if i > 0 && step.After <= req.Steps[i-1].After {
+			return fmt.Errorf("step %d: after_seconds must be later than step %d", i+1, i)
+		}
+		if err := webhook.NormalizeFallback(&step.Channel); err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// Checker escalates critical notifications that aren't acknowledged in time along their
+// tenant's escalation policy, recording each step it takes
+type Checker struct {
+	policies      PolicyStore
+	notifications NotificationStore
+	records       RecordStore
+	sends         Sender
+	links         Linker // Optional; nil leaves acknowledgment links out of notices
+	interval      time.Duration
+	log           *logger.Logger
+}
+
+// NewChecker creates a new escalation checker
+// The linker is optional.
+func NewChecker(policies PolicyStore, notifications NotificationStore, records RecordStore, sends Sender, links Linker, config Config, log *logger.Logger) *Checker {
+	interval := config.PollInterval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	return &Checker{
+		policies:      policies,
+		notifications: notifications,
+		records:       records,
+		sends:         sends,
+		links:         links,
+		interval:      interval,
+		log:           log,
+	}
+}
+
+// Start escalates overdue notifications until ctx is cancelled
+func (c *Checker) Start(ctx context.Context) {
+	c.log.Info("Starting critical notification escalation", "interval", c.interval)
+
+	go func() {
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				c.log.Info("Critical notification escalation stopped")
+				return
+			case <-ticker.C:
+				c.escalateOverdue(ctx, time.Now())
+			}
+		}
+	}()
+}
+
+// escalateOverdue takes every escalation step that is due for each tenant with a policy
+func (c *Checker) escalateOverdue(ctx context.Context, now time.Time) {
+	policies, err := c.policies.FindAll(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			c.log.Error("Failed to load escalation policies", "error", err)
+		}
+		return
+	}
+
+	for _, policy := range policies {
+		// Steps are taken in order, so a notification overdue for several takes them in one pass
+		for step := range policy.Steps {
+			due := now.Add(-time.Duration(policy.Steps[step].After) * time.Second)
+			for ctx.Err() == nil {
+				notification, err := c.notifications.ClaimOverdue(ctx, policy.TenantID, step, policy.CreatedAt, due, policy.StopOnDelivery)
+				if err != nil {
+					if ctx.Err() == nil {
+						c.log.Error("Failed to claim overdue notification", "error", err, "tenant_id", policy.TenantID, "step", step+1)
+					}
+					break
+				}
+				if notification == nil {
+					break
+				}
+				c.escalate(ctx, policy, step, notification)
+			}
+		}
+	}
+}
+
+// escalate sends a claimed notification's escalation notice for step and records the outcome
+func (c *Checker) escalate(ctx context.Context, policy *domain.EscalationPolicy, step int, notification *domain.Notification) {
+	channel := policy.Steps[step].Channel
+	id := notification.ID.Hex()
+
+	record := &domain.EscalationRecord{
+		TenantID:       notification.TenantID,
+		NotificationID: id,
+		Step:           step + 1,
+		Channel:        channel,
+		Status:         domain.NotificationStatusSent,
+		CreatedAt:      time.Now(),
+	}
+	if err := c.send(ctx, notification, step+1, len(policy.Steps), channel); err != nil {
+		record.Status = domain.NotificationStatusFailed
+		record.Error = err.Error()
+		c.log.Error("Failed to send escalation notice", "error", err, "tenant_id", notification.TenantID, "notification_id", id, "step", step+1)
+	} else {
+		c.log.Info("Escalated unacknowledged notification", "tenant_id", notification.TenantID, "notification_id", id, "step", step+1, "channel", channel.Type)
+	}
+	metrics.CriticalEscalations.WithLabelValues(string(channel.Type), string(record.Status)).Inc()
+
+	if err := c.records.Create(ctx, record); err != nil {
+		c.log.Error("Failed to record escalation", "error", err, "tenant_id", notification.TenantID, "notification_id", id, "step", step+1)
+	}
+}
+
+// send notifies channel that notification is still unacknowledged
+func (c *Checker) send(ctx context.Context, notification *domain.Notification, step, steps int, channel domain.FallbackChannel) error {
+	id := notification.ID.Hex()
+	metadata := map[string]string{
+		domain.EscalationMetadataKey: id,
+		"escalation_step":            strconv.Itoa(step),
+	}
+	// The step is claimed before it is sent, but a retried send shouldn't notify twice
+	key := fmt.Sprintf("escalation:%s:%d", id, step)
+
+	var link string
+	if c.links != nil {
+		link, _ = c.links.Link(notification.TenantID, id)
+	}
+
+	if channel.Type == domain.NotificationTypeSMS {
+		message := fmt.Sprintf("Critical %s to %s not acknowledged (escalation %d of %d).", notification.Type, notification.Recipient, step, steps)
+		if link != "" {
+			message += " Acknowledge: " + link
+		}
+		var errs []error
+		for i, to := range channel.To {
+			err := c.sends.SendSMS(ctx, &domain.SendSMSRequest{
+				TenantID:       notification.TenantID,
+				To:             to,
+				Message:        message,
+				Priority:       domain.NotificationPriorityCritical,
+				Category:       notification.Category,
+				Tags:           []string{"escalation"},
+				Metadata:       metadata,
+				IdempotencyKey: fmt.Sprintf("%s:%d", key, i),
+			})
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if len(errs) > 0 {
+			return fmt.Errorf("%d of %d escalation SMS failed: %w", len(errs), len(channel.To), errs[0])
+		}
+		return nil
+	}
+
+	subject := notification.Subject
+	if subject == "" {
+		subject = string(notification.Type) + " to " + notification.Recipient
+	}
+	return c.sends.SendEmail(ctx, &domain.SendEmailRequest{
+		TenantID:       notification.TenantID,
+		To:             channel.To,
+		Subject:        "Unacknowledged critical notification: " + subject,
+		Body:           summary(notification, step, steps, link),
+		Priority:       domain.NotificationPriorityCritical,
+		Category:       notification.Category,
+		Tags:           []string{"escalation"},
+		Metadata:       metadata,
+		IdempotencyKey: key,
+	})
+}
+
+// summary describes an unacknowledged notification for an escalation email
+func summary(notification *domain.Notification, step, steps int, link string) string {
+	var b strings.Builder
+	b.WriteString("A critical notification has not been acknowledged.\n\n")
+	fmt.Fprintf(&b, "Notification: %s\n", notification.ID.Hex())
+	fmt.Fprintf(&b, "Type: %s\n", notification.Type)
+	fmt.Fprintf(&b, "Recipient: %s\n", notification.Recipient)
+	for _, field := range []struct{ name, value string }{
+		{"Subject", notification.Subject},
+		{"Category", notification.Category},
+		{"Group", notification.GroupID},
+	} {
+		if field.value != "" {
+			fmt.Fprintf(&b, "%s: %s\n", field.name, field.value)
+		}
+	}
+	if notification.SentAt != nil {
+		fmt.Fprintf(&b, "Sent: %s\n", notification.SentAt.UTC().Format(time.RFC3339))
+	}
+	fmt.Fprintf(&b, "Escalation: %d of %d\n", step, steps)
+	if link != "" {
+		fmt.Fprintf(&b, "\nAcknowledge: %s\n", link)
+	}
+	return b.String()
+}
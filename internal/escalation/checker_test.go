@@ -0,0 +1,153 @@
+package escalation
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type policyStore []*domain.EscalationPolicy
+
+func (s policyStore) FindAll(ctx context.Context) ([]*domain.EscalationPolicy, error) {
+	return s, nil
+}
+
+// overdueStore hands out each notification once per step, when it was sent before the step's due time
+type overdueStore struct {
+	notifications []*domain.Notification
+}
+
+func (s *overdueStore) ClaimOverdue(ctx context.Context, tenantID string, step int, sentAfter, sentBefore time.Time, undelivered bool) (*domain.Notification, error) {
+	for _, n := range s.notifications {
+		if n.TenantID == tenantID && n.EscalationStep == step && !n.SentAt.After(sentBefore) && !n.SentAt.Before(sentAfter) {
+			n.EscalationStep = step + 1
+			return n, nil
+		}
+	}
+	return nil, nil
+}
+
+type recordStore struct {
+	records []*domain.EscalationRecord
+}
+
+func (s *recordStore) Create(ctx context.Context, record *domain.EscalationRecord) error {
+	s.records = append(s.records, record)
+	return nil
+}
+
+type recordingSender struct {
+	emails  []*domain.SendEmailRequest
+	sms     []*domain.SendSMSRequest
+	failSMS bool
+}
+
+func (s *recordingSender) SendEmail(ctx context.Context, req *domain.SendEmailRequest) error {
+	s.emails = append(s.emails, req)
+	return nil
+}
+
+func (s *recordingSender) SendSMS(ctx context.Context, req *domain.SendSMSRequest) error {
+	if s.failSMS {
+		return errors.New("provider unavailable")
+	}
+	s.sms = append(s.sms, req)
+	return nil
+}
+
+type staticLinker string
+
+func (l staticLinker) Link(tenantID, notificationID string) (string, time.Time) {
+	return string(l) + notificationID, time.Time{}
+}
+
+func TestEscalateOverdue(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	sentAt := now.Add(-20 * time.Minute)
+
+	policy := &domain.EscalationPolicy{
+		TenantID:  "tenant-1",
+		CreatedAt: now.Add(-24 * time.Hour),
+		Steps: []domain.EscalationStep{
+			{After: 600, Channel: domain.FallbackChannel{Type: domain.NotificationTypeEmail, To: []string{"lead@example.com"}}},
+			{After: 1800, Channel: domain.FallbackChannel{Type: domain.NotificationTypeSMS, To: []string{"+15550100"}}},
+		},
+	}
+	notification := &domain.Notification{
+		ID:        primitive.NewObjectID(),
+		TenantID:  "tenant-1",
+		Type:      domain.NotificationTypeSMS,
+		Priority:  domain.NotificationPriorityCritical,
+		Recipient: "+15550199",
+		SentAt:    &sentAt,
+	}
+	notifications := &overdueStore{notifications: []*domain.Notification{notification}}
+	records := &recordStore{}
+	sends := &recordingSender{failSMS: true}
+	checker := NewChecker(policyStore{policy}, notifications, records, sends, staticLinker("https://notify.example.com/ack/"), Config{}, logger.NewLogger())
+
+	// Twenty minutes unacknowledged: the first step is due, the second isn't
+	checker.escalateOverdue(ctx, now)
+	if len(sends.emails) != 1 || len(records.records) != 1 {
+		t.Fatalf("sent %d emails, recorded %d steps, want 1 each", len(sends.emails), len(records.records))
+	}
+	email := sends.emails[0]
+	id := notification.ID.Hex()
+	if email.To[0] != "lead@example.com" || email.Priority != domain.NotificationPriorityCritical || email.Metadata[domain.EscalationMetadataKey] != id {
+		t.Errorf("escalation email to %v, priority %q, metadata %v", email.To, email.Priority, email.Metadata)
+	}
+	if !strings.Contains(email.Body, "https://notify.example.com/ack/"+id) || email.IdempotencyKey != "escalation:"+id+":1" {
+		t.Errorf("escalation email key %q, body %q", email.IdempotencyKey, email.Body)
+	}
+	if records.records[0].Step != 1 || records.records[0].Status != domain.NotificationStatusSent {
+		t.Errorf("recorded step %d, status %q", records.records[0].Step, records.records[0].Status)
+	}
+
+	// Polling again doesn't repeat the first step
+	checker.escalateOverdue(ctx, now.Add(time.Minute))
+	if len(sends.emails) != 1 || len(records.records) != 1 {
+		t.Fatalf("repeated poll sent %d emails, recorded %d steps", len(sends.emails), len(records.records))
+	}
+
+	// Half an hour unacknowledged: the second step fails and is recorded as failed
+	checker.escalateOverdue(ctx, now.Add(15*time.Minute))
+	if len(records.records) != 2 || records.records[1].Step != 2 || records.records[1].Status != domain.NotificationStatusFailed || records.records[1].Error == "" {
+		t.Errorf("second step records %+v", records.records)
+	}
+}
+
+func TestNormalizePolicy(t *testing.T) {
+	valid := func() *domain.UpdateEscalationPolicyRequest {
+		return &domain.UpdateEscalationPolicyRequest{Steps: []domain.EscalationStep{
+			{After: 300, Channel: domain.FallbackChannel{Type: domain.NotificationTypeEmail, To: []string{" lead@example.com "}}},
+			{After: 900, Channel: domain.FallbackChannel{Type: domain.NotificationTypeSMS, To: []string{"+15550100"}}},
+		}}
+	}
+
+	req := valid()
+	if err := NormalizePolicy(req); err != nil || req.Steps[0].Channel.To[0] != "lead@example.com" {
+		t.Errorf("NormalizePolicy() error = %v, recipient %q", err, req.Steps[0].Channel.To[0])
+	}
+
+	for name, change := range map[string]func(*domain.UpdateEscalationPolicyRequest){
+		"no steps":     func(r *domain.UpdateEscalationPolicyRequest) { r.Steps = nil },
+		"out of order": func(r *domain.UpdateEscalationPolicyRequest) { r.Steps[1].After = 300 },
+		"webhook": func(r *domain.UpdateEscalationPolicyRequest) {
+			r.Steps[0].Channel.Type = domain.NotificationTypeWebhook
+		},
+		"invalid email": func(r *domain.UpdateEscalationPolicyRequest) { r.Steps[0].Channel.To = []string{"not-an-address"} },
+	} {
+		req := valid()
+		change(req)
+		if err := NormalizePolicy(req); err == nil {
+			t.Errorf("NormalizePolicy() with %s succeeded", name)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package experiment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/sender"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// ErrInvalidTest is returned when an email's A/B test options can't be used
+var ErrInvalidTest = errors.New("invalid A/B test")
+
+// TestError describes A/B test options that can't be used
+type TestError struct {
+	Reason string
+}
+
+// Error implements the error interface
+func (e *TestError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrInvalidTest, e.Reason)
+}
+
+// Unwrap allows errors.Is(err, ErrInvalidTest)
+func (e *TestError) Unwrap() error {
+	return ErrInvalidTest
+}
+
+// AsTestError returns the *TestError in err's chain, if any
+func AsTestError(err error) (*TestError, bool) {
+	var testErr *TestError
+	ok := errors.As(err, &testErr)
+	return testErr, ok
+}
+
+// Assign returns the index of the variant recipient gets in experiment.
+// The recipient's address is hashed with the experiment name, so the assignment is the same
+// on every send and independent between experiments; variants get recipients in proportion to their weight.
+func Assign(experiment, recipient string, variants []domain.TemplateVariant) int {
+	total := 0
+	for _, variant := range variants {
+		total += variant.Weight
+	}
+	if total <= 0 {
+		return 0
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(experiment))
+	h.Write([]byte{0})
+	h.Write([]byte(strings.ToLower(strings.TrimSpace(recipient))))
+	point := int(h.Sum64() % uint64(total))
+
+	for i, variant := range variants {
+		if point < variant.Weight {
+			return i
+		}
+		point -= variant.Weight
+	}
+	return len(variants) - 1
+}
+
+// Check rejects an email whose ab_test options can't be used
+func Check(req *domain.SendEmailRequest) error {
+	test := req.ABTest
+	switch {
+	case test == nil:
+		return nil
+	case strings.TrimSpace(test.Experiment) == "":
+		return &TestError{Reason: "experiment is required"}
+	case len(test.Variants) < 2:
+		return &TestError{Reason: "at least two variants are required"}
+	case req.TemplateID != "":
+		return &TestError{Reason: "template_id cannot be combined with ab_test; set a template on each variant"}
+	case len(req.CC) > 0 || len(req.BCC) > 0:
+		return &TestError{Reason: "cc and bcc cannot be sent in an A/B test"}
+	}
+
+	names := make(map[string]bool, len(test.Variants))
+	for i, variant := range test.Variants {
+		switch {
+		case strings.TrimSpace(variant.Name) == "":
+			return &TestError{Reason: fmt.Sprintf("variant %d has no name", i)}
+		case names[variant.Name]:
+			return &TestError{Reason: fmt.Sprintf("variant name %q is used more than once", variant.Name)}
+		case variant.TemplateID == "":
+			return &TestError{Reason: fmt.Sprintf("variant %q has no template_id", variant.Name)}
+		case variant.Weight <= 0:
+			return &TestError{Reason: fmt.Sprintf("variant %q must have a positive weight", variant.Name)}
+		}
+		names[variant.Name] = true
+	}
+	return nil
+}
+
+// VariantSender sends emails marked with ab_test as one email per variant, each to the
+// recipients assigned that variant and tagged with it in the metadata. Other sends pass straight through.
+type VariantSender struct {
+	next sender.Sender
+	log  *logger.Logger
+}
+
+// NewSender creates a sender that splits A/B tested emails between their variants
+func NewSender(next sender.Sender, log *logger.Logger) *VariantSender {
+	return &VariantSender{
+		next: next,
+		log:  log,
+	}
+}
+
+// SendEmail sends each recipient of an A/B tested email the template of their variant, or sends the email
+func (s *VariantSender) SendEmail(ctx context.Context, req *domain.SendEmailRequest) error {
+	if req.ABTest == nil {
+		return s.next.SendEmail(ctx, req)
+	}
+	// Held and scheduled emails are checked again here
+	if err := Check(req); err != nil {
+		return err
+	}
+
+	test := req.ABTest
+	assigned := make([][]string, len(test.Variants))
+	for _, recipient := range req.To {
+		i := Assign(test.Experiment, recipient, test.Variants)
+		assigned[i] = append(assigned[i], recipient)
+	}
+
+	for i, variant := range test.Variants {
+		if len(assigned[i]) == 0 {
+			continue
+		}
+		split := *req
+		split.To = assigned[i]
+		split.TemplateID = variant.TemplateID
+		split.ABTest = nil
+		split.Metadata = withVariant(req.Metadata, test.Experiment, variant.Name)
+		if req.IdempotencyKey != "" {
+			split.IdempotencyKey = req.IdempotencyKey + ":" + variant.Name
+		}
+		if err := s.next.SendEmail(ctx, &split); err != nil {
+			return fmt.Errorf("failed to send variant %q of experiment %q: %w", variant.Name, test.Experiment, err)
+		}
+	}
+	s.log.Debug("Sent A/B tested email", "tenant_id", req.TenantID, "experiment", test.Experiment, "recipients", len(req.To), "variants", len(test.Variants))
+	return nil
+}
+
+// SendSMS sends an SMS
+func (s *VariantSender) SendSMS(ctx context.Context, req *domain.SendSMSRequest) error {
+	return s.next.SendSMS(ctx, req)
+}
+
+// SendWebhook sends a webhook
+func (s *VariantSender) SendWebhook(ctx context.Context, req *domain.SendWebhookRequest) error {
+	return s.next.SendWebhook(ctx, req)
+}
+
+// withVariant returns metadata with the experiment and variant added
+func withVariant(metadata map[string]string, experiment, variant string) map[string]string {
+	tagged := make(map[string]string, len(metadata)+2)
+	for key, value := range metadata {
+		tagged[key] = value
+	}
+	tagged[domain.ExperimentMetadataKey] = experiment
+	tagged[domain.VariantMetadataKey] = variant
+	return tagged
+}
@@ -0,0 +1,119 @@
+package experiment
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+type recordingSender struct {
+	emails []*domain.SendEmailRequest
+}
+
+func (s *recordingSender) SendEmail(ctx context.Context, req *domain.SendEmailRequest) error {
+	s.emails = append(s.emails, req)
+	return nil
+}
+
+func (s *recordingSender) SendSMS(ctx context.Context, req *domain.SendSMSRequest) error {
+	return nil
+}
+
+func (s *recordingSender) SendWebhook(ctx context.Context, req *domain.SendWebhookRequest) error {
+	return nil
+}
+
+func TestAssign(t *testing.T) {
+	variants := []domain.TemplateVariant{
+		{Name: "a", TemplateID: "tmpl-a", Weight: 80},
+		{Name: "b", TemplateID: "tmpl-b", Weight: 20},
+	}
+
+	counts := make([]int, len(variants))
+	for i := 0; i < 10000; i++ {
+		recipient := fmt.Sprintf("user%d@example.com", i)
+		variant := Assign("subject-line", recipient, variants)
+		if again := Assign("subject-line", recipient, variants); again != variant {
+			t.Fatalf("Assign(%s) = %d, then %d", recipient, variant, again)
+		}
+		counts[variant]++
+	}
+	if counts[0] < 7600 || counts[0] > 8400 {
+		t.Errorf("variant a got %d of 10000 recipients, want about 8000", counts[0])
+	}
+
+	if Assign("subject-line", "Ann@Example.com ", variants) != Assign("subject-line", "ann@example.com", variants) {
+		t.Error("Assign() depends on address case")
+	}
+}
+
+func TestVariantSender(t *testing.T) {
+	next := &recordingSender{}
+	sender := NewSender(next, logger.NewLogger())
+
+	to := make([]string, 50)
+	for i := range to {
+		to[i] = fmt.Sprintf("user%d@example.com", i)
+	}
+	req := &domain.SendEmailRequest{
+		TenantID:       "tenant-1",
+		To:             to,
+		Subject:        "Spring sale",
+		Body:           "Hello",
+		IdempotencyKey: "sale-1",
+		Metadata:       map[string]string{"campaign": "spring"},
+		ABTest: &domain.ABTestOptions{
+			Experiment: "spring-sale",
+			Variants: []domain.TemplateVariant{
+				{Name: "plain", TemplateID: "tmpl-plain", Weight: 1},
+				{Name: "bold", TemplateID: "tmpl-bold", Weight: 1},
+			},
+		},
+	}
+	if err := sender.SendEmail(context.Background(), req); err != nil {
+		t.Fatalf("SendEmail() error = %v", err)
+	}
+	if len(next.emails) != 2 {
+		t.Fatalf("sent %d emails, want one per variant", len(next.emails))
+	}
+
+	sent := 0
+	for i, email := range next.emails {
+		variant := req.ABTest.Variants[i]
+		if email.TemplateID != variant.TemplateID || email.ABTest != nil || email.IdempotencyKey != "sale-1:"+variant.Name {
+			t.Errorf("variant %s email has template %q, key %q", variant.Name, email.TemplateID, email.IdempotencyKey)
+		}
+		if email.Metadata[domain.VariantMetadataKey] != variant.Name || email.Metadata[domain.ExperimentMetadataKey] != "spring-sale" || email.Metadata["campaign"] != "spring" {
+			t.Errorf("variant %s email metadata %v", variant.Name, email.Metadata)
+		}
+		for _, recipient := range email.To {
+			if Assign("spring-sale", recipient, req.ABTest.Variants) != i {
+				t.Errorf("%s was sent variant %s", recipient, variant.Name)
+			}
+		}
+		sent += len(email.To)
+	}
+	if sent != len(to) || len(req.Metadata) != 1 {
+		t.Errorf("sent to %d of %d recipients, request metadata %v", sent, len(to), req.Metadata)
+	}
+
+	for name, change := range map[string]func(*domain.SendEmailRequest){
+		"template_id":    func(r *domain.SendEmailRequest) { r.TemplateID = "tmpl-1" },
+		"cc":             func(r *domain.SendEmailRequest) { r.CC = []string{"cc@example.com"} },
+		"one variant":    func(r *domain.SendEmailRequest) { r.ABTest.Variants = r.ABTest.Variants[:1] },
+		"duplicate name": func(r *domain.SendEmailRequest) { r.ABTest.Variants[1].Name = "plain" },
+		"zero weight":    func(r *domain.SendEmailRequest) { r.ABTest.Variants[0].Weight = 0 },
+	} {
+		invalid := *req
+		test := *req.ABTest
+		test.Variants = append([]domain.TemplateVariant(nil), req.ABTest.Variants...)
+		invalid.ABTest = &test
+		change(&invalid)
+		if _, ok := AsTestError(sender.SendEmail(context.Background(), &invalid)); !ok {
+			t.Errorf("SendEmail() with invalid %s did not return a TestError", name)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+// Package footer appends each tenant's email footer, such as a legal notice or signature, to
+// outgoing emails after their templates are rendered.
+package footer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/email"
+)
+
+// ErrInvalidFooter is returned when a footer is too large or its unsubscribe variants have no URL placeholder
+var ErrInvalidFooter = errors.New("invalid email footer")
+
+// unsubscribeURLRegex matches the http or https URL in a List-Unsubscribe header, which may
+// also list a mailto address (RFC 2369)
+var unsubscribeURLRegex = regexp.MustCompile(`<(https?://[^>\s]+)>`)
+
+// Store interface for per-tenant email footer storage
+type Store interface {
+	FindEmailFooter(ctx context.Context, tenantID string) (*domain.EmailFooter, error)
+}
+
+// Validate checks that a footer fits in domain.MaxEmailFooterBytes and that its unsubscribe
+// variants contain domain.UnsubscribeURLPlaceholder
+func Validate(req *domain.SetEmailFooterRequest) error {
+	size := len(req.HTML) + len(req.Text) + len(req.UnsubscribeHTML) + len(req.UnsubscribeText)
+	if size > domain.MaxEmailFooterBytes {
+		return fmt.Errorf("%w: footer is %d bytes, at most %d are allowed", ErrInvalidFooter, size, domain.MaxEmailFooterBytes)
+	}
+	if !hasPlaceholder(req.UnsubscribeHTML) {
+		return fmt.Errorf("%w: unsubscribe_html must contain %s", ErrInvalidFooter, domain.UnsubscribeURLPlaceholder)
+	}
+	if !hasPlaceholder(req.UnsubscribeText) {
+		return fmt.Errorf("%w: unsubscribe_text must contain %s", ErrInvalidFooter, domain.UnsubscribeURLPlaceholder)
+	}
+	return nil
+}
+
+// Resolver finds the footer to append to each email
+type Resolver struct {
+	store Store
+}
+
+// NewResolver creates a new email footer resolver
+func NewResolver(store Store) *Resolver {
+	return &Resolver{store: store}
+}
+
+// For returns the HTML and text footer for the email in req, or empty strings when the
+// email opts out with SkipFooter or the tenant has no footer
+func (r *Resolver) For(ctx context.Context, req *domain.SendEmailRequest) (htmlFooter, textFooter string, err error) {
+	if req.SkipFooter {
+		return "", "", nil
+	}
+	footer, err := r.store.FindEmailFooter(ctx, req.TenantID)
+	if err != nil || footer == nil {
+		return "", "", err
+	}
+	htmlFooter, textFooter = Render(footer, req.Headers)
+	return htmlFooter, textFooter, nil
+}
+
+// Compose adds the tenant's footer to msg, unless msg was built with SkipFooter or the tenant
+// has no footer. The Resolver is an email.Composer, so the email router adds footers to every
+// email it sends.
+func (r *Resolver) Compose(ctx context.Context, tenantID string, msg *email.Message) error {
+	if msg.SkipFooter {
+		return nil
+	}
+	footer, err := r.store.FindEmailFooter(ctx, tenantID)
+	if err != nil || footer == nil {
+		return err
+	}
+	headers := make(map[string]string, len(msg.Headers))
+	for _, header := range msg.Headers {
+		headers[header.Name] = header.Value
+	}
+	msg.FooterHTML, msg.FooterText = Render(footer, headers)
+	return nil
+}
+
+// Render returns footer's HTML and text for an email with the given headers. The unsubscribe
+// variants follow the footer when the headers have a List-Unsubscribe URL. A footer without
+// an HTML variant is added to HTML emails as escaped text.
+func Render(footer *domain.EmailFooter, headers map[string]string) (htmlFooter, textFooter string) {
+	htmlFooter = footer.HTML
+	if htmlFooter == "" {
+		htmlFooter = textToHTML(footer.Text)
+	}
+	textFooter = footer.Text
+
+	url := UnsubscribeURL(headers)
+	if url == "" {
+		return htmlFooter, textFooter
+	}
+	unsubscribeHTML := strings.ReplaceAll(footer.UnsubscribeHTML, domain.UnsubscribeURLPlaceholder, html.EscapeString(url))
+	if unsubscribeHTML == "" {
+		unsubscribeHTML = textToHTML(strings.ReplaceAll(footer.UnsubscribeText, domain.UnsubscribeURLPlaceholder, url))
+	}
+	unsubscribeText := strings.ReplaceAll(footer.UnsubscribeText, domain.UnsubscribeURLPlaceholder, url)
+	return htmlFooter + unsubscribeHTML, join(textFooter, unsubscribeText)
+}
+
+// UnsubscribeURL returns the http or https URL in an email's List-Unsubscribe header, or an
+// empty string when it has none. Header names are compared without regard to case.
+func UnsubscribeURL(headers map[string]string) string {
+	for name, value := range headers {
+		if strings.EqualFold(name, "List-Unsubscribe") {
+			if match := unsubscribeURLRegex.FindStringSubmatch(value); match != nil {
+				return match[1]
+			}
+		}
+	}
+	return ""
+}
+
+// hasPlaceholder reports whether an unsubscribe variant is unset or has the URL placeholder
+func hasPlaceholder(variant string) bool {
+	return variant == "" || strings.Contains(variant, domain.UnsubscribeURLPlaceholder)
+}
+
+// textToHTML renders plain text as an escaped paragraph, keeping its line breaks
+func textToHTML(text string) string {
+	if text == "" {
+		return ""
+	}
+	return "<p>" + strings.ReplaceAll(html.EscapeString(text), "\n", "<br>") + "</p>"
+}
+
+// join joins the non-empty parts with a line break
+func join(first, second string) string {
+	if first == "" || second == "" {
+		return first + second
+	}
+	return first + "\n" + second
+}
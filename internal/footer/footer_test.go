@@ -0,0 +1,107 @@
+package footer
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/email"
+)
+
+type fakeStore struct {
+	footer *domain.EmailFooter
+}
+
+func (s fakeStore) FindEmailFooter(ctx context.Context, tenantID string) (*domain.EmailFooter, error) {
+	return s.footer, nil
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate(&domain.SetEmailFooterRequest{Text: "Acme Inc.", UnsubscribeText: "Unsubscribe: {{unsubscribe_url}}"}); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	if err := Validate(&domain.SetEmailFooterRequest{Text: "Acme Inc.", UnsubscribeHTML: "<a>Unsubscribe</a>"}); !errors.Is(err, ErrInvalidFooter) {
+		t.Errorf("Validate() error = %v, want an unsubscribe variant without a URL rejected", err)
+	}
+	if err := Validate(&domain.SetEmailFooterRequest{HTML: strings.Repeat("x", domain.MaxEmailFooterBytes+1)}); !errors.Is(err, ErrInvalidFooter) {
+		t.Errorf("Validate() error = %v, want an oversized footer rejected", err)
+	}
+}
+
+func TestResolverFor(t *testing.T) {
+	resolver := NewResolver(fakeStore{footer: &domain.EmailFooter{
+		Text:            "Acme Inc.\n1 Main St",
+		UnsubscribeHTML: `<a href="{{unsubscribe_url}}">Unsubscribe</a>`,
+		UnsubscribeText: "Unsubscribe: {{unsubscribe_url}}",
+	}})
+	ctx := context.Background()
+
+	htmlFooter, textFooter, err := resolver.For(ctx, &domain.SendEmailRequest{TenantID: "tenant-1"})
+	if err != nil {
+		t.Fatalf("For() error = %v", err)
+	}
+	if htmlFooter != "<p>Acme Inc.<br>1 Main St</p>" || textFooter != "Acme Inc.\n1 Main St" {
+		t.Errorf("For() = %q, %q; want the text footer without unsubscribe links", htmlFooter, textFooter)
+	}
+
+	req := &domain.SendEmailRequest{
+		TenantID: "tenant-1",
+		Headers:  map[string]string{"list-unsubscribe": "<mailto:unsub@example.com>, <https://example.com/unsub?a=1&b=2>"},
+	}
+	htmlFooter, textFooter, _ = resolver.For(ctx, req)
+	if !strings.HasSuffix(htmlFooter, `<a href="https://example.com/unsub?a=1&amp;b=2">Unsubscribe</a>`) {
+		t.Errorf("HTML footer = %q, want the escaped unsubscribe link appended", htmlFooter)
+	}
+	if !strings.HasSuffix(textFooter, "\nUnsubscribe: https://example.com/unsub?a=1&b=2") {
+		t.Errorf("text footer = %q, want the unsubscribe line appended", textFooter)
+	}
+
+	req.SkipFooter = true
+	if htmlFooter, textFooter, _ = resolver.For(ctx, req); htmlFooter != "" || textFooter != "" {
+		t.Errorf("For() = %q, %q; want no footer for an email that opts out", htmlFooter, textFooter)
+	}
+}
+
+// capturingSender records the text part of each message it sends
+type capturingSender struct {
+	texts []string
+}
+
+func (s *capturingSender) Send(ctx context.Context, msg *email.Message) (string, error) {
+	s.texts = append(s.texts, msg.TextBody())
+	return "provider-1", nil
+}
+
+func TestRouterSendsFooter(t *testing.T) {
+	provider := &capturingSender{}
+	email.Register("footer-capture", func(cfg email.Config, client *http.Client) (email.Sender, error) {
+		return provider, nil
+	})
+	router, err := email.NewRouter(email.Config{Provider: "footer-capture"})
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+	router.Use(NewResolver(fakeStore{footer: &domain.EmailFooter{Text: "Acme Inc."}}))
+
+	for _, skip := range []bool{false, true} {
+		req := &domain.SendEmailRequest{TenantID: "tenant-1", To: []string{"user@example.com"}, Subject: "Hi", Body: "Hello", SkipFooter: skip}
+		msg, err := email.NewMessage(mail.Address{Address: "noreply@example.com"}, req, "notification-1")
+		if err != nil {
+			t.Fatalf("NewMessage() error = %v", err)
+		}
+		if _, _, err := router.Send(context.Background(), req.TenantID, msg); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+	}
+
+	if !strings.HasPrefix(provider.texts[0], "Hello") || !strings.HasSuffix(provider.texts[0], "Acme Inc.") {
+		t.Errorf("sent text = %q, want the body followed by the footer", provider.texts[0])
+	}
+	if provider.texts[1] != "Hello" {
+		t.Errorf("sent text with skip_footer = %q, want %q", provider.texts[1], "Hello")
+	}
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/ack"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/metrics"
+	"github.com/vhvplatform/go-notification-service/internal/middleware"
+	"github.com/vhvplatform/go-notification-service/internal/repository"
+	"github.com/vhvplatform/go-notification-service/internal/shared/errors"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+	"github.com/vhvplatform/go-notification-service/internal/signedlink"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// AckHandler handles explicit acknowledgments of notifications by their recipients
+type AckHandler struct {
+	notifications *repository.NotificationRepository
+	signer        *ack.Signer
+	log           *logger.Logger
+}
+
+// NewAckHandler creates a new acknowledgment handler
+func NewAckHandler(notifications *repository.NotificationRepository, signer *ack.Signer, log *logger.Logger) *AckHandler {
+	return &AckHandler{
+		notifications: notifications,
+		signer:        signer,
+		log:           log,
+	}
+}
+
+// AcknowledgeLink acknowledges a notification through a signed link, without API credentials.
+// The link's tenant_id, expires and signature query parameters must match what GetAckLink issued.
+func (h *AckHandler) AcknowledgeLink(c *gin.Context) {
+	id := c.Param("notificationID")
+	tenantID := c.Query("tenant_id")
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err == nil {
+		err = h.signer.Verify(tenantID, id, expires, c.Query(signedlink.SignatureParam))
+	}
+	if err != nil {
+		h.log.Warn("Rejected acknowledgment link", "error", err, "tenant_id", tenantID, "id", id)
+		c.Error(errors.NewUnauthorizedError("Invalid or expired acknowledgment link", err))
+		return
+	}
+
+	h.acknowledge(c, tenantID, id)
+}
+
+// Acknowledge acknowledges a notification on behalf of its recipient
+func (h *AckHandler) Acknowledge(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+
+	h.acknowledge(c, tenantID, c.Param("id"))
+}
+
+// acknowledge records the acknowledgment with the request's optional metadata
+func (h *AckHandler) acknowledge(c *gin.Context, tenantID, id string) {
+	var req domain.AcknowledgeRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(errors.NewBindingError(err))
+			return
+		}
+	}
+
+	notification, acknowledged, err := h.notifications.Acknowledge(c.Request.Context(), tenantID, id, time.Now(), req.Metadata)
+	if err != nil {
+		h.log.Error("Failed to acknowledge notification", "error", err, "tenant_id", tenantID, "id", id)
+		c.Error(appError(err, "Failed to acknowledge notification"))
+		return
+	}
+	if notification == nil {
+		c.Error(errors.NewNotFoundError("Notification not found", nil))
+		return
+	}
+
+	message := "Notification already acknowledged"
+	if acknowledged {
+		message = "Notification acknowledged"
+		metrics.NotificationsAcknowledged.WithLabelValues(string(notification.Type), string(notification.Priority)).Inc()
+		h.log.Info("Acknowledged notification", "tenant_id", tenantID, "id", id, "priority", notification.Priority)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"data": gin.H{
+			"notification_id": id,
+			"acknowledged_at": notification.AcknowledgedAt,
+			"ack_metadata":    notification.AckMetadata,
+		},
+	})
+}
+
+// GetAckLink returns a signed link that acknowledges the notification, for embedding in its content or an app
+func (h *AckHandler) GetAckLink(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+	id := c.Param("id")
+
+	if _, err := primitive.ObjectIDFromHex(id); err != nil {
+		c.Error(errors.NewValidationError("Invalid notification ID", err))
+		return
+	}
+
+	if _, err := h.notifications.FindByID(c.Request.Context(), id, tenantID); err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.Error(errors.NewNotFoundError("Notification not found", err))
+			return
+		}
+		h.log.Error("Failed to get notification", "error", err, "tenant_id", tenantID, "id", id)
+		c.Error(appError(err, "Failed to get notification"))
+		return
+	}
+
+	url, expiresAt := h.signer.Link(tenantID, id)
+	c.JSON(http.StatusOK, gin.H{"data": domain.AckLink{URL: url, ExpiresAt: expiresAt}})
+}
+
+// GetUnacknowledged lists critical notifications that were sent but not acknowledged, oldest first.
+// older_than_seconds leaves out notifications sent more recently.
+func (h *AckHandler) GetUnacknowledged(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+
+	olderThan, err := strconv.Atoi(c.DefaultQuery("older_than_seconds", "0"))
+	if err != nil || olderThan < 0 {
+		c.Error(errors.NewValidationError("older_than_seconds must be a non-negative number", err))
+		return
+	}
+	page, pageSize := pageQuery(c)
+
+	sentBefore := time.Now().Add(-time.Duration(olderThan) * time.Second)
+	notifications, total, err := h.notifications.FindUnacknowledged(c.Request.Context(), tenantID, sentBefore, page, pageSize)
+	if err != nil {
+		h.log.Error("Failed to get unacknowledged notifications", "error", err, "tenant_id", tenantID)
+		c.Error(appError(err, "Failed to get unacknowledged notifications"))
+		return
+	}
+
+	c.JSON(http.StatusOK, NewPaginatedResponse(notifications, total, page, pageSize))
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/apitoken"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/middleware"
+	"github.com/vhvplatform/go-notification-service/internal/shared/errors"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// APITokenHandler issues API tokens to tenants
+type APITokenHandler struct {
+	signer *apitoken.Signer
+	maxTTL time.Duration
+	log    *logger.Logger
+}
+
+// NewAPITokenHandler creates a new API token handler. Issued tokens last at most maxTTL.
+func NewAPITokenHandler(signer *apitoken.Signer, maxTTL time.Duration, log *logger.Logger) *APITokenHandler {
+	return &APITokenHandler{
+		signer: signer,
+		maxTTL: maxTTL,
+		log:    log,
+	}
+}
+
+// IssueToken issues a token for the tenant in the path with the requested scopes.
+// Tokens can't be revoked individually; they expire, or all of them stop verifying once
+// their signing secret is removed from API_TOKEN_SECRETS.
+func (h *APITokenHandler) IssueToken(c *gin.Context) {
+	tenantID := c.Param("id")
+	c.Set(middleware.AuditTenantIDKey, tenantID)
+
+	var req domain.IssueAPITokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBindingError(err))
+		return
+	}
+
+	ttl := domain.DefaultAPITokenTTL
+	if req.TTLSeconds > 0 {
+		ttl = time.Duration(req.TTLSeconds) * time.Second
+	}
+	ttl = min(ttl, h.maxTTL)
+
+	token, claims, err := h.signer.Issue(tenantID, req.Scopes, ttl)
+	if err != nil {
+		c.Error(errors.NewValidationError("Invalid API token request", err))
+		return
+	}
+	c.Set(middleware.AuditResourceIDKey, claims.ID)
+
+	// The token is a credential, so only its ID is logged
+	h.log.Info("Issued API token", "tenant_id", tenantID, "token_id", claims.ID, "scopes", req.Scopes, "expires_at", claims.ExpiresAt)
+	c.JSON(http.StatusCreated, gin.H{
+		"data": domain.APIToken{
+			ID:        claims.ID,
+			TenantID:  tenantID,
+			Scopes:    claims.Scopes(),
+			Token:     token,
+			ExpiresAt: time.Unix(claims.ExpiresAt, 0).UTC(),
+		},
+	})
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/middleware"
+	"github.com/vhvplatform/go-notification-service/internal/shared/errors"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// AuditLogFinder interface for querying the audit log
+type AuditLogFinder interface {
+	Find(ctx context.Context, query *domain.AuditLogQuery) ([]*domain.AuditLog, int64, error)
+}
+
+// AuditHandler handles HTTP requests for the tenant audit log
+type AuditHandler struct {
+	repo AuditLogFinder
+	log  *logger.Logger
+}
+
+// NewAuditHandler creates a new audit log handler
+func NewAuditHandler(repo AuditLogFinder, log *logger.Logger) *AuditHandler {
+	return &AuditHandler{
+		repo: repo,
+		log:  log,
+	}
+}
+
+// GetAuditLogs lists the tenant's audit log entries, newest first
+func (h *AuditHandler) GetAuditLogs(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+
+	var query domain.AuditLogQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.Error(errors.NewBindingError(err))
+		return
+	}
+
+	// Set tenant_id from authenticated context
+	query.TenantID = tenantID
+	query.Page, query.PageSize = normalizePage(query.Page, query.PageSize)
+
+	entries, total, err := h.repo.Find(c.Request.Context(), &query)
+	if err != nil {
+		h.log.Error("Failed to get audit logs", "error", err, "tenant_id", tenantID)
+		c.Error(appError(err, "Failed to get audit logs"))
+		return
+	}
+
+	c.JSON(http.StatusOK, NewPaginatedResponse(entries, total, query.Page, query.PageSize))
+}
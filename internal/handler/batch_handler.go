@@ -0,0 +1,257 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/vhvplatform/go-notification-service/internal/attachments"
+	"github.com/vhvplatform/go-notification-service/internal/contentcheck"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/email"
+	"github.com/vhvplatform/go-notification-service/internal/metrics"
+	"github.com/vhvplatform/go-notification-service/internal/middleware"
+	"github.com/vhvplatform/go-notification-service/internal/mxcheck"
+	"github.com/vhvplatform/go-notification-service/internal/pause"
+	"github.com/vhvplatform/go-notification-service/internal/quota"
+	"github.com/vhvplatform/go-notification-service/internal/shared/errors"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+	"github.com/vhvplatform/go-notification-service/internal/webhook"
+)
+
+// NotificationKeyFinder finds a tenant's notification by idempotency key
+type NotificationKeyFinder interface {
+	FindByIdempotencyKey(ctx context.Context, tenantID, idempotencyKey string) (*domain.Notification, error)
+}
+
+// BatchHandler handles batch send requests with mixed notification types
+type BatchHandler struct {
+	sends    *pause.Gate // Holds items whose channel is paused
+	repo     NotificationKeyFinder
+	quotas   *quota.Enforcer // Optional; nil disables quota checks
+	checks   emailChecks
+	webhooks *webhook.Allowlist // Optional; nil skips the destination check before items are held
+	log      *logger.Logger
+}
+
+// NewBatchHandler creates a new batch handler
+// The quota enforcer, attachment validator, domain verifier, content checker and webhook allowlist are optional.
+func NewBatchHandler(sends *pause.Gate, repo NotificationKeyFinder, quotas *quota.Enforcer, attachments *attachments.Validator, domains *mxcheck.Verifier, content *contentcheck.Checker, webhooks *webhook.Allowlist, log *logger.Logger) *BatchHandler {
+	return &BatchHandler{
+		sends:    sends,
+		repo:     repo,
+		quotas:   quotas,
+		checks:   emailChecks{attachments: attachments, domains: domains, content: content},
+		webhooks: webhooks,
+		log:      log,
+	}
+}
+
+// SendBatch sends a list of email, SMS and webhook requests in one call.
+// Each item gets a derived idempotency key so a retried batch does not resend
+// items that already succeeded. Item failures are reported per item, and an email
+// rejected for some of its recipients is reported as partial with each recipient's outcome.
+func (h *BatchHandler) SendBatch(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+
+	var req domain.BatchSendRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBindingError(err))
+		return
+	}
+	if !checkTenantID(c, req.TenantID) {
+		return
+	}
+
+	for i := range req.Items {
+		if err := validateBatchItem(&req.Items[i]); err != nil {
+			c.Error(errors.NewValidationError(fmt.Sprintf("Invalid item at index %d", i), err))
+			return
+		}
+		if !checkTenantID(c, batchItemTenantID(&req.Items[i])) {
+			return
+		}
+		if req.Items[i].Email != nil {
+			if err := h.checks.check(c.Request.Context(), tenantID, req.Items[i].Email); err != nil {
+				h.log.Warn("Rejected batch email item", "error", err, "tenant_id", tenantID, "index", i)
+				c.Error(appError(err, "Failed to validate email request"))
+				return
+			}
+		}
+		if req.Items[i].Webhook != nil && h.webhooks != nil {
+			if err := h.webhooks.Check(c.Request.Context(), tenantID, req.Items[i].Webhook.URL); err != nil {
+				h.log.Warn("Rejected batch webhook item", "error", err, "tenant_id", tenantID, "index", i)
+				c.Error(appError(err, "Failed to validate webhook request"))
+				return
+			}
+		}
+	}
+
+	// Set tenant_id from authenticated context
+	req.TenantID = tenantID
+	if req.IdempotencyKey == "" {
+		req.IdempotencyKey = uuid.NewString()
+	}
+
+	resp := domain.BatchSendResponse{
+		IdempotencyKey: req.IdempotencyKey,
+		Total:          len(req.Items),
+		Results:        make([]domain.BatchItemResult, 0, len(req.Items)),
+	}
+
+	ctx := c.Request.Context()
+	for i := range req.Items {
+		result := h.sendItem(ctx, tenantID, req.IdempotencyKey, i, &req.Items[i])
+		if result.Status == domain.NotificationStatusFailed {
+			resp.Failed++
+		} else {
+			resp.Succeeded++
+		}
+		resp.Results = append(resp.Results, result)
+	}
+
+	h.log.Info("Processed notification batch", "tenant_id", tenantID, "total", resp.Total, "failed", resp.Failed)
+	c.JSON(http.StatusOK, resp)
+}
+
+// sendItem sends a single batch item and builds its result
+func (h *BatchHandler) sendItem(ctx context.Context, tenantID, batchKey string, index int, item *domain.BatchSendItem) domain.BatchItemResult {
+	key := fmt.Sprintf("%s:%d", batchKey, index)
+	result := domain.BatchItemResult{
+		Index:          index,
+		Type:           item.Type,
+		IdempotencyKey: key,
+	}
+
+	// Each item counts against its channel's quota; items over a hard quota fail individually
+	var reservation *quota.Reservation
+	if h.quotas != nil {
+		var err error
+		reservation, err = h.quotas.Reserve(ctx, tenantID, item.Type, 1)
+		if err != nil {
+			h.log.Warn("Batch item rejected by quota", "error", err, "tenant_id", tenantID, "index", index, "type", item.Type)
+			result.Status = domain.NotificationStatusFailed
+			result.Error = err.Error()
+			return result
+		}
+	}
+
+	start := time.Now()
+	var err error
+	var held bool
+	switch item.Type {
+	case domain.NotificationTypeEmail:
+		item.Email.TenantID = tenantID
+		item.Email.IdempotencyKey = key
+		held = h.sends.Paused(item.Type, item.Email.Priority)
+		err = h.sends.SendEmail(ctx, item.Email)
+	case domain.NotificationTypeSMS:
+		item.SMS.TenantID = tenantID
+		item.SMS.IdempotencyKey = key
+		held = h.sends.Paused(item.Type, item.SMS.Priority)
+		err = h.sends.SendSMS(ctx, item.SMS)
+	case domain.NotificationTypeWebhook:
+		item.Webhook.TenantID = tenantID
+		item.Webhook.IdempotencyKey = key
+		held = h.sends.Paused(item.Type, item.Webhook.Priority)
+		err = h.sends.SendWebhook(ctx, item.Webhook)
+		// A webhook whose first attempt failed and was queued for a retry is pending, like a held one
+		held = held || item.Webhook.RetryQueued
+	}
+	partialErr, partial := email.AsPartialDeliveryError(err)
+	if !held {
+		observed := err
+		if partial {
+			observed = nil // The email was sent, to the recipients that were accepted
+		}
+		metrics.ObserveSend(string(item.Type), tenantID, start, observed)
+	}
+
+	if err != nil && !partial {
+		if h.quotas != nil {
+			if releaseErr := h.quotas.Release(ctx, reservation); releaseErr != nil {
+				h.log.Error("Failed to release batch item quota", "error", releaseErr, "tenant_id", tenantID, "index", index, "type", item.Type)
+			}
+		}
+		h.log.Error("Failed to send batch item", "error", err, "tenant_id", tenantID, "index", index, "type", item.Type)
+		result.Status = domain.NotificationStatusFailed
+		result.Error = err.Error()
+		return result
+	}
+
+	// Held items are sent, and get a notification ID, once their channel resumes or their retry succeeds
+	if held {
+		result.Status = domain.NotificationStatusPending
+		return result
+	}
+
+	result.Status = domain.NotificationStatusSent
+	if partial {
+		// The email was sent, so its quota stays charged
+		h.log.Warn("Batch email rejected for some recipients", "error", err, "tenant_id", tenantID, "index", index)
+		result.Status = domain.NotificationStatusPartial
+		result.Error = err.Error()
+		result.Recipients = recipientResults(item.Email, partialErr).Results
+	}
+	if notification, err := h.repo.FindByIdempotencyKey(ctx, tenantID, key); err == nil {
+		result.ID = notification.ID.Hex()
+	}
+	return result
+}
+
+// batchItemTenantID returns the tenant_id of the item's payload
+func batchItemTenantID(item *domain.BatchSendItem) string {
+	switch {
+	case item.Email != nil:
+		return item.Email.TenantID
+	case item.SMS != nil:
+		return item.SMS.TenantID
+	case item.Webhook != nil:
+		return item.Webhook.TenantID
+	}
+	return ""
+}
+
+// validateBatchItem checks that exactly the payload matching the item type is set
+func validateBatchItem(item *domain.BatchSendItem) error {
+	payloads := 0
+	for _, set := range []bool{item.Email != nil, item.SMS != nil, item.Webhook != nil} {
+		if set {
+			payloads++
+		}
+	}
+	if payloads != 1 {
+		return fmt.Errorf("exactly one of email, sms or webhook must be set")
+	}
+
+	switch item.Type {
+	case domain.NotificationTypeEmail:
+		if item.Email == nil {
+			return fmt.Errorf("email payload is required for type email")
+		}
+	case domain.NotificationTypeSMS:
+		if item.SMS == nil {
+			return fmt.Errorf("sms payload is required for type sms")
+		}
+	case domain.NotificationTypeWebhook:
+		if item.Webhook == nil {
+			return fmt.Errorf("webhook payload is required for type webhook")
+		}
+		if len(item.Webhook.URLs) > 0 {
+			return fmt.Errorf("webhook urls are not supported in batch items; send one item per url")
+		}
+		if err := webhook.CheckPayload(item.Webhook); err != nil {
+			return err
+		}
+		if item.Webhook.Fallback != nil {
+			return webhook.NormalizeFallback(item.Webhook.Fallback)
+		}
+	default:
+		return fmt.Errorf("unsupported notification type: %s", item.Type)
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/email"
+	"github.com/vhvplatform/go-notification-service/internal/middleware"
+	"github.com/vhvplatform/go-notification-service/internal/pause"
+	"github.com/vhvplatform/go-notification-service/internal/quota"
+	"github.com/vhvplatform/go-notification-service/internal/sender"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// smsSender fails SMS sends to the numbers in failTo
+type smsSender struct {
+	sender.Sender
+	failTo map[string]bool
+}
+
+func (s *smsSender) SendSMS(ctx context.Context, req *domain.SendSMSRequest) error {
+	if s.failTo[req.To] {
+		return errors.New("sms provider unavailable")
+	}
+	return nil
+}
+
+// partialSender rejects emails for the recipients in rejected, sending them to the others
+type partialSender struct {
+	sender.Sender
+	rejected []string
+}
+
+func (s *partialSender) SendEmail(ctx context.Context, req *domain.SendEmailRequest) error {
+	partialErr := &email.PartialDeliveryError{}
+	for _, recipient := range s.rejected {
+		partialErr.Rejected = append(partialErr.Rejected, email.RecipientError{Recipient: recipient, Err: errors.New("550 mailbox unavailable")})
+	}
+	return partialErr
+}
+
+// notificationsByKey finds notifications by idempotency key in memory
+type notificationsByKey map[string]*domain.Notification
+
+func (n notificationsByKey) FindByIdempotencyKey(ctx context.Context, tenantID, idempotencyKey string) (*domain.Notification, error) {
+	if notification, ok := n[idempotencyKey]; ok {
+		return notification, nil
+	}
+	return nil, errors.New("not found")
+}
+
+// usageStore counts quota usage in memory, optionally failing releases
+type usageStore struct {
+	mu          sync.Mutex
+	usage       map[string]int64
+	failRelease bool
+}
+
+func (s *usageStore) FindQuota(ctx context.Context, tenantID string, channel domain.NotificationType) (*domain.TenantQuota, error) {
+	return nil, nil
+}
+
+func (s *usageStore) IncrementUsage(ctx context.Context, tenantID string, channel domain.NotificationType, period domain.QuotaPeriod, periodKey string, delta int64, expiresAt time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if delta < 0 && s.failRelease {
+		return 0, errors.New("usage store unavailable")
+	}
+	s.usage[string(channel)+"/"+string(period)] += delta
+	return s.usage[string(channel)+"/"+string(period)], nil
+}
+
+func (s *usageStore) GetUsage(ctx context.Context, tenantID string, channel domain.NotificationType, period domain.QuotaPeriod, periodKey string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.usage[string(channel)+"/"+string(period)], nil
+}
+
+// sendBatch posts a batch of SMS to a batch handler and decodes the response
+func sendBatch(t *testing.T, next sender.Sender, store *usageStore, repo NotificationKeyFinder, to ...string) domain.BatchSendResponse {
+	t.Helper()
+	var items []domain.BatchSendItem
+	for _, number := range to {
+		items = append(items, domain.BatchSendItem{
+			Type: domain.NotificationTypeSMS,
+			SMS:  &domain.SendSMSRequest{To: number, Message: "Your code is 1234"},
+		})
+	}
+	return postBatch(t, next, store, repo, items)
+}
+
+// postBatch posts items to a batch handler and decodes the response
+func postBatch(t *testing.T, next sender.Sender, store *usageStore, repo NotificationKeyFinder, items []domain.BatchSendItem) domain.BatchSendResponse {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	log := logger.NewLogger()
+
+	gate := pause.NewGate(pause.NewController(nil, pause.Config{}, domain.SendPause{}, log), nil, next, log)
+	enforcer := quota.NewEnforcer(store, map[domain.NotificationType]quota.Limits{
+		domain.NotificationTypeEmail: {Daily: 100},
+		domain.NotificationTypeSMS:   {Daily: 100},
+	}, domain.QuotaPolicyHard)
+	h := NewBatchHandler(gate, repo, enforcer, nil, nil, nil, nil, log)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandlerMiddleware(), middleware.TenancyMiddleware())
+	router.POST("/batch", h.SendBatch)
+
+	body, err := json.Marshal(domain.BatchSendRequest{IdempotencyKey: "batch-1", Items: items})
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/batch", bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set(middleware.TenantIDHeader, "tenant-1")
+	router.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	var resp domain.BatchSendResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	return resp
+}
+
+func TestSendBatchReportsPartialFailure(t *testing.T) {
+	id := primitive.NewObjectID()
+	repo := notificationsByKey{"batch-1:0": {ID: id}}
+	store := &usageStore{usage: make(map[string]int64)}
+	next := &smsSender{failTo: map[string]bool{"+15550199": true}}
+
+	resp := sendBatch(t, next, store, repo, "+15550100", "+15550199")
+
+	if resp.Total != 2 || resp.Succeeded != 1 || resp.Failed != 1 {
+		t.Fatalf("response = %d total, %d succeeded, %d failed, want 2, 1, 1", resp.Total, resp.Succeeded, resp.Failed)
+	}
+	sent, failed := resp.Results[0], resp.Results[1]
+	if sent.Status != domain.NotificationStatusSent || sent.ID != id.Hex() || sent.IdempotencyKey != "batch-1:0" {
+		t.Errorf("results[0] = %+v, want sent notification %s with key batch-1:0", sent, id.Hex())
+	}
+	if failed.Status != domain.NotificationStatusFailed || failed.Error != "sms provider unavailable" || failed.IdempotencyKey != "batch-1:1" {
+		t.Errorf("results[1] = %+v, want failed with the send error and key batch-1:1", failed)
+	}
+}
+
+func TestSendBatchReleasesQuotaOfFailedItems(t *testing.T) {
+	store := &usageStore{usage: make(map[string]int64)}
+	next := &smsSender{failTo: map[string]bool{"+15550199": true}}
+
+	sendBatch(t, next, store, notificationsByKey{}, "+15550100", "+15550199")
+
+	// Only the sent item stays counted
+	for _, period := range []domain.QuotaPeriod{domain.QuotaPeriodDaily, domain.QuotaPeriodMonthly} {
+		if used := store.usage["sms/"+string(period)]; used != 1 {
+			t.Errorf("%s sms usage = %d, want 1", period, used)
+		}
+	}
+}
+
+func TestSendBatchReportsSendErrorWhenQuotaReleaseFails(t *testing.T) {
+	store := &usageStore{usage: make(map[string]int64), failRelease: true}
+	next := &smsSender{failTo: map[string]bool{"+15550199": true}}
+
+	resp := sendBatch(t, next, store, notificationsByKey{}, "+15550199")
+
+	if resp.Failed != 1 || resp.Results[0].Error != "sms provider unavailable" {
+		t.Errorf("results = %+v, want the item failed with the send error", resp.Results)
+	}
+}
+
+func TestValidateBatchItemRejectsWebhookFanout(t *testing.T) {
+	item := &domain.BatchSendItem{
+		Type:    domain.NotificationTypeWebhook,
+		Webhook: &domain.SendWebhookRequest{URLs: []string{"https://a.example.com/hook", "https://b.example.com/hook"}},
+	}
+	if err := validateBatchItem(item); err == nil {
+		t.Error("validateBatchItem() error = nil, want webhook urls rejected")
+	}
+}
+
+func TestValidateBatchItemRejectsPayloadsThatCannotBeEncoded(t *testing.T) {
+	item := &domain.BatchSendItem{
+		Type:    domain.NotificationTypeWebhook,
+		Webhook: &domain.SendWebhookRequest{URL: "https://a.example.com/hook", Encoding: "xml", Payload: map[string]any{"1st": "x"}},
+	}
+	if err := validateBatchItem(item); err == nil {
+		t.Error("validateBatchItem() error = nil, want the payload's invalid XML name rejected")
+	}
+}
+
+func TestSendBatchReportsPartialEmailDelivery(t *testing.T) {
+	id := primitive.NewObjectID()
+	repo := notificationsByKey{"batch-1:0": {ID: id}}
+	store := &usageStore{usage: make(map[string]int64)}
+	next := &partialSender{rejected: []string{"b@example.com"}}
+
+	resp := postBatch(t, next, store, repo, []domain.BatchSendItem{{
+		Type:  domain.NotificationTypeEmail,
+		Email: &domain.SendEmailRequest{To: []string{"a@example.com", "b@example.com"}, Subject: "Invoice", Body: "Attached"},
+	}})
+
+	// The email was sent, so the item counts as succeeded and keeps its notification ID
+	if resp.Succeeded != 1 || resp.Failed != 0 {
+		t.Fatalf("response = %d succeeded, %d failed, want 1, 0", resp.Succeeded, resp.Failed)
+	}
+	result := resp.Results[0]
+	if result.Status != domain.NotificationStatusPartial || result.ID != id.Hex() || result.Error == "" {
+		t.Errorf("results[0] = %+v, want partial notification %s with the rejection", result, id.Hex())
+	}
+	want := []domain.EmailRecipientResult{
+		{Recipient: "a@example.com", Status: domain.NotificationStatusSent},
+		{Recipient: "b@example.com", Status: domain.NotificationStatusFailed, Error: "550 mailbox unavailable"},
+	}
+	if len(result.Recipients) != len(want) {
+		t.Fatalf("recipients = %+v, want %+v", result.Recipients, want)
+	}
+	for i := range want {
+		if result.Recipients[i] != want[i] {
+			t.Errorf("recipients[%d] = %+v, want %+v", i, result.Recipients[i], want[i])
+		}
+	}
+
+	// The quota stays charged for the sent email
+	if used := store.usage["email/"+string(domain.QuotaPeriodDaily)]; used != 1 {
+		t.Errorf("daily email usage = %d, want 1", used)
+	}
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/vhvplatform/go-notification-service/internal/middleware"
+)
+
+// RegisterJSONFieldNames makes request binding failures name fields as they appear in the
+// JSON body, e.g. items[0].email.to, so validation errors point clients at the right field
+func RegisterJSONFieldNames() {
+	validate, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			return ""
+		case "":
+			return field.Name
+		}
+		return name
+	})
+}
+
+// checkTenantID rejects a bound request whose tenant_id names a tenant other than the
+// authenticated one. The authenticated tenant then replaces the request's.
+func checkTenantID(c *gin.Context, requestTenantID string) bool {
+	if _, err := middleware.ResolveTenantID(c.Request.Context(), requestTenantID); err != nil {
+		c.Error(appError(err, "Failed to resolve tenant"))
+		return false
+	}
+	return true
+}
@@ -1,53 +1,130 @@
-package handler
-
-import (
-	"net/http"
-
-	"github.com/gin-gonic/gin"
-	"github.com/vhvplatform/go-notification-service/internal/domain"
-	"github.com/vhvplatform/go-notification-service/internal/middleware"
-	"github.com/vhvplatform/go-notification-service/internal/service"
-	"github.com/vhvplatform/go-notification-service/internal/shared/errors"
-	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
-)
-
-// BulkHandler handles bulk notification operations
-type BulkHandler struct {
-	bulkEmailService *service.BulkEmailService
-	log              *logger.Logger
-}
-
-// NewBulkHandler creates a new bulk handler
-func NewBulkHandler(bulkEmailService *service.BulkEmailService, log *logger.Logger) *BulkHandler {
-	return &BulkHandler{
-		bulkEmailService: bulkEmailService,
-		log:              log,
-	}
-}
-
-// SendBulkEmail handles bulk email requests
-func (h *BulkHandler) SendBulkEmail(c *gin.Context) {
-	// Extract tenant_id from authenticated context
-	tenantID := middleware.MustGetTenantID(c)
-
-	var req domain.BulkEmailRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, errors.NewValidationError("Invalid request", err))
-		return
-	}
-
-	// Set tenant_id from authenticated context
-	req.TenantID = tenantID
-
-	if err := h.bulkEmailService.SendBulk(c.Request.Context(), &req); err != nil {
-		h.log.Error("Failed to queue bulk emails", "error", err, "tenant_id", tenantID)
-		c.JSON(http.StatusInternalServerError, errors.NewInternalError("Failed to queue bulk emails", err))
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"message":    "Bulk emails queued successfully",
-		"count":      len(req.Recipients),
-		"queue_size": h.bulkEmailService.QueueSize(),
-	})
-}
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/middleware"
+	"github.com/vhvplatform/go-notification-service/internal/quota"
+	"github.com/vhvplatform/go-notification-service/internal/sendtime"
+	"github.com/vhvplatform/go-notification-service/internal/service"
+	"github.com/vhvplatform/go-notification-service/internal/shared/errors"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// BulkHandler handles bulk notification operations
+type BulkHandler struct {
+	bulkEmailService *service.BulkEmailService
+	sendTimes        *sendtime.Planner
+	quotas           *quota.Enforcer // Optional; nil disables quota checks
+	defaultPriority  domain.NotificationPriority
+	log              *logger.Logger
+}
+
+// NewBulkHandler creates a new bulk handler
+// defaultPriority applies to requests that don't set a priority.
+func NewBulkHandler(bulkEmailService *service.BulkEmailService, sendTimes *sendtime.Planner, quotas *quota.Enforcer, defaultPriority domain.NotificationPriority, log *logger.Logger) *BulkHandler {
+	return &BulkHandler{
+		bulkEmailService: bulkEmailService,
+		sendTimes:        sendTimes,
+		quotas:           quotas,
+		defaultPriority:  defaultPriority,
+		log:              log,
+	}
+}
+
+// SendBulkEmail handles bulk email requests
+func (h *BulkHandler) SendBulkEmail(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+
+	var req domain.BulkEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBindingError(err))
+		return
+	}
+	if !checkTenantID(c, req.TenantID) {
+		return
+	}
+
+	if req.SendAtLocal != nil {
+		err := sendtime.CheckOptions(req.SendAtLocal)
+		if err == nil && req.ScheduledFor != nil {
+			err = fmt.Errorf("send_at_local cannot be combined with scheduled_for")
+		}
+		if err != nil {
+			c.Error(errors.NewBindingError(err))
+			return
+		}
+	}
+
+	// Set tenant_id from authenticated context
+	req.TenantID = tenantID
+	if req.Priority == "" {
+		req.Priority = h.defaultPriority
+	}
+
+	// Every recipient counts against the email quota
+	var reservation *quota.Reservation
+	if h.quotas != nil {
+		var err error
+		reservation, err = h.quotas.Reserve(c.Request.Context(), tenantID, domain.NotificationTypeEmail, int64(len(req.Recipients)))
+		if exceeded, ok := quota.AsExceeded(err); ok {
+			middleware.AbortQuotaExceeded(c, exceeded)
+			return
+		}
+		if err != nil {
+			h.log.Error("Failed to check quota", "error", err, "tenant_id", tenantID)
+			c.Error(appError(err, "Failed to check quota"))
+			return
+		}
+		if reservation.Warning != nil {
+			c.Header(middleware.HeaderQuotaWarning, reservation.Warning.Error())
+		}
+	}
+
+	if err := h.send(c.Request.Context(), &req); err != nil {
+		if h.quotas != nil {
+			h.quotas.Release(c.Request.Context(), reservation)
+		}
+		h.log.Error("Failed to queue bulk emails", "error", err, "tenant_id", tenantID)
+		c.Error(appError(err, "Failed to queue bulk emails"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Bulk emails queued successfully",
+		"count":      len(req.Recipients),
+		"queue_size": h.bulkEmailService.QueueSize(),
+	})
+}
+
+// send queues the bulk emails, split into one scheduled batch per local send time if the
+// request asks for one
+func (h *BulkHandler) send(ctx context.Context, req *domain.BulkEmailRequest) error {
+	if req.SendAtLocal == nil {
+		return h.bulkEmailService.SendBulk(ctx, req)
+	}
+
+	slots, err := h.sendTimes.Plan(ctx, req.TenantID, req.Recipients, req.SendAtLocal)
+	if err != nil {
+		return err
+	}
+	for _, slot := range slots {
+		scheduled := *req
+		scheduled.Recipients = slot.Recipients
+		scheduled.ScheduledFor = &slot.At
+		scheduled.SendAtLocal = nil
+		if req.IdempotencyKey != "" && len(slots) > 1 {
+			scheduled.IdempotencyKey = fmt.Sprintf("%s:%d", req.IdempotencyKey, slot.At.Unix())
+		}
+		if err := h.bulkEmailService.SendBulk(ctx, &scheduled); err != nil {
+			return err
+		}
+	}
+	h.log.Info("Scheduled bulk emails for local send time", "tenant_id", req.TenantID, "time", req.SendAtLocal.Time, "send_times", len(slots))
+	return nil
+}
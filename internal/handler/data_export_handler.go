@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/middleware"
+	"github.com/vhvplatform/go-notification-service/internal/shared/errors"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// maxExportNotifications caps the notifications in one recipient export
+const maxExportNotifications = 10000
+
+// RecipientNotificationFinder interface for reading the notifications sent to a recipient
+type RecipientNotificationFinder interface {
+	FindByRecipient(ctx context.Context, tenantID, recipient string, limit int) ([]*domain.Notification, error)
+}
+
+// NotificationEventsFinder interface for reading the status events of several notifications
+type NotificationEventsFinder interface {
+	FindByNotificationIDs(ctx context.Context, tenantID string, notificationIDs []string) ([]*domain.NotificationEvent, error)
+}
+
+// RecipientFailureFinder interface for reading a recipient's dead letter queue entries
+type RecipientFailureFinder interface {
+	FindByRecipient(ctx context.Context, tenantID, recipient string) ([]*domain.FailedNotification, error)
+}
+
+// BounceFinder interface for reading an address's bounces
+type BounceFinder interface {
+	FindByEmail(ctx context.Context, email string) ([]*domain.EmailBounce, error)
+}
+
+// PreferencesFinder interface for reading users' notification preferences
+type PreferencesFinder interface {
+	FindByUserIDs(ctx context.Context, tenantID string, userIDs []string) ([]*domain.NotificationPreferences, error)
+}
+
+// MembershipFinder interface for reading the recipient lists that contain an address
+type MembershipFinder interface {
+	FindMemberships(ctx context.Context, tenantID, address string) ([]*domain.RecipientList, error)
+}
+
+// DataExportHandler handles data subject access exports for a recipient
+type DataExportHandler struct {
+	notifications RecipientNotificationFinder
+	events        NotificationEventsFinder
+	failed        RecipientFailureFinder
+	bounces       BounceFinder
+	preferences   PreferencesFinder
+	lists         MembershipFinder
+	log           *logger.Logger
+}
+
+// NewDataExportHandler creates a new data export handler
+func NewDataExportHandler(notifications RecipientNotificationFinder, events NotificationEventsFinder, failed RecipientFailureFinder, bounces BounceFinder, preferences PreferencesFinder, lists MembershipFinder, log *logger.Logger) *DataExportHandler {
+	return &DataExportHandler{
+		notifications: notifications,
+		events:        events,
+		failed:        failed,
+		bounces:       bounces,
+		preferences:   preferences,
+		lists:         lists,
+		log:           log,
+	}
+}
+
+// ExportRecipient returns everything the tenant's notification data holds about a recipient:
+// notifications with their stored content, status events, dead letter queue entries, bounces,
+// preferences and recipient list memberships. With ?download=true the export is sent as a
+// JSON file attachment. The recipient is never logged.
+func (h *DataExportHandler) ExportRecipient(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+
+	var req domain.RecipientExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBindingError(err))
+		return
+	}
+
+	export, err := h.export(c.Request.Context(), tenantID, &req)
+	if err != nil {
+		h.log.Error("Failed to export recipient data", "error", err, "tenant_id", tenantID)
+		c.Error(appError(err, "Failed to export recipient data"))
+		return
+	}
+
+	h.log.Info("Exported recipient data", "tenant_id", tenantID, "notifications", len(export.Notifications), "truncated", export.Truncated)
+	if c.Query("download") == "true" {
+		filename := fmt.Sprintf("recipient-export-%s.json", export.GeneratedAt.Format("20060102T150405Z"))
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		c.JSON(http.StatusOK, export)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": export})
+}
+
+// export gathers the recipient's records
+func (h *DataExportHandler) export(ctx context.Context, tenantID string, req *domain.RecipientExportRequest) (*domain.RecipientExport, error) {
+	export := &domain.RecipientExport{
+		TenantID:    tenantID,
+		Recipient:   req.Recipient,
+		GeneratedAt: time.Now().UTC(),
+	}
+
+	notifications, err := h.notifications.FindByRecipient(ctx, tenantID, req.Recipient, maxExportNotifications+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load notifications: %w", err)
+	}
+	if len(notifications) > maxExportNotifications {
+		notifications = notifications[:maxExportNotifications]
+		export.Truncated = true
+	}
+	export.Notifications = notifications
+
+	if len(notifications) > 0 {
+		ids := make([]string, len(notifications))
+		for i, notification := range notifications {
+			ids[i] = notification.ID.Hex()
+		}
+		if export.Events, err = h.events.FindByNotificationIDs(ctx, tenantID, ids); err != nil {
+			return nil, fmt.Errorf("failed to load notification events: %w", err)
+		}
+
+		// Bounces are shared across tenants; only a tenant that has sent to the address sees them
+		if export.Bounces, err = h.bounces.FindByEmail(ctx, req.Recipient); err != nil {
+			return nil, fmt.Errorf("failed to load bounces: %w", err)
+		}
+	}
+
+	if export.FailedNotifications, err = h.failed.FindByRecipient(ctx, tenantID, req.Recipient); err != nil {
+		return nil, fmt.Errorf("failed to load failed notifications: %w", err)
+	}
+	if export.RecipientLists, err = h.lists.FindMemberships(ctx, tenantID, req.Recipient); err != nil {
+		return nil, fmt.Errorf("failed to load recipient lists: %w", err)
+	}
+
+	// Preferences are keyed by user; include the requested user and any linked from list memberships
+	var userIDs []string
+	if req.UserID != "" {
+		userIDs = append(userIDs, req.UserID)
+	}
+	for _, list := range export.RecipientLists {
+		for _, member := range list.Members {
+			if member.UserID != "" {
+				userIDs = append(userIDs, member.UserID)
+			}
+		}
+	}
+	if len(userIDs) > 0 {
+		if export.Preferences, err = h.preferences.FindByUserIDs(ctx, tenantID, userIDs); err != nil {
+			return nil, fmt.Errorf("failed to load preferences: %w", err)
+		}
+	}
+	return export, nil
+}
@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/middleware"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// exportSources holds the records each fake store returns and the arguments it was given
+type exportSources struct {
+	notifications []*domain.Notification
+	events        []*domain.NotificationEvent
+	failed        []*domain.FailedNotification
+	bounces       []*domain.EmailBounce
+	preferences   []*domain.NotificationPreferences
+	lists         []*domain.RecipientList
+	err           error // Returned by the failed notification store
+
+	eventIDs       []string
+	bouncesQueried bool
+	userIDs        []string
+}
+
+type exportNotifications struct{ *exportSources }
+
+func (s exportNotifications) FindByRecipient(ctx context.Context, tenantID, recipient string, limit int) ([]*domain.Notification, error) {
+	return s.notifications[:min(limit, len(s.notifications))], nil
+}
+
+type exportEvents struct{ *exportSources }
+
+func (s exportEvents) FindByNotificationIDs(ctx context.Context, tenantID string, notificationIDs []string) ([]*domain.NotificationEvent, error) {
+	s.eventIDs = notificationIDs
+	return s.events, nil
+}
+
+type exportFailures struct{ *exportSources }
+
+func (s exportFailures) FindByRecipient(ctx context.Context, tenantID, recipient string) ([]*domain.FailedNotification, error) {
+	return s.failed, s.err
+}
+
+type exportBounces struct{ *exportSources }
+
+func (s exportBounces) FindByEmail(ctx context.Context, email string) ([]*domain.EmailBounce, error) {
+	s.bouncesQueried = true
+	return s.bounces, nil
+}
+
+type exportPreferences struct{ *exportSources }
+
+func (s exportPreferences) FindByUserIDs(ctx context.Context, tenantID string, userIDs []string) ([]*domain.NotificationPreferences, error) {
+	s.userIDs = userIDs
+	return s.preferences, nil
+}
+
+type exportMemberships struct{ *exportSources }
+
+func (s exportMemberships) FindMemberships(ctx context.Context, tenantID, address string) ([]*domain.RecipientList, error) {
+	return s.lists, nil
+}
+
+// exportRecipient posts an export request for jane@example.com and returns the recorded response
+func exportRecipient(t *testing.T, sources *exportSources, query string, req domain.RecipientExportRequest) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	h := NewDataExportHandler(exportNotifications{sources}, exportEvents{sources}, exportFailures{sources},
+		exportBounces{sources}, exportPreferences{sources}, exportMemberships{sources}, logger.NewLogger())
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandlerMiddleware(), middleware.TenancyMiddleware())
+	router.POST("/export", h.ExportRecipient)
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/export"+query, bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set(middleware.TenantIDHeader, "tenant-1")
+	router.ServeHTTP(w, r)
+	return w
+}
+
+// decodeExport decodes an export wrapped in a data envelope
+func decodeExport(t *testing.T, w *httptest.ResponseRecorder) domain.RecipientExport {
+	t.Helper()
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var resp struct {
+		Data domain.RecipientExport `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	return resp.Data
+}
+
+func TestExportRecipientGathersRecords(t *testing.T) {
+	first, second := primitive.NewObjectID(), primitive.NewObjectID()
+	sources := &exportSources{
+		notifications: []*domain.Notification{{ID: first, Recipient: "jane@example.com"}, {ID: second, Recipient: "Jane@example.com"}},
+		events:        []*domain.NotificationEvent{{NotificationID: first.Hex()}},
+		failed:        []*domain.FailedNotification{{Recipient: "jane@example.com"}},
+		bounces:       []*domain.EmailBounce{{Email: "jane@example.com"}},
+		preferences:   []*domain.NotificationPreferences{{UserID: "user-1"}},
+		lists: []*domain.RecipientList{{
+			Name:    "Customers",
+			Members: []domain.RecipientListMember{{Address: "jane@example.com", UserID: "user-2"}},
+		}},
+	}
+
+	export := decodeExport(t, exportRecipient(t, sources, "", domain.RecipientExportRequest{Recipient: "jane@example.com", UserID: "user-1"}))
+
+	if export.TenantID != "tenant-1" || export.Recipient != "jane@example.com" || export.Truncated {
+		t.Errorf("export = %+v, want tenant-1's untruncated export for jane@example.com", export)
+	}
+	if len(export.Notifications) != 2 || len(export.Events) != 1 || len(export.FailedNotifications) != 1 ||
+		len(export.Bounces) != 1 || len(export.Preferences) != 1 || len(export.RecipientLists) != 1 {
+		t.Errorf("export = %+v, want every record", export)
+	}
+	if got := strings.Join(sources.eventIDs, ","); got != first.Hex()+","+second.Hex() {
+		t.Errorf("events read for %s, want both notifications", got)
+	}
+	// Preferences cover the requested user and users linked from list memberships
+	if got := strings.Join(sources.userIDs, ","); got != "user-1,user-2" {
+		t.Errorf("preferences read for %q, want user-1,user-2", got)
+	}
+}
+
+func TestExportRecipientTruncatesNotifications(t *testing.T) {
+	sources := &exportSources{}
+	for i := 0; i <= maxExportNotifications; i++ {
+		sources.notifications = append(sources.notifications, &domain.Notification{ID: primitive.NewObjectID()})
+	}
+
+	export := decodeExport(t, exportRecipient(t, sources, "", domain.RecipientExportRequest{Recipient: "jane@example.com"}))
+
+	if !export.Truncated || len(export.Notifications) != maxExportNotifications {
+		t.Errorf("exported %d notifications, truncated %v, want %d truncated", len(export.Notifications), export.Truncated, maxExportNotifications)
+	}
+	if len(sources.eventIDs) != maxExportNotifications {
+		t.Errorf("events read for %d notifications, want %d", len(sources.eventIDs), maxExportNotifications)
+	}
+}
+
+func TestExportRecipientWithoutNotificationsSkipsBounces(t *testing.T) {
+	sources := &exportSources{bounces: []*domain.EmailBounce{{Email: "jane@example.com"}}}
+
+	export := decodeExport(t, exportRecipient(t, sources, "", domain.RecipientExportRequest{Recipient: "jane@example.com"}))
+
+	// Bounces are shared across tenants, so a tenant that never sent to the address doesn't see them
+	if sources.bouncesQueried || len(export.Bounces) != 0 {
+		t.Errorf("bounces = %v, want none read for a recipient the tenant never sent to", export.Bounces)
+	}
+	if sources.userIDs != nil {
+		t.Errorf("preferences read for %v, want none without a user", sources.userIDs)
+	}
+}
+
+func TestExportRecipientDownload(t *testing.T) {
+	sources := &exportSources{notifications: []*domain.Notification{{ID: primitive.NewObjectID()}}}
+
+	w := exportRecipient(t, sources, "?download=true", domain.RecipientExportRequest{Recipient: "jane@example.com"})
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	if disposition := w.Header().Get("Content-Disposition"); !strings.HasPrefix(disposition, `attachment; filename="recipient-export-`) {
+		t.Errorf("Content-Disposition = %q, want a recipient export attachment", disposition)
+	}
+	var export domain.RecipientExport
+	if err := json.Unmarshal(w.Body.Bytes(), &export); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if export.Recipient != "jane@example.com" || len(export.Notifications) != 1 {
+		t.Errorf("export = %+v, want the unwrapped export", export)
+	}
+}
+
+func TestExportRecipientFailsWhenAStoreFails(t *testing.T) {
+	sources := &exportSources{err: errors.New("database unavailable")}
+
+	w := exportRecipient(t, sources, "", domain.RecipientExportRequest{Recipient: "jane@example.com"})
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+	if strings.Contains(w.Body.String(), "jane@example.com") {
+		t.Errorf("error response %s exposes the recipient", w.Body.String())
+	}
+}
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/middleware"
+	"github.com/vhvplatform/go-notification-service/internal/repository"
+	"github.com/vhvplatform/go-notification-service/internal/scheduler"
+	"github.com/vhvplatform/go-notification-service/internal/shared/errors"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// DeletionHandler handles deleting notifications, singly or for a whole recipient
+type DeletionHandler struct {
+	notifications  *repository.NotificationRepository
+	failed         *repository.FailedNotificationRepository
+	recipientLists *repository.RecipientListRepository
+	scheduler      *scheduler.NotificationScheduler
+	held           *repository.SendPauseRepository
+	digests        *repository.DigestRepository
+	log            *logger.Logger
+}
+
+// NewDeletionHandler creates a new deletion handler
+func NewDeletionHandler(notifications *repository.NotificationRepository, failed *repository.FailedNotificationRepository, recipientLists *repository.RecipientListRepository, scheduler *scheduler.NotificationScheduler, held *repository.SendPauseRepository, digests *repository.DigestRepository, log *logger.Logger) *DeletionHandler {
+	return &DeletionHandler{
+		notifications:  notifications,
+		failed:         failed,
+		recipientLists: recipientLists,
+		scheduler:      scheduler,
+		held:           held,
+		digests:        digests,
+		log:            log,
+	}
+}
+
+// DeleteNotification soft deletes a notification
+func (h *DeletionHandler) DeleteNotification(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+	id := c.Param("id")
+
+	if _, err := primitive.ObjectIDFromHex(id); err != nil {
+		c.Error(errors.NewValidationError("Invalid notification ID", err))
+		return
+	}
+
+	if err := h.notifications.SoftDelete(c.Request.Context(), id, tenantID); err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.Error(errors.NewNotFoundError("Notification not found", err))
+			return
+		}
+		h.log.Error("Failed to delete notification", "error", err, "tenant_id", tenantID, "id", id)
+		c.Error(appError(err, "Failed to delete notification"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Notification deleted successfully",
+	})
+}
+
+// EraseRecipient deletes every notification the tenant sent to a recipient.
+// Soft mode soft deletes them. Hard mode, for right-to-be-forgotten requests, permanently
+// deletes them with their status history and dead letter queue entries and scrubs the
+// recipient from their outbox events, recipient lists, schedules, held sends and pending
+// digests. The recipient is never logged.
+func (h *DeletionHandler) EraseRecipient(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+
+	var req domain.EraseRecipientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBindingError(err))
+		return
+	}
+	if req.Mode == "" {
+		req.Mode = domain.ErasureModeSoft
+	}
+
+	ctx := c.Request.Context()
+	resp := domain.EraseRecipientResponse{Mode: req.Mode}
+	var err error
+	if req.Mode == domain.ErasureModeHard {
+		resp.Notifications, err = h.notifications.EraseByRecipient(ctx, tenantID, req.Recipient)
+		if err == nil {
+			err = h.eraseRecipientData(ctx, tenantID, req.Recipient, &resp)
+		}
+	} else {
+		resp.Notifications, err = h.notifications.SoftDeleteByRecipient(ctx, tenantID, req.Recipient)
+	}
+	if err != nil {
+		// Batches already processed stay deleted; repeating the request finishes the rest
+		h.log.Error("Failed to erase recipient", "error", err, "tenant_id", tenantID, "mode", req.Mode, "deleted", resp.Notifications)
+		c.Error(appError(err, "Failed to erase recipient"))
+		return
+	}
+
+	h.log.Info("Erased recipient", "tenant_id", tenantID, "mode", req.Mode, "notifications", resp.Notifications, "failed_notifications", resp.FailedNotifications,
+		"recipient_lists", resp.RecipientLists, "schedules", resp.Schedules, "held_notifications", resp.HeldNotifications, "digests", resp.Digests)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Recipient erased successfully",
+		"data":    resp,
+	})
+}
+
+// eraseRecipientData removes the recipient from every store besides notifications that can
+// hold their address, recording the counts in resp
+func (h *DeletionHandler) eraseRecipientData(ctx context.Context, tenantID, recipient string, resp *domain.EraseRecipientResponse) error {
+	var err error
+	if resp.FailedNotifications, err = h.failed.DeleteByRecipient(ctx, tenantID, recipient); err != nil {
+		return err
+	}
+	if resp.RecipientLists, err = h.recipientLists.RemoveMember(ctx, tenantID, recipient); err != nil {
+		return err
+	}
+	if resp.Schedules, err = h.scheduler.EraseRecipient(ctx, tenantID, recipient); err != nil {
+		return err
+	}
+	if resp.HeldNotifications, err = h.held.EraseRecipient(ctx, tenantID, recipient); err != nil {
+		return err
+	}
+	resp.Digests, err = h.digests.DeleteByRecipient(ctx, tenantID, recipient)
+	return err
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/vhvplatform/go-notification-service/internal/attachments"
+	"github.com/vhvplatform/go-notification-service/internal/contentcheck"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/experiment"
+	"github.com/vhvplatform/go-notification-service/internal/mxcheck"
+	"github.com/vhvplatform/go-notification-service/internal/sendtime"
+	"github.com/vhvplatform/go-notification-service/internal/shared/errors"
+	"github.com/vhvplatform/go-notification-service/internal/smtp"
+)
+
+// emailChecks runs the optional checks applied to an email request before it is sent
+type emailChecks struct {
+	attachments *attachments.Validator // Optional; nil skips attachment policy checks
+	domains     *mxcheck.Verifier      // Optional; nil skips recipient domain verification
+	content     *contentcheck.Checker  // Optional; nil skips content spam checks
+}
+
+// check de-duplicates recipients, validates custom headers, attachments, inline images, the AMP part, local send time
+// and A/B test variants, scores the content for spam and, when the request or tenant opts in, verifies recipient domains
+func (e emailChecks) check(ctx context.Context, tenantID string, req *domain.SendEmailRequest) error {
+	// Each recipient becomes a notification, so duplicates would be sent twice
+	req.To, req.CC, req.BCC = smtp.DedupeRecipients(req.To, req.CC, req.BCC)
+
+	if err := smtp.ValidateHeaders(req.Headers); err != nil {
+		return errors.NewValidationError("Invalid email headers", err)
+	}
+	if err := smtp.ValidateInlineImages(req.Body, req.IsHTML, req.Attachments); err != nil {
+		return errors.NewValidationError("Invalid inline images", err)
+	}
+	if err := smtp.ValidateAMP(req.AMPHTML, req.IsHTML); err != nil {
+		return errors.NewValidationError("Invalid AMP part", err)
+	}
+	if err := sendtime.Check(req); err != nil {
+		return err
+	}
+	if err := experiment.Check(req); err != nil {
+		return err
+	}
+
+	if e.attachments != nil {
+		if err := e.attachments.Validate(ctx, tenantID, req.Attachments); err != nil {
+			return err
+		}
+	}
+
+	if e.content != nil {
+		if _, err := e.content.Check(tenantID, req); err != nil {
+			return err
+		}
+	}
+
+	if e.domains != nil && (req.VerifyDomains || e.domains.EnabledFor(tenantID)) {
+		recipients := make([]string, 0, len(req.To)+len(req.CC)+len(req.BCC))
+		recipients = append(append(append(recipients, req.To...), req.CC...), req.BCC...)
+		if err := e.domains.VerifyAddresses(ctx, recipients...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/footer"
+	"github.com/vhvplatform/go-notification-service/internal/middleware"
+	"github.com/vhvplatform/go-notification-service/internal/repository"
+	"github.com/vhvplatform/go-notification-service/internal/shared/errors"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// EmailFooterHandler handles the tenant's footer appended to outgoing emails
+type EmailFooterHandler struct {
+	repo *repository.EmailFooterRepository
+	log  *logger.Logger
+}
+
+// NewEmailFooterHandler creates a new email footer handler
+func NewEmailFooterHandler(repo *repository.EmailFooterRepository, log *logger.Logger) *EmailFooterHandler {
+	return &EmailFooterHandler{
+		repo: repo,
+		log:  log,
+	}
+}
+
+// GetFooter returns the tenant's email footer
+func (h *EmailFooterHandler) GetFooter(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+
+	emailFooter, err := h.repo.FindEmailFooter(c.Request.Context(), tenantID)
+	if err != nil {
+		h.log.Error("Failed to get email footer", "error", err, "tenant_id", tenantID)
+		c.Error(appError(err, "Failed to get email footer"))
+		return
+	}
+	if emailFooter == nil {
+		c.Error(errors.NewNotFoundError("Email footer not set", nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": emailFooter})
+}
+
+// UpdateFooter replaces the tenant's email footer
+func (h *EmailFooterHandler) UpdateFooter(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+
+	var req domain.SetEmailFooterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBindingError(err))
+		return
+	}
+	if err := footer.Validate(&req); err != nil {
+		c.Error(errors.NewValidationError("Invalid email footer", err))
+		return
+	}
+
+	emailFooter, err := h.repo.Upsert(c.Request.Context(), tenantID, req)
+	if err != nil {
+		h.log.Error("Failed to update email footer", "error", err, "tenant_id", tenantID)
+		c.Error(appError(err, "Failed to update email footer"))
+		return
+	}
+
+	h.log.Info("Updated email footer", "tenant_id", tenantID)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Email footer updated successfully",
+		"data":    emailFooter,
+	})
+}
+
+// DeleteFooter removes the tenant's email footer
+func (h *EmailFooterHandler) DeleteFooter(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+
+	deleted, err := h.repo.Delete(c.Request.Context(), tenantID)
+	if err != nil {
+		h.log.Error("Failed to delete email footer", "error", err, "tenant_id", tenantID)
+		c.Error(appError(err, "Failed to delete email footer"))
+		return
+	}
+	if !deleted {
+		c.Error(errors.NewNotFoundError("Email footer not set", nil))
+		return
+	}
+
+	h.log.Info("Deleted email footer", "tenant_id", tenantID)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Email footer deleted successfully",
+	})
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	stderrors "errors"
+	"math"
+
+	"github.com/vhvplatform/go-notification-service/internal/attachments"
+	"github.com/vhvplatform/go-notification-service/internal/contentcheck"
+	"github.com/vhvplatform/go-notification-service/internal/dedup"
+	"github.com/vhvplatform/go-notification-service/internal/digest"
+	"github.com/vhvplatform/go-notification-service/internal/experiment"
+	"github.com/vhvplatform/go-notification-service/internal/importer"
+	"github.com/vhvplatform/go-notification-service/internal/middleware"
+	"github.com/vhvplatform/go-notification-service/internal/mxcheck"
+	"github.com/vhvplatform/go-notification-service/internal/queue"
+	"github.com/vhvplatform/go-notification-service/internal/repository"
+	"github.com/vhvplatform/go-notification-service/internal/reputation"
+	"github.com/vhvplatform/go-notification-service/internal/sendtime"
+	"github.com/vhvplatform/go-notification-service/internal/shared/errors"
+	"github.com/vhvplatform/go-notification-service/internal/sizelimit"
+	"github.com/vhvplatform/go-notification-service/internal/throttle"
+	"github.com/vhvplatform/go-notification-service/internal/webhook"
+)
+
+// appError converts err into an AppError, mapping repository errors to their API codes.
+// Errors without a specific mapping become internal errors with message.
+func appError(err error, message string) *errors.AppError {
+	if stderrors.Is(err, repository.ErrExternalIDDeleted) {
+		return errors.NewConflictError("External ID belongs to a deleted notification", err)
+	}
+	if stderrors.Is(err, middleware.ErrTenantMismatch) {
+		return errors.NewValidationError("tenant_id does not match authenticated tenant", err)
+	}
+	if conflict, ok := repository.AsConflict(err); ok {
+		return errors.NewConflictError("Resource was modified concurrently; reload and retry", err).
+			WithField("current_version", conflict.CurrentVersion)
+	}
+	if _, ok := attachments.AsRejected(err); ok {
+		return errors.NewValidationError("Attachment not allowed", err)
+	}
+	if _, ok := sizelimit.AsTooLarge(err); ok {
+		return errors.NewValidationError("Notification content too large", err)
+	}
+	if _, ok := contentcheck.AsSpamError(err); ok {
+		return errors.NewValidationError("Email content looks like spam", err)
+	}
+	if _, ok := mxcheck.AsUndeliverable(err); ok {
+		return errors.NewValidationError("Recipient domain cannot receive email", err)
+	}
+	if _, ok := webhook.AsDestinationError(err); ok {
+		return errors.NewValidationError("Webhook destination not allowed", err)
+	}
+	if _, ok := digest.AsRequestError(err); ok {
+		return errors.NewValidationError("Email cannot be sent as a digest", err)
+	}
+	if _, ok := sendtime.AsOptionsError(err); ok {
+		return errors.NewValidationError("Email cannot be sent at local time", err)
+	}
+	if _, ok := experiment.AsTestError(err); ok {
+		return errors.NewValidationError("Invalid A/B test", err)
+	}
+	if _, ok := dedup.AsDuplicate(err); ok {
+		return errors.NewConflictError("Identical notification was sent to the same recipients recently", err)
+	}
+	if throttled, ok := throttle.AsThrottled(err); ok {
+		return errors.NewRateLimitedError("Recipient received too many notifications; retry later", err).
+			WithField("retry_after_seconds", int(math.Ceil(throttled.RetryAfter.Seconds())))
+	}
+	if paused, ok := reputation.AsPaused(err); ok {
+		return errors.NewRateLimitedError("Tenant email is paused for a high bounce or complaint rate", err).
+			WithField("reason", paused.Reputation.PausedReason)
+	}
+	if stderrors.Is(err, importer.ErrInvalidFile) {
+		return errors.NewValidationError("Invalid import file", err)
+	}
+	if stderrors.Is(err, queue.ErrQueueFull) {
+		return errors.NewRateLimitedError("Email queue is full; retry later", err)
+	}
+	return errors.FromError(err, message)
+}
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/escalation"
+	"github.com/vhvplatform/go-notification-service/internal/middleware"
+	"github.com/vhvplatform/go-notification-service/internal/repository"
+	"github.com/vhvplatform/go-notification-service/internal/shared/errors"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// EscalationPolicyHandler handles the tenant's escalation policy for unacknowledged critical notifications
+type EscalationPolicyHandler struct {
+	repo    *repository.EscalationPolicyRepository
+	records *repository.EscalationRecordRepository
+	log     *logger.Logger
+}
+
+// NewEscalationPolicyHandler creates a new escalation policy handler
+func NewEscalationPolicyHandler(repo *repository.EscalationPolicyRepository, records *repository.EscalationRecordRepository, log *logger.Logger) *EscalationPolicyHandler {
+	return &EscalationPolicyHandler{
+		repo:    repo,
+		records: records,
+		log:     log,
+	}
+}
+
+// GetPolicy returns the tenant's escalation policy
+func (h *EscalationPolicyHandler) GetPolicy(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+
+	policy, err := h.repo.FindEscalationPolicy(c.Request.Context(), tenantID)
+	if err != nil {
+		h.log.Error("Failed to get escalation policy", "error", err, "tenant_id", tenantID)
+		c.Error(appError(err, "Failed to get escalation policy"))
+		return
+	}
+	if policy == nil {
+		c.Error(errors.NewNotFoundError("Escalation policy not set", nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": policy})
+}
+
+// UpdatePolicy replaces the tenant's escalation policy
+func (h *EscalationPolicyHandler) UpdatePolicy(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+
+	var req domain.UpdateEscalationPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBindingError(err))
+		return
+	}
+	if err := escalation.NormalizePolicy(&req); err != nil {
+		c.Error(errors.NewValidationError("Invalid escalation policy", err))
+		return
+	}
+
+	policy, err := h.repo.Upsert(c.Request.Context(), tenantID, &req)
+	if err != nil {
+		h.log.Error("Failed to update escalation policy", "error", err, "tenant_id", tenantID)
+		c.Error(appError(err, "Failed to update escalation policy"))
+		return
+	}
+
+	h.log.Info("Updated escalation policy", "tenant_id", tenantID, "steps", len(policy.Steps))
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Escalation policy updated successfully",
+		"data":    policy,
+	})
+}
+
+// DeletePolicy removes the tenant's escalation policy
+func (h *EscalationPolicyHandler) DeletePolicy(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+
+	deleted, err := h.repo.Delete(c.Request.Context(), tenantID)
+	if err != nil {
+		h.log.Error("Failed to delete escalation policy", "error", err, "tenant_id", tenantID)
+		c.Error(appError(err, "Failed to delete escalation policy"))
+		return
+	}
+	if !deleted {
+		c.Error(errors.NewNotFoundError("Escalation policy not set", nil))
+		return
+	}
+
+	h.log.Info("Deleted escalation policy", "tenant_id", tenantID)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Escalation policy deleted successfully",
+	})
+}
+
+// GetEscalations returns the escalation steps taken for a notification, in order
+func (h *EscalationPolicyHandler) GetEscalations(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+	id := c.Param("id")
+
+	records, err := h.records.FindByNotificationID(c.Request.Context(), tenantID, id)
+	if err != nil {
+		h.log.Error("Failed to get notification escalations", "error", err, "tenant_id", tenantID, "id", id)
+		c.Error(appError(err, "Failed to get notification escalations"))
+		return
+	}
+	if records == nil {
+		records = []*domain.EscalationRecord{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": records})
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/middleware"
+	"github.com/vhvplatform/go-notification-service/internal/repository"
+	"github.com/vhvplatform/go-notification-service/internal/shared/errors"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// ExperimentHandler handles reports on A/B tested emails
+type ExperimentHandler struct {
+	notifications *repository.NotificationRepository
+	log           *logger.Logger
+}
+
+// NewExperimentHandler creates a new experiment handler
+func NewExperimentHandler(notifications *repository.NotificationRepository, log *logger.Logger) *ExperimentHandler {
+	return &ExperimentHandler{
+		notifications: notifications,
+		log:           log,
+	}
+}
+
+// GetReport compares the variants of an A/B test by how many of their notifications were
+// sent, delivered, read and clicked
+func (h *ExperimentHandler) GetReport(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+	experiment := c.Param("experiment")
+
+	stats, err := h.notifications.VariantStats(c.Request.Context(), tenantID, experiment)
+	if err != nil {
+		h.log.Error("Failed to get experiment report", "error", err, "tenant_id", tenantID, "experiment", experiment)
+		c.Error(appError(err, "Failed to get experiment report"))
+		return
+	}
+	if len(stats) == 0 {
+		c.Error(errors.NewNotFoundError("Experiment not found", nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": domain.ExperimentReport{
+		Experiment: experiment,
+		Variants:   stats,
+	}})
+}
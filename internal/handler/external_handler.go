@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/middleware"
+	"github.com/vhvplatform/go-notification-service/internal/repository"
+	"github.com/vhvplatform/go-notification-service/internal/shared/errors"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// ExternalHandler lets external systems manage notifications keyed by their own IDs
+type ExternalHandler struct {
+	notifications *repository.NotificationRepository
+	log           *logger.Logger
+}
+
+// NewExternalHandler creates a new external ID handler
+func NewExternalHandler(notifications *repository.NotificationRepository, log *logger.Logger) *ExternalHandler {
+	return &ExternalHandler{
+		notifications: notifications,
+		log:           log,
+	}
+}
+
+// UpsertNotification creates the notification with the external ID in the path, or replaces
+// the existing one's content. Repeating a request is safe, so external systems can sync their
+// state without tracking our IDs. Responds 201 Created when the notification is new.
+func (h *ExternalHandler) UpsertNotification(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+	externalID := c.Param("external_id")
+	if strings.TrimSpace(externalID) == "" || utf8.RuneCountInString(externalID) > domain.MaxExternalIDLength {
+		c.Error(errors.NewValidationError("Invalid external ID", nil))
+		return
+	}
+
+	var req domain.UpsertNotificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBindingError(err))
+		return
+	}
+
+	notification := &domain.Notification{
+		TenantID:   tenantID,
+		ExternalID: externalID,
+		Type:       req.Type,
+		Status:     req.Status,
+		Priority:   req.Priority,
+		Recipient:  req.Recipient,
+		Subject:    req.Subject,
+		Body:       req.Body,
+		IsHTML:     req.IsHTML,
+		Payload:    req.Payload,
+		Tags:       req.Tags,
+		Category:   req.Category,
+		GroupID:    req.GroupID,
+		Metadata:   req.Metadata,
+	}
+	created, err := h.notifications.UpsertByExternalID(c.Request.Context(), notification)
+	if err != nil {
+		h.log.Error("Failed to upsert notification", "error", err, "tenant_id", tenantID, "external_id", externalID)
+		c.Error(appError(err, "Failed to upsert notification"))
+		return
+	}
+	c.Set(middleware.AuditResourceIDKey, notification.ID.Hex())
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, gin.H{
+		"data": domain.UpsertNotificationResponse{Notification: notification, Created: created},
+	})
+}
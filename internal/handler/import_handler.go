@@ -0,0 +1,121 @@
+package handler
+
+import (
+	stderrors "errors"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/importer"
+	"github.com/vhvplatform/go-notification-service/internal/middleware"
+	"github.com/vhvplatform/go-notification-service/internal/shared/errors"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// importFileField is the multipart form field holding an uploaded import file
+const importFileField = "file"
+
+// ImportHandler bulk imports a tenant's templates and preferences from uploaded files
+type ImportHandler struct {
+	importer *importer.Importer
+	log      *logger.Logger
+}
+
+// NewImportHandler creates a new import handler
+func NewImportHandler(importer *importer.Importer, log *logger.Logger) *ImportHandler {
+	return &ImportHandler{
+		importer: importer,
+		log:      log,
+	}
+}
+
+// ImportTemplates creates or updates the tenant's templates, by name, from a JSON or CSV file
+// and reports each row's outcome
+func (h *ImportHandler) ImportTemplates(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+
+	rows, ok := importRows(c)
+	if !ok {
+		return
+	}
+	report, err := h.importer.ImportTemplates(c.Request.Context(), rows, importer.Options{TenantID: tenantID, MaxRows: domain.MaxImportRows})
+	h.respond(c, "templates", tenantID, report, err)
+}
+
+// ImportPreferences creates or updates users' preferences, by user ID, from a JSON or CSV
+// file and reports each row's outcome
+func (h *ImportHandler) ImportPreferences(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+
+	rows, ok := importRows(c)
+	if !ok {
+		return
+	}
+	validate := func(prefs *domain.NotificationPreferences) error {
+		return validateQuietHours(prefs.QuietHoursStart, prefs.QuietHoursEnd, prefs.Timezone)
+	}
+	report, err := h.importer.ImportPreferences(c.Request.Context(), rows, importer.Options{TenantID: tenantID, MaxRows: domain.MaxImportRows}, validate)
+	h.respond(c, "preferences", tenantID, report, err)
+}
+
+// respond returns an import's report, with the error that stopped it early if any
+func (h *ImportHandler) respond(c *gin.Context, kind, tenantID string, report *domain.ImportReport, err error) {
+	if err != nil {
+		// Rows before the failure were already imported, so their outcomes are still reported
+		h.log.Warn("Import stopped early", "error", err, "kind", kind, "tenant_id", tenantID, "imported", report.Total)
+		c.Error(appError(err, "Import stopped early").WithField("data", report))
+		return
+	}
+
+	h.log.Info("Imported "+kind, "tenant_id", tenantID, "created", report.Created, "updated", report.Updated, "failed", report.Failed)
+	c.JSON(http.StatusOK, gin.H{
+		"data": report,
+	})
+}
+
+// importRows returns a reader of the uploaded file: the multipart form's file field, or the
+// raw request body. The format query parameter overrides the format detected from the
+// file's name or content type, and the charset query parameter the charset of its content
+// type. It reports false after recording the error on c.
+func importRows(c *gin.Context) (importer.RowReader, bool) {
+	var file io.Reader = c.Request.Body
+	contentType, filename := c.GetHeader("Content-Type"), "" // With parameters, for the charset
+	if multipart, err := c.Request.MultipartReader(); err == nil {
+		for {
+			part, err := multipart.NextPart()
+			if err != nil {
+				c.Error(errors.NewValidationError("Invalid import file", stderrors.New("multipart form has no file field")))
+				return nil, false
+			}
+			if part.FormName() == importFileField {
+				file, contentType, filename = part, part.Header.Get("Content-Type"), part.FileName()
+				break
+			}
+		}
+	}
+
+	format := c.Query("format")
+	if format == "" {
+		detected, err := importer.DetectFormat(contentType, filename)
+		if err != nil {
+			c.Error(appError(err, "Invalid import file"))
+			return nil, false
+		}
+		format = detected
+	}
+	fileCharset := c.Query("charset")
+	if fileCharset == "" {
+		_, params, _ := mime.ParseMediaType(contentType)
+		fileCharset = params["charset"]
+	}
+	rows, err := importer.NewReader(format, fileCharset, file)
+	if err != nil {
+		c.Error(appError(err, "Invalid import file"))
+		return nil, false
+	}
+	return rows, true
+}
@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	stderrors "errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/inbound"
+	"github.com/vhvplatform/go-notification-service/internal/metrics"
+	"github.com/vhvplatform/go-notification-service/internal/middleware"
+	"github.com/vhvplatform/go-notification-service/internal/repository"
+	"github.com/vhvplatform/go-notification-service/internal/shared/errors"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// maxInboundFormMemory is how much of an inbound parse post is held in memory; the rest spills to disk
+const maxInboundFormMemory = 8 << 20
+
+// InboundHandler receives replies to sent emails from providers' inbound email webhooks and
+// stores each as a received notification linked to the original by ParentID
+type InboundHandler struct {
+	notifications *repository.NotificationRepository
+	sendGrid      *inbound.SendGridVerifier // Optional; nil disables the SendGrid endpoint
+	sns           *inbound.SNSVerifier      // Optional; nil disables the SES endpoint
+	log           *logger.Logger
+}
+
+// NewInboundHandler creates a new inbound email handler
+// The SendGrid and SNS verifiers are optional.
+func NewInboundHandler(notifications *repository.NotificationRepository, sendGrid *inbound.SendGridVerifier, sns *inbound.SNSVerifier, log *logger.Logger) *InboundHandler {
+	return &InboundHandler{
+		notifications: notifications,
+		sendGrid:      sendGrid,
+		sns:           sns,
+		log:           log,
+	}
+}
+
+// HandleSendGrid handles SendGrid Inbound Parse posts, which must be signed
+func (h *InboundHandler) HandleSendGrid(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.Error(errors.NewValidationError("Invalid request body", err))
+		return
+	}
+	if err := h.sendGrid.Verify(c.GetHeader(inbound.SendGridTimestampHeader), c.GetHeader(inbound.SendGridSignatureHeader), body); err != nil {
+		metrics.InboundEmails.WithLabelValues("sendgrid", "rejected").Inc()
+		h.log.Warn("Rejected inbound email webhook", "error", err, "provider", "sendgrid")
+		c.Error(errors.NewUnauthorizedError("Invalid webhook signature", err))
+		return
+	}
+
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	if err := c.Request.ParseMultipartForm(maxInboundFormMemory); err != nil && !stderrors.Is(err, http.ErrNotMultipart) {
+		metrics.InboundEmails.WithLabelValues("sendgrid", "invalid").Inc()
+		c.Error(errors.NewValidationError("Invalid inbound parse post", err))
+		return
+	}
+	if c.Request.MultipartForm != nil {
+		defer c.Request.MultipartForm.RemoveAll()
+	}
+
+	msg, err := inbound.ParseSendGrid(c.Request.PostForm)
+	if err != nil {
+		metrics.InboundEmails.WithLabelValues("sendgrid", "invalid").Inc()
+		h.log.Warn("Invalid inbound email", "error", err, "provider", "sendgrid")
+		c.Error(errors.NewValidationError("Invalid inbound email", err))
+		return
+	}
+	h.storeReply(c, "sendgrid", msg)
+}
+
+// HandleSES handles Amazon SNS deliveries of SES receipt notifications, confirming the
+// subscription when SNS first delivers to the endpoint
+func (h *InboundHandler) HandleSES(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.Error(errors.NewValidationError("Invalid request body", err))
+		return
+	}
+	var msg inbound.SNSMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		metrics.InboundEmails.WithLabelValues("ses", "invalid").Inc()
+		c.Error(errors.NewValidationError("Invalid SNS message", err))
+		return
+	}
+	if err := h.sns.Verify(c.Request.Context(), &msg); err != nil {
+		metrics.InboundEmails.WithLabelValues("ses", "rejected").Inc()
+		h.log.Warn("Rejected inbound email webhook", "error", err, "provider", "ses", "topic_arn", msg.TopicArn)
+		c.Error(errors.NewUnauthorizedError("Invalid webhook signature", err))
+		return
+	}
+
+	switch msg.Type {
+	case inbound.SNSTypeSubscriptionConfirmation:
+		if err := h.sns.Confirm(c.Request.Context(), &msg); err != nil {
+			h.log.Error("Failed to confirm SNS subscription", "error", err, "topic_arn", msg.TopicArn)
+			c.Error(errors.NewInternalError("Failed to confirm subscription", err))
+			return
+		}
+		h.log.Info("Confirmed SNS subscription for inbound email", "topic_arn", msg.TopicArn)
+		c.JSON(http.StatusOK, gin.H{"status": "confirmed"})
+		return
+	case inbound.SNSTypeNotification:
+	default:
+		c.JSON(http.StatusOK, gin.H{"status": "ignored"})
+		return
+	}
+
+	email, err := inbound.ParseSES(msg.Message)
+	if err != nil {
+		metrics.InboundEmails.WithLabelValues("ses", "invalid").Inc()
+		h.log.Warn("Invalid inbound email", "error", err, "provider", "ses", "message_id", msg.MessageID)
+		c.Error(errors.NewValidationError("Invalid inbound email", err))
+		return
+	}
+	h.storeReply(c, "ses", email)
+}
+
+// storeReply stores msg as a reply to the notification it answers. Emails that don't answer
+// one of ours are acknowledged and dropped, since the provider would otherwise retry them.
+func (h *InboundHandler) storeReply(c *gin.Context, provider string, msg *inbound.Message) {
+	ctx := c.Request.Context()
+	parent, err := h.notifications.FindReplyParent(ctx, msg.EntityRefID, msg.References)
+	if err != nil {
+		h.log.Error("Failed to match inbound email", "error", err, "provider", provider)
+		c.Error(appError(err, "Failed to process inbound email"))
+		return
+	}
+	if parent == nil {
+		metrics.InboundEmails.WithLabelValues(provider, "unmatched").Inc()
+		h.log.Info("Ignored inbound email that doesn't reply to a notification", "provider", provider, "message_id", msg.MessageID)
+		c.JSON(http.StatusOK, gin.H{"status": "ignored"})
+		return
+	}
+
+	body, isHTML := msg.Body()
+	metadata := map[string]string{domain.InboundProviderMetadataKey: provider}
+	if msg.MessageID != "" {
+		metadata[domain.InboundMessageIDMetadataKey] = msg.MessageID
+	}
+	if isHTML {
+		metadata[domain.InboundHTMLMetadataKey] = "true"
+	}
+	reply := &domain.Notification{
+		TenantID:  parent.TenantID,
+		Type:      domain.NotificationTypeEmail,
+		Status:    domain.NotificationStatusReceived,
+		Priority:  domain.NotificationPriorityNormal,
+		Recipient: msg.From, // The other party of the thread, as on the original
+		Subject:   msg.Subject,
+		Body:      body,
+		Category:  parent.Category,
+		GroupID:   parent.GroupID,
+		ParentID:  parent.ID.Hex(),
+		Tags:      []string{"inbound"},
+		Metadata:  metadata,
+	}
+	if msg.MessageID != "" {
+		// Providers retry deliveries they think failed; the reply's Message-ID makes them idempotent
+		reply.IdempotencyKey = "inbound:" + msg.MessageID
+	}
+
+	if err := h.notifications.Create(ctx, reply); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			metrics.InboundEmails.WithLabelValues(provider, "duplicate").Inc()
+			c.JSON(http.StatusOK, gin.H{"status": "duplicate"})
+			return
+		}
+		h.log.Error("Failed to store inbound email", "error", err, "provider", provider, "tenant_id", parent.TenantID)
+		c.Error(appError(err, "Failed to process inbound email"))
+		return
+	}
+
+	metrics.InboundEmails.WithLabelValues(provider, "stored").Inc()
+	h.log.Info("Stored inbound reply", "provider", provider, "tenant_id", parent.TenantID, "parent_id", reply.ParentID, "id", reply.ID.Hex())
+	c.JSON(http.StatusOK, gin.H{"status": "stored", "id": reply.ID.Hex()})
+}
+
+// GetReplies lists the inbound replies to a notification, oldest first
+func (h *InboundHandler) GetReplies(c *gin.Context) {
+	tenantID := middleware.MustGetTenantID(c)
+	id := c.Param("id")
+	page, pageSize := pageQuery(c)
+
+	replies, total, err := h.notifications.FindReplies(c.Request.Context(), tenantID, id, page, pageSize)
+	if err != nil {
+		h.log.Error("Failed to get replies", "error", err, "tenant_id", tenantID, "id", id)
+		c.Error(appError(err, "Failed to get replies"))
+		return
+	}
+
+	c.JSON(http.StatusOK, NewPaginatedResponse(replies, total, page, pageSize))
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/middleware"
+	"github.com/vhvplatform/go-notification-service/internal/quota"
+	"github.com/vhvplatform/go-notification-service/internal/shared/errors"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// LimitsHandler reports a tenant's rate limit and quota state
+type LimitsHandler struct {
+	limiter  *middleware.TenantRateLimiter
+	enforcer *quota.Enforcer // Optional; nil when quotas are disabled
+	log      *logger.Logger
+}
+
+// NewLimitsHandler creates a new limits handler
+func NewLimitsHandler(limiter *middleware.TenantRateLimiter, enforcer *quota.Enforcer, log *logger.Logger) *LimitsHandler {
+	return &LimitsHandler{
+		limiter:  limiter,
+		enforcer: enforcer,
+		log:      log,
+	}
+}
+
+// GetLimits reports the tenant's configured rate limit, available tokens and quota usage.
+// Tenant routes may only read their own limits; admin routes have no tenant and may read any.
+func (h *LimitsHandler) GetLimits(c *gin.Context) {
+	tenantID := c.Param("id")
+	if authenticated := middleware.GetTenantID(c); authenticated != "" && authenticated != tenantID {
+		c.Error(errors.NewNotFoundError("Tenant not found", nil))
+		return
+	}
+
+	rps, burst := h.limiter.Limit()
+	report := domain.TenantLimitsReport{
+		TenantID: tenantID,
+		RateLimit: domain.RateLimitStatus{
+			RequestsPerSecond: rps,
+			Burst:             burst,
+			TokensAvailable:   h.limiter.Tokens(tenantID),
+		},
+	}
+
+	if h.enforcer != nil {
+		usage, err := h.enforcer.Usage(c.Request.Context(), tenantID)
+		if err != nil {
+			h.log.Error("Failed to get quota usage", "error", err, "tenant_id", tenantID)
+			c.Error(appError(err, "Failed to get quota usage"))
+			return
+		}
+		report.Quotas = usage
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": report,
+	})
+}
@@ -1,126 +1,440 @@
-package handler
-
-import (
-	"net/http"
-
-	"github.com/gin-gonic/gin"
-	"github.com/vhvplatform/go-notification-service/internal/domain"
-	"github.com/vhvplatform/go-notification-service/internal/middleware"
-	"github.com/vhvplatform/go-notification-service/internal/service"
-	"github.com/vhvplatform/go-notification-service/internal/shared/errors"
-	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
-)
-
-// NotificationHandler handles HTTP requests for notifications
-type NotificationHandler struct {
-	service *service.NotificationService
-	log     *logger.Logger
-}
-
-// NewNotificationHandler creates a new notification handler
-func NewNotificationHandler(service *service.NotificationService, log *logger.Logger) *NotificationHandler {
-	return &NotificationHandler{
-		service: service,
-		log:     log,
-	}
-}
-
-// SendEmail handles email notification requests
-func (h *NotificationHandler) SendEmail(c *gin.Context) {
-	// Extract tenant_id from context (injected by TenancyMiddleware)
-	tenantID := middleware.MustGetTenantID(c)
-
-	var req domain.SendEmailRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, errors.NewValidationError("Invalid request", err))
-		return
-	}
-
-	// Set tenant_id from authenticated context
-	req.TenantID = tenantID
-
-	if err := h.service.SendEmail(c.Request.Context(), &req); err != nil {
-		h.log.Error("Failed to send email", "error", err, "tenant_id", tenantID)
-		c.JSON(http.StatusInternalServerError, errors.NewInternalError("Failed to send email", err))
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Email sent successfully",
-	})
-}
-
-// SendWebhook handles webhook notification requests
-func (h *NotificationHandler) SendWebhook(c *gin.Context) {
-	// Extract tenant_id from context
-	tenantID := middleware.MustGetTenantID(c)
-
-	var req domain.SendWebhookRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, errors.NewValidationError("Invalid request", err))
-		return
-	}
-
-	// Set tenant_id from authenticated context
-	req.TenantID = tenantID
-
-	if err := h.service.SendWebhook(c.Request.Context(), &req); err != nil {
-		h.log.Error("Failed to send webhook", "error", err, "tenant_id", tenantID)
-		c.JSON(http.StatusInternalServerError, errors.NewInternalError("Failed to send webhook", err))
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Webhook sent successfully",
-	})
-}
-
-// GetNotifications retrieves notification history
-func (h *NotificationHandler) GetNotifications(c *gin.Context) {
-	// Extract tenant_id from context
-	tenantID := middleware.MustGetTenantID(c)
-
-	var req domain.GetNotificationsRequest
-	if err := c.ShouldBindQuery(&req); err != nil {
-		c.JSON(http.StatusBadRequest, errors.NewValidationError("Invalid request", err))
-		return
-	}
-
-	// Set tenant_id from authenticated context
-	req.TenantID = tenantID
-
-	notifications, total, err := h.service.GetNotifications(c.Request.Context(), &req)
-	if err != nil {
-		h.log.Error("Failed to get notifications", "error", err, "tenant_id", tenantID)
-		c.JSON(http.StatusInternalServerError, errors.NewInternalError("Failed to get notifications", err))
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"data":      notifications,
-		"total":     total,
-		"page":      req.Page,
-		"page_size": req.PageSize,
-	})
-}
-
-// GetNotification retrieves a single notification by ID
-func (h *NotificationHandler) GetNotification(c *gin.Context) {
-	// Extract tenant_id from context
-	tenantID := middleware.MustGetTenantID(c)
-
-	id := c.Param("id")
-	if id == "" {
-		c.JSON(http.StatusBadRequest, errors.NewValidationError("ID is required", nil))
-		return
-	}
-
-	notification, err := h.service.GetNotification(c.Request.Context(), id, tenantID)
-	if err != nil {
-		h.log.Error("Failed to get notification", "error", err, "id", id, "tenant_id", tenantID)
-		c.JSON(http.StatusNotFound, errors.NewNotFoundError("Notification not found", err))
-		return
-	}
-
-	c.JSON(http.StatusOK, notification)
-}
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/vhvplatform/go-notification-service/internal/attachments"
+	"github.com/vhvplatform/go-notification-service/internal/contentcheck"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/email"
+	"github.com/vhvplatform/go-notification-service/internal/metrics"
+	"github.com/vhvplatform/go-notification-service/internal/middleware"
+	"github.com/vhvplatform/go-notification-service/internal/mxcheck"
+	"github.com/vhvplatform/go-notification-service/internal/pause"
+	"github.com/vhvplatform/go-notification-service/internal/quota"
+	"github.com/vhvplatform/go-notification-service/internal/service"
+	"github.com/vhvplatform/go-notification-service/internal/shared/errors"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+	"github.com/vhvplatform/go-notification-service/internal/webhook"
+)
+
+// NotificationEventFinder interface for reading a notification's status history
+type NotificationEventFinder interface {
+	FindByNotificationID(ctx context.Context, tenantID, notificationID string) ([]*domain.NotificationEvent, error)
+}
+
+// NotificationHandler handles HTTP requests for notifications
+type NotificationHandler struct {
+	service  *service.NotificationService
+	sends    *pause.Gate // Holds sends while their channel is paused
+	events   NotificationEventFinder
+	quotas   *quota.Enforcer // Optional; webhooks are counted here, one unit per URL, rather than by QuotaMiddleware
+	checks   emailChecks
+	webhooks *webhook.Allowlist // Optional; nil skips the destination check before a send is held
+	log      *logger.Logger
+}
+
+// NewNotificationHandler creates a new notification handler
+// The quota enforcer, attachment validator, domain verifier, content checker and webhook allowlist are optional.
+func NewNotificationHandler(service *service.NotificationService, sends *pause.Gate, events NotificationEventFinder, quotas *quota.Enforcer, attachments *attachments.Validator, domains *mxcheck.Verifier, content *contentcheck.Checker, webhooks *webhook.Allowlist, log *logger.Logger) *NotificationHandler {
+	return &NotificationHandler{
+		service:  service,
+		sends:    sends,
+		events:   events,
+		quotas:   quotas,
+		checks:   emailChecks{attachments: attachments, domains: domains, content: content},
+		webhooks: webhooks,
+		log:      log,
+	}
+}
+
+// SendEmail handles email notification requests
+func (h *NotificationHandler) SendEmail(c *gin.Context) {
+	// Extract tenant_id from context (injected by TenancyMiddleware)
+	tenantID := middleware.MustGetTenantID(c)
+
+	var req domain.SendEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBindingError(err))
+		return
+	}
+	if !checkTenantID(c, req.TenantID) {
+		return
+	}
+
+	if err := h.checks.check(c.Request.Context(), tenantID, &req); err != nil {
+		h.log.Warn("Rejected email request", "error", err, "tenant_id", tenantID)
+		c.Error(appError(err, "Failed to validate email request"))
+		return
+	}
+
+	// Set tenant_id from authenticated context
+	req.TenantID = tenantID
+
+	held := h.sends.Paused(domain.NotificationTypeEmail, req.Priority)
+	start := time.Now()
+	err := h.sends.SendEmail(c.Request.Context(), &req)
+	partialErr, partial := email.AsPartialDeliveryError(err)
+	if !held {
+		observed := err
+		if partial {
+			observed = nil // The email was sent, to the recipients that were accepted
+		}
+		metrics.ObserveSend(string(domain.NotificationTypeEmail), tenantID, start, observed)
+	}
+	if partial {
+		h.log.Warn("Email rejected for some recipients", "error", err, "tenant_id", tenantID)
+		c.JSON(http.StatusMultiStatus, recipientResults(&req, partialErr))
+		return
+	}
+	if err != nil {
+		h.log.Error("Failed to send email", "error", err, "tenant_id", tenantID)
+		c.Error(appError(err, "Failed to send email"))
+		return
+	}
+	if held {
+		respondHeld(c, domain.NotificationTypeEmail)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Email sent successfully",
+	})
+}
+
+// recipientResults reports every recipient of req as sent except those partialErr rejected
+func recipientResults(req *domain.SendEmailRequest, partialErr *email.PartialDeliveryError) domain.SendEmailResponse {
+	// Recipient list members are added to the email after the request is bound, so rejected
+	// members are reported after the request's own recipients
+	resp := domain.SendEmailResponse{Message: "Email sent to some recipients", Results: partialErr.Results(req.To, req.CC, req.BCC)}
+	for _, result := range resp.Results {
+		if result.Status == domain.NotificationStatusFailed {
+			resp.Failed++
+		} else {
+			resp.Succeeded++
+		}
+	}
+	resp.Total = len(resp.Results)
+	return resp
+}
+
+// CheckEmail scores an email's content for likely spam without sending it, so senders can
+// fix it first. The report is returned whether or not the tenant's sends would be rejected.
+func (h *NotificationHandler) CheckEmail(c *gin.Context) {
+	tenantID := middleware.MustGetTenantID(c)
+	if h.checks.content == nil {
+		c.Error(errors.NewNotFoundError("Content checks are not enabled", nil))
+		return
+	}
+
+	var req domain.SendEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBindingError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": h.checks.content.Analyze(tenantID, &req),
+	})
+}
+
+// SendWebhook handles webhook notification requests
+func (h *NotificationHandler) SendWebhook(c *gin.Context) {
+	// Extract tenant_id from context
+	tenantID := middleware.MustGetTenantID(c)
+
+	var req domain.SendWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBindingError(err))
+		return
+	}
+	if !checkTenantID(c, req.TenantID) {
+		return
+	}
+
+	if err := webhook.CheckPayload(&req); err != nil {
+		c.Error(errors.NewValidationError("Invalid payload", err))
+		return
+	}
+	if req.Fallback != nil {
+		if err := webhook.NormalizeFallback(req.Fallback); err != nil {
+			c.Error(errors.NewValidationError("Invalid fallback", err))
+			return
+		}
+	}
+
+	// Set tenant_id from authenticated context
+	req.TenantID = tenantID
+
+	if len(req.URLs) > 0 {
+		h.sendWebhookFanout(c, &req)
+		return
+	}
+
+	if err := h.checkDestination(c.Request.Context(), &req); err != nil {
+		h.log.Warn("Rejected webhook request", "error", err, "tenant_id", tenantID)
+		c.Error(appError(err, "Failed to validate webhook request"))
+		return
+	}
+
+	var reservation *quota.Reservation
+	if h.quotas != nil {
+		var err error
+		reservation, err = h.quotas.Reserve(c.Request.Context(), tenantID, domain.NotificationTypeWebhook, 1)
+		if exceeded, ok := quota.AsExceeded(err); ok {
+			middleware.AbortQuotaExceeded(c, exceeded)
+			return
+		}
+		if err != nil {
+			h.log.Error("Failed to check quota", "error", err, "tenant_id", tenantID)
+			c.Error(appError(err, "Failed to check quota"))
+			return
+		}
+		if reservation.Warning != nil {
+			c.Header(middleware.HeaderQuotaWarning, reservation.Warning.Error())
+		}
+	}
+
+	held := h.sends.Paused(domain.NotificationTypeWebhook, req.Priority)
+	start := time.Now()
+	err := h.sends.SendWebhook(c.Request.Context(), &req)
+	if !held && !req.RetryQueued {
+		metrics.ObserveSend(string(domain.NotificationTypeWebhook), tenantID, start, err)
+	}
+	if err != nil {
+		h.releaseQuota(c.Request.Context(), reservation)
+		h.log.Error("Failed to send webhook", "error", err, "tenant_id", tenantID)
+		c.Error(appError(err, "Failed to send webhook"))
+		return
+	}
+	if held {
+		respondHeld(c, domain.NotificationTypeWebhook)
+		return
+	}
+	if req.RetryQueued {
+		respondRetryQueued(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Webhook sent successfully",
+	})
+}
+
+// respondRetryQueued reports that a webhook's first attempt failed and a retry was queued
+func respondRetryQueued(c *gin.Context) {
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Webhook delivery failed; a retry is queued",
+		"data": gin.H{
+			"type":   domain.NotificationTypeWebhook,
+			"status": domain.NotificationStatusPending,
+		},
+	})
+}
+
+// sendWebhookFanout delivers the webhook to each of req.URLs concurrently.
+// Every URL gets its own notification, linked by a shared group ID, and a derived
+// idempotency key so a retried request does not resend URLs that already succeeded.
+// A failing URL does not affect the others; outcomes are reported per URL. Each URL counts
+// against the webhook quota, so URLs over a hard quota fail individually.
+func (h *NotificationHandler) sendWebhookFanout(c *gin.Context, req *domain.SendWebhookRequest) {
+	if req.GroupID == "" {
+		req.GroupID = uuid.NewString()
+	}
+	if req.IdempotencyKey == "" {
+		req.IdempotencyKey = uuid.NewString()
+	}
+
+	resp := domain.WebhookFanoutResponse{
+		GroupID:        req.GroupID,
+		IdempotencyKey: req.IdempotencyKey,
+		Total:          len(req.URLs),
+		Results:        make([]domain.WebhookTargetResult, len(req.URLs)),
+	}
+
+	ctx := c.Request.Context()
+	var wg sync.WaitGroup
+	for i, url := range req.URLs {
+		target := *req
+		target.URL = url
+		target.URLs = nil
+		target.IdempotencyKey = fmt.Sprintf("%s:%d", req.IdempotencyKey, i)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp.Results[i] = h.sendWebhookTarget(ctx, &target)
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range resp.Results {
+		if result.Status == domain.NotificationStatusFailed {
+			resp.Failed++
+		} else {
+			resp.Succeeded++
+		}
+	}
+
+	h.log.Info("Fanned out webhook", "tenant_id", req.TenantID, "group_id", req.GroupID, "total", resp.Total, "failed", resp.Failed)
+	c.JSON(http.StatusOK, resp)
+}
+
+// sendWebhookTarget sends a single fan-out target and builds its result
+func (h *NotificationHandler) sendWebhookTarget(ctx context.Context, req *domain.SendWebhookRequest) domain.WebhookTargetResult {
+	result := domain.WebhookTargetResult{
+		URL:            req.URL,
+		IdempotencyKey: req.IdempotencyKey,
+	}
+
+	if err := h.checkDestination(ctx, req); err != nil {
+		h.log.Warn("Rejected webhook target", "error", err, "tenant_id", req.TenantID, "group_id", req.GroupID, "url", req.URL)
+		result.Status = domain.NotificationStatusFailed
+		result.Error = err.Error()
+		return result
+	}
+
+	var reservation *quota.Reservation
+	if h.quotas != nil {
+		var err error
+		reservation, err = h.quotas.Reserve(ctx, req.TenantID, domain.NotificationTypeWebhook, 1)
+		if err != nil {
+			h.log.Warn("Webhook target rejected by quota", "error", err, "tenant_id", req.TenantID, "group_id", req.GroupID, "url", req.URL)
+			result.Status = domain.NotificationStatusFailed
+			result.Error = err.Error()
+			return result
+		}
+	}
+
+	held := h.sends.Paused(domain.NotificationTypeWebhook, req.Priority)
+	start := time.Now()
+	err := h.sends.SendWebhook(ctx, req)
+	if !held && !req.RetryQueued {
+		metrics.ObserveSend(string(domain.NotificationTypeWebhook), req.TenantID, start, err)
+	}
+
+	switch {
+	case err != nil:
+		h.releaseQuota(ctx, reservation)
+		h.log.Error("Failed to send webhook", "error", err, "tenant_id", req.TenantID, "group_id", req.GroupID, "url", req.URL)
+		result.Status = domain.NotificationStatusFailed
+		result.Error = err.Error()
+	case held, req.RetryQueued:
+		result.Status = domain.NotificationStatusPending
+	default:
+		result.Status = domain.NotificationStatusSent
+	}
+	return result
+}
+
+// releaseQuota returns the quota reserved for a webhook that failed to send
+func (h *NotificationHandler) releaseQuota(ctx context.Context, reservation *quota.Reservation) {
+	if h.quotas == nil {
+		return
+	}
+	if err := h.quotas.Release(ctx, reservation); err != nil {
+		h.log.Error("Failed to release webhook quota", "error", err)
+	}
+}
+
+// checkDestination rejects a webhook whose URL is outside the tenant's allowlist.
+// The send chain enforces the allowlist too; checking here also fails sends that
+// would otherwise be held while webhooks are paused.
+func (h *NotificationHandler) checkDestination(ctx context.Context, req *domain.SendWebhookRequest) error {
+	if h.webhooks == nil {
+		return nil
+	}
+	return h.webhooks.Check(ctx, req.TenantID, req.URL)
+}
+
+// GetNotifications retrieves notification history
+func (h *NotificationHandler) GetNotifications(c *gin.Context) {
+	// Extract tenant_id from context
+	tenantID := middleware.MustGetTenantID(c)
+
+	var req domain.GetNotificationsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(errors.NewBindingError(err))
+		return
+	}
+	if !checkTenantID(c, req.TenantID) {
+		return
+	}
+
+	// Set tenant_id from authenticated context
+	req.TenantID = tenantID
+	req.Page, req.PageSize = normalizePage(req.Page, req.PageSize)
+
+	notifications, total, err := h.service.GetNotifications(c.Request.Context(), &req)
+	if err != nil {
+		h.log.Error("Failed to get notifications", "error", err, "tenant_id", tenantID)
+		c.Error(appError(err, "Failed to get notifications"))
+		return
+	}
+
+	c.JSON(http.StatusOK, NewPaginatedResponse(notifications, total, req.Page, req.PageSize))
+}
+
+// GetNotification retrieves a single notification by ID
+func (h *NotificationHandler) GetNotification(c *gin.Context) {
+	// Extract tenant_id from context
+	tenantID := middleware.MustGetTenantID(c)
+
+	id := c.Param("id")
+	if id == "" {
+		c.Error(errors.NewValidationError("ID is required", nil))
+		return
+	}
+
+	notification, err := h.service.GetNotification(c.Request.Context(), id, tenantID)
+	if err != nil {
+		h.log.Error("Failed to get notification", "error", err, "id", id, "tenant_id", tenantID)
+		c.Error(errors.NewNotFoundError("Notification not found", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, notification)
+}
+
+// GetTimeline returns the ordered status history of a notification
+func (h *NotificationHandler) GetTimeline(c *gin.Context) {
+	// Extract tenant_id from context
+	tenantID := middleware.MustGetTenantID(c)
+
+	id := c.Param("id")
+	if id == "" {
+		c.Error(errors.NewValidationError("ID is required", nil))
+		return
+	}
+
+	notification, err := h.service.GetNotification(c.Request.Context(), id, tenantID)
+	if err != nil {
+		h.log.Error("Failed to get notification", "error", err, "id", id, "tenant_id", tenantID)
+		c.Error(errors.NewNotFoundError("Notification not found", err))
+		return
+	}
+
+	events, err := h.events.FindByNotificationID(c.Request.Context(), tenantID, id)
+	if err != nil {
+		h.log.Error("Failed to get notification timeline", "error", err, "id", id, "tenant_id", tenantID)
+		c.Error(appError(err, "Failed to get notification timeline"))
+		return
+	}
+	if events == nil {
+		events = []*domain.NotificationEvent{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": domain.NotificationTimeline{
+			NotificationID: id,
+			Status:         notification.Status,
+			Events:         events,
+		},
+	})
+}
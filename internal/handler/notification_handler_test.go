@@ -0,0 +1,294 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/middleware"
+	"github.com/vhvplatform/go-notification-service/internal/pause"
+	"github.com/vhvplatform/go-notification-service/internal/quota"
+	"github.com/vhvplatform/go-notification-service/internal/sender"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// webhookSender records the webhooks it sends, fails those to the URLs in failURLs and queues
+// retries, as retry.RetryingSender does, for those to the URLs in queueURLs
+type webhookSender struct {
+	sender.Sender
+	failURLs  map[string]bool
+	queueURLs map[string]bool
+	mu        sync.Mutex
+	sent      []*domain.SendWebhookRequest
+}
+
+func (s *webhookSender) SendWebhook(ctx context.Context, req *domain.SendWebhookRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, req)
+	if s.failURLs[req.URL] {
+		return errors.New("connection refused")
+	}
+	req.RetryQueued = s.queueURLs[req.URL]
+	return nil
+}
+
+// heldWebhooks is a HoldStore that only records held notifications
+type heldWebhooks struct {
+	mu   sync.Mutex
+	held []*domain.HeldNotification
+}
+
+func (h *heldWebhooks) Hold(ctx context.Context, held *domain.HeldNotification) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.held = append(h.held, held)
+	return nil
+}
+
+func (h *heldWebhooks) ClaimHeld(ctx context.Context, channels []domain.NotificationType, since time.Time, staleAfter time.Duration) (*domain.HeldNotification, error) {
+	return nil, nil
+}
+
+func (h *heldWebhooks) CompleteHeld(ctx context.Context, id primitive.ObjectID) error {
+	return nil
+}
+
+func (h *heldWebhooks) ReleaseHeld(ctx context.Context, id primitive.ObjectID, failure string) error {
+	return nil
+}
+
+// postWebhook posts body to a notification handler whose sends go to next, holding them when paused
+func postWebhook(t *testing.T, next sender.Sender, paused domain.SendPause, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	return postWebhookWithQuota(t, next, paused, nil, body)
+}
+
+// postWebhookWithQuota posts body to a notification handler that counts webhooks with quotas
+func postWebhookWithQuota(t *testing.T, next sender.Sender, paused domain.SendPause, quotas *quota.Enforcer, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	log := logger.NewLogger()
+	gate := pause.NewGate(pause.NewController(nil, pause.Config{}, paused, log), &heldWebhooks{}, next, log)
+	h := NewNotificationHandler(nil, gate, nil, quotas, nil, nil, nil, nil, log)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandlerMiddleware(), middleware.TenancyMiddleware())
+	router.POST("/webhook", h.SendWebhook)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewBufferString(body))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set(middleware.TenantIDHeader, "tenant-1")
+	router.ServeHTTP(w, r)
+	return w
+}
+
+// decodeFanout decodes a successful fan-out response
+func decodeFanout(t *testing.T, w *httptest.ResponseRecorder) domain.WebhookFanoutResponse {
+	t.Helper()
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var resp domain.WebhookFanoutResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	return resp
+}
+
+func TestSendWebhookFansOutToEachURL(t *testing.T) {
+	next := &webhookSender{failURLs: map[string]bool{"https://b.example.com/hook": true}}
+
+	resp := decodeFanout(t, postWebhook(t, next, domain.SendPause{}, `{
+		"urls": ["https://a.example.com/hook", "https://b.example.com/hook", "https://c.example.com/hook"],
+		"payload": {"event": "order.created"},
+		"idempotency_key": "order-42",
+		"group_id": "orders"
+	}`))
+
+	if resp.GroupID != "orders" || resp.IdempotencyKey != "order-42" || resp.Total != 3 || resp.Succeeded != 2 || resp.Failed != 1 {
+		t.Fatalf("response = %+v, want group orders with 2 of 3 sent", resp)
+	}
+	// Results follow the request's URL order; one URL failing doesn't affect the others
+	wantStatuses := []domain.NotificationStatus{domain.NotificationStatusSent, domain.NotificationStatusFailed, domain.NotificationStatusSent}
+	for i, result := range resp.Results {
+		if result.Status != wantStatuses[i] || result.IdempotencyKey != fmt.Sprintf("order-42:%d", i) {
+			t.Errorf("results[%d] = %+v, want %s with key order-42:%d", i, result, wantStatuses[i], i)
+		}
+	}
+	if resp.Results[1].URL != "https://b.example.com/hook" || resp.Results[1].Error != "connection refused" {
+		t.Errorf("results[1] = %+v, want the failing URL's send error", resp.Results[1])
+	}
+
+	// Each URL is sent as its own webhook in the shared group
+	if len(next.sent) != 3 {
+		t.Fatalf("sent %d webhooks, want 3", len(next.sent))
+	}
+	for _, req := range next.sent {
+		if req.GroupID != "orders" || req.TenantID != "tenant-1" || len(req.URLs) != 0 || req.URL == "" {
+			t.Errorf("sent %+v, want one URL of group orders for tenant-1", req)
+		}
+	}
+}
+
+func TestSendWebhookFanoutGeneratesKeys(t *testing.T) {
+	resp := decodeFanout(t, postWebhook(t, &webhookSender{}, domain.SendPause{}, `{
+		"urls": ["https://a.example.com/hook"],
+		"payload": {"event": "order.created"}
+	}`))
+
+	if resp.GroupID == "" || resp.IdempotencyKey == "" || resp.Results[0].IdempotencyKey != resp.IdempotencyKey+":0" {
+		t.Errorf("response = %+v, want a generated group ID and idempotency key", resp)
+	}
+}
+
+func TestSendWebhookFanoutHoldsWhilePaused(t *testing.T) {
+	next := &webhookSender{}
+	paused := domain.SendPause{Channels: []domain.NotificationType{domain.NotificationTypeWebhook}}
+
+	resp := decodeFanout(t, postWebhook(t, next, paused, `{
+		"urls": ["https://a.example.com/hook", "https://b.example.com/hook"],
+		"payload": {"event": "order.created"}
+	}`))
+
+	if resp.Succeeded != 2 || resp.Results[0].Status != domain.NotificationStatusPending || resp.Results[1].Status != domain.NotificationStatusPending {
+		t.Errorf("response = %+v, want both URLs pending", resp)
+	}
+	if len(next.sent) != 0 {
+		t.Errorf("sent %d webhooks while paused, want 0", len(next.sent))
+	}
+}
+
+func TestSendWebhookReportsQueuedRetryPending(t *testing.T) {
+	next := &webhookSender{queueURLs: map[string]bool{"https://a.example.com/hook": true}}
+
+	w := postWebhook(t, next, domain.SendPause{}, `{"url": "https://a.example.com/hook", "payload": {"event": "order.created"}}`)
+	if w.Code != http.StatusAccepted || !strings.Contains(w.Body.String(), `"status":"pending"`) {
+		t.Errorf("response = %d %s, want 202 pending", w.Code, w.Body.String())
+	}
+
+	resp := decodeFanout(t, postWebhook(t, next, domain.SendPause{}, `{
+		"urls": ["https://a.example.com/hook", "https://b.example.com/hook"],
+		"payload": {"event": "order.created"}
+	}`))
+	for _, result := range resp.Results {
+		want := domain.NotificationStatusSent
+		if result.URL == "https://a.example.com/hook" {
+			want = domain.NotificationStatusPending
+		}
+		if result.Status != want {
+			t.Errorf("result for %s = %s, want %s", result.URL, result.Status, want)
+		}
+	}
+}
+
+func TestSendWebhookRejectsInvalidURLSets(t *testing.T) {
+	tooMany := make([]string, 21)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("https://hook%d.example.com", i)
+	}
+	urls, err := json.Marshal(tooMany)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+
+	for name, body := range map[string]string{
+		"url and urls": `{"url": "https://a.example.com/hook", "urls": ["https://b.example.com/hook"], "payload": {}}`,
+		"invalid url":  `{"urls": ["not a url"], "payload": {}}`,
+		"too many":     `{"urls": ` + string(urls) + `, "payload": {}}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			next := &webhookSender{}
+			if w := postWebhook(t, next, domain.SendPause{}, body); w.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+			}
+			if len(next.sent) != 0 {
+				t.Errorf("sent %d webhooks, want 0", len(next.sent))
+			}
+		})
+	}
+}
+
+func TestSendWebhookRejectsPayloadsThatCannotBeEncoded(t *testing.T) {
+	for name, body := range map[string]string{
+		"invalid xml name":     `{"url": "https://a.example.com/hook", "encoding": "xml", "payload": {"1st": "x"}}`,
+		"invalid content type": `{"url": "https://a.example.com/hook", "content_type": "text/", "payload": {"event": "order.created"}}`,
+		"fan-out":              `{"urls": ["https://a.example.com/hook"], "encoding": "xml", "payload": {"xmlns": "x"}}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			next := &webhookSender{}
+			if w := postWebhook(t, next, domain.SendPause{}, body); w.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d: %s", w.Code, http.StatusBadRequest, w.Body.String())
+			}
+			if len(next.sent) != 0 {
+				t.Errorf("sent %d webhooks, want 0", len(next.sent))
+			}
+		})
+	}
+}
+
+// webhookQuota returns an enforcer allowing tenants limit webhooks a day, and its usage store
+func webhookQuota(limit int64) (*quota.Enforcer, *usageStore) {
+	store := &usageStore{usage: make(map[string]int64)}
+	enforcer := quota.NewEnforcer(store, map[domain.NotificationType]quota.Limits{
+		domain.NotificationTypeWebhook: {Daily: limit},
+	}, domain.QuotaPolicyHard)
+	return enforcer, store
+}
+
+func TestSendWebhookFanoutCountsEachURLAgainstQuota(t *testing.T) {
+	body := `{
+		"urls": ["https://a.example.com/hook", "https://b.example.com/hook", "https://c.example.com/hook"],
+		"payload": {"event": "order.created"}
+	}`
+
+	// Each URL takes one unit, so the URL over the quota fails on its own
+	enforcer, store := webhookQuota(2)
+	resp := decodeFanout(t, postWebhookWithQuota(t, &webhookSender{}, domain.SendPause{}, enforcer, body))
+	if resp.Succeeded != 2 || resp.Failed != 1 {
+		t.Fatalf("response = %+v, want 2 of 3 URLs sent", resp)
+	}
+	for _, result := range resp.Results {
+		if result.Status == domain.NotificationStatusFailed && !strings.Contains(result.Error, "quota") {
+			t.Errorf("result = %+v, want a quota error", result)
+		}
+	}
+	if used := store.usage["webhook/daily"]; used != 2 {
+		t.Errorf("daily webhook usage = %d, want 2", used)
+	}
+
+	// A URL that fails to send gives its unit back
+	enforcer, store = webhookQuota(10)
+	next := &webhookSender{failURLs: map[string]bool{"https://b.example.com/hook": true}}
+	decodeFanout(t, postWebhookWithQuota(t, next, domain.SendPause{}, enforcer, body))
+	if used := store.usage["webhook/daily"]; used != 2 {
+		t.Errorf("daily webhook usage with a failed URL = %d, want 2", used)
+	}
+}
+
+func TestSendWebhookCountsAgainstQuota(t *testing.T) {
+	enforcer, store := webhookQuota(1)
+	body := `{"url": "https://a.example.com/hook", "payload": {"event": "order.created"}}`
+
+	if w := postWebhookWithQuota(t, &webhookSender{}, domain.SendPause{}, enforcer, body); w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	if w := postWebhookWithQuota(t, &webhookSender{}, domain.SendPause{}, enforcer, body); w.Code != http.StatusTooManyRequests {
+		t.Errorf("status over quota = %d, want %d: %s", w.Code, http.StatusTooManyRequests, w.Body.String())
+	}
+	if used := store.usage["webhook/daily"]; used != 1 {
+		t.Errorf("daily webhook usage = %d, want 1", used)
+	}
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/attachments"
+	"github.com/vhvplatform/go-notification-service/internal/contentcheck"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/middleware"
+	"github.com/vhvplatform/go-notification-service/internal/mxcheck"
+	"github.com/vhvplatform/go-notification-service/internal/orchestration"
+	"github.com/vhvplatform/go-notification-service/internal/repository"
+	"github.com/vhvplatform/go-notification-service/internal/shared/errors"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+	"github.com/vhvplatform/go-notification-service/internal/webhook"
+)
+
+// OrchestrationHandler handles notifications sent over several channels with a strategy
+type OrchestrationHandler struct {
+	orchestrator *orchestration.Orchestrator
+	repo         *repository.OrchestrationRepository
+	checks       emailChecks
+	webhooks     *webhook.Allowlist // Optional; nil skips the destination check before channels are held
+	log          *logger.Logger
+}
+
+// NewOrchestrationHandler creates a new orchestration handler
+// The attachment validator, domain verifier, content checker and webhook allowlist are optional.
+func NewOrchestrationHandler(orchestrator *orchestration.Orchestrator, repo *repository.OrchestrationRepository, attachments *attachments.Validator, domains *mxcheck.Verifier, content *contentcheck.Checker, webhooks *webhook.Allowlist, log *logger.Logger) *OrchestrationHandler {
+	return &OrchestrationHandler{
+		orchestrator: orchestrator,
+		repo:         repo,
+		checks:       emailChecks{attachments: attachments, domains: domains, content: content},
+		webhooks:     webhooks,
+		log:          log,
+	}
+}
+
+// Orchestrate sends one notification over an ordered list of channels.
+// With the all strategy every channel is sent; with first_success channels are tried in
+// order until one is sent; with escalate the next channel is sent if the last one isn't
+// delivered within escalate_after_seconds. Each channel gets a derived idempotency key and
+// the request's group ID.
+func (h *OrchestrationHandler) Orchestrate(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+
+	var req domain.OrchestrateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBindingError(err))
+		return
+	}
+	if !checkTenantID(c, req.TenantID) {
+		return
+	}
+	if req.Strategy == domain.OrchestrationStrategyEscalate {
+		if req.EscalateAfter == 0 || len(req.Channels) < 2 {
+			c.Error(errors.NewValidationError("Invalid request", fmt.Errorf("escalate needs escalate_after_seconds and at least two channels")))
+			return
+		}
+	}
+
+	for i := range req.Channels {
+		channel := &req.Channels[i]
+		if err := validateBatchItem(channel); err != nil {
+			c.Error(errors.NewValidationError(fmt.Sprintf("Invalid channel at index %d", i), err))
+			return
+		}
+		if !checkTenantID(c, batchItemTenantID(channel)) {
+			return
+		}
+		if channel.Email != nil {
+			if channel.Email.Digest != nil {
+				c.Error(errors.NewValidationError(fmt.Sprintf("Invalid channel at index %d", i), fmt.Errorf("digest emails cannot be orchestrated")))
+				return
+			}
+			if err := h.checks.check(c.Request.Context(), tenantID, channel.Email); err != nil {
+				h.log.Warn("Rejected orchestrated email", "error", err, "tenant_id", tenantID, "index", i)
+				c.Error(appError(err, "Failed to validate email request"))
+				return
+			}
+		}
+		if channel.Webhook != nil && h.webhooks != nil {
+			if err := h.webhooks.Check(c.Request.Context(), tenantID, channel.Webhook.URL); err != nil {
+				h.log.Warn("Rejected orchestrated webhook", "error", err, "tenant_id", tenantID, "index", i)
+				c.Error(appError(err, "Failed to validate webhook request"))
+				return
+			}
+		}
+	}
+
+	// Set tenant_id from authenticated context
+	req.TenantID = tenantID
+
+	resp, err := h.orchestrator.Orchestrate(c.Request.Context(), &req)
+	if err != nil {
+		h.log.Error("Failed to orchestrate notification", "error", err, "tenant_id", tenantID)
+		c.Error(appError(err, "Failed to orchestrate notification"))
+		return
+	}
+
+	h.log.Info("Orchestrated notification", "tenant_id", tenantID, "group_id", resp.GroupID, "strategy", resp.Strategy, "status", resp.Status)
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetOrchestration returns an escalating notification and the channels sent so far
+func (h *OrchestrationHandler) GetOrchestration(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+	id := c.Param("id")
+
+	orchestration, err := h.repo.FindByID(c.Request.Context(), tenantID, id)
+	if err != nil {
+		h.log.Error("Failed to get orchestration", "error", err, "tenant_id", tenantID, "id", id)
+		c.Error(appError(err, "Failed to get orchestration"))
+		return
+	}
+	if orchestration == nil {
+		c.Error(errors.NewNotFoundError("Orchestration not found", nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": orchestration})
+}
+
+// AcknowledgeOrchestration stops an escalating notification from escalating further
+func (h *OrchestrationHandler) AcknowledgeOrchestration(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+	id := c.Param("id")
+
+	orchestration, err := h.repo.Acknowledge(c.Request.Context(), tenantID, id)
+	if err != nil {
+		h.log.Error("Failed to acknowledge orchestration", "error", err, "tenant_id", tenantID, "id", id)
+		c.Error(appError(err, "Failed to acknowledge orchestration"))
+		return
+	}
+	if orchestration == nil {
+		c.Error(errors.NewNotFoundError("Orchestration not found", nil))
+		return
+	}
+
+	h.log.Info("Acknowledged orchestration", "tenant_id", tenantID, "id", id, "status", orchestration.Status)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Orchestration acknowledged",
+		"data":    orchestration,
+	})
+}
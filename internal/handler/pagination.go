@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// PaginatedResponse is the envelope returned by every list endpoint
+type PaginatedResponse[T any] struct {
+	Data       []T   `json:"data"`
+	Total      int64 `json:"total"`
+	Page       int   `json:"page"`
+	PageSize   int   `json:"page_size"`
+	TotalPages int   `json:"total_pages"`
+	HasNext    bool  `json:"has_next"`
+}
+
+// NewPaginatedResponse builds the envelope for one page of a list of total items
+func NewPaginatedResponse[T any](data []T, total int64, page, pageSize int) PaginatedResponse[T] {
+	if data == nil {
+		data = []T{}
+	}
+
+	totalPages := 0
+	if pageSize > 0 {
+		totalPages = int((total + int64(pageSize) - 1) / int64(pageSize))
+	}
+
+	return PaginatedResponse[T]{
+		Data:       data,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+		HasNext:    page < totalPages,
+	}
+}
+
+// normalizePage clamps a requested page to at least 1 and a page size to 1..maxPageSize,
+// using defaultPageSize when none was given
+func normalizePage(page, pageSize int) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	return page, min(pageSize, maxPageSize)
+}
+
+// pageQuery reads and normalizes the page and page_size query parameters
+func pageQuery(c *gin.Context) (int, int) {
+	page, _ := strconv.Atoi(c.Query("page"))
+	pageSize, _ := strconv.Atoi(c.Query("page_size"))
+	return normalizePage(page, pageSize)
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/middleware"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+func TestNewPaginatedResponse(t *testing.T) {
+	tests := []struct {
+		name           string
+		total          int64
+		page, pageSize int
+		wantPages      int
+		wantNext       bool
+	}{
+		{"first of several pages", 45, 1, 20, 3, true},
+		{"last page", 45, 3, 20, 3, false},
+		{"exact fit", 40, 2, 20, 2, false},
+		{"no items", 0, 1, 20, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := NewPaginatedResponse([]string{"a"}, tt.total, tt.page, tt.pageSize)
+			if resp.TotalPages != tt.wantPages || resp.HasNext != tt.wantNext {
+				t.Errorf("TotalPages, HasNext = %d, %v, want %d, %v", resp.TotalPages, resp.HasNext, tt.wantPages, tt.wantNext)
+			}
+		})
+	}
+}
+
+func TestNewPaginatedResponseEncodesEmptyPageAsArray(t *testing.T) {
+	body, err := json.Marshal(NewPaginatedResponse[string](nil, 0, 1, 20))
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	want := `{"data":[],"total":0,"page":1,"page_size":20,"total_pages":0,"has_next":false}`
+	if string(body) != want {
+		t.Errorf("body = %s, want %s", body, want)
+	}
+}
+
+func TestNormalizePage(t *testing.T) {
+	tests := []struct {
+		page, pageSize         int
+		wantPage, wantPageSize int
+	}{
+		{0, 0, 1, defaultPageSize},
+		{-2, -5, 1, defaultPageSize},
+		{3, 50, 3, 50},
+		{1, 1000, 1, maxPageSize},
+	}
+	for _, tt := range tests {
+		page, pageSize := normalizePage(tt.page, tt.pageSize)
+		if page != tt.wantPage || pageSize != tt.wantPageSize {
+			t.Errorf("normalizePage(%d, %d) = %d, %d, want %d, %d", tt.page, tt.pageSize, page, pageSize, tt.wantPage, tt.wantPageSize)
+		}
+	}
+}
+
+// auditLogs returns total entries from a fixed page and records the query it was given
+type auditLogs struct {
+	entries []*domain.AuditLog
+	total   int64
+	query   *domain.AuditLogQuery
+}
+
+func (a *auditLogs) Find(ctx context.Context, query *domain.AuditLogQuery) ([]*domain.AuditLog, int64, error) {
+	a.query = query
+	return a.entries, a.total, nil
+}
+
+func TestGetAuditLogsReturnsPaginatedEnvelope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo := &auditLogs{entries: []*domain.AuditLog{{Action: "send_email"}}, total: 250}
+	router := gin.New()
+	router.Use(middleware.ErrorHandlerMiddleware(), middleware.TenancyMiddleware())
+	router.GET("/audit-logs", NewAuditHandler(repo, logger.NewLogger()).GetAuditLogs)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/audit-logs?page=2&page_size=500", nil)
+	r.Header.Set(middleware.TenantIDHeader, "tenant-1")
+	router.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	if repo.query.TenantID != "tenant-1" || repo.query.Page != 2 || repo.query.PageSize != maxPageSize {
+		t.Errorf("query = %+v, want tenant-1, page 2 and the maximum page size", repo.query)
+	}
+	var resp PaginatedResponse[domain.AuditLog]
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if len(resp.Data) != 1 || resp.Total != 250 || resp.Page != 2 || resp.PageSize != maxPageSize || resp.TotalPages != 3 || !resp.HasNext {
+		t.Errorf("response = %+v", resp)
+	}
+}
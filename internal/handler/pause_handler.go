@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/middleware"
+	"github.com/vhvplatform/go-notification-service/internal/pause"
+	"github.com/vhvplatform/go-notification-service/internal/shared/errors"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// HeldCounter interface for counting notifications held while paused
+type HeldCounter interface {
+	CountHeld(ctx context.Context) (map[domain.NotificationType]int64, error)
+}
+
+// PauseHandler handles the administrative send pause endpoints
+type PauseHandler struct {
+	controller *pause.Controller
+	held       HeldCounter
+	log        *logger.Logger
+}
+
+// NewPauseHandler creates a new send pause handler
+func NewPauseHandler(controller *pause.Controller, held HeldCounter, log *logger.Logger) *PauseHandler {
+	return &PauseHandler{
+		controller: controller,
+		held:       held,
+		log:        log,
+	}
+}
+
+// GetStatus reports the pause state and the number of held notifications per channel
+func (h *PauseHandler) GetStatus(c *gin.Context) {
+	held, err := h.held.CountHeld(c.Request.Context())
+	if err != nil {
+		h.log.Error("Failed to count held notifications", "error", err)
+		c.Error(appError(err, "Failed to count held notifications"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"pause": h.controller.Status(),
+			"held":  held,
+		},
+	})
+}
+
+// Pause pauses the requested channels, or every channel when none are given
+func (h *PauseHandler) Pause(c *gin.Context) {
+	// An empty body pauses every channel
+	var req domain.PauseSendsRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.Error(errors.NewBindingError(err))
+		return
+	}
+
+	if err := h.controller.Pause(c.Request.Context(), req.Channels, middleware.Actor(c), req.Reason); err != nil {
+		h.log.Error("Failed to pause sends", "error", err)
+		c.Error(appError(err, "Failed to pause sends"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sends paused",
+		"data":    h.controller.Status(),
+	})
+}
+
+// Resume resumes the requested channels, or every channel when none are given.
+// Held notifications for resumed channels are sent in the background.
+func (h *PauseHandler) Resume(c *gin.Context) {
+	var req domain.ResumeSendsRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.Error(errors.NewBindingError(err))
+		return
+	}
+
+	if err := h.controller.Resume(c.Request.Context(), req.Channels, middleware.Actor(c)); err != nil {
+		h.log.Error("Failed to resume sends", "error", err)
+		c.Error(appError(err, "Failed to resume sends"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sends resumed",
+		"data":    h.controller.Status(),
+	})
+}
+
+// respondHeld reports that a send was accepted but held because its channel is paused
+func respondHeld(c *gin.Context, channel domain.NotificationType) {
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Sends are paused; the notification will be sent when they resume",
+		"data": gin.H{
+			"type":   channel,
+			"status": domain.NotificationStatusPending,
+		},
+	})
+}
@@ -1,75 +1,357 @@
-package handler
-
-import (
-	"net/http"
-
-	"github.com/gin-gonic/gin"
-	"github.com/vhvplatform/go-notification-service/internal/domain"
-	"github.com/vhvplatform/go-notification-service/internal/middleware"
-	"github.com/vhvplatform/go-notification-service/internal/repository"
-	"github.com/vhvplatform/go-notification-service/internal/shared/errors"
-	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
-)
-
-// PreferencesHandler handles notification preferences requests
-type PreferencesHandler struct {
-	repo *repository.PreferencesRepository
-	log  *logger.Logger
-}
-
-// NewPreferencesHandler creates a new preferences handler
-func NewPreferencesHandler(repo *repository.PreferencesRepository, log *logger.Logger) *PreferencesHandler {
-	return &PreferencesHandler{
-		repo: repo,
-		log:  log,
-	}
-}
-
-// GetPreferences retrieves user notification preferences
-func (h *PreferencesHandler) GetPreferences(c *gin.Context) {
-	// Extract tenant_id from authenticated context
-	tenantID := middleware.MustGetTenantID(c)
-	userID := c.Param("user_id")
-
-	if userID == "" {
-		c.JSON(http.StatusBadRequest, errors.NewValidationError("user_id is required", nil))
-		return
-	}
-
-	prefs, err := h.repo.GetByUserID(c.Request.Context(), tenantID, userID)
-	if err != nil {
-		h.log.Error("Failed to get preferences", "error", err, "tenant_id", tenantID, "user_id", userID)
-		c.JSON(http.StatusInternalServerError, errors.NewInternalError("Failed to get preferences", err))
-		return
-	}
-
-	c.JSON(http.StatusOK, prefs)
-}
-
-// UpdatePreferences updates user notification preferences
-func (h *PreferencesHandler) UpdatePreferences(c *gin.Context) {
-	// Extract tenant_id from authenticated context
-	tenantID := middleware.MustGetTenantID(c)
-	userID := c.Param("user_id")
-
-	var prefs domain.NotificationPreferences
-	if err := c.ShouldBindJSON(&prefs); err != nil {
-		c.JSON(http.StatusBadRequest, errors.NewValidationError("Invalid request", err))
-		return
-	}
-
-	// Set tenant_id and user_id from authenticated context and URL param
-	prefs.TenantID = tenantID
-	prefs.UserID = userID
-
-	if err := h.repo.Update(c.Request.Context(), &prefs); err != nil {
-		h.log.Error("Failed to update preferences", "error", err, "tenant_id", tenantID, "user_id", userID)
-		c.JSON(http.StatusInternalServerError, errors.NewInternalError("Failed to update preferences", err))
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Preferences updated successfully",
-		"data":    prefs,
-	})
-}
+package handler
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/middleware"
+	"github.com/vhvplatform/go-notification-service/internal/repository"
+	"github.com/vhvplatform/go-notification-service/internal/shared/errors"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// categoryNameRegex restricts category names so they are safe to use as document field paths
+var categoryNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// PreferencesHandler handles notification preferences requests
+type PreferencesHandler struct {
+	repo         *repository.PreferencesRepository
+	categoryRepo *repository.PreferenceCategoryRepository
+	log          *logger.Logger
+}
+
+// NewPreferencesHandler creates a new preferences handler
+func NewPreferencesHandler(repo *repository.PreferencesRepository, categoryRepo *repository.PreferenceCategoryRepository, log *logger.Logger) *PreferencesHandler {
+	return &PreferencesHandler{
+		repo:         repo,
+		categoryRepo: categoryRepo,
+		log:          log,
+	}
+}
+
+// GetPreferences retrieves user notification preferences
+func (h *PreferencesHandler) GetPreferences(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+	userID := c.Param("user_id")
+
+	if userID == "" {
+		c.Error(errors.NewValidationError("user_id is required", nil))
+		return
+	}
+
+	prefs, err := h.repo.GetByUserID(c.Request.Context(), tenantID, userID)
+	if err != nil {
+		h.log.Error("Failed to get preferences", "error", err, "tenant_id", tenantID, "user_id", userID)
+		c.Error(appError(err, "Failed to get preferences"))
+		return
+	}
+
+	c.JSON(http.StatusOK, prefs)
+}
+
+// CreatePreferences stores a user's first preferences. It fails with a conflict when the
+// user already has stored preferences, so a retried create never overwrites later changes.
+func (h *PreferencesHandler) CreatePreferences(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+	userID := c.Param("user_id")
+
+	prefs, ok := bindPreferences(c, tenantID, userID)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.Create(c.Request.Context(), prefs); err != nil {
+		if stderrors.Is(err, repository.ErrPreferencesExist) {
+			c.Error(errors.NewConflictError("Preferences already exist for this user; update them instead", err))
+			return
+		}
+		h.log.Error("Failed to create preferences", "error", err, "tenant_id", tenantID, "user_id", userID)
+		c.Error(appError(err, "Failed to create preferences"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Preferences created successfully",
+		"data":    prefs,
+	})
+}
+
+// UpdatePreferences updates user notification preferences
+func (h *PreferencesHandler) UpdatePreferences(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+	userID := c.Param("user_id")
+
+	prefs, ok := bindPreferences(c, tenantID, userID)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.Update(c.Request.Context(), prefs); err != nil {
+		h.log.Error("Failed to update preferences", "error", err, "tenant_id", tenantID, "user_id", userID)
+		c.Error(appError(err, "Failed to update preferences"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Preferences updated successfully",
+		"data":    prefs,
+	})
+}
+
+// bindPreferences binds and validates a full preferences body for the user in the path.
+// It reports false after recording the error on c.
+func bindPreferences(c *gin.Context, tenantID, userID string) (*domain.NotificationPreferences, bool) {
+	if userID == "" {
+		c.Error(errors.NewValidationError("user_id is required", nil))
+		return nil, false
+	}
+
+	var prefs domain.NotificationPreferences
+	if err := c.ShouldBindJSON(&prefs); err != nil {
+		c.Error(errors.NewBindingError(err))
+		return nil, false
+	}
+
+	// Tenant and user come from the authenticated context and URL; reject conflicting body values
+	if !checkTenantID(c, prefs.TenantID) {
+		return nil, false
+	}
+	if prefs.UserID != "" && prefs.UserID != userID {
+		c.Error(errors.NewValidationError("user_id does not match path", nil))
+		return nil, false
+	}
+
+	if prefs.Timezone == "" {
+		prefs.Timezone = "UTC"
+	}
+	if err := validateQuietHours(prefs.QuietHoursStart, prefs.QuietHoursEnd, prefs.Timezone); err != nil {
+		c.Error(errors.NewValidationError("Invalid quiet hours", err))
+		return nil, false
+	}
+
+	// Set tenant_id and user_id from authenticated context and URL param
+	prefs.TenantID = tenantID
+	prefs.UserID = userID
+	// Stored preferences are explicit, whatever the body claims
+	prefs.IsDefault = false
+	return &prefs, true
+}
+
+// PatchPreferences partially updates user notification preferences
+func (h *PreferencesHandler) PatchPreferences(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+	userID := c.Param("user_id")
+
+	if userID == "" {
+		c.Error(errors.NewValidationError("user_id is required", nil))
+		return
+	}
+
+	var patch domain.PreferencesPatchRequest
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		c.Error(errors.NewBindingError(err))
+		return
+	}
+
+	if err := validatePatchSchedule(&patch); err != nil {
+		c.Error(errors.NewValidationError("Invalid quiet hours", err))
+		return
+	}
+
+	categories := make([]string, 0, len(patch.EmailCategories)+len(patch.SMSCategories))
+	for category := range patch.EmailCategories {
+		categories = append(categories, category)
+	}
+	for category := range patch.SMSCategories {
+		categories = append(categories, category)
+	}
+	if err := h.validateCategories(c.Request.Context(), tenantID, categories); err != nil {
+		c.Error(errors.NewValidationError("Invalid category", err))
+		return
+	}
+
+	prefs, err := h.repo.Patch(c.Request.Context(), tenantID, userID, &patch)
+	if err != nil {
+		h.log.Error("Failed to patch preferences", "error", err, "tenant_id", tenantID, "user_id", userID)
+		c.Error(appError(err, "Failed to update preferences"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Preferences updated successfully",
+		"data":    prefs,
+	})
+}
+
+// BulkUpdateCategory enables or disables one category for many users
+func (h *PreferencesHandler) BulkUpdateCategory(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+
+	var req domain.BulkCategoryUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBindingError(err))
+		return
+	}
+
+	if err := h.validateCategories(c.Request.Context(), tenantID, []string{req.Category}); err != nil {
+		c.Error(errors.NewValidationError("Invalid category", err))
+		return
+	}
+
+	matched, upserted, err := h.repo.BulkSetCategory(c.Request.Context(), tenantID, req.UserIDs, req.Channel, req.Category, *req.Enabled)
+	if err != nil {
+		h.log.Error("Failed to bulk update preferences", "error", err, "tenant_id", tenantID, "category", req.Category)
+		c.Error(appError(err, "Failed to update preferences"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Preferences updated successfully",
+		"matched":  matched,
+		"upserted": upserted,
+	})
+}
+
+// GetCategories retrieves the tenant's preference category list
+func (h *PreferencesHandler) GetCategories(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+
+	categories, err := h.categoryRepo.GetByTenantID(c.Request.Context(), tenantID)
+	if err != nil {
+		h.log.Error("Failed to get preference categories", "error", err, "tenant_id", tenantID)
+		c.Error(appError(err, "Failed to get preference categories"))
+		return
+	}
+
+	c.JSON(http.StatusOK, categories)
+}
+
+// UpdateCategories replaces the tenant's preference category list
+func (h *PreferencesHandler) UpdateCategories(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+
+	var req domain.UpdatePreferenceCategoriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBindingError(err))
+		return
+	}
+
+	for _, category := range req.Categories {
+		if !categoryNameRegex.MatchString(category) {
+			c.Error(errors.NewValidationError("Invalid category", fmt.Errorf("invalid category name: %q", category)))
+			return
+		}
+	}
+
+	categories, err := h.categoryRepo.Upsert(c.Request.Context(), tenantID, req.Categories)
+	if err != nil {
+		h.log.Error("Failed to update preference categories", "error", err, "tenant_id", tenantID)
+		c.Error(appError(err, "Failed to update preference categories"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Preference categories updated successfully",
+		"data":    categories,
+	})
+}
+
+// validateCategories checks category names are well-formed and, when the tenant has
+// defined a category list, that each name is in it
+func (h *PreferencesHandler) validateCategories(ctx context.Context, tenantID string, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+
+	for _, name := range names {
+		if !categoryNameRegex.MatchString(name) {
+			return fmt.Errorf("invalid category name: %q", name)
+		}
+	}
+
+	defined, err := h.categoryRepo.GetByTenantID(ctx, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to load tenant categories: %w", err)
+	}
+	if len(defined.Categories) == 0 {
+		return nil
+	}
+
+	allowed := make(map[string]struct{}, len(defined.Categories))
+	for _, category := range defined.Categories {
+		allowed[category] = struct{}{}
+	}
+	for _, name := range names {
+		if _, ok := allowed[name]; !ok {
+			return fmt.Errorf("unknown category: %q", name)
+		}
+	}
+	return nil
+}
+
+// validateQuietHours checks quiet hours are HH:MM (both or neither set) and the timezone is a valid IANA name
+func validateQuietHours(start, end, timezone string) error {
+	if (start == "") != (end == "") {
+		return fmt.Errorf("quiet_hours_start and quiet_hours_end must be set together")
+	}
+	for _, value := range []string{start, end} {
+		if err := validateClockTime(value); err != nil {
+			return err
+		}
+	}
+	return validateTimezone(timezone)
+}
+
+// validatePatchSchedule validates the quiet hours and timezone fields present in a patch
+func validatePatchSchedule(patch *domain.PreferencesPatchRequest) error {
+	for _, value := range []*string{patch.QuietHoursStart, patch.QuietHoursEnd} {
+		if value == nil {
+			continue
+		}
+		if err := validateClockTime(*value); err != nil {
+			return err
+		}
+	}
+	if patch.Timezone != nil {
+		if *patch.Timezone == "" {
+			return fmt.Errorf("timezone cannot be empty")
+		}
+		return validateTimezone(*patch.Timezone)
+	}
+	return nil
+}
+
+// validateClockTime checks a time of day is in HH:MM format (empty means unset)
+func validateClockTime(value string) error {
+	if value == "" {
+		return nil
+	}
+	if _, err := time.Parse("15:04", value); err != nil || len(value) != 5 {
+		return fmt.Errorf("invalid quiet hours time %q (expected HH:MM)", value)
+	}
+	return nil
+}
+
+// validateTimezone checks a timezone is a valid IANA name (empty means unset)
+func validateTimezone(timezone string) error {
+	if timezone == "" {
+		return nil
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return fmt.Errorf("invalid timezone %q", timezone)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/middleware"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// sendPreferences sends body to the preferences route for user-1 of tenant-1. Every request
+// here is rejected before the repositories are used, so none are configured.
+func sendPreferences(t *testing.T, method, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	h := NewPreferencesHandler(nil, nil, logger.NewLogger())
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandlerMiddleware(), middleware.TenancyMiddleware())
+	router.POST("/preferences/:user_id", h.CreatePreferences)
+	router.PUT("/preferences/:user_id", h.UpdatePreferences)
+	router.PATCH("/preferences/:user_id", h.PatchPreferences)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(method, "/preferences/user-1", bytes.NewBufferString(body))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set(middleware.TenantIDHeader, "tenant-1")
+	router.ServeHTTP(w, r)
+	return w
+}
+
+func TestPreferencesRejectInvalidBodies(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"other tenant", `{"tenant_id": "tenant-2", "email_enabled": true}`},
+		{"other user", `{"user_id": "user-2", "email_enabled": true}`},
+		{"start without end", `{"quiet_hours_start": "22:00"}`},
+		{"malformed start", `{"quiet_hours_start": "10pm", "quiet_hours_end": "08:00"}`},
+		{"single digit hour", `{"quiet_hours_start": "22:00", "quiet_hours_end": "8:00"}`},
+		{"hour out of range", `{"quiet_hours_start": "24:00", "quiet_hours_end": "08:00"}`},
+		{"unknown timezone", `{"quiet_hours_start": "22:00", "quiet_hours_end": "08:00", "timezone": "Mars/Olympus_Mons"}`},
+	}
+
+	for _, method := range []string{http.MethodPost, http.MethodPut} {
+		for _, tt := range tests {
+			t.Run(method+" "+tt.name, func(t *testing.T) {
+				if w := sendPreferences(t, method, tt.body); w.Code != http.StatusBadRequest {
+					t.Errorf("status = %d, want %d: %s", w.Code, http.StatusBadRequest, w.Body.String())
+				}
+			})
+		}
+	}
+}
+
+func TestPatchPreferencesRejectsInvalidSchedules(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"malformed start", `{"quiet_hours_start": "22h"}`},
+		{"malformed end", `{"quiet_hours_end": "08:00:00"}`},
+		{"unknown timezone", `{"timezone": "Europe/Atlantis"}`},
+		{"empty timezone", `{"timezone": ""}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := sendPreferences(t, http.MethodPatch, tt.body); w.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d: %s", w.Code, http.StatusBadRequest, w.Body.String())
+			}
+		})
+	}
+}
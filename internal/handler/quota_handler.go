@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/middleware"
+	"github.com/vhvplatform/go-notification-service/internal/quota"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// QuotaHandler handles HTTP requests for tenant send quotas
+type QuotaHandler struct {
+	enforcer *quota.Enforcer
+	log      *logger.Logger
+}
+
+// NewQuotaHandler creates a new quota handler
+func NewQuotaHandler(enforcer *quota.Enforcer, log *logger.Logger) *QuotaHandler {
+	return &QuotaHandler{
+		enforcer: enforcer,
+		log:      log,
+	}
+}
+
+// GetUsage reports the tenant's current usage against its daily and monthly quotas
+func (h *QuotaHandler) GetUsage(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+
+	usage, err := h.enforcer.Usage(c.Request.Context(), tenantID)
+	if err != nil {
+		h.log.Error("Failed to get quota usage", "error", err, "tenant_id", tenantID)
+		c.Error(appError(err, "Failed to get quota usage"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": usage,
+	})
+}
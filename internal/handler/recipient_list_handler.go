@@ -0,0 +1,262 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/middleware"
+	"github.com/vhvplatform/go-notification-service/internal/recipients"
+	"github.com/vhvplatform/go-notification-service/internal/repository"
+	"github.com/vhvplatform/go-notification-service/internal/shared/errors"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// memberUpdateAttempts bounds retries of a member change that lost a race with another writer
+const memberUpdateAttempts = 3
+
+// RecipientListHandler handles HTTP requests for recipient lists
+type RecipientListHandler struct {
+	repo *repository.RecipientListRepository
+	log  *logger.Logger
+}
+
+// NewRecipientListHandler creates a new recipient list handler
+func NewRecipientListHandler(repo *repository.RecipientListRepository, log *logger.Logger) *RecipientListHandler {
+	return &RecipientListHandler{
+		repo: repo,
+		log:  log,
+	}
+}
+
+// GetRecipientLists lists the tenant's recipient lists without their members
+func (h *RecipientListHandler) GetRecipientLists(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+
+	page, pageSize := pageQuery(c)
+	lists, total, err := h.repo.FindByTenantID(c.Request.Context(), tenantID, page, pageSize)
+	if err != nil {
+		h.log.Error("Failed to get recipient lists", "error", err, "tenant_id", tenantID)
+		c.Error(appError(err, "Failed to get recipient lists"))
+		return
+	}
+
+	c.JSON(http.StatusOK, NewPaginatedResponse(lists, total, page, pageSize))
+}
+
+// GetRecipientList retrieves a recipient list with its members
+func (h *RecipientListHandler) GetRecipientList(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+
+	list, ok := h.find(c, tenantID)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": list,
+	})
+}
+
+// CreateRecipientList creates a recipient list
+func (h *RecipientListHandler) CreateRecipientList(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+
+	var req domain.CreateRecipientListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBindingError(err))
+		return
+	}
+
+	list := &domain.RecipientList{
+		TenantID:    tenantID,
+		Name:        req.Name,
+		Description: req.Description,
+		Members:     []domain.RecipientListMember{},
+	}
+	if _, err := recipients.AddMembers(list, req.Members, time.Now()); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := h.repo.Create(c.Request.Context(), list); err != nil {
+		h.log.Error("Failed to create recipient list", "error", err, "tenant_id", tenantID)
+		c.Error(appError(err, "Failed to create recipient list"))
+		return
+	}
+	c.Set(middleware.AuditResourceIDKey, list.ID.Hex())
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Recipient list created successfully",
+		"data":    list,
+	})
+}
+
+// UpdateRecipientList renames or re-describes a recipient list
+func (h *RecipientListHandler) UpdateRecipientList(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+
+	var req domain.UpdateRecipientListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBindingError(err))
+		return
+	}
+
+	list, ok := h.find(c, tenantID)
+	if !ok {
+		return
+	}
+
+	list.Name = req.Name
+	list.Description = req.Description
+	list.Version = req.Version
+	if err := h.repo.Update(c.Request.Context(), list); err != nil {
+		h.log.Error("Failed to update recipient list", "error", err, "tenant_id", tenantID)
+		c.Error(appError(err, "Failed to update recipient list"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Recipient list updated successfully",
+		"data":    list,
+	})
+}
+
+// DeleteRecipientList deletes a recipient list
+func (h *RecipientListHandler) DeleteRecipientList(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+
+	if _, ok := h.find(c, tenantID); !ok {
+		return
+	}
+
+	if err := h.repo.Delete(c.Request.Context(), tenantID, c.Param("id")); err != nil {
+		h.log.Error("Failed to delete recipient list", "error", err, "tenant_id", tenantID)
+		c.Error(appError(err, "Failed to delete recipient list"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Recipient list deleted successfully",
+	})
+}
+
+// AddMembers adds members to a recipient list; addresses already on the list are left unchanged
+func (h *RecipientListHandler) AddMembers(c *gin.Context) {
+	var req domain.AddRecipientListMembersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBindingError(err))
+		return
+	}
+
+	h.changeMembers(c, "added", func(list *domain.RecipientList) (int, error) {
+		return recipients.AddMembers(list, req.Members, time.Now())
+	})
+}
+
+// RemoveMembers removes members from a recipient list
+func (h *RecipientListHandler) RemoveMembers(c *gin.Context) {
+	var req domain.RecipientListMembersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBindingError(err))
+		return
+	}
+
+	h.changeMembers(c, "removed", func(list *domain.RecipientList) (int, error) {
+		return recipients.RemoveMembers(list, req.Addresses), nil
+	})
+}
+
+// Subscribe resubscribes members of a recipient list
+func (h *RecipientListHandler) Subscribe(c *gin.Context) {
+	h.setSubscribed(c, true)
+}
+
+// Unsubscribe unsubscribes members of a recipient list; they stay on the list but are not sent to
+func (h *RecipientListHandler) Unsubscribe(c *gin.Context) {
+	h.setSubscribed(c, false)
+}
+
+// setSubscribed sets the subscription state of the members named in the request
+func (h *RecipientListHandler) setSubscribed(c *gin.Context, subscribed bool) {
+	var req domain.RecipientListMembersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBindingError(err))
+		return
+	}
+
+	result := "unsubscribed"
+	if subscribed {
+		result = "subscribed"
+	}
+	h.changeMembers(c, result, func(list *domain.RecipientList) (int, error) {
+		return recipients.SetSubscribed(list, req.Addresses, subscribed, time.Now()), nil
+	})
+}
+
+// changeMembers applies change to the list's members and saves it, reloading and reapplying
+// the change when another writer updated the list first
+func (h *RecipientListHandler) changeMembers(c *gin.Context, result string, change func(list *domain.RecipientList) (int, error)) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+
+	for attempt := 1; ; attempt++ {
+		list, ok := h.find(c, tenantID)
+		if !ok {
+			return
+		}
+
+		changed, err := change(list)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		err = h.save(c.Request.Context(), list, changed)
+		if _, conflict := repository.AsConflict(err); conflict && attempt < memberUpdateAttempts {
+			continue
+		}
+		if err != nil {
+			h.log.Error("Failed to update recipient list members", "error", err, "tenant_id", tenantID)
+			c.Error(appError(err, "Failed to update recipient list members"))
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Recipient list members updated successfully",
+			"data":    gin.H{result: changed, "member_count": len(list.Members)},
+		})
+		return
+	}
+}
+
+// save stores the list when a change was made
+func (h *RecipientListHandler) save(ctx context.Context, list *domain.RecipientList, changed int) error {
+	if changed == 0 {
+		return nil
+	}
+	return h.repo.Update(ctx, list)
+}
+
+// find loads the list named by the id path parameter, reporting an error on c when it can't
+func (h *RecipientListHandler) find(c *gin.Context, tenantID string) (*domain.RecipientList, bool) {
+	id := c.Param("id")
+	list, err := h.repo.FindRecipientList(c.Request.Context(), tenantID, id)
+	if err != nil {
+		h.log.Error("Failed to get recipient list", "error", err, "id", id, "tenant_id", tenantID)
+		c.Error(appError(err, "Failed to get recipient list"))
+		return nil, false
+	}
+	if list == nil {
+		c.Error(errors.NewNotFoundError("Recipient list not found", nil))
+		return nil, false
+	}
+	return list, true
+}
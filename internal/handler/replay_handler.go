@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/consumer"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/middleware"
+	"github.com/vhvplatform/go-notification-service/internal/shared/errors"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// ndjsonContentType selects a newline-delimited body of raw events
+const ndjsonContentType = "application/x-ndjson"
+
+// ReplayHandler replays raw events for a tenant, bypassing RabbitMQ
+type ReplayHandler struct {
+	replayer *consumer.Replayer
+	log      *logger.Logger
+}
+
+// NewReplayHandler creates a new event replay handler
+func NewReplayHandler(replayer *consumer.Replayer, log *logger.Logger) *ReplayHandler {
+	return &ReplayHandler{
+		replayer: replayer,
+		log:      log,
+	}
+}
+
+// ReplayEvents processes the submitted events for the tenant in the path and reports each
+// one's outcome. The body is a ReplayEventsRequest, or newline-delimited events with
+// Content-Type application/x-ndjson and a dry_run query parameter. Events for other tenants
+// are rejected.
+func (h *ReplayHandler) ReplayEvents(c *gin.Context) {
+	tenantID := c.Param("id")
+	c.Set(middleware.AuditTenantIDKey, tenantID)
+
+	opts := consumer.ReplayOptions{TenantID: tenantID, MaxEvents: domain.MaxReplayEvents}
+	var source consumer.EventSource
+	if c.ContentType() == ndjsonContentType {
+		if value := c.Query("dry_run"); value != "" {
+			dryRun, err := strconv.ParseBool(value)
+			if err != nil {
+				c.Error(errors.NewValidationError("Invalid dry_run", err))
+				return
+			}
+			opts.DryRun = dryRun
+		}
+		source = consumer.NewReaderSource(c.Request.Body)
+	} else {
+		var req domain.ReplayEventsRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(errors.NewBindingError(err))
+			return
+		}
+		opts.DryRun = req.DryRun
+		source = consumer.NewPayloadSource(req.Events)
+	}
+
+	report, err := h.replayer.ReplayEvents(c.Request.Context(), source, opts)
+	if err != nil {
+		// Events before the failure were already processed, so their outcomes are still reported
+		h.log.Warn("Event replay stopped early", "error", err, "tenant_id", tenantID, "replayed", report.Total)
+		c.Error(errors.NewValidationError("Event replay stopped early", err).WithField("data", report))
+		return
+	}
+
+	h.log.Info("Replayed events", "tenant_id", tenantID, "dry_run", report.DryRun, "processed", report.Processed, "failed", report.Failed, "invalid", report.Invalid, "paused", report.Paused)
+	c.JSON(http.StatusOK, gin.H{
+		"data": report,
+	})
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/middleware"
+	"github.com/vhvplatform/go-notification-service/internal/reputation"
+	"github.com/vhvplatform/go-notification-service/internal/shared/errors"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// ReputationHandler handles the tenant bounce rate and automatic pause endpoints
+type ReputationHandler struct {
+	monitor *reputation.Monitor
+	log     *logger.Logger
+}
+
+// NewReputationHandler creates a new reputation handler
+func NewReputationHandler(monitor *reputation.Monitor, log *logger.Logger) *ReputationHandler {
+	return &ReputationHandler{
+		monitor: monitor,
+		log:     log,
+	}
+}
+
+// GetReputation reports the tenant's recent bounce and complaint rates and whether its email is paused
+func (h *ReputationHandler) GetReputation(c *gin.Context) {
+	tenantID := middleware.MustGetTenantID(c)
+
+	status, err := h.monitor.Status(c.Request.Context(), tenantID)
+	if err != nil {
+		h.log.Error("Failed to get tenant reputation", "error", err, "tenant_id", tenantID)
+		c.Error(appError(err, "Failed to get tenant reputation"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": status,
+	})
+}
+
+// ListReputations reports every tenant's recent bounce and complaint rates and pause state
+func (h *ReputationHandler) ListReputations(c *gin.Context) {
+	reputations, err := h.monitor.List(c.Request.Context())
+	if err != nil {
+		h.log.Error("Failed to list tenant reputations", "error", err)
+		c.Error(appError(err, "Failed to list tenant reputations"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": reputations,
+	})
+}
+
+// OverrideReputation pauses or resumes the email of the tenant in the path for a number of
+// hours whatever its rates, or returns it to automatic control
+func (h *ReputationHandler) OverrideReputation(c *gin.Context) {
+	tenantID := c.Param("id")
+	c.Set(middleware.AuditTenantIDKey, tenantID)
+
+	var req domain.OverrideReputationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBindingError(err))
+		return
+	}
+
+	ctx := c.Request.Context()
+	duration := time.Duration(req.Hours) * time.Hour
+	if err := h.monitor.Override(ctx, tenantID, req.Action, middleware.Actor(c), duration); err != nil {
+		h.log.Error("Failed to override tenant reputation", "error", err, "tenant_id", tenantID)
+		c.Error(appError(err, "Failed to override tenant reputation"))
+		return
+	}
+
+	status, err := h.monitor.Status(ctx, tenantID)
+	if err != nil {
+		h.log.Error("Failed to get tenant reputation", "error", err, "tenant_id", tenantID)
+		c.Error(appError(err, "Failed to get tenant reputation"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Reputation override applied",
+		"data":    status,
+	})
+}
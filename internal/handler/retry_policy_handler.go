@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/middleware"
+	"github.com/vhvplatform/go-notification-service/internal/retry"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// RetryPolicyHandler reports the retry policies in effect for a tenant
+type RetryPolicyHandler struct {
+	policies *retry.Resolver
+	log      *logger.Logger
+}
+
+// NewRetryPolicyHandler creates a new retry policy handler
+func NewRetryPolicyHandler(policies *retry.Resolver, log *logger.Logger) *RetryPolicyHandler {
+	return &RetryPolicyHandler{
+		policies: policies,
+		log:      log,
+	}
+}
+
+// GetRetryPolicies reports the tenant's effective retry policy for each channel
+func (h *RetryPolicyHandler) GetRetryPolicies(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+
+	report, err := h.policies.Report(c.Request.Context(), tenantID)
+	if err != nil {
+		h.log.Error("Failed to get retry policies", "error", err, "tenant_id", tenantID)
+		c.Error(appError(err, "Failed to get retry policies"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": report,
+	})
+}
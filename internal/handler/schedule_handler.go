@@ -1,156 +1,210 @@
-package handler
-
-import (
-	"net/http"
-	"strconv"
-	"time"
-
-	"github.com/gin-gonic/gin"
-	"github.com/robfig/cron/v3"
-	"github.com/vhvplatform/go-notification-service/internal/domain"
-	"github.com/vhvplatform/go-notification-service/internal/middleware"
-	"github.com/vhvplatform/go-notification-service/internal/repository"
-	"github.com/vhvplatform/go-notification-service/internal/scheduler"
-	"github.com/vhvplatform/go-notification-service/internal/shared/errors"
-	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
-)
-
-// ScheduleHandler handles scheduled notification requests
-type ScheduleHandler struct {
-	repo      *repository.ScheduledNotificationRepository
-	scheduler *scheduler.NotificationScheduler
-	log       *logger.Logger
-}
-
-// NewScheduleHandler creates a new schedule handler
-func NewScheduleHandler(repo *repository.ScheduledNotificationRepository, scheduler *scheduler.NotificationScheduler, log *logger.Logger) *ScheduleHandler {
-	return &ScheduleHandler{
-		repo:      repo,
-		scheduler: scheduler,
-		log:       log,
-	}
-}
-
-// GetSchedules retrieves scheduled notifications
-func (h *ScheduleHandler) GetSchedules(c *gin.Context) {
-	// Extract tenant_id from authenticated context
-	tenantID := middleware.MustGetTenantID(c)
-
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
-
-	schedules, total, err := h.repo.FindByTenantID(c.Request.Context(), tenantID, page, pageSize)
-	if err != nil {
-		h.log.Error("Failed to get schedules", "error", err, "tenant_id", tenantID)
-		c.JSON(http.StatusInternalServerError, errors.NewInternalError("Failed to get schedules", err))
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"data":      schedules,
-		"total":     total,
-		"page":      page,
-		"page_size": pageSize,
-	})
-}
-
-// CreateSchedule creates a new scheduled notification
-func (h *ScheduleHandler) CreateSchedule(c *gin.Context) {
-	// Extract tenant_id from authenticated context
-	tenantID := middleware.MustGetTenantID(c)
-
-	var sched domain.ScheduledNotification
-	if err := c.ShouldBindJSON(&sched); err != nil {
-		c.JSON(http.StatusBadRequest, errors.NewValidationError("Invalid request", err))
-		return
-	}
-
-	// Set tenant_id from authenticated context
-	sched.TenantID = tenantID
-
-	// Validate cron expression
-	parser := cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
-	schedule, err := parser.Parse(sched.Schedule)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, errors.NewValidationError("Invalid cron expression", err))
-		return
-	}
-
-	// Set next run time
-	sched.NextRunAt = schedule.Next(time.Now())
-	sched.IsActive = true
-
-	// Add schedule
-	if err := h.scheduler.AddSchedule(&sched); err != nil {
-		h.log.Error("Failed to create schedule", "error", err, "tenant_id", tenantID)
-		c.JSON(http.StatusInternalServerError, errors.NewInternalError("Failed to create schedule", err))
-		return
-	}
-
-	c.JSON(http.StatusCreated, gin.H{
-		"message": "Schedule created successfully",
-		"data":    sched,
-	})
-}
-
-// UpdateSchedule updates a scheduled notification
-func (h *ScheduleHandler) UpdateSchedule(c *gin.Context) {
-	id := c.Param("id")
-
-	var sched domain.ScheduledNotification
-	if err := c.ShouldBindJSON(&sched); err != nil {
-		c.JSON(http.StatusBadRequest, errors.NewValidationError("Invalid request", err))
-		return
-	}
-
-	// Validate cron expression if changed
-	if sched.Schedule != "" {
-		parser := cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
-		schedule, err := parser.Parse(sched.Schedule)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, errors.NewValidationError("Invalid cron expression", err))
-			return
-		}
-		sched.NextRunAt = schedule.Next(time.Now())
-	}
-
-	// Get existing schedule
-	existing, err := h.repo.FindByID(c.Request.Context(), id)
-	if err != nil {
-		h.log.Error("Failed to find schedule", "error", err)
-		c.JSON(http.StatusNotFound, errors.NewNotFoundError("Schedule not found", err))
-		return
-	}
-
-	// Update fields
-	existing.Schedule = sched.Schedule
-	existing.Request = sched.Request
-	existing.IsActive = sched.IsActive
-	existing.NextRunAt = sched.NextRunAt
-
-	if err := h.repo.Update(c.Request.Context(), existing); err != nil {
-		h.log.Error("Failed to update schedule", "error", err)
-		c.JSON(http.StatusInternalServerError, errors.NewInternalError("Failed to update schedule", err))
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Schedule updated successfully",
-		"data":    existing,
-	})
-}
-
-// DeleteSchedule deletes a scheduled notification
-func (h *ScheduleHandler) DeleteSchedule(c *gin.Context) {
-	id := c.Param("id")
-
-	if err := h.scheduler.RemoveSchedule(id); err != nil {
-		h.log.Error("Failed to delete schedule", "error", err)
-		c.JSON(http.StatusInternalServerError, errors.NewInternalError("Failed to delete schedule", err))
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Schedule deleted successfully",
-	})
-}
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/middleware"
+	"github.com/vhvplatform/go-notification-service/internal/repository"
+	"github.com/vhvplatform/go-notification-service/internal/scheduler"
+	"github.com/vhvplatform/go-notification-service/internal/shared/errors"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// defaultSchedulePreviewRuns is how many run times ValidateSchedule returns when no count is given
+const defaultSchedulePreviewRuns = 5
+
+// ScheduleHandler handles scheduled notification requests
+type ScheduleHandler struct {
+	repo          *repository.ScheduledNotificationRepository
+	executionRepo *repository.ScheduleExecutionRepository
+	scheduler     *scheduler.NotificationScheduler
+	log           *logger.Logger
+}
+
+// NewScheduleHandler creates a new schedule handler
+func NewScheduleHandler(repo *repository.ScheduledNotificationRepository, executionRepo *repository.ScheduleExecutionRepository, scheduler *scheduler.NotificationScheduler, log *logger.Logger) *ScheduleHandler {
+	return &ScheduleHandler{
+		repo:          repo,
+		executionRepo: executionRepo,
+		scheduler:     scheduler,
+		log:           log,
+	}
+}
+
+// GetSchedules retrieves scheduled notifications
+func (h *ScheduleHandler) GetSchedules(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+
+	page, pageSize := pageQuery(c)
+
+	schedules, total, err := h.repo.FindByTenantID(c.Request.Context(), tenantID, page, pageSize)
+	if err != nil {
+		h.log.Error("Failed to get schedules", "error", err, "tenant_id", tenantID)
+		c.Error(appError(err, "Failed to get schedules"))
+		return
+	}
+
+	c.JSON(http.StatusOK, NewPaginatedResponse(schedules, total, page, pageSize))
+}
+
+// CreateSchedule creates a new scheduled notification
+func (h *ScheduleHandler) CreateSchedule(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+
+	var sched domain.ScheduledNotification
+	if err := c.ShouldBindJSON(&sched); err != nil {
+		c.Error(errors.NewBindingError(err))
+		return
+	}
+	if !checkTenantID(c, sched.TenantID) {
+		return
+	}
+
+	// Set tenant_id from authenticated context
+	sched.TenantID = tenantID
+
+	// Validate cron expression
+	expr, err := h.scheduler.ParseExpression(sched.Schedule, sched.Timezone)
+	if err != nil {
+		c.Error(errors.NewValidationError("Invalid cron expression", err))
+		return
+	}
+
+	// Set next run time
+	sched.NextRunAt = expr.Schedule.Next(time.Now())
+	sched.IsActive = true
+
+	// Add schedule
+	if err := h.scheduler.AddSchedule(&sched); err != nil {
+		h.log.Error("Failed to create schedule", "error", err, "tenant_id", tenantID)
+		c.Error(appError(err, "Failed to create schedule"))
+		return
+	}
+	c.Set(middleware.AuditResourceIDKey, sched.ID.Hex())
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Schedule created successfully",
+		"data":    sched,
+	})
+}
+
+// UpdateSchedule updates a scheduled notification
+func (h *ScheduleHandler) UpdateSchedule(c *gin.Context) {
+	id := c.Param("id")
+
+	var sched domain.ScheduledNotification
+	if err := c.ShouldBindJSON(&sched); err != nil {
+		c.Error(errors.NewBindingError(err))
+		return
+	}
+
+	// Validate cron expression if changed
+	if sched.Schedule != "" {
+		expr, err := h.scheduler.ParseExpression(sched.Schedule, sched.Timezone)
+		if err != nil {
+			c.Error(errors.NewValidationError("Invalid cron expression", err))
+			return
+		}
+		sched.NextRunAt = expr.Schedule.Next(time.Now())
+	}
+
+	// Get existing schedule
+	existing, err := h.repo.FindByID(c.Request.Context(), id)
+	if err != nil {
+		h.log.Error("Failed to find schedule", "error", err)
+		c.Error(errors.NewNotFoundError("Schedule not found", err))
+		return
+	}
+
+	// Update fields
+	existing.Schedule = sched.Schedule
+	existing.Timezone = sched.Timezone
+	existing.Request = sched.Request
+	existing.IsActive = sched.IsActive
+	existing.NextRunAt = sched.NextRunAt
+
+	if err := h.repo.Update(c.Request.Context(), existing); err != nil {
+		h.log.Error("Failed to update schedule", "error", err)
+		c.Error(appError(err, "Failed to update schedule"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Schedule updated successfully",
+		"data":    existing,
+	})
+}
+
+// ValidateSchedule checks a cron expression and previews its next run times without saving anything
+// An invalid expression is reported in the response body rather than as an error.
+func (h *ScheduleHandler) ValidateSchedule(c *gin.Context) {
+	var req domain.ValidateScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBindingError(err))
+		return
+	}
+	if req.Count == 0 {
+		req.Count = defaultSchedulePreviewRuns
+	}
+
+	resp := domain.ValidateScheduleResponse{
+		Expression: req.Expression,
+		Timezone:   req.Timezone,
+	}
+	expr, err := h.scheduler.ParseExpression(req.Expression, req.Timezone)
+	if err != nil {
+		resp.Error = err.Error()
+		c.JSON(http.StatusOK, gin.H{"data": resp})
+		return
+	}
+
+	resp.Valid = true
+	resp.Format = expr.Format
+	resp.HasSeconds = expr.Format == scheduler.FormatSixField
+	resp.Timezone = expr.Location.String()
+	resp.NextRuns = expr.Next(time.Now(), req.Count)
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+// GetScheduleHistory retrieves a scheduled notification's past runs, newest first
+func (h *ScheduleHandler) GetScheduleHistory(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+	id := c.Param("id")
+
+	if _, err := h.repo.FindByID(c.Request.Context(), id, tenantID); err != nil {
+		c.Error(errors.NewNotFoundError("Schedule not found", err))
+		return
+	}
+
+	page, pageSize := pageQuery(c)
+
+	executions, total, err := h.executionRepo.FindBySchedule(c.Request.Context(), tenantID, id, page, pageSize)
+	if err != nil {
+		h.log.Error("Failed to get schedule history", "error", err, "id", id, "tenant_id", tenantID)
+		c.Error(appError(err, "Failed to get schedule history"))
+		return
+	}
+
+	c.JSON(http.StatusOK, NewPaginatedResponse(executions, total, page, pageSize))
+}
+
+// DeleteSchedule deletes a scheduled notification
+func (h *ScheduleHandler) DeleteSchedule(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.scheduler.RemoveSchedule(id); err != nil {
+		h.log.Error("Failed to delete schedule", "error", err)
+		c.Error(appError(err, "Failed to delete schedule"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Schedule deleted successfully",
+	})
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/selftest"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// SelfTestHandler runs the send path self-test for operators
+type SelfTestHandler struct {
+	runner *selftest.Runner
+	log    *logger.Logger
+}
+
+// NewSelfTestHandler creates a new self-test handler
+func NewSelfTestHandler(runner *selftest.Runner, log *logger.Logger) *SelfTestHandler {
+	return &SelfTestHandler{
+		runner: runner,
+		log:    log,
+	}
+}
+
+// RunSelfTest sends a test email through the configured provider and reports each step.
+// Responds 200 when every step passed and 503 Service Unavailable otherwise, with the report
+// under data either way.
+func (h *SelfTestHandler) RunSelfTest(c *gin.Context) {
+	report := h.runner.Run(c.Request.Context())
+
+	status := http.StatusOK
+	if !report.Passed {
+		status = http.StatusServiceUnavailable
+		h.log.Warn("Self-test failed", "provider", report.Provider, "notification_id", report.NotificationID, "steps", report.Steps)
+	} else {
+		h.log.Info("Self-test passed", "provider", report.Provider, "notification_id", report.NotificationID)
+	}
+	c.JSON(status, gin.H{"data": report})
+}
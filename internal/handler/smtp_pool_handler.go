@@ -0,0 +1,68 @@
+package handler
+
+import (
+	stderrors "errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/email"
+	"github.com/vhvplatform/go-notification-service/internal/shared/errors"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// PoolResizer interface for resizing the email providers' connection pools
+type PoolResizer interface {
+	PoolSizes() map[string]int
+	ResizePools(size int) (map[string]int, error)
+}
+
+// SMTPPoolHandler handles the administrative SMTP connection pool endpoints
+type SMTPPoolHandler struct {
+	pools PoolResizer
+	log   *logger.Logger
+}
+
+// NewSMTPPoolHandler creates a new SMTP pool handler
+func NewSMTPPoolHandler(pools PoolResizer, log *logger.Logger) *SMTPPoolHandler {
+	return &SMTPPoolHandler{
+		pools: pools,
+		log:   log,
+	}
+}
+
+// GetPool reports the pool size of each provider with a connection pool
+func (h *SMTPPoolHandler) GetPool(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"data": h.pools.PoolSizes(),
+	})
+}
+
+// ResizePool changes the number of pooled connections without a restart. Sends in flight
+// finish on their connections; the new size lasts until the service restarts, when
+// SMTP_POOL_SIZE applies again.
+func (h *SMTPPoolHandler) ResizePool(c *gin.Context) {
+	var req domain.ResizeSMTPPoolRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBindingError(err))
+		return
+	}
+
+	sizes, err := h.pools.ResizePools(req.Size)
+	if stderrors.Is(err, email.ErrNoConnectionPool) {
+		c.Error(errors.NewNotFoundError("No email provider has a connection pool", err))
+		return
+	}
+	if err != nil {
+		// The pool is resized even when some of its new connections failed to open
+		h.log.Warn("SMTP pool resized with errors", "error", err, "size", req.Size)
+		c.Error(errors.NewInternalError("SMTP pool resized, but some connections failed to open", err).WithField("data", sizes))
+		return
+	}
+
+	h.log.Info("Resized SMTP pool", "size", req.Size, "pools", sizes)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "SMTP pool resized",
+		"data":    sizes,
+	})
+}
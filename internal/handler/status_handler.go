@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/middleware"
+	"github.com/vhvplatform/go-notification-service/internal/shared/errors"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// NotificationStatusFinder interface for looking up the current status of many notifications
+type NotificationStatusFinder interface {
+	FindStatuses(ctx context.Context, tenantID string, ids []string) ([]*domain.NotificationStatusResult, error)
+}
+
+// StatusHandler handles polling the status of many notifications at once
+type StatusHandler struct {
+	notifications NotificationStatusFinder
+	log           *logger.Logger
+}
+
+// NewStatusHandler creates a new status handler
+func NewStatusHandler(notifications NotificationStatusFinder, log *logger.Logger) *StatusHandler {
+	return &StatusHandler{
+		notifications: notifications,
+		log:           log,
+	}
+}
+
+// GetStatuses returns the current status of up to domain.MaxStatusBatchSize notifications,
+// so clients can reconcile a batch of sends in one request instead of one per notification.
+// Results follow the order of the requested IDs; IDs not found for the tenant are listed separately.
+func (h *StatusHandler) GetStatuses(c *gin.Context) {
+	tenantID := middleware.MustGetTenantID(c)
+
+	var req domain.NotificationStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBindingError(err))
+		return
+	}
+
+	results, err := h.notifications.FindStatuses(c.Request.Context(), tenantID, req.IDs)
+	if err != nil {
+		h.log.Error("Failed to get notification statuses", "error", err, "tenant_id", tenantID, "count", len(req.IDs))
+		c.Error(appError(err, "Failed to get notification statuses"))
+		return
+	}
+
+	byID := make(map[string]*domain.NotificationStatusResult, len(results))
+	for _, result := range results {
+		byID[result.ID.Hex()] = result
+	}
+	response := domain.NotificationStatusResponse{
+		Data:     make([]*domain.NotificationStatusResult, 0, len(results)),
+		NotFound: []string{},
+	}
+	seen := make(map[string]bool, len(req.IDs))
+	for _, id := range req.IDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		// Hex IDs are case-insensitive, but stored IDs print in lowercase
+		if result, ok := byID[strings.ToLower(id)]; ok {
+			response.Data = append(response.Data, result)
+		} else {
+			response.NotFound = append(response.NotFound, id)
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/middleware"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// statusStore returns the statuses of its notifications that were asked for, in no particular order
+type statusStore struct {
+	statuses []*domain.NotificationStatusResult
+	tenantID string
+}
+
+func (s *statusStore) FindStatuses(ctx context.Context, tenantID string, ids []string) ([]*domain.NotificationStatusResult, error) {
+	s.tenantID = tenantID
+	var results []*domain.NotificationStatusResult
+	for i := len(s.statuses) - 1; i >= 0; i-- {
+		for _, id := range ids {
+			if strings.EqualFold(id, s.statuses[i].ID.Hex()) {
+				results = append(results, s.statuses[i])
+				break
+			}
+		}
+	}
+	return results, nil
+}
+
+// pollStatuses posts ids to a status handler backed by store
+func pollStatuses(t *testing.T, store *statusStore, ids []string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.ErrorHandlerMiddleware(), middleware.TenancyMiddleware())
+	router.POST("/statuses", NewStatusHandler(store, logger.NewLogger()).GetStatuses)
+
+	body, err := json.Marshal(domain.NotificationStatusRequest{IDs: ids})
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/statuses", bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set(middleware.TenantIDHeader, "tenant-1")
+	router.ServeHTTP(w, r)
+	return w
+}
+
+func TestGetStatusesFollowsRequestOrder(t *testing.T) {
+	sent := &domain.NotificationStatusResult{ID: primitive.NewObjectID(), Status: domain.NotificationStatusSent}
+	failed := &domain.NotificationStatusResult{ID: primitive.NewObjectID(), Status: domain.NotificationStatusFailed}
+	store := &statusStore{statuses: []*domain.NotificationStatusResult{sent, failed}}
+	missing := primitive.NewObjectID().Hex()
+
+	// Duplicates are reported once, and an uppercase ID matches its notification
+	w := pollStatuses(t, store, []string{failed.ID.Hex(), "not-an-id", strings.ToUpper(sent.ID.Hex()), missing, failed.ID.Hex()})
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	if store.tenantID != "tenant-1" {
+		t.Errorf("statuses read for tenant %q, want tenant-1", store.tenantID)
+	}
+	var resp domain.NotificationStatusResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if len(resp.Data) != 2 || resp.Data[0].ID != failed.ID || resp.Data[1].ID != sent.ID {
+		t.Errorf("data = %+v, want the failed then the sent notification", resp.Data)
+	}
+	if got := strings.Join(resp.NotFound, ","); got != "not-an-id,"+missing {
+		t.Errorf("not_found = %q, want not-an-id,%s", got, missing)
+	}
+}
+
+func TestGetStatusesEncodesEmptyResultsAsArrays(t *testing.T) {
+	w := pollStatuses(t, &statusStore{}, []string{"not-an-id"})
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	if want := `{"data":[],"not_found":["not-an-id"]}`; w.Body.String() != want {
+		t.Errorf("body = %s, want %s", w.Body.String(), want)
+	}
+}
+
+func TestGetStatusesRejectsInvalidBatches(t *testing.T) {
+	tooMany := make([]string, domain.MaxStatusBatchSize+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("id-%d", i)
+	}
+
+	for name, ids := range map[string][]string{
+		"no ids":   nil,
+		"empty id": {""},
+		"too many": tooMany,
+	} {
+		t.Run(name, func(t *testing.T) {
+			if w := pollStatuses(t, &statusStore{}, ids); w.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	stderrors "errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/middleware"
+	"github.com/vhvplatform/go-notification-service/internal/repository"
+	"github.com/vhvplatform/go-notification-service/internal/shared/errors"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+	"github.com/vhvplatform/go-notification-service/internal/signedlink"
+	"github.com/vhvplatform/go-notification-service/internal/webview"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ViewHandler serves hosted "view in browser" copies of sent emails
+type ViewHandler struct {
+	notifications *repository.NotificationRepository
+	signer        *webview.Signer
+	log           *logger.Logger
+}
+
+// NewViewHandler creates a new view handler
+func NewViewHandler(notifications *repository.NotificationRepository, signer *webview.Signer, log *logger.Logger) *ViewHandler {
+	return &ViewHandler{
+		notifications: notifications,
+		signer:        signer,
+		log:           log,
+	}
+}
+
+// View serves an email's stored body through a signed link, without API credentials.
+// The link's tenant_id, expires and signature query parameters must match what GetViewLink issued.
+// Emails whose content was redacted are gone, even while their link is unexpired.
+func (h *ViewHandler) View(c *gin.Context) {
+	id := c.Param("notificationID")
+	tenantID := c.Query("tenant_id")
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err == nil {
+		err = h.signer.Verify(tenantID, id, expires, c.Query(signedlink.SignatureParam))
+	}
+	if err != nil {
+		h.log.Warn("Rejected view link", "error", err, "tenant_id", tenantID, "id", id)
+		c.Error(errors.NewUnauthorizedError("Invalid or expired view link", err))
+		return
+	}
+
+	notification, err := h.notifications.FindByID(c.Request.Context(), id, tenantID)
+	if err == mongo.ErrNoDocuments {
+		c.Error(errors.NewNotFoundError("Notification not found", err))
+		return
+	}
+	if err != nil {
+		h.log.Error("Failed to get notification", "error", err, "tenant_id", tenantID, "id", id)
+		c.Error(appError(err, "Failed to get notification"))
+		return
+	}
+
+	page, err := webview.Render(notification)
+	if stderrors.Is(err, webview.ErrContentRemoved) {
+		c.Error(errors.NewGoneError("This email's content is no longer available", err))
+		return
+	}
+	if err != nil {
+		c.Error(errors.NewNotFoundError("Notification not found", err))
+		return
+	}
+
+	webview.SetHeaders(c.Writer.Header())
+	c.Data(http.StatusOK, page.ContentType, page.Body)
+}
+
+// GetViewLink returns a signed link to an email's hosted copy, for embedding in its content
+func (h *ViewHandler) GetViewLink(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+	id := c.Param("id")
+
+	if _, err := primitive.ObjectIDFromHex(id); err != nil {
+		c.Error(errors.NewValidationError("Invalid notification ID", err))
+		return
+	}
+
+	notification, err := h.notifications.FindByID(c.Request.Context(), id, tenantID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.Error(errors.NewNotFoundError("Notification not found", err))
+			return
+		}
+		h.log.Error("Failed to get notification", "error", err, "tenant_id", tenantID, "id", id)
+		c.Error(appError(err, "Failed to get notification"))
+		return
+	}
+	if notification.Type != domain.NotificationTypeEmail {
+		c.Error(errors.NewValidationError("Only emails can be viewed in a browser", nil))
+		return
+	}
+
+	url, expiresAt := h.signer.Link(tenantID, id)
+	c.JSON(http.StatusOK, gin.H{"data": domain.ViewLink{URL: url, ExpiresAt: expiresAt}})
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/middleware"
+	"github.com/vhvplatform/go-notification-service/internal/repository"
+	"github.com/vhvplatform/go-notification-service/internal/shared/errors"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+	"github.com/vhvplatform/go-notification-service/internal/webhook"
+)
+
+// WebhookAllowlistHandler handles the tenant's webhook destination allowlist
+type WebhookAllowlistHandler struct {
+	repo *repository.WebhookAllowlistRepository
+	log  *logger.Logger
+}
+
+// NewWebhookAllowlistHandler creates a new webhook allowlist handler
+func NewWebhookAllowlistHandler(repo *repository.WebhookAllowlistRepository, log *logger.Logger) *WebhookAllowlistHandler {
+	return &WebhookAllowlistHandler{
+		repo: repo,
+		log:  log,
+	}
+}
+
+// GetAllowlist returns the tenant's allowed webhook domains; an empty list allows any destination
+func (h *WebhookAllowlistHandler) GetAllowlist(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+
+	allowlist, err := h.repo.FindWebhookAllowlist(c.Request.Context(), tenantID)
+	if err != nil {
+		h.log.Error("Failed to get webhook allowlist", "error", err, "tenant_id", tenantID)
+		c.Error(appError(err, "Failed to get webhook allowlist"))
+		return
+	}
+	if allowlist == nil {
+		allowlist = &domain.WebhookAllowlist{TenantID: tenantID, Domains: []string{}}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": allowlist})
+}
+
+// UpdateAllowlist replaces the tenant's allowed webhook domains
+func (h *WebhookAllowlistHandler) UpdateAllowlist(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+
+	var req domain.UpdateWebhookAllowlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBindingError(err))
+		return
+	}
+
+	domains, err := webhook.NormalizeDomains(req.Domains)
+	if err != nil {
+		c.Error(errors.NewValidationError("Invalid domain", err))
+		return
+	}
+
+	allowlist, err := h.repo.Upsert(c.Request.Context(), tenantID, domains)
+	if err != nil {
+		h.log.Error("Failed to update webhook allowlist", "error", err, "tenant_id", tenantID)
+		c.Error(appError(err, "Failed to update webhook allowlist"))
+		return
+	}
+
+	h.log.Info("Updated webhook allowlist", "tenant_id", tenantID, "domains", len(allowlist.Domains))
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Webhook allowlist updated successfully",
+		"data":    allowlist,
+	})
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/middleware"
+	"github.com/vhvplatform/go-notification-service/internal/repository"
+	"github.com/vhvplatform/go-notification-service/internal/shared/errors"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+	"github.com/vhvplatform/go-notification-service/internal/webhook"
+)
+
+// WebhookFallbackHandler handles the tenant's fallback channel for failed webhooks
+type WebhookFallbackHandler struct {
+	repo *repository.WebhookFallbackRepository
+	log  *logger.Logger
+}
+
+// NewWebhookFallbackHandler creates a new webhook fallback handler
+func NewWebhookFallbackHandler(repo *repository.WebhookFallbackRepository, log *logger.Logger) *WebhookFallbackHandler {
+	return &WebhookFallbackHandler{
+		repo: repo,
+		log:  log,
+	}
+}
+
+// GetFallback returns the tenant's webhook fallback
+func (h *WebhookFallbackHandler) GetFallback(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+
+	fallback, err := h.repo.FindWebhookFallback(c.Request.Context(), tenantID)
+	if err != nil {
+		h.log.Error("Failed to get webhook fallback", "error", err, "tenant_id", tenantID)
+		c.Error(appError(err, "Failed to get webhook fallback"))
+		return
+	}
+	if fallback == nil {
+		c.Error(errors.NewNotFoundError("Webhook fallback not set", nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": fallback})
+}
+
+// UpdateFallback replaces the tenant's webhook fallback
+func (h *WebhookFallbackHandler) UpdateFallback(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+
+	var req domain.FallbackChannel
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBindingError(err))
+		return
+	}
+	if err := webhook.NormalizeFallback(&req); err != nil {
+		c.Error(errors.NewValidationError("Invalid fallback", err))
+		return
+	}
+
+	fallback, err := h.repo.Upsert(c.Request.Context(), tenantID, req)
+	if err != nil {
+		h.log.Error("Failed to update webhook fallback", "error", err, "tenant_id", tenantID)
+		c.Error(appError(err, "Failed to update webhook fallback"))
+		return
+	}
+
+	h.log.Info("Updated webhook fallback", "tenant_id", tenantID, "type", fallback.Channel.Type)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Webhook fallback updated successfully",
+		"data":    fallback,
+	})
+}
+
+// DeleteFallback removes the tenant's webhook fallback
+func (h *WebhookFallbackHandler) DeleteFallback(c *gin.Context) {
+	// Extract tenant_id from authenticated context
+	tenantID := middleware.MustGetTenantID(c)
+
+	deleted, err := h.repo.Delete(c.Request.Context(), tenantID)
+	if err != nil {
+		h.log.Error("Failed to delete webhook fallback", "error", err, "tenant_id", tenantID)
+		c.Error(appError(err, "Failed to delete webhook fallback"))
+		return
+	}
+	if !deleted {
+		c.Error(errors.NewNotFoundError("Webhook fallback not set", nil))
+		return
+	}
+
+	h.log.Info("Deleted webhook fallback", "tenant_id", tenantID)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Webhook fallback deleted successfully",
+	})
+}
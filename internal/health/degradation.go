@@ -0,0 +1,176 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/metrics"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// Dependencies tracked by the degradation controller
+const (
+	DependencyMongoDB = "mongodb"
+	DependencySMTP    = "smtp"
+)
+
+// DegradationConfig controls when a dependency is considered degraded
+type DegradationConfig struct {
+	Window      time.Duration // Period over which the error rate is measured
+	Threshold   float64       // Failure ratio (0-1] that trips degraded mode
+	MinRequests int           // Samples required in a window before it can trip
+	Cooldown    time.Duration // Time spent degraded before traffic is attempted again
+}
+
+// DependencyStatus is the reported state of a single dependency
+type DependencyStatus struct {
+	Degraded    bool      `json:"degraded"`
+	Until       time.Time `json:"until,omitempty"`
+	Successes   int       `json:"successes"`
+	Failures    int       `json:"failures"`
+	WindowStart time.Time `json:"window_start"`
+}
+
+// dependencyState holds the current window counts and degraded deadline
+type dependencyState struct {
+	windowStart   time.Time
+	successes     int
+	failures      int
+	degradedUntil time.Time
+}
+
+// DegradationController tracks dependency error rates and switches them into degraded
+// mode when the failure ratio exceeds the threshold. A degraded dependency recovers
+// after the cooldown and trips again if failures continue, like a circuit breaker.
+type DegradationController struct {
+	config DegradationConfig
+	mu     sync.Mutex
+	deps   map[string]*dependencyState
+	now    func() time.Time
+}
+
+// NewDegradationController creates a new degradation controller
+func NewDegradationController(config DegradationConfig) *DegradationController {
+	return &DegradationController{
+		config: config,
+		deps:   make(map[string]*dependencyState),
+		now:    time.Now,
+	}
+}
+
+// Record records the outcome of a call to a dependency
+func (d *DegradationController) Record(dependency string, failed bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	state := d.state(dependency, now)
+
+	// Outcomes seen while degraded come from requests already in flight
+	if now.Before(state.degradedUntil) {
+		return
+	}
+
+	if failed {
+		state.failures++
+	} else {
+		state.successes++
+	}
+
+	total := state.successes + state.failures
+	if total < d.config.MinRequests {
+		return
+	}
+
+	if float64(state.failures)/float64(total) >= d.config.Threshold {
+		state.degradedUntil = now.Add(d.config.Cooldown)
+		state.windowStart = state.degradedUntil
+		state.successes, state.failures = 0, 0
+		metrics.DependencyDegraded.WithLabelValues(dependency).Set(1)
+	}
+}
+
+// Degraded reports whether a dependency is degraded and how long until it is retried
+func (d *DegradationController) Degraded(dependency string) (bool, time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	state := d.state(dependency, now)
+	if now.Before(state.degradedUntil) {
+		return true, state.degradedUntil.Sub(now)
+	}
+	return false, 0
+}
+
+// MongoMonitor returns a command monitor that records successful MongoDB commands. A failed
+// command, such as a duplicate key or validation error, was still answered by the server, so
+// it isn't recorded as a failure; outages are recorded by MongoServerMonitor.
+func (d *DegradationController) MongoMonitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Succeeded: func(context.Context, *event.CommandSucceededEvent) {
+			d.Record(DependencyMongoDB, false)
+		},
+	}
+}
+
+// MongoServerMonitor returns a server monitor that records MongoDB heartbeat outcomes.
+// Heartbeats keep failing while a server is unreachable, including while operations fail
+// server selection and never send a command.
+func (d *DegradationController) MongoServerMonitor() *event.ServerMonitor {
+	return &event.ServerMonitor{
+		ServerHeartbeatSucceeded: func(*event.ServerHeartbeatSucceededEvent) {
+			d.Record(DependencyMongoDB, false)
+		},
+		ServerHeartbeatFailed: func(*event.ServerHeartbeatFailedEvent) {
+			d.Record(DependencyMongoDB, true)
+		},
+	}
+}
+
+// Status returns the state of every tracked dependency
+func (d *DegradationController) Status() map[string]DependencyStatus {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	status := make(map[string]DependencyStatus, len(d.deps))
+	for name := range d.deps {
+		state := d.state(name, now)
+		s := DependencyStatus{
+			Degraded:    now.Before(state.degradedUntil),
+			Successes:   state.successes,
+			Failures:    state.failures,
+			WindowStart: state.windowStart,
+		}
+		if s.Degraded {
+			s.Until = state.degradedUntil
+		}
+		status[name] = s
+	}
+	return status
+}
+
+// state returns the dependency state, starting a new window when the current one has elapsed
+// Must be called with d.mu held.
+func (d *DegradationController) state(dependency string, now time.Time) *dependencyState {
+	state, ok := d.deps[dependency]
+	if !ok {
+		state = &dependencyState{windowStart: now}
+		d.deps[dependency] = state
+		metrics.DependencyDegraded.WithLabelValues(dependency).Set(0)
+		return state
+	}
+
+	if !state.degradedUntil.IsZero() && !now.Before(state.degradedUntil) {
+		state.degradedUntil = time.Time{}
+		metrics.DependencyDegraded.WithLabelValues(dependency).Set(0)
+	}
+
+	if now.Sub(state.windowStart) >= d.config.Window {
+		state.windowStart = now
+		state.successes, state.failures = 0, 0
+	}
+	return state
+}
@@ -0,0 +1,113 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/event"
+)
+
+func newTestController(now *time.Time) *DegradationController {
+	d := NewDegradationController(DegradationConfig{
+		Window:      10 * time.Second,
+		Threshold:   0.5,
+		MinRequests: 4,
+		Cooldown:    30 * time.Second,
+	})
+	d.now = func() time.Time { return *now }
+	return d
+}
+
+func TestDegradationTripsAndRecovers(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	d := newTestController(&now)
+
+	// Below the minimum sample size, failures don't trip
+	for i := 0; i < 3; i++ {
+		d.Record(DependencySMTP, true)
+	}
+	if degraded, _ := d.Degraded(DependencySMTP); degraded {
+		t.Fatal("Degraded() = true before MinRequests samples")
+	}
+
+	d.Record(DependencySMTP, false)
+	degraded, retryAfter := d.Degraded(DependencySMTP)
+	if !degraded {
+		t.Fatal("Degraded() = false after 3/4 failures")
+	}
+	if retryAfter != 30*time.Second {
+		t.Errorf("retry after = %v, want 30s", retryAfter)
+	}
+
+	// Other dependencies are unaffected
+	if degraded, _ := d.Degraded(DependencyMongoDB); degraded {
+		t.Error("Degraded(mongodb) = true, want false")
+	}
+
+	now = now.Add(31 * time.Second)
+	if degraded, _ := d.Degraded(DependencySMTP); degraded {
+		t.Error("Degraded() = true after cooldown")
+	}
+}
+
+func TestDegradationBelowThreshold(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	d := newTestController(&now)
+
+	for i := 0; i < 10; i++ {
+		d.Record(DependencyMongoDB, i%4 == 0)
+	}
+	if degraded, _ := d.Degraded(DependencyMongoDB); degraded {
+		t.Error("Degraded() = true at 30% failures, want false")
+	}
+}
+
+func TestDegradationWindowResets(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	d := newTestController(&now)
+
+	d.Record(DependencySMTP, true)
+	d.Record(DependencySMTP, true)
+	d.Record(DependencySMTP, true)
+
+	// Failures from an elapsed window don't count towards the next one
+	now = now.Add(11 * time.Second)
+	d.Record(DependencySMTP, true)
+	if degraded, _ := d.Degraded(DependencySMTP); degraded {
+		t.Error("Degraded() = true with failures spread across windows")
+	}
+
+	status := d.Status()[DependencySMTP]
+	if status.Failures != 1 || status.Degraded {
+		t.Errorf("Status() = %+v, want 1 failure and not degraded", status)
+	}
+}
+
+func TestMongoMonitors(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	d := newTestController(&now)
+	commands := d.MongoMonitor()
+	servers := d.MongoServerMonitor()
+
+	// Failed commands, such as duplicate keys, were answered by the server
+	if commands.Failed != nil {
+		t.Error("MongoMonitor() records failed commands, want them ignored")
+	}
+	commands.Succeeded(context.Background(), &event.CommandSucceededEvent{})
+
+	// Failing heartbeats trip degraded mode even when no command can be sent
+	for i := 0; i < 3; i++ {
+		servers.ServerHeartbeatFailed(&event.ServerHeartbeatFailedEvent{Failure: errors.New("server selection timeout")})
+	}
+	if degraded, _ := d.Degraded(DependencyMongoDB); !degraded {
+		t.Error("Degraded() = false after 3/4 heartbeats and commands failed")
+	}
+
+	now = now.Add(31 * time.Second)
+	servers.ServerHeartbeatSucceeded(&event.ServerHeartbeatSucceededEvent{})
+	if status := d.Status()[DependencyMongoDB]; status.Degraded || status.Successes != 1 {
+		t.Errorf("status after recovery = %+v, want one success and not degraded", status)
+	}
+}
@@ -0,0 +1,188 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/metrics"
+	"github.com/vhvplatform/go-notification-service/internal/mjml"
+	"github.com/vhvplatform/go-notification-service/internal/repository"
+	"github.com/vhvplatform/go-notification-service/internal/templates"
+)
+
+// batchSize bounds the rows written by one bulk write
+const batchSize = 500
+
+// TemplateStore upserts templates by name
+type TemplateStore interface {
+	UpsertMany(ctx context.Context, tenantID string, templates []*domain.Template) ([]repository.UpsertResult, error)
+}
+
+// PreferencesStore upserts user preferences by user ID
+type PreferencesStore interface {
+	UpsertMany(ctx context.Context, tenantID string, prefs []*domain.NotificationPreferences) ([]repository.UpsertResult, error)
+}
+
+// Options controls an import
+type Options struct {
+	TenantID string // Records are imported for this tenant; rows naming another tenant fail
+	MaxRows  int    // Rows read before the import stops with an error; 0 is unlimited
+}
+
+// Importer validates the records of an import file and upserts them in batches, keyed by
+// template name or user ID, so importing a file again updates what the first import created
+type Importer struct {
+	templates   TemplateStore
+	preferences PreferencesStore
+	compiler    mjml.Compiler // Optional; nil rejects MJML templates
+}
+
+// NewImporter creates a template and preferences importer
+func NewImporter(templates TemplateStore, preferences PreferencesStore) *Importer {
+	return &Importer{
+		templates:   templates,
+		preferences: preferences,
+	}
+}
+
+// SetMJMLCompiler compiles the MJML source of imported templates
+func (i *Importer) SetMJMLCompiler(compiler mjml.Compiler) {
+	i.compiler = compiler
+}
+
+// ImportTemplates validates every template in rows and upserts the valid ones, reporting
+// each row's outcome. A failed row doesn't stop the import; an error is only returned when
+// the file can't be read, a batch can't be written or ctx is done, along with the report so
+// far. Rows before the failure are still imported.
+func (i *Importer) ImportTemplates(ctx context.Context, rows RowReader, opts Options) (*domain.ImportReport, error) {
+	compile := func(t *domain.Template) error {
+		return templates.CompileMJML(ctx, i.compiler, t)
+	}
+	return run(ctx, rows, opts, templateKind(compile), func(ctx context.Context, items []*domain.Template) ([]repository.UpsertResult, error) {
+		return i.templates.UpsertMany(ctx, opts.TenantID, items)
+	})
+}
+
+// ImportPreferences is ImportTemplates for user preferences. Fields a row leaves out take
+// their defaults. validate checks each row's preferences beyond their types; rows it
+// rejects fail.
+func (i *Importer) ImportPreferences(ctx context.Context, rows RowReader, opts Options, validate func(*domain.NotificationPreferences) error) (*domain.ImportReport, error) {
+	return run(ctx, rows, opts, preferencesKind(validate), func(ctx context.Context, items []*domain.NotificationPreferences) ([]repository.UpsertResult, error) {
+		return i.preferences.UpsertMany(ctx, opts.TenantID, items)
+	})
+}
+
+// kind describes one type of imported record
+type kind[T any] struct {
+	name    string   // Metric label
+	columns []string // CSV columns the kind reads
+	// decode returns a row's record for the tenant and its key, which may be set on error
+	decode func(row *Row, tenantID string) (T, string, error)
+}
+
+// run imports rows of kind k, writing batches with write
+func run[T any](ctx context.Context, rows RowReader, opts Options, k kind[T], write func(context.Context, []T) ([]repository.UpsertResult, error)) (*domain.ImportReport, error) {
+	report := &domain.ImportReport{Results: []domain.ImportRowResult{}}
+	settle := func(index int, status, message string) {
+		result := &report.Results[index]
+		result.Status = status
+		result.Error = message
+		switch status {
+		case domain.ImportStatusCreated:
+			report.Created++
+		case domain.ImportStatusUpdated:
+			report.Updated++
+		case domain.ImportStatusFailed:
+			report.Failed++
+		}
+		metrics.RowsImported.WithLabelValues(k.name, status).Inc()
+	}
+
+	// Queued records, and the indexes of their results
+	var batch []T
+	var pending []int
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		results, err := write(ctx, batch)
+		for j, index := range pending {
+			switch {
+			case err != nil:
+				settle(index, domain.ImportStatusFailed, err.Error())
+			case results[j].Err != nil:
+				settle(index, domain.ImportStatusFailed, results[j].Err.Error())
+			case results[j].Created:
+				settle(index, domain.ImportStatusCreated, "")
+			default:
+				settle(index, domain.ImportStatusUpdated, "")
+			}
+		}
+		batch, pending = batch[:0], pending[:0]
+		return err
+	}
+	// stop writes the queued records before ending the import with err
+	stop := func(err error) (*domain.ImportReport, error) {
+		if flushErr := flush(); flushErr != nil {
+			return report, flushErr
+		}
+		return report, err
+	}
+
+	seen := make(map[string]int) // Key to the row that queued it
+	columnsChecked := false
+	for {
+		if err := ctx.Err(); err != nil {
+			return stop(err)
+		}
+		row, err := rows.Next()
+		if err == io.EOF {
+			return stop(nil)
+		}
+		if err != nil {
+			return stop(err)
+		}
+		if opts.MaxRows > 0 && report.Total >= opts.MaxRows {
+			return stop(fmt.Errorf("%w: imports are limited to %d rows", ErrInvalidFile, opts.MaxRows))
+		}
+		if !columnsChecked && row.Fields != nil {
+			columnsChecked = true
+			for column := range row.Fields {
+				if !slices.Contains(k.columns, column) {
+					return stop(fmt.Errorf("%w: unknown column %q (expected some of %v)", ErrInvalidFile, column, k.columns))
+				}
+			}
+		}
+
+		report.Total++
+		index := len(report.Results)
+		report.Results = append(report.Results, domain.ImportRowResult{Row: row.Number})
+		if row.Err != nil {
+			settle(index, domain.ImportStatusFailed, row.Err.Error())
+			continue
+		}
+
+		record, key, err := k.decode(row, opts.TenantID)
+		report.Results[index].Key = key
+		if err != nil {
+			settle(index, domain.ImportStatusFailed, err.Error())
+			continue
+		}
+		if first, ok := seen[key]; ok {
+			settle(index, domain.ImportStatusFailed, fmt.Sprintf("duplicate of row %d", first))
+			continue
+		}
+		seen[key] = row.Number
+
+		batch = append(batch, record)
+		pending = append(pending, index)
+		if len(batch) >= batchSize {
+			if err := flush(); err != nil {
+				return report, err
+			}
+		}
+	}
+}
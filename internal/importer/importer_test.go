@@ -0,0 +1,168 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/repository"
+)
+
+// memoryStore upserts records by key in memory, failing keys listed in fail
+type memoryStore struct {
+	records map[string]bool
+	fail    map[string]bool
+	writes  int
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{records: map[string]bool{}, fail: map[string]bool{}}
+}
+
+func (s *memoryStore) upsert(keys []string) []repository.UpsertResult {
+	s.writes++
+	results := make([]repository.UpsertResult, len(keys))
+	for i, key := range keys {
+		if s.fail[key] {
+			results[i].Err = errors.New("write failed")
+			continue
+		}
+		results[i].Created = !s.records[key]
+		s.records[key] = true
+	}
+	return results
+}
+
+func (s *memoryStore) templateStore() TemplateStore { return templateStore{s} }
+
+type templateStore struct{ *memoryStore }
+
+func (s templateStore) UpsertMany(ctx context.Context, tenantID string, items []*domain.Template) ([]repository.UpsertResult, error) {
+	keys := make([]string, len(items))
+	for i, item := range items {
+		keys[i] = item.Name
+	}
+	return s.upsert(keys), nil
+}
+
+type preferencesStore struct{ *memoryStore }
+
+func (s preferencesStore) UpsertMany(ctx context.Context, tenantID string, items []*domain.NotificationPreferences) ([]repository.UpsertResult, error) {
+	keys := make([]string, len(items))
+	for i, item := range items {
+		if item.TenantID != tenantID || !item.EmailEnabled || item.Timezone == "" {
+			return nil, fmt.Errorf("unexpected preferences %+v", item)
+		}
+		keys[i] = item.UserID
+	}
+	return s.upsert(keys), nil
+}
+
+const templateCSV = `name,channel,subject,body,is_html,variables
+welcome,email,Welcome {{.name}},<p>Hi</p>,true,"name, plan"
+reminder,sms,,Your code is {{.code}},,code
+broken,email,Missing body,,,
+welcome,email,Again,Body,,
+stored,email,Subject,Body,,
+`
+
+func TestImportTemplates(t *testing.T) {
+	store := newMemoryStore()
+	store.records["stored"] = true
+	importer := NewImporter(store.templateStore(), nil)
+
+	report, err := importer.ImportTemplates(context.Background(), NewCSVReader(strings.NewReader(templateCSV)), Options{TenantID: "tenant-1"})
+	if err != nil {
+		t.Fatalf("ImportTemplates() error = %v", err)
+	}
+
+	if report.Total != 5 || report.Created != 2 || report.Updated != 1 || report.Failed != 2 {
+		t.Errorf("report = %+v, want 5 total, 2 created, 1 updated, 2 failed", report)
+	}
+	want := []string{domain.ImportStatusCreated, domain.ImportStatusCreated, domain.ImportStatusFailed, domain.ImportStatusFailed, domain.ImportStatusUpdated}
+	for i, result := range report.Results {
+		if result.Row != i+1 || result.Status != want[i] {
+			t.Errorf("result %d = %+v, want status %s", i, result, want[i])
+		}
+	}
+	if report.Results[3].Error != "duplicate of row 1" {
+		t.Errorf("duplicate row error = %q", report.Results[3].Error)
+	}
+
+	// Importing the same file again updates what the first import created
+	report, _ = importer.ImportTemplates(context.Background(), NewCSVReader(strings.NewReader(templateCSV)), Options{TenantID: "tenant-1"})
+	if report.Created != 0 || report.Updated != 3 {
+		t.Errorf("second import report = %+v, want 3 updated", report)
+	}
+}
+
+func TestImportTemplatesRejectsOtherTenants(t *testing.T) {
+	importer := NewImporter(newMemoryStore().templateStore(), nil)
+	rows := NewJSONReader(strings.NewReader(`[{"tenant_id":"tenant-2","name":"welcome","subject":"Hi","body":"Hi"}]`))
+
+	report, err := importer.ImportTemplates(context.Background(), rows, Options{TenantID: "tenant-1"})
+	if err != nil {
+		t.Fatalf("ImportTemplates() error = %v", err)
+	}
+	if report.Failed != 1 || report.Results[0].Key != "welcome" {
+		t.Errorf("report = %+v, want the other tenant's template failed", report)
+	}
+}
+
+func TestImportTemplatesUnknownColumn(t *testing.T) {
+	importer := NewImporter(newMemoryStore().templateStore(), nil)
+	rows := NewCSVReader(strings.NewReader("name,subjct\nwelcome,Hi\n"))
+
+	if _, err := importer.ImportTemplates(context.Background(), rows, Options{TenantID: "tenant-1"}); !errors.Is(err, ErrInvalidFile) {
+		t.Errorf("ImportTemplates() error = %v, want %v", err, ErrInvalidFile)
+	}
+}
+
+func TestImportPreferences(t *testing.T) {
+	store := newMemoryStore()
+	store.fail["u3"] = true
+	importer := NewImporter(nil, preferencesStore{store})
+
+	var input strings.Builder
+	for i := 1; i <= batchSize+2; i++ {
+		fmt.Fprintf(&input, `{"user_id":"u%d","sms_enabled":false,"email_categories":{"marketing":false}}`+"\n", i)
+	}
+	input.WriteString(`{"user_id":"late","quiet_hours_start":"25:00"}` + "\n")
+	validate := func(prefs *domain.NotificationPreferences) error {
+		if prefs.QuietHoursStart == "25:00" {
+			return errors.New("invalid quiet hours")
+		}
+		return nil
+	}
+
+	report, err := importer.ImportPreferences(context.Background(), NewJSONReader(strings.NewReader(input.String())), Options{TenantID: "tenant-1"}, validate)
+	if err != nil {
+		t.Fatalf("ImportPreferences() error = %v", err)
+	}
+	if report.Total != batchSize+3 || report.Created != batchSize+1 || report.Failed != 2 {
+		t.Errorf("report = created %d, failed %d of %d; want %d created, 2 failed", report.Created, report.Failed, report.Total, batchSize+1)
+	}
+	if store.writes != 2 {
+		t.Errorf("wrote %d batches, want 2", store.writes)
+	}
+	if result := report.Results[2]; result.Key != "u3" || result.Error != "write failed" {
+		t.Errorf("failed write result = %+v", result)
+	}
+}
+
+func TestImportMaxRows(t *testing.T) {
+	store := newMemoryStore()
+	importer := NewImporter(nil, preferencesStore{store})
+	rows := NewCSVReader(strings.NewReader("user_id\nu1\nu2\nu3\n"))
+
+	report, err := importer.ImportPreferences(context.Background(), rows, Options{TenantID: "tenant-1", MaxRows: 2}, nil)
+	if !errors.Is(err, ErrInvalidFile) {
+		t.Fatalf("ImportPreferences() error = %v, want the row limit", err)
+	}
+	if report.Total != 2 || len(store.records) != 2 {
+		t.Errorf("imported %d rows (%d stored) before the limit, want 2", report.Total, len(store.records))
+	}
+}
@@ -0,0 +1,211 @@
+// Package importer bulk imports a tenant's templates and user preferences from JSON or CSV files
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"path"
+	"strings"
+
+	"github.com/vhvplatform/go-notification-service/internal/charset"
+)
+
+// Import file formats
+const (
+	FormatJSON = "json" // A JSON array of objects, or one object per line
+	FormatCSV  = "csv"  // A header row naming the columns, then one record per row
+)
+
+// ErrInvalidFile is returned when an import file can't be read in its format
+var ErrInvalidFile = errors.New("invalid import file")
+
+// utf8BOM starts files saved by some spreadsheet applications
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// Row is one record read from an import file
+type Row struct {
+	Number int               // 1 is the file's first record; a CSV header isn't counted
+	JSON   json.RawMessage   // The record of a JSON file
+	Fields map[string]string // The record of a CSV file, keyed by header column
+	Err    error             // The record couldn't be read; only its row fails
+}
+
+// RowReader reads an import file one record at a time, so large files are never held whole.
+// Next returns io.EOF after the last record.
+type RowReader interface {
+	Next() (*Row, error)
+}
+
+// NewReader creates a reader of a file in format, transcoding it to UTF-8 from fileCharset.
+// Without a charset, UTF-8 and UTF-16 files are detected and any other file is read as
+// Windows-1252 (see charset.NewUTF8Reader).
+func NewReader(format, fileCharset string, r io.Reader) (RowReader, error) {
+	r, err := charset.NewUTF8Reader(r, fileCharset)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+
+	switch format {
+	case FormatJSON:
+		return NewJSONReader(r), nil
+	case FormatCSV:
+		return NewCSVReader(r), nil
+	}
+	return nil, fmt.Errorf("%w: unsupported format %q (must be json or csv)", ErrInvalidFile, format)
+}
+
+// DetectFormat returns a file's format from its name's extension or, failing that, its
+// content type
+func DetectFormat(contentType, filename string) (string, error) {
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".json", ".ndjson", ".jsonl":
+		return FormatJSON, nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/csv", "application/csv":
+		return FormatCSV, nil
+	case "application/json", "application/x-ndjson":
+		return FormatJSON, nil
+	}
+	return "", fmt.Errorf("%w: unknown format of %q; use a .csv or .json file or set format", ErrInvalidFile, contentType)
+}
+
+// jsonReader decodes the objects of a JSON array, or of a stream of JSON values
+type jsonReader struct {
+	reader  *bufio.Reader
+	decoder *json.Decoder
+	started bool
+	array   bool
+	number  int
+}
+
+// NewJSONReader creates a reader of a JSON array of records, or of records one per line
+func NewJSONReader(r io.Reader) RowReader {
+	reader := bufio.NewReader(r)
+	return &jsonReader{reader: reader, decoder: json.NewDecoder(reader)}
+}
+
+// Next decodes the next record. Malformed JSON ends the file, since decoding can't resume.
+func (r *jsonReader) Next() (*Row, error) {
+	if !r.started {
+		r.started = true
+		if err := r.start(); err != nil {
+			return nil, err
+		}
+	}
+	if r.array && !r.decoder.More() {
+		if _, err := r.decoder.Token(); err != nil {
+			return nil, fmt.Errorf("%w: unterminated array: %v", ErrInvalidFile, err)
+		}
+		return nil, io.EOF
+	}
+
+	var record json.RawMessage
+	if err := r.decoder.Decode(&record); err != nil {
+		if err == io.EOF && !r.array {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("%w: record %d: %v", ErrInvalidFile, r.number+1, err)
+	}
+	r.number++
+	return &Row{Number: r.number, JSON: record}, nil
+}
+
+// start skips a byte order mark and leading space, then consumes the opening bracket of an array
+func (r *jsonReader) start() error {
+	if prefix, _ := r.reader.Peek(len(utf8BOM)); bytes.Equal(prefix, utf8BOM) {
+		r.reader.Discard(len(utf8BOM))
+	}
+	for {
+		next, err := r.reader.Peek(1)
+		if err != nil {
+			// An empty file has no records, which Decode reports
+			return nil
+		}
+		switch next[0] {
+		case ' ', '\t', '\r', '\n':
+			r.reader.Discard(1)
+			continue
+		case '[':
+			r.array = true
+			_, err := r.decoder.Token()
+			return err
+		}
+		return nil
+	}
+}
+
+// csvReader reads CSV records keyed by the header row's column names
+type csvReader struct {
+	reader *csv.Reader
+	header []string
+	number int
+}
+
+// NewCSVReader creates a reader of a CSV file whose first row names the columns
+func NewCSVReader(r io.Reader) RowReader {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1 // Rows with the wrong column count fail alone
+	return &csvReader{reader: reader}
+}
+
+// Next reads the next record. Column names are matched case-insensitively.
+func (r *csvReader) Next() (*Row, error) {
+	if r.header == nil {
+		if err := r.readHeader(); err != nil {
+			return nil, err
+		}
+	}
+
+	record, err := r.reader.Read()
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	r.number++
+	if len(record) != len(r.header) {
+		return &Row{Number: r.number, Err: fmt.Errorf("row has %d columns, the header has %d", len(record), len(r.header))}, nil
+	}
+
+	fields := make(map[string]string, len(record))
+	for i, column := range r.header {
+		fields[column] = record[i]
+	}
+	return &Row{Number: r.number, Fields: fields}, nil
+}
+
+// readHeader reads the column names
+func (r *csvReader) readHeader() error {
+	header, err := r.reader.Read()
+	if err == io.EOF {
+		return io.EOF
+	}
+	if err != nil {
+		return fmt.Errorf("%w: header: %v", ErrInvalidFile, err)
+	}
+
+	header[0] = strings.TrimPrefix(header[0], string(utf8BOM))
+	seen := make(map[string]bool, len(header))
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(column))
+		if column == "" || seen[column] {
+			return fmt.Errorf("%w: header has an empty or repeated column %q", ErrInvalidFile, column)
+		}
+		seen[column] = true
+		header[i] = column
+	}
+	r.header = header
+	return nil
+}
@@ -0,0 +1,119 @@
+package importer
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// readAll returns every row of r
+func readAll(t *testing.T, r RowReader) []*Row {
+	t.Helper()
+	var rows []*Row
+	for {
+		row, err := r.Next()
+		if err == io.EOF {
+			return rows
+		}
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		rows = append(rows, row)
+	}
+}
+
+func TestJSONReader(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"array", "\xef\xbb\xbf [\n {\"name\":\"a\"},\n {\"name\":\"b\"}\n]\n"},
+		{"newline-delimited", "{\"name\":\"a\"}\n\n{\"name\":\"b\"}\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := readAll(t, NewJSONReader(strings.NewReader(tt.input)))
+			if len(rows) != 2 || rows[1].Number != 2 || string(rows[1].JSON) != `{"name":"b"}` {
+				t.Errorf("rows = %v, want a and b", rows)
+			}
+		})
+	}
+
+	if rows := readAll(t, NewJSONReader(strings.NewReader("[]"))); len(rows) != 0 {
+		t.Errorf("empty array gave %d rows", len(rows))
+	}
+
+	reader := NewJSONReader(strings.NewReader(`[{"name":"a"}, {"name":`))
+	reader.Next()
+	if _, err := reader.Next(); !errors.Is(err, ErrInvalidFile) {
+		t.Errorf("Next() on truncated JSON error = %v, want %v", err, ErrInvalidFile)
+	}
+}
+
+func TestCSVReader(t *testing.T) {
+	input := "\xef\xbb\xbfUser_ID, Timezone\nu1,UTC\nu2\n\"u3\",\"Europe/Berlin\"\n"
+	rows := readAll(t, NewCSVReader(strings.NewReader(input)))
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, want 3", len(rows))
+	}
+	if rows[0].Fields["user_id"] != "u1" || rows[2].Fields["timezone"] != "Europe/Berlin" {
+		t.Errorf("rows = %v, %v; want fields keyed by lowercase column", rows[0].Fields, rows[2].Fields)
+	}
+	if rows[1].Err == nil || rows[2].Number != 3 {
+		t.Errorf("short row should fail alone, got %+v", rows[1])
+	}
+
+	if _, err := NewCSVReader(strings.NewReader("name,name\na,b\n")).Next(); !errors.Is(err, ErrInvalidFile) {
+		t.Errorf("repeated column error = %v, want %v", err, ErrInvalidFile)
+	}
+}
+
+func TestNewReaderTranscodes(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		fileCharset string
+	}{
+		{name: "utf-8", input: "name,body\nwelcome,Grüße\n"},
+		{name: "undeclared latin-1", input: "name,body\nwelcome,Gr\xfc\xdfe\n"},
+		{name: "declared latin-1", input: "name,body\nwelcome,Gr\xfc\xdfe\n", fileCharset: "iso-8859-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := NewReader(FormatCSV, tt.fileCharset, strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("NewReader() error = %v", err)
+			}
+			rows := readAll(t, reader)
+			if len(rows) != 1 || rows[0].Fields["body"] != "Grüße" {
+				t.Errorf("rows = %v, want the body as UTF-8", rows)
+			}
+		})
+	}
+
+	if _, err := NewReader(FormatCSV, "klingon", strings.NewReader("")); !errors.Is(err, ErrInvalidFile) {
+		t.Errorf("NewReader() with an unknown charset error = %v, want %v", err, ErrInvalidFile)
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		contentType string
+		filename    string
+		want        string
+	}{
+		{"application/octet-stream", "templates.CSV", FormatCSV},
+		{"text/plain", "users.ndjson", FormatJSON},
+		{"text/csv; charset=utf-8", "", FormatCSV},
+		{"application/json", "", FormatJSON},
+	}
+	for _, tt := range tests {
+		if got, err := DetectFormat(tt.contentType, tt.filename); err != nil || got != tt.want {
+			t.Errorf("DetectFormat(%q, %q) = %q, %v; want %q", tt.contentType, tt.filename, got, err, tt.want)
+		}
+	}
+	if _, err := DetectFormat("text/plain", "users.txt"); !errors.Is(err, ErrInvalidFile) {
+		t.Errorf("DetectFormat() of a text file error = %v, want %v", err, ErrInvalidFile)
+	}
+}
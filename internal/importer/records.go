@@ -0,0 +1,194 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/templates"
+)
+
+// errOtherTenant is returned for records that name a tenant other than the importing one
+var errOtherTenant = errors.New("tenant_id does not match authenticated tenant")
+
+// templateKind imports templates, keyed by name, compiling MJML source with compile. CSV map
+// and object columns hold JSON objects and variables are comma-separated.
+func templateKind(compile func(*domain.Template) error) kind[*domain.Template] {
+	return kind[*domain.Template]{
+		name:    "templates",
+		columns: []string{"tenant_id", "name", "channel", "subject", "localized_subjects", "preheader", "body", "mjml", "charset", "is_html", "payload", "variables"},
+		decode: func(row *Row, tenantID string) (*domain.Template, string, error) {
+			return decodeTemplate(row, tenantID, compile)
+		},
+	}
+}
+
+// preferencesKind imports user preferences, keyed by user ID, checking each with validate
+func preferencesKind(validate func(*domain.NotificationPreferences) error) kind[*domain.NotificationPreferences] {
+	return kind[*domain.NotificationPreferences]{
+		name:    "preferences",
+		columns: []string{"tenant_id", "user_id", "email_enabled", "sms_enabled", "webhook_enabled", "email_categories", "sms_categories", "quiet_hours_start", "quiet_hours_end", "timezone"},
+		decode: func(row *Row, tenantID string) (*domain.NotificationPreferences, string, error) {
+			prefs, key, err := decodePreferences(row, tenantID)
+			if err == nil && validate != nil {
+				err = validate(prefs)
+			}
+			return prefs, key, err
+		},
+	}
+}
+
+// decodeTemplate returns a row's valid template for the tenant, its MJML source compiled with
+// compile. Only a template's content is imported; its ID, version and timestamps are the store's.
+func decodeTemplate(row *Row, tenantID string, compile func(*domain.Template) error) (*domain.Template, string, error) {
+	var record domain.Template
+	var err error
+	if row.Fields != nil {
+		err = templateFromCSV(row.Fields, &record)
+	} else {
+		err = json.Unmarshal(row.JSON, &record)
+	}
+	if err != nil {
+		return nil, record.Name, fmt.Errorf("invalid record: %w", err)
+	}
+	if record.TenantID != "" && record.TenantID != tenantID {
+		return nil, record.Name, errOtherTenant
+	}
+
+	template := &domain.Template{
+		TenantID:          tenantID,
+		Name:              record.Name,
+		Channel:           record.Channel,
+		Subject:           record.Subject,
+		LocalizedSubjects: record.LocalizedSubjects,
+		Preheader:         record.Preheader,
+		Body:              record.Body,
+		MJML:              record.MJML,
+		Charset:           record.Charset,
+		IsHTML:            record.IsHTML,
+		Payload:           record.Payload,
+		Variables:         record.Variables,
+	}
+	if err := compile(template); err != nil {
+		return nil, template.Name, err
+	}
+	if err := templates.Validate(template); err != nil {
+		return nil, template.Name, err
+	}
+	return template, template.Name, nil
+}
+
+// templateFromCSV sets a template's fields from a CSV row
+func templateFromCSV(fields map[string]string, t *domain.Template) error {
+	t.TenantID = fields["tenant_id"]
+	t.Name = fields["name"]
+	t.Channel = domain.NotificationType(fields["channel"])
+	t.Subject = fields["subject"]
+	t.Preheader = fields["preheader"]
+	t.Body = fields["body"]
+	t.MJML = fields["mjml"]
+	t.Charset = fields["charset"]
+	for _, variable := range strings.Split(fields["variables"], ",") {
+		if variable = strings.TrimSpace(variable); variable != "" {
+			t.Variables = append(t.Variables, variable)
+		}
+	}
+	if err := parseBool(fields, "is_html", &t.IsHTML); err != nil {
+		return err
+	}
+	if err := parseObject(fields, "localized_subjects", &t.LocalizedSubjects); err != nil {
+		return err
+	}
+	return parseObject(fields, "payload", &t.Payload)
+}
+
+// decodePreferences returns a row's preferences for the tenant, starting from the defaults
+func decodePreferences(row *Row, tenantID string) (*domain.NotificationPreferences, string, error) {
+	prefs := &domain.NotificationPreferences{
+		EmailEnabled:   true,
+		SMSEnabled:     true,
+		WebhookEnabled: true,
+	}
+	var err error
+	if row.Fields != nil {
+		err = preferencesFromCSV(row.Fields, prefs)
+	} else {
+		err = json.Unmarshal(row.JSON, prefs)
+	}
+	if err != nil {
+		return nil, prefs.UserID, fmt.Errorf("invalid record: %w", err)
+	}
+	if prefs.UserID == "" {
+		return nil, "", errors.New("user_id is required")
+	}
+	if prefs.TenantID != "" && prefs.TenantID != tenantID {
+		return nil, prefs.UserID, errOtherTenant
+	}
+
+	if prefs.Timezone == "" {
+		prefs.Timezone = "UTC"
+	}
+	return &domain.NotificationPreferences{
+		TenantID:        tenantID,
+		UserID:          prefs.UserID,
+		EmailEnabled:    prefs.EmailEnabled,
+		SMSEnabled:      prefs.SMSEnabled,
+		WebhookEnabled:  prefs.WebhookEnabled,
+		EmailCategories: prefs.EmailCategories,
+		SMSCategories:   prefs.SMSCategories,
+		QuietHoursStart: prefs.QuietHoursStart,
+		QuietHoursEnd:   prefs.QuietHoursEnd,
+		Timezone:        prefs.Timezone,
+	}, prefs.UserID, nil
+}
+
+// preferencesFromCSV sets preferences from a CSV row, leaving fields with empty cells unchanged
+func preferencesFromCSV(fields map[string]string, prefs *domain.NotificationPreferences) error {
+	prefs.TenantID = fields["tenant_id"]
+	prefs.UserID = fields["user_id"]
+	prefs.QuietHoursStart = fields["quiet_hours_start"]
+	prefs.QuietHoursEnd = fields["quiet_hours_end"]
+	prefs.Timezone = fields["timezone"]
+	for column, value := range map[string]*bool{
+		"email_enabled":   &prefs.EmailEnabled,
+		"sms_enabled":     &prefs.SMSEnabled,
+		"webhook_enabled": &prefs.WebhookEnabled,
+	} {
+		if err := parseBool(fields, column, value); err != nil {
+			return err
+		}
+	}
+	if err := parseObject(fields, "email_categories", &prefs.EmailCategories); err != nil {
+		return err
+	}
+	return parseObject(fields, "sms_categories", &prefs.SMSCategories)
+}
+
+// parseBool sets value from a boolean column, when its cell isn't empty
+func parseBool(fields map[string]string, column string, value *bool) error {
+	cell := strings.TrimSpace(fields[column])
+	if cell == "" {
+		return nil
+	}
+	parsed, err := strconv.ParseBool(cell)
+	if err != nil {
+		return fmt.Errorf("%s must be true or false, not %q", column, cell)
+	}
+	*value = parsed
+	return nil
+}
+
+// parseObject decodes a column holding a JSON object into value, when its cell isn't empty
+func parseObject(fields map[string]string, column string, value any) error {
+	cell := strings.TrimSpace(fields[column])
+	if cell == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(cell), value); err != nil {
+		return fmt.Errorf("%s must be a JSON object: %v", column, err)
+	}
+	return nil
+}
@@ -0,0 +1,319 @@
+package inbound
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const multipartReply = "From: Jane Doe <jane@example.com>\r\n" +
+	"To: support@example.org\r\n" +
+	"Subject: =?UTF-8?Q?Re:_Your_order_=E2=9C=93?=\r\n" +
+	"Message-ID: <reply-1@mail.example.com>\r\n" +
+	"In-Reply-To: <sent-2@example.org>\r\n" +
+	"References: <sent-1@example.org> <sent-2@example.org>\r\n" +
+	"X-Entity-Ref-ID: 65f1a2b3c4d5e6f708091a2b\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/mixed; boundary=outer\r\n" +
+	"\r\n" +
+	"--outer\r\n" +
+	"Content-Type: multipart/alternative; boundary=inner\r\n" +
+	"\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"Thanks, that=\r\n" +
+	" works =E2=9C=93\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/html; charset=utf-8\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"PHA+VGhhbmtzPC9wPg==\r\n" +
+	"--inner--\r\n" +
+	"--outer\r\n" +
+	"Content-Type: text/plain\r\n" +
+	"Content-Disposition: attachment; filename=notes.txt\r\n" +
+	"\r\n" +
+	"attached notes\r\n" +
+	"--outer--\r\n"
+
+func TestParseMIME(t *testing.T) {
+	m, err := ParseMIME([]byte(multipartReply))
+	if err != nil {
+		t.Fatalf("ParseMIME() error = %v", err)
+	}
+
+	if m.From != "jane@example.com" {
+		t.Errorf("From = %q, want jane@example.com", m.From)
+	}
+	if m.Subject != "Re: Your order ✓" {
+		t.Errorf("Subject = %q, want decoded subject", m.Subject)
+	}
+	if m.MessageID != "reply-1@mail.example.com" {
+		t.Errorf("MessageID = %q", m.MessageID)
+	}
+	if m.EntityRefID != "65f1a2b3c4d5e6f708091a2b" {
+		t.Errorf("EntityRefID = %q", m.EntityRefID)
+	}
+	if want := []string{"sent-2@example.org", "sent-1@example.org"}; !reflect.DeepEqual(m.References, want) {
+		t.Errorf("References = %v, want %v", m.References, want)
+	}
+	if m.Text != "Thanks, that works ✓" {
+		t.Errorf("Text = %q", m.Text)
+	}
+	if m.HTML != "<p>Thanks</p>" {
+		t.Errorf("HTML = %q", m.HTML)
+	}
+	if body, isHTML := m.Body(); isHTML || !strings.HasPrefix(body, "Thanks") {
+		t.Errorf("Body() = %q, %v, want the text part", body, isHTML)
+	}
+}
+
+func TestParseMIMEHTMLOnly(t *testing.T) {
+	raw := "From: jane@example.com\r\n" +
+		"Subject: Re: hi\r\n" +
+		"Content-Type: text/html\r\n" +
+		"\r\n" +
+		"<p>Hello</p>"
+
+	m, err := ParseMIME([]byte(raw))
+	if err != nil {
+		t.Fatalf("ParseMIME() error = %v", err)
+	}
+	if body, isHTML := m.Body(); !isHTML || body != "<p>Hello</p>" {
+		t.Errorf("Body() = %q, %v, want the HTML part", body, isHTML)
+	}
+}
+
+func TestParseMIMEInvalid(t *testing.T) {
+	if _, err := ParseMIME([]byte("not an email")); err == nil {
+		t.Error("ParseMIME() error = nil, want an error")
+	}
+}
+
+// signSendGrid signs body the way SendGrid does
+func signSendGrid(t *testing.T, key *ecdsa.PrivateKey, timestamp string, body []byte) string {
+	t.Helper()
+	digest := sha256.Sum256(append([]byte(timestamp), body...))
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(sig)
+}
+
+func newSendGridVerifier(t *testing.T) (*SendGridVerifier, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	v, err := NewSendGridVerifier(base64.StdEncoding.EncodeToString(der))
+	if err != nil {
+		t.Fatalf("NewSendGridVerifier() error = %v", err)
+	}
+	return v, key
+}
+
+func TestSendGridVerify(t *testing.T) {
+	v, key := newSendGridVerifier(t)
+	now := time.Unix(1700000000, 0)
+	v.now = func() time.Time { return now }
+	body := []byte("email=raw")
+
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	sig := signSendGrid(t, key, timestamp, body)
+	if err := v.Verify(timestamp, sig, body); err != nil {
+		t.Errorf("Verify() error = %v, want nil", err)
+	}
+	if err := v.Verify(timestamp, sig, []byte("email=tampered")); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify() with a tampered body error = %v, want ErrInvalidSignature", err)
+	}
+
+	old := strconv.FormatInt(now.Add(-time.Hour).Unix(), 10)
+	if err := v.Verify(old, signSendGrid(t, key, old, body), body); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify() with an old timestamp error = %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestNewSendGridVerifierInvalidKey(t *testing.T) {
+	if _, err := NewSendGridVerifier("not base64!"); err == nil {
+		t.Error("NewSendGridVerifier() error = nil, want an error")
+	}
+}
+
+func TestParseSendGridFields(t *testing.T) {
+	form := url.Values{
+		"headers": {"From: Jane <jane@example.com>\nMessage-ID: <reply-1@example.com>\nIn-Reply-To: <sent-1@example.org>\n"},
+		"subject": {"Re: hi"},
+		"text":    {"Sounds good"},
+	}
+
+	m, err := ParseSendGrid(form)
+	if err != nil {
+		t.Fatalf("ParseSendGrid() error = %v", err)
+	}
+	if m.From != "jane@example.com" || m.Subject != "Re: hi" || m.Text != "Sounds good" {
+		t.Errorf("ParseSendGrid() = %+v", m)
+	}
+	if !reflect.DeepEqual(m.References, []string{"sent-1@example.org"}) {
+		t.Errorf("References = %v", m.References)
+	}
+
+	if _, err := ParseSendGrid(url.Values{}); err == nil {
+		t.Error("ParseSendGrid() without headers error = nil, want an error")
+	}
+}
+
+func TestParseSendGridRaw(t *testing.T) {
+	m, err := ParseSendGrid(url.Values{"email": {multipartReply}})
+	if err != nil {
+		t.Fatalf("ParseSendGrid() error = %v", err)
+	}
+	if m.MessageID != "reply-1@mail.example.com" {
+		t.Errorf("MessageID = %q", m.MessageID)
+	}
+}
+
+const (
+	testTopicARN = "arn:aws:sns:us-east-1:123456789012:inbound"
+	testCertURL  = "https://sns.us-east-1.amazonaws.com/SimpleNotificationService-test.pem"
+)
+
+// newSNSVerifier returns a verifier with a self-signed certificate preloaded for testCertURL
+func newSNSVerifier(t *testing.T) (*SNSVerifier, *rsa.PrivateKey) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sns.amazonaws.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %v", err)
+	}
+
+	v := NewSNSVerifier(nil, []string{testTopicARN})
+	v.certs[testCertURL] = cert
+	return v, key
+}
+
+func signSNS(t *testing.T, key *rsa.PrivateKey, msg *SNSMessage) {
+	t.Helper()
+	msg.SignatureVersion = "2"
+	msg.SigningCertURL = testCertURL
+	digest := sha256.Sum256(snsStringToSign(msg))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	msg.Signature = base64.StdEncoding.EncodeToString(sig)
+}
+
+func TestSNSVerify(t *testing.T) {
+	v, key := newSNSVerifier(t)
+	msg := &SNSMessage{
+		Type:      SNSTypeNotification,
+		MessageID: "msg-1",
+		TopicArn:  testTopicARN,
+		Message:   `{"notificationType":"Received"}`,
+		Timestamp: "2024-01-01T00:00:00.000Z",
+	}
+	signSNS(t, key, msg)
+
+	if err := v.Verify(context.Background(), msg); err != nil {
+		t.Errorf("Verify() error = %v, want nil", err)
+	}
+
+	tampered := *msg
+	tampered.Message = `{"notificationType":"Bounce"}`
+	if err := v.Verify(context.Background(), &tampered); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify() with a tampered message error = %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestSNSVerifyRejectsUnknownTopic(t *testing.T) {
+	v, key := newSNSVerifier(t)
+	msg := &SNSMessage{
+		Type:      SNSTypeNotification,
+		MessageID: "msg-1",
+		TopicArn:  "arn:aws:sns:us-east-1:999999999999:other",
+		Message:   "{}",
+		Timestamp: "2024-01-01T00:00:00.000Z",
+	}
+	signSNS(t, key, msg)
+
+	if err := v.Verify(context.Background(), msg); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify() error = %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestCheckSNSURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://sns.us-east-1.amazonaws.com/cert.pem", true},
+		{"https://sns.cn-north-1.amazonaws.com.cn/cert.pem", true},
+		{"http://sns.us-east-1.amazonaws.com/cert.pem", false},
+		{"https://sns.us-east-1.amazonaws.com.evil.com/cert.pem", false},
+		{"https://example.com/cert.pem", false},
+	}
+	for _, tt := range tests {
+		if got := checkSNSURL(tt.url) == nil; got != tt.want {
+			t.Errorf("checkSNSURL(%q) ok = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestParseSES(t *testing.T) {
+	notification, _ := json.Marshal(map[string]string{
+		"notificationType": "Received",
+		"content":          base64.StdEncoding.EncodeToString([]byte(multipartReply)),
+	})
+
+	m, err := ParseSES(string(notification))
+	if err != nil {
+		t.Fatalf("ParseSES() error = %v", err)
+	}
+	if m.From != "jane@example.com" || m.EntityRefID != "65f1a2b3c4d5e6f708091a2b" {
+		t.Errorf("ParseSES() = %+v", m)
+	}
+
+	if _, err := ParseSES(`{"notificationType":"Received"}`); err == nil {
+		t.Error("ParseSES() without content error = nil, want an error")
+	}
+	if _, err := ParseSES(`{"notificationType":"Bounce","content":"x"}`); err == nil {
+		t.Error("ParseSES() for a bounce error = nil, want an error")
+	}
+}
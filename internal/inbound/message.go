@@ -0,0 +1,172 @@
+package inbound
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"regexp"
+	"strings"
+)
+
+// ErrInvalidSignature is returned when an inbound webhook wasn't signed by its provider
+var ErrInvalidSignature = errors.New("invalid inbound webhook signature")
+
+const (
+	maxBodyLength  = 256 * 1024 // Reply text or HTML kept, in bytes
+	maxMIMEDepth   = 5          // Nested multipart levels read
+	headerEntityID = "X-Entity-Ref-ID"
+)
+
+// messageIDPattern matches the <id> tokens of Message-ID, In-Reply-To and References headers
+var messageIDPattern = regexp.MustCompile(`<([^<>\s]+)>`)
+
+// Message is an inbound email, typically a reply to one this service sent
+type Message struct {
+	From        string // Sender's address
+	To          string // Recipients as the To header lists them
+	Subject     string
+	Text        string
+	HTML        string
+	MessageID   string   // The email's own Message-ID, without angle brackets
+	EntityRefID string   // X-Entity-Ref-ID, the original notification's ID, when the reply kept it
+	References  []string // Message-IDs it replies to, from In-Reply-To and References, nearest first
+}
+
+// Body returns the email's text, or its HTML when it has no text part, and whether it is HTML
+func (m *Message) Body() (string, bool) {
+	if strings.TrimSpace(m.Text) != "" || m.HTML == "" {
+		return m.Text, false
+	}
+	return m.HTML, true
+}
+
+// ParseMIME parses a raw RFC 5322 email, keeping its first text and HTML parts.
+// Attachments are skipped.
+func ParseMIME(raw []byte) (*Message, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("invalid email: %w", err)
+	}
+	m := fromHeader(textproto.MIMEHeader(msg.Header))
+
+	var body part
+	if err := body.read(msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), msg.Body, 0); err != nil {
+		return nil, fmt.Errorf("invalid email body: %w", err)
+	}
+	m.Text, m.HTML = body.text, body.html
+	return m, nil
+}
+
+// fromHeader reads a message's addressing and threading headers
+func fromHeader(header textproto.MIMEHeader) *Message {
+	m := &Message{
+		From:        header.Get("From"),
+		To:          header.Get("To"),
+		Subject:     header.Get("Subject"),
+		EntityRefID: strings.TrimSpace(header.Get(headerEntityID)),
+	}
+	if from, err := mail.ParseAddress(m.From); err == nil {
+		m.From = from.Address
+	}
+	if subject, err := new(mime.WordDecoder).DecodeHeader(m.Subject); err == nil {
+		m.Subject = subject
+	}
+	if ids := messageIDs(header.Get("Message-ID")); len(ids) > 0 {
+		m.MessageID = ids[0]
+	}
+
+	// In-Reply-To names the message replied to; References lists the thread oldest first
+	seen := make(map[string]bool)
+	references := reversed(messageIDs(header.Get("References")))
+	for _, id := range append(messageIDs(header.Get("In-Reply-To")), references...) {
+		if !seen[id] {
+			seen[id] = true
+			m.References = append(m.References, id)
+		}
+	}
+	return m
+}
+
+// messageIDs returns the ids in a Message-ID style header, without angle brackets
+func messageIDs(value string) []string {
+	var ids []string
+	for _, match := range messageIDPattern.FindAllStringSubmatch(value, -1) {
+		ids = append(ids, match[1])
+	}
+	return ids
+}
+
+// reversed returns ids in reverse order
+func reversed(ids []string) []string {
+	out := make([]string, len(ids))
+	for i, id := range ids {
+		out[len(ids)-1-i] = id
+	}
+	return out
+}
+
+// part collects the first text and HTML bodies of a MIME tree
+type part struct {
+	text string
+	html string
+}
+
+// read decodes one MIME entity, descending into multipart entities
+func (p *part) read(contentType, encoding string, body io.Reader, depth int) error {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if contentType == "" || err != nil {
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		if depth >= maxMIMEDepth || params["boundary"] == "" {
+			return nil
+		}
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			next, err := reader.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if disposition, _, _ := mime.ParseMediaType(next.Header.Get("Content-Disposition")); disposition == "attachment" {
+				continue
+			}
+			if err := p.read(next.Header.Get("Content-Type"), next.Header.Get("Content-Transfer-Encoding"), next, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	if (mediaType == "text/plain" && p.text != "") || (mediaType == "text/html" && p.html != "") ||
+		(mediaType != "text/plain" && mediaType != "text/html") {
+		return nil
+	}
+
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	}
+	content, err := io.ReadAll(io.LimitReader(body, maxBodyLength))
+	if err != nil {
+		return err
+	}
+
+	if mediaType == "text/plain" {
+		p.text = string(content)
+	} else {
+		p.html = string(content)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package inbound
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers SendGrid signs webhooks with
+const (
+	SendGridSignatureHeader = "X-Twilio-Email-Event-Webhook-Signature"
+	SendGridTimestampHeader = "X-Twilio-Email-Event-Webhook-Timestamp"
+)
+
+// DefaultMaxSignatureAge is how old a signed webhook may be before it is rejected as a replay
+const DefaultMaxSignatureAge = 10 * time.Minute
+
+// SendGridVerifier verifies SendGrid's signed webhooks: an ECDSA signature over the
+// timestamp header followed by the raw request body
+type SendGridVerifier struct {
+	key    *ecdsa.PublicKey
+	maxAge time.Duration
+	now    func() time.Time
+}
+
+// NewSendGridVerifier creates a verifier for the base64 public key shown in SendGrid's
+// webhook security settings
+func NewSendGridVerifier(publicKey string) (*SendGridVerifier, error) {
+	der, err := base64.StdEncoding.DecodeString(strings.TrimSpace(publicKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SendGrid public key: %w", err)
+	}
+	parsed, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SendGrid public key: %w", err)
+	}
+	key, ok := parsed.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("invalid SendGrid public key: %T is not an ECDSA key", parsed)
+	}
+	return &SendGridVerifier{key: key, maxAge: DefaultMaxSignatureAge, now: time.Now}, nil
+}
+
+// Verify checks a webhook's signature and that its timestamp, in Unix seconds, is recent
+func (v *SendGridVerifier) Verify(timestamp, signature string, body []byte) error {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: malformed timestamp", ErrInvalidSignature)
+	}
+	if age := v.now().Sub(time.Unix(seconds, 0)); age > v.maxAge || age < -v.maxAge {
+		return fmt.Errorf("%w: timestamp outside the allowed window", ErrInvalidSignature)
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("%w: malformed signature", ErrInvalidSignature)
+	}
+
+	digest := sha256.New()
+	digest.Write([]byte(timestamp))
+	digest.Write(body)
+	if !ecdsa.VerifyASN1(v.key, digest.Sum(nil), sig) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// ParseSendGrid parses a SendGrid Inbound Parse post. With "Send Raw" enabled the whole email
+// is in the email field; otherwise it is split into headers, text and html fields.
+func ParseSendGrid(form url.Values) (*Message, error) {
+	if raw := form.Get("email"); raw != "" {
+		return ParseMIME([]byte(raw))
+	}
+
+	headers := form.Get("headers")
+	if headers == "" {
+		return nil, fmt.Errorf("inbound parse post has no headers")
+	}
+	reader := textproto.NewReader(bufio.NewReader(strings.NewReader(strings.TrimRight(headers, "\r\n") + "\r\n\r\n")))
+	header, err := reader.ReadMIMEHeader()
+	if err != nil {
+		return nil, fmt.Errorf("invalid inbound parse headers: %w", err)
+	}
+
+	m := fromHeader(header)
+	// The separate fields are already decoded
+	if subject := form.Get("subject"); subject != "" {
+		m.Subject = subject
+	}
+	if to := form.Get("to"); to != "" {
+		m.To = to
+	}
+	m.Text = truncate(form.Get("text"))
+	m.HTML = truncate(form.Get("html"))
+	return m, nil
+}
+
+// truncate bounds s to maxBodyLength bytes
+func truncate(s string) string {
+	if len(s) > maxBodyLength {
+		return s[:maxBodyLength]
+	}
+	return s
+}
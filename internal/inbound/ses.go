@@ -0,0 +1,243 @@
+package inbound
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// SNS message types
+const (
+	SNSTypeNotification             = "Notification"
+	SNSTypeSubscriptionConfirmation = "SubscriptionConfirmation"
+	SNSTypeUnsubscribeConfirmation  = "UnsubscribeConfirmation"
+)
+
+// maxCertSize bounds a downloaded SNS signing certificate
+const maxCertSize = 64 * 1024
+
+// snsHostPattern matches the hosts SNS serves signing certificates and subscription URLs from
+var snsHostPattern = regexp.MustCompile(`^sns\.[a-z0-9-]+\.amazonaws\.com(\.cn)?$`)
+
+// SNSMessage is an Amazon SNS HTTP(S) delivery
+type SNSMessage struct {
+	Type             string `json:"Type"`
+	MessageID        string `json:"MessageId"`
+	Token            string `json:"Token"`
+	TopicArn         string `json:"TopicArn"`
+	Subject          string `json:"Subject"`
+	Message          string `json:"Message"`
+	Timestamp        string `json:"Timestamp"`
+	SignatureVersion string `json:"SignatureVersion"`
+	Signature        string `json:"Signature"`
+	SigningCertURL   string `json:"SigningCertURL"`
+	SubscribeURL     string `json:"SubscribeURL"`
+}
+
+// SNSVerifier verifies Amazon SNS deliveries. A valid signature only shows that SNS sent the
+// message, so the topic must also be one of the configured topics.
+type SNSVerifier struct {
+	client *http.Client
+	topics map[string]bool
+
+	mu    sync.Mutex
+	certs map[string]*x509.Certificate // Signing certificates by URL
+}
+
+// NewSNSVerifier creates a verifier that accepts deliveries from the given topic ARNs
+func NewSNSVerifier(client *http.Client, topicARNs []string) *SNSVerifier {
+	v := &SNSVerifier{
+		client: client,
+		topics: make(map[string]bool, len(topicARNs)),
+		certs:  make(map[string]*x509.Certificate),
+	}
+	for _, arn := range topicARNs {
+		v.topics[arn] = true
+	}
+	return v
+}
+
+// Verify checks a delivery's topic and its signature with the SNS certificate it names
+func (v *SNSVerifier) Verify(ctx context.Context, msg *SNSMessage) error {
+	if !v.topics[msg.TopicArn] {
+		return fmt.Errorf("%w: topic %q is not accepted", ErrInvalidSignature, msg.TopicArn)
+	}
+
+	var hash crypto.Hash
+	switch msg.SignatureVersion {
+	case "1":
+		hash = crypto.SHA1
+	case "2":
+		hash = crypto.SHA256
+	default:
+		return fmt.Errorf("%w: unsupported signature version %q", ErrInvalidSignature, msg.SignatureVersion)
+	}
+	sig, err := base64.StdEncoding.DecodeString(msg.Signature)
+	if err != nil {
+		return fmt.Errorf("%w: malformed signature", ErrInvalidSignature)
+	}
+	cert, err := v.certificate(ctx, msg.SigningCertURL)
+	if err != nil {
+		return err
+	}
+	key, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("%w: signing certificate has no RSA key", ErrInvalidSignature)
+	}
+
+	var digest []byte
+	if hash == crypto.SHA1 {
+		sum := sha1.Sum(snsStringToSign(msg))
+		digest = sum[:]
+	} else {
+		sum := sha256.Sum256(snsStringToSign(msg))
+		digest = sum[:]
+	}
+	if err := rsa.VerifyPKCS1v15(key, hash, digest, sig); err != nil {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Confirm confirms a verified subscription to a topic, so SNS starts delivering to it
+func (v *SNSVerifier) Confirm(ctx context.Context, msg *SNSMessage) error {
+	if err := checkSNSURL(msg.SubscribeURL); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, msg.SubscribeURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxCertSize))
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("subscription confirmation returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// certificate returns the signing certificate at certURL, downloading it once
+func (v *SNSVerifier) certificate(ctx context.Context, certURL string) (*x509.Certificate, error) {
+	v.mu.Lock()
+	cert, ok := v.certs[certURL]
+	v.mu.Unlock()
+	if ok {
+		return cert, nil
+	}
+
+	if err := checkSNSURL(certURL); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, certURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download SNS signing certificate: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download SNS signing certificate: status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxCertSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download SNS signing certificate: %w", err)
+	}
+	block, _ := pem.Decode(body)
+	if block == nil {
+		return nil, fmt.Errorf("%w: signing certificate is not PEM", ErrInvalidSignature)
+	}
+	if cert, err = x509.ParseCertificate(block.Bytes); err != nil {
+		return nil, fmt.Errorf("%w: invalid signing certificate: %v", ErrInvalidSignature, err)
+	}
+
+	v.mu.Lock()
+	v.certs[certURL] = cert
+	v.mu.Unlock()
+	return cert, nil
+}
+
+// checkSNSURL rejects URLs that aren't served by SNS over HTTPS
+func checkSNSURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Scheme != "https" || !snsHostPattern.MatchString(parsed.Hostname()) || parsed.Port() != "" {
+		return fmt.Errorf("%w: %q is not an SNS URL", ErrInvalidSignature, rawURL)
+	}
+	return nil
+}
+
+// snsStringToSign builds the string SNS signs: selected fields as name and value lines, in order
+func snsStringToSign(msg *SNSMessage) []byte {
+	var fields []struct{ name, value string }
+	add := func(name, value string) {
+		fields = append(fields, struct{ name, value string }{name, value})
+	}
+	add("Message", msg.Message)
+	add("MessageId", msg.MessageID)
+	if msg.Type == SNSTypeNotification {
+		if msg.Subject != "" {
+			add("Subject", msg.Subject)
+		}
+		add("Timestamp", msg.Timestamp)
+	} else {
+		add("SubscribeURL", msg.SubscribeURL)
+		add("Timestamp", msg.Timestamp)
+		add("Token", msg.Token)
+	}
+	add("TopicArn", msg.TopicArn)
+	add("Type", msg.Type)
+
+	var b bytes.Buffer
+	for _, field := range fields {
+		b.WriteString(field.name + "\n" + field.value + "\n")
+	}
+	return b.Bytes()
+}
+
+// sesNotification is the part of an SES receipt notification that carries the email
+type sesNotification struct {
+	NotificationType string `json:"notificationType"`
+	Content          string `json:"content"`
+}
+
+// ParseSES parses an SES receipt notification published by an SNS action. The action must
+// include the email, which SES sends as UTF-8 text or base64.
+func ParseSES(message string) (*Message, error) {
+	var notification sesNotification
+	if err := json.Unmarshal([]byte(message), &notification); err != nil {
+		return nil, fmt.Errorf("invalid SES notification: %w", err)
+	}
+	if notification.NotificationType != "Received" {
+		return nil, fmt.Errorf("SES notification is %q, not a received email", notification.NotificationType)
+	}
+	if notification.Content == "" {
+		return nil, fmt.Errorf("SES notification has no content; the SNS action must include the email")
+	}
+
+	raw := []byte(notification.Content)
+	if !strings.Contains(notification.Content[:min(len(notification.Content), 1000)], ":") {
+		if decoded, err := base64.StdEncoding.DecodeString(notification.Content); err == nil {
+			raw = decoded
+		}
+	}
+	return ParseMIME(raw)
+}
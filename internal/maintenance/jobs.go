@@ -0,0 +1,289 @@
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/dlq"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/metrics"
+	"github.com/vhvplatform/go-notification-service/internal/middleware"
+	"github.com/vhvplatform/go-notification-service/internal/repository"
+)
+
+// Job names
+const (
+	JobOutboxPurge             = "outbox_purge"
+	JobNotificationPurge       = "notification_purge"
+	JobFailedNotificationPurge = "failed_notification_purge"
+	JobExpiredNotifications    = "expired_notifications"
+	JobRateLimiterCleanup      = "rate_limiter_cleanup"
+	JobIdempotencyKeyRelease   = "idempotency_key_release"
+	JobSoftDeletePurge         = "soft_delete_purge"
+	JobContentRedaction        = "content_redaction"
+	JobStuckNotifications      = "stuck_notifications"
+)
+
+// stuckStatuses are the statuses a notification only holds while it is being sent
+var stuckStatuses = []domain.NotificationStatus{domain.NotificationStatusQueued, domain.NotificationStatusSending}
+
+// RetentionPolicy defines how long soft-deleted records are kept, per tenant
+// A retention of zero or less keeps records indefinitely.
+type RetentionPolicy struct {
+	DefaultDays int
+	TenantDays  map[string]int // Overrides DefaultDays for specific tenants
+}
+
+// DaysFor returns the retention in days for a tenant
+func (p RetentionPolicy) DaysFor(tenantID string) int {
+	if days, ok := p.TenantDays[tenantID]; ok {
+		return days
+	}
+	return p.DefaultDays
+}
+
+// ContentRetentionPolicy defines how long sent notifications keep their subject, body and
+// payload, per tenant and category. A retention of zero or less keeps content indefinitely.
+// When both a tenant and a category override apply, the shorter retention wins.
+type ContentRetentionPolicy struct {
+	DefaultDays  int
+	TenantDays   map[string]int // Overrides DefaultDays for specific tenants
+	CategoryDays map[string]int // Applies to a category in every tenant
+	Drop         bool           // Remove content fields instead of replacing them with a placeholder
+}
+
+// DaysFor returns the content retention in days for a tenant's notifications in category
+func (p ContentRetentionPolicy) DaysFor(tenantID, category string) int {
+	days := p.DefaultDays
+	if tenantDays, ok := p.TenantDays[tenantID]; ok {
+		days = tenantDays
+	}
+	if categoryDays, ok := p.CategoryDays[category]; ok {
+		days = shorterRetention(days, categoryDays)
+	}
+	return days
+}
+
+// shorterRetention returns the shorter of two retentions, where zero or less means indefinite
+func shorterRetention(a, b int) int {
+	switch {
+	case a <= 0:
+		return b
+	case b <= 0:
+		return a
+	default:
+		return min(a, b)
+	}
+}
+
+// OutboxPurgeJob deletes processed outbox events older than retentionDays
+func OutboxPurgeJob(repo *repository.OutboxEventRepository, schedule string, retentionDays int) Job {
+	return Job{
+		Name:     JobOutboxPurge,
+		Schedule: schedule,
+		Run: func(ctx context.Context) (int64, error) {
+			return repo.DeleteOldProcessedEvents(ctx, retentionDays)
+		},
+	}
+}
+
+// NotificationPurgeJob deletes sent, delivered and failed notifications older than retentionDays
+func NotificationPurgeJob(repo *repository.NotificationRepository, schedule string, retentionDays int) Job {
+	return Job{
+		Name:     JobNotificationPurge,
+		Schedule: schedule,
+		Run: func(ctx context.Context) (int64, error) {
+			return repo.DeleteOlderThan(ctx, time.Now().AddDate(0, 0, -retentionDays))
+		},
+	}
+}
+
+// FailedNotificationPurgeJob deletes dead letter queue entries older than retentionDays
+func FailedNotificationPurgeJob(repo *repository.FailedNotificationRepository, schedule string, retentionDays int) Job {
+	return Job{
+		Name:     JobFailedNotificationPurge,
+		Schedule: schedule,
+		Run: func(ctx context.Context) (int64, error) {
+			return repo.DeleteOlderThan(ctx, time.Now().AddDate(0, 0, -retentionDays))
+		},
+	}
+}
+
+// ExpiredNotificationsJob soft deletes unsent notifications past their expiry time
+func ExpiredNotificationsJob(repo *repository.NotificationRepository, schedule string) Job {
+	return Job{
+		Name:     JobExpiredNotifications,
+		Schedule: schedule,
+		Run: func(ctx context.Context) (int64, error) {
+			return repo.SoftDeleteExpired(ctx, time.Now())
+		},
+	}
+}
+
+// RateLimiterCleanupJob removes per-tenant rate limiters idle for longer than maxIdle
+func RateLimiterCleanupJob(limiter *middleware.TenantRateLimiter, schedule string, maxIdle time.Duration) Job {
+	return Job{
+		Name:     JobRateLimiterCleanup,
+		Schedule: schedule,
+		Run: func(ctx context.Context) (int64, error) {
+			return int64(limiter.Cleanup(maxIdle)), nil
+		},
+	}
+}
+
+// IdempotencyKeyReleaseJob removes idempotency keys whose deduplication window has ended
+func IdempotencyKeyReleaseJob(repo *repository.NotificationRepository, schedule string) Job {
+	return Job{
+		Name:     JobIdempotencyKeyRelease,
+		Schedule: schedule,
+		Run: func(ctx context.Context) (int64, error) {
+			return repo.ReleaseExpiredIdempotencyKeys(ctx, time.Now())
+		},
+	}
+}
+
+// SoftDeletePurgeJob permanently removes notifications soft-deleted longer ago than each
+// tenant's retention. In dry-run mode it only counts the records that would be removed.
+func SoftDeletePurgeJob(repo *repository.NotificationRepository, schedule string, policy RetentionPolicy, dryRun bool) Job {
+	return Job{
+		Name:     JobSoftDeletePurge,
+		Schedule: schedule,
+		DryRun:   dryRun,
+		Run: func(ctx context.Context) (int64, error) {
+			tenantIDs, err := repo.FindSoftDeletedTenantIDs(ctx)
+			if err != nil {
+				return 0, err
+			}
+
+			var total int64
+			for _, tenantID := range tenantIDs {
+				days := policy.DaysFor(tenantID)
+				if days <= 0 {
+					continue
+				}
+
+				removed, err := repo.HardDeleteSoftDeleted(ctx, tenantID, time.Now().AddDate(0, 0, -days), dryRun)
+				if err != nil {
+					return total, err
+				}
+				total += removed
+
+				if !dryRun && removed > 0 {
+					metrics.SoftDeletedPurged.WithLabelValues("notifications", metrics.TenantLabel(tenantID)).Add(float64(removed))
+				}
+			}
+			return total, nil
+		},
+	}
+}
+
+// ContentRedactionJob removes the subject, body and payload of sent notifications once they
+// are older than the retention for their tenant and category, keeping the rest for reporting
+func ContentRedactionJob(repo *repository.NotificationRepository, schedule string, policy ContentRetentionPolicy) Job {
+	return Job{
+		Name:     JobContentRedaction,
+		Schedule: schedule,
+		Run: func(ctx context.Context) (int64, error) {
+			tenantIDs, err := repo.FindRedactableTenantIDs(ctx)
+			if err != nil {
+				return 0, err
+			}
+
+			categories := make([]string, 0, len(policy.CategoryDays))
+			for category := range policy.CategoryDays {
+				categories = append(categories, category)
+			}
+
+			now := time.Now()
+			var total int64
+			redact := func(redaction repository.ContentRedaction, days int) error {
+				if days <= 0 {
+					return nil // Content is kept indefinitely
+				}
+				redaction.SentBefore = now.AddDate(0, 0, -days)
+				redaction.Drop = policy.Drop
+				redacted, err := repo.RedactContent(ctx, redaction)
+				total += redacted
+				return err
+			}
+
+			for _, tenantID := range tenantIDs {
+				for _, category := range categories {
+					redaction := repository.ContentRedaction{TenantID: tenantID, Categories: []string{category}}
+					if err := redact(redaction, policy.DaysFor(tenantID, category)); err != nil {
+						return total, err
+					}
+				}
+				// Notifications outside the overridden categories follow the tenant's retention
+				redaction := repository.ContentRedaction{TenantID: tenantID, ExcludeCategories: categories}
+				if err := redact(redaction, policy.DaysFor(tenantID, "")); err != nil {
+					return total, err
+				}
+			}
+			return total, nil
+		},
+	}
+}
+
+// StuckNotificationPolicy decides what happens to notifications left queued or sending, as
+// they are when the service stops mid-send
+type StuckNotificationPolicy struct {
+	Timeout time.Duration // How long a notification may stay queued or sending before it is stuck
+	Requeue bool          // Resend stuck notifications instead of only moving them to the DLQ
+}
+
+// StuckNotificationsJob fails notifications that have been queued or sending for longer than
+// the policy's timeout and adds them to the dead-letter queue. When the policy requeues them,
+// each is then resent through service, as a DLQ retry is, and leaves the DLQ once resent.
+func StuckNotificationsJob(repo *repository.NotificationRepository, queue *dlq.DeadLetterQueue, service dlq.NotificationService, schedule string, policy StuckNotificationPolicy) Job {
+	return Job{
+		Name:       JobStuckNotifications,
+		Schedule:   schedule,
+		RunOnStart: true, // Picks up what a crash left behind before new sends pile on top
+		Run: func(ctx context.Context) (int64, error) {
+			stuckBefore := time.Now().Add(-policy.Timeout)
+			tenantIDs, err := repo.FindStuckTenantIDs(ctx, stuckStatuses, stuckBefore)
+			if err != nil {
+				return 0, err
+			}
+
+			cause := fmt.Errorf("stuck in queued or sending for over %s", policy.Timeout)
+			var total int64
+			for _, tenantID := range tenantIDs {
+				tenantCtx := middleware.WithTenantID(ctx, tenantID)
+				for {
+					notification, err := repo.ClaimStuck(tenantCtx, tenantID, stuckStatuses, stuckBefore, cause.Error())
+					if err != nil {
+						return total, err
+					}
+					if notification == nil {
+						break
+					}
+					total++
+
+					if !policy.Requeue {
+						if err := queue.Add(tenantCtx, notification, cause); err != nil {
+							return total, err
+						}
+						metrics.StuckNotificationsReconciled.WithLabelValues("failed", metrics.TenantLabel(tenantID)).Inc()
+						continue
+					}
+
+					// The notification stays in the DLQ when its resend fails, so one bad
+					// notification doesn't hold up the rest
+					action := "requeued"
+					if err := queue.Requeue(tenantCtx, notification, cause, service); err != nil {
+						if errors.Is(err, ctx.Err()) {
+							return total, err
+						}
+						action = "requeue_failed"
+					}
+					metrics.StuckNotificationsReconciled.WithLabelValues(action, metrics.TenantLabel(tenantID)).Inc()
+				}
+			}
+			return total, nil
+		},
+	}
+}
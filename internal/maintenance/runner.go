@@ -0,0 +1,121 @@
+package maintenance
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// Job is a periodic cleanup task
+type Job struct {
+	Name       string
+	Schedule   string                                   // Cron expression
+	Run        func(ctx context.Context) (int64, error) // Returns the number of records removed
+	DryRun     bool                                     // Run only counts records that would be removed
+	RunOnStart bool                                     // Also run once as soon as the runner starts
+}
+
+// Runner runs maintenance jobs on cron schedules
+type Runner struct {
+	cron     *cron.Cron
+	log      *logger.Logger
+	jobs     []Job
+	inFlight atomic.Int64
+
+	onStart []cron.EntryID // Jobs to run when the runner starts
+	started sync.WaitGroup // Runs begun by Start rather than the schedule
+
+	runCtx   context.Context    // Context for job runs, survives shutdown until the drain deadline
+	abortRun context.CancelFunc // Cancels running jobs when the drain deadline passes
+}
+
+// NewRunner creates a new maintenance job runner
+// A job whose previous run is still in progress is skipped rather than run concurrently.
+func NewRunner(log *logger.Logger) *Runner {
+	return &Runner{
+		cron:   cron.New(cron.WithChain(cron.SkipIfStillRunning(cron.DiscardLogger))),
+		log:    log,
+		runCtx: context.Background(),
+	}
+}
+
+// Register adds a job to the runner
+func (r *Runner) Register(job Job) error {
+	id, err := r.cron.AddFunc(job.Schedule, func() {
+		r.run(job)
+	})
+	if err != nil {
+		return err
+	}
+	if job.RunOnStart {
+		r.onStart = append(r.onStart, id)
+	}
+
+	r.jobs = append(r.jobs, job)
+	r.log.Info("Registered maintenance job", "job", job.Name, "schedule", job.Schedule)
+	return nil
+}
+
+// Start starts running registered jobs on their schedules, running those marked RunOnStart
+// straight away. A scheduled run is skipped while the startup run is still in progress.
+func (r *Runner) Start(ctx context.Context) {
+	r.runCtx, r.abortRun = context.WithCancel(context.WithoutCancel(ctx))
+	for _, id := range r.onStart {
+		job := r.cron.Entry(id).WrappedJob // Shares the skip-if-still-running guard with the schedule
+		r.started.Add(1)
+		go func() {
+			defer r.started.Done()
+			job.Run()
+		}()
+	}
+	r.cron.Start()
+	r.log.Info("Maintenance runner started", "jobs", len(r.jobs))
+}
+
+// Shutdown stops scheduling jobs and waits for running ones to finish
+// If ctx expires first, running jobs are cancelled and ctx.Err() is returned.
+func (r *Runner) Shutdown(ctx context.Context) error {
+	r.log.Info("Stopping maintenance runner", "in_flight", r.inFlight.Load())
+	stopped := r.cron.Stop()
+	drained := make(chan struct{})
+	go func() {
+		<-stopped.Done()
+		r.started.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		r.log.Info("Maintenance runner stopped")
+		return nil
+	case <-ctx.Done():
+		r.log.Warn("Maintenance runner drain deadline exceeded", "in_flight", r.inFlight.Load())
+		if r.abortRun != nil {
+			r.abortRun()
+		}
+		return ctx.Err()
+	}
+}
+
+// run executes a single job and logs its outcome
+func (r *Runner) run(job Job) {
+	r.inFlight.Add(1)
+	defer r.inFlight.Add(-1)
+
+	start := time.Now()
+	removed, err := job.Run(r.runCtx)
+	if err != nil {
+		r.log.Error("Maintenance job failed", "job", job.Name, "error", err, "removed", removed, "duration", time.Since(start))
+		return
+	}
+
+	if job.DryRun {
+		r.log.Info("Maintenance job dry run completed", "job", job.Name, "would_remove", removed, "duration", time.Since(start))
+		return
+	}
+	r.log.Info("Maintenance job completed", "job", job.Name, "removed", removed, "duration", time.Since(start))
+}
@@ -0,0 +1,134 @@
+package maintenance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+func TestRunnerRegister(t *testing.T) {
+	runner := NewRunner(logger.NewLogger())
+
+	noop := func(ctx context.Context) (int64, error) { return 0, nil }
+
+	if err := runner.Register(Job{Name: "valid", Schedule: "0 3 * * *", Run: noop}); err != nil {
+		t.Errorf("Register() error = %v", err)
+	}
+	if err := runner.Register(Job{Name: "invalid", Schedule: "not a schedule", Run: noop}); err == nil {
+		t.Error("Expected error for invalid schedule")
+	}
+	if len(runner.jobs) != 1 {
+		t.Errorf("registered jobs = %d, want 1", len(runner.jobs))
+	}
+}
+
+func TestRunnerRun(t *testing.T) {
+	runner := NewRunner(logger.NewLogger())
+
+	calls := 0
+	runner.run(Job{
+		Name: "count",
+		Run: func(ctx context.Context) (int64, error) {
+			calls++
+			return 3, nil
+		},
+	})
+
+	if calls != 1 {
+		t.Errorf("job calls = %d, want 1", calls)
+	}
+	if runner.inFlight.Load() != 0 {
+		t.Errorf("in-flight = %d after run, want 0", runner.inFlight.Load())
+	}
+}
+
+func TestRunnerRunOnStart(t *testing.T) {
+	runner := NewRunner(logger.NewLogger())
+
+	ran := make(chan string, 2)
+	job := func(name string) Job {
+		return Job{
+			Name:     name,
+			Schedule: "0 3 * * *",
+			Run: func(ctx context.Context) (int64, error) {
+				ran <- name
+				return 0, nil
+			},
+		}
+	}
+	startup := job("startup")
+	startup.RunOnStart = true
+	for _, j := range []Job{startup, job("scheduled")} {
+		if err := runner.Register(j); err != nil {
+			t.Fatalf("Register() error = %v", err)
+		}
+	}
+
+	runner.Start(context.Background())
+	if err := runner.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	close(ran)
+	var names []string
+	for name := range ran {
+		names = append(names, name)
+	}
+	if len(names) != 1 || names[0] != "startup" {
+		t.Errorf("jobs run at start = %v, want only startup", names)
+	}
+}
+
+func TestRetentionPolicyDaysFor(t *testing.T) {
+	policy := RetentionPolicy{
+		DefaultDays: 30,
+		TenantDays: map[string]int{
+			"tenant-long": 365,
+			"tenant-keep": 0,
+		},
+	}
+
+	tests := map[string]int{
+		"tenant-long":  365,
+		"tenant-keep":  0,
+		"tenant-other": 30,
+	}
+	for tenantID, want := range tests {
+		if got := policy.DaysFor(tenantID); got != want {
+			t.Errorf("DaysFor(%q) = %d, want %d", tenantID, got, want)
+		}
+	}
+}
+
+func TestContentRetentionPolicyDaysFor(t *testing.T) {
+	policy := ContentRetentionPolicy{
+		DefaultDays: 30,
+		TenantDays: map[string]int{
+			"tenant-short": 7,
+			"tenant-keep":  0,
+		},
+		CategoryDays: map[string]int{
+			"marketing": 14,
+			"legal":     0,
+		},
+	}
+
+	tests := []struct {
+		tenantID string
+		category string
+		want     int
+	}{
+		{"tenant-other", "", 30},
+		{"tenant-other", "marketing", 14},
+		{"tenant-other", "legal", 30},
+		{"tenant-short", "marketing", 7},
+		{"tenant-keep", "", 0},
+		{"tenant-keep", "marketing", 14},
+	}
+	for _, tt := range tests {
+		if got := policy.DaysFor(tt.tenantID, tt.category); got != tt.want {
+			t.Errorf("DaysFor(%q, %q) = %d, want %d", tt.tenantID, tt.category, got, tt.want)
+		}
+	}
+}
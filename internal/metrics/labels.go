@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// TenantLabelMode controls how tenant IDs appear as metric label values
+type TenantLabelMode int32
+
+const (
+	// TenantLabelRaw uses the tenant ID as-is (default)
+	TenantLabelRaw TenantLabelMode = iota
+	// TenantLabelHash replaces the tenant ID with a short stable hash
+	TenantLabelHash
+	// TenantLabelDrop leaves the tenant label empty so series aggregate across tenants
+	TenantLabelDrop
+	// TenantLabelAllowlist keeps allowlisted tenant IDs and buckets the rest into "other"
+	TenantLabelAllowlist
+)
+
+const (
+	// hashedTenantLabelLength is the number of hex characters kept from the tenant ID hash
+	hashedTenantLabelLength = 12
+
+	// OtherTenantLabel is the label value for tenants outside the allowlist
+	OtherTenantLabel = "other"
+)
+
+var (
+	tenantLabelMode      atomic.Int32
+	tenantLabelAllowlist atomic.Pointer[map[string]struct{}]
+)
+
+// ParseTenantLabelMode parses a tenant label mode name (raw, hash, drop, allowlist)
+func ParseTenantLabelMode(value string) (TenantLabelMode, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "raw":
+		return TenantLabelRaw, nil
+	case "hash":
+		return TenantLabelHash, nil
+	case "drop":
+		return TenantLabelDrop, nil
+	case "allowlist":
+		return TenantLabelAllowlist, nil
+	default:
+		return TenantLabelRaw, fmt.Errorf("invalid tenant label mode: %s (must be raw, hash, drop or allowlist)", value)
+	}
+}
+
+// SetTenantLabelMode sets how tenant IDs are exposed in metric labels
+func SetTenantLabelMode(mode TenantLabelMode) {
+	tenantLabelMode.Store(int32(mode))
+}
+
+// SetTenantLabelAllowlist sets the tenant IDs that keep their own label in allowlist mode
+func SetTenantLabelAllowlist(tenantIDs []string) {
+	allowlist := make(map[string]struct{}, len(tenantIDs))
+	for _, id := range tenantIDs {
+		if id = strings.TrimSpace(id); id != "" {
+			allowlist[id] = struct{}{}
+		}
+	}
+	tenantLabelAllowlist.Store(&allowlist)
+}
+
+// TenantLabel returns the label value to use for a tenant ID under the configured mode
+func TenantLabel(tenantID string) string {
+	if tenantID == "" {
+		return tenantID
+	}
+
+	switch TenantLabelMode(tenantLabelMode.Load()) {
+	case TenantLabelHash:
+		sum := sha256.Sum256([]byte(tenantID))
+		return hex.EncodeToString(sum[:])[:hashedTenantLabelLength]
+	case TenantLabelDrop:
+		return ""
+	case TenantLabelAllowlist:
+		if allowlist := tenantLabelAllowlist.Load(); allowlist != nil {
+			if _, ok := (*allowlist)[tenantID]; ok {
+				return tenantID
+			}
+		}
+		return OtherTenantLabel
+	default:
+		return tenantID
+	}
+}
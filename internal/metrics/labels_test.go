@@ -0,0 +1,55 @@
+package metrics
+
+import "testing"
+
+func TestTenantLabel(t *testing.T) {
+	defer SetTenantLabelMode(TenantLabelRaw)
+
+	SetTenantLabelMode(TenantLabelRaw)
+	if got := TenantLabel("tenant-a"); got != "tenant-a" {
+		t.Errorf("TenantLabel() raw = %q, want %q", got, "tenant-a")
+	}
+
+	SetTenantLabelMode(TenantLabelHash)
+	hashed := TenantLabel("tenant-a")
+	if hashed == "tenant-a" || len(hashed) != hashedTenantLabelLength {
+		t.Errorf("TenantLabel() hash = %q", hashed)
+	}
+	if TenantLabel("tenant-a") != hashed {
+		t.Error("TenantLabel() hash is not stable")
+	}
+	if TenantLabel("tenant-b") == hashed {
+		t.Error("TenantLabel() hash collides for different tenants")
+	}
+
+	SetTenantLabelMode(TenantLabelDrop)
+	if got := TenantLabel("tenant-a"); got != "" {
+		t.Errorf("TenantLabel() drop = %q, want empty", got)
+	}
+
+	SetTenantLabelMode(TenantLabelAllowlist)
+	SetTenantLabelAllowlist([]string{"tenant-a", " tenant-vip "})
+	defer SetTenantLabelAllowlist(nil)
+
+	tests := map[string]string{
+		"tenant-a":   "tenant-a",
+		"tenant-vip": "tenant-vip",
+		"tenant-b":   OtherTenantLabel,
+	}
+	for tenantID, want := range tests {
+		if got := TenantLabel(tenantID); got != want {
+			t.Errorf("TenantLabel(%q) allowlist = %q, want %q", tenantID, got, want)
+		}
+	}
+}
+
+func TestParseTenantLabelMode(t *testing.T) {
+	for _, value := range []string{"", "raw", "HASH", "drop", "allowlist"} {
+		if _, err := ParseTenantLabelMode(value); err != nil {
+			t.Errorf("ParseTenantLabelMode(%q) error = %v", value, err)
+		}
+	}
+	if _, err := ParseTenantLabelMode("drop-all"); err == nil {
+		t.Error("Expected error for unknown tenant label mode")
+	}
+}
@@ -1,86 +1,456 @@
-package metrics
-
-import (
-	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promauto"
-)
-
-var (
-	// NotificationsSent tracks the total number of notifications sent
-	NotificationsSent = promauto.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "notification_service_sent_total",
-			Help: "Total number of notifications sent",
-		},
-		[]string{"type", "tenant_id", "status"},
-	)
-
-	// NotificationDuration tracks notification sending duration
-	NotificationDuration = promauto.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name:    "notification_service_duration_seconds",
-			Help:    "Notification sending duration in seconds",
-			Buckets: prometheus.DefBuckets,
-		},
-		[]string{"type"},
-	)
-
-	// EmailQueueSize tracks the current email queue size
-	EmailQueueSize = promauto.NewGauge(
-		prometheus.GaugeOpts{
-			Name: "notification_service_email_queue_size",
-			Help: "Current number of emails in the priority queue",
-		},
-	)
-
-	// SMTPConnectionPool tracks the number of SMTP connections in the pool
-	SMTPConnectionPool = promauto.NewGauge(
-		prometheus.GaugeOpts{
-			Name: "notification_service_smtp_connections",
-			Help: "Number of active SMTP connections in the pool",
-		},
-	)
-
-	// FailedNotifications tracks the number of failed notifications
-	FailedNotifications = promauto.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "notification_service_failed_total",
-			Help: "Total number of failed notifications",
-		},
-		[]string{"type", "tenant_id", "reason"},
-	)
-
-	// DLQSize tracks the size of the dead letter queue
-	DLQSize = promauto.NewGauge(
-		prometheus.GaugeOpts{
-			Name: "notification_service_dlq_size",
-			Help: "Number of notifications in the dead letter queue",
-		},
-	)
-
-	// EmailBounces tracks email bounce events
-	EmailBounces = promauto.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "notification_service_email_bounces_total",
-			Help: "Total number of email bounce events",
-		},
-		[]string{"type"}, // hard, soft, complaint
-	)
-
-	// RateLimitExceeded tracks rate limit violations
-	RateLimitExceeded = promauto.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "notification_service_rate_limit_exceeded_total",
-			Help: "Total number of rate limit exceeded events",
-		},
-		[]string{"tenant_id"},
-	)
-
-	// ConsumerRestarts tracks event consumer restart events
-	ConsumerRestarts = promauto.NewCounter(
-		prometheus.CounterOpts{
-			Name: "notification_service_consumer_restarts_total",
-			Help: "Total number of event consumer restarts",
-		},
-	)
-)
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// NotificationsSent tracks the total number of notifications sent
+	NotificationsSent = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notification_service_sent_total",
+			Help: "Total number of notifications sent",
+		},
+		[]string{"type", "tenant_id", "status"},
+	)
+
+	// NotificationDuration tracks notification sending duration
+	NotificationDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "notification_service_duration_seconds",
+			Help:    "Notification sending duration in seconds",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"type"},
+	)
+
+	// EmailQueueSize tracks the current email queue size
+	EmailQueueSize = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "notification_service_email_queue_size",
+			Help: "Current number of emails in the priority queue",
+		},
+	)
+
+	// EmailQueueRejected tracks email jobs turned away because the priority queue was full
+	EmailQueueRejected = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notification_service_email_queue_rejected_total",
+			Help: "Total number of email jobs rejected because the priority queue was full",
+		},
+		[]string{"reason"}, // full when rejected at once, timeout after waiting for room
+	)
+
+	// SMTPConnectionPool tracks the number of SMTP connections in the pool
+	SMTPConnectionPool = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "notification_service_smtp_connections",
+			Help: "Number of active SMTP connections in the pool",
+		},
+	)
+
+	// SMTPConnectionMessages tracks the messages sent on each pooled SMTP connection since it was opened
+	SMTPConnectionMessages = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "notification_service_smtp_connection_messages",
+			Help: "Messages sent on each pooled SMTP connection since it was opened",
+		},
+		[]string{"connection"},
+	)
+
+	// SMTPConnectionSends tracks the total messages sent through each SMTP pool slot
+	SMTPConnectionSends = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notification_service_smtp_connection_sends_total",
+			Help: "Total number of messages sent through each SMTP pool slot",
+		},
+		[]string{"connection"},
+	)
+
+	// SMTPConnectionsRecycled tracks pooled SMTP connections closed and replaced, by reason
+	SMTPConnectionsRecycled = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notification_service_smtp_connections_recycled_total",
+			Help: "Total number of pooled SMTP connections closed and replaced, by reason",
+		},
+		[]string{"reason"},
+	)
+
+	// FailedNotifications tracks the number of failed notifications
+	FailedNotifications = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notification_service_failed_total",
+			Help: "Total number of failed notifications",
+		},
+		[]string{"type", "tenant_id", "reason"},
+	)
+
+	// DLQSize tracks the size of the dead letter queue
+	DLQSize = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "notification_service_dlq_size",
+			Help: "Number of notifications in the dead letter queue",
+		},
+	)
+
+	// EmailBounces tracks email bounce events
+	EmailBounces = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notification_service_email_bounces_total",
+			Help: "Total number of email bounce events",
+		},
+		[]string{"type"}, // hard, soft, complaint
+	)
+
+	// RateLimitExceeded tracks rate limit violations
+	RateLimitExceeded = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notification_service_rate_limit_exceeded_total",
+			Help: "Total number of rate limit exceeded events",
+		},
+		[]string{"tenant_id"},
+	)
+
+	// ConsumerRestarts tracks event consumer restart events
+	ConsumerRestarts = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "notification_service_consumer_restarts_total",
+			Help: "Total number of event consumer restarts",
+		},
+	)
+
+	// QuotaExceeded tracks sends over a tenant quota, rejected (hard) or allowed with a warning (soft)
+	QuotaExceeded = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notification_service_quota_exceeded_total",
+			Help: "Total number of sends exceeding a tenant quota",
+		},
+		[]string{"tenant_id", "channel", "policy"},
+	)
+
+	// DependencyDegraded reports whether a dependency is in degraded mode (1) or healthy (0)
+	DependencyDegraded = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "notification_service_dependency_degraded",
+			Help: "Whether a dependency is in degraded mode (1) or healthy (0)",
+		},
+		[]string{"dependency"},
+	)
+
+	// SendsPaused reports whether sends on a channel are paused (1) or active (0)
+	SendsPaused = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "notification_service_sends_paused",
+			Help: "Whether sends on a channel are paused (1) or active (0)",
+		},
+		[]string{"channel"},
+	)
+
+	// NotificationsHeld tracks notifications held because their channel was paused
+	NotificationsHeld = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notification_service_held_total",
+			Help: "Total number of notifications held while their channel was paused",
+		},
+		[]string{"channel"},
+	)
+
+	// MongoOperationsRetried tracks MongoDB reads retried after a transient error
+	MongoOperationsRetried = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notification_service_mongodb_operations_retried_total",
+			Help: "Total number of MongoDB read operations retried after a transient error",
+		},
+		[]string{"operation"},
+	)
+
+	// SendRetries tracks send attempts retried under a retry policy
+	SendRetries = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notification_service_send_retries_total",
+			Help: "Total number of notification send attempts retried under a retry policy",
+		},
+		[]string{"channel"},
+	)
+
+	// NotificationsDeduplicated tracks sends suppressed as identical to a recent send
+	NotificationsDeduplicated = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notification_service_deduplicated_total",
+			Help: "Total number of sends suppressed as identical to one sent to the same recipients within the deduplication window",
+		},
+		[]string{"channel", "tenant_id"},
+	)
+
+	// IdempotentReplays tracks sends answered with the notification an earlier send created under
+	// the same idempotency key, instead of sending again
+	IdempotentReplays = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notification_service_idempotent_replays_total",
+			Help: "Total number of sends not repeated because an earlier send used the same idempotency key",
+		},
+		[]string{"channel", "tenant_id"},
+	)
+
+	// RecipientsThrottled tracks recipients a send was withheld from for reaching their rate limit
+	RecipientsThrottled = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notification_service_recipients_throttled_total",
+			Help: "Total number of recipients a send was withheld from because they reached the per-recipient rate limit",
+		},
+		[]string{"channel", "tenant_id"},
+	)
+
+	// ScheduleExecutionsWaiting tracks due schedule executions waiting for a free slot
+	ScheduleExecutionsWaiting = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "notification_service_schedule_executions_waiting",
+			Help: "Number of due schedule executions waiting for a slot under SCHEDULER_MAX_CONCURRENT",
+		},
+	)
+
+	// ScheduleExecutionsDelayed tracks schedule executions that had to wait for a free slot
+	ScheduleExecutionsDelayed = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "notification_service_schedule_executions_delayed_total",
+			Help: "Total number of schedule executions delayed by the concurrency limit",
+		},
+	)
+
+	// NotificationsDigested tracks emails collected into digests instead of being sent
+	NotificationsDigested = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notification_service_digested_total",
+			Help: "Total number of emails collected into a recipient's digest",
+		},
+		[]string{"tenant_id"},
+	)
+
+	// DigestsSent tracks digest deliveries by outcome
+	DigestsSent = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notification_service_digests_total",
+			Help: "Total number of digests sent or failed",
+		},
+		[]string{"status"},
+	)
+
+	// WebhookRetries tracks webhooks queued for a later attempt and the outcomes of those attempts
+	WebhookRetries = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notification_service_webhook_retries_total",
+			Help: "Total number of webhook retries queued, rescheduled, sent or failed",
+		},
+		[]string{"status"},
+	)
+
+	// WebhookFallbacks tracks fallback notifications for failed webhooks by outcome
+	WebhookFallbacks = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notification_service_webhook_fallbacks_total",
+			Help: "Total number of fallback notifications for failed webhooks sent, failed or rate limited",
+		},
+		[]string{"status"},
+	)
+
+	// NotificationsEscalated tracks channels sent because an earlier channel wasn't delivered in time
+	NotificationsEscalated = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notification_service_escalations_total",
+			Help: "Total number of orchestrated channels sent by escalation",
+		},
+		[]string{"type"},
+	)
+
+	// CriticalEscalations tracks escalation notices for unacknowledged critical notifications by outcome
+	CriticalEscalations = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notification_service_critical_escalations_total",
+			Help: "Total number of escalation notices for unacknowledged critical notifications sent or failed",
+		},
+		[]string{"type", "status"},
+	)
+
+	// ContentChecks tracks email content spam checks by result
+	ContentChecks = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notification_service_content_checks_total",
+			Help: "Total number of email content spam checks that passed, warned or blocked",
+		},
+		[]string{"result"},
+	)
+
+	// CacheLookups tracks shared cache lookups by kind and result (hit, miss, error or bypassed)
+	CacheLookups = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notification_service_cache_lookups_total",
+			Help: "Total number of shared cache lookups by kind and result",
+		},
+		[]string{"kind", "result"},
+	)
+
+	// InboundEmails tracks inbound email webhooks by provider and result
+	InboundEmails = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notification_service_inbound_emails_total",
+			Help: "Total number of inbound emails stored as replies, duplicated, unmatched, rejected or invalid",
+		},
+		[]string{"provider", "result"},
+	)
+
+	// NotificationsAcknowledged tracks notifications explicitly acknowledged by their recipient
+	NotificationsAcknowledged = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notification_service_acknowledgments_total",
+			Help: "Total number of notifications acknowledged by their recipient",
+		},
+		[]string{"type", "priority"},
+	)
+
+	// AuditWriteFailures tracks audit log entries that could not be written
+	AuditWriteFailures = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "notification_service_audit_write_failures_total",
+			Help: "Total number of audit log entries that could not be written",
+		},
+	)
+
+	// EventsRejected tracks consumed events rejected without requeue, whether or not they are dead-lettered
+	EventsRejected = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notification_service_events_rejected_total",
+			Help: "Total number of consumed events rejected without requeue",
+		},
+		[]string{"reason"},
+	)
+
+	// EventsDeadLettered tracks consumed events rejected to the dead-letter exchange
+	EventsDeadLettered = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notification_service_events_dead_lettered_total",
+			Help: "Total number of consumed events sent to the dead-letter exchange",
+		},
+		[]string{"reason"},
+	)
+
+	// EventsReplayed tracks events replayed through the admin endpoint by outcome
+	EventsReplayed = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notification_service_events_replayed_total",
+			Help: "Total number of events replayed outside RabbitMQ, by outcome",
+		},
+		[]string{"status"},
+	)
+
+	// RowsImported tracks template and preference rows bulk imported from files by outcome
+	RowsImported = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notification_service_import_rows_total",
+			Help: "Total number of rows bulk imported from files, by kind and outcome",
+		},
+		[]string{"kind", "status"},
+	)
+
+	// OutboxPendingEvents tracks the number of pending outbox events per tenant
+	OutboxPendingEvents = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "notification_service_outbox_pending_events",
+			Help: "Number of outbox events waiting to be processed",
+		},
+		[]string{"tenant_id"},
+	)
+
+	// OutboxOldestPendingAge tracks the age of the oldest pending outbox event
+	OutboxOldestPendingAge = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "notification_service_outbox_oldest_pending_age_seconds",
+			Help: "Age in seconds of the oldest pending outbox event (0 when none are pending)",
+		},
+	)
+
+	// OutboxProcessingDuration tracks time from outbox event creation to processing
+	OutboxProcessingDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "notification_service_outbox_processing_duration_seconds",
+			Help:    "Time from outbox event creation until it is processed, in seconds",
+			Buckets: []float64{0.1, 0.5, 1, 5, 15, 30, 60, 300, 900, 3600},
+		},
+		[]string{"event_type"},
+	)
+
+	// OutboxEventsFailed tracks outbox events that failed processing
+	OutboxEventsFailed = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notification_service_outbox_failed_total",
+			Help: "Total number of outbox events that failed processing",
+		},
+		[]string{"event_type"},
+	)
+
+	// OutboxEventsRetried tracks failed outbox event publishes left pending to be retried
+	OutboxEventsRetried = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notification_service_outbox_retried_total",
+			Help: "Total number of failed outbox event publishes scheduled for retry",
+		},
+		[]string{"event_type"},
+	)
+
+	// WebhookResponseDuration tracks webhook endpoint response time by HTTP status class
+	WebhookResponseDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "notification_service_webhook_response_duration_seconds",
+			Help:    "Webhook endpoint response time in seconds",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"status_class"}, // 2xx, 3xx, 4xx, 5xx, error
+	)
+
+	// SoftDeletedPurged tracks soft-deleted records permanently removed by retention purges
+	SoftDeletedPurged = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notification_service_soft_deleted_purged_total",
+			Help: "Total number of soft-deleted records permanently removed",
+		},
+		[]string{"collection", "tenant_id"},
+	)
+
+	// StuckNotificationsReconciled tracks notifications found stuck mid-send and failed or requeued
+	StuckNotificationsReconciled = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notification_service_stuck_notifications_reconciled_total",
+			Help: "Total number of notifications stuck in queued or sending that were reconciled",
+		},
+		[]string{"action", "tenant_id"}, // action: failed, requeued, requeue_failed
+	)
+
+	// TenantBounceRate tracks each tenant's hard bounce and complaint rates over the reputation window
+	// Tenants sharing a label report the highest rate among them.
+	TenantBounceRate = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "notification_service_tenant_bounce_rate",
+			Help: "Tenant's hard bounce or complaint rate over the reputation window; the highest among tenants sharing a label",
+		},
+		[]string{"tenant_id", "kind"}, // bounce, complaint
+	)
+
+	// TenantEmailPaused counts the tenants whose email is paused for their bounce or complaint rate
+	// It is 0 or 1 for a label with a single tenant.
+	TenantEmailPaused = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "notification_service_tenant_email_paused",
+			Help: "Number of tenants with the label whose email is paused for their bounce or complaint rate",
+		},
+		[]string{"tenant_id"},
+	)
+
+	// ReputationPauses tracks tenants' email paused automatically for high bounce or complaint rates
+	ReputationPauses = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notification_service_reputation_pauses_total",
+			Help: "Total number of times a tenant's email was paused for its bounce or complaint rate",
+		},
+		[]string{"tenant_id"},
+	)
+)
@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"fmt"
+	"time"
+)
+
+// Send status label values
+const (
+	StatusSent   = "sent"
+	StatusFailed = "failed"
+)
+
+// ObserveSend records the outcome and duration of a single notification send
+func ObserveSend(notificationType, tenantID string, start time.Time, err error) {
+	NotificationDuration.WithLabelValues(notificationType).Observe(time.Since(start).Seconds())
+	tenantLabel := TenantLabel(tenantID)
+
+	if err != nil {
+		NotificationsSent.WithLabelValues(notificationType, tenantLabel, StatusFailed).Inc()
+		FailedNotifications.WithLabelValues(notificationType, tenantLabel, "send_error").Inc()
+		return
+	}
+	NotificationsSent.WithLabelValues(notificationType, tenantLabel, StatusSent).Inc()
+}
+
+// ObserveIdempotentReplay records a send that was not repeated because its idempotency key
+// matched an earlier send's. Many replays from one tenant suggest a caller retrying in a loop.
+func ObserveIdempotentReplay(notificationType, tenantID string) {
+	IdempotentReplays.WithLabelValues(notificationType, TenantLabel(tenantID)).Inc()
+}
+
+// ObserveWebhookResponse records a webhook delivery response time.
+// statusCode is 0 when no response was received (timeout, connection error).
+func ObserveWebhookResponse(duration time.Duration, statusCode int) {
+	WebhookResponseDuration.WithLabelValues(StatusClass(statusCode)).Observe(duration.Seconds())
+}
+
+// StatusClass maps an HTTP status code to its class label, e.g. 404 -> "4xx"
+func StatusClass(statusCode int) string {
+	if statusCode < 100 || statusCode > 599 {
+		return "error"
+	}
+	return fmt.Sprintf("%dxx", statusCode/100)
+}
@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestStatusClass(t *testing.T) {
+	tests := []struct {
+		code int
+		want string
+	}{
+		{code: 200, want: "2xx"},
+		{code: 204, want: "2xx"},
+		{code: 301, want: "3xx"},
+		{code: 429, want: "4xx"},
+		{code: 503, want: "5xx"},
+		{code: 0, want: "error"},
+		{code: 999, want: "error"},
+	}
+
+	for _, tt := range tests {
+		if got := StatusClass(tt.code); got != tt.want {
+			t.Errorf("StatusClass(%d) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestObserveSend(t *testing.T) {
+	start := time.Now()
+
+	ObserveSend("sms", "tenant-observe", start, nil)
+	ObserveSend("sms", "tenant-observe", start, errors.New("provider unavailable"))
+
+	if got := testutil.ToFloat64(NotificationsSent.WithLabelValues("sms", "tenant-observe", StatusSent)); got != 1 {
+		t.Errorf("sent count = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(NotificationsSent.WithLabelValues("sms", "tenant-observe", StatusFailed)); got != 1 {
+		t.Errorf("failed status count = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(FailedNotifications.WithLabelValues("sms", "tenant-observe", "send_error")); got != 1 {
+		t.Errorf("failed count = %v, want 1", got)
+	}
+}
+
+func TestObserveIdempotentReplay(t *testing.T) {
+	ObserveIdempotentReplay("webhook", "tenant-replay")
+	ObserveIdempotentReplay("webhook", "tenant-replay")
+
+	if got := testutil.ToFloat64(IdempotentReplays.WithLabelValues("webhook", "tenant-replay")); got != 2 {
+		t.Errorf("replay count = %v, want 2", got)
+	}
+}
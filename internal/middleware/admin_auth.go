@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ActorHeader is the HTTP header identifying the operator behind an administrative request
+const ActorHeader = "X-Actor-ID"
+
+// AdminAuthMiddleware protects administrative endpoints with a bearer token.
+// Unlike the metrics endpoint, requests are always rejected when no token is configured.
+func AdminAuthMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token != "" {
+			if bearer, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok && secureCompare(bearer, token) {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "Unauthorized",
+			"message": "A valid admin token is required",
+			"code":    "UNAUTHORIZED",
+		})
+		c.Abort()
+	}
+}
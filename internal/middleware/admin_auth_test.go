@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/pause"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+func TestAdminAuthMiddleware(t *testing.T) {
+	tests := []struct {
+		name          string
+		token         string
+		authorization string
+		wantStatus    int
+	}{
+		{name: "valid token", token: "s3cret", authorization: "Bearer s3cret", wantStatus: http.StatusOK},
+		{name: "wrong token", token: "s3cret", authorization: "Bearer guess", wantStatus: http.StatusUnauthorized},
+		{name: "missing token", token: "s3cret", wantStatus: http.StatusUnauthorized},
+		{name: "no token configured", authorization: "Bearer ", wantStatus: http.StatusUnauthorized},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.POST("/admin/pause", AdminAuthMiddleware(tt.token), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/pause", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestSendPauseMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	controller := pause.NewController(nil, pause.Config{}, domain.SendPause{
+		Channels: []domain.NotificationType{domain.NotificationTypeEmail},
+	}, logger.NewLogger())
+
+	router := gin.New()
+	router.POST("/bulk/email", SendPauseMiddleware(controller, domain.NotificationTypeEmail), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	router.POST("/sms", SendPauseMiddleware(controller, domain.NotificationTypeSMS), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	for path, want := range map[string]int{"/bulk/email": http.StatusServiceUnavailable, "/sms": http.StatusOK} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, nil))
+		if w.Code != want {
+			t.Errorf("POST %s status = %d, want %d", path, w.Code, want)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/apitoken"
+	"github.com/vhvplatform/go-notification-service/internal/shared/errors"
+)
+
+// TokenClaimsKey is the context key for the claims of the request's API token
+const TokenClaimsKey = "api_token_claims"
+
+// APIAuthMiddleware authenticates API requests with a bearer token issued by signer.
+// Missing, invalid and expired tokens get 401; tokens for another tenant or without the route's
+// RequiredScope get 403. It must run after TenancyMiddleware.
+func APIAuthMiddleware(signer *apitoken.Signer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		bearer, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || bearer == "" {
+			c.Error(errors.NewUnauthorizedError("A bearer API token is required", nil))
+			c.Abort()
+			return
+		}
+		claims, err := signer.Verify(bearer)
+		if err != nil {
+			c.Error(errors.NewUnauthorizedError("A valid API token is required", err))
+			c.Abort()
+			return
+		}
+
+		if claims.TenantID != GetTenantID(c) {
+			c.Error(errors.NewForbiddenError("API token does not belong to this tenant", nil))
+			c.Abort()
+			return
+		}
+		if scope := RequiredScope(c.Request.Method, c.FullPath()); !claims.HasScope(scope) {
+			c.Error(errors.NewForbiddenError("API token lacks the "+scope+" scope", nil).WithField("required_scope", scope))
+			c.Abort()
+			return
+		}
+
+		c.Set(TokenClaimsKey, claims)
+		c.Next()
+	}
+}
+
+// GetTokenClaims returns the claims of the request's API token, or nil without one
+func GetTokenClaims(c *gin.Context) *apitoken.Claims {
+	if claims, ok := c.Get(TokenClaimsKey); ok {
+		if tc, ok := claims.(*apitoken.Claims); ok {
+			return tc
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/apitoken"
+)
+
+func TestAPIAuthMiddleware(t *testing.T) {
+	signer := apitoken.NewSigner("secret-0123456789abcdef0123456789abcd")
+	sendToken, _, _ := signer.Issue("tenant-1", []string{apitoken.ScopeSend}, time.Hour)
+	readToken, _, _ := signer.Issue("tenant-1", []string{apitoken.ScopeRead}, time.Hour)
+	adminToken, _, _ := signer.Issue("tenant-1", []string{apitoken.ScopeAdmin}, time.Hour)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		token      string
+		tenantID   string
+		wantStatus int
+	}{
+		{name: "send token sends", method: http.MethodPost, path: "/api/v1/notifications/email", token: sendToken, tenantID: "tenant-1", wantStatus: http.StatusOK},
+		{name: "read token reads", method: http.MethodGet, path: "/api/v1/notifications/n1", token: readToken, tenantID: "tenant-1", wantStatus: http.StatusOK},
+		{name: "read token checks status", method: http.MethodPost, path: "/api/v1/notifications/status", token: readToken, tenantID: "tenant-1", wantStatus: http.StatusOK},
+		{name: "read token cannot send", method: http.MethodPost, path: "/api/v1/notifications/email", token: readToken, tenantID: "tenant-1", wantStatus: http.StatusForbidden},
+		{name: "send token cannot change settings", method: http.MethodPut, path: "/api/v1/webhook-allowlist", token: sendToken, tenantID: "tenant-1", wantStatus: http.StatusForbidden},
+		{name: "admin token changes settings", method: http.MethodPut, path: "/api/v1/webhook-allowlist", token: adminToken, tenantID: "tenant-1", wantStatus: http.StatusOK},
+		{name: "send token cannot retry dead letters", method: http.MethodPost, path: "/api/v1/dlq/f1/retry", token: sendToken, tenantID: "tenant-1", wantStatus: http.StatusForbidden},
+		{name: "read token cannot list dead letters", method: http.MethodGet, path: "/api/v1/dlq", token: readToken, tenantID: "tenant-1", wantStatus: http.StatusForbidden},
+		{name: "admin token retries dead letters", method: http.MethodPost, path: "/api/v1/dlq/f1/retry", token: adminToken, tenantID: "tenant-1", wantStatus: http.StatusOK},
+		{name: "other tenant", method: http.MethodGet, path: "/api/v1/notifications/n1", token: adminToken, tenantID: "tenant-2", wantStatus: http.StatusForbidden},
+		{name: "missing token", method: http.MethodGet, path: "/api/v1/notifications/n1", tenantID: "tenant-1", wantStatus: http.StatusUnauthorized},
+		{name: "invalid token", method: http.MethodGet, path: "/api/v1/notifications/n1", token: "guess", tenantID: "tenant-1", wantStatus: http.StatusUnauthorized},
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ErrorHandlerMiddleware())
+	v1 := router.Group("/api/v1", TenancyMiddleware(), APIAuthMiddleware(signer))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	v1.POST("/notifications/email", ok)
+	v1.POST("/notifications/status", ok)
+	v1.GET("/notifications/:id", ok)
+	v1.PUT("/webhook-allowlist", ok)
+	v1.GET("/dlq", ok)
+	v1.POST("/dlq/:id/retry", ok)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set(TenantIDHeader, tt.tenantID)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRequiredScope(t *testing.T) {
+	tests := []struct {
+		method, route, want string
+	}{
+		{http.MethodPost, "/api/v1/notifications/sms", apitoken.ScopeSend},
+		{http.MethodGet, "/api/v1/recipient-lists/:id", apitoken.ScopeRead},
+		{http.MethodPost, "/api/v1/scheduled/validate", apitoken.ScopeRead},
+		{http.MethodDelete, "/api/v1/notifications/:id", apitoken.ScopeAdmin},
+		{http.MethodGet, "/api/v1/audit-logs", apitoken.ScopeAdmin},
+		// Unlisted writes default to admin
+		{http.MethodPut, "/api/v1/some-new-setting", apitoken.ScopeAdmin},
+	}
+	for _, tt := range tests {
+		if got := RequiredScope(tt.method, tt.route); got != tt.want {
+			t.Errorf("RequiredScope(%s %s) = %s, want %s", tt.method, tt.route, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/audit"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+)
+
+// AuditResourceIDKey is the key handlers set to the ID of a resource created by the request,
+// which has no path parameter to read it from
+const AuditResourceIDKey = "audit_resource_id"
+
+// AuditMiddleware records the request in the audit log once the handler has run.
+// The resource ID is read from AuditResourceIDKey, falling back to the idParam path parameter.
+// A nil recorder disables auditing.
+func AuditMiddleware(recorder *audit.Recorder, action, resourceType, idParam string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if recorder == nil {
+			return
+		}
+
+		status := responseStatus(c)
+		result := domain.AuditResultSuccess
+		if status >= 400 {
+			result = domain.AuditResultFailure
+		}
+
+		resourceID := c.GetString(AuditResourceIDKey)
+		if resourceID == "" && idParam != "" {
+			resourceID = c.Param(idParam)
+		}
+
+		recorder.Record(c.Request.Context(), &domain.AuditLog{
+			TenantID:     c.GetString(AuditTenantIDKey),
+			Actor:        Actor(c),
+			Action:       action,
+			ResourceType: resourceType,
+			ResourceID:   resourceID,
+			Result:       result,
+			StatusCode:   status,
+			ClientIP:     c.ClientIP(),
+			Timestamp:    time.Now(),
+		})
+	}
+}
+
+// Actor returns the operator or client behind a request, identified by ActorHeader or else
+// by the ID of its API token
+func Actor(c *gin.Context) string {
+	if id := c.GetHeader(ActorHeader); id != "" {
+		return id
+	}
+	if claims := GetTokenClaims(c); claims != nil && claims.ID != "" {
+		return "token:" + claims.ID
+	}
+	return "anonymous"
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/audit"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/shared/errors"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// memoryAuditStore keeps audit entries in memory
+type memoryAuditStore struct {
+	entries []*domain.AuditLog
+}
+
+func (s *memoryAuditStore) Create(ctx context.Context, entry *domain.AuditLog) error {
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+func TestAuditMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := &memoryAuditStore{}
+	recorder := audit.NewRecorder(store, logger.NewLogger())
+
+	router := gin.New()
+	router.Use(ErrorHandlerMiddleware(), TenancyMiddleware())
+	router.PUT("/schedules/:id", AuditMiddleware(recorder, "schedule.update", "schedule", "id"), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	router.POST("/schedules", AuditMiddleware(recorder, "schedule.create", "schedule", ""), func(c *gin.Context) {
+		c.Set(AuditResourceIDKey, "created-id")
+		c.Error(errors.NewValidationError("Invalid request", nil))
+	})
+
+	req := httptest.NewRequest(http.MethodPut, "/schedules/sched-1", nil)
+	req.Header.Set(TenantIDHeader, "tenant-a")
+	req.Header.Set(ActorHeader, "user-1")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	req = httptest.NewRequest(http.MethodPost, "/schedules", nil)
+	req.Header.Set(TenantIDHeader, "tenant-a")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(store.entries) != 2 {
+		t.Fatalf("recorded %d entries, want 2", len(store.entries))
+	}
+
+	update := store.entries[0]
+	if update.TenantID != "tenant-a" || update.Actor != "user-1" || update.Action != "schedule.update" ||
+		update.ResourceID != "sched-1" || update.Result != domain.AuditResultSuccess || update.StatusCode != http.StatusOK {
+		t.Errorf("update entry = %+v", update)
+	}
+
+	create := store.entries[1]
+	if create.Actor != "anonymous" || create.ResourceID != "created-id" ||
+		create.Result != domain.AuditResultFailure || create.StatusCode != http.StatusBadRequest {
+		t.Errorf("create entry = %+v", create)
+	}
+}
+
+func TestAuditMiddlewareNilRecorder(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/pause", AuditMiddleware(nil, "sends.pause", "send_pause", ""), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/pause", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
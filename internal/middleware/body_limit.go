@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// DefaultMaxRequestBodyBytes allows 25MB of base64-encoded attachments plus the message body
+	DefaultMaxRequestBodyBytes int64 = 35 * 1024 * 1024
+
+	// DefaultMaxJSONDepth is the maximum nesting depth accepted in JSON request bodies
+	DefaultMaxJSONDepth = 32
+)
+
+// errJSONTooDeep is returned when a JSON body exceeds the allowed nesting depth
+var errJSONTooDeep = errors.New("json nesting depth exceeded")
+
+// BodyLimitMiddleware enforces a maximum request body size and JSON nesting depth
+// on POST, PUT and PATCH requests. Oversized bodies are rejected with 413 before
+// reaching handlers, so handlers never bind an unbounded payload.
+func BodyLimitMiddleware(maxBytes int64, maxDepth int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+		default:
+			c.Next()
+			return
+		}
+
+		// Reject early when the declared length is already too large
+		if c.Request.ContentLength > maxBytes {
+			abortBodyTooLarge(c, maxBytes)
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				abortBodyTooLarge(c, maxBytes)
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request body",
+				"message": "Failed to read request body",
+				"code":    "INVALID_REQUEST_BODY",
+			})
+			c.Abort()
+			return
+		}
+
+		if maxDepth > 0 && len(body) > 0 && strings.Contains(c.ContentType(), "json") {
+			if err := checkJSONDepth(body, maxDepth); errors.Is(err, errJSONTooDeep) {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error":   "Request body too deeply nested",
+					"message": "JSON nesting depth exceeds the allowed maximum",
+					"code":    "JSON_TOO_DEEP",
+				})
+				c.Abort()
+				return
+			}
+			// Malformed JSON is left for the handler's binding to report
+		}
+
+		// Restore the body for downstream binding
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Next()
+	}
+}
+
+// abortBodyTooLarge responds with 413 Request Entity Too Large
+func abortBodyTooLarge(c *gin.Context, maxBytes int64) {
+	c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+		"error":     "Request body too large",
+		"message":   "Request body exceeds the maximum allowed size",
+		"code":      "REQUEST_BODY_TOO_LARGE",
+		"max_bytes": maxBytes,
+	})
+	c.Abort()
+}
+
+// checkJSONDepth streams through a JSON document and fails once nesting exceeds maxDepth
+func checkJSONDepth(body []byte, maxDepth int) error {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	depth := 0
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		delim, ok := token.(json.Delim)
+		if !ok {
+			continue
+		}
+
+		switch delim {
+		case '{', '[':
+			depth++
+			if depth > maxDepth {
+				return errJSONTooDeep
+			}
+		case '}', ']':
+			depth--
+		}
+	}
+}
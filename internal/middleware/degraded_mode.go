@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/health"
+)
+
+// DegradedModeMiddleware rejects requests with 503 and Retry-After while any of
+// dependencies is degraded, instead of attempting and failing each request.
+// If observe is set, responses are recorded against it: a 5xx counts as a failure.
+// A nil controller disables degraded mode.
+func DegradedModeMiddleware(controller *health.DegradationController, observe string, dependencies ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if controller == nil {
+			c.Next()
+			return
+		}
+
+		for _, dependency := range dependencies {
+			degraded, retryAfter := controller.Degraded(dependency)
+			if !degraded {
+				continue
+			}
+
+			seconds := int(math.Ceil(retryAfter.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(seconds))
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error":       "Service temporarily degraded",
+				"message":     "A dependency is failing; retry after the indicated delay",
+				"code":        "SERVICE_DEGRADED",
+				"dependency":  dependency,
+				"retry_after": seconds,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+
+		if observe != "" {
+			controller.Record(observe, responseStatus(c) >= http.StatusInternalServerError)
+		}
+	}
+}
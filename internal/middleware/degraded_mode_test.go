@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/health"
+)
+
+func TestDegradedModeMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	controller := health.NewDegradationController(health.DegradationConfig{
+		Window:      time.Minute,
+		Threshold:   0.5,
+		MinRequests: 2,
+		Cooldown:    10 * time.Second,
+	})
+
+	status := http.StatusInternalServerError
+	router := gin.New()
+	router.POST("/send", DegradedModeMiddleware(controller, health.DependencySMTP, health.DependencySMTP, health.DependencyMongoDB), func(c *gin.Context) {
+		c.Status(status)
+	})
+
+	send := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/send", nil))
+		return w
+	}
+
+	// Two failures trip the SMTP dependency
+	for i := 0; i < 2; i++ {
+		if w := send(); w.Code != http.StatusInternalServerError {
+			t.Fatalf("request %d status = %d, want 500", i, w.Code)
+		}
+	}
+
+	status = http.StatusOK
+	w := send()
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "10" {
+		t.Errorf("Retry-After = %q, want \"10\"", got)
+	}
+}
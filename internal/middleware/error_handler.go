@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/shared/errors"
+)
+
+// ErrorHandlerMiddleware renders errors attached with c.Error as a JSON response.
+// AppError codes are mapped to their HTTP status; any other error becomes a 500.
+// Handlers can therefore return typed errors from services without choosing a status.
+func ErrorHandlerMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		appErr := errors.FromError(c.Errors.Last().Err, "Internal server error")
+		status := appErr.HTTPStatus()
+
+		body := gin.H{
+			"error":   http.StatusText(status),
+			"message": appErr.Message,
+			"code":    appErr.Code,
+		}
+		// Underlying causes are only exposed for client errors, never for internal failures
+		if appErr.Err != nil && status < http.StatusInternalServerError {
+			body["details"] = appErr.Err.Error()
+		}
+		for key, value := range appErr.Fields {
+			if _, reserved := body[key]; !reserved {
+				body[key] = value
+			}
+		}
+
+		c.JSON(status, body)
+	}
+}
+
+// responseStatus returns the status of the response, including an error attached with
+// c.Error that ErrorHandlerMiddleware has not rendered yet
+func responseStatus(c *gin.Context) int {
+	if len(c.Errors) > 0 && !c.Writer.Written() {
+		return errors.FromError(c.Errors.Last().Err, "").HTTPStatus()
+	}
+	return c.Writer.Status()
+}
@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/shared/errors"
+)
+
+func TestErrorHandlerMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name        string
+		err         error
+		wantStatus  int
+		wantCode    string
+		wantDetails string
+	}{
+		{
+			name:        "validation",
+			err:         errors.NewValidationError("Invalid request", stderrors.New("to is required")),
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    errors.CodeValidation,
+			wantDetails: "to is required",
+		},
+		{name: "not found", err: errors.NewNotFoundError("Notification not found", nil), wantStatus: http.StatusNotFound, wantCode: errors.CodeNotFound},
+		{name: "unauthorized", err: errors.NewUnauthorizedError("Invalid token", nil), wantStatus: http.StatusUnauthorized, wantCode: errors.CodeUnauthorized},
+		{name: "rate limited", err: errors.NewRateLimitedError("Provider rate limit reached", nil), wantStatus: http.StatusTooManyRequests, wantCode: errors.CodeRateLimited},
+		{name: "conflict", err: errors.NewConflictError("Notification was modified", nil), wantStatus: http.StatusConflict, wantCode: errors.CodeConflict},
+		{name: "wrapped app error", err: fmt.Errorf("send: %w", errors.NewRateLimitedError("slow down", nil)), wantStatus: http.StatusTooManyRequests, wantCode: errors.CodeRateLimited},
+		{name: "plain error hides cause", err: stderrors.New("dial tcp: refused"), wantStatus: http.StatusInternalServerError, wantCode: errors.CodeInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(ErrorHandlerMiddleware())
+			router.GET("/", func(c *gin.Context) {
+				c.Error(tt.err)
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+
+			var body map[string]string
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("invalid JSON response: %v", err)
+			}
+			if body["code"] != tt.wantCode {
+				t.Errorf("code = %q, want %q", body["code"], tt.wantCode)
+			}
+			if body["details"] != tt.wantDetails {
+				t.Errorf("details = %q, want %q", body["details"], tt.wantDetails)
+			}
+		})
+	}
+}
+
+func TestErrorHandlerMiddleware_ResponseAlreadyWritten(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(ErrorHandlerMiddleware())
+	router.GET("/", func(c *gin.Context) {
+		c.Error(stderrors.New("logged only"))
+		c.JSON(http.StatusAccepted, gin.H{"message": "accepted"})
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != http.StatusAccepted {
+		t.Errorf("status = %d, want 202", w.Code)
+	}
+}
+
+func TestErrorHandlerMiddleware_Fields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(ErrorHandlerMiddleware())
+	router.PUT("/", func(c *gin.Context) {
+		c.Error(errors.NewConflictError("Resource was modified concurrently", nil).
+			WithField("current_version", 4).
+			WithField("code", "overridden"))
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/", nil))
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want 409", w.Code)
+	}
+
+	var body map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON response: %v", err)
+	}
+	if body["current_version"] != float64(4) {
+		t.Errorf("current_version = %v, want 4", body["current_version"])
+	}
+	if body["code"] != errors.CodeConflict {
+		t.Errorf("code = %v, fields must not override the envelope", body["code"])
+	}
+}
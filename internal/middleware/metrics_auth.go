@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsAuthMiddleware protects the metrics endpoint with a bearer token and/or basic auth.
+// When neither a token nor basic auth credentials are configured, requests pass through
+// unauthenticated for backward compatibility.
+func MetricsAuthMiddleware(token, username, password string) gin.HandlerFunc {
+	basicAuthEnabled := username != "" && password != ""
+
+	return func(c *gin.Context) {
+		if token == "" && !basicAuthEnabled {
+			c.Next()
+			return
+		}
+
+		if token != "" {
+			if bearer, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok && secureCompare(bearer, token) {
+				c.Next()
+				return
+			}
+		}
+
+		if basicAuthEnabled {
+			if user, pass, ok := c.Request.BasicAuth(); ok && secureCompare(user, username) && secureCompare(pass, password) {
+				c.Next()
+				return
+			}
+			c.Header("WWW-Authenticate", `Basic realm="metrics"`)
+		}
+
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "Unauthorized",
+			"message": "Valid credentials are required to access metrics",
+			"code":    "UNAUTHORIZED",
+		})
+		c.Abort()
+	}
+}
+
+// secureCompare compares two strings in constant time
+func secureCompare(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newMetricsAuthRouter(token, username, password string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/metrics", MetricsAuthMiddleware(token, username, password), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func TestMetricsAuthMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		token      string
+		username   string
+		password   string
+		setAuth    func(req *http.Request)
+		wantStatus int
+	}{
+		{
+			name:       "no auth configured",
+			setAuth:    func(req *http.Request) {},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "valid bearer token",
+			token:      "s3cret",
+			setAuth:    func(req *http.Request) { req.Header.Set("Authorization", "Bearer s3cret") },
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "wrong bearer token",
+			token:      "s3cret",
+			setAuth:    func(req *http.Request) { req.Header.Set("Authorization", "Bearer nope") },
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "missing credentials",
+			token:      "s3cret",
+			setAuth:    func(req *http.Request) {},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "valid basic auth",
+			username:   "prometheus",
+			password:   "scrape",
+			setAuth:    func(req *http.Request) { req.SetBasicAuth("prometheus", "scrape") },
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "wrong basic auth password",
+			username:   "prometheus",
+			password:   "scrape",
+			setAuth:    func(req *http.Request) { req.SetBasicAuth("prometheus", "guess") },
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "basic auth accepted alongside token",
+			token:      "s3cret",
+			username:   "prometheus",
+			password:   "scrape",
+			setAuth:    func(req *http.Request) { req.SetBasicAuth("prometheus", "scrape") },
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newMetricsAuthRouter(tt.token, tt.username, tt.password)
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			tt.setAuth(req)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/quota"
+)
+
+// HeaderQuotaWarning is set when a send exceeded a soft quota
+const HeaderQuotaWarning = "X-Quota-Warning"
+
+// QuotaMiddleware counts each request as one send against the tenant's quota for channel.
+// Requests over a hard quota are rejected with 429; sends that fail are released
+// back to the quota. A nil enforcer disables quota checks.
+func QuotaMiddleware(enforcer *quota.Enforcer, channel domain.NotificationType) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if enforcer == nil {
+			c.Next()
+			return
+		}
+
+		tenantID := MustGetTenantID(c)
+		ctx := c.Request.Context()
+
+		reservation, err := enforcer.Reserve(ctx, tenantID, channel, 1)
+		if err != nil {
+			if exceeded, ok := quota.AsExceeded(err); ok {
+				AbortQuotaExceeded(c, exceeded)
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Quota check failed",
+				"message": "Unable to verify send quota",
+				"code":    "INTERNAL_ERROR",
+			})
+			c.Abort()
+			return
+		}
+
+		if reservation.Warning != nil {
+			c.Header(HeaderQuotaWarning, reservation.Warning.Error())
+		}
+
+		c.Next()
+
+		if responseStatus(c) >= http.StatusBadRequest {
+			enforcer.Release(ctx, reservation)
+		}
+	}
+}
+
+// AbortQuotaExceeded responds with 429 and the quota that was exceeded
+func AbortQuotaExceeded(c *gin.Context, exceeded *quota.ExceededError) {
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":     "Quota exceeded",
+		"message":   exceeded.Error(),
+		"code":      "QUOTA_EXCEEDED",
+		"channel":   exceeded.Channel,
+		"period":    exceeded.Period,
+		"limit":     exceeded.Limit,
+		"used":      exceeded.Used,
+		"resets_at": exceeded.ResetsAt,
+	})
+	c.Abort()
+}
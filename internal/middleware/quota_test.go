@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/quota"
+	"github.com/vhvplatform/go-notification-service/internal/shared/errors"
+)
+
+// countingStore is an in-memory quota store keyed by period
+type countingStore struct {
+	usage map[domain.QuotaPeriod]int64
+}
+
+func (s *countingStore) FindQuota(ctx context.Context, tenantID string, channel domain.NotificationType) (*domain.TenantQuota, error) {
+	return nil, nil
+}
+
+func (s *countingStore) IncrementUsage(ctx context.Context, tenantID string, channel domain.NotificationType, period domain.QuotaPeriod, periodKey string, delta int64, expiresAt time.Time) (int64, error) {
+	s.usage[period] += delta
+	return s.usage[period], nil
+}
+
+func (s *countingStore) GetUsage(ctx context.Context, tenantID string, channel domain.NotificationType, period domain.QuotaPeriod, periodKey string) (int64, error) {
+	return s.usage[period], nil
+}
+
+func TestQuotaMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := &countingStore{usage: make(map[domain.QuotaPeriod]int64)}
+	enforcer := quota.NewEnforcer(store, map[domain.NotificationType]quota.Limits{
+		domain.NotificationTypeSMS: {Daily: 2},
+	}, domain.QuotaPolicyHard)
+
+	status := http.StatusOK
+	router := gin.New()
+	router.POST("/sms", func(c *gin.Context) {
+		c.Set(string(TenantIDKey), "tenant-1")
+	}, QuotaMiddleware(enforcer, domain.NotificationTypeSMS), func(c *gin.Context) {
+		c.Status(status)
+	})
+
+	send := func() int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/sms", nil))
+		return w.Code
+	}
+
+	// A failed send is released back to the quota
+	status = http.StatusInternalServerError
+	if code := send(); code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", code)
+	}
+	if store.usage[domain.QuotaPeriodDaily] != 0 {
+		t.Errorf("daily usage after failed send = %d, want 0", store.usage[domain.QuotaPeriodDaily])
+	}
+
+	status = http.StatusOK
+	for i := 0; i < 2; i++ {
+		if code := send(); code != http.StatusOK {
+			t.Fatalf("send %d status = %d, want 200", i, code)
+		}
+	}
+	if code := send(); code != http.StatusTooManyRequests {
+		t.Errorf("status over quota = %d, want 429", code)
+	}
+}
+
+func TestQuotaMiddleware_ReleasesOnHandlerError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := &countingStore{usage: make(map[domain.QuotaPeriod]int64)}
+	enforcer := quota.NewEnforcer(store, map[domain.NotificationType]quota.Limits{
+		domain.NotificationTypeSMS: {Daily: 2},
+	}, domain.QuotaPolicyHard)
+
+	router := gin.New()
+	router.Use(ErrorHandlerMiddleware())
+	router.POST("/sms", func(c *gin.Context) {
+		c.Set(string(TenantIDKey), "tenant-1")
+	}, QuotaMiddleware(enforcer, domain.NotificationTypeSMS), func(c *gin.Context) {
+		c.Error(errors.NewRateLimitedError("Provider rate limit reached", nil))
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/sms", nil))
+
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", w.Code)
+	}
+	if store.usage[domain.QuotaPeriodDaily] != 0 {
+		t.Errorf("daily usage after failed send = %d, want 0", store.usage[domain.QuotaPeriodDaily])
+	}
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTenantRateLimiterCleanup(t *testing.T) {
+	rl := NewTenantRateLimiter(10, 10)
+
+	rl.GetLimiter("tenant-idle")
+	rl.limiters["tenant-idle"].lastSeen.Store(time.Now().Add(-2 * time.Hour).UnixNano())
+	active := rl.GetLimiter("tenant-active")
+
+	if removed := rl.Cleanup(time.Hour); removed != 1 {
+		t.Errorf("Cleanup() removed = %d, want 1", removed)
+	}
+	if _, ok := rl.limiters["tenant-idle"]; ok {
+		t.Error("idle tenant limiter was not removed")
+	}
+	if rl.GetLimiter("tenant-active") != active {
+		t.Error("active tenant limiter was replaced")
+	}
+}
+
+func TestTenantRateLimiterTokens(t *testing.T) {
+	rl := NewTenantRateLimiter(0.001, 5)
+
+	if tokens := rl.Tokens("tenant-new"); tokens != 5 {
+		t.Errorf("Tokens() for an unseen tenant = %v, want 5", tokens)
+	}
+	if _, ok := rl.limiters["tenant-new"]; ok {
+		t.Error("Tokens() created a limiter")
+	}
+
+	limiter := rl.GetLimiter("tenant-a")
+	limiter.Allow()
+	limiter.Allow()
+	if tokens := rl.Tokens("tenant-a"); tokens < 2.9 || tokens > 3.1 {
+		t.Errorf("Tokens() after two requests = %v, want about 3", tokens)
+	}
+
+	if rps, burst := rl.Limit(); rps != 0.001 || burst != 5 {
+		t.Errorf("Limit() = %v, %d, want 0.001, 5", rps, burst)
+	}
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/vhvplatform/go-notification-service/internal/apitoken"
+)
+
+// routeScopes maps "METHOD route" to the API token scope the route needs, for every route
+// whose scope isn't implied by its method. It is the one place API authorization is decided:
+// GET and HEAD routes not listed need read, and any other route not listed needs admin, so a
+// new write route is admin-only until it is added here.
+var routeScopes = map[string]string{
+	// Sending, and acting on sent notifications
+	"POST /api/v1/notifications/email":                apitoken.ScopeSend,
+	"POST /api/v1/notifications/webhook":              apitoken.ScopeSend,
+	"POST /api/v1/notifications/sms":                  apitoken.ScopeSend,
+	"POST /api/v1/notifications/batch":                apitoken.ScopeSend,
+	"POST /api/v1/notifications/orchestrate":          apitoken.ScopeSend,
+	"POST /api/v1/notifications/bulk/email":           apitoken.ScopeSend,
+	"PUT /api/v1/notifications/external/:external_id": apitoken.ScopeSend,
+	"POST /api/v1/notifications/:id/acknowledge":      apitoken.ScopeSend,
+	"POST /api/v1/orchestrations/:id/acknowledge":     apitoken.ScopeSend,
+	"POST /api/v1/scheduled":                          apitoken.ScopeSend,
+	"PUT /api/v1/scheduled/:id":                       apitoken.ScopeSend,
+	"DELETE /api/v1/scheduled/:id":                    apitoken.ScopeSend,
+
+	// Queries sent as POST
+	"POST /api/v1/notifications/email/check": apitoken.ScopeRead,
+	"POST /api/v1/notifications/status":      apitoken.ScopeRead,
+	"POST /api/v1/scheduled/validate":        apitoken.ScopeRead,
+
+	// Failed notifications, the audit trail and recipients' personal data, read or written
+	"GET /api/v1/dlq":                   apitoken.ScopeAdmin,
+	"POST /api/v1/dlq/:id/retry":        apitoken.ScopeAdmin,
+	"GET /api/v1/audit-logs":            apitoken.ScopeAdmin,
+	"POST /api/v1/notifications/erase":  apitoken.ScopeAdmin,
+	"POST /api/v1/notifications/export": apitoken.ScopeAdmin,
+	"DELETE /api/v1/notifications/:id":  apitoken.ScopeAdmin,
+}
+
+// RequiredScope returns the API token scope a route needs, given the request method and the
+// route's path pattern
+func RequiredScope(method, route string) string {
+	if scope, ok := routeScopes[method+" "+route]; ok {
+		return scope
+	}
+	if method == http.MethodGet || method == http.MethodHead {
+		return apitoken.ScopeRead
+	}
+	return apitoken.ScopeAdmin
+}
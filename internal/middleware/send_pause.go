@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/pause"
+)
+
+// SendPauseMiddleware rejects requests with 503 while any of channels is paused.
+// It guards endpoints whose sends cannot be held individually, such as bulk email.
+// A nil controller never rejects.
+func SendPauseMiddleware(controller *pause.Controller, channels ...domain.NotificationType) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if controller == nil {
+			c.Next()
+			return
+		}
+
+		for _, channel := range channels {
+			if !controller.Paused(channel, "") {
+				continue
+			}
+
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error":   "Sends paused",
+				"message": "Sends on this channel are paused by an operator; retry later",
+				"code":    "SENDS_PAUSED",
+				"channel": channel,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
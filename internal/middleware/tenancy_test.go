@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestResolveTenantID(t *testing.T) {
+	authenticated := WithTenantID(context.Background(), "tenant-1")
+
+	tests := []struct {
+		name            string
+		ctx             context.Context
+		requestTenantID string
+		want            string
+		wantErr         error
+	}{
+		{name: "request matches context", ctx: authenticated, requestTenantID: "tenant-1", want: "tenant-1"},
+		{name: "request takes context tenant", ctx: authenticated, want: "tenant-1"},
+		{name: "request names another tenant", ctx: authenticated, requestTenantID: "tenant-2", wantErr: ErrTenantMismatch},
+		{name: "background request", ctx: context.Background(), requestTenantID: "tenant-2", want: "tenant-2"},
+		{name: "no tenant", ctx: context.Background(), wantErr: ErrTenantMissing},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveTenantID(tt.ctx, tt.requestTenantID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ResolveTenantID() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveTenantID() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+// Package mjml compiles MJML email templates to responsive HTML with the mjml command line tool.
+package mjml
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultPath is the mjml executable run when no path is configured
+	DefaultPath = "mjml"
+
+	// DefaultTimeout bounds one compilation when no timeout is configured
+	DefaultTimeout = 10 * time.Second
+
+	// DefaultCacheSize is how many compiled templates are cached when no size is configured
+	DefaultCacheSize = 500
+
+	// maxErrorBytes bounds the compiler output included in an error
+	maxErrorBytes = 2048
+)
+
+// ErrInvalidMJML is returned when MJML source fails validation or doesn't compile
+var ErrInvalidMJML = errors.New("invalid MJML")
+
+// Compiler compiles MJML source to HTML
+type Compiler interface {
+	Compile(ctx context.Context, source string) (string, error)
+}
+
+// Config holds MJML compiler settings
+type Config struct {
+	Path      string        // mjml executable; empty uses DefaultPath
+	Timeout   time.Duration // Per compilation; 0 uses DefaultTimeout
+	CacheSize int           // Compiled templates kept in memory; 0 uses DefaultCacheSize
+}
+
+// CLICompiler compiles MJML by running the mjml command line tool with strict validation,
+// so source with unknown tags or invalid attributes is rejected rather than compiled loosely
+type CLICompiler struct {
+	path    string
+	timeout time.Duration
+}
+
+// NewCLICompiler creates a compiler that runs the mjml executable
+func NewCLICompiler(config Config) *CLICompiler {
+	path := config.Path
+	if path == "" {
+		path = DefaultPath
+	}
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &CLICompiler{path: path, timeout: timeout}
+}
+
+// Compile compiles source, returning an error wrapping ErrInvalidMJML with mjml's messages
+// when the source is invalid
+func (c *CLICompiler) Compile(ctx context.Context, source string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, c.path, "-i", "-s", "--config.validationLevel", "strict")
+	cmd.Stdin = strings.NewReader(source)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("mjml compilation timed out after %s", c.timeout)
+		}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", fmt.Errorf("%w: %s", ErrInvalidMJML, truncate(strings.TrimSpace(stderr.String())))
+		}
+		return "", fmt.Errorf("failed to run mjml: %w", err)
+	}
+	return stdout.String(), nil
+}
+
+// truncate shortens compiler output to maxErrorBytes
+func truncate(output string) string {
+	if output == "" {
+		return "compilation failed"
+	}
+	if len(output) > maxErrorBytes {
+		return output[:maxErrorBytes] + "..."
+	}
+	return output
+}
+
+// CachingCompiler caches another compiler's output by source, so saving or importing
+// unchanged templates doesn't compile them again. Failures aren't cached.
+type CachingCompiler struct {
+	next  Compiler
+	size  int
+	mu    sync.Mutex
+	html  map[[sha256.Size]byte]string
+	order [][sha256.Size]byte // Cached sources, oldest first
+}
+
+// NewCachingCompiler caches up to size compiled templates from next
+func NewCachingCompiler(next Compiler, size int) *CachingCompiler {
+	if size <= 0 {
+		size = DefaultCacheSize
+	}
+	return &CachingCompiler{
+		next: next,
+		size: size,
+		html: make(map[[sha256.Size]byte]string),
+	}
+}
+
+// NewCompiler creates the caching mjml command line compiler config describes
+func NewCompiler(config Config) *CachingCompiler {
+	return NewCachingCompiler(NewCLICompiler(config), config.CacheSize)
+}
+
+// Compile returns the cached HTML for source, compiling it on a miss
+func (c *CachingCompiler) Compile(ctx context.Context, source string) (string, error) {
+	key := sha256.Sum256([]byte(source))
+	c.mu.Lock()
+	html, ok := c.html[key]
+	c.mu.Unlock()
+	if ok {
+		return html, nil
+	}
+
+	html, err := c.next.Compile(ctx, source)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.html[key]; !ok {
+		if len(c.order) >= c.size {
+			delete(c.html, c.order[0])
+			c.order = c.order[1:]
+		}
+		c.order = append(c.order, key)
+	}
+	c.html[key] = html
+	return html, nil
+}
@@ -0,0 +1,94 @@
+package mjml
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeMJML writes a shell script standing in for the mjml tool
+func fakeMJML(t *testing.T, script string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "mjml")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0o755); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return path
+}
+
+func TestCLICompiler(t *testing.T) {
+	compiler := NewCLICompiler(Config{Path: fakeMJML(t, `echo "<html>$(cat)</html>"`)})
+
+	html, err := compiler.Compile(context.Background(), "<mjml>{{.Name}}</mjml>")
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	if strings.TrimSpace(html) != "<html><mjml>{{.Name}}</mjml></html>" {
+		t.Errorf("Compile() = %q, want the compiled source", html)
+	}
+}
+
+func TestCLICompilerInvalid(t *testing.T) {
+	compiler := NewCLICompiler(Config{Path: fakeMJML(t, `echo "Line 1 of stdin (mj-txt) - mj-txt is not a valid tag" >&2; exit 1`)})
+
+	_, err := compiler.Compile(context.Background(), "<mjml><mj-txt/></mjml>")
+	if !errors.Is(err, ErrInvalidMJML) || !strings.Contains(err.Error(), "mj-txt is not a valid tag") {
+		t.Errorf("Compile() error = %v, want ErrInvalidMJML with mjml's message", err)
+	}
+}
+
+func TestCLICompilerMissing(t *testing.T) {
+	compiler := NewCLICompiler(Config{Path: filepath.Join(t.TempDir(), "missing")})
+
+	if _, err := compiler.Compile(context.Background(), "<mjml/>"); err == nil || errors.Is(err, ErrInvalidMJML) {
+		t.Errorf("Compile() error = %v, want a failure to run mjml", err)
+	}
+}
+
+// countingCompiler counts compilations, failing sources containing "bad"
+type countingCompiler struct {
+	calls int
+}
+
+func (c *countingCompiler) Compile(ctx context.Context, source string) (string, error) {
+	c.calls++
+	if strings.Contains(source, "bad") {
+		return "", ErrInvalidMJML
+	}
+	return "<html>" + source + "</html>", nil
+}
+
+func TestCachingCompiler(t *testing.T) {
+	next := &countingCompiler{}
+	compiler := NewCachingCompiler(next, 2)
+	ctx := context.Background()
+
+	for _, source := range []string{"a", "a", "b", "a"} {
+		if html, err := compiler.Compile(ctx, source); err != nil || html != "<html>"+source+"</html>" {
+			t.Fatalf("Compile(%q) = %q, %v", source, html, err)
+		}
+	}
+	if next.calls != 2 {
+		t.Errorf("compiled %d times, want 2 for 2 distinct sources", next.calls)
+	}
+
+	// Failures aren't cached
+	for range 2 {
+		if _, err := compiler.Compile(ctx, "bad"); !errors.Is(err, ErrInvalidMJML) {
+			t.Fatalf("Compile() error = %v, want ErrInvalidMJML", err)
+		}
+	}
+	if next.calls != 4 {
+		t.Errorf("compiled %d times, want failures compiled each time", next.calls)
+	}
+
+	// The oldest source is evicted once the cache is full
+	compiler.Compile(ctx, "c")
+	compiler.Compile(ctx, "a")
+	if next.calls != 6 {
+		t.Errorf("compiled %d times, want a evicted by c", next.calls)
+	}
+}
@@ -0,0 +1,190 @@
+package mxcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoMailHost is returned when a recipient's domain has no MX, A or AAAA record
+var ErrNoMailHost = errors.New("domain cannot receive email")
+
+// maxCacheEntries bounds the number of cached domain results
+const maxCacheEntries = 10000
+
+// Resolver is the subset of *net.Resolver used for lookups
+type Resolver interface {
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// Config holds lookup timeout and cache lifetimes
+type Config struct {
+	Timeout     time.Duration // Per-domain lookup timeout
+	PositiveTTL time.Duration // How long a deliverable domain is cached
+	NegativeTTL time.Duration // How long an undeliverable domain is cached
+	Tenants     []string      // Tenants that verify every email send
+}
+
+// UndeliverableError identifies the recipient whose domain cannot receive email
+type UndeliverableError struct {
+	Address string
+	Domain  string
+}
+
+// Error implements the error interface
+func (e *UndeliverableError) Error() string {
+	return fmt.Sprintf("recipient %s: %s has no MX or address record", e.Address, e.Domain)
+}
+
+// Unwrap allows errors.Is(err, ErrNoMailHost)
+func (e *UndeliverableError) Unwrap() error {
+	return ErrNoMailHost
+}
+
+// AsUndeliverable returns the *UndeliverableError in err's chain, if any
+func AsUndeliverable(err error) (*UndeliverableError, bool) {
+	var undeliverable *UndeliverableError
+	ok := errors.As(err, &undeliverable)
+	return undeliverable, ok
+}
+
+// cacheEntry is a cached lookup result for one domain
+type cacheEntry struct {
+	deliverable bool
+	expiresAt   time.Time
+}
+
+// Verifier checks that recipient domains can receive email before a notification is created.
+// Lookups are cached by domain; DNS failures other than "not found" never reject a recipient.
+type Verifier struct {
+	resolver Resolver
+	config   Config
+	tenants  map[string]struct{}
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+	now   func() time.Time
+}
+
+// NewVerifier creates a new recipient domain verifier
+func NewVerifier(resolver Resolver, config Config) *Verifier {
+	tenants := make(map[string]struct{}, len(config.Tenants))
+	for _, tenantID := range config.Tenants {
+		tenants[tenantID] = struct{}{}
+	}
+
+	return &Verifier{
+		resolver: resolver,
+		config:   config,
+		tenants:  tenants,
+		cache:    make(map[string]cacheEntry),
+		now:      time.Now,
+	}
+}
+
+// EnabledFor reports whether a tenant has opted in to verifying every send
+func (v *Verifier) EnabledFor(tenantID string) bool {
+	_, ok := v.tenants[tenantID]
+	return ok
+}
+
+// VerifyAddresses checks the domain of each address, returning an *UndeliverableError for the first
+// recipient whose domain cannot receive email. Each distinct domain is looked up once.
+func (v *Verifier) VerifyAddresses(ctx context.Context, addresses ...string) error {
+	checked := make(map[string]bool)
+	for _, address := range addresses {
+		at := strings.LastIndex(address, "@")
+		if at < 0 {
+			continue // Address syntax is validated elsewhere
+		}
+		domain := strings.ToLower(strings.TrimSuffix(address[at+1:], "."))
+
+		deliverable, seen := checked[domain]
+		if !seen {
+			deliverable = v.deliverable(ctx, domain)
+			checked[domain] = deliverable
+		}
+		if !deliverable {
+			return &UndeliverableError{Address: address, Domain: domain}
+		}
+	}
+	return nil
+}
+
+// deliverable reports whether a domain has a mail host, using the cache when possible
+func (v *Verifier) deliverable(ctx context.Context, domain string) bool {
+	v.mu.Lock()
+	entry, ok := v.cache[domain]
+	v.mu.Unlock()
+	if ok && v.now().Before(entry.expiresAt) {
+		return entry.deliverable
+	}
+
+	deliverable, definitive := v.lookup(ctx, domain)
+	if !definitive {
+		return true
+	}
+
+	ttl := v.config.PositiveTTL
+	if !deliverable {
+		ttl = v.config.NegativeTTL
+	}
+	v.store(domain, cacheEntry{deliverable: deliverable, expiresAt: v.now().Add(ttl)})
+	return deliverable
+}
+
+// lookup resolves MX records, falling back to A/AAAA records as SMTP does (RFC 5321 section 5.1).
+// definitive is false when DNS failed for a reason other than the domain not existing.
+func (v *Verifier) lookup(ctx context.Context, domain string) (deliverable, definitive bool) {
+	if v.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, v.config.Timeout)
+		defer cancel()
+	}
+
+	records, err := v.resolver.LookupMX(ctx, domain)
+	if err == nil && len(records) > 0 {
+		// A single "." host is a null MX: the domain explicitly accepts no mail (RFC 7505)
+		nullMX := len(records) == 1 && (records[0].Host == "." || records[0].Host == "")
+		return !nullMX, true
+	}
+	if err != nil && !isNotFound(err) {
+		return false, false
+	}
+
+	hosts, err := v.resolver.LookupHost(ctx, domain)
+	if err != nil {
+		return false, isNotFound(err)
+	}
+	return len(hosts) > 0, true
+}
+
+// store caches a result, dropping expired entries once the cache is full
+func (v *Verifier) store(domain string, entry cacheEntry) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if len(v.cache) >= maxCacheEntries {
+		now := v.now()
+		for key, cached := range v.cache {
+			if now.After(cached.expiresAt) {
+				delete(v.cache, key)
+			}
+		}
+		if len(v.cache) >= maxCacheEntries {
+			v.cache = make(map[string]cacheEntry)
+		}
+	}
+	v.cache[domain] = entry
+}
+
+// isNotFound reports whether a DNS error means the name has no records
+func isNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
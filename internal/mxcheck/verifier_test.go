@@ -0,0 +1,112 @@
+package mxcheck
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// fakeResolver answers lookups from fixed records and counts calls
+type fakeResolver struct {
+	mx      map[string][]*net.MX
+	hosts   map[string][]string
+	failing map[string]bool // Domains whose lookups fail with a temporary error
+	calls   int
+}
+
+func (r *fakeResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	r.calls++
+	if r.failing[name] {
+		return nil, &net.DNSError{Err: "server misbehaving", Name: name, IsTemporary: true}
+	}
+	if records, ok := r.mx[name]; ok {
+		return records, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+func (r *fakeResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if hosts, ok := r.hosts[host]; ok {
+		return hosts, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func TestVerifyAddresses(t *testing.T) {
+	resolver := &fakeResolver{
+		mx: map[string][]*net.MX{
+			"example.com": {{Host: "mx.example.com.", Pref: 10}},
+			"nomail.org":  {{Host: ".", Pref: 0}},
+		},
+		hosts:   map[string][]string{"a-only.io": {"192.0.2.1"}},
+		failing: map[string]bool{"flaky.net": true},
+	}
+	verifier := NewVerifier(resolver, Config{Timeout: time.Second, PositiveTTL: time.Hour, NegativeTTL: time.Minute})
+
+	tests := []struct {
+		name    string
+		address string
+		wantErr bool
+	}{
+		{name: "mx record", address: "jane@example.com"},
+		{name: "domain case and trailing dot", address: "jane@Example.COM."},
+		{name: "a record fallback", address: "ops@a-only.io"},
+		{name: "null mx", address: "info@nomail.org", wantErr: true},
+		{name: "nonexistent domain", address: "john@gmial.con", wantErr: true},
+		{name: "temporary dns failure is not rejected", address: "bob@flaky.net"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifier.VerifyAddresses(context.Background(), tt.address)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("VerifyAddresses() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				undeliverable, ok := AsUndeliverable(err)
+				if !ok || undeliverable.Address != tt.address || !errors.Is(err, ErrNoMailHost) {
+					t.Errorf("VerifyAddresses() error = %v, want *UndeliverableError for %s", err, tt.address)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifierCache(t *testing.T) {
+	resolver := &fakeResolver{mx: map[string][]*net.MX{"example.com": {{Host: "mx.example.com."}}}}
+	verifier := NewVerifier(resolver, Config{PositiveTTL: time.Hour, NegativeTTL: time.Minute})
+
+	now := time.Now()
+	verifier.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	_ = verifier.VerifyAddresses(ctx, "a@example.com", "b@example.com", "c@typo.example")
+	_ = verifier.VerifyAddresses(ctx, "d@example.com", "e@typo.example")
+	if resolver.calls != 2 {
+		t.Errorf("lookups = %d, want 2 (one per domain)", resolver.calls)
+	}
+
+	// Negative results expire sooner than positive ones
+	now = now.Add(2 * time.Minute)
+	_ = verifier.VerifyAddresses(ctx, "f@example.com", "g@typo.example")
+	if resolver.calls != 3 {
+		t.Errorf("lookups after negative TTL = %d, want 3", resolver.calls)
+	}
+
+	// Temporary failures are retried rather than cached
+	resolver.failing = map[string]bool{"flaky.net": true}
+	_ = verifier.VerifyAddresses(ctx, "h@flaky.net")
+	_ = verifier.VerifyAddresses(ctx, "i@flaky.net")
+	if resolver.calls != 5 {
+		t.Errorf("lookups after temporary failures = %d, want 5", resolver.calls)
+	}
+}
+
+func TestEnabledFor(t *testing.T) {
+	verifier := NewVerifier(&fakeResolver{}, Config{Tenants: []string{"tenant-a"}})
+	if !verifier.EnabledFor("tenant-a") || verifier.EnabledFor("tenant-b") {
+		t.Error("EnabledFor() should only report configured tenants")
+	}
+}
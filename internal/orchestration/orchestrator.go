@@ -0,0 +1,346 @@
+package orchestration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/metrics"
+	"github.com/vhvplatform/go-notification-service/internal/quota"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// DefaultPollInterval is how often due escalations are looked for when no interval is configured
+	DefaultPollInterval = 30 * time.Second
+
+	// claimTimeout is how long a claimed escalation may go unfinished before it is claimed again
+	claimTimeout = 10 * time.Minute
+)
+
+// Store interface for escalating orchestrations
+type Store interface {
+	Create(ctx context.Context, orchestration *domain.Orchestration) (*domain.Orchestration, error)
+	ClaimDue(ctx context.Context, now time.Time, staleAfter time.Duration) (*domain.Orchestration, error)
+	Advance(ctx context.Context, id primitive.ObjectID, results []domain.BatchItemResult, next int, dueAt time.Time) error
+	Complete(ctx context.Context, id primitive.ObjectID, status domain.OrchestrationStatus, results []domain.BatchItemResult) error
+}
+
+// Sender interface for sending each channel, holding those that are paused
+type Sender interface {
+	Paused(channel domain.NotificationType, priority domain.NotificationPriority) bool
+	SendEmail(ctx context.Context, req *domain.SendEmailRequest) error
+	SendSMS(ctx context.Context, req *domain.SendSMSRequest) error
+	SendWebhook(ctx context.Context, req *domain.SendWebhookRequest) error
+}
+
+// NotificationFinder interface for checking whether a channel's notification was delivered
+type NotificationFinder interface {
+	FindByID(ctx context.Context, id string, tenantID string) (*domain.Notification, error)
+	FindByIdempotencyKey(ctx context.Context, tenantID, idempotencyKey string) (*domain.Notification, error)
+}
+
+// Config holds orchestration settings
+type Config struct {
+	PollInterval time.Duration // How often due escalations are looked for
+}
+
+// Orchestrator sends one notification over several channels according to its strategy, and
+// escalates to the next channel when an earlier one isn't delivered in time
+type Orchestrator struct {
+	store         Store
+	sends         Sender
+	notifications NotificationFinder
+	quotas        *quota.Enforcer // Optional; nil disables quota checks
+	interval      time.Duration
+	log           *logger.Logger
+}
+
+// NewOrchestrator creates a new orchestrator
+// The quota enforcer is optional.
+func NewOrchestrator(store Store, sends Sender, notifications NotificationFinder, quotas *quota.Enforcer, config Config, log *logger.Logger) *Orchestrator {
+	interval := config.PollInterval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	return &Orchestrator{
+		store:         store,
+		sends:         sends,
+		notifications: notifications,
+		quotas:        quotas,
+		interval:      interval,
+		log:           log,
+	}
+}
+
+// Orchestrate sends req's channels according to its strategy
+// An escalating request that has channels left once one is sent is stored, and escalated
+// by Start if it isn't delivered or acknowledged within its escalation window.
+func (o *Orchestrator) Orchestrate(ctx context.Context, req *domain.OrchestrateRequest) (*domain.OrchestrationResponse, error) {
+	if req.GroupID == "" {
+		req.GroupID = uuid.NewString()
+	}
+	if req.IdempotencyKey == "" {
+		req.IdempotencyKey = uuid.NewString()
+	}
+	for i := range req.Channels {
+		link(req.TenantID, req.GroupID, key(req.IdempotencyKey, i), &req.Channels[i])
+	}
+
+	resp := &domain.OrchestrationResponse{
+		GroupID:        req.GroupID,
+		IdempotencyKey: req.IdempotencyKey,
+		Strategy:       req.Strategy,
+	}
+
+	if req.Strategy == domain.OrchestrationStrategyAll {
+		for i := range req.Channels {
+			resp.Results = append(resp.Results, o.send(ctx, req.TenantID, i, &req.Channels[i]))
+		}
+		resp.Status = finalStatus(resp.Results)
+		return resp, nil
+	}
+
+	var next int
+	resp.Results, next = o.sendUntilSent(ctx, req.TenantID, req.Channels, 0, nil)
+	resp.Status = finalStatus(resp.Results)
+	if req.Strategy != domain.OrchestrationStrategyEscalate || next >= len(req.Channels) || resp.Status == domain.OrchestrationStatusFailed {
+		return resp, nil
+	}
+
+	escalateAt := time.Now().Add(time.Duration(req.EscalateAfter) * time.Second)
+	stored, err := o.store.Create(ctx, &domain.Orchestration{
+		TenantID:       req.TenantID,
+		GroupID:        req.GroupID,
+		IdempotencyKey: req.IdempotencyKey,
+		Strategy:       req.Strategy,
+		Channels:       req.Channels,
+		Results:        resp.Results,
+		Next:           next,
+		EscalateAfter:  req.EscalateAfter,
+		Status:         domain.OrchestrationStatusActive,
+		DueAt:          escalateAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store orchestration: %w", err)
+	}
+
+	resp.ID = stored.ID.Hex()
+	resp.Status = stored.Status
+	resp.Results = stored.Results
+	if stored.Status == domain.OrchestrationStatusActive {
+		resp.EscalateAt = &stored.DueAt
+	}
+	return resp, nil
+}
+
+// Start escalates due orchestrations until ctx is cancelled
+func (o *Orchestrator) Start(ctx context.Context) {
+	o.log.Info("Starting notification escalation", "interval", o.interval)
+
+	go func() {
+		ticker := time.NewTicker(o.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				o.log.Info("Notification escalation stopped")
+				return
+			case <-ticker.C:
+				o.escalateDue(ctx)
+			}
+		}
+	}()
+}
+
+// escalateDue escalates orchestrations until none are due
+func (o *Orchestrator) escalateDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		orchestration, err := o.store.ClaimDue(ctx, time.Now(), claimTimeout)
+		if err != nil {
+			if ctx.Err() == nil {
+				o.log.Error("Failed to claim due escalation", "error", err)
+			}
+			return
+		}
+		if orchestration == nil {
+			return
+		}
+		o.escalate(ctx, orchestration, time.Now())
+	}
+}
+
+// escalate finishes a claimed orchestration if one of its channels was delivered, or sends
+// its next channel and waits again if any are left
+func (o *Orchestrator) escalate(ctx context.Context, orchestration *domain.Orchestration, now time.Time) {
+	id := orchestration.ID.Hex()
+	if o.delivered(ctx, orchestration) {
+		o.log.Info("Orchestrated notification delivered, not escalating", "orchestration_id", id, "tenant_id", orchestration.TenantID)
+		if err := o.store.Complete(ctx, orchestration.ID, domain.OrchestrationStatusAcknowledged, orchestration.Results); err != nil {
+			o.log.Error("Failed to complete orchestration", "error", err, "orchestration_id", id)
+		}
+		return
+	}
+
+	results, next := o.sendUntilSent(ctx, orchestration.TenantID, orchestration.Channels, orchestration.Next, orchestration.Results)
+	for _, result := range results[len(orchestration.Results):] {
+		metrics.NotificationsEscalated.WithLabelValues(string(result.Type)).Inc()
+	}
+	o.log.Info("Escalated orchestrated notification", "orchestration_id", id, "tenant_id", orchestration.TenantID, "channel", next-1)
+
+	if next < len(orchestration.Channels) && results[len(results)-1].Status != domain.NotificationStatusFailed {
+		dueAt := now.Add(time.Duration(orchestration.EscalateAfter) * time.Second)
+		if err := o.store.Advance(ctx, orchestration.ID, results, next, dueAt); err != nil {
+			o.log.Error("Failed to reschedule escalation", "error", err, "orchestration_id", id)
+		}
+		return
+	}
+	if err := o.store.Complete(ctx, orchestration.ID, finalStatus(results), results); err != nil {
+		o.log.Error("Failed to complete orchestration", "error", err, "orchestration_id", id)
+	}
+}
+
+// delivered reports whether any channel sent so far was delivered to, read, clicked or acknowledged by its recipient
+func (o *Orchestrator) delivered(ctx context.Context, orchestration *domain.Orchestration) bool {
+	for _, result := range orchestration.Results {
+		if result.Status == domain.NotificationStatusFailed {
+			continue
+		}
+
+		var notification *domain.Notification
+		var err error
+		if result.ID != "" {
+			notification, err = o.notifications.FindByID(ctx, result.ID, orchestration.TenantID)
+		} else {
+			// Held when sent, so the notification ID wasn't known yet
+			notification, err = o.notifications.FindByIdempotencyKey(ctx, orchestration.TenantID, result.IdempotencyKey)
+		}
+		if err != nil {
+			if !errors.Is(err, mongo.ErrNoDocuments) {
+				o.log.Error("Failed to check orchestrated notification", "error", err, "orchestration_id", orchestration.ID.Hex(), "index", result.Index)
+			}
+			continue
+		}
+
+		if notification.AcknowledgedAt != nil {
+			return true
+		}
+		switch notification.Status {
+		case domain.NotificationStatusDelivered, domain.NotificationStatusRead, domain.NotificationStatusClicked:
+			return true
+		}
+	}
+	return false
+}
+
+// sendUntilSent sends channels from start in order until one is sent or held, appending their
+// results to results. It returns the results and the index of the channel after the last one tried.
+func (o *Orchestrator) sendUntilSent(ctx context.Context, tenantID string, channels []domain.BatchSendItem, start int, results []domain.BatchItemResult) ([]domain.BatchItemResult, int) {
+	i := start
+	for i < len(channels) {
+		result := o.send(ctx, tenantID, i, &channels[i])
+		results = append(results, result)
+		i++
+		if result.Status != domain.NotificationStatusFailed {
+			break
+		}
+	}
+	return results, i
+}
+
+// send sends one channel and builds its result
+func (o *Orchestrator) send(ctx context.Context, tenantID string, index int, item *domain.BatchSendItem) domain.BatchItemResult {
+	result := domain.BatchItemResult{
+		Index: index,
+		Type:  item.Type,
+	}
+
+	// Each channel counts against its quota; one over a hard quota fails on its own
+	var reservation *quota.Reservation
+	if o.quotas != nil {
+		var err error
+		reservation, err = o.quotas.Reserve(ctx, tenantID, item.Type, 1)
+		if err != nil {
+			o.log.Warn("Orchestrated channel rejected by quota", "error", err, "tenant_id", tenantID, "index", index, "type", item.Type)
+			result.Status = domain.NotificationStatusFailed
+			result.Error = err.Error()
+			return result
+		}
+	}
+
+	start := time.Now()
+	var err error
+	var held bool
+	switch item.Type {
+	case domain.NotificationTypeEmail:
+		result.IdempotencyKey = item.Email.IdempotencyKey
+		held = o.sends.Paused(item.Type, item.Email.Priority)
+		err = o.sends.SendEmail(ctx, item.Email)
+	case domain.NotificationTypeSMS:
+		result.IdempotencyKey = item.SMS.IdempotencyKey
+		held = o.sends.Paused(item.Type, item.SMS.Priority)
+		err = o.sends.SendSMS(ctx, item.SMS)
+	case domain.NotificationTypeWebhook:
+		result.IdempotencyKey = item.Webhook.IdempotencyKey
+		held = o.sends.Paused(item.Type, item.Webhook.Priority)
+		err = o.sends.SendWebhook(ctx, item.Webhook)
+	}
+	if !held {
+		metrics.ObserveSend(string(item.Type), tenantID, start, err)
+	}
+
+	if err != nil {
+		if o.quotas != nil {
+			o.quotas.Release(ctx, reservation)
+		}
+		o.log.Error("Failed to send orchestrated channel", "error", err, "tenant_id", tenantID, "index", index, "type", item.Type)
+		result.Status = domain.NotificationStatusFailed
+		result.Error = err.Error()
+		return result
+	}
+
+	// Held channels are sent, and get a notification ID, once their channel resumes
+	if held {
+		result.Status = domain.NotificationStatusPending
+		return result
+	}
+
+	result.Status = domain.NotificationStatusSent
+	if notification, err := o.notifications.FindByIdempotencyKey(ctx, tenantID, result.IdempotencyKey); err == nil {
+		result.ID = notification.ID.Hex()
+	}
+	return result
+}
+
+// link sets the tenant, shared group ID and derived idempotency key on a channel's request
+func link(tenantID, groupID, key string, item *domain.BatchSendItem) {
+	switch {
+	case item.Email != nil:
+		item.Email.TenantID, item.Email.GroupID, item.Email.IdempotencyKey = tenantID, groupID, key
+	case item.SMS != nil:
+		item.SMS.TenantID, item.SMS.GroupID, item.SMS.IdempotencyKey = tenantID, groupID, key
+	case item.Webhook != nil:
+		item.Webhook.TenantID, item.Webhook.GroupID, item.Webhook.IdempotencyKey = tenantID, groupID, key
+	}
+}
+
+// key derives a channel's idempotency key from the request's
+func key(requestKey string, index int) string {
+	return fmt.Sprintf("%s:%d", requestKey, index)
+}
+
+// finalStatus is completed if any channel was sent or held, and failed otherwise
+func finalStatus(results []domain.BatchItemResult) domain.OrchestrationStatus {
+	for _, result := range results {
+		if result.Status != domain.NotificationStatusFailed {
+			return domain.OrchestrationStatusCompleted
+		}
+	}
+	return domain.OrchestrationStatusFailed
+}
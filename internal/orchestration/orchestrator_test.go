@@ -0,0 +1,183 @@
+package orchestration
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type memoryStore struct {
+	created   []*domain.Orchestration
+	advanced  map[primitive.ObjectID]int
+	completed map[primitive.ObjectID]domain.OrchestrationStatus
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		advanced:  map[primitive.ObjectID]int{},
+		completed: map[primitive.ObjectID]domain.OrchestrationStatus{},
+	}
+}
+
+func (s *memoryStore) Create(ctx context.Context, orchestration *domain.Orchestration) (*domain.Orchestration, error) {
+	orchestration.ID = primitive.NewObjectID()
+	s.created = append(s.created, orchestration)
+	return orchestration, nil
+}
+
+func (s *memoryStore) ClaimDue(ctx context.Context, now time.Time, staleAfter time.Duration) (*domain.Orchestration, error) {
+	return nil, nil
+}
+
+func (s *memoryStore) Advance(ctx context.Context, id primitive.ObjectID, results []domain.BatchItemResult, next int, dueAt time.Time) error {
+	s.advanced[id] = next
+	return nil
+}
+
+func (s *memoryStore) Complete(ctx context.Context, id primitive.ObjectID, status domain.OrchestrationStatus, results []domain.BatchItemResult) error {
+	s.completed[id] = status
+	return nil
+}
+
+// fakeSender records sends and fails the channels in failing
+type fakeSender struct {
+	failing map[domain.NotificationType]bool
+	sent    []domain.NotificationType
+}
+
+func (s *fakeSender) Paused(channel domain.NotificationType, priority domain.NotificationPriority) bool {
+	return false
+}
+
+func (s *fakeSender) send(channel domain.NotificationType) error {
+	if s.failing[channel] {
+		return errors.New("provider unavailable")
+	}
+	s.sent = append(s.sent, channel)
+	return nil
+}
+
+func (s *fakeSender) SendEmail(ctx context.Context, req *domain.SendEmailRequest) error {
+	return s.send(domain.NotificationTypeEmail)
+}
+
+func (s *fakeSender) SendSMS(ctx context.Context, req *domain.SendSMSRequest) error {
+	return s.send(domain.NotificationTypeSMS)
+}
+
+func (s *fakeSender) SendWebhook(ctx context.Context, req *domain.SendWebhookRequest) error {
+	return s.send(domain.NotificationTypeWebhook)
+}
+
+// notificationStatuses answers lookups by idempotency key with a notification in the key's status
+type notificationStatuses map[string]domain.NotificationStatus
+
+func (n notificationStatuses) FindByID(ctx context.Context, id string, tenantID string) (*domain.Notification, error) {
+	return nil, mongo.ErrNoDocuments
+}
+
+func (n notificationStatuses) FindByIdempotencyKey(ctx context.Context, tenantID, idempotencyKey string) (*domain.Notification, error) {
+	status, ok := n[idempotencyKey]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	return &domain.Notification{Status: status}, nil
+}
+
+func request(strategy domain.OrchestrationStrategy) *domain.OrchestrateRequest {
+	return &domain.OrchestrateRequest{
+		TenantID:       "tenant-1",
+		Strategy:       strategy,
+		IdempotencyKey: "alert-7",
+		EscalateAfter:  300,
+		Channels: []domain.BatchSendItem{
+			{Type: domain.NotificationTypeEmail, Email: &domain.SendEmailRequest{To: []string{"ann@example.com"}, Subject: "Disk full", Body: "Disk full"}},
+			{Type: domain.NotificationTypeSMS, SMS: &domain.SendSMSRequest{To: "+15550100", Message: "Disk full"}},
+			{Type: domain.NotificationTypeWebhook, Webhook: &domain.SendWebhookRequest{URL: "https://hooks.example.com/pager"}},
+		},
+	}
+}
+
+func TestOrchestrate(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		strategy domain.OrchestrationStrategy
+		failing  domain.NotificationType
+		sent     int
+		status   domain.OrchestrationStatus
+		stored   bool
+	}{
+		{"all", domain.OrchestrationStrategyAll, "", 3, domain.OrchestrationStatusCompleted, false},
+		{"first success", domain.OrchestrationStrategyFirstSuccess, "", 1, domain.OrchestrationStatusCompleted, false},
+		{"first success after failure", domain.OrchestrationStrategyFirstSuccess, domain.NotificationTypeEmail, 1, domain.OrchestrationStatusCompleted, false},
+		{"escalate", domain.OrchestrationStrategyEscalate, "", 1, domain.OrchestrationStatusActive, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemoryStore()
+			sends := &fakeSender{failing: map[domain.NotificationType]bool{tt.failing: true}}
+			orchestrator := NewOrchestrator(store, sends, notificationStatuses{}, nil, Config{}, logger.NewLogger())
+
+			req := request(tt.strategy)
+			resp, err := orchestrator.Orchestrate(ctx, req)
+			if err != nil {
+				t.Fatalf("Orchestrate() error = %v", err)
+			}
+			if len(sends.sent) != tt.sent || resp.Status != tt.status || (resp.ID != "") != tt.stored {
+				t.Errorf("Orchestrate() sent %v, status %q, id %q", sends.sent, resp.Status, resp.ID)
+			}
+			if req.Channels[1].SMS.GroupID != resp.GroupID || req.Channels[1].SMS.IdempotencyKey != "alert-7:1" {
+				t.Errorf("SMS channel group %q, key %q, want %q and alert-7:1", req.Channels[1].SMS.GroupID, req.Channels[1].SMS.IdempotencyKey, resp.GroupID)
+			}
+		})
+	}
+}
+
+func TestEscalate(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	store := newMemoryStore()
+	sends := &fakeSender{failing: map[domain.NotificationType]bool{}}
+	statuses := notificationStatuses{}
+	orchestrator := NewOrchestrator(store, sends, statuses, nil, Config{}, logger.NewLogger())
+
+	if _, err := orchestrator.Orchestrate(ctx, request(domain.OrchestrationStrategyEscalate)); err != nil {
+		t.Fatalf("Orchestrate() error = %v", err)
+	}
+	orchestration := store.created[0]
+	if orchestration.Next != 1 || orchestration.Status != domain.OrchestrationStatusActive {
+		t.Fatalf("stored orchestration next %d, status %q", orchestration.Next, orchestration.Status)
+	}
+
+	// The email wasn't delivered in time, so the SMS goes out
+	statuses["alert-7:0"] = domain.NotificationStatusSent
+	orchestrator.escalate(ctx, orchestration, now)
+	if store.advanced[orchestration.ID] != 2 || sends.sent[len(sends.sent)-1] != domain.NotificationTypeSMS {
+		t.Fatalf("escalation advanced to %d, sent %v", store.advanced[orchestration.ID], sends.sent)
+	}
+
+	// The SMS was delivered before the next escalation
+	orchestration.Results = append(orchestration.Results, domain.BatchItemResult{Index: 1, Type: domain.NotificationTypeSMS, IdempotencyKey: "alert-7:1", Status: domain.NotificationStatusSent})
+	orchestration.Next = 2
+	statuses["alert-7:1"] = domain.NotificationStatusDelivered
+	orchestrator.escalate(ctx, orchestration, now)
+	if store.completed[orchestration.ID] != domain.OrchestrationStatusAcknowledged || len(sends.sent) != 2 {
+		t.Errorf("delivered orchestration status %q, sent %v", store.completed[orchestration.ID], sends.sent)
+	}
+
+	// Escalating to the last channel completes the orchestration, even if it fails
+	delete(statuses, "alert-7:1")
+	sends.failing[domain.NotificationTypeWebhook] = true
+	orchestrator.escalate(ctx, orchestration, now)
+	if store.completed[orchestration.ID] != domain.OrchestrationStatusCompleted {
+		t.Errorf("exhausted orchestration status %q, want completed", store.completed[orchestration.ID])
+	}
+}
@@ -0,0 +1,88 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/metrics"
+	"github.com/vhvplatform/go-notification-service/internal/repository"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// DefaultMonitorInterval is how often pending outbox metrics are refreshed
+const DefaultMonitorInterval = 30 * time.Second
+
+// Monitor periodically publishes outbox depth and lag metrics
+type Monitor struct {
+	repo     *repository.OutboxEventRepository
+	log      *logger.Logger
+	interval time.Duration
+}
+
+// NewMonitor creates a new outbox monitor
+func NewMonitor(repo *repository.OutboxEventRepository, interval time.Duration, log *logger.Logger) *Monitor {
+	if interval <= 0 {
+		interval = DefaultMonitorInterval
+	}
+	return &Monitor{
+		repo:     repo,
+		log:      log,
+		interval: interval,
+	}
+}
+
+// Start refreshes outbox metrics until ctx is cancelled
+func (m *Monitor) Start(ctx context.Context) {
+	m.log.Info("Starting outbox monitor", "interval", m.interval)
+
+	go func() {
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+
+		m.refresh(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				m.log.Info("Outbox monitor stopped")
+				return
+			case <-ticker.C:
+				m.refresh(ctx)
+			}
+		}
+	}()
+}
+
+// refresh loads pending outbox statistics and updates the gauges
+func (m *Monitor) refresh(ctx context.Context) {
+	stats, err := m.repo.GetPendingStats(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			m.log.Error("Failed to load outbox pending stats", "error", err)
+		}
+		return
+	}
+
+	recordPendingStats(stats, time.Now())
+}
+
+// recordPendingStats replaces the pending gauges with the given per-tenant statistics
+func recordPendingStats(stats []*domain.OutboxPendingStats, now time.Time) {
+	// Reset so tenants with no pending events drop out of the gauge
+	metrics.OutboxPendingEvents.Reset()
+
+	var oldest time.Time
+	for _, s := range stats {
+		// Add rather than Set, since several tenants may share a label value
+		metrics.OutboxPendingEvents.WithLabelValues(metrics.TenantLabel(s.TenantID)).Add(float64(s.Count))
+		if oldest.IsZero() || s.OldestCreatedAt.Before(oldest) {
+			oldest = s.OldestCreatedAt
+		}
+	}
+
+	age := 0.0
+	if !oldest.IsZero() {
+		age = now.Sub(oldest).Seconds()
+	}
+	metrics.OutboxOldestPendingAge.Set(age)
+}
@@ -0,0 +1,36 @@
+package outbox
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/metrics"
+)
+
+func TestRecordPendingStats(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	recordPendingStats([]*domain.OutboxPendingStats{
+		{TenantID: "tenant-a", Count: 3, OldestCreatedAt: now.Add(-30 * time.Second)},
+		{TenantID: "tenant-b", Count: 1, OldestCreatedAt: now.Add(-2 * time.Minute)},
+	}, now)
+
+	if got := testutil.ToFloat64(metrics.OutboxPendingEvents.WithLabelValues("tenant-a")); got != 3 {
+		t.Errorf("pending events for tenant-a = %v, want 3", got)
+	}
+	if got := testutil.ToFloat64(metrics.OutboxOldestPendingAge); got != 120 {
+		t.Errorf("oldest pending age = %v, want 120", got)
+	}
+
+	// Drained outbox clears per-tenant series and the age
+	recordPendingStats(nil, now)
+
+	if got := testutil.CollectAndCount(metrics.OutboxPendingEvents); got != 0 {
+		t.Errorf("pending event series = %d, want 0", got)
+	}
+	if got := testutil.ToFloat64(metrics.OutboxOldestPendingAge); got != 0 {
+		t.Errorf("oldest pending age = %v, want 0", got)
+	}
+}
@@ -0,0 +1,235 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// Relay defaults used when a RelayConfig field is not positive
+const (
+	DefaultRelayWorkers     = 4
+	DefaultRelayBatchSize   = 500
+	DefaultRelayInterval    = time.Second
+	DefaultRelayMaxAttempts = 10
+)
+
+// RelayStore interface for reading pending outbox events and recording their outcome
+type RelayStore interface {
+	FindPending(ctx context.Context, limit int) ([]*domain.OutboxEvent, error)
+	MarkProcessed(ctx context.Context, id string, tenantID string) error
+	RecordFailure(ctx context.Context, id string, tenantID string, errorMsg string) error
+	MarkFailed(ctx context.Context, id string, tenantID string, errorMsg string) error
+}
+
+// Publisher interface for delivering an outbox event to its consumers
+type Publisher interface {
+	Publish(ctx context.Context, event *domain.OutboxEvent) error
+}
+
+// RelayConfig holds outbox relay settings
+type RelayConfig struct {
+	Workers     int           // Aggregates published concurrently
+	BatchSize   int           // Pending events read per poll
+	Interval    time.Duration // Wait between polls that find no events
+	MaxAttempts int           // Publish attempts before an event is marked failed and skipped
+}
+
+// Relay publishes pending outbox events, for deployments without Debezium. Events for one
+// aggregate, such as a notification's created and status_changed events, are published one
+// at a time in creation order; different aggregates are published concurrently.
+//
+// A failed event stays pending and blocks its aggregate's later events until it is published
+// or runs out of attempts. Publishing is at least once: an event published just before the
+// relay stops may be published again, so consumers should ignore event IDs they have seen.
+// Run the relay on one instance only.
+type Relay struct {
+	store     RelayStore
+	publisher Publisher
+	config    RelayConfig
+	log       *logger.Logger
+}
+
+// NewRelay creates a new outbox relay
+func NewRelay(store RelayStore, publisher Publisher, config RelayConfig, log *logger.Logger) *Relay {
+	if config.Workers <= 0 {
+		config.Workers = DefaultRelayWorkers
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultRelayBatchSize
+	}
+	if config.Interval <= 0 {
+		config.Interval = DefaultRelayInterval
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = DefaultRelayMaxAttempts
+	}
+	return &Relay{
+		store:     store,
+		publisher: publisher,
+		config:    config,
+		log:       log,
+	}
+}
+
+// Start publishes pending events until ctx is cancelled. Full batches are followed at once
+// by the next; otherwise the relay waits an interval between polls.
+func (r *Relay) Start(ctx context.Context) {
+	r.log.Info("Starting outbox relay", "workers", r.config.Workers, "batch_size", r.config.BatchSize, "interval", r.config.Interval)
+
+	go func() {
+		for {
+			found, err := r.RelayBatch(ctx)
+			if err != nil && ctx.Err() == nil {
+				r.log.Error("Failed to relay outbox events", "error", err)
+			}
+
+			wait := r.config.Interval
+			if err == nil && found >= r.config.BatchSize {
+				wait = 0
+			}
+			select {
+			case <-ctx.Done():
+				r.log.Info("Outbox relay stopped")
+				return
+			case <-time.After(wait):
+			}
+		}
+	}()
+}
+
+// RelayBatch publishes one batch of the oldest pending events, returning how many were read.
+// The batch is the oldest events overall, so it holds every pending event of an aggregate
+// older than the newest one it holds, and each aggregate's events can be published in order.
+func (r *Relay) RelayBatch(ctx context.Context) (int, error) {
+	events, err := r.store.FindPending(ctx, r.config.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find pending outbox events: %w", err)
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	partitions := make(chan []*domain.OutboxEvent)
+	var wg sync.WaitGroup
+	for i := 0; i < r.config.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for partition := range partitions {
+				r.publishInOrder(ctx, partition)
+			}
+		}()
+	}
+	for _, partition := range partitionByAggregate(events) {
+		partitions <- partition
+	}
+	close(partitions)
+	wg.Wait()
+
+	return len(events), ctx.Err()
+}
+
+// publishInOrder publishes one aggregate's events in order, stopping at the first failure so
+// no later event overtakes it
+func (r *Relay) publishInOrder(ctx context.Context, events []*domain.OutboxEvent) {
+	for _, event := range events {
+		if ctx.Err() != nil {
+			return
+		}
+		if !r.publish(ctx, event) {
+			return
+		}
+	}
+}
+
+// publish publishes an event and records the outcome, reporting whether the aggregate's
+// later events may follow it
+func (r *Relay) publish(ctx context.Context, event *domain.OutboxEvent) bool {
+	id := event.ID.Hex()
+	err := r.publisher.Publish(ctx, event)
+	if err == nil {
+		if err := r.store.MarkProcessed(ctx, id, event.TenantID); err != nil {
+			// The event stays pending and is published again, before any later event
+			r.log.Error("Failed to mark outbox event processed", "error", err, "event_id", id, "tenant_id", event.TenantID)
+			return false
+		}
+		return true
+	}
+
+	attempts := event.ErrorCount + 1
+	if attempts >= r.config.MaxAttempts {
+		r.log.Error("Outbox event failed on final attempt, skipping it", "error", err, "event_id", id, "tenant_id", event.TenantID,
+			"event_type", event.EventType, "aggregate_id", event.AggregateID, "attempts", attempts)
+		if err := r.store.MarkFailed(ctx, id, event.TenantID, err.Error()); err != nil {
+			r.log.Error("Failed to mark outbox event failed", "error", err, "event_id", id, "tenant_id", event.TenantID)
+		}
+		return false
+	}
+
+	r.log.Warn("Failed to publish outbox event", "error", err, "event_id", id, "tenant_id", event.TenantID,
+		"event_type", event.EventType, "aggregate_id", event.AggregateID, "attempts", attempts)
+	if err := r.store.RecordFailure(ctx, id, event.TenantID, err.Error()); err != nil {
+		r.log.Error("Failed to record outbox event failure", "error", err, "event_id", id, "tenant_id", event.TenantID)
+	}
+	return false
+}
+
+// aggregateKey identifies an aggregate across tenants and aggregate types
+type aggregateKey struct {
+	tenantID      string
+	aggregateType string
+	aggregateID   string
+}
+
+// partitionByAggregate groups events by aggregate, keeping each group in the order given.
+// Groups are ordered by their first event.
+func partitionByAggregate(events []*domain.OutboxEvent) [][]*domain.OutboxEvent {
+	index := make(map[aggregateKey]int)
+	var partitions [][]*domain.OutboxEvent
+	for _, event := range events {
+		key := aggregateKey{event.TenantID, event.AggregateType, event.AggregateID}
+		i, ok := index[key]
+		if !ok {
+			i = len(partitions)
+			index[key] = i
+			partitions = append(partitions, nil)
+		}
+		partitions[i] = append(partitions[i], event)
+	}
+	return partitions
+}
+
+// MessagePublisher interface for publishing a message to an exchange
+type MessagePublisher interface {
+	Publish(exchange, routingKey string, body []byte) error
+}
+
+// ExchangePublisher publishes outbox events as JSON to an exchange, routed by event type as
+// Debezium's outbox router routes them to topics
+type ExchangePublisher struct {
+	client   MessagePublisher
+	exchange string
+}
+
+// NewExchangePublisher creates a publisher of outbox events to exchange
+func NewExchangePublisher(client MessagePublisher, exchange string) *ExchangePublisher {
+	return &ExchangePublisher{
+		client:   client,
+		exchange: exchange,
+	}
+}
+
+// Publish publishes the event with its event type as the routing key
+func (p *ExchangePublisher) Publish(ctx context.Context, event *domain.OutboxEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode outbox event: %w", err)
+	}
+	return p.client.Publish(p.exchange, string(event.EventType), body)
+}
@@ -0,0 +1,165 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryOutbox keeps outbox events in memory, in creation order
+type memoryOutbox struct {
+	mu     sync.Mutex
+	events []*domain.OutboxEvent
+}
+
+func (s *memoryOutbox) add(tenantID, aggregateID string, eventType domain.OutboxEventType) *domain.OutboxEvent {
+	created := time.Now()
+	event := &domain.OutboxEvent{
+		ID:            primitive.NewObjectID(),
+		TenantID:      tenantID,
+		AggregateType: "notification",
+		AggregateID:   aggregateID,
+		EventType:     eventType,
+		Status:        domain.OutboxEventStatusPending,
+		CreatedAt:     &created,
+	}
+	s.events = append(s.events, event)
+	return event
+}
+
+func (s *memoryOutbox) FindPending(ctx context.Context, limit int) ([]*domain.OutboxEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var pending []*domain.OutboxEvent
+	for _, event := range s.events {
+		if event.Status == domain.OutboxEventStatusPending && len(pending) < limit {
+			copied := *event
+			pending = append(pending, &copied)
+		}
+	}
+	return pending, nil
+}
+
+func (s *memoryOutbox) update(id string, update func(event *domain.OutboxEvent)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, event := range s.events {
+		if event.ID.Hex() == id {
+			update(event)
+			return nil
+		}
+	}
+	return errors.New("outbox event not found")
+}
+
+func (s *memoryOutbox) MarkProcessed(ctx context.Context, id string, tenantID string) error {
+	return s.update(id, func(event *domain.OutboxEvent) { event.Status = domain.OutboxEventStatusProcessed })
+}
+
+func (s *memoryOutbox) RecordFailure(ctx context.Context, id string, tenantID string, errorMsg string) error {
+	return s.update(id, func(event *domain.OutboxEvent) { event.ErrorCount++ })
+}
+
+func (s *memoryOutbox) MarkFailed(ctx context.Context, id string, tenantID string, errorMsg string) error {
+	return s.update(id, func(event *domain.OutboxEvent) {
+		event.ErrorCount++
+		event.Status = domain.OutboxEventStatusFailed
+	})
+}
+
+// recordingPublisher records published events after a random delay, so workers interleave,
+// failing events listed in fail
+type recordingPublisher struct {
+	mu        sync.Mutex
+	published []*domain.OutboxEvent
+	fail      map[primitive.ObjectID]bool
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, event *domain.OutboxEvent) error {
+	time.Sleep(time.Duration(rand.Intn(500)) * time.Microsecond)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.fail[event.ID] {
+		return errors.New("broker unavailable")
+	}
+	p.published = append(p.published, event)
+	return nil
+}
+
+// publishedFor returns the event types published for an aggregate, in publish order
+func (p *recordingPublisher) publishedFor(aggregateID string) []domain.OutboxEventType {
+	var types []domain.OutboxEventType
+	for _, event := range p.published {
+		if event.AggregateID == aggregateID {
+			types = append(types, event.EventType)
+		}
+	}
+	return types
+}
+
+func TestRelayPublishesAggregateInCreationOrder(t *testing.T) {
+	store := &memoryOutbox{}
+	for _, aggregateID := range []string{"n1", "n2", "n3", "n4", "n5", "n6"} {
+		store.add("tenant-1", aggregateID, domain.EventNotificationCreated)
+	}
+	for _, aggregateID := range []string{"n6", "n5", "n4", "n3", "n2", "n1"} {
+		store.add("tenant-1", aggregateID, domain.EventNotificationStatusChanged)
+	}
+	publisher := &recordingPublisher{}
+	relay := NewRelay(store, publisher, RelayConfig{Workers: 4}, logger.NewLogger())
+
+	found, err := relay.RelayBatch(context.Background())
+	if err != nil {
+		t.Fatalf("RelayBatch() error = %v", err)
+	}
+	if found != 12 || len(publisher.published) != 12 {
+		t.Fatalf("relayed %d events and published %d, want 12", found, len(publisher.published))
+	}
+	for _, aggregateID := range []string{"n1", "n2", "n3", "n4", "n5", "n6"} {
+		types := publisher.publishedFor(aggregateID)
+		if len(types) != 2 || types[0] != domain.EventNotificationCreated || types[1] != domain.EventNotificationStatusChanged {
+			t.Errorf("events for %s published as %v, want created then status_changed", aggregateID, types)
+		}
+	}
+}
+
+func TestRelayFailureBlocksLaterAggregateEvents(t *testing.T) {
+	store := &memoryOutbox{}
+	created := store.add("tenant-1", "n1", domain.EventNotificationCreated)
+	store.add("tenant-1", "n2", domain.EventNotificationCreated)
+	store.add("tenant-1", "n1", domain.EventNotificationStatusChanged)
+	publisher := &recordingPublisher{fail: map[primitive.ObjectID]bool{created.ID: true}}
+	relay := NewRelay(store, publisher, RelayConfig{Workers: 2, MaxAttempts: 2}, logger.NewLogger())
+	ctx := context.Background()
+
+	if _, err := relay.RelayBatch(ctx); err != nil {
+		t.Fatalf("RelayBatch() error = %v", err)
+	}
+	if got := publisher.publishedFor("n1"); len(got) != 0 {
+		t.Errorf("n1 published %v after its first event failed, want nothing", got)
+	}
+	if got := publisher.publishedFor("n2"); len(got) != 1 {
+		t.Errorf("n2 published %v, want its event despite n1's failure", got)
+	}
+
+	// Once the failed event runs out of attempts it is skipped, and the aggregate moves on
+	if _, err := relay.RelayBatch(ctx); err != nil {
+		t.Fatalf("RelayBatch() error = %v", err)
+	}
+	if created.Status != domain.OutboxEventStatusFailed {
+		t.Errorf("failed event status = %s, want failed", created.Status)
+	}
+	if _, err := relay.RelayBatch(ctx); err != nil {
+		t.Fatalf("RelayBatch() error = %v", err)
+	}
+	if got := publisher.publishedFor("n1"); len(got) != 1 || got[0] != domain.EventNotificationStatusChanged {
+		t.Errorf("n1 published %v, want its status_changed event", got)
+	}
+}
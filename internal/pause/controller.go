@@ -0,0 +1,273 @@
+package pause
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/metrics"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// Channels are the notification types that can be paused individually
+var Channels = []domain.NotificationType{
+	domain.NotificationTypeEmail,
+	domain.NotificationTypeSMS,
+	domain.NotificationTypeWebhook,
+}
+
+// Store interface for the shared send pause state
+type Store interface {
+	LoadPause(ctx context.Context) (*domain.SendPause, error)
+	SavePause(ctx context.Context, pause *domain.SendPause) error
+}
+
+// Config holds send pause settings
+type Config struct {
+	AllowCritical   bool          // Critical-priority sends bypass the pause
+	RefreshInterval time.Duration // How often the shared state is reloaded from the store
+}
+
+// Controller is the global and per-channel send kill switch. The state lives in the store so
+// a pause applied through one instance reaches every instance within the refresh interval.
+type Controller struct {
+	store  Store // Optional; nil keeps the state in memory
+	config Config
+	log    *logger.Logger
+
+	mu       sync.RWMutex
+	state    domain.SendPause
+	changed  chan struct{} // Closed and replaced whenever the state changes
+	onResume []func(ctx context.Context, channels []domain.NotificationType)
+	ctx      context.Context
+}
+
+// NewController creates a new send pause controller with an initial state
+func NewController(store Store, config Config, initial domain.SendPause, log *logger.Logger) *Controller {
+	c := &Controller{
+		store:   store,
+		config:  config,
+		log:     log,
+		changed: make(chan struct{}),
+		ctx:     context.Background(),
+	}
+	c.apply(initial)
+	return c
+}
+
+// OnResume registers a function called in the background with the channels that resumed
+func (c *Controller) OnResume(fn func(ctx context.Context, channels []domain.NotificationType)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onResume = append(c.onResume, fn)
+}
+
+// Start loads the shared state and keeps it in sync until ctx is cancelled.
+// A pause in the initial state (from configuration) is written to the store first.
+// Resume hooks run once for every active channel, so notifications held before a
+// restart are not stranded.
+func (c *Controller) Start(ctx context.Context) error {
+	c.mu.Lock()
+	c.ctx = ctx
+	initial := c.state
+	c.mu.Unlock()
+
+	if c.store != nil {
+		if initial.All || len(initial.Channels) > 0 {
+			if err := c.store.SavePause(ctx, &initial); err != nil {
+				return err
+			}
+		}
+		if err := c.refresh(ctx); err != nil {
+			return err
+		}
+	}
+
+	var active []domain.NotificationType
+	for _, channel := range Channels {
+		if !c.Paused(channel, "") {
+			active = append(active, channel)
+		}
+	}
+	c.runHooks(active)
+
+	if c.store != nil && c.config.RefreshInterval > 0 {
+		go func() {
+			ticker := time.NewTicker(c.config.RefreshInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					if err := c.refresh(ctx); err != nil {
+						c.log.Error("Failed to refresh send pause state", "error", err)
+					}
+				}
+			}
+		}()
+	}
+	return nil
+}
+
+// Pause pauses channels, or every channel when none are given
+func (c *Controller) Pause(ctx context.Context, channels []domain.NotificationType, actor, reason string) error {
+	state := c.Status()
+	if len(channels) == 0 {
+		state.All = true
+		state.Channels = nil
+	} else {
+		for _, channel := range channels {
+			if !slices.Contains(state.Channels, channel) {
+				state.Channels = append(state.Channels, channel)
+			}
+		}
+	}
+	state.Reason = reason
+	state.UpdatedBy = actor
+
+	c.log.Warn("Pausing sends", "channels", channels, "all", state.All, "actor", actor, "reason", reason)
+	return c.save(ctx, state)
+}
+
+// Resume resumes channels, or every channel when none are given
+func (c *Controller) Resume(ctx context.Context, channels []domain.NotificationType, actor string) error {
+	state := c.Status()
+	if len(channels) == 0 {
+		state = domain.SendPause{}
+	} else {
+		if state.All {
+			state.All = false
+			state.Channels = slices.Clone(Channels)
+		}
+		state.Channels = slices.DeleteFunc(state.Channels, func(channel domain.NotificationType) bool {
+			return slices.Contains(channels, channel)
+		})
+		if len(state.Channels) == 0 {
+			state.Reason = ""
+		}
+	}
+	state.UpdatedBy = actor
+
+	c.log.Info("Resuming sends", "channels", channels, "actor", actor)
+	return c.save(ctx, state)
+}
+
+// Paused reports whether a send on channel with priority must be held
+func (c *Controller) Paused(channel domain.NotificationType, priority domain.NotificationPriority) bool {
+	if c.config.AllowCritical && priority == domain.NotificationPriorityCritical {
+		return false
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.state.All || slices.Contains(c.state.Channels, channel)
+}
+
+// Wait blocks while every channel is paused, returning early with ctx.Err() if ctx is done
+func (c *Controller) Wait(ctx context.Context) error {
+	for {
+		c.mu.RLock()
+		paused, changed := c.allPaused(), c.changed
+		c.mu.RUnlock()
+		if !paused {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// Status returns a copy of the current pause state
+func (c *Controller) Status() domain.SendPause {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	state := c.state
+	state.Channels = slices.Clone(c.state.Channels)
+	return state
+}
+
+// save stores and applies a new state
+func (c *Controller) save(ctx context.Context, state domain.SendPause) error {
+	if c.store != nil {
+		if err := c.store.SavePause(ctx, &state); err != nil {
+			return err
+		}
+	} else {
+		state.UpdatedAt = time.Now()
+	}
+	c.apply(state)
+	return nil
+}
+
+// refresh reloads the state from the store
+func (c *Controller) refresh(ctx context.Context) error {
+	state, err := c.store.LoadPause(ctx)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		state = &domain.SendPause{}
+	}
+	c.apply(*state)
+	return nil
+}
+
+// apply replaces the in-memory state, updates metrics and notifies waiters and resume hooks
+func (c *Controller) apply(state domain.SendPause) {
+	c.mu.Lock()
+	var resumed []domain.NotificationType
+	for _, channel := range Channels {
+		was := c.state.All || slices.Contains(c.state.Channels, channel)
+		now := state.All || slices.Contains(state.Channels, channel)
+		if was && !now {
+			resumed = append(resumed, channel)
+		}
+
+		value := 0.0
+		if now {
+			value = 1
+		}
+		metrics.SendsPaused.WithLabelValues(string(channel)).Set(value)
+	}
+
+	c.state = state
+	close(c.changed)
+	c.changed = make(chan struct{})
+	c.mu.Unlock()
+
+	c.runHooks(resumed)
+}
+
+// runHooks calls the resume hooks in the background for channels
+func (c *Controller) runHooks(channels []domain.NotificationType) {
+	if len(channels) == 0 {
+		return
+	}
+
+	c.mu.RLock()
+	hooks, ctx := c.onResume, c.ctx
+	c.mu.RUnlock()
+	for _, hook := range hooks {
+		go hook(ctx, channels)
+	}
+}
+
+// allPaused reports whether every channel is paused. Must be called with c.mu held.
+func (c *Controller) allPaused() bool {
+	if c.state.All {
+		return true
+	}
+	for _, channel := range Channels {
+		if !slices.Contains(c.state.Channels, channel) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,176 @@
+package pause
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/metrics"
+	"github.com/vhvplatform/go-notification-service/internal/sender"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// heldClaimTimeout is how long a claimed held notification may go unsent before it is claimed again
+const heldClaimTimeout = 10 * time.Minute
+
+// HoldStore interface for storing notifications held while paused
+type HoldStore interface {
+	Hold(ctx context.Context, held *domain.HeldNotification) error
+	ClaimHeld(ctx context.Context, channels []domain.NotificationType, since time.Time, staleAfter time.Duration) (*domain.HeldNotification, error)
+	CompleteHeld(ctx context.Context, id primitive.ObjectID) error
+	ReleaseHeld(ctx context.Context, id primitive.ObjectID, failure string) error
+}
+
+// Gate sends through next unless the channel is paused, in which case the request is held and
+// nil is returned. Held requests are sent once their channel resumes.
+type Gate struct {
+	controller *Controller
+	holds      HoldStore
+	next       sender.Sender
+	log        *logger.Logger
+	draining   sync.Mutex // Serializes drains on this instance; claims are atomic across instances
+}
+
+// NewGate creates a new send gate and registers it to drain held notifications on resume
+func NewGate(controller *Controller, holds HoldStore, next sender.Sender, log *logger.Logger) *Gate {
+	g := &Gate{
+		controller: controller,
+		holds:      holds,
+		next:       next,
+		log:        log,
+	}
+	controller.OnResume(g.drain)
+	return g
+}
+
+// Paused reports whether a send on channel with priority would be held
+func (g *Gate) Paused(channel domain.NotificationType, priority domain.NotificationPriority) bool {
+	return g.controller.Paused(channel, priority)
+}
+
+// SendEmail sends an email, or holds it while email sends are paused
+func (g *Gate) SendEmail(ctx context.Context, req *domain.SendEmailRequest) error {
+	if held, err := g.hold(ctx, domain.NotificationTypeEmail, req.TenantID, req.Priority, req); held || err != nil {
+		return err
+	}
+	return g.next.SendEmail(ctx, req)
+}
+
+// SendSMS sends an SMS, or holds it while SMS sends are paused
+func (g *Gate) SendSMS(ctx context.Context, req *domain.SendSMSRequest) error {
+	if held, err := g.hold(ctx, domain.NotificationTypeSMS, req.TenantID, req.Priority, req); held || err != nil {
+		return err
+	}
+	return g.next.SendSMS(ctx, req)
+}
+
+// SendWebhook sends a webhook, or holds it while webhook sends are paused
+func (g *Gate) SendWebhook(ctx context.Context, req *domain.SendWebhookRequest) error {
+	if held, err := g.hold(ctx, domain.NotificationTypeWebhook, req.TenantID, req.Priority, req); held || err != nil {
+		return err
+	}
+	return g.next.SendWebhook(ctx, req)
+}
+
+// hold stores req if channel is paused, reporting whether it was held
+func (g *Gate) hold(ctx context.Context, channel domain.NotificationType, tenantID string, priority domain.NotificationPriority, req interface{}) (bool, error) {
+	if !g.controller.Paused(channel, priority) {
+		return false, nil
+	}
+
+	request, err := json.Marshal(req)
+	if err != nil {
+		return true, fmt.Errorf("failed to encode held %s request: %w", channel, err)
+	}
+	held := &domain.HeldNotification{
+		TenantID: tenantID,
+		Type:     channel,
+		Priority: priority,
+		Request:  request,
+	}
+	if err := g.holds.Hold(ctx, held); err != nil {
+		return true, fmt.Errorf("failed to hold %s notification: %w", channel, err)
+	}
+
+	metrics.NotificationsHeld.WithLabelValues(string(channel)).Inc()
+	g.log.Info("Holding notification while sends are paused", "type", channel, "tenant_id", tenantID)
+	return true, nil
+}
+
+// drain sends held notifications for channels until none remain or the channels pause again.
+// A notification whose send fails is held again and left for the next drain.
+func (g *Gate) drain(ctx context.Context, channels []domain.NotificationType) {
+	g.draining.Lock()
+	defer g.draining.Unlock()
+
+	started := time.Now()
+	sent, failed := 0, 0
+	for ctx.Err() == nil {
+		active := make([]domain.NotificationType, 0, len(channels))
+		for _, channel := range channels {
+			if !g.controller.Paused(channel, "") {
+				active = append(active, channel)
+			}
+		}
+		if len(active) == 0 {
+			break
+		}
+
+		held, err := g.holds.ClaimHeld(ctx, active, started, heldClaimTimeout)
+		if err != nil {
+			g.log.Error("Failed to claim held notification", "error", err)
+			break
+		}
+		if held == nil {
+			break
+		}
+
+		if err := g.send(ctx, held); err != nil {
+			g.log.Error("Failed to send held notification", "error", err, "id", held.ID.Hex(), "type", held.Type, "tenant_id", held.TenantID)
+			if err := g.holds.ReleaseHeld(context.WithoutCancel(ctx), held.ID, err.Error()); err != nil {
+				g.log.Error("Failed to hold notification again", "error", err, "id", held.ID.Hex())
+			}
+			failed++
+			continue
+		}
+		if err := g.holds.CompleteHeld(context.WithoutCancel(ctx), held.ID); err != nil {
+			// It is claimed again once heldClaimTimeout passes, so it may be sent twice
+			g.log.Error("Failed to remove sent held notification", "error", err, "id", held.ID.Hex())
+		}
+		sent++
+	}
+
+	if sent > 0 || failed > 0 {
+		g.log.Info("Sent held notifications", "channels", channels, "count", sent, "failed", failed)
+	}
+}
+
+// send decodes a held request and sends it through next
+func (g *Gate) send(ctx context.Context, held *domain.HeldNotification) error {
+	switch held.Type {
+	case domain.NotificationTypeEmail:
+		var req domain.SendEmailRequest
+		if err := json.Unmarshal(held.Request, &req); err != nil {
+			return err
+		}
+		return g.next.SendEmail(ctx, &req)
+	case domain.NotificationTypeSMS:
+		var req domain.SendSMSRequest
+		if err := json.Unmarshal(held.Request, &req); err != nil {
+			return err
+		}
+		return g.next.SendSMS(ctx, &req)
+	case domain.NotificationTypeWebhook:
+		var req domain.SendWebhookRequest
+		if err := json.Unmarshal(held.Request, &req); err != nil {
+			return err
+		}
+		return g.next.SendWebhook(ctx, &req)
+	default:
+		return fmt.Errorf("unsupported notification type: %s", held.Type)
+	}
+}
@@ -0,0 +1,353 @@
+package pause
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryStore keeps the pause state and held notifications in memory
+type memoryStore struct {
+	mu    sync.Mutex
+	state *domain.SendPause
+	held  []*domain.HeldNotification
+}
+
+func (s *memoryStore) LoadPause(ctx context.Context) (*domain.SendPause, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.state == nil {
+		return nil, nil
+	}
+	state := *s.state
+	return &state, nil
+}
+
+func (s *memoryStore) SavePause(ctx context.Context, pause *domain.SendPause) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := *pause
+	s.state = &state
+	return nil
+}
+
+func (s *memoryStore) Hold(ctx context.Context, held *domain.HeldNotification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	held.ID = primitive.NewObjectID()
+	held.Status = domain.HeldStatusHeld
+	s.held = append(s.held, held)
+	return nil
+}
+
+func (s *memoryStore) ClaimHeld(ctx context.Context, channels []domain.NotificationType, since time.Time, staleAfter time.Duration) (*domain.HeldNotification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for _, held := range s.held {
+		if !slices.Contains(channels, held.Type) {
+			continue
+		}
+		claimable := held.Status != domain.HeldStatusSending && (held.FailedAt == nil || held.FailedAt.Before(since))
+		stale := held.Status == domain.HeldStatusSending && !held.ClaimedAt.After(now.Add(-staleAfter))
+		if claimable || stale {
+			held.Status = domain.HeldStatusSending
+			held.ClaimedAt = &now
+			claimed := *held
+			return &claimed, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *memoryStore) CompleteHeld(ctx context.Context, id primitive.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.held = slices.DeleteFunc(s.held, func(held *domain.HeldNotification) bool {
+		return held.ID == id && held.Status == domain.HeldStatusSending
+	})
+	return nil
+}
+
+func (s *memoryStore) ReleaseHeld(ctx context.Context, id primitive.ObjectID, failure string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, held := range s.held {
+		if held.ID == id && held.Status == domain.HeldStatusSending {
+			now := time.Now()
+			held.Status = domain.HeldStatusHeld
+			held.LastError = failure
+			held.FailedAt = &now
+			held.ClaimedAt = nil
+			held.Attempts++
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) snapshot() []domain.HeldNotification {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	held := make([]domain.HeldNotification, 0, len(s.held))
+	for _, h := range s.held {
+		held = append(held, *h)
+	}
+	return held
+}
+
+func (s *memoryStore) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.held)
+}
+
+// recordingSender records the recipients of sent notifications, failing sends to recipients in fail
+type recordingSender struct {
+	mu   sync.Mutex
+	sent []string
+	fail map[string]bool
+}
+
+func (r *recordingSender) record(to string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.fail[to] {
+		return errors.New("provider unavailable")
+	}
+	r.sent = append(r.sent, to)
+	return nil
+}
+
+func (r *recordingSender) SendEmail(ctx context.Context, req *domain.SendEmailRequest) error {
+	return r.record(req.To[0])
+}
+
+func (r *recordingSender) SendSMS(ctx context.Context, req *domain.SendSMSRequest) error {
+	return r.record(req.To)
+}
+
+func (r *recordingSender) SendWebhook(ctx context.Context, req *domain.SendWebhookRequest) error {
+	return r.record(req.URL)
+}
+
+func (r *recordingSender) recipients() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.sent)
+}
+
+func TestControllerPauseAndResume(t *testing.T) {
+	ctx := context.Background()
+	c := NewController(&memoryStore{}, Config{}, domain.SendPause{}, logger.NewLogger())
+
+	if err := c.Pause(ctx, []domain.NotificationType{domain.NotificationTypeSMS}, "ops", "bad template"); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+	if !c.Paused(domain.NotificationTypeSMS, "") || c.Paused(domain.NotificationTypeEmail, "") {
+		t.Error("Pausing SMS should pause only SMS")
+	}
+
+	if err := c.Pause(ctx, nil, "ops", "incident"); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+	for _, channel := range Channels {
+		if !c.Paused(channel, domain.NotificationPriorityCritical) {
+			t.Errorf("Paused(%s) = false during a global pause", channel)
+		}
+	}
+
+	// Resuming one channel of a global pause leaves the others paused
+	if err := c.Resume(ctx, []domain.NotificationType{domain.NotificationTypeEmail}, "ops"); err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+	if c.Paused(domain.NotificationTypeEmail, "") || !c.Paused(domain.NotificationTypeWebhook, "") {
+		t.Errorf("Status() = %+v, want only email resumed", c.Status())
+	}
+
+	if err := c.Resume(ctx, nil, "ops"); err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+	if status := c.Status(); status.All || len(status.Channels) > 0 {
+		t.Errorf("Status() = %+v after resuming everything", status)
+	}
+}
+
+func TestControllerCriticalOverride(t *testing.T) {
+	c := NewController(nil, Config{AllowCritical: true}, domain.SendPause{All: true}, logger.NewLogger())
+	if c.Paused(domain.NotificationTypeEmail, domain.NotificationPriorityCritical) {
+		t.Error("Critical sends should bypass the pause when AllowCritical is set")
+	}
+	if !c.Paused(domain.NotificationTypeEmail, domain.NotificationPriorityHigh) {
+		t.Error("High priority sends should be paused")
+	}
+}
+
+func TestControllerSharesStateThroughStore(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := &memoryStore{}
+	first := NewController(store, Config{}, domain.SendPause{}, logger.NewLogger())
+	second := NewController(store, Config{RefreshInterval: 10 * time.Millisecond}, domain.SendPause{}, logger.NewLogger())
+	if err := second.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	if err := first.Pause(ctx, nil, "ops", "incident"); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for !second.Paused(domain.NotificationTypeEmail, "") {
+		if time.Now().After(deadline) {
+			t.Fatal("Pause was not picked up by the other instance")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestControllerWait(t *testing.T) {
+	c := NewController(nil, Config{}, domain.SendPause{All: true}, logger.NewLogger())
+
+	done := make(chan error, 1)
+	go func() { done <- c.Wait(context.Background()) }()
+
+	select {
+	case <-done:
+		t.Fatal("Wait() returned while every channel was paused")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	if err := c.Resume(context.Background(), []domain.NotificationType{domain.NotificationTypeSMS}, "ops"); err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Wait() error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Wait() did not return after a channel resumed")
+	}
+}
+
+func TestGateHoldsAndDrainsOnResume(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryStore{}
+	sender := &recordingSender{}
+	c := NewController(store, Config{}, domain.SendPause{}, logger.NewLogger())
+	gate := NewGate(c, store, sender, logger.NewLogger())
+
+	if err := c.Pause(ctx, []domain.NotificationType{domain.NotificationTypeEmail}, "ops", "incident"); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+
+	_ = gate.SendEmail(ctx, &domain.SendEmailRequest{TenantID: "tenant-a", To: []string{"first@example.com"}})
+	_ = gate.SendEmail(ctx, &domain.SendEmailRequest{TenantID: "tenant-a", To: []string{"second@example.com"}})
+	_ = gate.SendSMS(ctx, &domain.SendSMSRequest{TenantID: "tenant-a", To: "+15550100"})
+
+	if got := sender.recipients(); !slices.Equal(got, []string{"+15550100"}) {
+		t.Fatalf("sent while paused = %v, want only the SMS", got)
+	}
+	if store.count() != 2 {
+		t.Fatalf("held = %d, want 2", store.count())
+	}
+
+	if err := c.Resume(ctx, nil, "ops"); err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for store.count() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Held notifications were not drained after resume")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	gate.draining.Lock()
+	defer gate.draining.Unlock()
+	want := []string{"+15550100", "first@example.com", "second@example.com"}
+	if got := sender.recipients(); !slices.Equal(got, want) {
+		t.Errorf("sent = %v, want %v", got, want)
+	}
+}
+
+// holdEmail stores a held email to to, as Gate.SendEmail does while email is paused
+func holdEmail(t *testing.T, store *memoryStore, to string) {
+	t.Helper()
+	request, err := json.Marshal(&domain.SendEmailRequest{TenantID: "tenant-a", To: []string{to}})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if err := store.Hold(context.Background(), &domain.HeldNotification{TenantID: "tenant-a", Type: domain.NotificationTypeEmail, Request: request}); err != nil {
+		t.Fatalf("Hold() error = %v", err)
+	}
+}
+
+func TestGateHoldsFailedSendAgain(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryStore{}
+	sender := &recordingSender{fail: map[string]bool{"first@example.com": true}}
+	gate := NewGate(NewController(store, Config{}, domain.SendPause{}, logger.NewLogger()), store, sender, logger.NewLogger())
+	holdEmail(t, store, "first@example.com")
+	holdEmail(t, store, "second@example.com")
+
+	gate.drain(ctx, Channels)
+
+	if got := sender.recipients(); !slices.Equal(got, []string{"second@example.com"}) {
+		t.Errorf("sent = %v, want only the second email", got)
+	}
+	held := store.snapshot()
+	if len(held) != 1 {
+		t.Fatalf("held = %d, want the failed email held again", len(held))
+	}
+	if held[0].Status != domain.HeldStatusHeld || held[0].Attempts != 1 || held[0].LastError == "" {
+		t.Errorf("held = %+v, want held with one failed attempt", held[0])
+	}
+
+	// The next drain sends it once the provider recovers
+	sender.mu.Lock()
+	sender.fail = nil
+	sender.mu.Unlock()
+	time.Sleep(time.Millisecond) // The next drain starts after the failure
+	gate.drain(ctx, Channels)
+	if got := sender.recipients(); !slices.Equal(got, []string{"second@example.com", "first@example.com"}) {
+		t.Errorf("sent = %v, want the failed email sent on the next drain", got)
+	}
+	if held := store.snapshot(); len(held) != 0 {
+		t.Errorf("held = %d after a successful drain, want 0", len(held))
+	}
+}
+
+func TestGateReclaimsStaleClaim(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryStore{}
+	sender := &recordingSender{}
+	gate := NewGate(NewController(store, Config{}, domain.SendPause{}, logger.NewLogger()), store, sender, logger.NewLogger())
+	holdEmail(t, store, "first@example.com")
+
+	// An instance claimed it and crashed before sending
+	claimed, _ := store.ClaimHeld(ctx, Channels, time.Now(), heldClaimTimeout)
+	if claimed == nil {
+		t.Fatal("ClaimHeld() = nil")
+	}
+	if again, _ := store.ClaimHeld(ctx, Channels, time.Now(), heldClaimTimeout); again != nil {
+		t.Fatal("ClaimHeld() claimed a notification that is already being sent")
+	}
+	store.mu.Lock()
+	stale := time.Now().Add(-2 * heldClaimTimeout)
+	store.held[0].ClaimedAt = &stale
+	store.mu.Unlock()
+
+	gate.drain(ctx, Channels)
+	if got := sender.recipients(); !slices.Equal(got, []string{"first@example.com"}) {
+		t.Errorf("sent = %v, want the stale claim sent", got)
+	}
+}
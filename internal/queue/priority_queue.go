@@ -1,128 +1,309 @@
-package queue
-
-import (
-	"container/heap"
-	"sync"
-
-	"github.com/vhvplatform/go-notification-service/internal/domain"
-)
-
-// Priority represents the priority level of an email job
-type Priority int
-
-const (
-	// PriorityHigh for critical emails (alerts, security)
-	PriorityHigh Priority = iota
-	// PriorityNormal for regular transactional emails
-	PriorityNormal
-	// PriorityLow for marketing emails
-	PriorityLow
-)
-
-// EmailJob represents an email job in the queue
-type EmailJob struct {
-	ID       string
-	Priority Priority
-	Request  *domain.SendEmailRequest
-	Index    int // Index in the heap
-}
-
-// emailJobHeap implements heap.Interface
-type emailJobHeap []*EmailJob
-
-func (h emailJobHeap) Len() int { return len(h) }
-
-func (h emailJobHeap) Less(i, j int) bool {
-	// Lower priority value = higher priority (processed first)
-	return h[i].Priority < h[j].Priority
-}
-
-func (h emailJobHeap) Swap(i, j int) {
-	h[i], h[j] = h[j], h[i]
-	h[i].Index = i
-	h[j].Index = j
-}
-
-func (h *emailJobHeap) Push(x interface{}) {
-	n := len(*h)
-	job := x.(*EmailJob)
-	job.Index = n
-	*h = append(*h, job)
-}
-
-func (h *emailJobHeap) Pop() interface{} {
-	old := *h
-	n := len(old)
-	job := old[n-1]
-	old[n-1] = nil // Avoid memory leak
-	job.Index = -1
-	*h = old[0 : n-1]
-	return job
-}
-
-// PriorityQueue is a thread-safe priority queue for email jobs
-type PriorityQueue struct {
-	jobs emailJobHeap
-	mu   sync.Mutex
-	cond *sync.Cond
-}
-
-// NewPriorityQueue creates a new priority queue
-func NewPriorityQueue() *PriorityQueue {
-	pq := &PriorityQueue{
-		jobs: make(emailJobHeap, 0),
-	}
-	pq.cond = sync.NewCond(&pq.mu)
-	heap.Init(&pq.jobs)
-	return pq
-}
-
-// Push adds a job to the queue
-func (pq *PriorityQueue) Push(job *EmailJob) {
-	pq.mu.Lock()
-	defer pq.mu.Unlock()
-
-	heap.Push(&pq.jobs, job)
-	pq.cond.Signal() // Wake up a waiting worker
-}
-
-// Pop removes and returns the highest priority job
-// Blocks if the queue is empty
-func (pq *PriorityQueue) Pop() *EmailJob {
-	pq.mu.Lock()
-	defer pq.mu.Unlock()
-
-	// Wait while queue is empty
-	for pq.jobs.Len() == 0 {
-		pq.cond.Wait()
-	}
-
-	job := heap.Pop(&pq.jobs).(*EmailJob)
-	return job
-}
-
-// TryPop tries to pop a job without blocking
-// Returns nil if queue is empty
-func (pq *PriorityQueue) TryPop() *EmailJob {
-	pq.mu.Lock()
-	defer pq.mu.Unlock()
-
-	if pq.jobs.Len() == 0 {
-		return nil
-	}
-
-	job := heap.Pop(&pq.jobs).(*EmailJob)
-	return job
-}
-
-// Len returns the number of jobs in the queue
-func (pq *PriorityQueue) Len() int {
-	pq.mu.Lock()
-	defer pq.mu.Unlock()
-	return pq.jobs.Len()
-}
-
-// IsEmpty returns true if the queue is empty
-func (pq *PriorityQueue) IsEmpty() bool {
-	return pq.Len() == 0
-}
+package queue
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/metrics"
+)
+
+// ErrQueueFull is returned when a job can't be queued because the queue is at its maximum depth
+var ErrQueueFull = errors.New("email queue is full")
+
+// Reasons a job is rejected, recorded in the email_queue_rejected_total metric
+const (
+	RejectFull    = "full"
+	RejectTimeout = "timeout"
+)
+
+// Priority represents the priority level of an email job
+type Priority int
+
+const (
+	// PriorityCritical for emails that must go out ahead of everything else
+	PriorityCritical Priority = iota
+	// PriorityHigh for important emails (alerts, security)
+	PriorityHigh
+	// PriorityNormal for regular transactional emails
+	PriorityNormal
+	// PriorityLow for marketing emails
+	PriorityLow
+)
+
+// PriorityFor maps a notification priority to its queue priority
+// This is the only mapping between the two; unset priorities are normal.
+func PriorityFor(priority domain.NotificationPriority) Priority {
+	switch priority {
+	case domain.NotificationPriorityCritical:
+		return PriorityCritical
+	case domain.NotificationPriorityHigh:
+		return PriorityHigh
+	case domain.NotificationPriorityLow:
+		return PriorityLow
+	default:
+		return PriorityNormal
+	}
+}
+
+// DefaultAging is how long a queued job waits to gain one priority level. With it a low
+// priority job is taken ahead of newly queued critical jobs after waiting three minutes.
+const DefaultAging = time.Minute
+
+// EmailJob represents an email job in the queue
+type EmailJob struct {
+	ID         string
+	Priority   Priority
+	Request    *domain.SendEmailRequest
+	EnqueuedAt time.Time // Set by Push when zero
+	Index      int       // Index in the heap
+
+	ctx  context.Context // Context of the send waiting on the job; see PrioritySender
+	done chan error      // Receives the job's result
+}
+
+// emailJobHeap implements heap.Interface
+// Jobs are ordered by when they are due: their enqueue time plus one aging interval per
+// priority level below critical. A waiting job therefore gains a priority level every aging
+// interval, so higher priorities go first without starving lower ones. Without aging, jobs
+// are ordered by priority, then by enqueue time.
+type emailJobHeap struct {
+	jobs  []*EmailJob
+	aging time.Duration
+}
+
+// due returns when job should be taken relative to other jobs
+func (h *emailJobHeap) due(job *EmailJob) time.Time {
+	return job.EnqueuedAt.Add(time.Duration(job.Priority) * h.aging)
+}
+
+// before reports whether a should be taken ahead of b
+func (h *emailJobHeap) before(a, b *EmailJob) bool {
+	if h.aging <= 0 && a.Priority != b.Priority {
+		// Lower priority value = higher priority (processed first)
+		return a.Priority < b.Priority
+	}
+	if dueA, dueB := h.due(a), h.due(b); !dueA.Equal(dueB) {
+		return dueA.Before(dueB)
+	}
+	return a.Priority < b.Priority
+}
+
+func (h *emailJobHeap) Len() int { return len(h.jobs) }
+
+func (h *emailJobHeap) Less(i, j int) bool {
+	return h.before(h.jobs[i], h.jobs[j])
+}
+
+func (h *emailJobHeap) Swap(i, j int) {
+	h.jobs[i], h.jobs[j] = h.jobs[j], h.jobs[i]
+	h.jobs[i].Index = i
+	h.jobs[j].Index = j
+}
+
+func (h *emailJobHeap) Push(x interface{}) {
+	n := len(h.jobs)
+	job := x.(*EmailJob)
+	job.Index = n
+	h.jobs = append(h.jobs, job)
+}
+
+func (h *emailJobHeap) Pop() interface{} {
+	old := h.jobs
+	n := len(old)
+	job := old[n-1]
+	old[n-1] = nil // Avoid memory leak
+	job.Index = -1
+	h.jobs = old[0 : n-1]
+	return job
+}
+
+// PriorityQueue is a thread-safe priority queue for email jobs
+// Waiting jobs age by DefaultAging unless SetAging changes it. The queue is unbounded unless
+// SetMaxDepth limits it.
+type PriorityQueue struct {
+	jobs     emailJobHeap
+	maxDepth int
+	mu       sync.Mutex
+	cond     *sync.Cond
+	space    chan struct{} // Closed when a job leaves the queue, waking PushWait callers
+}
+
+// NewPriorityQueue creates a new priority queue
+func NewPriorityQueue() *PriorityQueue {
+	pq := &PriorityQueue{
+		jobs:  emailJobHeap{aging: DefaultAging},
+		space: make(chan struct{}),
+	}
+	pq.cond = sync.NewCond(&pq.mu)
+	heap.Init(&pq.jobs)
+	return pq
+}
+
+// SetAging sets how long a queued job waits to gain one priority level. Zero disables aging,
+// so a steady stream of higher priority jobs can hold back lower ones indefinitely.
+func (pq *PriorityQueue) SetAging(aging time.Duration) {
+	pq.mu.Lock()
+	defer pq.mu.Unlock()
+
+	pq.jobs.aging = aging
+	heap.Init(&pq.jobs) // Queued jobs are reordered under the new aging
+}
+
+// SetMaxDepth limits the queue to maxDepth jobs; TryPush and PushWait reject jobs beyond it.
+// Zero removes the limit.
+func (pq *PriorityQueue) SetMaxDepth(maxDepth int) {
+	pq.mu.Lock()
+	defer pq.mu.Unlock()
+	pq.maxDepth = maxDepth
+}
+
+// Push adds a job to the queue
+// It ignores the maximum depth, so it suits jobs that were already accepted, such as retries.
+// New work should use TryPush or PushWait.
+func (pq *PriorityQueue) Push(job *EmailJob) {
+	pq.mu.Lock()
+	defer pq.mu.Unlock()
+	pq.push(job)
+}
+
+// TryPush adds a job to the queue, or returns ErrQueueFull at once if the queue is full
+func (pq *PriorityQueue) TryPush(job *EmailJob) error {
+	pq.mu.Lock()
+	defer pq.mu.Unlock()
+
+	if pq.full() {
+		metrics.EmailQueueRejected.WithLabelValues(RejectFull).Inc()
+		return ErrQueueFull
+	}
+	pq.push(job)
+	return nil
+}
+
+// PushWait adds a job to the queue, waiting for room while it is full. It returns an error
+// wrapping ErrQueueFull and ctx's error if ctx is done first, so callers bound the wait with
+// a context deadline.
+func (pq *PriorityQueue) PushWait(ctx context.Context, job *EmailJob) error {
+	for {
+		pq.mu.Lock()
+		if !pq.full() {
+			pq.push(job)
+			pq.mu.Unlock()
+			return nil
+		}
+		space := pq.space
+		pq.mu.Unlock()
+
+		select {
+		case <-space:
+		case <-ctx.Done():
+			metrics.EmailQueueRejected.WithLabelValues(RejectTimeout).Inc()
+			return fmt.Errorf("%w: %w", ErrQueueFull, ctx.Err())
+		}
+	}
+}
+
+// push adds a job and wakes the workers. pq.mu must be held.
+func (pq *PriorityQueue) push(job *EmailJob) {
+	if job.EnqueuedAt.IsZero() {
+		job.EnqueuedAt = time.Now()
+	}
+	heap.Push(&pq.jobs, job)
+	metrics.EmailQueueSize.Set(float64(pq.jobs.Len()))
+	// Wake every waiting worker; a fast lane worker may not be able to take this job
+	pq.cond.Broadcast()
+}
+
+// full reports whether the queue is at its maximum depth. pq.mu must be held.
+func (pq *PriorityQueue) full() bool {
+	return pq.maxDepth > 0 && pq.jobs.Len() >= pq.maxDepth
+}
+
+// Pop removes and returns the highest priority job
+// Blocks if the queue is empty
+func (pq *PriorityQueue) Pop() *EmailJob {
+	return pq.PopAtLeast(PriorityLow)
+}
+
+// PopAtLeast removes and returns the highest priority job of priority lowest or higher,
+// blocking until there is one. It lets a dedicated fast lane worker serve critical jobs
+// while other workers are busy with long runs of normal ones. Aging doesn't promote a job
+// into the fast lane; only its own priority counts.
+func (pq *PriorityQueue) PopAtLeast(lowest Priority) *EmailJob {
+	pq.mu.Lock()
+	defer pq.mu.Unlock()
+
+	for {
+		if job := pq.take(lowest); job != nil {
+			return job
+		}
+		pq.cond.Wait()
+	}
+}
+
+// TryPop tries to pop a job without blocking
+// Returns nil if queue is empty
+func (pq *PriorityQueue) TryPop() *EmailJob {
+	return pq.TryPopAtLeast(PriorityLow)
+}
+
+// TryPopAtLeast is PopAtLeast without blocking
+// Returns nil if no queued job has priority lowest or higher.
+func (pq *PriorityQueue) TryPopAtLeast(lowest Priority) *EmailJob {
+	pq.mu.Lock()
+	defer pq.mu.Unlock()
+
+	return pq.take(lowest)
+}
+
+// take removes the first job of priority lowest or higher, or returns nil. pq.mu must be held.
+func (pq *PriorityQueue) take(lowest Priority) *EmailJob {
+	if pq.jobs.Len() == 0 {
+		return nil
+	}
+	var job *EmailJob
+	if lowest >= PriorityLow || pq.jobs.jobs[0].Priority <= lowest {
+		job = heap.Pop(&pq.jobs).(*EmailJob)
+	} else {
+		// The first job is below lowest, so find the first eligible one
+		var next *EmailJob
+		for _, queued := range pq.jobs.jobs {
+			if queued.Priority <= lowest && (next == nil || pq.jobs.before(queued, next)) {
+				next = queued
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		job = heap.Remove(&pq.jobs, next.Index).(*EmailJob)
+	}
+
+	metrics.EmailQueueSize.Set(float64(pq.jobs.Len()))
+	// Wake PushWait callers waiting for room
+	close(pq.space)
+	pq.space = make(chan struct{})
+	return job
+}
+
+// Len returns the number of jobs in the queue
+func (pq *PriorityQueue) Len() int {
+	pq.mu.Lock()
+	defer pq.mu.Unlock()
+	return pq.jobs.Len()
+}
+
+// IsEmpty returns true if the queue is empty
+func (pq *PriorityQueue) IsEmpty() bool {
+	return pq.Len() == 0
+}
+
+// IsFull returns true if the queue is at its maximum depth, so new jobs are rejected or wait
+func (pq *PriorityQueue) IsFull() bool {
+	pq.mu.Lock()
+	defer pq.mu.Unlock()
+	return pq.full()
+}
@@ -0,0 +1,212 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+)
+
+// popIDs pops every queued job and returns their IDs in order
+func popIDs(pq *PriorityQueue) []string {
+	var ids []string
+	for job := pq.TryPop(); job != nil; job = pq.TryPop() {
+		ids = append(ids, job.ID)
+	}
+	return ids
+}
+
+func assertOrder(t *testing.T, got []string, want ...string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("popped %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("popped %v, want %v", got, want)
+		}
+	}
+}
+
+func TestPriorityFor(t *testing.T) {
+	tests := []struct {
+		priority domain.NotificationPriority
+		want     Priority
+	}{
+		{domain.NotificationPriorityCritical, PriorityCritical},
+		{domain.NotificationPriorityHigh, PriorityHigh},
+		{domain.NotificationPriorityNormal, PriorityNormal},
+		{domain.NotificationPriorityLow, PriorityLow},
+		{"", PriorityNormal},
+	}
+	for _, tt := range tests {
+		if got := PriorityFor(tt.priority); got != tt.want {
+			t.Errorf("PriorityFor(%q) = %d, want %d", tt.priority, got, tt.want)
+		}
+	}
+}
+
+func TestPriorityQueueOrdersByPriorityThenAge(t *testing.T) {
+	pq := NewPriorityQueue()
+	now := time.Now()
+	pq.Push(&EmailJob{ID: "normal-1", Priority: PriorityNormal, EnqueuedAt: now})
+	pq.Push(&EmailJob{ID: "low", Priority: PriorityLow, EnqueuedAt: now})
+	pq.Push(&EmailJob{ID: "critical", Priority: PriorityCritical, EnqueuedAt: now.Add(time.Second)})
+	pq.Push(&EmailJob{ID: "normal-2", Priority: PriorityNormal, EnqueuedAt: now.Add(time.Second)})
+	pq.Push(&EmailJob{ID: "high", Priority: PriorityHigh, EnqueuedAt: now.Add(2 * time.Second)})
+
+	assertOrder(t, popIDs(pq), "critical", "high", "normal-1", "normal-2", "low")
+}
+
+func TestPriorityQueueAging(t *testing.T) {
+	pq := NewPriorityQueue()
+	now := time.Now()
+	// The low job has waited more than three aging intervals, so it is due before new critical jobs
+	pq.Push(&EmailJob{ID: "low", Priority: PriorityLow, EnqueuedAt: now.Add(-4 * DefaultAging)})
+	pq.Push(&EmailJob{ID: "critical", Priority: PriorityCritical, EnqueuedAt: now})
+	pq.Push(&EmailJob{ID: "normal", Priority: PriorityNormal, EnqueuedAt: now.Add(-DefaultAging / 2)})
+
+	assertOrder(t, popIDs(pq), "low", "critical", "normal")
+}
+
+func TestPriorityQueueLowJobSurvivesSteadyHighLoad(t *testing.T) {
+	pq := NewPriorityQueue()
+	start := time.Now()
+	pq.Push(&EmailJob{ID: "low", Priority: PriorityLow, EnqueuedAt: start})
+
+	// A worker takes one job every 10 seconds while a new high priority job arrives each time
+	const interval = 10 * time.Second
+	for tick := 1; tick <= 100; tick++ {
+		now := start.Add(time.Duration(tick) * interval)
+		pq.Push(&EmailJob{ID: "high", Priority: PriorityHigh, EnqueuedAt: now})
+		if job := pq.TryPop(); job.ID == "low" {
+			// Low is two levels below high, so it goes first once it has waited two aging intervals
+			if waited := now.Sub(start); waited < 2*DefaultAging {
+				t.Errorf("low job dispatched after %v, before aging promoted it", waited)
+			}
+			return
+		}
+	}
+	t.Fatal("low job was never dispatched under a steady stream of high priority jobs")
+}
+
+func TestPriorityQueueWithoutAging(t *testing.T) {
+	pq := NewPriorityQueue()
+	now := time.Now()
+	pq.Push(&EmailJob{ID: "low", Priority: PriorityLow, EnqueuedAt: now.Add(-time.Hour)})
+	pq.Push(&EmailJob{ID: "critical", Priority: PriorityCritical, EnqueuedAt: now})
+	pq.SetAging(0)
+
+	assertOrder(t, popIDs(pq), "critical", "low")
+}
+
+func TestPriorityQueuePushSetsEnqueuedAt(t *testing.T) {
+	pq := NewPriorityQueue()
+	job := &EmailJob{ID: "job", Priority: PriorityNormal}
+	pq.Push(job)
+	if job.EnqueuedAt.IsZero() {
+		t.Error("Push() left EnqueuedAt unset")
+	}
+}
+
+func TestPriorityQueueFastLane(t *testing.T) {
+	pq := NewPriorityQueue()
+	now := time.Now()
+	pq.Push(&EmailJob{ID: "low", Priority: PriorityLow, EnqueuedAt: now.Add(-time.Hour)})
+	pq.Push(&EmailJob{ID: "normal", Priority: PriorityNormal, EnqueuedAt: now})
+	pq.Push(&EmailJob{ID: "high", Priority: PriorityHigh, EnqueuedAt: now.Add(time.Second)})
+
+	// The aged low job is first overall, but the fast lane only takes high or critical jobs
+	if job := pq.TryPopAtLeast(PriorityHigh); job == nil || job.ID != "high" {
+		t.Fatalf("TryPopAtLeast(PriorityHigh) = %v, want high", job)
+	}
+	if job := pq.TryPopAtLeast(PriorityHigh); job != nil {
+		t.Fatalf("TryPopAtLeast(PriorityHigh) = %v, want nil", job.ID)
+	}
+	assertOrder(t, popIDs(pq), "low", "normal")
+}
+
+func TestPriorityQueuePopAtLeastWaits(t *testing.T) {
+	pq := NewPriorityQueue()
+	popped := make(chan *EmailJob)
+	go func() {
+		popped <- pq.PopAtLeast(PriorityCritical)
+	}()
+
+	pq.Push(&EmailJob{ID: "normal", Priority: PriorityNormal})
+	pq.Push(&EmailJob{ID: "critical", Priority: PriorityCritical})
+
+	select {
+	case job := <-popped:
+		if job.ID != "critical" {
+			t.Errorf("PopAtLeast(PriorityCritical) = %s, want critical", job.ID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("PopAtLeast(PriorityCritical) did not return")
+	}
+	if pq.Len() != 1 {
+		t.Errorf("Len() = %d, want the normal job still queued", pq.Len())
+	}
+}
+
+func TestPriorityQueueMaxDepth(t *testing.T) {
+	pq := NewPriorityQueue()
+	pq.SetMaxDepth(2)
+
+	for _, id := range []string{"first", "second"} {
+		if err := pq.TryPush(&EmailJob{ID: id, Priority: PriorityNormal}); err != nil {
+			t.Fatalf("TryPush(%s) error = %v", id, err)
+		}
+	}
+	if !pq.IsFull() {
+		t.Error("IsFull() = false at the maximum depth")
+	}
+	if err := pq.TryPush(&EmailJob{ID: "third", Priority: PriorityCritical}); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("TryPush() error = %v, want ErrQueueFull", err)
+	}
+	if pq.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", pq.Len())
+	}
+
+	pq.TryPop()
+	if err := pq.TryPush(&EmailJob{ID: "third", Priority: PriorityNormal}); err != nil {
+		t.Errorf("TryPush() after a pop error = %v", err)
+	}
+}
+
+func TestPriorityQueuePushWait(t *testing.T) {
+	pq := NewPriorityQueue()
+	pq.SetMaxDepth(1)
+	pq.Push(&EmailJob{ID: "queued", Priority: PriorityNormal})
+
+	// The wait times out while nothing leaves the queue
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := pq.PushWait(ctx, &EmailJob{ID: "late", Priority: PriorityNormal})
+	if !errors.Is(err, ErrQueueFull) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("PushWait() error = %v, want ErrQueueFull and the deadline", err)
+	}
+
+	// A pop makes room for a waiting push
+	pushed := make(chan error)
+	go func() {
+		pushed <- pq.PushWait(context.Background(), &EmailJob{ID: "waiting", Priority: PriorityNormal})
+	}()
+	time.Sleep(10 * time.Millisecond)
+	if job := pq.Pop(); job.ID != "queued" {
+		t.Fatalf("Pop() = %s, want queued", job.ID)
+	}
+	select {
+	case err := <-pushed:
+		if err != nil {
+			t.Errorf("PushWait() error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("PushWait() did not return after a pop made room")
+	}
+	if job := pq.TryPop(); job == nil || job.ID != "waiting" {
+		t.Errorf("TryPop() = %v, want the waiting job", job)
+	}
+}
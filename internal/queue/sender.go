@@ -0,0 +1,115 @@
+package queue
+
+import (
+	"context"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/retry"
+	"github.com/vhvplatform/go-notification-service/internal/sender"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// PrioritySender sends emails of at least a given priority through a priority queue, so they
+// are taken in priority order ahead of other queued email rather than sent as they arrive.
+// A send waits for its job to be sent and returns the result. Other emails, SMS and webhooks
+// go straight to next.
+type PrioritySender struct {
+	queue  *PriorityQueue
+	lowest Priority
+	wait   time.Duration // How long a send waits for room in a full queue
+	next   sender.Sender
+	log    *logger.Logger
+}
+
+// NewPrioritySender creates a sender that queues emails of priority lowest or higher on queue
+func NewPrioritySender(queue *PriorityQueue, lowest Priority, next sender.Sender, log *logger.Logger) *PrioritySender {
+	return &PrioritySender{
+		queue:  queue,
+		lowest: lowest,
+		next:   next,
+		log:    log,
+	}
+}
+
+// SetQueueWait sets how long a send waits for room in a full queue before it fails with
+// ErrQueueFull. Zero, the default, fails at once.
+func (s *PrioritySender) SetQueueWait(wait time.Duration) {
+	s.wait = wait
+}
+
+// Start starts workers that send queued jobs of the sender's priority or higher through next.
+// Workers take each job as soon as one is free, so they also serve as a fast lane for
+// urgent jobs that other producers queue.
+func (s *PrioritySender) Start(workers int) {
+	for i := 0; i < workers; i++ {
+		go s.work()
+	}
+}
+
+// work sends queued jobs until the process exits
+func (s *PrioritySender) work() {
+	for {
+		job := s.queue.PopAtLeast(s.lowest)
+		ctx := job.ctx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		// A send whose caller has given up is skipped, so it isn't delivered after being reported failed
+		err := ctx.Err()
+		if err == nil {
+			err = s.next.SendEmail(ctx, job.Request)
+		}
+		if job.done != nil {
+			job.done <- err
+		} else if err != nil {
+			s.log.Error("Failed to send queued email", "error", err, "job_id", job.ID)
+		}
+	}
+}
+
+// SendEmail queues an email of the sender's priority or higher and waits for it to be sent
+func (s *PrioritySender) SendEmail(ctx context.Context, req *domain.SendEmailRequest) error {
+	priority := PriorityFor(req.Priority)
+	if priority > s.lowest {
+		return s.next.SendEmail(ctx, req)
+	}
+
+	job := &EmailJob{
+		Priority: priority,
+		Request:  req,
+		ctx:      ctx,
+		done:     make(chan error, 1),
+	}
+	if err := s.push(ctx, job); err != nil {
+		// A full queue is reported to the caller to back off rather than retried here
+		return retry.Permanent(err)
+	}
+	select {
+	case err := <-job.done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// push queues job, waiting up to the sender's queue wait for room
+func (s *PrioritySender) push(ctx context.Context, job *EmailJob) error {
+	if s.wait <= 0 {
+		return s.queue.TryPush(job)
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.wait)
+	defer cancel()
+	return s.queue.PushWait(ctx, job)
+}
+
+// SendSMS sends an SMS directly
+func (s *PrioritySender) SendSMS(ctx context.Context, req *domain.SendSMSRequest) error {
+	return s.next.SendSMS(ctx, req)
+}
+
+// SendWebhook sends a webhook directly
+func (s *PrioritySender) SendWebhook(ctx context.Context, req *domain.SendWebhookRequest) error {
+	return s.next.SendWebhook(ctx, req)
+}
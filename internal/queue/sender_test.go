@@ -0,0 +1,172 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/retry"
+	"github.com/vhvplatform/go-notification-service/internal/sender"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// recordingSender records the subjects of the emails it sends
+type recordingSender struct {
+	sender.Sender
+	mu       sync.Mutex
+	subjects []string
+	err      error
+}
+
+func (s *recordingSender) SendEmail(ctx context.Context, req *domain.SendEmailRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subjects = append(s.subjects, req.Subject)
+	return s.err
+}
+
+func (s *recordingSender) sent() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.subjects...)
+}
+
+// sendAsync sends an email on its own goroutine and returns a channel for the result
+func sendAsync(ctx context.Context, s *PrioritySender, subject string, priority domain.NotificationPriority) <-chan error {
+	result := make(chan error, 1)
+	go func() {
+		result <- s.SendEmail(ctx, &domain.SendEmailRequest{Subject: subject, Priority: priority})
+	}()
+	return result
+}
+
+// waitForDepth waits until pq holds depth jobs
+func waitForDepth(t *testing.T, pq *PriorityQueue, depth int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for pq.Len() != depth {
+		if time.Now().After(deadline) {
+			t.Fatalf("queue depth = %d, want %d", pq.Len(), depth)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestPrioritySenderSendsLowerPrioritiesDirectly(t *testing.T) {
+	next := &recordingSender{}
+	pq := NewPriorityQueue()
+	s := NewPrioritySender(pq, PriorityHigh, next, logger.NewLogger())
+
+	// No workers run, so a queued email would never be sent
+	if err := s.SendEmail(context.Background(), &domain.SendEmailRequest{Subject: "newsletter", Priority: domain.NotificationPriorityNormal}); err != nil {
+		t.Fatalf("SendEmail() error = %v", err)
+	}
+	assertOrder(t, next.sent(), "newsletter")
+	if pq.Len() != 0 {
+		t.Errorf("queue depth = %d, want 0", pq.Len())
+	}
+}
+
+func TestPrioritySenderQueuesUrgentEmailsInPriorityOrder(t *testing.T) {
+	next := &recordingSender{}
+	pq := NewPriorityQueue()
+	s := NewPrioritySender(pq, PriorityHigh, next, logger.NewLogger())
+
+	high := sendAsync(context.Background(), s, "high", domain.NotificationPriorityHigh)
+	waitForDepth(t, pq, 1)
+	critical := sendAsync(context.Background(), s, "critical", domain.NotificationPriorityCritical)
+	waitForDepth(t, pq, 2)
+
+	s.Start(1)
+	for _, result := range []<-chan error{high, critical} {
+		if err := <-result; err != nil {
+			t.Fatalf("SendEmail() error = %v", err)
+		}
+	}
+	assertOrder(t, next.sent(), "critical", "high")
+}
+
+func TestPrioritySenderReturnsTheSendResult(t *testing.T) {
+	sendErr := errors.New("smtp unavailable")
+	next := &recordingSender{err: sendErr}
+	s := NewPrioritySender(NewPriorityQueue(), PriorityHigh, next, logger.NewLogger())
+	s.Start(1)
+
+	err := s.SendEmail(context.Background(), &domain.SendEmailRequest{Subject: "alert", Priority: domain.NotificationPriorityCritical})
+	if !errors.Is(err, sendErr) {
+		t.Errorf("SendEmail() error = %v, want %v", err, sendErr)
+	}
+}
+
+func TestPrioritySenderSkipsAbandonedSends(t *testing.T) {
+	next := &recordingSender{}
+	pq := NewPriorityQueue()
+	s := NewPrioritySender(pq, PriorityHigh, next, logger.NewLogger())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := sendAsync(ctx, s, "abandoned", domain.NotificationPriorityHigh)
+	waitForDepth(t, pq, 1)
+	cancel()
+	if err := <-result; !errors.Is(err, context.Canceled) {
+		t.Fatalf("SendEmail() error = %v, want %v", err, context.Canceled)
+	}
+
+	// The abandoned job is skipped; the next one is still sent
+	s.Start(1)
+	if err := s.SendEmail(context.Background(), &domain.SendEmailRequest{Subject: "alert", Priority: domain.NotificationPriorityHigh}); err != nil {
+		t.Fatalf("SendEmail() error = %v", err)
+	}
+	assertOrder(t, next.sent(), "alert")
+}
+
+func TestPrioritySenderRejectsSendsToAFullQueue(t *testing.T) {
+	pq := NewPriorityQueue()
+	pq.SetMaxDepth(1)
+	s := NewPrioritySender(pq, PriorityHigh, &recordingSender{}, logger.NewLogger())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sendAsync(ctx, s, "queued", domain.NotificationPriorityHigh)
+	waitForDepth(t, pq, 1)
+
+	err := s.SendEmail(context.Background(), &domain.SendEmailRequest{Subject: "rejected", Priority: domain.NotificationPriorityHigh})
+	if !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("SendEmail() error = %v, want %v", err, ErrQueueFull)
+	}
+	if retry.Retryable(err) {
+		t.Errorf("Retryable(%v) = true, want false", err)
+	}
+
+	s.SetQueueWait(20 * time.Millisecond)
+	start := time.Now()
+	err = s.SendEmail(context.Background(), &domain.SendEmailRequest{Subject: "rejected", Priority: domain.NotificationPriorityHigh})
+	if !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("SendEmail() error = %v, want %v", err, ErrQueueFull)
+	}
+	if waited := time.Since(start); waited < 20*time.Millisecond {
+		t.Errorf("SendEmail() returned after %v, want it to wait for room", waited)
+	}
+}
+
+func TestPrioritySenderWaitsForRoom(t *testing.T) {
+	next := &recordingSender{}
+	pq := NewPriorityQueue()
+	pq.SetMaxDepth(1)
+	s := NewPrioritySender(pq, PriorityHigh, next, logger.NewLogger())
+	s.SetQueueWait(time.Second)
+
+	first := sendAsync(context.Background(), s, "first", domain.NotificationPriorityHigh)
+	waitForDepth(t, pq, 1)
+	second := sendAsync(context.Background(), s, "second", domain.NotificationPriorityHigh)
+
+	s.Start(1)
+	for _, result := range []<-chan error{first, second} {
+		if err := <-result; err != nil {
+			t.Fatalf("SendEmail() error = %v", err)
+		}
+	}
+	assertOrder(t, next.sent(), "first", "second")
+}
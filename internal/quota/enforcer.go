@@ -0,0 +1,231 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/metrics"
+)
+
+// ErrQuotaExceeded is returned when a send would exceed a hard quota
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// channels are the notification types that quotas apply to
+var channels = []domain.NotificationType{
+	domain.NotificationTypeEmail,
+	domain.NotificationTypeSMS,
+	domain.NotificationTypeWebhook,
+}
+
+// Store interface for quota and usage counter storage
+type Store interface {
+	FindQuota(ctx context.Context, tenantID string, channel domain.NotificationType) (*domain.TenantQuota, error)
+	IncrementUsage(ctx context.Context, tenantID string, channel domain.NotificationType, period domain.QuotaPeriod, periodKey string, delta int64, expiresAt time.Time) (int64, error)
+	GetUsage(ctx context.Context, tenantID string, channel domain.NotificationType, period domain.QuotaPeriod, periodKey string) (int64, error)
+}
+
+// Limits holds daily and monthly send caps; 0 means unlimited
+type Limits struct {
+	Daily   int64
+	Monthly int64
+}
+
+// ExceededError describes which quota a send would exceed
+type ExceededError struct {
+	Channel  domain.NotificationType
+	Period   domain.QuotaPeriod
+	Limit    int64
+	Used     int64
+	ResetsAt time.Time
+}
+
+// Error implements the error interface
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("%s %s quota of %d exceeded", e.Period, e.Channel, e.Limit)
+}
+
+// Unwrap allows errors.Is(err, ErrQuotaExceeded)
+func (e *ExceededError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
+// AsExceeded returns the *ExceededError in err's chain, if any
+func AsExceeded(err error) (*ExceededError, bool) {
+	var exceeded *ExceededError
+	ok := errors.As(err, &exceeded)
+	return exceeded, ok
+}
+
+// Reservation records sends counted against a tenant's quota
+type Reservation struct {
+	tenantID string
+	channel  domain.NotificationType
+	count    int64
+	day      period
+	month    period
+
+	// Warning is set when a soft quota was exceeded
+	Warning *ExceededError
+}
+
+// period is a single quota window
+type period struct {
+	kind     domain.QuotaPeriod
+	key      string
+	resetsAt time.Time
+}
+
+// Enforcer checks and counts sends against per-tenant daily and monthly quotas
+// Tenant overrides from the store take precedence over the defaults.
+type Enforcer struct {
+	store    Store
+	defaults map[domain.NotificationType]Limits
+	policy   domain.QuotaPolicy
+	now      func() time.Time
+}
+
+// NewEnforcer creates a new quota enforcer
+func NewEnforcer(store Store, defaults map[domain.NotificationType]Limits, policy domain.QuotaPolicy) *Enforcer {
+	return &Enforcer{
+		store:    store,
+		defaults: defaults,
+		policy:   policy,
+		now:      time.Now,
+	}
+}
+
+// Reserve counts n sends against the tenant's quota for a channel.
+// With a hard policy, a send over the quota is not counted and an *ExceededError is
+// returned. With a soft policy the send is counted and Reservation.Warning is set.
+func (e *Enforcer) Reserve(ctx context.Context, tenantID string, channel domain.NotificationType, n int64) (*Reservation, error) {
+	limits, policy, err := e.limits(ctx, tenantID, channel)
+	if err != nil {
+		return nil, err
+	}
+
+	day, month := periods(e.now())
+	res := &Reservation{tenantID: tenantID, channel: channel, count: n, day: day, month: month}
+
+	dayCount, err := e.increment(ctx, res, day, n)
+	if err != nil {
+		return nil, err
+	}
+	monthCount, err := e.increment(ctx, res, month, n)
+	if err != nil {
+		e.increment(ctx, res, day, -n)
+		return nil, err
+	}
+
+	exceeded := exceededPeriod(channel, day, limits.Daily, dayCount)
+	if exceeded == nil {
+		exceeded = exceededPeriod(channel, month, limits.Monthly, monthCount)
+	}
+	if exceeded == nil {
+		return res, nil
+	}
+
+	metrics.QuotaExceeded.WithLabelValues(metrics.TenantLabel(tenantID), string(channel), string(policy)).Inc()
+
+	if policy == domain.QuotaPolicySoft {
+		res.Warning = exceeded
+		return res, nil
+	}
+
+	// Roll back so rejected sends don't consume quota
+	if err := e.Release(ctx, res); err != nil {
+		return nil, err
+	}
+	exceeded.Used -= n
+	return nil, exceeded
+}
+
+// Release returns reserved sends to the quota, e.g. when the send failed
+func (e *Enforcer) Release(ctx context.Context, res *Reservation) error {
+	if res == nil {
+		return nil
+	}
+	if _, err := e.increment(ctx, res, res.day, -res.count); err != nil {
+		return err
+	}
+	_, err := e.increment(ctx, res, res.month, -res.count)
+	return err
+}
+
+// Usage reports the tenant's current usage against its quotas for every channel
+func (e *Enforcer) Usage(ctx context.Context, tenantID string) ([]domain.QuotaUsageReport, error) {
+	day, month := periods(e.now())
+
+	reports := make([]domain.QuotaUsageReport, 0, len(channels))
+	for _, channel := range channels {
+		limits, policy, err := e.limits(ctx, tenantID, channel)
+		if err != nil {
+			return nil, err
+		}
+
+		daily, err := e.store.GetUsage(ctx, tenantID, channel, day.kind, day.key)
+		if err != nil {
+			return nil, err
+		}
+		monthly, err := e.store.GetUsage(ctx, tenantID, channel, month.kind, month.key)
+		if err != nil {
+			return nil, err
+		}
+
+		reports = append(reports, domain.QuotaUsageReport{
+			Channel: channel,
+			Policy:  policy,
+			Daily:   domain.QuotaPeriodUsage{Used: daily, Limit: limits.Daily, ResetsAt: day.resetsAt},
+			Monthly: domain.QuotaPeriodUsage{Used: monthly, Limit: limits.Monthly, ResetsAt: month.resetsAt},
+		})
+	}
+	return reports, nil
+}
+
+// limits resolves the quota and policy for a tenant and channel
+func (e *Enforcer) limits(ctx context.Context, tenantID string, channel domain.NotificationType) (Limits, domain.QuotaPolicy, error) {
+	override, err := e.store.FindQuota(ctx, tenantID, channel)
+	if err != nil {
+		return Limits{}, "", err
+	}
+	if override == nil {
+		return e.defaults[channel], e.policy, nil
+	}
+
+	policy := override.Policy
+	if policy == "" {
+		policy = e.policy
+	}
+	return Limits{Daily: override.DailyLimit, Monthly: override.MonthlyLimit}, policy, nil
+}
+
+// increment adjusts a usage counter, keeping it until a day after its period ends
+func (e *Enforcer) increment(ctx context.Context, res *Reservation, p period, delta int64) (int64, error) {
+	return e.store.IncrementUsage(ctx, res.tenantID, res.channel, p.kind, p.key, delta, p.resetsAt.Add(24*time.Hour))
+}
+
+// periods returns the current UTC day and month windows
+func periods(now time.Time) (period, period) {
+	now = now.UTC()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	return period{kind: domain.QuotaPeriodDaily, key: dayStart.Format("2006-01-02"), resetsAt: dayStart.AddDate(0, 0, 1)},
+		period{kind: domain.QuotaPeriodMonthly, key: monthStart.Format("2006-01"), resetsAt: monthStart.AddDate(0, 1, 0)}
+}
+
+// exceededPeriod returns an error if count is over a non-zero limit
+func exceededPeriod(channel domain.NotificationType, p period, limit, count int64) *ExceededError {
+	if limit <= 0 || count <= limit {
+		return nil
+	}
+	return &ExceededError{
+		Channel:  channel,
+		Period:   p.kind,
+		Limit:    limit,
+		Used:     count,
+		ResetsAt: p.resetsAt,
+	}
+}
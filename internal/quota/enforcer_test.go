@@ -0,0 +1,131 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+)
+
+// memoryStore is an in-memory Store for tests
+type memoryStore struct {
+	quotas map[domain.NotificationType]*domain.TenantQuota
+	usage  map[string]int64
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		quotas: make(map[domain.NotificationType]*domain.TenantQuota),
+		usage:  make(map[string]int64),
+	}
+}
+
+func (s *memoryStore) FindQuota(ctx context.Context, tenantID string, channel domain.NotificationType) (*domain.TenantQuota, error) {
+	return s.quotas[channel], nil
+}
+
+func (s *memoryStore) IncrementUsage(ctx context.Context, tenantID string, channel domain.NotificationType, period domain.QuotaPeriod, periodKey string, delta int64, expiresAt time.Time) (int64, error) {
+	key := tenantID + "/" + string(channel) + "/" + periodKey
+	s.usage[key] += delta
+	return s.usage[key], nil
+}
+
+func (s *memoryStore) GetUsage(ctx context.Context, tenantID string, channel domain.NotificationType, period domain.QuotaPeriod, periodKey string) (int64, error) {
+	return s.usage[tenantID+"/"+string(channel)+"/"+periodKey], nil
+}
+
+func newTestEnforcer(store Store, policy domain.QuotaPolicy) *Enforcer {
+	e := NewEnforcer(store, map[domain.NotificationType]Limits{
+		domain.NotificationTypeEmail: {Daily: 3, Monthly: 10},
+	}, policy)
+	e.now = func() time.Time { return time.Date(2024, 3, 15, 10, 0, 0, 0, time.UTC) }
+	return e
+}
+
+func TestReserveHardPolicy(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	e := newTestEnforcer(store, domain.QuotaPolicyHard)
+
+	if _, err := e.Reserve(ctx, "tenant-1", domain.NotificationTypeEmail, 3); err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+
+	_, err := e.Reserve(ctx, "tenant-1", domain.NotificationTypeEmail, 1)
+	var exceeded *ExceededError
+	if !errors.As(err, &exceeded) || !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("Reserve() error = %v, want *ExceededError", err)
+	}
+	if exceeded.Period != domain.QuotaPeriodDaily || exceeded.Limit != 3 || exceeded.Used != 3 {
+		t.Errorf("ExceededError = %+v", exceeded)
+	}
+	if want := time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC); !exceeded.ResetsAt.Equal(want) {
+		t.Errorf("ResetsAt = %v, want %v", exceeded.ResetsAt, want)
+	}
+
+	// Rejected sends are not counted
+	if got := store.usage["tenant-1/email/2024-03-15"]; got != 3 {
+		t.Errorf("daily usage = %d, want 3", got)
+	}
+	if got := store.usage["tenant-1/email/2024-03"]; got != 3 {
+		t.Errorf("monthly usage = %d, want 3", got)
+	}
+
+	// Channels without a limit are unlimited
+	if _, err := e.Reserve(ctx, "tenant-1", domain.NotificationTypeSMS, 100); err != nil {
+		t.Errorf("Reserve(sms) error = %v", err)
+	}
+}
+
+func TestReserveSoftPolicyOverride(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	store.quotas[domain.NotificationTypeEmail] = &domain.TenantQuota{DailyLimit: 1, Policy: domain.QuotaPolicySoft}
+	e := newTestEnforcer(store, domain.QuotaPolicyHard)
+
+	res, err := e.Reserve(ctx, "tenant-1", domain.NotificationTypeEmail, 2)
+	if err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+	if res.Warning == nil || res.Warning.Limit != 1 {
+		t.Errorf("Reservation.Warning = %+v, want daily limit 1", res.Warning)
+	}
+	if got := store.usage["tenant-1/email/2024-03-15"]; got != 2 {
+		t.Errorf("daily usage = %d, want 2", got)
+	}
+}
+
+func TestReleaseAndUsage(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	e := newTestEnforcer(store, domain.QuotaPolicyHard)
+
+	res, err := e.Reserve(ctx, "tenant-1", domain.NotificationTypeEmail, 2)
+	if err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+	if err := e.Release(ctx, res); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if _, err := e.Reserve(ctx, "tenant-1", domain.NotificationTypeEmail, 1); err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+
+	reports, err := e.Usage(ctx, "tenant-1")
+	if err != nil {
+		t.Fatalf("Usage() error = %v", err)
+	}
+	if len(reports) != 3 {
+		t.Fatalf("Usage() returned %d reports, want 3", len(reports))
+	}
+
+	email := reports[0]
+	if email.Channel != domain.NotificationTypeEmail || email.Daily.Used != 1 || email.Daily.Limit != 3 || email.Monthly.Limit != 10 {
+		t.Errorf("email report = %+v", email)
+	}
+	if want := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC); !email.Monthly.ResetsAt.Equal(want) {
+		t.Errorf("Monthly.ResetsAt = %v, want %v", email.Monthly.ResetsAt, want)
+	}
+}
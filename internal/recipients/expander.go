@@ -0,0 +1,191 @@
+package recipients
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/shared/errors"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+	"github.com/vhvplatform/go-notification-service/internal/smtp"
+)
+
+// ListStore interface for recipient list storage
+type ListStore interface {
+	FindRecipientList(ctx context.Context, tenantID, id string) (*domain.RecipientList, error)
+}
+
+// BounceStore interface for looking up suppressed addresses
+type BounceStore interface {
+	FindHardBounced(ctx context.Context, emails []string, since time.Time) ([]string, error)
+}
+
+// PreferenceStore interface for looking up members' notification preferences
+type PreferenceStore interface {
+	FindByUserIDs(ctx context.Context, tenantID string, userIDs []string) ([]*domain.NotificationPreferences, error)
+}
+
+// Config holds recipient list expansion settings
+type Config struct {
+	MaxRecipients     int           // Upper bound on To, CC and BCC combined after expansion
+	SuppressionWindow time.Duration // Members that hard bounced within this window are skipped
+}
+
+// Expander replaces a send's recipient list reference with the list's deliverable members
+type Expander struct {
+	lists       ListStore
+	bounces     BounceStore     // Optional; nil skips suppression checks
+	preferences PreferenceStore // Optional; nil skips preference checks
+	config      Config
+	log         *logger.Logger
+	now         func() time.Time
+}
+
+// NewExpander creates a new recipient list expander
+func NewExpander(lists ListStore, bounces BounceStore, preferences PreferenceStore, config Config, log *logger.Logger) *Expander {
+	return &Expander{
+		lists:       lists,
+		bounces:     bounces,
+		preferences: preferences,
+		config:      config,
+		log:         log,
+		now:         time.Now,
+	}
+}
+
+// Expand returns req with the subscribed members of its recipient list added to To.
+// Members that recently hard bounced or whose user preferences opt out of email, or of
+// the request's category, are skipped, and recipients are de-duplicated. Requests without
+// a list are returned as is.
+func (e *Expander) Expand(ctx context.Context, req *domain.SendEmailRequest) (*domain.SendEmailRequest, error) {
+	if req.RecipientListID == "" {
+		return req, nil
+	}
+
+	list, err := e.lists.FindRecipientList(ctx, req.TenantID, req.RecipientListID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load recipient list: %w", err)
+	}
+	if list == nil {
+		return nil, errors.NewNotFoundError("Recipient list not found", nil)
+	}
+
+	members, err := e.deliverable(ctx, req.TenantID, req.Category, list.Members)
+	if err != nil {
+		return nil, err
+	}
+
+	expanded := *req
+	expanded.RecipientListID = ""
+	expanded.To, expanded.CC, expanded.BCC = smtp.DedupeRecipients(append(slices.Clone(req.To), members...), req.CC, req.BCC)
+	if req.SendAtLocal != nil {
+		expanded.SendAtLocal = withMemberUsers(req.SendAtLocal, list.Members)
+	}
+
+	if len(expanded.To) == 0 {
+		return nil, errors.NewValidationError("Recipient list has no deliverable members", nil)
+	}
+	if count := len(expanded.To) + len(expanded.CC) + len(expanded.BCC); e.config.MaxRecipients > 0 && count > e.config.MaxRecipients {
+		return nil, errors.NewValidationError(fmt.Sprintf("Recipient list expands to %d recipients, more than the limit of %d", count, e.config.MaxRecipients), nil)
+	}
+
+	e.log.Info("Expanded recipient list", "tenant_id", req.TenantID, "list_id", req.RecipientListID,
+		"members", len(list.Members), "deliverable", len(members))
+	return &expanded, nil
+}
+
+// withMemberUsers returns a copy of options that also maps list members' addresses to their
+// user IDs, so their timezones are known. User IDs given in the request take precedence.
+func withMemberUsers(options *domain.LocalSendOptions, members []domain.RecipientListMember) *domain.LocalSendOptions {
+	withMembers := *options
+	withMembers.UserIDs = make(map[string]string, len(options.UserIDs)+len(members))
+	for _, member := range members {
+		if member.UserID != "" {
+			withMembers.UserIDs[member.Address] = member.UserID
+		}
+	}
+	for address, userID := range options.UserIDs {
+		withMembers.UserIDs[address] = userID
+	}
+	return &withMembers
+}
+
+// deliverable returns the addresses of subscribed members that are neither suppressed nor opted out
+func (e *Expander) deliverable(ctx context.Context, tenantID, category string, members []domain.RecipientListMember) ([]string, error) {
+	subscribed := make([]domain.RecipientListMember, 0, len(members))
+	for _, member := range members {
+		if member.Subscribed {
+			subscribed = append(subscribed, member)
+		}
+	}
+
+	suppressed, err := e.suppressed(ctx, subscribed)
+	if err != nil {
+		return nil, err
+	}
+	optedOut, err := e.optedOut(ctx, tenantID, category, subscribed)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := make([]string, 0, len(subscribed))
+	for _, member := range subscribed {
+		if _, ok := suppressed[mailboxKey(member.Address)]; ok {
+			continue
+		}
+		if _, ok := optedOut[member.UserID]; ok {
+			continue
+		}
+		addresses = append(addresses, member.Address)
+	}
+	return addresses, nil
+}
+
+// suppressed returns the mailbox keys of members that hard bounced within the suppression window
+func (e *Expander) suppressed(ctx context.Context, members []domain.RecipientListMember) (map[string]struct{}, error) {
+	if e.bounces == nil || e.config.SuppressionWindow <= 0 || len(members) == 0 {
+		return nil, nil
+	}
+
+	emails := make([]string, len(members))
+	for i, member := range members {
+		emails[i] = mailboxKey(member.Address)
+	}
+	bounced, err := e.bounces.FindHardBounced(ctx, emails, e.now().Add(-e.config.SuppressionWindow))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check suppressed recipients: %w", err)
+	}
+	return addressKeys(bounced), nil
+}
+
+// optedOut returns the user IDs of members whose preferences turn off email or the category
+func (e *Expander) optedOut(ctx context.Context, tenantID, category string, members []domain.RecipientListMember) (map[string]struct{}, error) {
+	if e.preferences == nil {
+		return nil, nil
+	}
+
+	userIDs := make([]string, 0, len(members))
+	for _, member := range members {
+		if member.UserID != "" {
+			userIDs = append(userIDs, member.UserID)
+		}
+	}
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	prefs, err := e.preferences.FindByUserIDs(ctx, tenantID, userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check recipient preferences: %w", err)
+	}
+
+	optedOut := make(map[string]struct{})
+	for _, p := range prefs {
+		if enabled, ok := p.EmailCategories[category]; !p.EmailEnabled || (category != "" && ok && !enabled) {
+			optedOut[p.UserID] = struct{}{}
+		}
+	}
+	return optedOut, nil
+}
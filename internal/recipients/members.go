@@ -0,0 +1,99 @@
+package recipients
+
+import (
+	"fmt"
+	"net/mail"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/shared/errors"
+	"github.com/vhvplatform/go-notification-service/internal/smtp"
+)
+
+// MaxMembers bounds the members of one list, keeping the list document well under MongoDB's size limit
+const MaxMembers = 10000
+
+// mailboxKey returns the key two addresses share when they name the same mailbox
+// It matches how smtp.DedupeRecipients compares addresses.
+func mailboxKey(address string) string {
+	normalized := smtp.NormalizeAddress(address)
+	if parsed, err := mail.ParseAddress(normalized); err == nil {
+		return parsed.Address
+	}
+	return normalized
+}
+
+// AddMembers adds members that are not already on the list, subscribed
+// Existing members keep their subscription state. It returns how many were added.
+func AddMembers(list *domain.RecipientList, members []domain.RecipientListMemberInput, now time.Time) (int, error) {
+	present := make(map[string]struct{}, len(list.Members))
+	for _, member := range list.Members {
+		present[mailboxKey(member.Address)] = struct{}{}
+	}
+
+	added := 0
+	for _, member := range members {
+		key := mailboxKey(member.Address)
+		if _, ok := present[key]; ok {
+			continue
+		}
+		present[key] = struct{}{}
+		list.Members = append(list.Members, domain.RecipientListMember{
+			Address:    smtp.NormalizeAddress(member.Address),
+			UserID:     member.UserID,
+			Subscribed: true,
+			AddedAt:    now,
+		})
+		added++
+	}
+
+	if len(list.Members) > MaxMembers {
+		return 0, errors.NewValidationError(fmt.Sprintf("A recipient list can have at most %d members", MaxMembers), nil)
+	}
+	return added, nil
+}
+
+// SetSubscribed subscribes or unsubscribes the members with the given addresses
+// It returns how many members changed state.
+func SetSubscribed(list *domain.RecipientList, addresses []string, subscribed bool, now time.Time) int {
+	keys := addressKeys(addresses)
+	changed := 0
+	for i := range list.Members {
+		member := &list.Members[i]
+		if _, ok := keys[mailboxKey(member.Address)]; !ok || member.Subscribed == subscribed {
+			continue
+		}
+		member.Subscribed = subscribed
+		if subscribed {
+			member.UnsubscribedAt = nil
+		} else {
+			unsubscribedAt := now
+			member.UnsubscribedAt = &unsubscribedAt
+		}
+		changed++
+	}
+	return changed
+}
+
+// RemoveMembers removes the members with the given addresses and returns how many were removed
+func RemoveMembers(list *domain.RecipientList, addresses []string) int {
+	keys := addressKeys(addresses)
+	kept := list.Members[:0]
+	for _, member := range list.Members {
+		if _, ok := keys[mailboxKey(member.Address)]; !ok {
+			kept = append(kept, member)
+		}
+	}
+	removed := len(list.Members) - len(kept)
+	list.Members = kept
+	return removed
+}
+
+// addressKeys returns the set of mailbox keys for addresses
+func addressKeys(addresses []string) map[string]struct{} {
+	keys := make(map[string]struct{}, len(addresses))
+	for _, address := range addresses {
+		keys[mailboxKey(address)] = struct{}{}
+	}
+	return keys
+}
@@ -0,0 +1,139 @@
+package recipients
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	apperrors "github.com/vhvplatform/go-notification-service/internal/shared/errors"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// memoryStore is an in-memory ListStore, BounceStore and PreferenceStore for tests
+type memoryStore struct {
+	lists   map[string]*domain.RecipientList
+	bounced []string
+	prefs   []*domain.NotificationPreferences
+}
+
+func (s *memoryStore) FindRecipientList(ctx context.Context, tenantID, id string) (*domain.RecipientList, error) {
+	return s.lists[id], nil
+}
+
+func (s *memoryStore) FindHardBounced(ctx context.Context, emails []string, since time.Time) ([]string, error) {
+	return s.bounced, nil
+}
+
+func (s *memoryStore) FindByUserIDs(ctx context.Context, tenantID string, userIDs []string) ([]*domain.NotificationPreferences, error) {
+	return s.prefs, nil
+}
+
+func newTestList(addresses ...string) *domain.RecipientList {
+	list := &domain.RecipientList{TenantID: "tenant-1", Name: "team"}
+	members := make([]domain.RecipientListMember, len(addresses))
+	for i, address := range addresses {
+		members[i] = domain.RecipientListMember{Address: address, Subscribed: true}
+	}
+	list.Members = members
+	return list
+}
+
+func appErrorStatus(err error) int {
+	var appErr *apperrors.AppError
+	if !errors.As(err, &appErr) {
+		return 0
+	}
+	return appErr.HTTPStatus()
+}
+
+func TestAddMembersSkipsExisting(t *testing.T) {
+	list := newTestList("a@example.com")
+	list.Members[0].Subscribed = false
+
+	added, err := AddMembers(list, []domain.RecipientListMemberInput{
+		{Address: "a@EXAMPLE.com"},
+		{Address: "b@example.com"},
+		{Address: "b@Example.com"},
+	}, time.Now())
+	if err != nil {
+		t.Fatalf("AddMembers() error = %v", err)
+	}
+	if added != 1 || len(list.Members) != 2 {
+		t.Fatalf("AddMembers() added %d, list has %d members; want 1 and 2", added, len(list.Members))
+	}
+	if list.Members[0].Subscribed {
+		t.Error("AddMembers() resubscribed an existing member")
+	}
+}
+
+func TestSetSubscribedAndRemove(t *testing.T) {
+	list := newTestList("a@example.com", "b@example.com")
+
+	if changed := SetSubscribed(list, []string{"A@example.com", "c@example.com"}, false, time.Now()); changed != 0 {
+		t.Errorf("SetSubscribed() changed %d, want 0: local-parts are case-sensitive", changed)
+	}
+	if changed := SetSubscribed(list, []string{"a@EXAMPLE.com"}, false, time.Now()); changed != 1 {
+		t.Errorf("SetSubscribed() changed %d, want 1", changed)
+	}
+	if list.Members[0].Subscribed || list.Members[0].UnsubscribedAt == nil {
+		t.Errorf("member = %+v, want unsubscribed with a timestamp", list.Members[0])
+	}
+
+	if removed := RemoveMembers(list, []string{"b@example.com"}); removed != 1 || len(list.Members) != 1 {
+		t.Errorf("RemoveMembers() removed %d, %d left; want 1 and 1", removed, len(list.Members))
+	}
+}
+
+func TestExpandSkipsUndeliverableMembers(t *testing.T) {
+	list := newTestList("a@example.com", "bounced@example.com", "off@example.com", "unsub@example.com", "marketing-off@example.com")
+	list.Members[2].UserID = "user-off"
+	list.Members[3].Subscribed = false
+	list.Members[4].UserID = "user-marketing-off"
+	store := &memoryStore{
+		lists:   map[string]*domain.RecipientList{"list-1": list},
+		bounced: []string{"bounced@example.com"},
+		prefs: []*domain.NotificationPreferences{
+			{UserID: "user-off", EmailEnabled: false},
+			{UserID: "user-marketing-off", EmailEnabled: true, EmailCategories: map[string]bool{"marketing": false}},
+		},
+	}
+	e := NewExpander(store, store, store, Config{MaxRecipients: 10, SuppressionWindow: 24 * time.Hour}, logger.NewLogger())
+
+	req := &domain.SendEmailRequest{TenantID: "tenant-1", To: []string{"a@example.com"}, Category: "marketing", RecipientListID: "list-1"}
+	expanded, err := e.Expand(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Expand() error = %v", err)
+	}
+	if len(expanded.To) != 1 || expanded.To[0] != "a@example.com" || expanded.RecipientListID != "" {
+		t.Errorf("Expand() = %v (list %q), want only a@example.com", expanded.To, expanded.RecipientListID)
+	}
+	if req.RecipientListID != "list-1" {
+		t.Error("Expand() modified the original request")
+	}
+}
+
+func TestExpandErrors(t *testing.T) {
+	store := &memoryStore{lists: map[string]*domain.RecipientList{
+		"big":   newTestList("a@example.com", "b@example.com", "c@example.com"),
+		"empty": newTestList(),
+	}}
+	e := NewExpander(store, nil, nil, Config{MaxRecipients: 2}, logger.NewLogger())
+
+	tests := []struct {
+		listID string
+		want   int
+	}{
+		{"missing", http.StatusNotFound},
+		{"big", http.StatusBadRequest},
+		{"empty", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		_, err := e.Expand(context.Background(), &domain.SendEmailRequest{TenantID: "tenant-1", RecipientListID: tt.listID})
+		if got := appErrorStatus(err); got != tt.want {
+			t.Errorf("Expand(%s) error = %v, want status %d", tt.listID, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package recipients
+
+import (
+	"context"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/sender"
+)
+
+// ExpandingSender expands recipient lists on email sends before passing them to next
+type ExpandingSender struct {
+	expander *Expander
+	next     sender.Sender
+}
+
+// NewSender creates a sender that expands recipient lists at send time
+func NewSender(expander *Expander, next sender.Sender) *ExpandingSender {
+	return &ExpandingSender{
+		expander: expander,
+		next:     next,
+	}
+}
+
+// SendEmail expands the request's recipient list, if any, and sends the email
+func (s *ExpandingSender) SendEmail(ctx context.Context, req *domain.SendEmailRequest) error {
+	expanded, err := s.expander.Expand(ctx, req)
+	if err != nil {
+		return err
+	}
+	return s.next.SendEmail(ctx, expanded)
+}
+
+// SendSMS sends an SMS
+func (s *ExpandingSender) SendSMS(ctx context.Context, req *domain.SendSMSRequest) error {
+	return s.next.SendSMS(ctx, req)
+}
+
+// SendWebhook sends a webhook
+func (s *ExpandingSender) SendWebhook(ctx context.Context, req *domain.SendWebhookRequest) error {
+	return s.next.SendWebhook(ctx, req)
+}
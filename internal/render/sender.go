@@ -0,0 +1,70 @@
+// Package render renders templated emails on the send path, so every email sent with a
+// template_id gets the subject for its locale, the body and the preheader the template
+// defines, rendered with the request's variables.
+package render
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/retry"
+	"github.com/vhvplatform/go-notification-service/internal/sender"
+	"github.com/vhvplatform/go-notification-service/internal/templates"
+)
+
+// TemplateStore interface for loading the templates emails are rendered from
+type TemplateStore interface {
+	FindByID(ctx context.Context, id string, tenantID string) (*domain.Template, error)
+}
+
+// TemplateSender renders emails with a template before passing them to next. The rendered
+// email replaces the request's subject, body, preheader, HTML flag and charset, and is passed on
+// without its TemplateID so it is not rendered again. SMS and webhooks pass straight through.
+type TemplateSender struct {
+	templates TemplateStore
+	next      sender.Sender
+}
+
+// NewSender creates a sender that renders templated emails
+func NewSender(templates TemplateStore, next sender.Sender) *TemplateSender {
+	return &TemplateSender{
+		templates: templates,
+		next:      next,
+	}
+}
+
+// SendEmail renders the email's template, if it has one, and sends the result
+func (s *TemplateSender) SendEmail(ctx context.Context, req *domain.SendEmailRequest) error {
+	if req.TemplateID == "" {
+		return s.next.SendEmail(ctx, req)
+	}
+	tmpl, err := s.templates.FindByID(ctx, req.TemplateID, req.TenantID)
+	if err != nil {
+		return fmt.Errorf("template %s: %w", req.TemplateID, err)
+	}
+	subject, body, preheader, err := templates.RenderEmail(tmpl, req.Variables, req.Locale)
+	if err != nil {
+		// Rendering the same template with the same variables fails the same way every time
+		return retry.Permanent(fmt.Errorf("template %s: %w", req.TemplateID, err))
+	}
+
+	rendered := *req
+	rendered.TemplateID = ""
+	rendered.Subject = subject
+	rendered.Body = body
+	rendered.Preheader = preheader
+	rendered.IsHTML = tmpl.IsHTML
+	rendered.Charset = tmpl.Charset
+	return s.next.SendEmail(ctx, &rendered)
+}
+
+// SendSMS sends the SMS unchanged
+func (s *TemplateSender) SendSMS(ctx context.Context, req *domain.SendSMSRequest) error {
+	return s.next.SendSMS(ctx, req)
+}
+
+// SendWebhook sends the webhook unchanged
+func (s *TemplateSender) SendWebhook(ctx context.Context, req *domain.SendWebhookRequest) error {
+	return s.next.SendWebhook(ctx, req)
+}
@@ -0,0 +1,202 @@
+package render
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/email"
+	"github.com/vhvplatform/go-notification-service/internal/retry"
+)
+
+// templateStore serves one template to its tenant
+type templateStore struct {
+	template *domain.Template
+}
+
+func (s *templateStore) FindByID(ctx context.Context, id string, tenantID string) (*domain.Template, error) {
+	if id != s.template.ID.Hex() || tenantID != s.template.TenantID {
+		return nil, errors.New("template not found")
+	}
+	return s.template, nil
+}
+
+// emailRecorder records the emails it is asked to send
+type emailRecorder struct {
+	emails []*domain.SendEmailRequest
+}
+
+func (r *emailRecorder) SendEmail(ctx context.Context, req *domain.SendEmailRequest) error {
+	r.emails = append(r.emails, req)
+	return nil
+}
+
+func (r *emailRecorder) SendSMS(ctx context.Context, req *domain.SendSMSRequest) error {
+	return nil
+}
+
+func (r *emailRecorder) SendWebhook(ctx context.Context, req *domain.SendWebhookRequest) error {
+	return nil
+}
+
+// welcomeTemplate is an HTML email template with a German subject
+func welcomeTemplate() *domain.Template {
+	return &domain.Template{
+		ID:                [12]byte{1},
+		TenantID:          "tenant-1",
+		Name:              "welcome",
+		Subject:           "Welcome {{.name}}",
+		LocalizedSubjects: map[string]string{"de": "Willkommen {{.name}}"},
+		Preheader:         "Your account is ready",
+		Body:              "<p>Hi {{.name}}</p>",
+		IsHTML:            true,
+	}
+}
+
+// sendTemplated sends an email with tmpl in locale and returns the message a provider would
+// be given for the email next received
+func sendTemplated(t *testing.T, tmpl *domain.Template, locale string) *mail.Message {
+	t.Helper()
+	next := &emailRecorder{}
+	s := NewSender(&templateStore{template: tmpl}, next)
+
+	req := &domain.SendEmailRequest{
+		TenantID:   tmpl.TenantID,
+		To:         []string{"user@example.com"},
+		TemplateID: tmpl.ID.Hex(),
+		Locale:     locale,
+		Variables:  map[string]string{"name": "Ada"},
+	}
+	if err := s.SendEmail(context.Background(), req); err != nil {
+		t.Fatalf("SendEmail() error = %v", err)
+	}
+	if len(next.emails) != 1 {
+		t.Fatalf("sent %d emails, want 1", len(next.emails))
+	}
+	if next.emails[0].TemplateID != "" {
+		t.Errorf("TemplateID = %q after rendering, want it cleared", next.emails[0].TemplateID)
+	}
+
+	msg, err := email.NewMessage(mail.Address{Address: "noreply@example.com"}, next.emails[0], "notification-1")
+	if err != nil {
+		t.Fatalf("NewMessage() error = %v", err)
+	}
+	raw, err := msg.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes() error = %v", err)
+	}
+	parsed, err := mail.ReadMessage(strings.NewReader(string(raw)))
+	if err != nil {
+		t.Fatalf("ReadMessage() error = %v", err)
+	}
+	return parsed
+}
+
+func TestTemplateSenderRendersSubjectForLocale(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   string
+	}{
+		{"de-AT", "Willkommen Ada"}, // Falls back to the language
+		{"DE", "Willkommen Ada"},
+		{"fr", "Welcome Ada"}, // Falls back to the default subject
+		{"", "Welcome Ada"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			msg := sendTemplated(t, welcomeTemplate(), tt.locale)
+			subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+			if err != nil {
+				t.Fatalf("decoding subject: %v", err)
+			}
+			if subject != tt.want {
+				t.Errorf("Subject = %q, want %q", subject, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplateSenderRendersBodyAndPreheader(t *testing.T) {
+	msg := sendTemplated(t, welcomeTemplate(), "de")
+	raw, err := io.ReadAll(msg.Body)
+	if err != nil {
+		t.Fatalf("reading body: %v", err)
+	}
+	if contentType := msg.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "text/html") {
+		t.Errorf("Content-Type = %q, want text/html", contentType)
+	}
+	for _, want := range []string{"Hi Ada", "Your account is ready"} {
+		if !strings.Contains(string(raw), want) {
+			t.Errorf("message body does not contain %q:\n%s", want, raw)
+		}
+	}
+}
+
+func TestTemplateSenderFailures(t *testing.T) {
+	broken := welcomeTemplate()
+	broken.Body = "{{.name"
+	next := &emailRecorder{}
+	s := NewSender(&templateStore{template: broken}, next)
+
+	tests := []struct {
+		name string
+		req  *domain.SendEmailRequest
+	}{
+		{"unknown template", &domain.SendEmailRequest{TenantID: "tenant-1", TemplateID: "missing"}},
+		{"other tenant", &domain.SendEmailRequest{TenantID: "tenant-2", TemplateID: broken.ID.Hex()}},
+		{"template fails to render", &domain.SendEmailRequest{TenantID: "tenant-1", TemplateID: broken.ID.Hex()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.SendEmail(context.Background(), tt.req); err == nil {
+				t.Error("SendEmail() error = nil, want an error")
+			}
+		})
+	}
+	if len(next.emails) != 0 {
+		t.Errorf("sent %d emails, want none", len(next.emails))
+	}
+
+	err := s.SendEmail(context.Background(), tests[2].req)
+	if retry.Retryable(err) {
+		t.Errorf("render error %v is retryable, want permanent", err)
+	}
+}
+
+func TestTemplateSenderPassesUntemplatedEmailsThrough(t *testing.T) {
+	next := &emailRecorder{}
+	s := NewSender(&templateStore{template: welcomeTemplate()}, next)
+	req := &domain.SendEmailRequest{TenantID: "tenant-1", Subject: "Hello", Body: "Hi"}
+	if err := s.SendEmail(context.Background(), req); err != nil {
+		t.Fatalf("SendEmail() error = %v", err)
+	}
+	if len(next.emails) != 1 || next.emails[0] != req {
+		t.Errorf("sent %v, want the request unchanged", next.emails)
+	}
+}
+
+func TestTemplateSenderEncodesInTemplateCharset(t *testing.T) {
+	tmpl := welcomeTemplate()
+	tmpl.Body = "<p>Grüße {{.name}}</p>"
+	tmpl.Charset = "iso-8859-1"
+	msg := sendTemplated(t, tmpl, "de")
+
+	if contentType := msg.Header.Get("Content-Type"); !strings.Contains(contentType, "charset=iso-8859-1") {
+		t.Errorf("Content-Type = %q, want charset=iso-8859-1", contentType)
+	}
+	body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	if err != nil {
+		t.Fatalf("reading body: %v", err)
+	}
+	// ü and ß are single bytes in ISO-8859-1
+	if !strings.Contains(string(body), "Gr\xfc\xdfe Ada") {
+		t.Errorf("body is not ISO-8859-1 encoded:\n%q", body)
+	}
+}
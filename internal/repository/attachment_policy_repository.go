@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/vhvplatform/go-notification-service/internal/cache"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/shared/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const attachmentPoliciesCollection = "tenant_attachment_policies"
+
+// AttachmentPolicyRepository handles per-tenant attachment policy data operations
+type AttachmentPolicyRepository struct {
+	client *mongodb.MongoClient
+	cache  *cache.Cache // Optional; nil reads every lookup from the database
+}
+
+// NewAttachmentPolicyRepository creates a new attachment policy repository
+func NewAttachmentPolicyRepository(client *mongodb.MongoClient) *AttachmentPolicyRepository {
+	return &AttachmentPolicyRepository{client: client}
+}
+
+// SetCache caches policy lookups in c. Policies are managed outside this service, so
+// changes take effect once cached entries expire.
+func (r *AttachmentPolicyRepository) SetCache(c *cache.Cache) {
+	r.cache = c
+}
+
+// EnsureIndexes creates necessary indexes for optimal query performance
+func (r *AttachmentPolicyRepository) EnsureIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "tenantId", Value: 1}},
+			Options: options.Index().SetName("tenant_idx").SetUnique(true),
+		},
+	}
+	return r.client.CreateIndexes(ctx, attachmentPoliciesCollection, indexes)
+}
+
+// FindPolicy finds the attachment policy override for a tenant
+// Returns nil without error when the tenant has no override.
+func (r *AttachmentPolicyRepository) FindPolicy(ctx context.Context, tenantID string) (*domain.TenantAttachmentPolicy, error) {
+	key := "attachment_policy:" + tenantID
+	var cached domain.TenantAttachmentPolicy
+	if hit, found := cachedDocument(ctx, r.cache, cache.KindTenantConfig, key, &cached); hit {
+		if !found {
+			return nil, nil
+		}
+		return &cached, nil
+	}
+
+	var policy domain.TenantAttachmentPolicy
+	filter := bson.M{
+		"tenantId":  tenantID,
+		"deletedAt": nil,
+	}
+	err := r.client.Collection(attachmentPoliciesCollection).FindOne(ctx, filter).Decode(&policy)
+	if err == mongo.ErrNoDocuments {
+		cacheDocument(ctx, r.cache, cache.KindTenantConfig, key, nil)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cacheDocument(ctx, r.cache, cache.KindTenantConfig, key, &policy)
+	return &policy, nil
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/shared/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const auditLogsCollection = "audit_logs"
+
+// AuditLogRepository handles audit log data operations
+// The audit log is append-only: entries are never updated or deleted by the service.
+type AuditLogRepository struct {
+	client *mongodb.MongoClient
+}
+
+// NewAuditLogRepository creates a new audit log repository
+func NewAuditLogRepository(client *mongodb.MongoClient) *AuditLogRepository {
+	return &AuditLogRepository{client: client}
+}
+
+// EnsureIndexes creates necessary indexes for optimal query performance
+func (r *AuditLogRepository) EnsureIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "tenantId", Value: 1}, {Key: "timestamp", Value: -1}},
+			Options: options.Index().SetName("tenant_timestamp_idx"),
+		},
+		{
+			Keys:    bson.D{{Key: "tenantId", Value: 1}, {Key: "resourceType", Value: 1}, {Key: "resourceId", Value: 1}},
+			Options: options.Index().SetName("tenant_resource_idx"),
+		},
+	}
+	return r.client.CreateIndexes(ctx, auditLogsCollection, indexes)
+}
+
+// Create appends an entry to the audit log
+func (r *AuditLogRepository) Create(ctx context.Context, entry *domain.AuditLog) error {
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+	_, err := r.client.Collection(auditLogsCollection).InsertOne(ctx, entry)
+	return err
+}
+
+// Find lists a tenant's audit log entries matching query, newest first
+func (r *AuditLogRepository) Find(ctx context.Context, query *domain.AuditLogQuery) ([]*domain.AuditLog, int64, error) {
+	filter := bson.M{"tenantId": query.TenantID}
+	for field, value := range map[string]string{
+		"actor":        query.Actor,
+		"action":       query.Action,
+		"resourceType": query.ResourceType,
+		"resourceId":   query.ResourceID,
+	} {
+		if value != "" {
+			filter[field] = value
+		}
+	}
+	if query.From != nil || query.To != nil {
+		timestamp := bson.M{}
+		if query.From != nil {
+			timestamp["$gte"] = *query.From
+		}
+		if query.To != nil {
+			timestamp["$lt"] = *query.To
+		}
+		filter["timestamp"] = timestamp
+	}
+
+	collection := r.client.Collection(auditLogsCollection)
+	opts := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: -1}}).
+		SetSkip(int64((query.Page - 1) * query.PageSize)).
+		SetLimit(int64(query.PageSize))
+
+	var total int64
+	entries := []*domain.AuditLog{}
+	err := retryRead(ctx, "audit_logs.find", func() error {
+		var err error
+		if total, err = collection.CountDocuments(ctx, filter); err != nil {
+			return err
+		}
+
+		cursor, err := collection.Find(ctx, filter, opts)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		return cursor.All(ctx, &entries)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
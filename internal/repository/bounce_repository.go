@@ -1,97 +1,242 @@
-package repository
-
-import (
-	"context"
-	"time"
-
-	"github.com/vhvplatform/go-notification-service/internal/domain"
-	"github.com/vhvplatform/go-notification-service/internal/shared/mongodb"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
-)
-
-const bouncesCollection = "email_bounces"
-
-// BounceRepository handles email bounce data operations
-type BounceRepository struct {
-	client *mongodb.MongoClient
-}
-
-// NewBounceRepository creates a new bounce repository
-func NewBounceRepository(client *mongodb.MongoClient) *BounceRepository {
-	return &BounceRepository{client: client}
-}
-
-// EnsureIndexes creates necessary indexes for optimal query performance
-func (r *BounceRepository) EnsureIndexes(ctx context.Context) error {
-	indexes := []mongo.IndexModel{
-		{
-			Keys: bson.D{
-				{Key: "email", Value: 1},
-				{Key: "timestamp", Value: -1},
-			},
-			Options: options.Index().SetName("email_timestamp_idx"),
-		},
-		{
-			Keys: bson.D{
-				{Key: "email", Value: 1},
-				{Key: "type", Value: 1},
-				{Key: "timestamp", Value: -1},
-			},
-			Options: options.Index().SetName("email_type_timestamp_idx"),
-		},
-	}
-
-	return r.client.CreateIndexes(ctx, bouncesCollection, indexes)
-}
-
-// Create creates a new bounce record
-func (r *BounceRepository) Create(ctx context.Context, bounce *domain.EmailBounce) error {
-	bounce.ID = primitive.NewObjectID()
-	bounce.CreatedAt = time.Now()
-
-	_, err := r.client.Collection(bouncesCollection).InsertOne(ctx, bounce)
-	return err
-}
-
-// FindByEmail finds bounce records for an email address
-func (r *BounceRepository) FindByEmail(ctx context.Context, email string) ([]*domain.EmailBounce, error) {
-	filter := bson.M{"email": email}
-	cursor, err := r.client.Collection(bouncesCollection).Find(ctx, filter)
-	if err != nil {
-		return nil, err
-	}
-	defer cursor.Close(ctx)
-
-	var bounces []*domain.EmailBounce
-	if err = cursor.All(ctx, &bounces); err != nil {
-		return nil, err
-	}
-
-	return bounces, nil
-}
-
-// FindRecentHardBounces finds recent hard bounces for an email
-func (r *BounceRepository) FindRecentHardBounces(ctx context.Context, email string, days int) ([]*domain.EmailBounce, error) {
-	cutoff := time.Now().AddDate(0, 0, -days)
-	filter := bson.M{
-		"email":     email,
-		"type":      "hard",
-		"timestamp": bson.M{"$gte": cutoff},
-	}
-
-	cursor, err := r.client.Collection(bouncesCollection).Find(ctx, filter)
-	if err != nil {
-		return nil, err
-	}
-	defer cursor.Close(ctx)
-
-	var bounces []*domain.EmailBounce
-	if err = cursor.All(ctx, &bounces); err != nil {
-		return nil, err
-	}
-
-	return bounces, nil
-}
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/cache"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/shared/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const bouncesCollection = "email_bounces"
+
+// BounceRepository handles email bounce data operations
+type BounceRepository struct {
+	client *mongodb.MongoClient
+	cache  *cache.Cache // Optional; nil reads every lookup from the database
+}
+
+// NewBounceRepository creates a new bounce repository
+func NewBounceRepository(client *mongodb.MongoClient) *BounceRepository {
+	return &BounceRepository{client: client}
+}
+
+// SetCache caches each address's latest hard bounce in c, invalidating it when a bounce is recorded
+func (r *BounceRepository) SetCache(c *cache.Cache) {
+	r.cache = c
+}
+
+// EnsureIndexes creates necessary indexes for optimal query performance
+func (r *BounceRepository) EnsureIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "email", Value: 1},
+				{Key: "timestamp", Value: -1},
+			},
+			Options: options.Index().SetName("email_timestamp_idx"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "email", Value: 1},
+				{Key: "type", Value: 1},
+				{Key: "timestamp", Value: -1},
+			},
+			Options: options.Index().SetName("email_type_timestamp_idx"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "timestamp", Value: 1},
+				{Key: "tenantId", Value: 1},
+			},
+			Options: options.Index().SetName("timestamp_tenant_idx"), // Per-tenant bounce rates
+		},
+	}
+
+	return r.client.CreateIndexes(ctx, bouncesCollection, indexes)
+}
+
+// Create creates a new bounce record
+func (r *BounceRepository) Create(ctx context.Context, bounce *domain.EmailBounce) error {
+	bounce.ID = primitive.NewObjectID()
+	bounce.CreatedAt = time.Now()
+
+	_, err := r.client.Collection(bouncesCollection).InsertOne(ctx, bounce)
+	r.cache.Delete(ctx, cache.KindSuppression, bounce.Email)
+	return err
+}
+
+// CountByTenant counts each tenant's hard bounces and complaints at or after since.
+// Tenants without any are left out.
+func (r *BounceRepository) CountByTenant(ctx context.Context, since time.Time) ([]domain.BounceCounts, error) {
+	countType := func(bounceType string) bson.M {
+		return bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$type", bounceType}}, 1, 0}}}
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"timestamp": bson.M{"$gte": since},
+			"type":      bson.M{"$in": bson.A{"hard", "complaint"}},
+			"deletedAt": nil,
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":        "$tenantId",
+			"bounces":    countType("hard"),
+			"complaints": countType("complaint"),
+		}}},
+	}
+
+	var counts []domain.BounceCounts
+	err := retryRead(ctx, "email_bounces.count_by_tenant", func() error {
+		cursor, err := r.client.Collection(bouncesCollection).Aggregate(ctx, pipeline)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		counts = nil
+		return cursor.All(ctx, &counts)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// FindByEmail finds bounce records for an email address
+func (r *BounceRepository) FindByEmail(ctx context.Context, email string) ([]*domain.EmailBounce, error) {
+	filter := bson.M{"email": email}
+	cursor, err := r.client.Collection(bouncesCollection).Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var bounces []*domain.EmailBounce
+	if err = cursor.All(ctx, &bounces); err != nil {
+		return nil, err
+	}
+
+	return bounces, nil
+}
+
+// FindRecentHardBounces finds recent hard bounces for an email
+func (r *BounceRepository) FindRecentHardBounces(ctx context.Context, email string, days int) ([]*domain.EmailBounce, error) {
+	cutoff := time.Now().AddDate(0, 0, -days)
+	filter := bson.M{
+		"email":     email,
+		"type":      "hard",
+		"timestamp": bson.M{"$gte": cutoff},
+	}
+
+	cursor, err := r.client.Collection(bouncesCollection).Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var bounces []*domain.EmailBounce
+	if err = cursor.All(ctx, &bounces); err != nil {
+		return nil, err
+	}
+
+	return bounces, nil
+}
+
+// FindHardBounced returns which of emails hard bounced at or after since
+func (r *BounceRepository) FindHardBounced(ctx context.Context, emails []string, since time.Time) ([]string, error) {
+	if r.cache != nil {
+		return r.findHardBouncedCached(ctx, emails, since)
+	}
+
+	filter := bson.M{
+		"email":     bson.M{"$in": emails},
+		"type":      "hard",
+		"timestamp": bson.M{"$gte": since},
+	}
+
+	var bounced []string
+	err := retryRead(ctx, "email_bounces.find_hard_bounced", func() error {
+		values, err := r.client.Collection(bouncesCollection).Distinct(ctx, "email", filter)
+		if err != nil {
+			return err
+		}
+		bounced = make([]string, 0, len(values))
+		for _, value := range values {
+			if email, ok := value.(string); ok {
+				bounced = append(bounced, email)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return bounced, nil
+}
+
+// findHardBouncedCached answers FindHardBounced from each address's cached latest hard bounce,
+// looking up and caching the addresses that aren't cached. Caching the latest bounce rather
+// than the answer lets one entry serve any since.
+func (r *BounceRepository) findHardBouncedCached(ctx context.Context, emails []string, since time.Time) ([]string, error) {
+	var bounced, missed []string
+	for i, value := range r.cache.Get(ctx, cache.KindSuppression, emails...) {
+		if value == nil {
+			missed = append(missed, emails[i])
+			continue
+		}
+		if len(value) == 0 {
+			continue // Never hard bounced
+		}
+		last, err := time.Parse(time.RFC3339Nano, string(value))
+		if err != nil {
+			missed = append(missed, emails[i])
+			continue
+		}
+		if !last.Before(since) {
+			bounced = append(bounced, emails[i])
+		}
+	}
+	if len(missed) == 0 {
+		return bounced, nil
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"email": bson.M{"$in": missed}, "type": "hard"}}},
+		{{Key: "$group", Value: bson.M{"_id": "$email", "last": bson.M{"$max": "$timestamp"}}}},
+	}
+	var latest []struct {
+		Email string    `bson:"_id"`
+		Last  time.Time `bson:"last"`
+	}
+	err := retryRead(ctx, "email_bounces.latest_hard_bounces", func() error {
+		cursor, err := r.client.Collection(bouncesCollection).Aggregate(ctx, pipeline)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		latest = nil
+		return cursor.All(ctx, &latest)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]bool, len(latest))
+	for _, l := range latest {
+		found[l.Email] = true
+		r.cache.Set(ctx, cache.KindSuppression, l.Email, []byte(l.Last.UTC().Format(time.RFC3339Nano)))
+		if !l.Last.Before(since) {
+			bounced = append(bounced, l.Email)
+		}
+	}
+	for _, email := range missed {
+		if !found[email] {
+			r.cache.Set(ctx, cache.KindSuppression, email, []byte{})
+		}
+	}
+	return bounced, nil
+}
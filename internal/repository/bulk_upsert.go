@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// UpsertResult is the outcome of one upsert in a bulk write
+type UpsertResult struct {
+	Created bool  // No document matched, so one was inserted
+	Err     error // The document wasn't written; other upserts in the batch are unaffected
+}
+
+// bulkUpsert runs upsert models as one unordered bulk write and reports each model's outcome.
+// An error is only returned when the write as a whole failed, such as on a lost connection
+// or a write concern error.
+func bulkUpsert(ctx context.Context, collection *mongo.Collection, models []mongo.WriteModel) ([]UpsertResult, error) {
+	result, err := collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	return upsertResults(len(models), result, err)
+}
+
+// upsertResults splits a bulk write's outcome by model. Models neither upserted nor failed
+// matched an existing document.
+func upsertResults(n int, result *mongo.BulkWriteResult, err error) ([]UpsertResult, error) {
+	var writeErrs mongo.BulkWriteException
+	if err != nil && (!errors.As(err, &writeErrs) || writeErrs.WriteConcernError != nil || len(writeErrs.WriteErrors) == 0) {
+		return nil, err
+	}
+
+	results := make([]UpsertResult, n)
+	for _, writeErr := range writeErrs.WriteErrors {
+		if writeErr.Index >= 0 && writeErr.Index < n {
+			results[writeErr.Index].Err = writeErr
+		}
+	}
+	if result != nil {
+		for index := range result.UpsertedIDs {
+			if index >= 0 && int(index) < n {
+				results[index].Created = true
+			}
+		}
+	}
+	return results, nil
+}
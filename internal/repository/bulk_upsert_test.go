@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestUpsertResults(t *testing.T) {
+	result := &mongo.BulkWriteResult{UpsertedIDs: map[int64]interface{}{0: "a", 2: "c"}}
+	writeErr := mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{{WriteError: mongo.WriteError{Index: 1, Code: 11000, Message: "duplicate key"}}}}
+
+	results, err := upsertResults(4, result, writeErr)
+	if err != nil {
+		t.Fatalf("upsertResults() error = %v", err)
+	}
+	if !results[0].Created || results[1].Err == nil || !results[2].Created || results[3].Created || results[3].Err != nil {
+		t.Errorf("results = %+v, want created, failed, created, updated", results)
+	}
+
+	if _, err := upsertResults(1, nil, errors.New("connection reset")); err == nil {
+		t.Error("upsertResults() should return an error that isn't per document")
+	}
+	concernErr := mongo.BulkWriteException{WriteConcernError: &mongo.WriteConcernError{Message: "timeout"}}
+	if _, err := upsertResults(1, result, concernErr); err == nil {
+		t.Error("upsertResults() should return a write concern error")
+	}
+}
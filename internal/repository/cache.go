@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/vhvplatform/go-notification-service/internal/cache"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// cachedDocument decodes the cached document under key into v.
+// hit reports whether key was cached and found whether the cached entry holds a document,
+// rather than recording that there is none; v is only written when both are true.
+func cachedDocument(ctx context.Context, c *cache.Cache, kind, key string, v interface{}) (hit, found bool) {
+	value := c.Get(ctx, kind, key)[0]
+	if value == nil {
+		return false, false
+	}
+	if len(value) == 0 {
+		return true, false
+	}
+	if err := bson.Unmarshal(value, v); err != nil {
+		// An undecodable entry is treated as a miss and overwritten by the caller
+		return false, false
+	}
+	return true, true
+}
+
+// cacheDocument caches doc under key, or that there is no document when doc is nil
+func cacheDocument(ctx context.Context, c *cache.Cache, kind, key string, doc interface{}) {
+	if c == nil {
+		return
+	}
+	value := []byte{}
+	if doc != nil {
+		encoded, err := bson.Marshal(doc)
+		if err != nil {
+			return
+		}
+		value = encoded
+	}
+	c.Set(ctx, kind, key, value)
+}
@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/shared/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const digestsCollection = "notification_digests"
+
+// digestRetention is how long sent and failed digests are kept
+const digestRetention = 7 * 24 * time.Hour
+
+// DigestRepository stages notifications collected into digests until they are sent
+type DigestRepository struct {
+	client *mongodb.MongoClient
+}
+
+// NewDigestRepository creates a new digest repository
+func NewDigestRepository(client *mongodb.MongoClient) *DigestRepository {
+	return &DigestRepository{client: client}
+}
+
+// EnsureIndexes creates necessary indexes for optimal query performance
+func (r *DigestRepository) EnsureIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "tenantId", Value: 1},
+				{Key: "recipient", Value: 1},
+				{Key: "key", Value: 1},
+				{Key: "status", Value: 1},
+			},
+			Options: options.Index().SetName("tenant_recipient_key_status_idx"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "status", Value: 1},
+				{Key: "dueAt", Value: 1},
+			},
+			Options: options.Index().SetName("status_due_at_idx"),
+		},
+		{
+			Keys: bson.D{{Key: "completedAt", Value: 1}},
+			Options: options.Index().
+				SetName("completed_at_idx").
+				SetExpireAfterSeconds(int32(digestRetention.Seconds())), // TTL index
+		},
+	}
+	return r.client.CreateIndexes(ctx, digestsCollection, indexes)
+}
+
+// Stage adds item to the recipient's open digest for digest.Key, opening one from digest if
+// there is none or the open one holds maxItems. A digest that reaches its threshold or
+// maxItems becomes due at once.
+func (r *DigestRepository) Stage(ctx context.Context, digest *domain.Digest, item domain.DigestItem, maxItems int) (*domain.Digest, error) {
+	now := time.Now()
+	filter := bson.M{
+		"tenantId":  digest.TenantID,
+		"recipient": digest.Recipient,
+		"key":       digest.Key,
+		"status":    domain.DigestStatusOpen,
+		"itemCount": bson.M{"$lt": maxItems},
+	}
+	update := bson.M{
+		"$push": bson.M{"items": item},
+		"$inc":  bson.M{"itemCount": 1},
+		"$set":  bson.M{"updatedAt": now},
+		"$setOnInsert": bson.M{
+			"userId":     digest.UserID,
+			"templateId": digest.TemplateID,
+			"category":   digest.Category,
+			"interval":   digest.Interval,
+			"threshold":  digest.Threshold,
+			"dueAt":      now.Add(digest.Interval.Duration()),
+			"attempts":   0,
+			"createdAt":  now,
+		},
+	}
+	opts := options.FindOneAndUpdate().
+		SetUpsert(true).
+		SetReturnDocument(options.After)
+
+	var staged domain.Digest
+	err := r.client.CriticalCollection(digestsCollection).FindOneAndUpdate(ctx, filter, update, opts).Decode(&staged)
+	if err != nil {
+		return nil, err
+	}
+
+	full := staged.ItemCount >= maxItems || (staged.Threshold > 0 && staged.ItemCount >= staged.Threshold)
+	if full && staged.DueAt.After(now) {
+		filter := bson.M{"_id": staged.ID, "status": domain.DigestStatusOpen}
+		update := bson.M{"$set": bson.M{"dueAt": now}}
+		if _, err := r.client.Collection(digestsCollection).UpdateOne(ctx, filter, update); err != nil {
+			return nil, err
+		}
+		staged.DueAt = now
+	}
+	return &staged, nil
+}
+
+// ClaimDue claims the most overdue open digest for delivery
+// Digests claimed more than staleAfter ago are claimed again, so a digest whose sender died
+// is not lost. It returns nil if no digest is due.
+func (r *DigestRepository) ClaimDue(ctx context.Context, now time.Time, staleAfter time.Duration) (*domain.Digest, error) {
+	filter := bson.M{
+		"$or": bson.A{
+			bson.M{"status": domain.DigestStatusOpen, "dueAt": bson.M{"$lte": now}},
+			bson.M{"status": domain.DigestStatusSending, "claimedAt": bson.M{"$lte": now.Add(-staleAfter)}},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":    domain.DigestStatusSending,
+			"claimedAt": now,
+			"updatedAt": now,
+		},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "dueAt", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var digest domain.Digest
+	err := r.client.Collection(digestsCollection).FindOneAndUpdate(ctx, filter, update, opts).Decode(&digest)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &digest, nil
+}
+
+// Reschedule reopens a claimed digest to be sent at dueAt
+// A non-empty failure records a failed delivery attempt.
+func (r *DigestRepository) Reschedule(ctx context.Context, id primitive.ObjectID, dueAt time.Time, failure string) error {
+	set := bson.M{
+		"status":    domain.DigestStatusOpen,
+		"dueAt":     dueAt,
+		"updatedAt": time.Now(),
+	}
+	update := bson.M{
+		"$set":   set,
+		"$unset": bson.M{"claimedAt": ""},
+	}
+	if failure != "" {
+		set["lastError"] = failure
+		update["$inc"] = bson.M{"attempts": 1}
+	}
+
+	filter := bson.M{"_id": id, "status": domain.DigestStatusSending}
+	_, err := r.client.Collection(digestsCollection).UpdateOne(ctx, filter, update)
+	return err
+}
+
+// Complete marks a claimed digest sent, or failed with failure
+func (r *DigestRepository) Complete(ctx context.Context, id primitive.ObjectID, status domain.DigestStatus, failure string) error {
+	now := time.Now()
+	set := bson.M{
+		"status":      status,
+		"completedAt": now,
+		"updatedAt":   now,
+	}
+	if failure != "" {
+		set["lastError"] = failure
+	}
+
+	filter := bson.M{"_id": id, "status": domain.DigestStatusSending}
+	_, err := r.client.CriticalCollection(digestsCollection).UpdateOne(ctx, filter, bson.M{"$set": set})
+	return err
+}
+
+// DeleteByRecipient deletes all of a tenant's digests for recipient, whatever their status,
+// and returns how many were deleted
+func (r *DigestRepository) DeleteByRecipient(ctx context.Context, tenantID, recipient string) (int64, error) {
+	filter := bson.M{
+		"tenantId":  tenantID,
+		"recipient": recipientFilter(recipient),
+	}
+	result, err := r.client.CriticalCollection(digestsCollection).DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
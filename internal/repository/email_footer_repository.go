@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/cache"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/shared/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const emailFootersCollection = "tenant_email_footers"
+
+// EmailFooterRepository handles per-tenant footers appended to outgoing emails
+type EmailFooterRepository struct {
+	client *mongodb.MongoClient
+	cache  *cache.Cache // Optional; nil reads every lookup from the database
+}
+
+// NewEmailFooterRepository creates a new email footer repository
+func NewEmailFooterRepository(client *mongodb.MongoClient) *EmailFooterRepository {
+	return &EmailFooterRepository{client: client}
+}
+
+// SetCache caches footer lookups in c, invalidating them when a footer is replaced or removed
+func (r *EmailFooterRepository) SetCache(c *cache.Cache) {
+	r.cache = c
+}
+
+// EnsureIndexes creates necessary indexes for optimal query performance
+func (r *EmailFooterRepository) EnsureIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "tenantId", Value: 1}},
+			Options: options.Index().SetName("tenant_idx").SetUnique(true),
+		},
+	}
+	return r.client.CreateIndexes(ctx, emailFootersCollection, indexes)
+}
+
+// FindEmailFooter finds the email footer for a tenant
+// Returns nil without error when the tenant has no footer.
+func (r *EmailFooterRepository) FindEmailFooter(ctx context.Context, tenantID string) (*domain.EmailFooter, error) {
+	key := "email_footer:" + tenantID
+	var cached domain.EmailFooter
+	if hit, found := cachedDocument(ctx, r.cache, cache.KindTenantConfig, key, &cached); hit {
+		if !found {
+			return nil, nil
+		}
+		return &cached, nil
+	}
+
+	var footer domain.EmailFooter
+	err := retryRead(ctx, "email_footers.find", func() error {
+		return r.client.Collection(emailFootersCollection).FindOne(ctx, bson.M{"tenantId": tenantID}).Decode(&footer)
+	})
+	if err == mongo.ErrNoDocuments {
+		cacheDocument(ctx, r.cache, cache.KindTenantConfig, key, nil)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cacheDocument(ctx, r.cache, cache.KindTenantConfig, key, &footer)
+	return &footer, nil
+}
+
+// Upsert replaces the email footer for a tenant
+func (r *EmailFooterRepository) Upsert(ctx context.Context, tenantID string, req domain.SetEmailFooterRequest) (*domain.EmailFooter, error) {
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"html":            req.HTML,
+			"text":            req.Text,
+			"unsubscribeHtml": req.UnsubscribeHTML,
+			"unsubscribeText": req.UnsubscribeText,
+			"updatedAt":       now,
+		},
+		"$setOnInsert": bson.M{
+			"_id":       primitive.NewObjectID(),
+			"createdAt": now,
+		},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var result domain.EmailFooter
+	err := r.client.Collection(emailFootersCollection).FindOneAndUpdate(ctx, bson.M{"tenantId": tenantID}, update, opts).Decode(&result)
+	r.cache.Delete(ctx, cache.KindTenantConfig, "email_footer:"+tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Delete removes the email footer for a tenant
+// Returns false without error when the tenant had no footer.
+func (r *EmailFooterRepository) Delete(ctx context.Context, tenantID string) (bool, error) {
+	result, err := r.client.Collection(emailFootersCollection).DeleteOne(ctx, bson.M{"tenantId": tenantID})
+	r.cache.Delete(ctx, cache.KindTenantConfig, "email_footer:"+tenantID)
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func heldRequest(t *testing.T, notifType domain.NotificationType, req any) *domain.HeldNotification {
+	t.Helper()
+	data, err := json.Marshal(req)
+	require.NoError(t, err)
+	return &domain.HeldNotification{TenantID: "tenant-1", Type: notifType, Request: data}
+}
+
+func TestEraseHeldRecipient(t *testing.T) {
+	t.Run("removes the address from an email kept for others", func(t *testing.T) {
+		held := heldRequest(t, domain.NotificationTypeEmail, domain.SendEmailRequest{
+			To:  []string{"Jane@Example.com", "bob@example.com"},
+			CC:  []string{"jane@example.com"},
+			BCC: []string{"audit@example.com"},
+		})
+
+		request, remove, err := eraseHeldRecipient(held, "jane@example.com")
+		require.NoError(t, err)
+		assert.False(t, remove)
+		require.NotNil(t, request)
+
+		var req domain.SendEmailRequest
+		require.NoError(t, json.Unmarshal(request, &req))
+		assert.Equal(t, []string{"bob@example.com"}, req.To)
+		assert.Empty(t, req.CC)
+		assert.Equal(t, []string{"audit@example.com"}, req.BCC)
+	})
+
+	t.Run("removes an email left without a To address", func(t *testing.T) {
+		held := heldRequest(t, domain.NotificationTypeEmail, domain.SendEmailRequest{
+			To: []string{"jane@example.com"},
+			CC: []string{"bob@example.com"},
+		})
+
+		request, remove, err := eraseHeldRecipient(held, "JANE@example.com")
+		require.NoError(t, err)
+		assert.True(t, remove)
+		assert.Nil(t, request)
+	})
+
+	t.Run("keeps an email sent to a recipient list", func(t *testing.T) {
+		held := heldRequest(t, domain.NotificationTypeEmail, domain.SendEmailRequest{
+			To:              []string{"jane@example.com"},
+			RecipientListID: "list-1",
+		})
+
+		request, remove, err := eraseHeldRecipient(held, "jane@example.com")
+		require.NoError(t, err)
+		assert.False(t, remove)
+		assert.NotNil(t, request)
+	})
+
+	t.Run("leaves other recipients' emails alone", func(t *testing.T) {
+		held := heldRequest(t, domain.NotificationTypeEmail, domain.SendEmailRequest{To: []string{"bob@example.com"}})
+
+		request, remove, err := eraseHeldRecipient(held, "jane@example.com")
+		require.NoError(t, err)
+		assert.False(t, remove)
+		assert.Nil(t, request)
+	})
+
+	t.Run("removes an SMS to the recipient", func(t *testing.T) {
+		held := heldRequest(t, domain.NotificationTypeSMS, domain.SendSMSRequest{To: "+15550100"})
+
+		_, remove, err := eraseHeldRecipient(held, "+15550100")
+		require.NoError(t, err)
+		assert.True(t, remove)
+
+		_, remove, err = eraseHeldRecipient(held, "+15550199")
+		require.NoError(t, err)
+		assert.False(t, remove)
+	})
+}
+
+// TestEraseRecipient_Stores verifies a recipient is scrubbed from lists, schedules, held sends and digests
+func TestEraseRecipient_Stores(t *testing.T) {
+	t.Skip("Requires MongoDB connection - run with integration test suite")
+
+	client := setupTestMongoDB(t)
+	defer teardownTestMongoDB(t, client)
+	ctx := context.Background()
+	defer func() {
+		for _, coll := range []string{recipientListsCollection, heldNotificationsCollection, digestsCollection} {
+			_ = client.Collection(coll).Drop(ctx)
+		}
+	}()
+
+	lists := NewRecipientListRepository(client)
+	list := &domain.RecipientList{
+		TenantID: "tenant-1",
+		Name:     "Customers",
+		Members: []domain.RecipientListMember{
+			{Address: "Jane@example.com", Subscribed: true},
+			{Address: "bob@example.com", Subscribed: true},
+		},
+	}
+	require.NoError(t, lists.Create(ctx, list))
+
+	count, err := lists.RemoveMember(ctx, "tenant-1", "jane@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	found, err := lists.FindRecipientList(ctx, "tenant-1", list.ID.Hex())
+	require.NoError(t, err)
+	require.Len(t, found.Members, 1)
+	assert.Equal(t, "bob@example.com", found.Members[0].Address)
+	assert.Equal(t, 1, found.MemberCount)
+	assert.Equal(t, list.Version+1, found.Version)
+
+	schedules := NewScheduledNotificationRepository(client)
+	shared := &domain.ScheduledNotification{
+		TenantID: "tenant-1",
+		Type:     domain.NotificationTypeEmail,
+		Schedule: "0 9 * * *",
+		Request:  bson.M{"to": bson.A{"jane@example.com", "bob@example.com"}, "subject": "Hi"},
+	}
+	only := &domain.ScheduledNotification{
+		TenantID: "tenant-1",
+		Type:     domain.NotificationTypeEmail,
+		Schedule: "0 9 * * *",
+		Request:  bson.M{"to": bson.A{"JANE@example.com"}, "subject": "Hi"},
+	}
+	sms := &domain.ScheduledNotification{
+		TenantID: "tenant-1",
+		Type:     domain.NotificationTypeSMS,
+		Schedule: "0 9 * * *",
+		Request:  bson.M{"to": "jane@example.com", "message": "Hi"},
+	}
+	for _, sched := range []*domain.ScheduledNotification{shared, only, sms} {
+		require.NoError(t, schedules.Create(ctx, sched))
+	}
+
+	updated, deleted, err := schedules.EraseRecipient(ctx, "tenant-1", "jane@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, []primitive.ObjectID{shared.ID}, updated)
+	assert.ElementsMatch(t, []primitive.ObjectID{only.ID, sms.ID}, deleted)
+
+	held := NewSendPauseRepository(client)
+	require.NoError(t, held.Hold(ctx, heldRequest(t, domain.NotificationTypeSMS, domain.SendSMSRequest{To: "jane@example.com"})))
+	require.NoError(t, held.Hold(ctx, heldRequest(t, domain.NotificationTypeEmail, domain.SendEmailRequest{To: []string{"bob@example.com"}})))
+	count, err = held.EraseRecipient(ctx, "tenant-1", "jane@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	digests := NewDigestRepository(client)
+	_, err = client.Collection(digestsCollection).InsertOne(ctx, domain.Digest{TenantID: "tenant-1", Recipient: "jane@example.com"})
+	require.NoError(t, err)
+	count, err = digests.DeleteByRecipient(ctx, "tenant-1", "JANE@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
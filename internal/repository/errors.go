@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrConcurrentModification is returned when a version-guarded update loses to another writer
+var ErrConcurrentModification = errors.New("concurrent modification")
+
+// ConflictError reports an optimistic-locking failure along with the document's current version,
+// so callers can reload and retry. It unwraps to ErrConcurrentModification.
+type ConflictError struct {
+	CurrentVersion int
+}
+
+// Error implements the error interface
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%v: current version is %d", ErrConcurrentModification, e.CurrentVersion)
+}
+
+// Unwrap returns ErrConcurrentModification
+func (e *ConflictError) Unwrap() error {
+	return ErrConcurrentModification
+}
+
+// AsConflict returns the ConflictError in err's chain, if any
+func AsConflict(err error) (*ConflictError, bool) {
+	var conflict *ConflictError
+	ok := errors.As(err, &conflict)
+	return conflict, ok
+}
+
+// versionConflict explains why a version-guarded update matched nothing. It returns a
+// *ConflictError when the document still exists at another version, or mongo.ErrNoDocuments.
+func versionConflict(ctx context.Context, collection *mongo.Collection, filter bson.M) error {
+	lookup := make(bson.M, len(filter))
+	for key, value := range filter {
+		if key != "version" {
+			lookup[key] = value
+		}
+	}
+
+	var current struct {
+		Version int `bson:"version"`
+	}
+	opts := options.FindOne().SetProjection(bson.M{"version": 1})
+	if err := collection.FindOne(ctx, lookup, opts).Decode(&current); err != nil {
+		return err
+	}
+	return &ConflictError{CurrentVersion: current.Version}
+}
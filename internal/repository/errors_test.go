@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestConflictError(t *testing.T) {
+	err := fmt.Errorf("update preferences: %w", &ConflictError{CurrentVersion: 4})
+
+	if !errors.Is(err, ErrConcurrentModification) {
+		t.Error("ConflictError should match ErrConcurrentModification")
+	}
+
+	conflict, ok := AsConflict(err)
+	if !ok || conflict.CurrentVersion != 4 {
+		t.Errorf("AsConflict() = %v, %v; want current version 4", conflict, ok)
+	}
+
+	if _, ok := AsConflict(errors.New("other")); ok {
+		t.Error("AsConflict() matched an unrelated error")
+	}
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/shared/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const escalationPoliciesCollection = "tenant_escalation_policies"
+
+// EscalationPolicyRepository handles per-tenant escalation policies for unacknowledged critical notifications
+type EscalationPolicyRepository struct {
+	client *mongodb.MongoClient
+}
+
+// NewEscalationPolicyRepository creates a new escalation policy repository
+func NewEscalationPolicyRepository(client *mongodb.MongoClient) *EscalationPolicyRepository {
+	return &EscalationPolicyRepository{client: client}
+}
+
+// EnsureIndexes creates necessary indexes for optimal query performance
+func (r *EscalationPolicyRepository) EnsureIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "tenantId", Value: 1}},
+			Options: options.Index().SetName("tenant_idx").SetUnique(true),
+		},
+	}
+	return r.client.CreateIndexes(ctx, escalationPoliciesCollection, indexes)
+}
+
+// FindEscalationPolicy finds the escalation policy for a tenant
+// Returns nil without error when the tenant has no policy.
+func (r *EscalationPolicyRepository) FindEscalationPolicy(ctx context.Context, tenantID string) (*domain.EscalationPolicy, error) {
+	var policy domain.EscalationPolicy
+	err := retryRead(ctx, "escalation_policies.find", func() error {
+		return r.client.Collection(escalationPoliciesCollection).FindOne(ctx, bson.M{"tenantId": tenantID}).Decode(&policy)
+	})
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// FindAll returns every tenant's escalation policy
+func (r *EscalationPolicyRepository) FindAll(ctx context.Context) ([]*domain.EscalationPolicy, error) {
+	var policies []*domain.EscalationPolicy
+	err := retryRead(ctx, "escalation_policies.find_all", func() error {
+		cursor, err := r.client.Collection(escalationPoliciesCollection).Find(ctx, bson.M{})
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		policies = nil
+		return cursor.All(ctx, &policies)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// Upsert replaces the escalation policy for a tenant
+func (r *EscalationPolicyRepository) Upsert(ctx context.Context, tenantID string, req *domain.UpdateEscalationPolicyRequest) (*domain.EscalationPolicy, error) {
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"steps":          req.Steps,
+			"stopOnDelivery": req.StopOnDelivery,
+			"updatedAt":      now,
+		},
+		"$setOnInsert": bson.M{
+			"_id":       primitive.NewObjectID(),
+			"createdAt": now,
+		},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var result domain.EscalationPolicy
+	if err := r.client.Collection(escalationPoliciesCollection).FindOneAndUpdate(ctx, bson.M{"tenantId": tenantID}, update, opts).Decode(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Delete removes the escalation policy for a tenant
+// Returns false without error when the tenant had no policy.
+func (r *EscalationPolicyRepository) Delete(ctx context.Context, tenantID string) (bool, error) {
+	result, err := r.client.Collection(escalationPoliciesCollection).DeleteOne(ctx, bson.M{"tenantId": tenantID})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
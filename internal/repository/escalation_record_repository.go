@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/shared/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const escalationRecordsCollection = "notification_escalations"
+
+// EscalationRecordRepository handles the escalation steps taken for notifications
+// Records are append-only.
+type EscalationRecordRepository struct {
+	client *mongodb.MongoClient
+}
+
+// NewEscalationRecordRepository creates a new escalation record repository
+func NewEscalationRecordRepository(client *mongodb.MongoClient) *EscalationRecordRepository {
+	return &EscalationRecordRepository{client: client}
+}
+
+// EnsureIndexes creates necessary indexes for optimal query performance
+func (r *EscalationRecordRepository) EnsureIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "tenantId", Value: 1}, {Key: "notificationId", Value: 1}, {Key: "step", Value: 1}},
+			Options: options.Index().SetName("tenant_notification_step_idx"),
+		},
+	}
+	return r.client.CreateIndexes(ctx, escalationRecordsCollection, indexes)
+}
+
+// Create records an escalation step
+func (r *EscalationRecordRepository) Create(ctx context.Context, record *domain.EscalationRecord) error {
+	if record.ID.IsZero() {
+		record.ID = primitive.NewObjectID()
+	}
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now()
+	}
+	_, err := r.client.Collection(escalationRecordsCollection).InsertOne(ctx, record)
+	return err
+}
+
+// FindByNotificationID returns the escalation steps taken for a notification in order, with tenant isolation
+func (r *EscalationRecordRepository) FindByNotificationID(ctx context.Context, tenantID, notificationID string) ([]*domain.EscalationRecord, error) {
+	filter := bson.M{
+		"tenantId":       tenantID,
+		"notificationId": notificationID,
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "step", Value: 1}, {Key: "createdAt", Value: 1}})
+
+	var records []*domain.EscalationRecord
+	err := retryRead(ctx, "notification_escalations.find", func() error {
+		cursor, err := r.client.Collection(escalationRecordsCollection).Find(ctx, filter, opts)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		records = nil
+		return cursor.All(ctx, &records)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// matchesAny reports whether doc matches any of the filters, supporting the operators the
+// idempotency window filters use ($gt, $lte and $exists on time fields)
+func matchesAny(doc bson.M, filters bson.A) bool {
+	for _, f := range filters {
+		if matchesAll(doc, f.(bson.M)) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAll(doc bson.M, filter bson.M) bool {
+	for field, condition := range filter {
+		value, exists := doc[field]
+		for op, operand := range condition.(bson.M) {
+			switch op {
+			case "$exists":
+				if exists != operand.(bool) {
+					return false
+				}
+			case "$gt":
+				if !exists || !value.(time.Time).After(operand.(time.Time)) {
+					return false
+				}
+			case "$lte":
+				if !exists || value.(time.Time).After(operand.(time.Time)) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+func TestIdempotencyWindow(t *testing.T) {
+	repo := &NotificationRepository{idempotencyTTL: 24 * time.Hour}
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		doc    bson.M
+		active bool
+	}{
+		{"window open", bson.M{"idempotencyExpiresAt": now.Add(time.Hour), "createdAt": now.Add(-time.Hour)}, true},
+		{"window ended", bson.M{"idempotencyExpiresAt": now.Add(-time.Hour), "createdAt": now.Add(-25 * time.Hour)}, false},
+		{"window ends now", bson.M{"idempotencyExpiresAt": now, "createdAt": now.Add(-24 * time.Hour)}, false},
+		{"window set longer than TTL", bson.M{"idempotencyExpiresAt": now.Add(time.Hour), "createdAt": now.Add(-47 * time.Hour)}, true},
+		{"legacy within TTL", bson.M{"createdAt": now.Add(-23 * time.Hour)}, true},
+		{"legacy past TTL", bson.M{"createdAt": now.Add(-25 * time.Hour)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchesAny(tt.doc, repo.idempotencyActive(now)); got != tt.active {
+				t.Errorf("idempotencyActive() matches = %v, want %v", got, tt.active)
+			}
+			if got := matchesAny(tt.doc, repo.idempotencyExpired(now)); got != !tt.active {
+				t.Errorf("idempotencyExpired() matches = %v, want %v", got, !tt.active)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+	"errors"
+)
+
+// Indexer is a repository that creates the indexes its queries rely on
+type Indexer interface {
+	EnsureIndexes(ctx context.Context) error
+}
+
+// EnsureIndexes creates every indexer's indexes. Creating an index that already exists is a
+// no-op, so it is safe to call at each startup. A failure doesn't stop the remaining indexers;
+// the failures are returned joined.
+func EnsureIndexes(ctx context.Context, indexers ...Indexer) error {
+	var errs []error
+	for _, indexer := range indexers {
+		if err := indexer.EnsureIndexes(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
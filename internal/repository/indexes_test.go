@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// fakeIndexer counts its calls and fails with err
+type fakeIndexer struct {
+	calls int
+	err   error
+}
+
+func (f *fakeIndexer) EnsureIndexes(ctx context.Context) error {
+	f.calls++
+	return f.err
+}
+
+func TestEnsureIndexesContinuesPastFailures(t *testing.T) {
+	errIndex := errors.New("index build failed")
+	first := &fakeIndexer{err: errIndex}
+	second := &fakeIndexer{}
+
+	err := EnsureIndexes(context.Background(), first, second)
+	if !errors.Is(err, errIndex) {
+		t.Errorf("EnsureIndexes() error = %v, want %v", err, errIndex)
+	}
+	if first.calls != 1 || second.calls != 1 {
+		t.Errorf("calls = %d, %d; want every indexer called once", first.calls, second.calls)
+	}
+
+	if err := EnsureIndexes(context.Background(), second); err != nil {
+		t.Errorf("EnsureIndexes() error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/shared/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const notificationEventsCollection = "notification_events"
+
+// maxTimelineEvents bounds the events returned for one notification's timeline
+const maxTimelineEvents = 500
+
+// NotificationEventRepository handles notification event data operations
+// Events are append-only; each records one status a notification passed through.
+type NotificationEventRepository struct {
+	client *mongodb.MongoClient
+}
+
+// NewNotificationEventRepository creates a new notification event repository
+func NewNotificationEventRepository(client *mongodb.MongoClient) *NotificationEventRepository {
+	return &NotificationEventRepository{client: client}
+}
+
+// EnsureIndexes creates necessary indexes for optimal query performance
+func (r *NotificationEventRepository) EnsureIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "tenantId", Value: 1}, {Key: "notificationId", Value: 1}, {Key: "timestamp", Value: 1}},
+			Options: options.Index().SetName("tenant_notification_timestamp_idx"),
+		},
+	}
+	return r.client.CreateIndexes(ctx, notificationEventsCollection, indexes)
+}
+
+// Create records notification events
+func (r *NotificationEventRepository) Create(ctx context.Context, events ...*domain.NotificationEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	documents := make([]interface{}, len(events))
+	for i, event := range events {
+		if event.ID == "" {
+			event.ID = primitive.NewObjectID().Hex()
+		}
+		if event.Timestamp.IsZero() {
+			event.Timestamp = now
+		}
+		event.CreatedAt = now
+		documents[i] = event
+	}
+
+	_, err := r.client.Collection(notificationEventsCollection).InsertMany(ctx, documents)
+	return err
+}
+
+// FindByNotificationID returns a notification's events in the order they happened, with tenant isolation
+func (r *NotificationEventRepository) FindByNotificationID(ctx context.Context, tenantID, notificationID string) ([]*domain.NotificationEvent, error) {
+	filter := bson.M{
+		"tenantId":       tenantID,
+		"notificationId": notificationID,
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: 1}, {Key: "createdAt", Value: 1}}).
+		SetLimit(maxTimelineEvents)
+
+	var events []*domain.NotificationEvent
+	err := retryRead(ctx, "notification_events.find", func() error {
+		cursor, err := r.client.Collection(notificationEventsCollection).Find(ctx, filter, opts)
+		if err != nil {
+			return err
+		}
+		events = nil
+		return cursor.All(ctx, &events)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// FindByNotificationIDs returns the events of the given notifications in the order they happened, with tenant isolation
+func (r *NotificationEventRepository) FindByNotificationIDs(ctx context.Context, tenantID string, notificationIDs []string) ([]*domain.NotificationEvent, error) {
+	filter := bson.M{
+		"tenantId":       tenantID,
+		"notificationId": bson.M{"$in": notificationIDs},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "notificationId", Value: 1}, {Key: "timestamp", Value: 1}, {Key: "createdAt", Value: 1}})
+
+	var events []*domain.NotificationEvent
+	err := retryRead(ctx, "notification_events.find_by_notifications", func() error {
+		cursor, err := r.client.Collection(notificationEventsCollection).Find(ctx, filter, opts)
+		if err != nil {
+			return err
+		}
+		events = nil
+		return cursor.All(ctx, &events)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// DeleteByNotificationIDs permanently deletes the events of the given notifications, with tenant isolation
+func (r *NotificationEventRepository) DeleteByNotificationIDs(ctx context.Context, tenantID string, notificationIDs []string) (int64, error) {
+	result, err := r.client.Collection(notificationEventsCollection).DeleteMany(ctx, bson.M{
+		"tenantId":       tenantID,
+		"notificationId": bson.M{"$in": notificationIDs},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+// statusEvent returns the event recording that a notification reached status at the given time
+// detail carries provider information such as the error reported for a failure.
+func statusEvent(notificationID primitive.ObjectID, tenantID string, status domain.NotificationStatus, at time.Time, detail map[string]string) *domain.NotificationEvent {
+	return &domain.NotificationEvent{
+		NotificationID: notificationID.Hex(),
+		TenantID:       tenantID,
+		EventType:      string(status),
+		Timestamp:      at,
+		Metadata:       detail,
+	}
+}
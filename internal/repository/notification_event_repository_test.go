@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestStatusEvent(t *testing.T) {
+	id := primitive.NewObjectID()
+	at := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+
+	event := statusEvent(id, "tenant-1", domain.NotificationStatusFailed, at, map[string]string{"error": "mailbox full"})
+
+	assert.Equal(t, id.Hex(), event.NotificationID)
+	assert.Equal(t, "tenant-1", event.TenantID)
+	assert.Equal(t, string(domain.NotificationStatusFailed), event.EventType)
+	assert.Equal(t, at, event.Timestamp)
+	assert.Equal(t, "mailbox full", event.Metadata["error"])
+}
+
+func TestRecordStatusWithoutEventRepository(t *testing.T) {
+	r := &NotificationRepository{}
+	event := statusEvent(primitive.NewObjectID(), "tenant-1", domain.NotificationStatusSent, time.Now(), nil)
+
+	assert.NoError(t, r.recordStatus(context.Background(), event))
+}
+
+// TestNotificationTimeline_RecordsEachStatus verifies every status change is recorded in order and read with tenant isolation
+func TestNotificationTimeline_RecordsEachStatus(t *testing.T) {
+	t.Skip("Requires MongoDB connection - run with integration test suite")
+
+	client := setupTestMongoDB(t)
+	defer teardownTestMongoDB(t, client)
+	ctx := context.Background()
+	defer func() {
+		for _, coll := range []string{notificationsCollection, notificationEventsCollection} {
+			_ = client.Collection(coll).Drop(ctx)
+		}
+	}()
+
+	events := NewNotificationEventRepository(client)
+	repo := NewNotificationRepository(client, nil)
+	repo.SetEventRepository(events)
+
+	notification := &domain.Notification{
+		TenantID:  "tenant-1",
+		Type:      domain.NotificationTypeEmail,
+		Recipient: "jane@example.com",
+		Status:    domain.NotificationStatusPending,
+	}
+	require.NoError(t, repo.Create(ctx, notification))
+	id := notification.ID.Hex()
+
+	sentAt := time.Now()
+	require.NoError(t, repo.UpdateStatus(ctx, id, "tenant-1", domain.NotificationStatusSent, "", &sentAt))
+	require.NoError(t, repo.UpdateDeliveryStatus(ctx, id, "tenant-1", domain.NotificationStatusDelivered, sentAt.Add(time.Minute)))
+	require.NoError(t, repo.UpdateStatus(ctx, id, "tenant-1", domain.NotificationStatusFailed, "mailbox full", nil))
+
+	// Updates for another tenant's notification don't match and add no events
+	require.NoError(t, repo.UpdateStatus(ctx, id, "tenant-2", domain.NotificationStatusSent, "", &sentAt))
+
+	timeline, err := events.FindByNotificationID(ctx, "tenant-1", id)
+	require.NoError(t, err)
+	var types []string
+	for _, event := range timeline {
+		types = append(types, event.EventType)
+	}
+	assert.Equal(t, []string{"pending", "sent", "delivered", "failed"}, types)
+	assert.Equal(t, "mailbox full", timeline[3].Metadata["error"])
+
+	other, err := events.FindByNotificationID(ctx, "tenant-2", id)
+	require.NoError(t, err)
+	assert.Empty(t, other)
+}
@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Acknowledge marks a notification acknowledged at the given time with tenant isolation, and
+// records it on the notification's timeline. Only the first acknowledgment is kept; it reports
+// whether this call made it. It returns nil if the notification doesn't exist.
+func (r *NotificationRepository) Acknowledge(ctx context.Context, tenantID, id string, at time.Time, metadata map[string]string) (*domain.Notification, bool, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, false, nil
+	}
+
+	set := bson.M{
+		"acknowledgedAt": at,
+		"updatedAt":      time.Now(),
+	}
+	if len(metadata) > 0 {
+		set["ackMetadata"] = metadata
+	}
+	filter := bson.M{
+		"_id":            objectID,
+		"tenantId":       tenantID,
+		"acknowledgedAt": nil,
+		"deletedAt":      nil,
+	}
+	update := bson.M{
+		"$set": set,
+		"$inc": bson.M{"version": 1},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var notification domain.Notification
+	err = r.client.CriticalCollection(notificationsCollection).FindOneAndUpdate(ctx, filter, update, opts).Decode(&notification)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// Already acknowledged, or not found
+		existing, err := r.FindByID(ctx, id, tenantID)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, false, nil
+		}
+		return existing, false, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	event := &domain.NotificationEvent{
+		NotificationID: id,
+		TenantID:       tenantID,
+		EventType:      domain.NotificationEventAcknowledged,
+		Timestamp:      at,
+		Metadata:       metadata,
+	}
+	return &notification, true, r.recordStatus(ctx, event)
+}
+
+// FindUnacknowledged finds critical notifications sent at or before sentBefore that haven't been
+// acknowledged, oldest first, with tenant isolation
+func (r *NotificationRepository) FindUnacknowledged(ctx context.Context, tenantID string, sentBefore time.Time, page, pageSize int) ([]*domain.Notification, int64, error) {
+	filter := bson.M{
+		"tenantId":       tenantID,
+		"priority":       domain.NotificationPriorityCritical,
+		"status":         bson.M{"$in": sentStatuses},
+		"sentAt":         bson.M{"$lte": sentBefore},
+		"acknowledgedAt": nil,
+		"deletedAt":      nil,
+	}
+
+	skip := (page - 1) * pageSize
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$facet", Value: bson.M{
+			"metadata": bson.A{bson.M{"$count": "total"}},
+			"data": bson.A{
+				bson.M{"$sort": bson.M{"sentAt": 1}},
+				bson.M{"$skip": skip},
+				bson.M{"$limit": pageSize},
+			},
+		}}},
+	}
+
+	type Result struct {
+		Metadata []struct {
+			Total int64 `bson:"total"`
+		} `bson:"metadata"`
+		Data []*domain.Notification `bson:"data"`
+	}
+
+	var results []Result
+	err := retryRead(ctx, "notifications.find_unacknowledged", func() error {
+		cursor, err := r.client.Collection(notificationsCollection).Aggregate(ctx, pipeline)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		return cursor.All(ctx, &results)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(results) == 0 || len(results[0].Data) == 0 {
+		return []*domain.Notification{}, 0, nil
+	}
+
+	total := int64(0)
+	if len(results[0].Metadata) > 0 {
+		total = results[0].Metadata[0].Total
+	}
+
+	return results[0].Data, total, nil
+}
+
+// ClaimOverdue claims one critical notification of the tenant that has taken step escalation
+// steps and went unacknowledged since it was sent between sentAfter and sentBefore, advancing
+// it to the next step. Escalation notices aren't claimed themselves. With undelivered, delivered
+// notifications aren't claimed either. Returns nil without error when none is overdue.
+func (r *NotificationRepository) ClaimOverdue(ctx context.Context, tenantID string, step int, sentAfter, sentBefore time.Time, undelivered bool) (*domain.Notification, error) {
+	statuses := sentStatuses
+	if undelivered {
+		statuses = []domain.NotificationStatus{domain.NotificationStatusSent}
+	}
+	filter := bson.M{
+		"tenantId":       tenantID,
+		"priority":       domain.NotificationPriorityCritical,
+		"status":         bson.M{"$in": statuses},
+		"sentAt":         bson.M{"$gte": sentAfter, "$lte": sentBefore},
+		"acknowledgedAt": nil,
+		"deletedAt":      nil,
+		"metadata." + domain.EscalationMetadataKey: nil,
+	}
+	if step == 0 {
+		filter["escalationStep"] = nil
+	} else {
+		filter["escalationStep"] = step
+	}
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"escalationStep": step + 1,
+			"escalatedAt":    now,
+			"updatedAt":      now,
+		},
+		"$inc": bson.M{"version": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "sentAt", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var notification domain.Notification
+	err := r.client.CriticalCollection(notificationsCollection).FindOneAndUpdate(ctx, filter, update, opts).Decode(&notification)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &notification, nil
+}
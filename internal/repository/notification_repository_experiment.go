@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// VariantStats counts an A/B test's notifications by variant, ordered by variant name
+// Notifications are matched by the experiment and variant the A/B test sender puts in the request metadata.
+func (r *NotificationRepository) VariantStats(ctx context.Context, tenantID, experiment string) ([]domain.VariantStats, error) {
+	countIn := func(statuses []domain.NotificationStatus) bson.M {
+		return bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$in": bson.A{"$status", statuses}}, 1, 0}}}
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"tenantId": tenantID,
+			"metadata." + domain.ExperimentMetadataKey: experiment,
+			"deletedAt": nil,
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":            "$metadata." + domain.VariantMetadataKey,
+			"total":          bson.M{"$sum": 1},
+			"totalSent":      countIn(sentStatuses),
+			"totalDelivered": countIn(deliveredStatuses),
+			"totalFailed":    countIn([]domain.NotificationStatus{domain.NotificationStatusFailed, domain.NotificationStatusBounced}),
+			"totalRead":      countIn(readStatuses),
+			"totalClicked":   countIn([]domain.NotificationStatus{domain.NotificationStatusClicked}),
+		}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+
+	var stats []domain.VariantStats
+	err := retryRead(ctx, "notifications.variant_stats", func() error {
+		cursor, err := r.client.Collection(notificationsCollection).Aggregate(ctx, pipeline)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		stats = nil
+		return cursor.All(ctx, &stats)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range stats {
+		if sent := stats[i].TotalSent; sent > 0 {
+			stats[i].OpenRate = float64(stats[i].TotalRead) / float64(sent)
+			stats[i].ClickRate = float64(stats[i].TotalClicked) / float64(sent)
+		}
+	}
+	return stats, nil
+}
@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// FindByRecipient returns up to limit of a tenant's notifications sent to recipient, oldest
+// first, matched case-insensitively. Soft-deleted notifications are included because they
+// are still stored.
+func (r *NotificationRepository) FindByRecipient(ctx context.Context, tenantID, recipient string, limit int) ([]*domain.Notification, error) {
+	filter := bson.M{
+		"tenantId":  tenantID,
+		"recipient": recipientFilter(recipient),
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: 1}}).
+		SetLimit(int64(limit))
+
+	var notifications []*domain.Notification
+	err := retryRead(ctx, "notifications.find_by_recipient", func() error {
+		cursor, err := r.client.Collection(notificationsCollection).Find(ctx, filter, opts)
+		if err != nil {
+			return err
+		}
+		notifications = nil
+		return cursor.All(ctx, &notifications)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrExternalIDDeleted is returned when an upsert's external ID belongs to a deleted notification
+var ErrExternalIDDeleted = errors.New("external ID belongs to a deleted notification")
+
+// UpsertByExternalID creates the tenant's notification with notification.ExternalID, or replaces
+// the content of the existing one, and reports whether it was created. Status and Priority are
+// only changed when set, and default to pending and normal on create. The notification and its
+// created or updated outbox event are written atomically; on return, notification holds the
+// stored notification.
+func (r *NotificationRepository) UpsertByExternalID(ctx context.Context, notification *domain.Notification) (bool, error) {
+	created, err := r.upsertByExternalID(ctx, notification)
+	if mongo.IsDuplicateKeyError(err) {
+		// A concurrent upsert created the notification first; update it instead. A second
+		// conflict means a deleted notification still holds the ID.
+		created, err = r.upsertByExternalID(ctx, notification)
+		if mongo.IsDuplicateKeyError(err) {
+			return false, ErrExternalIDDeleted
+		}
+	}
+	return created, err
+}
+
+// upsertByExternalID makes one upsert attempt
+func (r *NotificationRepository) upsertByExternalID(ctx context.Context, notification *domain.Notification) (bool, error) {
+	now := time.Now()
+	id := primitive.NewObjectID()
+	filter := bson.M{
+		"tenantId":   notification.TenantID,
+		"externalId": notification.ExternalID,
+		"deletedAt":  nil,
+	}
+	update, updatedFields := externalIDUpdate(notification, id, now)
+
+	var created bool
+	upsert := func(ctx context.Context) ([]*domain.OutboxEvent, error) {
+		collection := r.client.CriticalCollection(notificationsCollection)
+
+		// The notification as it was before, to tell creates from updates and spot status changes
+		var previous domain.Notification
+		opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before)
+		err := collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&previous)
+		created = errors.Is(err, mongo.ErrNoDocuments)
+		if err != nil && !created {
+			return nil, err
+		}
+		if !created {
+			id = previous.ID
+		}
+		if err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(notification); err != nil {
+			return nil, err
+		}
+
+		if created {
+			event := r.createNotificationCreatedEvent(ctx, notification)
+			return []*domain.OutboxEvent{event}, r.recordStatus(ctx, statusEvent(id, notification.TenantID, notification.Status, now, nil))
+		}
+		events := []*domain.OutboxEvent{r.createNotificationUpdatedEvent(ctx, notification, updatedFields)}
+		if previous.Status == notification.Status {
+			return events, nil
+		}
+		events = append(events, r.createNotificationStatusChangedEvent(ctx, notification, previous.Status))
+		return events, r.recordStatus(ctx, statusEvent(id, notification.TenantID, notification.Status, now, nil))
+	}
+
+	// If outbox repository is not set, use simple upsert (backward compatibility)
+	if r.outboxRepo == nil {
+		_, err := upsert(ctx)
+		return created, err
+	}
+
+	// Write the change and its outbox events atomically
+	err := r.client.WithTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		events, err := upsert(sessCtx)
+		if err != nil {
+			return err
+		}
+		for _, event := range events {
+			if err := r.outboxRepo.CreateWithSession(ctx, sessCtx, event); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return created, err
+}
+
+// externalIDUpdate builds the upsert of notification, inserted with id at now, and lists the
+// fields an update replaces
+func externalIDUpdate(notification *domain.Notification, id primitive.ObjectID, now time.Time) (bson.M, []string) {
+	set := bson.M{
+		"type":      notification.Type,
+		"recipient": notification.Recipient,
+		"subject":   notification.Subject,
+		"body":      notification.Body,
+		"isHtml":    notification.IsHTML,
+		"payload":   notification.Payload,
+		"tags":      notification.Tags,
+		"category":  notification.Category,
+		"groupId":   notification.GroupID,
+		"metadata":  notification.Metadata,
+		"updatedAt": now,
+	}
+	insert := bson.M{
+		"_id":        id,
+		"retryCount": 0,
+		"createdAt":  now,
+	}
+	updatedFields := []string{"type", "recipient", "subject", "body", "is_html", "payload", "tags", "category", "group_id", "metadata"}
+
+	// Status and priority are kept on update unless the caller sets them
+	if notification.Status != "" {
+		set["status"] = notification.Status
+		updatedFields = append(updatedFields, "status")
+	} else {
+		insert["status"] = domain.NotificationStatusPending
+	}
+	if notification.Priority != "" {
+		set["priority"] = notification.Priority
+		updatedFields = append(updatedFields, "priority")
+	} else {
+		insert["priority"] = domain.NotificationPriorityNormal
+	}
+
+	return bson.M{
+		"$set":         set,
+		"$setOnInsert": insert,
+		"$inc":         bson.M{"version": 1},
+	}, updatedFields
+}
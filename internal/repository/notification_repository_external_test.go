@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestExternalIDUpdate(t *testing.T) {
+	id := primitive.NewObjectID()
+	now := time.Now()
+
+	update, fields := externalIDUpdate(&domain.Notification{Type: domain.NotificationTypeSMS, Recipient: "+15550100"}, id, now)
+	set, insert := update["$set"].(bson.M), update["$setOnInsert"].(bson.M)
+	if _, ok := set["status"]; ok {
+		t.Error("an unset status should be kept on update")
+	}
+	if insert["status"] != domain.NotificationStatusPending || insert["priority"] != domain.NotificationPriorityNormal || insert["_id"] != id {
+		t.Errorf("$setOnInsert = %v, want a pending, normal priority notification with the new ID", insert)
+	}
+	if len(fields) != 10 {
+		t.Errorf("updated fields = %v, want the content fields only", fields)
+	}
+
+	update, fields = externalIDUpdate(&domain.Notification{Status: domain.NotificationStatusSent, Priority: domain.NotificationPriorityHigh}, id, now)
+	set, insert = update["$set"].(bson.M), update["$setOnInsert"].(bson.M)
+	if set["status"] != domain.NotificationStatusSent || set["priority"] != domain.NotificationPriorityHigh {
+		t.Errorf("$set = %v, want the given status and priority", set)
+	}
+	if _, ok := insert["status"]; ok {
+		t.Error("a set status must not also be in $setOnInsert")
+	}
+	if fields[len(fields)-2] != "status" || fields[len(fields)-1] != "priority" {
+		t.Errorf("updated fields = %v, want status and priority listed", fields)
+	}
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// FindReplyParent finds the email notification an inbound reply answers: the one whose ID is
+// entityRefID, or else the one whose provider message id is nearest in messageIDs, which are
+// ordered nearest first. Inbound emails carry no tenant, so the lookup spans tenants.
+// Returns nil without error when nothing matches.
+func (r *NotificationRepository) FindReplyParent(ctx context.Context, entityRefID string, messageIDs []string) (*domain.Notification, error) {
+	// SMTP message ids are stored whole; SendGrid's are the local part of the Message-ID it sets
+	candidates := make([]string, 0, 2*len(messageIDs))
+	rank := make(map[string]int, 2*len(messageIDs))
+	for i, id := range messageIDs {
+		local, _, _ := strings.Cut(id, "@")
+		for _, candidate := range []string{id, local} {
+			if _, ok := rank[candidate]; !ok && candidate != "" {
+				rank[candidate] = i
+				candidates = append(candidates, candidate)
+			}
+		}
+	}
+
+	var or bson.A
+	objectID, refErr := primitive.ObjectIDFromHex(entityRefID)
+	if refErr == nil {
+		or = append(or, bson.M{"_id": objectID})
+	}
+	if len(candidates) > 0 {
+		or = append(or, bson.M{"providerMessageId": bson.M{"$in": candidates}})
+	}
+	if len(or) == 0 {
+		return nil, nil
+	}
+
+	filter := bson.M{
+		"$or":       or,
+		"type":      domain.NotificationTypeEmail,
+		"parentId":  bson.M{"$exists": false},
+		"deletedAt": nil,
+	}
+
+	var matches []*domain.Notification
+	err := retryRead(ctx, "notifications.find_reply_parent", func() error {
+		cursor, err := r.client.Collection(notificationsCollection).Find(ctx, filter)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		matches = nil
+		return cursor.All(ctx, &matches)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var best *domain.Notification
+	bestRank := len(messageIDs)
+	for _, match := range matches {
+		if refErr == nil && match.ID == objectID {
+			return match, nil
+		}
+		if i, ok := rank[match.ProviderMessageID]; ok && i < bestRank {
+			best, bestRank = match, i
+		}
+	}
+	return best, nil
+}
+
+// FindReplies finds the inbound replies to a notification, oldest first, with tenant isolation
+func (r *NotificationRepository) FindReplies(ctx context.Context, tenantID, parentID string, page, pageSize int) ([]*domain.Notification, int64, error) {
+	filter := bson.M{
+		"tenantId":  tenantID,
+		"parentId":  parentID,
+		"status":    domain.NotificationStatusReceived,
+		"deletedAt": nil,
+	}
+
+	skip := (page - 1) * pageSize
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$facet", Value: bson.M{
+			"metadata": bson.A{bson.M{"$count": "total"}},
+			"data": bson.A{
+				bson.M{"$sort": bson.M{"createdAt": 1}},
+				bson.M{"$skip": skip},
+				bson.M{"$limit": pageSize},
+			},
+		}}},
+	}
+
+	type Result struct {
+		Metadata []struct {
+			Total int64 `bson:"total"`
+		} `bson:"metadata"`
+		Data []*domain.Notification `bson:"data"`
+	}
+
+	var results []Result
+	err := retryRead(ctx, "notifications.find_replies", func() error {
+		cursor, err := r.client.Collection(notificationsCollection).Aggregate(ctx, pipeline)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		return cursor.All(ctx, &results)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(results) == 0 || len(results[0].Data) == 0 {
+		return []*domain.Notification{}, 0, nil
+	}
+
+	total := int64(0)
+	if len(results[0].Metadata) > 0 {
+		total = results[0].Metadata[0].Total
+	}
+	return results[0].Data, total, nil
+}
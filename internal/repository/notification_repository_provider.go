@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// SetProviderMessageID records which provider accepted a notification and the id it assigned
+func (r *NotificationRepository) SetProviderMessageID(ctx context.Context, id, tenantID, provider, providerMessageID string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{
+		"_id":       objectID,
+		"tenantId":  tenantID,
+		"deletedAt": nil,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"provider":          provider,
+			"providerMessageId": providerMessageID,
+			"updatedAt":         time.Now(),
+		},
+		"$inc": bson.M{"version": 1},
+	}
+
+	_, err = r.client.CriticalCollection(notificationsCollection).UpdateOne(ctx, filter, update)
+	return err
+}
+
+// FindByProviderMessageID finds the notification a provider delivery event refers to
+// Provider events carry no tenant, so the lookup is by provider and message id alone.
+func (r *NotificationRepository) FindByProviderMessageID(ctx context.Context, provider, providerMessageID string) (*domain.Notification, error) {
+	filter := bson.M{
+		"provider":          provider,
+		"providerMessageId": providerMessageID,
+		"deletedAt":         nil,
+	}
+
+	var notification domain.Notification
+	err := retryRead(ctx, "notifications.find_by_provider_message_id", func() error {
+		return r.client.Collection(notificationsCollection).FindOne(ctx, filter).Decode(&notification)
+	})
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &notification, nil
+}
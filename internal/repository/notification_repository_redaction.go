@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// RedactedContent replaces the subject and body of notifications redacted in place
+const RedactedContent = "[redacted]"
+
+// redactableStatuses are the terminal states whose stored content is no longer needed to send
+var redactableStatuses = []domain.NotificationStatus{
+	domain.NotificationStatusSent,
+	domain.NotificationStatusDelivered,
+	domain.NotificationStatusRead,
+	domain.NotificationStatusClicked,
+	domain.NotificationStatusBounced,
+	domain.NotificationStatusReceived,
+}
+
+// ContentRedaction selects the notifications whose content RedactContent removes
+type ContentRedaction struct {
+	TenantID          string
+	Categories        []string  // Only these categories; empty means any category
+	ExcludeCategories []string  // Categories to skip, for those handled by their own redaction
+	SentBefore        time.Time // Notifications sent (or, without a send time, created) before this
+	Drop              bool      // Unset subject and body instead of replacing them with RedactedContent
+}
+
+// RedactContent removes the subject, body and payload of a tenant's sent notifications that
+// are past the cutoff, keeping their metadata for reporting. It returns how many were redacted.
+func (r *NotificationRepository) RedactContent(ctx context.Context, redaction ContentRedaction) (int64, error) {
+	filter := bson.M{
+		"tenantId":          redaction.TenantID,
+		"status":            bson.M{"$in": redactableStatuses},
+		"contentRedactedAt": nil,
+		"$or": bson.A{
+			bson.M{"sentAt": bson.M{"$lt": redaction.SentBefore}},
+			bson.M{"sentAt": nil, "createdAt": bson.M{"$lt": redaction.SentBefore}},
+		},
+	}
+	category := bson.M{}
+	if len(redaction.Categories) > 0 {
+		category["$in"] = redaction.Categories
+	}
+	if len(redaction.ExcludeCategories) > 0 {
+		category["$nin"] = redaction.ExcludeCategories
+	}
+	if len(category) > 0 {
+		filter["category"] = category
+	}
+
+	now := time.Now()
+	set := bson.M{
+		"contentRedactedAt": now,
+		"updatedAt":         now,
+	}
+	unset := bson.M{"payload": ""}
+	if redaction.Drop {
+		unset["subject"] = ""
+		unset["body"] = ""
+	} else {
+		set["subject"] = RedactedContent
+		set["body"] = RedactedContent
+	}
+	update := bson.M{
+		"$set":   set,
+		"$unset": unset,
+		"$inc":   bson.M{"version": 1},
+	}
+
+	result, err := r.client.Collection(notificationsCollection).UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// FindRedactableTenantIDs returns the IDs of tenants with sent notifications whose content has not been redacted
+func (r *NotificationRepository) FindRedactableTenantIDs(ctx context.Context) ([]string, error) {
+	values, err := r.client.Collection(notificationsCollection).Distinct(ctx, "tenantId", bson.M{
+		"status":            bson.M{"$in": redactableStatuses},
+		"contentRedactedAt": nil,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tenantIDs := make([]string, 0, len(values))
+	for _, v := range values {
+		if id, ok := v.(string); ok {
+			tenantIDs = append(tenantIDs, id)
+		}
+	}
+	return tenantIDs, nil
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CountSentByTenant counts each tenant's notifications of channel sent at or after since.
+// Tenants that sent none are left out.
+func (r *NotificationRepository) CountSentByTenant(ctx context.Context, channel domain.NotificationType, since time.Time) (map[string]int64, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"type":   channel,
+			"sentAt": bson.M{"$gte": since},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   "$tenantId",
+			"count": bson.M{"$sum": 1},
+		}}},
+	}
+
+	var results []struct {
+		TenantID string `bson:"_id"`
+		Count    int64  `bson:"count"`
+	}
+	err := retryRead(ctx, "notifications.count_sent_by_tenant", func() error {
+		cursor, err := r.client.Collection(notificationsCollection).Aggregate(ctx, pipeline)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		results = nil
+		return cursor.All(ctx, &results)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(results))
+	for _, result := range results {
+		counts[result.TenantID] = result.Count
+	}
+	return counts, nil
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// FindIDsByScheduleExecution returns the IDs of the notifications a schedule execution created
+// Notifications are matched by the execution ID the scheduler puts in the request metadata.
+func (r *NotificationRepository) FindIDsByScheduleExecution(ctx context.Context, tenantID, executionID string) ([]string, error) {
+	filter := bson.M{
+		"tenantId": tenantID,
+		"metadata." + domain.ScheduleExecutionMetadataKey: executionID,
+	}
+	opts := options.Find().
+		SetProjection(bson.M{"_id": 1}).
+		SetSort(bson.D{{Key: "createdAt", Value: 1}})
+
+	var notifications []domain.Notification
+	err := retryRead(ctx, "notifications.find_by_schedule_execution", func() error {
+		cursor, err := r.client.Collection(notificationsCollection).Find(ctx, filter, opts)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		notifications = nil
+		return cursor.All(ctx, &notifications)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(notifications))
+	for _, notification := range notifications {
+		ids = append(ids, notification.ID.Hex())
+	}
+	return ids, nil
+}
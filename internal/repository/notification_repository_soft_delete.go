@@ -2,20 +2,37 @@ package repository
 
 import (
 	"context"
+	"regexp"
 	"time"
 
+	"github.com/vhvplatform/go-notification-service/internal/domain"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // SoftDelete marks a notification as deleted (soft delete) with tenant isolation
+// When an outbox repository is configured, a notification.deleted event is written atomically.
 func (r *NotificationRepository) SoftDelete(ctx context.Context, id string, tenantID string) error {
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return err
 	}
 
+	// Fetch notification before deletion to create event
+	var notification domain.Notification
+	if r.outboxRepo != nil {
+		err := r.client.Collection(notificationsCollection).FindOne(ctx, bson.M{
+			"_id":       objectID,
+			"tenantId":  tenantID,
+			"deletedAt": nil,
+		}).Decode(&notification)
+		if err != nil {
+			return err
+		}
+	}
+
 	now := time.Now()
 	filter := bson.M{
 		"_id":       objectID,
@@ -30,14 +47,38 @@ func (r *NotificationRepository) SoftDelete(ctx context.Context, id string, tena
 		"$inc": bson.M{"version": 1},
 	}
 
-	result, err := r.client.Collection(notificationsCollection).UpdateOne(ctx, filter, update)
-	if err != nil {
-		return err
-	}
-	if result.MatchedCount == 0 {
-		return mongo.ErrNoDocuments
+	// If outbox repository is not set, use simple update
+	if r.outboxRepo == nil {
+		result, err := r.client.Collection(notificationsCollection).UpdateOne(ctx, filter, update)
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return mongo.ErrNoDocuments
+		}
+		return nil
 	}
-	return nil
+
+	// Write the change and its outbox event atomically
+	return r.client.WithTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		// 1. Soft delete notification
+		result, err := r.client.Collection(notificationsCollection).UpdateOne(sessCtx, filter, update)
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return mongo.ErrNoDocuments
+		}
+
+		// 2. Create outbox event for deletion
+		notification.DeletedAt = &now
+		event := r.createNotificationDeletedEvent(ctx, &notification)
+		if err := r.outboxRepo.CreateWithSession(ctx, sessCtx, event); err != nil {
+			return err
+		}
+
+		return nil
+	})
 }
 
 // Restore restores a soft-deleted notification with tenant isolation
@@ -69,3 +110,182 @@ func (r *NotificationRepository) Restore(ctx context.Context, id string, tenantI
 	}
 	return nil
 }
+
+// HardDeleteSoftDeleted permanently removes a tenant's notifications soft-deleted before olderThan.
+// With dryRun set, nothing is deleted and the number of matching records is returned.
+func (r *NotificationRepository) HardDeleteSoftDeleted(ctx context.Context, tenantID string, olderThan time.Time, dryRun bool) (int64, error) {
+	filter := bson.M{
+		"tenantId":  tenantID,
+		"deletedAt": bson.M{"$ne": nil, "$lt": olderThan},
+	}
+
+	if dryRun {
+		return r.client.Collection(notificationsCollection).CountDocuments(ctx, filter)
+	}
+
+	result, err := r.client.Collection(notificationsCollection).DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+// FindSoftDeletedTenantIDs returns the IDs of tenants that have soft-deleted notifications
+func (r *NotificationRepository) FindSoftDeletedTenantIDs(ctx context.Context) ([]string, error) {
+	values, err := r.client.Collection(notificationsCollection).Distinct(ctx, "tenantId", bson.M{
+		"deletedAt": bson.M{"$ne": nil},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tenantIDs := make([]string, 0, len(values))
+	for _, v := range values {
+		if id, ok := v.(string); ok {
+			tenantIDs = append(tenantIDs, id)
+		}
+	}
+	return tenantIDs, nil
+}
+
+// recipientBatchSize bounds how many notifications one recipient deletion batch changes,
+// keeping each transaction and its outbox events small
+const recipientBatchSize = 500
+
+// recipientFilter matches a recipient address or phone number case-insensitively,
+// so erasure also covers notifications stored with different capitalization
+func recipientFilter(recipient string) primitive.Regex {
+	return primitive.Regex{Pattern: "^" + regexp.QuoteMeta(recipient) + "$", Options: "i"}
+}
+
+// SoftDeleteByRecipient soft deletes all of a tenant's notifications sent to recipient and
+// returns how many were deleted. A notification.deleted event is written for each one.
+func (r *NotificationRepository) SoftDeleteByRecipient(ctx context.Context, tenantID, recipient string) (int64, error) {
+	filter := bson.M{
+		"tenantId":  tenantID,
+		"recipient": recipientFilter(recipient),
+		"deletedAt": nil,
+	}
+
+	return r.eachRecipientBatch(ctx, tenantID, filter, false, func(ctx context.Context, ids []primitive.ObjectID) (int64, error) {
+		now := time.Now()
+		result, err := r.client.CriticalCollection(notificationsCollection).UpdateMany(ctx, bson.M{
+			"_id":       bson.M{"$in": ids},
+			"tenantId":  tenantID,
+			"deletedAt": nil,
+		}, bson.M{
+			"$set": bson.M{
+				"deletedAt": now,
+				"updatedAt": now,
+			},
+			"$inc": bson.M{"version": 1},
+		})
+		if err != nil {
+			return 0, err
+		}
+		return result.ModifiedCount, nil
+	})
+}
+
+// EraseByRecipient permanently deletes all of a tenant's notifications sent to recipient,
+// including soft-deleted ones, for right-to-be-forgotten requests. Their status history is
+// deleted and the recipient and subject are removed from their earlier outbox event payloads.
+// A notification.deleted event marked as an erasure is written for each one.
+func (r *NotificationRepository) EraseByRecipient(ctx context.Context, tenantID, recipient string) (int64, error) {
+	filter := bson.M{
+		"tenantId":  tenantID,
+		"recipient": recipientFilter(recipient),
+	}
+
+	return r.eachRecipientBatch(ctx, tenantID, filter, true, func(ctx context.Context, ids []primitive.ObjectID) (int64, error) {
+		result, err := r.client.CriticalCollection(notificationsCollection).DeleteMany(ctx, bson.M{
+			"_id":      bson.M{"$in": ids},
+			"tenantId": tenantID,
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		hexIDs := make([]string, len(ids))
+		for i, id := range ids {
+			hexIDs[i] = id.Hex()
+		}
+		if r.events != nil {
+			if _, err := r.events.DeleteByNotificationIDs(ctx, tenantID, hexIDs); err != nil {
+				return 0, err
+			}
+		}
+		if r.outboxRepo != nil {
+			if _, err := r.outboxRepo.ScrubNotificationPayloads(ctx, tenantID, hexIDs); err != nil {
+				return 0, err
+			}
+		}
+		return result.DeletedCount, nil
+	})
+}
+
+// eachRecipientBatch applies fn to the IDs of notifications matching filter, a batch at a time,
+// until none are left. fn must change the batch so it no longer matches filter. When an outbox
+// repository is configured each batch runs in a transaction that also writes a
+// notification.deleted event per notification, marked as an erasure when erased is set.
+func (r *NotificationRepository) eachRecipientBatch(ctx context.Context, tenantID string, filter bson.M, erased bool, fn func(ctx context.Context, ids []primitive.ObjectID) (int64, error)) (int64, error) {
+	opts := options.Find().SetProjection(bson.M{"_id": 1}).SetLimit(recipientBatchSize)
+
+	var total int64
+	for {
+		var docs []struct {
+			ID primitive.ObjectID `bson:"_id"`
+		}
+		cursor, err := r.client.Collection(notificationsCollection).Find(ctx, filter, opts)
+		if err != nil {
+			return total, err
+		}
+		if err := cursor.All(ctx, &docs); err != nil {
+			return total, err
+		}
+		if len(docs) == 0 {
+			return total, nil
+		}
+
+		ids := make([]primitive.ObjectID, len(docs))
+		for i, doc := range docs {
+			ids[i] = doc.ID
+		}
+
+		var changed int64
+		if r.outboxRepo == nil {
+			changed, err = fn(ctx, ids)
+		} else {
+			err = r.client.WithTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+				var err error
+				if changed, err = fn(sessCtx, ids); err != nil {
+					return err
+				}
+				return r.recordDeleted(ctx, sessCtx, tenantID, ids, erased)
+			})
+		}
+		if err != nil {
+			return total, err
+		}
+		total += changed
+		if changed == 0 {
+			// Another request removed this batch first; stop rather than loop on stale reads
+			return total, nil
+		}
+	}
+}
+
+// recordDeleted writes a notification.deleted outbox event for each deleted notification
+func (r *NotificationRepository) recordDeleted(ctx context.Context, sessCtx mongo.SessionContext, tenantID string, ids []primitive.ObjectID, erased bool) error {
+	deletedAt := time.Now()
+	for _, id := range ids {
+		event := r.createNotificationDeletedEvent(ctx, &domain.Notification{ID: id, TenantID: tenantID, DeletedAt: &deletedAt})
+		payload := event.Payload.(domain.NotificationDeletedPayload)
+		payload.Erased = erased
+		event.Payload = payload
+		if err := r.outboxRepo.CreateWithSession(ctx, sessCtx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// statusProjection limits status lookups to the fields of domain.NotificationStatusResult
+var statusProjection = bson.M{
+	"type":           1,
+	"status":         1,
+	"error":          1,
+	"retryCount":     1,
+	"sentAt":         1,
+	"deliveredAt":    1,
+	"readAt":         1,
+	"clickedAt":      1,
+	"acknowledgedAt": 1,
+	"createdAt":      1,
+	"updatedAt":      1,
+	"recipients":     1,
+}
+
+// FindStatuses finds the current status of the given notifications in one query with tenant
+// isolation. IDs that aren't valid or don't match a notification are omitted; results are unordered.
+func (r *NotificationRepository) FindStatuses(ctx context.Context, tenantID string, ids []string) ([]*domain.NotificationStatusResult, error) {
+	objectIDs := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		if objectID, err := primitive.ObjectIDFromHex(id); err == nil {
+			objectIDs = append(objectIDs, objectID)
+		}
+	}
+	if len(objectIDs) == 0 {
+		return nil, nil
+	}
+
+	filter := bson.M{
+		"_id":       bson.M{"$in": objectIDs},
+		"tenantId":  tenantID,
+		"deletedAt": nil,
+	}
+	opts := options.Find().SetProjection(statusProjection)
+
+	var results []*domain.NotificationStatusResult
+	err := retryRead(ctx, "notifications.find_statuses", func() error {
+		cursor, err := r.client.Collection(notificationsCollection).Find(ctx, filter, opts)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		results = nil
+		return cursor.All(ctx, &results)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// stuckFilter matches a tenant's notifications left in one of statuses since before updatedBefore
+func stuckFilter(tenantID string, statuses []domain.NotificationStatus, updatedBefore time.Time) bson.M {
+	filter := bson.M{
+		"status":    bson.M{"$in": statuses},
+		"updatedAt": bson.M{"$lt": updatedBefore},
+		"deletedAt": nil,
+	}
+	if tenantID != "" {
+		filter["tenantId"] = tenantID
+	}
+	return filter
+}
+
+// FindStuckTenantIDs returns the IDs of tenants with notifications left in one of statuses
+// since before updatedBefore
+func (r *NotificationRepository) FindStuckTenantIDs(ctx context.Context, statuses []domain.NotificationStatus, updatedBefore time.Time) ([]string, error) {
+	var values []interface{}
+	err := retryRead(ctx, "notifications.distinct_stuck_tenants", func() error {
+		var err error
+		values, err = r.client.Collection(notificationsCollection).Distinct(ctx, "tenantId", stuckFilter("", statuses, updatedBefore))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tenantIDs := make([]string, 0, len(values))
+	for _, v := range values {
+		if id, ok := v.(string); ok {
+			tenantIDs = append(tenantIDs, id)
+		}
+	}
+	return tenantIDs, nil
+}
+
+// ClaimStuck marks one of the tenant's notifications left in one of statuses since before
+// updatedBefore failed with errorMsg, writing its status change event, and returns it as it
+// was before. Concurrent callers never claim the same notification. Returns nil without error
+// when none is stuck.
+func (r *NotificationRepository) ClaimStuck(ctx context.Context, tenantID string, statuses []domain.NotificationStatus, updatedBefore time.Time, errorMsg string) (*domain.Notification, error) {
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"status":    domain.NotificationStatusFailed,
+			"error":     errorMsg,
+			"updatedAt": now,
+		},
+		"$inc": bson.M{"version": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "updatedAt", Value: 1}}).
+		SetReturnDocument(options.Before)
+	detail := map[string]string{"error": errorMsg}
+
+	var claimed *domain.Notification
+	claim := func(ctx context.Context) error {
+		claimed = nil
+		var notification domain.Notification
+		err := r.client.CriticalCollection(notificationsCollection).FindOneAndUpdate(ctx, stuckFilter(tenantID, statuses, updatedBefore), update, opts).Decode(&notification)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		claimed = &notification
+		return nil
+	}
+
+	if r.outboxRepo == nil {
+		if err := claim(ctx); err != nil || claimed == nil {
+			return nil, err
+		}
+		return claimed, r.recordStatus(ctx, statusEvent(claimed.ID, tenantID, domain.NotificationStatusFailed, now, detail))
+	}
+
+	// Write the change and its outbox event atomically
+	err := r.client.WithTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		if err := claim(sessCtx); err != nil || claimed == nil {
+			return err
+		}
+
+		failed := *claimed
+		failed.Status = domain.NotificationStatusFailed
+		failed.Error = errorMsg
+		failed.UpdatedAt = now
+		event := r.createNotificationStatusChangedEvent(ctx, &failed, claimed.Status)
+		if err := r.outboxRepo.CreateWithSession(ctx, sessCtx, event); err != nil {
+			return err
+		}
+
+		return r.recordStatus(sessCtx, statusEvent(claimed.ID, tenantID, domain.NotificationStatusFailed, now, detail))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claimed, nil
+}
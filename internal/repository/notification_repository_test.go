@@ -1,101 +1,101 @@
-package repository
-
-import (
-	"context"
-	"testing"
-	"time"
-
-	"github.com/vhvplatform/go-notification-service/internal/domain"
-)
-
-// BenchmarkCreateBatch benchmarks batch notification creation
-func BenchmarkCreateBatch(b *testing.B) {
-	// Skip if MongoDB is not available
-	b.Skip("Requires MongoDB connection")
-
-	// This benchmark would be run in an environment with MongoDB
-	notifications := make([]*domain.Notification, 100)
-	for i := 0; i < 100; i++ {
-		notifications[i] = &domain.Notification{
-			TenantID:  "test-tenant",
-			Type:      domain.NotificationTypeEmail,
-			Status:    domain.NotificationStatusPending,
-			Recipient: "test@example.com",
-			Subject:   "Test Subject",
-			Body:      "Test Body",
-		}
-	}
-
-	ctx := context.Background()
-	b.ResetTimer()
-
-	for i := 0; i < b.N; i++ {
-		// repo.CreateBatch(ctx, notifications)
-		_ = ctx
-		_ = notifications
-	}
-}
-
-// BenchmarkCreate benchmarks single notification creation (for comparison)
-func BenchmarkCreate(b *testing.B) {
-	// Skip if MongoDB is not available
-	b.Skip("Requires MongoDB connection")
-
-	notification := &domain.Notification{
-		TenantID:  "test-tenant",
-		Type:      domain.NotificationTypeEmail,
-		Status:    domain.NotificationStatusPending,
-		Recipient: "test@example.com",
-		Subject:   "Test Subject",
-		Body:      "Test Body",
-	}
-
-	ctx := context.Background()
-	b.ResetTimer()
-
-	for i := 0; i < b.N; i++ {
-		// repo.Create(ctx, notification)
-		_ = ctx
-		_ = notification
-	}
-}
-
-// TestCreateBatch tests batch creation functionality
-func TestCreateBatch(t *testing.T) {
-	t.Skip("Requires MongoDB connection - integration test")
-
-	// This would be a full integration test
-	notifications := []*domain.Notification{
-		{
-			TenantID:  "test-tenant",
-			Type:      domain.NotificationTypeEmail,
-			Status:    domain.NotificationStatusPending,
-			Recipient: "test1@example.com",
-			Subject:   "Test Subject 1",
-			Body:      "Test Body 1",
-		},
-		{
-			TenantID:  "test-tenant",
-			Type:      domain.NotificationTypeEmail,
-			Status:    domain.NotificationStatusPending,
-			Recipient: "test2@example.com",
-			Subject:   "Test Subject 2",
-			Body:      "Test Body 2",
-		},
-	}
-
-	if len(notifications) != 2 {
-		t.Errorf("Expected 2 notifications, got %d", len(notifications))
-	}
-}
-
-// TestFindByTenantIDOptimized tests the optimized pagination
-func TestFindByTenantIDOptimized(t *testing.T) {
-	t.Skip("Requires MongoDB connection - integration test")
-
-	// This would test the aggregation pipeline approach
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	_ = ctx
-}
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+)
+
+// BenchmarkCreateBatch benchmarks batch notification creation
+func BenchmarkCreateBatch(b *testing.B) {
+	// Skip if MongoDB is not available
+	b.Skip("Requires MongoDB connection")
+
+	// This benchmark would be run in an environment with MongoDB
+	notifications := make([]*domain.Notification, 100)
+	for i := 0; i < 100; i++ {
+		notifications[i] = &domain.Notification{
+			TenantID:  "test-tenant",
+			Type:      domain.NotificationTypeEmail,
+			Status:    domain.NotificationStatusPending,
+			Recipient: "test@example.com",
+			Subject:   "Test Subject",
+			Body:      "Test Body",
+		}
+	}
+
+	ctx := context.Background()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		// repo.CreateBatch(ctx, notifications)
+		_ = ctx
+		_ = notifications
+	}
+}
+
+// BenchmarkCreate benchmarks single notification creation (for comparison)
+func BenchmarkCreate(b *testing.B) {
+	// Skip if MongoDB is not available
+	b.Skip("Requires MongoDB connection")
+
+	notification := &domain.Notification{
+		TenantID:  "test-tenant",
+		Type:      domain.NotificationTypeEmail,
+		Status:    domain.NotificationStatusPending,
+		Recipient: "test@example.com",
+		Subject:   "Test Subject",
+		Body:      "Test Body",
+	}
+
+	ctx := context.Background()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		// repo.Create(ctx, notification)
+		_ = ctx
+		_ = notification
+	}
+}
+
+// TestCreateBatch tests batch creation functionality
+func TestCreateBatch(t *testing.T) {
+	t.Skip("Requires MongoDB connection - integration test")
+
+	// This would be a full integration test
+	notifications := []*domain.Notification{
+		{
+			TenantID:  "test-tenant",
+			Type:      domain.NotificationTypeEmail,
+			Status:    domain.NotificationStatusPending,
+			Recipient: "test1@example.com",
+			Subject:   "Test Subject 1",
+			Body:      "Test Body 1",
+		},
+		{
+			TenantID:  "test-tenant",
+			Type:      domain.NotificationTypeEmail,
+			Status:    domain.NotificationStatusPending,
+			Recipient: "test2@example.com",
+			Subject:   "Test Subject 2",
+			Body:      "Test Body 2",
+		},
+	}
+
+	if len(notifications) != 2 {
+		t.Errorf("Expected 2 notifications, got %d", len(notifications))
+	}
+}
+
+// TestFindByTenantIDOptimized tests the optimized pagination
+func TestFindByTenantIDOptimized(t *testing.T) {
+	t.Skip("Requires MongoDB connection - integration test")
+
+	// This would test the aggregation pipeline approach
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_ = ctx
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// TestFindStatuses verifies statuses are found only for the tenant's live notifications, skipping invalid IDs
+func TestFindStatuses(t *testing.T) {
+	t.Skip("Requires MongoDB connection - run with integration test suite")
+
+	client := setupTestMongoDB(t)
+	defer teardownTestMongoDB(t, client)
+	ctx := context.Background()
+	defer func() { _ = client.Collection(notificationsCollection).Drop(ctx) }()
+
+	repo := NewNotificationRepository(client, nil)
+	sent := &domain.Notification{TenantID: "tenant-1", Type: domain.NotificationTypeEmail, Recipient: "jane@example.com", Status: domain.NotificationStatusSent}
+	deleted := &domain.Notification{TenantID: "tenant-1", Type: domain.NotificationTypeEmail, Recipient: "jane@example.com", Status: domain.NotificationStatusSent}
+	other := &domain.Notification{TenantID: "tenant-2", Type: domain.NotificationTypeSMS, Recipient: "+15550100", Status: domain.NotificationStatusSent}
+	for _, notification := range []*domain.Notification{sent, deleted, other} {
+		require.NoError(t, repo.Create(ctx, notification))
+	}
+	_, err := client.Collection(notificationsCollection).UpdateOne(ctx, bson.M{"_id": deleted.ID}, bson.M{"$set": bson.M{"deletedAt": time.Now()}})
+	require.NoError(t, err)
+
+	results, err := repo.FindStatuses(ctx, "tenant-1", []string{sent.ID.Hex(), deleted.ID.Hex(), other.ID.Hex(), "not-an-id"})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, sent.ID, results[0].ID)
+	assert.Equal(t, domain.NotificationStatusSent, results[0].Status)
+
+	results, err = repo.FindStatuses(ctx, "tenant-1", []string{"not-an-id"})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+// TestUpdateRecipients verifies a partial delivery's recipient results are reported with the status, with tenant isolation
+func TestUpdateRecipients(t *testing.T) {
+	t.Skip("Requires MongoDB connection - run with integration test suite")
+
+	client := setupTestMongoDB(t)
+	defer teardownTestMongoDB(t, client)
+	ctx := context.Background()
+	defer func() { _ = client.Collection(notificationsCollection).Drop(ctx) }()
+
+	repo := NewNotificationRepository(client, nil)
+	notification := &domain.Notification{TenantID: "tenant-1", Type: domain.NotificationTypeEmail, Recipient: "jane@example.com", Status: domain.NotificationStatusSent}
+	require.NoError(t, repo.Create(ctx, notification))
+	id := notification.ID.Hex()
+
+	recipients := []domain.EmailRecipientResult{
+		{Recipient: "jane@example.com", Status: domain.NotificationStatusSent},
+		{Recipient: "bob@example.com", Status: domain.NotificationStatusFailed, Error: "550 mailbox unavailable"},
+	}
+	require.NoError(t, repo.UpdateRecipients(ctx, id, "tenant-1", recipients))
+	// Another tenant's update doesn't match
+	require.NoError(t, repo.UpdateRecipients(ctx, id, "tenant-2", nil))
+
+	results, err := repo.FindStatuses(ctx, "tenant-1", []string{id})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, recipients, results[0].Recipients)
+}
@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/shared/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const orchestrationsCollection = "notification_orchestrations"
+
+// orchestrationRetention is how long finished orchestrations are kept
+const orchestrationRetention = 30 * 24 * time.Hour
+
+// OrchestrationRepository stores escalating multi-channel notifications
+type OrchestrationRepository struct {
+	client *mongodb.MongoClient
+}
+
+// NewOrchestrationRepository creates a new orchestration repository
+func NewOrchestrationRepository(client *mongodb.MongoClient) *OrchestrationRepository {
+	return &OrchestrationRepository{client: client}
+}
+
+// EnsureIndexes creates necessary indexes for optimal query performance
+func (r *OrchestrationRepository) EnsureIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "tenantId", Value: 1},
+				{Key: "idempotencyKey", Value: 1},
+			},
+			Options: options.Index().SetName("tenant_idempotency_key_idx").SetUnique(true),
+		},
+		{
+			Keys: bson.D{
+				{Key: "status", Value: 1},
+				{Key: "dueAt", Value: 1},
+			},
+			Options: options.Index().SetName("status_due_at_idx"),
+		},
+		{
+			Keys: bson.D{{Key: "completedAt", Value: 1}},
+			Options: options.Index().
+				SetName("completed_at_idx").
+				SetExpireAfterSeconds(int32(orchestrationRetention.Seconds())), // TTL index
+		},
+	}
+	return r.client.CreateIndexes(ctx, orchestrationsCollection, indexes)
+}
+
+// Create stores an orchestration, or returns the one already stored under its idempotency key
+func (r *OrchestrationRepository) Create(ctx context.Context, orchestration *domain.Orchestration) (*domain.Orchestration, error) {
+	now := time.Now()
+	orchestration.ID = primitive.NewObjectID()
+	orchestration.CreatedAt = now
+	orchestration.UpdatedAt = now
+
+	filter := bson.M{
+		"tenantId":       orchestration.TenantID,
+		"idempotencyKey": orchestration.IdempotencyKey,
+	}
+	opts := options.FindOneAndUpdate().
+		SetUpsert(true).
+		SetReturnDocument(options.After)
+
+	var stored domain.Orchestration
+	err := r.client.CriticalCollection(orchestrationsCollection).FindOneAndUpdate(ctx, filter, bson.M{"$setOnInsert": orchestration}, opts).Decode(&stored)
+	if err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+// FindByID finds an orchestration with tenant isolation
+// Returns nil without error when there is no such orchestration.
+func (r *OrchestrationRepository) FindByID(ctx context.Context, tenantID, id string) (*domain.Orchestration, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, nil
+	}
+
+	var orchestration domain.Orchestration
+	err = retryRead(ctx, "orchestrations.find", func() error {
+		return r.client.Collection(orchestrationsCollection).FindOne(ctx, bson.M{"_id": objectID, "tenantId": tenantID}).Decode(&orchestration)
+	})
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &orchestration, nil
+}
+
+// ClaimDue claims the most overdue active orchestration for escalation
+// Orchestrations claimed more than staleAfter ago are claimed again, so one whose
+// escalation died is not lost. It returns nil if none is due.
+func (r *OrchestrationRepository) ClaimDue(ctx context.Context, now time.Time, staleAfter time.Duration) (*domain.Orchestration, error) {
+	filter := bson.M{
+		"$or": bson.A{
+			bson.M{"status": domain.OrchestrationStatusActive, "dueAt": bson.M{"$lte": now}},
+			bson.M{"status": domain.OrchestrationStatusEscalating, "claimedAt": bson.M{"$lte": now.Add(-staleAfter)}},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":    domain.OrchestrationStatusEscalating,
+			"claimedAt": now,
+			"updatedAt": now,
+		},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "dueAt", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var orchestration domain.Orchestration
+	err := r.client.Collection(orchestrationsCollection).FindOneAndUpdate(ctx, filter, update, opts).Decode(&orchestration)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &orchestration, nil
+}
+
+// Advance records the channels tried by an escalation and reopens the orchestration to
+// escalate to channel next at dueAt
+func (r *OrchestrationRepository) Advance(ctx context.Context, id primitive.ObjectID, results []domain.BatchItemResult, next int, dueAt time.Time) error {
+	update := bson.M{
+		"$set": bson.M{
+			"status":    domain.OrchestrationStatusActive,
+			"results":   results,
+			"next":      next,
+			"dueAt":     dueAt,
+			"updatedAt": time.Now(),
+		},
+		"$unset": bson.M{"claimedAt": ""},
+	}
+	filter := bson.M{"_id": id, "status": domain.OrchestrationStatusEscalating}
+	_, err := r.client.CriticalCollection(orchestrationsCollection).UpdateOne(ctx, filter, update)
+	return err
+}
+
+// Complete finishes a claimed orchestration with status and the channels it tried
+func (r *OrchestrationRepository) Complete(ctx context.Context, id primitive.ObjectID, status domain.OrchestrationStatus, results []domain.BatchItemResult) error {
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"status":      status,
+			"results":     results,
+			"completedAt": now,
+			"updatedAt":   now,
+		},
+	}
+	filter := bson.M{"_id": id, "status": domain.OrchestrationStatusEscalating}
+	_, err := r.client.CriticalCollection(orchestrationsCollection).UpdateOne(ctx, filter, update)
+	return err
+}
+
+// Acknowledge stops an orchestration from escalating further
+// Returns nil without error when there is no such orchestration. An orchestration that
+// already finished is returned unchanged.
+func (r *OrchestrationRepository) Acknowledge(ctx context.Context, tenantID, id string) (*domain.Orchestration, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, nil
+	}
+
+	now := time.Now()
+	filter := bson.M{
+		"_id":      objectID,
+		"tenantId": tenantID,
+		"status":   bson.M{"$in": bson.A{domain.OrchestrationStatusActive, domain.OrchestrationStatusEscalating}},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":      domain.OrchestrationStatusAcknowledged,
+			"completedAt": now,
+			"updatedAt":   now,
+		},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var orchestration domain.Orchestration
+	err = r.client.CriticalCollection(orchestrationsCollection).FindOneAndUpdate(ctx, filter, update, opts).Decode(&orchestration)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return r.FindByID(ctx, tenantID, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &orchestration, nil
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/metrics"
 	"github.com/vhvplatform/go-notification-service/internal/shared/mongodb"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -18,7 +19,8 @@ const outboxEventsCollection = "outbox_events"
 // OutboxEventRepository handles outbox event data operations
 // This repository is critical for Transactional Outbox Pattern (Phase 2)
 type OutboxEventRepository struct {
-	client *mongodb.MongoClient
+	client   *mongodb.MongoClient
+	payloads domain.OutboxPayloadOptions
 }
 
 // NewOutboxEventRepository creates a new outbox event repository
@@ -75,8 +77,11 @@ func (r *OutboxEventRepository) Create(ctx context.Context, event *domain.Outbox
 	if event.Status == "" {
 		event.Status = domain.OutboxEventStatusPending
 	}
+	if err := encodePayload(event, r.payloads); err != nil {
+		return err
+	}
 
-	_, err := r.client.Collection(outboxEventsCollection).InsertOne(ctx, event)
+	_, err := r.client.CriticalCollection(outboxEventsCollection).InsertOne(ctx, event)
 	return err
 }
 
@@ -94,11 +99,49 @@ func (r *OutboxEventRepository) CreateWithSession(ctx context.Context, session m
 	if event.Status == "" {
 		event.Status = domain.OutboxEventStatusPending
 	}
+	if err := encodePayload(event, r.payloads); err != nil {
+		return err
+	}
 
-	_, err := r.client.Collection(outboxEventsCollection).InsertOne(session, event)
+	_, err := r.client.CriticalCollection(outboxEventsCollection).InsertOne(session, event)
 	return err
 }
 
+// ScrubNotificationPayloads removes the recipient and subject from the payloads of the
+// given notifications' outbox events, with tenant isolation. Compressed payloads can't be
+// edited in place, so they are removed whole.
+func (r *OutboxEventRepository) ScrubNotificationPayloads(ctx context.Context, tenantID string, notificationIDs []string) (int64, error) {
+	filter := bson.M{
+		"tenantId":        tenantID,
+		"aggregateType":   "notification",
+		"aggregateId":     bson.M{"$in": notificationIDs},
+		"payloadEncoding": bson.M{"$ne": domain.OutboxPayloadGzip},
+	}
+	update := bson.M{
+		"$unset": bson.M{
+			"payload.recipient": "",
+			"payload.subject":   "",
+		},
+		"$set": bson.M{"updatedAt": time.Now()},
+	}
+
+	collection := r.client.CriticalCollection(outboxEventsCollection)
+	result, err := collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+
+	filter["payloadEncoding"] = domain.OutboxPayloadGzip
+	compressed, err := collection.UpdateMany(ctx, filter, bson.M{
+		"$unset": bson.M{"payload": "", "payloadEncoding": ""},
+		"$set":   bson.M{"updatedAt": time.Now()},
+	})
+	if err != nil {
+		return result.ModifiedCount, err
+	}
+	return result.ModifiedCount + compressed.ModifiedCount, nil
+}
+
 // FindUnprocessed retrieves all pending events for processing by Debezium
 func (r *OutboxEventRepository) FindUnprocessed(ctx context.Context, tenantID string, limit int) ([]*domain.OutboxEvent, error) {
 	filter := bson.M{
@@ -125,6 +168,73 @@ func (r *OutboxEventRepository) FindUnprocessed(ctx context.Context, tenantID st
 	return events, nil
 }
 
+// FindPending retrieves the oldest pending events of every tenant for the outbox relay.
+// Events created in the same millisecond are ordered by ID, which increases with creation.
+func (r *OutboxEventRepository) FindPending(ctx context.Context, limit int) ([]*domain.OutboxEvent, error) {
+	filter := bson.M{
+		"status":    domain.OutboxEventStatusPending,
+		"deletedAt": nil,
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(int64(limit))
+
+	var events []*domain.OutboxEvent
+	err := retryRead(ctx, "outbox_events.find_pending", func() error {
+		cursor, err := r.client.Collection(outboxEventsCollection).Find(ctx, filter, opts)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		events = nil
+		return cursor.All(ctx, &events)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// RecordFailure records a failed publish attempt, leaving the event pending so it is retried
+// before any later event for its aggregate
+func (r *OutboxEventRepository) RecordFailure(ctx context.Context, id string, tenantID string, errorMsg string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"lastError": errorMsg,
+			"updatedAt": time.Now(),
+		},
+		"$inc": bson.M{
+			"version":    1,
+			"errorCount": 1,
+		},
+	}
+
+	filter := bson.M{
+		"_id":       objectID,
+		"tenantId":  tenantID,
+		"deletedAt": nil,
+	}
+
+	var event domain.OutboxEvent
+	err = r.client.CriticalCollection(outboxEventsCollection).FindOneAndUpdate(ctx, filter, update, outboxMetricsProjection()).Decode(&event)
+	if err == mongo.ErrNoDocuments {
+		return fmt.Errorf("outbox event not found or already deleted")
+	}
+	if err != nil {
+		return err
+	}
+
+	metrics.OutboxEventsRetried.WithLabelValues(string(event.EventType)).Inc()
+	return nil
+}
+
 // MarkProcessed marks an outbox event as processed by Debezium
 func (r *OutboxEventRepository) MarkProcessed(ctx context.Context, id string, tenantID string) error {
 	objectID, err := primitive.ObjectIDFromHex(id)
@@ -148,13 +258,18 @@ func (r *OutboxEventRepository) MarkProcessed(ctx context.Context, id string, te
 		"deletedAt": nil,
 	}
 
-	result, err := r.client.Collection(outboxEventsCollection).UpdateOne(ctx, filter, update)
+	// Return the previous document so processing metrics can be recorded
+	var event domain.OutboxEvent
+	err = r.client.CriticalCollection(outboxEventsCollection).FindOneAndUpdate(ctx, filter, update, outboxMetricsProjection()).Decode(&event)
+	if err == mongo.ErrNoDocuments {
+		return fmt.Errorf("outbox event not found or already deleted")
+	}
 	if err != nil {
 		return err
 	}
 
-	if result.MatchedCount == 0 {
-		return fmt.Errorf("outbox event not found or already deleted")
+	if event.CreatedAt != nil {
+		metrics.OutboxProcessingDuration.WithLabelValues(string(event.EventType)).Observe(now.Sub(*event.CreatedAt).Seconds())
 	}
 
 	return nil
@@ -186,19 +301,60 @@ func (r *OutboxEventRepository) MarkFailed(ctx context.Context, id string, tenan
 		"deletedAt": nil,
 	}
 
-	result, err := r.client.Collection(outboxEventsCollection).UpdateOne(ctx, filter, update)
+	var event domain.OutboxEvent
+	err = r.client.CriticalCollection(outboxEventsCollection).FindOneAndUpdate(ctx, filter, update, outboxMetricsProjection()).Decode(&event)
+	if err == mongo.ErrNoDocuments {
+		return fmt.Errorf("outbox event not found or already deleted")
+	}
 	if err != nil {
 		return err
 	}
 
-	if result.MatchedCount == 0 {
-		return fmt.Errorf("outbox event not found or already deleted")
-	}
+	metrics.OutboxEventsFailed.WithLabelValues(string(event.EventType)).Inc()
 
 	return nil
 }
 
+// outboxMetricsProjection limits FindOneAndUpdate results to the fields used for metrics
+func outboxMetricsProjection() *options.FindOneAndUpdateOptions {
+	return options.FindOneAndUpdate().
+		SetReturnDocument(options.Before).
+		SetProjection(bson.M{"eventType": 1, "createdAt": 1})
+}
+
+// GetPendingStats returns the pending event count and oldest pending event per tenant
+// Used by the outbox monitor to detect relay stalls across all tenants.
+func (r *OutboxEventRepository) GetPendingStats(ctx context.Context) ([]*domain.OutboxPendingStats, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"status":    domain.OutboxEventStatusPending,
+			"deletedAt": nil,
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":             "$tenantId",
+			"count":           bson.M{"$sum": 1},
+			"oldestCreatedAt": bson.M{"$min": "$createdAt"},
+		}}},
+	}
+
+	var stats []*domain.OutboxPendingStats
+	err := retryRead(ctx, "outbox_events.pending_stats", func() error {
+		cursor, err := r.client.Collection(outboxEventsCollection).Aggregate(ctx, pipeline)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		return cursor.All(ctx, &stats)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
 // FindByTraceID finds all events associated with a specific trace ID (for debugging)
+// Payloads are returned as their payload structs; see DecodePayload.
 func (r *OutboxEventRepository) FindByTraceID(ctx context.Context, traceID string, tenantID string) ([]*domain.OutboxEvent, error) {
 	filter := bson.M{
 		"traceId":   traceID,
@@ -216,11 +372,15 @@ func (r *OutboxEventRepository) FindByTraceID(ctx context.Context, traceID strin
 	if err = cursor.All(ctx, &events); err != nil {
 		return nil, err
 	}
+	if err := decodePayloads(events); err != nil {
+		return nil, err
+	}
 
 	return events, nil
 }
 
 // FindByAggregateID finds all events for a specific aggregate (e.g., all events for notification X)
+// Payloads are returned as their payload structs; see DecodePayload.
 func (r *OutboxEventRepository) FindByAggregateID(ctx context.Context, aggregateType string, aggregateID string, tenantID string) ([]*domain.OutboxEvent, error) {
 	filter := bson.M{
 		"aggregateType": aggregateType,
@@ -241,6 +401,9 @@ func (r *OutboxEventRepository) FindByAggregateID(ctx context.Context, aggregate
 	if err = cursor.All(ctx, &events); err != nil {
 		return nil, err
 	}
+	if err := decodePayloads(events); err != nil {
+		return nil, err
+	}
 
 	return events, nil
 }
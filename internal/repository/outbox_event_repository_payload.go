@@ -0,0 +1,218 @@
+package repository
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrUnknownOutboxEventType is returned when decoding the payload of an event type without a
+// payload struct
+var ErrUnknownOutboxEventType = errors.New("unknown outbox event type")
+
+// aggregateCollections maps outbox aggregate types to the collection holding the aggregate,
+// which an oversized payload's reference points to
+var aggregateCollections = map[string]string{
+	"notification":           notificationsCollection,
+	"template":               templatesCollection,
+	"scheduled_notification": scheduledNotificationsCollection,
+}
+
+// SetPayloadOptions sets how the payloads of events created from now on are stored.
+// By default payloads are full, uncompressed and of any size.
+func (r *OutboxEventRepository) SetPayloadOptions(opts domain.OutboxPayloadOptions) {
+	r.payloads = opts
+}
+
+// encodePayload compacts, compresses or replaces event's payload as opts require
+func encodePayload(event *domain.OutboxEvent, opts domain.OutboxPayloadOptions) error {
+	// An event written again, as when a transaction is retried, is already encoded
+	if event.PayloadMode != "" || event.PayloadEncoding != domain.OutboxPayloadPlain || event.PayloadRef != nil {
+		return nil
+	}
+	if opts.Mode == domain.OutboxPayloadCompact {
+		if compact, ok := compactPayload(event.Payload); ok {
+			event.Payload = compact
+			event.PayloadMode = domain.OutboxPayloadCompact
+		}
+	}
+	if !opts.Compress && opts.MaxBytes <= 0 {
+		return nil
+	}
+
+	encoded, err := json.Marshal(event.Payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode outbox payload: %w", err)
+	}
+	size := len(encoded)
+	if opts.Compress {
+		compressed, err := gzipBytes(encoded)
+		if err != nil {
+			return fmt.Errorf("failed to compress outbox payload: %w", err)
+		}
+		// Small payloads grow when compressed, so they are stored as they are
+		if len(compressed) < len(encoded) {
+			event.Payload = compressed
+			event.PayloadEncoding = domain.OutboxPayloadGzip
+			size = len(compressed)
+		}
+	}
+
+	if opts.MaxBytes > 0 && size > opts.MaxBytes {
+		event.Payload = nil
+		event.PayloadEncoding = domain.OutboxPayloadPlain
+		event.PayloadRef = &domain.OutboxPayloadRef{
+			Collection: aggregateCollections[event.AggregateType],
+			ID:         event.AggregateID,
+			Size:       len(encoded),
+		}
+	}
+	return nil
+}
+
+// compactPayload returns the IDs and changed values of a payload, leaving out fields the
+// event itself carries, such as the tenant and time, and those consumers can read from the
+// aggregate. Payloads without a compact form are reported as not compacted.
+func compactPayload(payload interface{}) (bson.M, bool) {
+	switch p := payload.(type) {
+	case domain.NotificationCreatedPayload:
+		return bson.M{"notificationId": p.NotificationID, "type": p.Type, "status": p.Status}, true
+	case domain.NotificationStatusChangedPayload:
+		return bson.M{"notificationId": p.NotificationID, "oldStatus": p.OldStatus, "newStatus": p.NewStatus}, true
+	case domain.NotificationUpdatedPayload:
+		return bson.M{"notificationId": p.NotificationID, "updatedFields": p.UpdatedFields}, true
+	case domain.NotificationDeletedPayload:
+		compact := bson.M{"notificationId": p.NotificationID}
+		if p.Erased {
+			compact["erased"] = true
+		}
+		return compact, true
+	case domain.ScheduledNotificationExecutedPayload:
+		// Executions of large schedules list many notifications; they stay on the execution
+		compact := bson.M{"scheduleId": p.ScheduleID, "executionId": p.ExecutionID, "status": p.Status}
+		if p.Error != "" {
+			compact["error"] = p.Error
+		}
+		return compact, true
+	}
+	return nil, false
+}
+
+// gzipBytes compresses data with gzip
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodePayload returns event's payload as the payload struct for its event type, such as
+// domain.NotificationCreatedPayload for notification.created. Payloads read back from
+// MongoDB are documents, and compressed payloads are binary; both are decoded. Compact
+// payloads decode with only their IDs and changed values set. An oversized payload was not
+// stored, so its event decodes to nil; read the aggregate its PayloadRef names instead.
+func DecodePayload(event *domain.OutboxEvent) (interface{}, error) {
+	if event.Payload == nil && event.PayloadRef != nil {
+		return nil, nil
+	}
+
+	switch event.EventType {
+	case domain.EventNotificationCreated:
+		return decodePayloadAs[domain.NotificationCreatedPayload](event)
+	case domain.EventNotificationStatusChanged:
+		return decodePayloadAs[domain.NotificationStatusChangedPayload](event)
+	case domain.EventNotificationUpdated:
+		return decodePayloadAs[domain.NotificationUpdatedPayload](event)
+	case domain.EventNotificationDeleted:
+		return decodePayloadAs[domain.NotificationDeletedPayload](event)
+	case domain.EventTemplateCreated:
+		return decodePayloadAs[domain.TemplateCreatedPayload](event)
+	case domain.EventTemplateUpdated:
+		return decodePayloadAs[domain.TemplateUpdatedPayload](event)
+	case domain.EventTemplateDeleted:
+		return decodePayloadAs[domain.TemplateDeletedPayload](event)
+	case domain.EventScheduledNotificationCreated:
+		return decodePayloadAs[domain.ScheduledNotificationCreatedPayload](event)
+	case domain.EventScheduledNotificationExecuted:
+		return decodePayloadAs[domain.ScheduledNotificationExecutedPayload](event)
+	case domain.EventScheduledNotificationCanceled:
+		return decodePayloadAs[domain.ScheduledNotificationCanceledPayload](event)
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownOutboxEventType, event.EventType)
+}
+
+// decodePayloadAs decodes event's payload into a T
+func decodePayloadAs[T any](event *domain.OutboxEvent) (T, error) {
+	var payload T
+	if typed, ok := event.Payload.(T); ok {
+		return typed, nil
+	}
+
+	if event.PayloadEncoding == domain.OutboxPayloadGzip {
+		var compressed []byte
+		switch data := event.Payload.(type) {
+		case []byte:
+			compressed = data
+		case primitive.Binary:
+			compressed = data.Data
+		default:
+			return payload, fmt.Errorf("compressed outbox payload of event %s is %T, not binary", event.ID.Hex(), event.Payload)
+		}
+		encoded, err := gunzipBytes(compressed)
+		if err != nil {
+			return payload, fmt.Errorf("failed to decompress outbox payload of event %s: %w", event.ID.Hex(), err)
+		}
+		if err := json.Unmarshal(encoded, &payload); err != nil {
+			return payload, fmt.Errorf("failed to decode outbox payload of event %s: %w", event.ID.Hex(), err)
+		}
+		return payload, nil
+	}
+
+	// Payload structs have no bson tags, so full payloads are stored with lowercased field
+	// names; compact payloads' camelCase names also match them, as names are matched again
+	// in lower case
+	data, err := bson.Marshal(event.Payload)
+	if err != nil {
+		return payload, fmt.Errorf("failed to decode outbox payload of event %s: %w", event.ID.Hex(), err)
+	}
+	if err := bson.Unmarshal(data, &payload); err != nil {
+		return payload, fmt.Errorf("failed to decode outbox payload of event %s: %w", event.ID.Hex(), err)
+	}
+	return payload, nil
+}
+
+// decodePayloads replaces the payloads of events read back from MongoDB with their payload
+// structs
+func decodePayloads(events []*domain.OutboxEvent) error {
+	for _, event := range events {
+		payload, err := DecodePayload(event)
+		if err != nil {
+			return err
+		}
+		event.Payload = payload
+		event.PayloadEncoding = domain.OutboxPayloadPlain
+	}
+	return nil
+}
+
+// gunzipBytes decompresses gzip data
+func gunzipBytes(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
@@ -0,0 +1,220 @@
+package repository
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func statusChangedEvent() *domain.OutboxEvent {
+	return &domain.OutboxEvent{
+		TenantID:      "tenant-1",
+		AggregateType: "notification",
+		AggregateID:   "n1",
+		EventType:     domain.EventNotificationStatusChanged,
+		Payload: domain.NotificationStatusChangedPayload{
+			NotificationID: "n1",
+			TenantID:       "tenant-1",
+			OldStatus:      domain.NotificationStatusPending,
+			NewStatus:      domain.NotificationStatusSent,
+		},
+	}
+}
+
+func TestEncodePayloadFullByDefault(t *testing.T) {
+	event := statusChangedEvent()
+	if err := encodePayload(event, domain.OutboxPayloadOptions{}); err != nil {
+		t.Fatalf("encodePayload() error = %v", err)
+	}
+	if _, ok := event.Payload.(domain.NotificationStatusChangedPayload); !ok || event.PayloadMode != "" || event.PayloadEncoding != "" {
+		t.Errorf("default encoding changed the payload to %#v", event.Payload)
+	}
+}
+
+func TestEncodePayloadCompact(t *testing.T) {
+	event := statusChangedEvent()
+	if err := encodePayload(event, domain.OutboxPayloadOptions{Mode: domain.OutboxPayloadCompact}); err != nil {
+		t.Fatalf("encodePayload() error = %v", err)
+	}
+	want := bson.M{"notificationId": "n1", "oldStatus": domain.NotificationStatusPending, "newStatus": domain.NotificationStatusSent}
+	compact, ok := event.Payload.(bson.M)
+	if !ok || len(compact) != len(want) || compact["newStatus"] != want["newStatus"] || event.PayloadMode != domain.OutboxPayloadCompact {
+		t.Errorf("compact payload = %#v, want %v", event.Payload, want)
+	}
+
+	// Payloads without a compact form are stored in full
+	event = &domain.OutboxEvent{Payload: domain.TemplateDeletedPayload{TemplateID: "t1"}}
+	if err := encodePayload(event, domain.OutboxPayloadOptions{Mode: domain.OutboxPayloadCompact}); err != nil {
+		t.Fatalf("encodePayload() error = %v", err)
+	}
+	if event.PayloadMode != "" {
+		t.Errorf("payload mode = %q for a payload without a compact form", event.PayloadMode)
+	}
+}
+
+func TestEncodePayloadCompress(t *testing.T) {
+	event := statusChangedEvent()
+	event.Payload = domain.NotificationUpdatedPayload{NotificationID: "n1", UpdatedFields: strings.Split(strings.Repeat("metadata,", 50), ",")}
+	if err := encodePayload(event, domain.OutboxPayloadOptions{Compress: true}); err != nil {
+		t.Fatalf("encodePayload() error = %v", err)
+	}
+	compressed, ok := event.Payload.([]byte)
+	if !ok || event.PayloadEncoding != domain.OutboxPayloadGzip {
+		t.Fatalf("payload = %T with encoding %q, want gzip bytes", event.Payload, event.PayloadEncoding)
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error = %v", err)
+	}
+	decoded, _ := io.ReadAll(reader)
+	var payload domain.NotificationUpdatedPayload
+	if err := json.Unmarshal(decoded, &payload); err != nil || payload.NotificationID != "n1" || len(payload.UpdatedFields) != 51 {
+		t.Errorf("decompressed payload = %s, error = %v", decoded, err)
+	}
+
+	// Encoding again, as a retried transaction does, leaves the payload alone
+	if err := encodePayload(event, domain.OutboxPayloadOptions{Compress: true}); err != nil || !bytes.Equal(event.Payload.([]byte), compressed) {
+		t.Errorf("encoding twice changed the payload, error = %v", err)
+	}
+
+	// Small payloads grow when compressed, so they are stored as they are
+	event = statusChangedEvent()
+	if err := encodePayload(event, domain.OutboxPayloadOptions{Compress: true}); err != nil {
+		t.Fatalf("encodePayload() error = %v", err)
+	}
+	if event.PayloadEncoding != domain.OutboxPayloadPlain {
+		t.Errorf("small payload encoding = %q, want plain", event.PayloadEncoding)
+	}
+}
+
+func TestEncodePayloadOversized(t *testing.T) {
+	event := statusChangedEvent()
+	if err := encodePayload(event, domain.OutboxPayloadOptions{MaxBytes: 20}); err != nil {
+		t.Fatalf("encodePayload() error = %v", err)
+	}
+	ref := event.PayloadRef
+	if event.Payload != nil || ref == nil || ref.Collection != notificationsCollection || ref.ID != "n1" || ref.Size <= 20 {
+		t.Errorf("oversized payload = %#v, ref = %+v, want a reference to the notification", event.Payload, ref)
+	}
+}
+
+// roundTrip stores event as MongoDB does and reads it back
+func roundTrip(t *testing.T, event *domain.OutboxEvent) *domain.OutboxEvent {
+	t.Helper()
+	data, err := bson.Marshal(event)
+	if err != nil {
+		t.Fatalf("bson.Marshal() error = %v", err)
+	}
+	var stored domain.OutboxEvent
+	if err := bson.Unmarshal(data, &stored); err != nil {
+		t.Fatalf("bson.Unmarshal() error = %v", err)
+	}
+	return &stored
+}
+
+func TestDecodePayloadRoundTrip(t *testing.T) {
+	// MongoDB stores times to the millisecond, in UTC
+	at := time.Date(2024, 3, 1, 12, 30, 0, 125_000_000, time.UTC)
+	payloads := map[domain.OutboxEventType]interface{}{
+		domain.EventNotificationCreated: domain.NotificationCreatedPayload{
+			NotificationID: "n1", TenantID: "tenant-1", Type: domain.NotificationTypeEmail,
+			Recipient: "user@example.com", Subject: "Welcome", Status: domain.NotificationStatusPending, CreatedAt: at,
+		},
+		domain.EventNotificationStatusChanged: domain.NotificationStatusChangedPayload{
+			NotificationID: "n1", TenantID: "tenant-1", OldStatus: domain.NotificationStatusPending,
+			NewStatus: domain.NotificationStatusSent, ChangedAt: at,
+		},
+		domain.EventNotificationUpdated: domain.NotificationUpdatedPayload{
+			NotificationID: "n1", TenantID: "tenant-1", Type: domain.NotificationTypeEmail,
+			UpdatedFields: []string{"subject", "body"}, UpdatedAt: at,
+		},
+		domain.EventNotificationDeleted: domain.NotificationDeletedPayload{
+			NotificationID: "n1", TenantID: "tenant-1", DeletedAt: at, Erased: true,
+		},
+		domain.EventTemplateCreated: domain.TemplateCreatedPayload{
+			TemplateID: "t1", TenantID: "tenant-1", Name: "welcome", TemplateType: "email", CreatedAt: at,
+		},
+		domain.EventTemplateUpdated: domain.TemplateUpdatedPayload{
+			TemplateID: "t1", TenantID: "tenant-1", Name: "welcome", UpdatedFields: []string{"body"}, UpdatedAt: at,
+		},
+		domain.EventTemplateDeleted: domain.TemplateDeletedPayload{
+			TemplateID: "t1", TenantID: "tenant-1", Name: "welcome", DeletedAt: at,
+		},
+		domain.EventScheduledNotificationCreated: domain.ScheduledNotificationCreatedPayload{
+			ScheduleID: "s1", TenantID: "tenant-1", NotificationID: "n1", ScheduledFor: at, CreatedAt: at,
+		},
+		domain.EventScheduledNotificationExecuted: domain.ScheduledNotificationExecutedPayload{
+			ScheduleID: "s1", TenantID: "tenant-1", ExecutionID: "x1", Status: domain.ScheduleExecutionSucceeded,
+			NotificationID: "n1", NotificationIDs: []string{"n1", "n2"}, ExecutedAt: at,
+		},
+		domain.EventScheduledNotificationCanceled: domain.ScheduledNotificationCanceledPayload{
+			ScheduleID: "s1", TenantID: "tenant-1", CanceledAt: at,
+		},
+	}
+
+	for eventType, payload := range payloads {
+		for _, opts := range []domain.OutboxPayloadOptions{{}, {Compress: true}} {
+			event := &domain.OutboxEvent{TenantID: "tenant-1", EventType: eventType, Payload: payload}
+			if err := encodePayload(event, opts); err != nil {
+				t.Fatalf("%s: encodePayload() error = %v", eventType, err)
+			}
+			// Force compression even for payloads too small to benefit from it
+			if opts.Compress && event.PayloadEncoding == domain.OutboxPayloadPlain {
+				encoded, _ := json.Marshal(payload)
+				compressed, err := gzipBytes(encoded)
+				if err != nil {
+					t.Fatalf("gzipBytes() error = %v", err)
+				}
+				event.Payload, event.PayloadEncoding = compressed, domain.OutboxPayloadGzip
+			}
+
+			decoded, err := DecodePayload(roundTrip(t, event))
+			if err != nil {
+				t.Fatalf("%s (compressed %t): DecodePayload() error = %v", eventType, opts.Compress, err)
+			}
+			if !reflect.DeepEqual(decoded, payload) {
+				t.Errorf("%s (compressed %t): decoded %#v, want %#v", eventType, opts.Compress, decoded, payload)
+			}
+		}
+	}
+}
+
+func TestDecodePayloadCompact(t *testing.T) {
+	event := statusChangedEvent()
+	if err := encodePayload(event, domain.OutboxPayloadOptions{Mode: domain.OutboxPayloadCompact}); err != nil {
+		t.Fatalf("encodePayload() error = %v", err)
+	}
+
+	decoded, err := DecodePayload(roundTrip(t, event))
+	if err != nil {
+		t.Fatalf("DecodePayload() error = %v", err)
+	}
+	want := domain.NotificationStatusChangedPayload{NotificationID: "n1", OldStatus: domain.NotificationStatusPending, NewStatus: domain.NotificationStatusSent}
+	if decoded != want {
+		t.Errorf("decoded %#v, want %#v", decoded, want)
+	}
+}
+
+func TestDecodePayloadOversizedAndUnknown(t *testing.T) {
+	event := statusChangedEvent()
+	if err := encodePayload(event, domain.OutboxPayloadOptions{MaxBytes: 20}); err != nil {
+		t.Fatalf("encodePayload() error = %v", err)
+	}
+	if decoded, err := DecodePayload(roundTrip(t, event)); err != nil || decoded != nil {
+		t.Errorf("oversized payload decoded to %#v, %v; want nil", decoded, err)
+	}
+
+	event = &domain.OutboxEvent{EventType: "template.archived", Payload: bson.M{"templateId": "t1"}}
+	if _, err := DecodePayload(event); !errors.Is(err, ErrUnknownOutboxEventType) {
+		t.Errorf("DecodePayload() error = %v, want ErrUnknownOutboxEventType", err)
+	}
+}
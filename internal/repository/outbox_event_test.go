@@ -5,9 +5,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/metrics"
 	"github.com/vhvplatform/go-notification-service/internal/shared/mongodb"
 )
 
@@ -301,6 +303,44 @@ func TestOutbox_MarkProcessed_UpdatesStatus(t *testing.T) {
 	assert.Equal(t, 2, processedEvents[0].Version, "Version should increment on mark processed")
 }
 
+// TestOutbox_RetriedMetric_CountsScheduledRetries verifies only failures left pending for retry are counted as retries
+func TestOutbox_RetriedMetric_CountsScheduledRetries(t *testing.T) {
+	t.Skip("Requires MongoDB with replica set - run with integration test suite")
+
+	// Setup
+	client := setupTestMongoDB(t)
+	defer teardownTestMongoDB(t, client)
+
+	outboxRepo := NewOutboxEventRepository(client)
+	notifRepo := NewNotificationRepository(client, outboxRepo)
+	ctx := context.Background()
+
+	notif := &domain.Notification{
+		TenantID:  "tenant-1",
+		Type:      domain.NotificationTypeEmail,
+		Recipient: "test@example.com",
+		Subject:   "Retry Test",
+		Status:    domain.NotificationStatusPending,
+	}
+	require.NoError(t, notifRepo.Create(ctx, notif))
+
+	events, err := outboxRepo.FindUnprocessed(ctx, "tenant-1", 1)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	event := events[0]
+	retried := metrics.OutboxEventsRetried.WithLabelValues(string(event.EventType))
+	before := testutil.ToFloat64(retried)
+
+	// A recorded failure schedules a retry
+	require.NoError(t, outboxRepo.RecordFailure(ctx, event.ID.Hex(), "tenant-1", "broker unavailable"))
+	assert.Equal(t, before+1, testutil.ToFloat64(retried))
+
+	// Publishing after a failure, or giving up, is not another retry
+	require.NoError(t, outboxRepo.MarkProcessed(ctx, event.ID.Hex(), "tenant-1"))
+	require.NoError(t, outboxRepo.MarkFailed(ctx, event.ID.Hex(), "tenant-1", "broker unavailable"))
+	assert.Equal(t, before+1, testutil.ToFloat64(retried))
+}
+
 // TestOutbox_TraceID_InjectedIntoEvent verifies trace_id from context is captured
 func TestOutbox_TraceID_InjectedIntoEvent(t *testing.T) {
 	t.Skip("Requires MongoDB with replica set - run with integration test suite")
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/shared/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const preferenceCategoriesCollection = "preference_categories"
+
+// PreferenceCategoryRepository handles tenant preference category data operations
+type PreferenceCategoryRepository struct {
+	client *mongodb.MongoClient
+}
+
+// NewPreferenceCategoryRepository creates a new preference category repository
+func NewPreferenceCategoryRepository(client *mongodb.MongoClient) *PreferenceCategoryRepository {
+	return &PreferenceCategoryRepository{client: client}
+}
+
+// EnsureIndexes creates necessary indexes for optimal query performance
+func (r *PreferenceCategoryRepository) EnsureIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "tenantId", Value: 1}},
+			Options: options.Index().SetName("tenant_idx").SetUnique(true),
+		},
+	}
+
+	return r.client.CreateIndexes(ctx, preferenceCategoriesCollection, indexes)
+}
+
+// GetByTenantID retrieves the category list for a tenant
+// Returns an empty list when the tenant has not defined categories.
+func (r *PreferenceCategoryRepository) GetByTenantID(ctx context.Context, tenantID string) (*domain.PreferenceCategories, error) {
+	var categories domain.PreferenceCategories
+	filter := bson.M{
+		"tenantId":  tenantID,
+		"deletedAt": nil,
+	}
+	err := r.client.Collection(preferenceCategoriesCollection).FindOne(ctx, filter).Decode(&categories)
+
+	if err == mongo.ErrNoDocuments {
+		return &domain.PreferenceCategories{
+			TenantID:   tenantID,
+			Categories: []string{},
+		}, nil
+	}
+
+	return &categories, err
+}
+
+// Upsert replaces the category list for a tenant
+func (r *PreferenceCategoryRepository) Upsert(ctx context.Context, tenantID string, categories []string) (*domain.PreferenceCategories, error) {
+	now := time.Now()
+	filter := bson.M{
+		"tenantId":  tenantID,
+		"deletedAt": nil,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"categories": categories,
+			"updatedAt":  now,
+		},
+		"$inc": bson.M{"version": 1},
+		"$setOnInsert": bson.M{
+			"_id":       primitive.NewObjectID(),
+			"createdAt": now,
+		},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var result domain.PreferenceCategories
+	if err := r.client.Collection(preferenceCategoriesCollection).FindOneAndUpdate(ctx, filter, update, opts).Decode(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
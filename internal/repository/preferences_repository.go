@@ -1,103 +1,374 @@
-package repository
-
-import (
-	"context"
-	"time"
-
-	"github.com/vhvplatform/go-notification-service/internal/domain"
-	"github.com/vhvplatform/go-notification-service/internal/shared/mongodb"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
-)
-
-const preferencesCollection = "notification_preferences"
-
-// PreferencesRepository handles notification preferences data operations
-type PreferencesRepository struct {
-	client *mongodb.MongoClient
-}
-
-// NewPreferencesRepository creates a new preferences repository
-func NewPreferencesRepository(client *mongodb.MongoClient) *PreferencesRepository {
-	return &PreferencesRepository{client: client}
-}
-
-// EnsureIndexes creates necessary indexes for optimal query performance
-func (r *PreferencesRepository) EnsureIndexes(ctx context.Context) error {
-	indexes := []mongo.IndexModel{
-		{
-			Keys: bson.D{
-				{Key: "tenantId", Value: 1},
-				{Key: "userId", Value: 1},
-			},
-			Options: options.Index().SetName("tenant_user_idx").SetUnique(true),
-		},
-	}
-
-	return r.client.CreateIndexes(ctx, preferencesCollection, indexes)
-}
-
-// GetByUserID retrieves preferences for a specific user with tenant isolation
-func (r *PreferencesRepository) GetByUserID(ctx context.Context, tenantID, userID string) (*domain.NotificationPreferences, error) {
-	var prefs domain.NotificationPreferences
-	filter := bson.M{
-		"tenantId":  tenantID,
-		"userId":    userID,
-		"deletedAt": nil,
-	}
-	err := r.client.Collection(preferencesCollection).FindOne(ctx, filter).Decode(&prefs)
-
-	if err == mongo.ErrNoDocuments {
-		// Return default preferences if not found
-		return &domain.NotificationPreferences{
-			TenantID:        tenantID,
-			UserID:          userID,
-			EmailEnabled:    true,
-			SMSEnabled:      true,
-			WebhookEnabled:  true,
-			EmailCategories: make(map[string]bool),
-			SMSCategories:   make(map[string]bool),
-			Timezone:        "UTC",
-		}, nil
-	}
-
-	return &prefs, err
-}
-
-// Create creates new preferences
-func (r *PreferencesRepository) Create(ctx context.Context, prefs *domain.NotificationPreferences) error {
-	prefs.ID = primitive.NewObjectID()
-	prefs.Version = 1
-	prefs.CreatedAt = time.Now()
-	prefs.UpdatedAt = time.Now()
-	prefs.DeletedAt = nil
-
-	_, err := r.client.Collection(preferencesCollection).InsertOne(ctx, prefs)
-	return err
-}
-
-// Update updates preferences with optimistic locking and tenant isolation
-func (r *PreferencesRepository) Update(ctx context.Context, prefs *domain.NotificationPreferences) error {
-	prefs.UpdatedAt = time.Now()
-	prefs.Version++
-
-	filter := bson.M{
-		"tenantId":  prefs.TenantID,
-		"userId":    prefs.UserID,
-		"deletedAt": nil,
-		"version":   prefs.Version - 1,
-	}
-	update := bson.M{"$set": prefs}
-	opts := options.Update().SetUpsert(true)
-
-	result, err := r.client.Collection(preferencesCollection).UpdateOne(ctx, filter, update, opts)
-	if err != nil {
-		return err
-	}
-	if result.MatchedCount == 0 && result.UpsertedCount == 0 {
-		return mongo.ErrNoDocuments
-	}
-	return nil
-}
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/cache"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/shared/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const preferencesCollection = "notification_preferences"
+
+// ErrPreferencesExist is returned by Create when the user already has stored preferences
+var ErrPreferencesExist = errors.New("preferences already exist")
+
+// PreferencesRepository handles notification preferences data operations
+type PreferencesRepository struct {
+	client *mongodb.MongoClient
+	cache  *cache.Cache // Optional; nil reads every lookup from the database
+}
+
+// NewPreferencesRepository creates a new preferences repository
+func NewPreferencesRepository(client *mongodb.MongoClient) *PreferencesRepository {
+	return &PreferencesRepository{client: client}
+}
+
+// SetCache caches preference lookups in c, invalidating them when preferences are written
+func (r *PreferencesRepository) SetCache(c *cache.Cache) {
+	r.cache = c
+}
+
+// preferencesCacheKey returns the cache key of a user's preferences
+func preferencesCacheKey(tenantID, userID string) string {
+	return tenantID + ":" + userID
+}
+
+// EnsureIndexes creates necessary indexes for optimal query performance
+func (r *PreferencesRepository) EnsureIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "tenantId", Value: 1},
+				{Key: "userId", Value: 1},
+			},
+			Options: options.Index().SetName("tenant_user_idx").SetUnique(true),
+		},
+	}
+
+	return r.client.CreateIndexes(ctx, preferencesCollection, indexes)
+}
+
+// GetByUserID retrieves preferences for a specific user with tenant isolation
+// Users without stored preferences get the defaults, with IsDefault set.
+func (r *PreferencesRepository) GetByUserID(ctx context.Context, tenantID, userID string) (*domain.NotificationPreferences, error) {
+	key := preferencesCacheKey(tenantID, userID)
+	var cached domain.NotificationPreferences
+	if hit, found := cachedDocument(ctx, r.cache, cache.KindPreferences, key, &cached); hit {
+		if !found {
+			return defaultPreferences(tenantID, userID), nil
+		}
+		return &cached, nil
+	}
+
+	var prefs domain.NotificationPreferences
+	filter := bson.M{
+		"tenantId":  tenantID,
+		"userId":    userID,
+		"deletedAt": nil,
+	}
+	err := r.client.Collection(preferencesCollection).FindOne(ctx, filter).Decode(&prefs)
+
+	if err == mongo.ErrNoDocuments {
+		// Return default preferences if not found
+		cacheDocument(ctx, r.cache, cache.KindPreferences, key, nil)
+		return defaultPreferences(tenantID, userID), nil
+	}
+	if err == nil {
+		cacheDocument(ctx, r.cache, cache.KindPreferences, key, &prefs)
+	}
+
+	return &prefs, err
+}
+
+// defaultPreferences returns the preferences of a user who hasn't stored any
+func defaultPreferences(tenantID, userID string) *domain.NotificationPreferences {
+	return &domain.NotificationPreferences{
+		TenantID:        tenantID,
+		UserID:          userID,
+		IsDefault:       true,
+		EmailEnabled:    true,
+		SMSEnabled:      true,
+		WebhookEnabled:  true,
+		EmailCategories: make(map[string]bool),
+		SMSCategories:   make(map[string]bool),
+		Timezone:        "UTC",
+	}
+}
+
+// FindByUserIDs retrieves the stored preferences of the given users with tenant isolation
+// Users without stored preferences are omitted; they have the defaults returned by GetByUserID.
+func (r *PreferencesRepository) FindByUserIDs(ctx context.Context, tenantID string, userIDs []string) ([]*domain.NotificationPreferences, error) {
+	var prefs []*domain.NotificationPreferences
+	missed := userIDs
+	if r.cache != nil {
+		keys := make([]string, len(userIDs))
+		for i, userID := range userIDs {
+			keys[i] = preferencesCacheKey(tenantID, userID)
+		}
+		missed = nil
+		for i, value := range r.cache.Get(ctx, cache.KindPreferences, keys...) {
+			var cached domain.NotificationPreferences
+			switch {
+			case value == nil || (len(value) > 0 && bson.Unmarshal(value, &cached) != nil):
+				missed = append(missed, userIDs[i])
+			case len(value) > 0:
+				prefs = append(prefs, &cached)
+			}
+		}
+		if len(missed) == 0 {
+			return prefs, nil
+		}
+	}
+
+	filter := bson.M{
+		"tenantId":  tenantID,
+		"userId":    bson.M{"$in": missed},
+		"deletedAt": nil,
+	}
+
+	var stored []*domain.NotificationPreferences
+	err := retryRead(ctx, "preferences.find_by_user_ids", func() error {
+		cursor, err := r.client.Collection(preferencesCollection).Find(ctx, filter)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		stored = nil
+		return cursor.All(ctx, &stored)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if r.cache != nil {
+		found := make(map[string]bool, len(stored))
+		for _, p := range stored {
+			found[p.UserID] = true
+			cacheDocument(ctx, r.cache, cache.KindPreferences, preferencesCacheKey(tenantID, p.UserID), p)
+		}
+		for _, userID := range missed {
+			if !found[userID] {
+				cacheDocument(ctx, r.cache, cache.KindPreferences, preferencesCacheKey(tenantID, userID), nil)
+			}
+		}
+	}
+	return append(prefs, stored...), nil
+}
+
+// Create stores a user's first preferences. It returns ErrPreferencesExist, relying on the
+// unique tenant_user_idx index, when the user already has stored preferences.
+func (r *PreferencesRepository) Create(ctx context.Context, prefs *domain.NotificationPreferences) error {
+	prefs.ID = primitive.NewObjectID()
+	prefs.Version = 1
+	prefs.CreatedAt = time.Now()
+	prefs.UpdatedAt = time.Now()
+	prefs.DeletedAt = nil
+	prefs.IsDefault = false
+
+	_, err := r.client.Collection(preferencesCollection).InsertOne(ctx, prefs)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrPreferencesExist
+	}
+	r.cache.Delete(ctx, cache.KindPreferences, preferencesCacheKey(prefs.TenantID, prefs.UserID))
+	return err
+}
+
+// Update updates preferences with optimistic locking and tenant isolation
+func (r *PreferencesRepository) Update(ctx context.Context, prefs *domain.NotificationPreferences) error {
+	prefs.UpdatedAt = time.Now()
+	prefs.Version++
+
+	filter := bson.M{
+		"tenantId":  prefs.TenantID,
+		"userId":    prefs.UserID,
+		"deletedAt": nil,
+		"version":   prefs.Version - 1,
+	}
+	update := bson.M{"$set": prefs}
+	opts := options.Update().SetUpsert(true)
+
+	result, err := r.client.Collection(preferencesCollection).UpdateOne(ctx, filter, update, opts)
+	r.cache.Delete(ctx, cache.KindPreferences, preferencesCacheKey(prefs.TenantID, prefs.UserID))
+	if mongo.IsDuplicateKeyError(err) {
+		// The upsert collided with an existing document at another version
+		return versionConflict(ctx, r.client.Collection(preferencesCollection), filter)
+	}
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 && result.UpsertedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// Patch applies a partial update to a user's preferences using $set on individual paths,
+// so concurrent changes to other fields or categories are not overwritten.
+// Preferences are created with defaults when the user has none yet.
+func (r *PreferencesRepository) Patch(ctx context.Context, tenantID, userID string, patch *domain.PreferencesPatchRequest) (*domain.NotificationPreferences, error) {
+	now := time.Now()
+	set := bson.M{"updatedAt": now}
+
+	if patch.EmailEnabled != nil {
+		set["emailEnabled"] = *patch.EmailEnabled
+	}
+	if patch.SMSEnabled != nil {
+		set["smsEnabled"] = *patch.SMSEnabled
+	}
+	if patch.WebhookEnabled != nil {
+		set["webhookEnabled"] = *patch.WebhookEnabled
+	}
+	for category, enabled := range patch.EmailCategories {
+		set["emailCategories."+category] = enabled
+	}
+	for category, enabled := range patch.SMSCategories {
+		set["smsCategories."+category] = enabled
+	}
+	if patch.QuietHoursStart != nil {
+		set["quietHoursStart"] = *patch.QuietHoursStart
+	}
+	if patch.QuietHoursEnd != nil {
+		set["quietHoursEnd"] = *patch.QuietHoursEnd
+	}
+	if patch.Timezone != nil {
+		set["timezone"] = *patch.Timezone
+	}
+
+	filter := bson.M{
+		"tenantId":  tenantID,
+		"userId":    userID,
+		"deletedAt": nil,
+	}
+	update := bson.M{
+		"$set":         set,
+		"$inc":         bson.M{"version": 1},
+		"$setOnInsert": preferenceInsertDefaults(set, now),
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var prefs domain.NotificationPreferences
+	err := r.client.Collection(preferencesCollection).FindOneAndUpdate(ctx, filter, update, opts).Decode(&prefs)
+	r.cache.Delete(ctx, cache.KindPreferences, preferencesCacheKey(tenantID, userID))
+	if err != nil {
+		return nil, err
+	}
+	return &prefs, nil
+}
+
+// BulkSetCategory enables or disables a single category for many users of a tenant.
+// Users without stored preferences get a preferences document with defaults.
+func (r *PreferencesRepository) BulkSetCategory(ctx context.Context, tenantID string, userIDs []string, channel domain.NotificationType, category string, enabled bool) (matched int64, upserted int64, err error) {
+	field := "emailCategories"
+	if channel == domain.NotificationTypeSMS {
+		field = "smsCategories"
+	}
+
+	now := time.Now()
+	set := bson.M{
+		field + "." + category: enabled,
+		"updatedAt":            now,
+	}
+
+	models := make([]mongo.WriteModel, 0, len(userIDs))
+	for _, userID := range userIDs {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{
+				"tenantId":  tenantID,
+				"userId":    userID,
+				"deletedAt": nil,
+			}).
+			SetUpdate(bson.M{
+				"$set":         set,
+				"$inc":         bson.M{"version": 1},
+				"$setOnInsert": preferenceInsertDefaults(set, now),
+			}).
+			SetUpsert(true))
+	}
+
+	result, err := r.client.Collection(preferencesCollection).BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	// An unordered bulk write can fail part way, so every user's entry is invalidated
+	keys := make([]string, len(userIDs))
+	for i, userID := range userIDs {
+		keys[i] = preferencesCacheKey(tenantID, userID)
+	}
+	r.cache.Delete(ctx, cache.KindPreferences, keys...)
+	if err != nil {
+		return 0, 0, err
+	}
+	return result.MatchedCount, result.UpsertedCount, nil
+}
+
+// preferenceInsertDefaults returns default preference fields for upserts, skipping any
+// field (or parent of a field) already present in set to avoid update path conflicts
+func preferenceInsertDefaults(set bson.M, now time.Time) bson.M {
+	defaults := bson.M{
+		"_id":             primitive.NewObjectID(),
+		"emailEnabled":    true,
+		"smsEnabled":      true,
+		"webhookEnabled":  true,
+		"emailCategories": bson.M{},
+		"smsCategories":   bson.M{},
+		"timezone":        "UTC",
+		"createdAt":       now,
+	}
+
+	for path := range set {
+		field, _, _ := strings.Cut(path, ".")
+		delete(defaults, field)
+	}
+	return defaults
+}
+
+// UpsertMany creates or replaces the tenant's users' preferences in one bulk write, reporting
+// each user's outcome in order. Soft-deleted preferences are restored.
+func (r *PreferencesRepository) UpsertMany(ctx context.Context, tenantID string, items []*domain.NotificationPreferences) ([]UpsertResult, error) {
+	now := time.Now()
+	models := make([]mongo.WriteModel, 0, len(items))
+	keys := make([]string, 0, len(items))
+	for _, prefs := range items {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{
+				"tenantId": tenantID,
+				"userId":   prefs.UserID,
+			}).
+			SetUpdate(bson.M{
+				"$set": bson.M{
+					"emailEnabled":    prefs.EmailEnabled,
+					"smsEnabled":      prefs.SMSEnabled,
+					"webhookEnabled":  prefs.WebhookEnabled,
+					"emailCategories": nonNil(prefs.EmailCategories),
+					"smsCategories":   nonNil(prefs.SMSCategories),
+					"quietHoursStart": prefs.QuietHoursStart,
+					"quietHoursEnd":   prefs.QuietHoursEnd,
+					"timezone":        prefs.Timezone,
+					"updatedAt":       now,
+				},
+				"$unset":       bson.M{"deletedAt": ""},
+				"$inc":         bson.M{"version": 1},
+				"$setOnInsert": bson.M{"_id": primitive.NewObjectID(), "createdAt": now},
+			}).
+			SetUpsert(true))
+		keys = append(keys, preferencesCacheKey(tenantID, prefs.UserID))
+	}
+
+	results, err := bulkUpsert(ctx, r.client.Collection(preferencesCollection), models)
+	// An unordered bulk write can fail part way, so every user's entry is invalidated
+	r.cache.Delete(ctx, cache.KindPreferences, keys...)
+	return results, err
+}
+
+// nonNil returns categories, or an empty map in place of nil so the stored field is an object
+func nonNil(categories map[string]bool) map[string]bool {
+	if categories == nil {
+		return map[string]bool{}
+	}
+	return categories
+}
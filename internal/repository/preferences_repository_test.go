@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestPreferenceInsertDefaults(t *testing.T) {
+	now := time.Now()
+	set := bson.M{
+		"emailCategories.marketing": false,
+		"timezone":                  "Europe/Berlin",
+		"updatedAt":                 now,
+	}
+
+	defaults := preferenceInsertDefaults(set, now)
+
+	// Fields being set, or whose sub-paths are being set, must not appear in $setOnInsert
+	for _, field := range []string{"emailCategories", "timezone"} {
+		if _, ok := defaults[field]; ok {
+			t.Errorf("defaults contains %q which conflicts with $set", field)
+		}
+	}
+
+	for _, field := range []string{"_id", "emailEnabled", "smsEnabled", "webhookEnabled", "smsCategories", "createdAt"} {
+		if _, ok := defaults[field]; !ok {
+			t.Errorf("defaults missing %q", field)
+		}
+	}
+}
+
+func TestDefaultPreferencesAreMarked(t *testing.T) {
+	prefs := defaultPreferences("tenant-1", "user-1")
+	if !prefs.IsDefault {
+		t.Error("default preferences should have IsDefault set")
+	}
+
+	// The flag is derived from whether preferences are stored, so it is never persisted
+	data, err := bson.Marshal(prefs)
+	if err != nil {
+		t.Fatalf("bson.Marshal() error = %v", err)
+	}
+	var stored bson.M
+	if err := bson.Unmarshal(data, &stored); err != nil {
+		t.Fatalf("bson.Unmarshal() error = %v", err)
+	}
+	for field := range stored {
+		if strings.EqualFold(field, "isDefault") {
+			t.Errorf("stored document contains %q", field)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/shared/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	tenantQuotasCollection = "tenant_quotas"
+	quotaUsageCollection   = "quota_usage"
+)
+
+// QuotaRepository handles tenant quota and usage counter data operations
+type QuotaRepository struct {
+	client *mongodb.MongoClient
+}
+
+// NewQuotaRepository creates a new quota repository
+func NewQuotaRepository(client *mongodb.MongoClient) *QuotaRepository {
+	return &QuotaRepository{client: client}
+}
+
+// EnsureIndexes creates necessary indexes for optimal query performance
+func (r *QuotaRepository) EnsureIndexes(ctx context.Context) error {
+	quotaIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "tenantId", Value: 1}, {Key: "channel", Value: 1}},
+			Options: options.Index().SetName("tenant_channel_idx").SetUnique(true),
+		},
+	}
+	if err := r.client.CreateIndexes(ctx, tenantQuotasCollection, quotaIndexes); err != nil {
+		return err
+	}
+
+	usageIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "tenantId", Value: 1},
+				{Key: "channel", Value: 1},
+				{Key: "period", Value: 1},
+				{Key: "periodKey", Value: 1},
+			},
+			Options: options.Index().SetName("tenant_channel_period_idx").SetUnique(true),
+		},
+		{
+			// Counters for past periods are removed automatically
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetName("expires_at_ttl_idx").SetExpireAfterSeconds(0),
+		},
+	}
+	return r.client.CreateIndexes(ctx, quotaUsageCollection, usageIndexes)
+}
+
+// FindQuota finds the quota override for a tenant and channel
+// Returns nil without error when the tenant has no override.
+func (r *QuotaRepository) FindQuota(ctx context.Context, tenantID string, channel domain.NotificationType) (*domain.TenantQuota, error) {
+	var quota domain.TenantQuota
+	filter := bson.M{
+		"tenantId":  tenantID,
+		"channel":   channel,
+		"deletedAt": nil,
+	}
+	err := r.client.Collection(tenantQuotasCollection).FindOne(ctx, filter).Decode(&quota)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &quota, nil
+}
+
+// IncrementUsage atomically adds delta to a usage counter and returns the new count
+// The counter is created on first use and expires at expiresAt.
+func (r *QuotaRepository) IncrementUsage(ctx context.Context, tenantID string, channel domain.NotificationType, period domain.QuotaPeriod, periodKey string, delta int64, expiresAt time.Time) (int64, error) {
+	filter := bson.M{
+		"tenantId":  tenantID,
+		"channel":   channel,
+		"period":    period,
+		"periodKey": periodKey,
+	}
+	update := bson.M{
+		"$inc":         bson.M{"count": delta},
+		"$setOnInsert": bson.M{"expiresAt": expiresAt},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var usage domain.QuotaUsage
+	if err := r.client.Collection(quotaUsageCollection).FindOneAndUpdate(ctx, filter, update, opts).Decode(&usage); err != nil {
+		return 0, err
+	}
+	return usage.Count, nil
+}
+
+// GetUsage returns the current count of a usage counter, or 0 if it doesn't exist
+func (r *QuotaRepository) GetUsage(ctx context.Context, tenantID string, channel domain.NotificationType, period domain.QuotaPeriod, periodKey string) (int64, error) {
+	filter := bson.M{
+		"tenantId":  tenantID,
+		"channel":   channel,
+		"period":    period,
+		"periodKey": periodKey,
+	}
+
+	var usage domain.QuotaUsage
+	err := r.client.Collection(quotaUsageCollection).FindOne(ctx, filter).Decode(&usage)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return usage.Count, nil
+}
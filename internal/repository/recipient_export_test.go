@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+)
+
+// TestRecipientExport_Finders verifies a recipient's records are found case-insensitively and within the tenant only
+func TestRecipientExport_Finders(t *testing.T) {
+	t.Skip("Requires MongoDB connection - run with integration test suite")
+
+	client := setupTestMongoDB(t)
+	defer teardownTestMongoDB(t, client)
+	ctx := context.Background()
+	defer func() {
+		for _, coll := range []string{notificationsCollection, notificationEventsCollection, failedNotificationsCollection, recipientListsCollection} {
+			_ = client.Collection(coll).Drop(ctx)
+		}
+	}()
+
+	notifications := NewNotificationRepository(client, nil)
+	for _, notification := range []*domain.Notification{
+		{TenantID: "tenant-1", Type: domain.NotificationTypeEmail, Recipient: "Jane@Example.com", Status: domain.NotificationStatusSent},
+		{TenantID: "tenant-1", Type: domain.NotificationTypeEmail, Recipient: "jane@example.com", Status: domain.NotificationStatusSent},
+		{TenantID: "tenant-1", Type: domain.NotificationTypeEmail, Recipient: "bob@example.com", Status: domain.NotificationStatusSent},
+		{TenantID: "tenant-2", Type: domain.NotificationTypeEmail, Recipient: "jane@example.com", Status: domain.NotificationStatusSent},
+	} {
+		require.NoError(t, notifications.Create(ctx, notification))
+	}
+
+	found, err := notifications.FindByRecipient(ctx, "tenant-1", "jane@example.com", 10)
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	limited, err := notifications.FindByRecipient(ctx, "tenant-1", "jane@example.com", 1)
+	require.NoError(t, err)
+	assert.Len(t, limited, 1)
+
+	events := NewNotificationEventRepository(client)
+	require.NoError(t, events.Create(ctx,
+		&domain.NotificationEvent{TenantID: "tenant-1", NotificationID: found[0].ID.Hex()},
+		&domain.NotificationEvent{TenantID: "tenant-1", NotificationID: found[1].ID.Hex()},
+		&domain.NotificationEvent{TenantID: "tenant-2", NotificationID: found[0].ID.Hex()},
+	))
+	foundEvents, err := events.FindByNotificationIDs(ctx, "tenant-1", []string{found[0].ID.Hex(), found[1].ID.Hex()})
+	require.NoError(t, err)
+	assert.Len(t, foundEvents, 2)
+
+	failed := NewFailedNotificationRepository(client)
+	require.NoError(t, failed.Create(ctx, &domain.FailedNotification{TenantID: "tenant-1", Recipient: "JANE@example.com"}))
+	require.NoError(t, failed.Create(ctx, &domain.FailedNotification{TenantID: "tenant-2", Recipient: "jane@example.com"}))
+	foundFailed, err := failed.FindByRecipient(ctx, "tenant-1", "jane@example.com")
+	require.NoError(t, err)
+	assert.Len(t, foundFailed, 1)
+
+	lists := NewRecipientListRepository(client)
+	list := &domain.RecipientList{
+		TenantID: "tenant-1",
+		Name:     "Customers",
+		Members: []domain.RecipientListMember{
+			{Address: "bob@example.com", Subscribed: true},
+			{Address: "Jane@example.com", UserID: "user-1", Subscribed: true},
+		},
+	}
+	require.NoError(t, lists.Create(ctx, list))
+	memberships, err := lists.FindMemberships(ctx, "tenant-1", "jane@example.com")
+	require.NoError(t, err)
+	require.Len(t, memberships, 1)
+	// Only the recipient's own member entry is exported
+	require.Len(t, memberships[0].Members, 1)
+	assert.Equal(t, "user-1", memberships[0].Members[0].UserID)
+}
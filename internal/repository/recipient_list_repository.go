@@ -0,0 +1,218 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/shared/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const recipientListsCollection = "recipient_lists"
+
+// RecipientListRepository handles recipient list data operations
+type RecipientListRepository struct {
+	client *mongodb.MongoClient
+}
+
+// NewRecipientListRepository creates a new recipient list repository
+func NewRecipientListRepository(client *mongodb.MongoClient) *RecipientListRepository {
+	return &RecipientListRepository{client: client}
+}
+
+// EnsureIndexes creates necessary indexes for optimal query performance
+func (r *RecipientListRepository) EnsureIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "tenantId", Value: 1}, {Key: "name", Value: 1}},
+			Options: options.Index().SetName("tenant_name_idx"),
+		},
+	}
+	return r.client.CreateIndexes(ctx, recipientListsCollection, indexes)
+}
+
+// Create creates a new recipient list
+func (r *RecipientListRepository) Create(ctx context.Context, list *domain.RecipientList) error {
+	now := time.Now()
+	list.ID = primitive.NewObjectID()
+	list.Version = 1
+	list.MemberCount = len(list.Members)
+	list.CreatedAt = now
+	list.UpdatedAt = now
+	list.DeletedAt = nil
+
+	_, err := r.client.Collection(recipientListsCollection).InsertOne(ctx, list)
+	return err
+}
+
+// FindRecipientList finds a recipient list with its members by ID with tenant isolation
+// It returns nil when the list does not exist.
+func (r *RecipientListRepository) FindRecipientList(ctx context.Context, tenantID, id string) (*domain.RecipientList, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, nil
+	}
+
+	var list domain.RecipientList
+	filter := bson.M{
+		"_id":       objectID,
+		"tenantId":  tenantID,
+		"deletedAt": nil,
+	}
+	err = r.client.Collection(recipientListsCollection).FindOne(ctx, filter).Decode(&list)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// FindByTenantID lists a tenant's recipient lists by name, without their members
+func (r *RecipientListRepository) FindByTenantID(ctx context.Context, tenantID string, page, pageSize int) ([]*domain.RecipientList, int64, error) {
+	filter := bson.M{
+		"tenantId":  tenantID,
+		"deletedAt": nil,
+	}
+	opts := options.Find().
+		SetProjection(bson.M{"members": 0}).
+		SetSort(bson.D{{Key: "name", Value: 1}}).
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize))
+
+	collection := r.client.Collection(recipientListsCollection)
+	var lists []*domain.RecipientList
+	var total int64
+	err := retryRead(ctx, "recipient_lists.find_by_tenant", func() error {
+		var err error
+		if total, err = collection.CountDocuments(ctx, filter); err != nil {
+			return err
+		}
+
+		cursor, err := collection.Find(ctx, filter, opts)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		lists = nil
+		return cursor.All(ctx, &lists)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return lists, total, nil
+}
+
+// FindMemberships returns the tenant's recipient lists that contain address, each with only
+// that member's entry
+func (r *RecipientListRepository) FindMemberships(ctx context.Context, tenantID, address string) ([]*domain.RecipientList, error) {
+	filter := bson.M{
+		"tenantId":        tenantID,
+		"deletedAt":       nil,
+		"members.address": recipientFilter(address),
+	}
+	opts := options.Find().
+		SetProjection(bson.M{"members": bson.M{"$elemMatch": bson.M{"address": recipientFilter(address)}}, "name": 1, "tenantId": 1, "memberCount": 1, "version": 1, "createdAt": 1, "updatedAt": 1}).
+		SetSort(bson.D{{Key: "name", Value: 1}})
+
+	var lists []*domain.RecipientList
+	err := retryRead(ctx, "recipient_lists.find_memberships", func() error {
+		cursor, err := r.client.Collection(recipientListsCollection).Find(ctx, filter, opts)
+		if err != nil {
+			return err
+		}
+		lists = nil
+		return cursor.All(ctx, &lists)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return lists, nil
+}
+
+// Update saves a recipient list, including its members, with optimistic locking and tenant isolation
+// list.Version must be the version that was read; it is incremented on success.
+func (r *RecipientListRepository) Update(ctx context.Context, list *domain.RecipientList) error {
+	list.UpdatedAt = time.Now()
+	list.MemberCount = len(list.Members)
+	list.Version++
+
+	filter := bson.M{
+		"_id":       list.ID,
+		"tenantId":  list.TenantID,
+		"deletedAt": nil,
+		"version":   list.Version - 1, // Optimistic locking
+	}
+	update := bson.M{"$set": list}
+
+	collection := r.client.Collection(recipientListsCollection)
+	result, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return versionConflict(ctx, collection, filter)
+	}
+	return nil
+}
+
+// Delete soft deletes a recipient list with tenant isolation
+func (r *RecipientListRepository) Delete(ctx context.Context, tenantID, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	filter := bson.M{
+		"_id":       objectID,
+		"tenantId":  tenantID,
+		"deletedAt": nil,
+	}
+	update := bson.M{
+		"$set": bson.M{"deletedAt": now, "updatedAt": now},
+		"$inc": bson.M{"version": 1},
+	}
+
+	_, err = r.client.Collection(recipientListsCollection).UpdateOne(ctx, filter, update)
+	return err
+}
+
+// RemoveMember removes address from every recipient list of the tenant and returns how many
+// lists contained it. Each changed list gets a new version, so concurrent edits read before
+// the removal fail with a version conflict instead of restoring the member.
+func (r *RecipientListRepository) RemoveMember(ctx context.Context, tenantID, address string) (int64, error) {
+	filter := bson.M{
+		"tenantId":        tenantID,
+		"members.address": recipientFilter(address),
+	}
+	collection := r.client.CriticalCollection(recipientListsCollection)
+	ids, err := collection.Distinct(ctx, "_id", filter)
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	update := bson.M{
+		"$pull": bson.M{"members": bson.M{"address": recipientFilter(address)}},
+		"$set":  bson.M{"updatedAt": time.Now()},
+		"$inc":  bson.M{"version": 1},
+	}
+	if _, err := collection.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": ids}}, update); err != nil {
+		return 0, err
+	}
+
+	// memberCount can only be derived from members once the pull has applied
+	recount := mongo.Pipeline{{{Key: "$set", Value: bson.M{"memberCount": bson.M{"$size": "$members"}}}}}
+	if _, err := collection.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": ids}}, recount); err != nil {
+		return 0, err
+	}
+	return int64(len(ids)), nil
+}
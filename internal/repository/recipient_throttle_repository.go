@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/shared/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const recipientThrottleCollection = "recipient_send_windows"
+
+// RecipientThrottleRepository stores the recent send times of each throttled recipient
+type RecipientThrottleRepository struct {
+	client *mongodb.MongoClient
+}
+
+// NewRecipientThrottleRepository creates a new recipient throttle repository
+func NewRecipientThrottleRepository(client *mongodb.MongoClient) *RecipientThrottleRepository {
+	return &RecipientThrottleRepository{client: client}
+}
+
+// EnsureIndexes creates necessary indexes for optimal query performance
+func (r *RecipientThrottleRepository) EnsureIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().
+				SetName("expires_at_idx").
+				SetExpireAfterSeconds(0), // TTL index
+		},
+	}
+	return r.client.CreateIndexes(ctx, recipientThrottleCollection, indexes)
+}
+
+// Acquire records a send to key at now when fewer than limit sends were recorded after
+// now-window. Otherwise it returns false and when the oldest counted send leaves the window.
+// Sends outside the window are dropped and the check and record are one atomic update, so
+// concurrent sends can't exceed the limit.
+func (r *RecipientThrottleRepository) Acquire(ctx context.Context, key string, now time.Time, window time.Duration, limit int) (bool, time.Time, error) {
+	now = now.Truncate(time.Millisecond) // Stored dates have millisecond precision
+	recent := bson.M{"$filter": bson.M{
+		"input": bson.M{"$ifNull": bson.A{"$sends", bson.A{}}},
+		"cond":  bson.M{"$gt": bson.A{"$$this", now.Add(-window)}},
+	}}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"sends": recent}}},
+		{{Key: "$set", Value: bson.M{"allowed": bson.M{"$lt": bson.A{bson.M{"$size": "$sends"}, limit}}}}},
+		{{Key: "$set", Value: bson.M{
+			"sends":     bson.M{"$cond": bson.A{"$allowed", bson.M{"$concatArrays": bson.A{"$sends", bson.A{now}}}, "$sends"}},
+			"expiresAt": now.Add(window),
+		}}},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var state struct {
+		Sends   []time.Time `bson:"sends"`
+		Allowed bool        `bson:"allowed"`
+	}
+	collection := r.client.Collection(recipientThrottleCollection)
+	err := collection.FindOneAndUpdate(ctx, bson.M{"_id": key}, update, opts).Decode(&state)
+	if mongo.IsDuplicateKeyError(err) {
+		// A concurrent first send inserted the document; update it instead
+		err = collection.FindOneAndUpdate(ctx, bson.M{"_id": key}, update, opts).Decode(&state)
+	}
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	if state.Allowed || len(state.Sends) == 0 {
+		return true, time.Time{}, nil
+	}
+	return false, state.Sends[0].Add(window), nil
+}
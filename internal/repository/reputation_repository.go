@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/shared/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const reputationCollection = "tenant_reputation"
+
+// ReputationRepository handles tenants' bounce and complaint rates and bounce pauses
+type ReputationRepository struct {
+	client *mongodb.MongoClient
+}
+
+// NewReputationRepository creates a new tenant reputation repository
+func NewReputationRepository(client *mongodb.MongoClient) *ReputationRepository {
+	return &ReputationRepository{client: client}
+}
+
+// FindAll returns every tenant's reputation, ordered by tenant ID
+func (r *ReputationRepository) FindAll(ctx context.Context) ([]*domain.TenantReputation, error) {
+	var reputations []*domain.TenantReputation
+	err := retryRead(ctx, "tenant_reputation.find_all", func() error {
+		cursor, err := r.client.Collection(reputationCollection).Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		reputations = nil
+		return cursor.All(ctx, &reputations)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reputations, nil
+}
+
+// Find returns the tenant's reputation, or nil when it has none yet
+func (r *ReputationRepository) Find(ctx context.Context, tenantID string) (*domain.TenantReputation, error) {
+	var reputation domain.TenantReputation
+	err := retryRead(ctx, "tenant_reputation.find", func() error {
+		return r.client.Collection(reputationCollection).FindOne(ctx, bson.M{"_id": tenantID}).Decode(&reputation)
+	})
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &reputation, nil
+}
+
+// SaveRates stores the tenant's latest rates and pause state, leaving any override in place
+func (r *ReputationRepository) SaveRates(ctx context.Context, reputation *domain.TenantReputation) error {
+	reputation.UpdatedAt = time.Now()
+	_, err := r.client.Collection(reputationCollection).UpdateOne(ctx,
+		bson.M{"_id": reputation.TenantID},
+		bson.M{"$set": bson.M{
+			"sent":          reputation.Sent,
+			"bounces":       reputation.Bounces,
+			"complaints":    reputation.Complaints,
+			"bounceRate":    reputation.BounceRate,
+			"complaintRate": reputation.ComplaintRate,
+			"paused":        reputation.Paused,
+			"pausedReason":  reputation.PausedReason,
+			"pausedAt":      reputation.PausedAt,
+			"updatedAt":     reputation.UpdatedAt,
+		}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// SetOverride records an operator's override of the tenant's bounce pause until until
+func (r *ReputationRepository) SetOverride(ctx context.Context, tenantID, override, actor string, until time.Time) error {
+	_, err := r.client.Collection(reputationCollection).UpdateOne(ctx,
+		bson.M{"_id": tenantID},
+		bson.M{"$set": bson.M{
+			"override":      override,
+			"overrideBy":    actor,
+			"overrideUntil": until,
+			"updatedAt":     time.Now(),
+		}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// ClearOverride returns the tenant to automatic control
+func (r *ReputationRepository) ClearOverride(ctx context.Context, tenantID string) error {
+	_, err := r.client.Collection(reputationCollection).UpdateOne(ctx,
+		bson.M{"_id": tenantID},
+		bson.M{
+			"$unset": bson.M{"override": "", "overrideBy": "", "overrideUntil": ""},
+			"$set":   bson.M{"updatedAt": time.Now()},
+		},
+	)
+	return err
+}
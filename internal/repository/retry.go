@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/metrics"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// maxReadAttempts bounds how many times a read is attempted
+	maxReadAttempts = 4
+
+	// Backoff between read attempts doubles from initialReadBackoff up to maxReadBackoff
+	initialReadBackoff = 50 * time.Millisecond
+	maxReadBackoff     = time.Second
+)
+
+// transientErrorCodes are server error codes returned while a replica set elects a new primary
+// or a node shuts down
+var transientErrorCodes = []int{
+	6,     // HostUnreachable
+	7,     // HostNotFound
+	89,    // NetworkTimeout
+	91,    // ShutdownInProgress
+	189,   // PrimarySteppedDown
+	9001,  // SocketException
+	10107, // NotWritablePrimary
+	11600, // InterruptedAtShutdown
+	11602, // InterruptedDueToReplStateChange
+	13435, // NotPrimaryNoSecondaryOk
+	13436, // NotPrimaryOrSecondary
+}
+
+// retryRead runs a read operation, retrying it with capped exponential backoff on transient errors.
+// The driver retries most reads once, but not cursor iteration, so fn should include decoding the
+// results. No attempt is started that could not finish its backoff before ctx's deadline.
+func retryRead(ctx context.Context, operation string, fn func() error) error {
+	backoff := initialReadBackoff
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt == maxReadAttempts || !isTransientError(err) || ctx.Err() != nil {
+			return err
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < backoff {
+			return err
+		}
+
+		metrics.MongoOperationsRetried.WithLabelValues(operation).Inc()
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, maxReadBackoff)
+	}
+}
+
+// isTransientError reports whether err is a network error or a replica set state change
+// that is likely to succeed when retried
+func isTransientError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if mongo.IsNetworkError(err) {
+		return true
+	}
+
+	var serverErr mongo.ServerError
+	if !errors.As(err, &serverErr) {
+		return false
+	}
+	if serverErr.HasErrorLabel("RetryableError") {
+		return true
+	}
+	for _, code := range transientErrorCodes {
+		if serverErr.HasErrorCode(code) {
+			return true
+		}
+	}
+	return false
+}
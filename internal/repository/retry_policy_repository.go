@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/vhvplatform/go-notification-service/internal/cache"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/shared/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const retryPoliciesCollection = "tenant_retry_policies"
+
+// RetryPolicyRepository handles per-tenant retry policy data operations
+type RetryPolicyRepository struct {
+	client *mongodb.MongoClient
+	cache  *cache.Cache // Optional; nil reads every lookup from the database
+}
+
+// NewRetryPolicyRepository creates a new retry policy repository
+func NewRetryPolicyRepository(client *mongodb.MongoClient) *RetryPolicyRepository {
+	return &RetryPolicyRepository{client: client}
+}
+
+// SetCache caches policy lookups in c. Policies are managed outside this service, so
+// changes take effect once cached entries expire.
+func (r *RetryPolicyRepository) SetCache(c *cache.Cache) {
+	r.cache = c
+}
+
+// EnsureIndexes creates necessary indexes for optimal query performance
+func (r *RetryPolicyRepository) EnsureIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "tenantId", Value: 1}, {Key: "channel", Value: 1}},
+			Options: options.Index().SetName("tenant_channel_idx").SetUnique(true),
+		},
+	}
+	return r.client.CreateIndexes(ctx, retryPoliciesCollection, indexes)
+}
+
+// FindRetryPolicy finds the retry policy override for a tenant and channel
+// Returns nil without error when the tenant has no override.
+func (r *RetryPolicyRepository) FindRetryPolicy(ctx context.Context, tenantID string, channel domain.NotificationType) (*domain.TenantRetryPolicy, error) {
+	key := "retry_policy:" + tenantID + ":" + string(channel)
+	var cached domain.TenantRetryPolicy
+	if hit, found := cachedDocument(ctx, r.cache, cache.KindTenantConfig, key, &cached); hit {
+		if !found {
+			return nil, nil
+		}
+		return &cached, nil
+	}
+
+	var policy domain.TenantRetryPolicy
+	filter := bson.M{
+		"tenantId":  tenantID,
+		"channel":   channel,
+		"deletedAt": nil,
+	}
+	err := r.client.Collection(retryPoliciesCollection).FindOne(ctx, filter).Decode(&policy)
+	if err == mongo.ErrNoDocuments {
+		cacheDocument(ctx, r.cache, cache.KindTenantConfig, key, nil)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cacheDocument(ctx, r.cache, cache.KindTenantConfig, key, &policy)
+	return &policy, nil
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestIsTransientError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "network error", err: mongo.CommandError{Labels: []string{"NetworkError"}}, want: true},
+		{name: "primary stepped down", err: mongo.CommandError{Code: 189, Name: "PrimarySteppedDown"}, want: true},
+		{name: "repl state change", err: mongo.CommandError{Code: 11602}, want: true},
+		{name: "retryable label", err: mongo.CommandError{Code: 1, Labels: []string{"RetryableError"}}, want: true},
+		{name: "duplicate key", err: mongo.CommandError{Code: 11000}, want: false},
+		{name: "no documents", err: mongo.ErrNoDocuments, want: false},
+		{name: "context cancelled", err: context.Canceled, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isTransientError(tt.err); got != tt.want {
+				t.Errorf("isTransientError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryRead(t *testing.T) {
+	transient := mongo.CommandError{Code: 189}
+
+	t.Run("retries until success", func(t *testing.T) {
+		calls := 0
+		err := retryRead(context.Background(), "test", func() error {
+			calls++
+			if calls < 3 {
+				return transient
+			}
+			return nil
+		})
+		if err != nil || calls != 3 {
+			t.Errorf("retryRead() error = %v after %d calls, want success after 3", err, calls)
+		}
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		calls := 0
+		err := retryRead(context.Background(), "test", func() error {
+			calls++
+			return transient
+		})
+		var serverErr mongo.ServerError
+		if !errors.As(err, &serverErr) || !serverErr.HasErrorCode(189) || calls != maxReadAttempts {
+			t.Errorf("retryRead() error = %v after %d calls, want transient error after %d", err, calls, maxReadAttempts)
+		}
+	})
+
+	t.Run("does not retry permanent errors", func(t *testing.T) {
+		calls := 0
+		_ = retryRead(context.Background(), "test", func() error {
+			calls++
+			return mongo.CommandError{Code: 11000}
+		})
+		if calls != 1 {
+			t.Errorf("calls = %d, want 1", calls)
+		}
+	})
+
+	t.Run("respects context deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), initialReadBackoff/2)
+		defer cancel()
+
+		calls := 0
+		start := time.Now()
+		_ = retryRead(ctx, "test", func() error {
+			calls++
+			return transient
+		})
+		if calls != 1 || time.Since(start) > initialReadBackoff {
+			t.Errorf("calls = %d in %v, want a single attempt without waiting", calls, time.Since(start))
+		}
+	})
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/shared/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const scheduleExecutionsCollection = "schedule_executions"
+
+// ScheduleExecutionRepository handles the run history of scheduled notifications
+type ScheduleExecutionRepository struct {
+	client     *mongodb.MongoClient
+	outboxRepo *OutboxEventRepository
+}
+
+// NewScheduleExecutionRepository creates a new schedule execution repository
+func NewScheduleExecutionRepository(client *mongodb.MongoClient, outboxRepo *OutboxEventRepository) *ScheduleExecutionRepository {
+	return &ScheduleExecutionRepository{
+		client:     client,
+		outboxRepo: outboxRepo,
+	}
+}
+
+// EnsureIndexes creates necessary indexes for optimal query performance
+func (r *ScheduleExecutionRepository) EnsureIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "tenantId", Value: 1},
+				{Key: "scheduleId", Value: 1},
+				{Key: "startedAt", Value: -1},
+			},
+			Options: options.Index().SetName("tenant_schedule_started_idx"),
+		},
+	}
+	return r.client.CreateIndexes(ctx, scheduleExecutionsCollection, indexes)
+}
+
+// Record stores an execution and updates its schedule's run count, failure count and last
+// status. With an outbox repository, the scheduled_notification.executed event is written in
+// the same transaction. An execution without an ID is given one.
+func (r *ScheduleExecutionRepository) Record(ctx context.Context, execution *domain.ScheduleExecution) error {
+	if execution.ID.IsZero() {
+		execution.ID = primitive.NewObjectID()
+	}
+	execution.CreatedAt = time.Now()
+
+	scheduleID, err := primitive.ObjectIDFromHex(execution.ScheduleID)
+	if err != nil {
+		return err
+	}
+	set := bson.M{
+		"lastRunAt":  execution.StartedAt,
+		"lastStatus": execution.Status,
+		"updatedAt":  execution.CreatedAt,
+	}
+	inc := bson.M{"runCount": 1}
+	update := bson.M{"$set": set, "$inc": inc}
+	if execution.Status == domain.ScheduleExecutionFailed {
+		set["lastError"] = execution.Error
+		inc["failureCount"] = 1
+	} else {
+		update["$unset"] = bson.M{"lastError": ""}
+	}
+	scheduleFilter := bson.M{"_id": scheduleID, "tenantId": execution.TenantID}
+
+	write := func(ctx context.Context) error {
+		if _, err := r.client.Collection(scheduleExecutionsCollection).InsertOne(ctx, execution); err != nil {
+			return err
+		}
+		_, err := r.client.Collection(scheduledNotificationsCollection).UpdateOne(ctx, scheduleFilter, update)
+		return err
+	}
+
+	if r.outboxRepo == nil {
+		return write(ctx)
+	}
+	return r.client.WithTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		if err := write(sessCtx); err != nil {
+			return err
+		}
+		return r.outboxRepo.CreateWithSession(ctx, sessCtx, r.createExecutedEvent(ctx, execution))
+	})
+}
+
+// FindBySchedule returns a schedule's executions, newest first, with tenant isolation
+func (r *ScheduleExecutionRepository) FindBySchedule(ctx context.Context, tenantID, scheduleID string, page, pageSize int) ([]*domain.ScheduleExecution, int64, error) {
+	filter := bson.M{
+		"tenantId":   tenantID,
+		"scheduleId": scheduleID,
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "startedAt", Value: -1}}).
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize))
+
+	var executions []*domain.ScheduleExecution
+	var total int64
+	err := retryRead(ctx, "schedule_executions.find_by_schedule", func() error {
+		var err error
+		total, err = r.client.Collection(scheduleExecutionsCollection).CountDocuments(ctx, filter)
+		if err != nil {
+			return err
+		}
+		cursor, err := r.client.Collection(scheduleExecutionsCollection).Find(ctx, filter, opts)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		executions = nil
+		return cursor.All(ctx, &executions)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	if executions == nil {
+		executions = []*domain.ScheduleExecution{}
+	}
+	return executions, total, nil
+}
+
+// createExecutedEvent creates an outbox event for a schedule execution
+func (r *ScheduleExecutionRepository) createExecutedEvent(ctx context.Context, execution *domain.ScheduleExecution) *domain.OutboxEvent {
+	traceID, spanID := extractTraceContext(ctx)
+
+	payload := domain.ScheduledNotificationExecutedPayload{
+		ScheduleID:      execution.ScheduleID,
+		TenantID:        execution.TenantID,
+		ExecutionID:     execution.ID.Hex(),
+		Status:          execution.Status,
+		Error:           execution.Error,
+		NotificationIDs: execution.NotificationIDs,
+		ExecutedAt:      execution.StartedAt,
+	}
+	if len(execution.NotificationIDs) > 0 {
+		payload.NotificationID = execution.NotificationIDs[0]
+	}
+
+	return &domain.OutboxEvent{
+		TenantID:      execution.TenantID,
+		AggregateType: "scheduled_notification",
+		AggregateID:   execution.ScheduleID,
+		EventType:     domain.EventScheduledNotificationExecuted,
+		Payload:       payload,
+		TraceID:       traceID,
+		SpanID:        spanID,
+		Status:        domain.OutboxEventStatusPending,
+	}
+}
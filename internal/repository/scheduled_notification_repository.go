@@ -1,174 +1,273 @@
-package repository
-
-import (
-	"context"
-	"time"
-
-	"github.com/vhvplatform/go-notification-service/internal/domain"
-	"github.com/vhvplatform/go-notification-service/internal/shared/mongodb"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
-)
-
-const scheduledNotificationsCollection = "scheduled_notifications"
-
-// ScheduledNotificationRepository handles scheduled notification data operations
-type ScheduledNotificationRepository struct {
-	client *mongodb.MongoClient
-}
-
-// NewScheduledNotificationRepository creates a new repository
-func NewScheduledNotificationRepository(client *mongodb.MongoClient) *ScheduledNotificationRepository {
-	return &ScheduledNotificationRepository{client: client}
-}
-
-// EnsureIndexes creates necessary indexes for optimal query performance
-func (r *ScheduledNotificationRepository) EnsureIndexes(ctx context.Context) error {
-	indexes := []mongo.IndexModel{
-		{
-			Keys: bson.D{
-				{Key: "tenantId", Value: 1},
-				{Key: "createdAt", Value: -1},
-			},
-			Options: options.Index().SetName("tenant_created_idx"),
-		},
-		{
-			Keys: bson.D{
-				{Key: "isActive", Value: 1},
-			},
-			Options: options.Index().SetName("is_active_idx"),
-		},
-	}
-
-	return r.client.CreateIndexes(ctx, scheduledNotificationsCollection, indexes)
-}
-
-// Create creates a new scheduled notification
-func (r *ScheduledNotificationRepository) Create(ctx context.Context, scheduled *domain.ScheduledNotification) error {
-	scheduled.ID = primitive.NewObjectID()
-	scheduled.Version = 1
-	scheduled.CreatedAt = time.Now()
-	scheduled.UpdatedAt = time.Now()
-	scheduled.DeletedAt = nil
-
-	_, err := r.client.Collection(scheduledNotificationsCollection).InsertOne(ctx, scheduled)
-	return err
-}
-
-// FindByID finds a scheduled notification by ID with tenant isolation
-func (r *ScheduledNotificationRepository) FindByID(ctx context.Context, id string, tenantID string) (*domain.ScheduledNotification, error) {
-	objectID, err := primitive.ObjectIDFromHex(id)
-	if err != nil {
-		return nil, err
-	}
-
-	var scheduled domain.ScheduledNotification
-	filter := bson.M{
-		"_id":       objectID,
-		"tenantId":  tenantID,
-		"deletedAt": nil,
-	}
-	err = r.client.Collection(scheduledNotificationsCollection).FindOne(ctx, filter).Decode(&scheduled)
-	if err != nil {
-		return nil, err
-	}
-
-	return &scheduled, nil
-}
-
-// FindActive finds all active scheduled notifications (not soft deleted)
-func (r *ScheduledNotificationRepository) FindActive(ctx context.Context) ([]*domain.ScheduledNotification, error) {
-	filter := bson.M{
-		"isActive":  true,
-		"deletedAt": nil,
-	}
-	cursor, err := r.client.Collection(scheduledNotificationsCollection).Find(ctx, filter)
-	if err != nil {
-		return nil, err
-	}
-	defer cursor.Close(ctx)
-
-	var scheduled []*domain.ScheduledNotification
-	if err = cursor.All(ctx, &scheduled); err != nil {
-		return nil, err
-	}
-
-	return scheduled, nil
-}
-
-// FindByTenantID finds scheduled notifications by tenant ID with optimized pagination
-func (r *ScheduledNotificationRepository) FindByTenantID(ctx context.Context, tenantID string, page, pageSize int) ([]*domain.ScheduledNotification, int64, error) {
-	filter := bson.M{
-		"tenantId":  tenantID,
-		"deletedAt": nil,
-	}
-
-	// Calculate pagination
-	skip := (page - 1) * pageSize
-
-	// Use aggregation pipeline for efficient count + results in one query
-	pipeline := mongo.Pipeline{
-		{{Key: "$match", Value: filter}},
-		{{Key: "$facet", Value: bson.M{
-			"metadata": bson.A{bson.M{"$count": "total"}},
-			"data": bson.A{
-				bson.M{"$sort": bson.M{"createdAt": -1}},
-				bson.M{"$skip": skip},
-				bson.M{"$limit": pageSize},
-			},
-		}}},
-	}
-
-	cursor, err := r.client.Collection(scheduledNotificationsCollection).Aggregate(ctx, pipeline)
-	if err != nil {
-		return nil, 0, err
-	}
-	defer cursor.Close(ctx)
-
-	type Result struct {
-		Metadata []struct {
-			Total int64 `bson:"total"`
-		} `bson:"metadata"`
-		Data []*domain.ScheduledNotification `bson:"data"`
-	}
-
-	var results []Result
-	if err = cursor.All(ctx, &results); err != nil {
-		return nil, 0, err
-	}
-
-	if len(results) == 0 || len(results[0].Data) == 0 {
-		return []*domain.ScheduledNotification{}, 0, nil
-	}
-
-	total := int64(0)
-	if len(results[0].Metadata) > 0 {
-		total = results[0].Metadata[0].Total
-	}
-
-	return results[0].Data, total, nil
-}
-
-// Update updates a scheduled notification
-func (r *ScheduledNotificationRepository) Update(ctx context.Context, scheduled *domain.ScheduledNotification) error {
-	scheduled.UpdatedAt = time.Now()
-
-	filter := bson.M{"_id": scheduled.ID}
-	update := bson.M{"$set": scheduled}
-
-	_, err := r.client.Collection(scheduledNotificationsCollection).UpdateOne(ctx, filter, update)
-	return err
-}
-
-// Delete deletes a scheduled notification
-func (r *ScheduledNotificationRepository) Delete(ctx context.Context, id string) error {
-	objectID, err := primitive.ObjectIDFromHex(id)
-	if err != nil {
-		return err
-	}
-
-	_, err = r.client.Collection(scheduledNotificationsCollection).DeleteOne(ctx, bson.M{"_id": objectID})
-	return err
-}
+package repository
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/shared/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const scheduledNotificationsCollection = "scheduled_notifications"
+
+// ScheduledNotificationRepository handles scheduled notification data operations
+type ScheduledNotificationRepository struct {
+	client *mongodb.MongoClient
+}
+
+// NewScheduledNotificationRepository creates a new repository
+func NewScheduledNotificationRepository(client *mongodb.MongoClient) *ScheduledNotificationRepository {
+	return &ScheduledNotificationRepository{client: client}
+}
+
+// EnsureIndexes creates necessary indexes for optimal query performance
+func (r *ScheduledNotificationRepository) EnsureIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "tenantId", Value: 1},
+				{Key: "createdAt", Value: -1},
+			},
+			Options: options.Index().SetName("tenant_created_idx"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "isActive", Value: 1},
+				{Key: "nextRunAt", Value: 1},
+			},
+			Options: options.Index().SetName("active_next_run_idx"),
+		},
+	}
+
+	return r.client.CreateIndexes(ctx, scheduledNotificationsCollection, indexes)
+}
+
+// Create creates a new scheduled notification
+func (r *ScheduledNotificationRepository) Create(ctx context.Context, scheduled *domain.ScheduledNotification) error {
+	scheduled.ID = primitive.NewObjectID()
+	scheduled.Version = 1
+	scheduled.CreatedAt = time.Now()
+	scheduled.UpdatedAt = time.Now()
+	scheduled.DeletedAt = nil
+
+	// Run history is maintained by the scheduler, never supplied by the caller
+	scheduled.LastRunAt = nil
+	scheduled.LastStatus = ""
+	scheduled.LastError = ""
+	scheduled.RunCount = 0
+	scheduled.FailureCount = 0
+
+	_, err := r.client.Collection(scheduledNotificationsCollection).InsertOne(ctx, scheduled)
+	return err
+}
+
+// FindByID finds a scheduled notification by ID with tenant isolation
+func (r *ScheduledNotificationRepository) FindByID(ctx context.Context, id string, tenantID string) (*domain.ScheduledNotification, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var scheduled domain.ScheduledNotification
+	filter := bson.M{
+		"_id":       objectID,
+		"tenantId":  tenantID,
+		"deletedAt": nil,
+	}
+	err = r.client.Collection(scheduledNotificationsCollection).FindOne(ctx, filter).Decode(&scheduled)
+	if err != nil {
+		return nil, err
+	}
+
+	return &scheduled, nil
+}
+
+// FindActive finds all active scheduled notifications (not soft deleted)
+func (r *ScheduledNotificationRepository) FindActive(ctx context.Context) ([]*domain.ScheduledNotification, error) {
+	filter := bson.M{
+		"isActive":  true,
+		"deletedAt": nil,
+	}
+	var scheduled []*domain.ScheduledNotification
+	err := retryRead(ctx, "scheduled_notifications.find_active", func() error {
+		cursor, err := r.client.Collection(scheduledNotificationsCollection).Find(ctx, filter)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		return cursor.All(ctx, &scheduled)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return scheduled, nil
+}
+
+// FindByTenantID finds scheduled notifications by tenant ID with optimized pagination
+func (r *ScheduledNotificationRepository) FindByTenantID(ctx context.Context, tenantID string, page, pageSize int) ([]*domain.ScheduledNotification, int64, error) {
+	filter := bson.M{
+		"tenantId":  tenantID,
+		"deletedAt": nil,
+	}
+
+	// Calculate pagination
+	skip := (page - 1) * pageSize
+
+	// Use aggregation pipeline for efficient count + results in one query
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$facet", Value: bson.M{
+			"metadata": bson.A{bson.M{"$count": "total"}},
+			"data": bson.A{
+				bson.M{"$sort": bson.M{"createdAt": -1}},
+				bson.M{"$skip": skip},
+				bson.M{"$limit": pageSize},
+			},
+		}}},
+	}
+
+	type Result struct {
+		Metadata []struct {
+			Total int64 `bson:"total"`
+		} `bson:"metadata"`
+		Data []*domain.ScheduledNotification `bson:"data"`
+	}
+
+	var results []Result
+	err := retryRead(ctx, "scheduled_notifications.find_by_tenant", func() error {
+		cursor, err := r.client.Collection(scheduledNotificationsCollection).Aggregate(ctx, pipeline)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		return cursor.All(ctx, &results)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(results) == 0 || len(results[0].Data) == 0 {
+		return []*domain.ScheduledNotification{}, 0, nil
+	}
+
+	total := int64(0)
+	if len(results[0].Metadata) > 0 {
+		total = results[0].Metadata[0].Total
+	}
+
+	return results[0].Data, total, nil
+}
+
+// Update updates a scheduled notification
+func (r *ScheduledNotificationRepository) Update(ctx context.Context, scheduled *domain.ScheduledNotification) error {
+	scheduled.UpdatedAt = time.Now()
+
+	filter := bson.M{"_id": scheduled.ID}
+	update := bson.M{"$set": scheduled}
+
+	_, err := r.client.Collection(scheduledNotificationsCollection).UpdateOne(ctx, filter, update)
+	return err
+}
+
+// Delete deletes a scheduled notification
+func (r *ScheduledNotificationRepository) Delete(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.client.Collection(scheduledNotificationsCollection).DeleteOne(ctx, bson.M{"_id": objectID})
+	return err
+}
+
+// EraseRecipient removes recipient from the tenant's scheduled notifications. SMS schedules
+// to the recipient, and email schedules left with no To address or recipient list, are
+// deleted; other email schedules are kept without the recipient's address. Returns the IDs
+// of the updated and of the deleted schedules.
+func (r *ScheduledNotificationRepository) EraseRecipient(ctx context.Context, tenantID, recipient string) ([]primitive.ObjectID, []primitive.ObjectID, error) {
+	collection := r.client.CriticalCollection(scheduledNotificationsCollection)
+	match := recipientFilter(recipient)
+
+	deleted, err := scheduleIDs(ctx, collection, bson.M{
+		"tenantId":   tenantID,
+		"type":       domain.NotificationTypeSMS,
+		"request.to": match,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var changed []primitive.ObjectID
+	for _, field := range []string{"request.to", "request.cc", "request.bcc"} {
+		filter := bson.M{
+			"tenantId": tenantID,
+			"type":     domain.NotificationTypeEmail,
+			field:      match,
+		}
+		ids, err := scheduleIDs(ctx, collection, filter)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(ids) == 0 {
+			continue
+		}
+		update := bson.M{
+			"$pull": bson.M{field: match},
+			"$set":  bson.M{"updatedAt": time.Now()},
+		}
+		if _, err := collection.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": ids}}, update); err != nil {
+			return nil, nil, err
+		}
+		for _, id := range ids {
+			if !slices.Contains(changed, id) {
+				changed = append(changed, id)
+			}
+		}
+	}
+
+	var updated []primitive.ObjectID
+	if len(changed) > 0 {
+		unsendable, err := scheduleIDs(ctx, collection, bson.M{
+			"_id":                       bson.M{"$in": changed},
+			"request.to.0":              bson.M{"$exists": false},
+			"request.recipient_list_id": bson.M{"$in": bson.A{nil, ""}},
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		deleted = append(deleted, unsendable...)
+		for _, id := range changed {
+			if !slices.Contains(unsendable, id) {
+				updated = append(updated, id)
+			}
+		}
+	}
+
+	if len(deleted) > 0 {
+		if _, err := collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": deleted}}); err != nil {
+			return nil, nil, err
+		}
+	}
+	return updated, deleted, nil
+}
+
+// scheduleIDs returns the IDs of the scheduled notifications matching filter
+func scheduleIDs(ctx context.Context, collection *mongo.Collection, filter bson.M) ([]primitive.ObjectID, error) {
+	values, err := collection.Distinct(ctx, "_id", filter)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, 0, len(values))
+	for _, value := range values {
+		if id, ok := value.(primitive.ObjectID); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/shared/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const sendDedupCollection = "send_dedup_fingerprints"
+
+// SendDedupRepository stores short-lived content fingerprints of recent sends
+type SendDedupRepository struct {
+	client *mongodb.MongoClient
+}
+
+// NewSendDedupRepository creates a new send deduplication repository
+func NewSendDedupRepository(client *mongodb.MongoClient) *SendDedupRepository {
+	return &SendDedupRepository{client: client}
+}
+
+// EnsureIndexes creates necessary indexes for optimal query performance
+func (r *SendDedupRepository) EnsureIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().
+				SetName("expires_at_idx").
+				SetExpireAfterSeconds(0), // TTL index
+		},
+	}
+	return r.client.CreateIndexes(ctx, sendDedupCollection, indexes)
+}
+
+// Claim records a tenant's fingerprint until expiresAt
+// It returns false if the fingerprint is already claimed and unexpired. Expired claims are
+// taken over in place, so correctness doesn't depend on when the TTL monitor removes them.
+func (r *SendDedupRepository) Claim(ctx context.Context, tenantID, fingerprint string, expiresAt time.Time) (bool, error) {
+	filter := bson.M{
+		"_id":       tenantID + ":" + fingerprint,
+		"expiresAt": bson.M{"$lte": time.Now()},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"tenantId":  tenantID,
+			"expiresAt": expiresAt,
+		},
+	}
+	_, err := r.client.Collection(sendDedupCollection).UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		// The upsert's insert collided with an unexpired claim
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Release removes a tenant's fingerprint claim
+func (r *SendDedupRepository) Release(ctx context.Context, tenantID, fingerprint string) error {
+	_, err := r.client.Collection(sendDedupCollection).DeleteOne(ctx, bson.M{"_id": tenantID + ":" + fingerprint})
+	return err
+}
@@ -0,0 +1,250 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/shared/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	sendPauseCollection         = "send_pause"
+	heldNotificationsCollection = "held_notifications"
+
+	// sendPauseID is the _id of the single service-wide pause document
+	sendPauseID = "global"
+)
+
+// SendPauseRepository handles the send pause state and notifications held while paused
+type SendPauseRepository struct {
+	client *mongodb.MongoClient
+}
+
+// NewSendPauseRepository creates a new send pause repository
+func NewSendPauseRepository(client *mongodb.MongoClient) *SendPauseRepository {
+	return &SendPauseRepository{client: client}
+}
+
+// EnsureIndexes creates necessary indexes for optimal query performance
+func (r *SendPauseRepository) EnsureIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "type", Value: 1}, {Key: "createdAt", Value: 1}},
+			Options: options.Index().SetName("type_created_idx"),
+		},
+	}
+	return r.client.CreateIndexes(ctx, heldNotificationsCollection, indexes)
+}
+
+// LoadPause loads the send pause state
+// Returns nil without error when sends have never been paused.
+func (r *SendPauseRepository) LoadPause(ctx context.Context) (*domain.SendPause, error) {
+	var pause domain.SendPause
+	err := r.client.Collection(sendPauseCollection).FindOne(ctx, bson.M{"_id": sendPauseID}).Decode(&pause)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &pause, nil
+}
+
+// SavePause replaces the send pause state
+func (r *SendPauseRepository) SavePause(ctx context.Context, pause *domain.SendPause) error {
+	pause.UpdatedAt = time.Now()
+	_, err := r.client.Collection(sendPauseCollection).ReplaceOne(
+		ctx,
+		bson.M{"_id": sendPauseID},
+		pause,
+		options.Replace().SetUpsert(true),
+	)
+	return err
+}
+
+// Hold stores a notification to be sent when its channel resumes
+func (r *SendPauseRepository) Hold(ctx context.Context, held *domain.HeldNotification) error {
+	held.Status = domain.HeldStatusHeld
+	held.CreatedAt = time.Now()
+	_, err := r.client.Collection(heldNotificationsCollection).InsertOne(ctx, held)
+	return err
+}
+
+// ClaimHeld claims the oldest held notification for one of channels that hasn't failed since
+// since, or a claimed one whose send has gone unfinished for staleAfter, as after a crash. The
+// notification stays stored until CompleteHeld, so each is claimed by exactly one instance at
+// a time and none is lost if the send fails. Returns nil when none are held.
+func (r *SendPauseRepository) ClaimHeld(ctx context.Context, channels []domain.NotificationType, since time.Time, staleAfter time.Duration) (*domain.HeldNotification, error) {
+	now := time.Now()
+	filter := bson.M{
+		"type": bson.M{"$in": channels},
+		"$or": bson.A{
+			bson.M{"status": bson.M{"$ne": domain.HeldStatusSending}, "failedAt": bson.M{"$not": bson.M{"$gte": since}}},
+			bson.M{"status": domain.HeldStatusSending, "claimedAt": bson.M{"$lte": now.Add(-staleAfter)}},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":    domain.HeldStatusSending,
+			"claimedAt": now,
+		},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "createdAt", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var held domain.HeldNotification
+	err := r.client.Collection(heldNotificationsCollection).FindOneAndUpdate(ctx, filter, update, opts).Decode(&held)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &held, nil
+}
+
+// CompleteHeld deletes a claimed held notification once it has been sent
+func (r *SendPauseRepository) CompleteHeld(ctx context.Context, id primitive.ObjectID) error {
+	filter := bson.M{"_id": id, "status": domain.HeldStatusSending}
+	_, err := r.client.CriticalCollection(heldNotificationsCollection).DeleteOne(ctx, filter)
+	return err
+}
+
+// ReleaseHeld holds a claimed notification again after its send failed, recording failure
+func (r *SendPauseRepository) ReleaseHeld(ctx context.Context, id primitive.ObjectID, failure string) error {
+	update := bson.M{
+		"$set": bson.M{
+			"status":    domain.HeldStatusHeld,
+			"lastError": failure,
+			"failedAt":  time.Now(),
+		},
+		"$inc":   bson.M{"attempts": 1},
+		"$unset": bson.M{"claimedAt": ""},
+	}
+
+	filter := bson.M{"_id": id, "status": domain.HeldStatusSending}
+	_, err := r.client.CriticalCollection(heldNotificationsCollection).UpdateOne(ctx, filter, update)
+	return err
+}
+
+// CountHeld returns the number of held notifications per channel
+func (r *SendPauseRepository) CountHeld(ctx context.Context) (map[domain.NotificationType]int64, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{"_id": "$type", "count": bson.M{"$sum": 1}}}},
+	}
+	var results []struct {
+		Type  domain.NotificationType `bson:"_id"`
+		Count int64                   `bson:"count"`
+	}
+	err := retryRead(ctx, "held_notifications.count", func() error {
+		cursor, err := r.client.Collection(heldNotificationsCollection).Aggregate(ctx, pipeline)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		return cursor.All(ctx, &results)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[domain.NotificationType]int64, len(results))
+	for _, result := range results {
+		counts[result.Type] = result.Count
+	}
+	return counts, nil
+}
+
+// EraseRecipient removes recipient from the tenant's held notifications and returns how many
+// were changed. Held SMS to the recipient, and held emails left with no To address or
+// recipient list, are deleted; other held emails are kept without the recipient's address.
+func (r *SendPauseRepository) EraseRecipient(ctx context.Context, tenantID, recipient string) (int64, error) {
+	filter := bson.M{
+		"tenantId": tenantID,
+		"type":     bson.M{"$in": []domain.NotificationType{domain.NotificationTypeEmail, domain.NotificationTypeSMS}},
+	}
+	collection := r.client.CriticalCollection(heldNotificationsCollection)
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	var held []*domain.HeldNotification
+	if err := cursor.All(ctx, &held); err != nil {
+		return 0, err
+	}
+
+	var erased int64
+	for _, h := range held {
+		request, remove, err := eraseHeldRecipient(h, recipient)
+		if err != nil {
+			return erased, err
+		}
+		switch {
+		case remove:
+			_, err = collection.DeleteOne(ctx, bson.M{"_id": h.ID})
+		case request != nil:
+			_, err = collection.UpdateOne(ctx, bson.M{"_id": h.ID}, bson.M{"$set": bson.M{"request": request}})
+		default:
+			continue
+		}
+		if err != nil {
+			return erased, err
+		}
+		erased++
+	}
+	return erased, nil
+}
+
+// eraseHeldRecipient removes recipient from a held notification's request. It returns the
+// re-encoded request when the recipient was removed, or remove when the request could no longer
+// be sent; neither when the notification isn't addressed to the recipient.
+func eraseHeldRecipient(held *domain.HeldNotification, recipient string) (json.RawMessage, bool, error) {
+	switch held.Type {
+	case domain.NotificationTypeSMS:
+		var req domain.SendSMSRequest
+		if err := json.Unmarshal(held.Request, &req); err != nil {
+			return nil, false, err
+		}
+		return nil, strings.EqualFold(req.To, recipient), nil
+	case domain.NotificationTypeEmail:
+		var req domain.SendEmailRequest
+		if err := json.Unmarshal(held.Request, &req); err != nil {
+			return nil, false, err
+		}
+		var to, cc, bcc bool
+		req.To, to = removeAddress(req.To, recipient)
+		req.CC, cc = removeAddress(req.CC, recipient)
+		req.BCC, bcc = removeAddress(req.BCC, recipient)
+		if !to && !cc && !bcc {
+			return nil, false, nil
+		}
+		if len(req.To) == 0 && req.RecipientListID == "" {
+			return nil, true, nil
+		}
+		request, err := json.Marshal(&req)
+		return request, false, err
+	}
+	return nil, false, nil
+}
+
+// removeAddress returns addresses without recipient, ignoring case, and whether it was there
+func removeAddress(addresses []string, recipient string) ([]string, bool) {
+	kept := addresses[:0:0]
+	for _, address := range addresses {
+		if !strings.EqualFold(address, recipient) {
+			kept = append(kept, address)
+		}
+	}
+	if len(kept) == len(addresses) {
+		return addresses, false
+	}
+	return kept, true
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/cache"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/shared/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const webhookAllowlistsCollection = "tenant_webhook_allowlists"
+
+// WebhookAllowlistRepository handles per-tenant webhook destination allowlists
+type WebhookAllowlistRepository struct {
+	client *mongodb.MongoClient
+	cache  *cache.Cache // Optional; nil reads every lookup from the database
+}
+
+// NewWebhookAllowlistRepository creates a new webhook allowlist repository
+func NewWebhookAllowlistRepository(client *mongodb.MongoClient) *WebhookAllowlistRepository {
+	return &WebhookAllowlistRepository{client: client}
+}
+
+// SetCache caches allowlist lookups in c, invalidating them when an allowlist is replaced
+func (r *WebhookAllowlistRepository) SetCache(c *cache.Cache) {
+	r.cache = c
+}
+
+// EnsureIndexes creates necessary indexes for optimal query performance
+func (r *WebhookAllowlistRepository) EnsureIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "tenantId", Value: 1}},
+			Options: options.Index().SetName("tenant_idx").SetUnique(true),
+		},
+	}
+	return r.client.CreateIndexes(ctx, webhookAllowlistsCollection, indexes)
+}
+
+// FindWebhookAllowlist finds the webhook destination allowlist for a tenant
+// Returns nil without error when the tenant has no allowlist.
+func (r *WebhookAllowlistRepository) FindWebhookAllowlist(ctx context.Context, tenantID string) (*domain.WebhookAllowlist, error) {
+	key := "webhook_allowlist:" + tenantID
+	var cached domain.WebhookAllowlist
+	if hit, found := cachedDocument(ctx, r.cache, cache.KindTenantConfig, key, &cached); hit {
+		if !found {
+			return nil, nil
+		}
+		return &cached, nil
+	}
+
+	var allowlist domain.WebhookAllowlist
+	filter := bson.M{
+		"tenantId":  tenantID,
+		"deletedAt": nil,
+	}
+	err := retryRead(ctx, "webhook_allowlists.find", func() error {
+		return r.client.Collection(webhookAllowlistsCollection).FindOne(ctx, filter).Decode(&allowlist)
+	})
+	if err == mongo.ErrNoDocuments {
+		cacheDocument(ctx, r.cache, cache.KindTenantConfig, key, nil)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cacheDocument(ctx, r.cache, cache.KindTenantConfig, key, &allowlist)
+	return &allowlist, nil
+}
+
+// Upsert replaces the webhook destination allowlist for a tenant
+func (r *WebhookAllowlistRepository) Upsert(ctx context.Context, tenantID string, domains []string) (*domain.WebhookAllowlist, error) {
+	now := time.Now()
+	filter := bson.M{
+		"tenantId":  tenantID,
+		"deletedAt": nil,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"domains":   domains,
+			"updatedAt": now,
+		},
+		"$inc": bson.M{"version": 1},
+		"$setOnInsert": bson.M{
+			"_id":       primitive.NewObjectID(),
+			"createdAt": now,
+		},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var result domain.WebhookAllowlist
+	err := r.client.Collection(webhookAllowlistsCollection).FindOneAndUpdate(ctx, filter, update, opts).Decode(&result)
+	r.cache.Delete(ctx, cache.KindTenantConfig, "webhook_allowlist:"+tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
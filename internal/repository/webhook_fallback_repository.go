@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/cache"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/shared/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const webhookFallbacksCollection = "tenant_webhook_fallbacks"
+
+// WebhookFallbackRepository handles per-tenant fallback channels for failed webhooks
+type WebhookFallbackRepository struct {
+	client *mongodb.MongoClient
+	cache  *cache.Cache // Optional; nil reads every lookup from the database
+}
+
+// NewWebhookFallbackRepository creates a new webhook fallback repository
+func NewWebhookFallbackRepository(client *mongodb.MongoClient) *WebhookFallbackRepository {
+	return &WebhookFallbackRepository{client: client}
+}
+
+// SetCache caches fallback lookups in c, invalidating them when a fallback is replaced or removed
+func (r *WebhookFallbackRepository) SetCache(c *cache.Cache) {
+	r.cache = c
+}
+
+// EnsureIndexes creates necessary indexes for optimal query performance
+func (r *WebhookFallbackRepository) EnsureIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "tenantId", Value: 1}},
+			Options: options.Index().SetName("tenant_idx").SetUnique(true),
+		},
+	}
+	return r.client.CreateIndexes(ctx, webhookFallbacksCollection, indexes)
+}
+
+// FindWebhookFallback finds the webhook fallback for a tenant
+// Returns nil without error when the tenant has no fallback.
+func (r *WebhookFallbackRepository) FindWebhookFallback(ctx context.Context, tenantID string) (*domain.WebhookFallback, error) {
+	key := "webhook_fallback:" + tenantID
+	var cached domain.WebhookFallback
+	if hit, found := cachedDocument(ctx, r.cache, cache.KindTenantConfig, key, &cached); hit {
+		if !found {
+			return nil, nil
+		}
+		return &cached, nil
+	}
+
+	var fallback domain.WebhookFallback
+	err := retryRead(ctx, "webhook_fallbacks.find", func() error {
+		return r.client.Collection(webhookFallbacksCollection).FindOne(ctx, bson.M{"tenantId": tenantID}).Decode(&fallback)
+	})
+	if err == mongo.ErrNoDocuments {
+		cacheDocument(ctx, r.cache, cache.KindTenantConfig, key, nil)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cacheDocument(ctx, r.cache, cache.KindTenantConfig, key, &fallback)
+	return &fallback, nil
+}
+
+// Upsert replaces the webhook fallback for a tenant
+func (r *WebhookFallbackRepository) Upsert(ctx context.Context, tenantID string, channel domain.FallbackChannel) (*domain.WebhookFallback, error) {
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"channel":   channel,
+			"updatedAt": now,
+		},
+		"$setOnInsert": bson.M{
+			"_id":       primitive.NewObjectID(),
+			"createdAt": now,
+		},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var result domain.WebhookFallback
+	err := r.client.Collection(webhookFallbacksCollection).FindOneAndUpdate(ctx, bson.M{"tenantId": tenantID}, update, opts).Decode(&result)
+	r.cache.Delete(ctx, cache.KindTenantConfig, "webhook_fallback:"+tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Delete removes the webhook fallback for a tenant
+// Returns false without error when the tenant had no fallback.
+func (r *WebhookFallbackRepository) Delete(ctx context.Context, tenantID string) (bool, error) {
+	result, err := r.client.Collection(webhookFallbacksCollection).DeleteOne(ctx, bson.M{"tenantId": tenantID})
+	r.cache.Delete(ctx, cache.KindTenantConfig, "webhook_fallback:"+tenantID)
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/shared/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const webhookRetriesCollection = "webhook_retries"
+
+// webhookRetryRetention is how long sent and failed webhook retries are kept
+const webhookRetryRetention = 7 * 24 * time.Hour
+
+// WebhookRetryRepository queues failed webhooks until their next attempt is due
+type WebhookRetryRepository struct {
+	client *mongodb.MongoClient
+}
+
+// NewWebhookRetryRepository creates a new webhook retry repository
+func NewWebhookRetryRepository(client *mongodb.MongoClient) *WebhookRetryRepository {
+	return &WebhookRetryRepository{client: client}
+}
+
+// EnsureIndexes creates necessary indexes for optimal query performance
+func (r *WebhookRetryRepository) EnsureIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "status", Value: 1},
+				{Key: "dueAt", Value: 1},
+			},
+			Options: options.Index().SetName("status_due_at_idx"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "tenantId", Value: 1},
+				{Key: "createdAt", Value: -1},
+			},
+			Options: options.Index().SetName("tenant_created_at_idx"),
+		},
+		{
+			Keys: bson.D{{Key: "completedAt", Value: 1}},
+			Options: options.Index().
+				SetName("completed_at_idx").
+				SetExpireAfterSeconds(int32(webhookRetryRetention.Seconds())), // TTL index
+		},
+	}
+	return r.client.CreateIndexes(ctx, webhookRetriesCollection, indexes)
+}
+
+// Create queues a webhook retry, pending until its DueAt
+func (r *WebhookRetryRepository) Create(ctx context.Context, retry *domain.WebhookRetry) error {
+	now := time.Now()
+	retry.ID = primitive.NewObjectID()
+	retry.Status = domain.WebhookRetryStatusPending
+	retry.CreatedAt = now
+	retry.UpdatedAt = now
+
+	_, err := r.client.CriticalCollection(webhookRetriesCollection).InsertOne(ctx, retry)
+	return err
+}
+
+// ClaimDue claims the earliest pending retry due by now, or a claimed one whose attempt has
+// gone unfinished for staleAfter, as after a crash. It returns nil when none is due.
+func (r *WebhookRetryRepository) ClaimDue(ctx context.Context, now time.Time, staleAfter time.Duration) (*domain.WebhookRetry, error) {
+	filter := bson.M{
+		"$or": bson.A{
+			bson.M{"status": domain.WebhookRetryStatusPending, "dueAt": bson.M{"$lte": now}},
+			bson.M{"status": domain.WebhookRetryStatusSending, "claimedAt": bson.M{"$lte": now.Add(-staleAfter)}},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":    domain.WebhookRetryStatusSending,
+			"claimedAt": now,
+			"updatedAt": now,
+		},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "dueAt", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var retry domain.WebhookRetry
+	err := r.client.Collection(webhookRetriesCollection).FindOneAndUpdate(ctx, filter, update, opts).Decode(&retry)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &retry, nil
+}
+
+// Reschedule records a failed attempt of a claimed retry and queues the next one at dueAt
+func (r *WebhookRetryRepository) Reschedule(ctx context.Context, id primitive.ObjectID, dueAt time.Time, failure string) error {
+	update := bson.M{
+		"$set": bson.M{
+			"status":    domain.WebhookRetryStatusPending,
+			"dueAt":     dueAt,
+			"lastError": failure,
+			"updatedAt": time.Now(),
+		},
+		"$inc":   bson.M{"attempts": 1},
+		"$unset": bson.M{"claimedAt": ""},
+	}
+
+	filter := bson.M{"_id": id, "status": domain.WebhookRetryStatusSending}
+	_, err := r.client.CriticalCollection(webhookRetriesCollection).UpdateOne(ctx, filter, update)
+	return err
+}
+
+// Complete marks a claimed retry sent, or failed with failure after its last attempt
+func (r *WebhookRetryRepository) Complete(ctx context.Context, id primitive.ObjectID, status domain.WebhookRetryStatus, failure string) error {
+	now := time.Now()
+	set := bson.M{
+		"status":      status,
+		"completedAt": now,
+		"updatedAt":   now,
+	}
+	update := bson.M{"$set": set}
+	if failure != "" {
+		set["lastError"] = failure
+		update["$inc"] = bson.M{"attempts": 1}
+	}
+
+	filter := bson.M{"_id": id, "status": domain.WebhookRetryStatusSending}
+	_, err := r.client.CriticalCollection(webhookRetriesCollection).UpdateOne(ctx, filter, update)
+	return err
+}
@@ -0,0 +1,349 @@
+// Package reputation pauses a tenant's email while its recent hard bounce or complaint rate is
+// high. Sending to a bad list damages the reputation of the IPs and domains every tenant shares,
+// so one tenant's email stops until its rates recover or an operator overrides the pause.
+package reputation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/metrics"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// Defaults used when a Config field is not positive
+const (
+	DefaultWindow             = 24 * time.Hour
+	DefaultInterval           = 5 * time.Minute
+	DefaultBounceThreshold    = 0.05
+	DefaultComplaintThreshold = 0.001
+	DefaultMinVolume          = 100
+	DefaultOverrideDuration   = 24 * time.Hour
+)
+
+// recoveryRatio is the share of a threshold a paused tenant's rate must drop below to resume,
+// so a tenant hovering at the threshold isn't paused and resumed on every evaluation
+const recoveryRatio = 0.8
+
+// ErrTenantPaused is returned for emails of a tenant paused for its bounce or complaint rate
+var ErrTenantPaused = errors.New("tenant email paused for a high bounce or complaint rate")
+
+// PausedError describes an email rejected because its tenant is paused
+type PausedError struct {
+	Reputation *domain.TenantReputation
+}
+
+// Error implements the error interface
+func (e *PausedError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrTenantPaused, e.Reputation.PausedReason)
+}
+
+// Unwrap allows errors.Is(err, ErrTenantPaused)
+func (e *PausedError) Unwrap() error {
+	return ErrTenantPaused
+}
+
+// AsPaused returns the *PausedError in err's chain, if any
+func AsPaused(err error) (*PausedError, bool) {
+	var pausedErr *PausedError
+	ok := errors.As(err, &pausedErr)
+	return pausedErr, ok
+}
+
+// Store interface for tenants' rates, pauses and overrides
+type Store interface {
+	FindAll(ctx context.Context) ([]*domain.TenantReputation, error)
+	Find(ctx context.Context, tenantID string) (*domain.TenantReputation, error)
+	SaveRates(ctx context.Context, reputation *domain.TenantReputation) error
+	SetOverride(ctx context.Context, tenantID, override, actor string, until time.Time) error
+	ClearOverride(ctx context.Context, tenantID string) error
+}
+
+// BounceCounter interface for counting tenants' hard bounces and complaints
+type BounceCounter interface {
+	CountByTenant(ctx context.Context, since time.Time) ([]domain.BounceCounts, error)
+}
+
+// SendCounter interface for counting tenants' sent notifications
+type SendCounter interface {
+	CountSentByTenant(ctx context.Context, channel domain.NotificationType, since time.Time) (map[string]int64, error)
+}
+
+// Config holds bounce rate monitoring settings
+type Config struct {
+	Window             time.Duration // Rates are over bounces and sends this recent
+	Interval           time.Duration // How often rates are recomputed
+	BounceThreshold    float64       // Hard bounce rate at or above which a tenant is paused
+	ComplaintThreshold float64       // Complaint rate at or above which a tenant is paused
+	MinVolume          int64         // Emails a tenant must have sent in the window before it can be paused
+	AllowCritical      bool          // Critical emails are sent even while their tenant is paused
+}
+
+// Monitor recomputes each tenant's bounce and complaint rates periodically and pauses the email
+// of tenants whose rates are too high. Every instance runs a monitor; the rates are shared
+// through the store and each instance enforces the pauses it computes.
+type Monitor struct {
+	store   Store
+	bounces BounceCounter
+	sends   SendCounter
+	config  Config
+	log     *logger.Logger
+	now     func() time.Time
+
+	mu     sync.RWMutex
+	paused map[string]*domain.TenantReputation
+}
+
+// NewMonitor creates a new bounce rate monitor
+func NewMonitor(store Store, bounces BounceCounter, sends SendCounter, config Config, log *logger.Logger) *Monitor {
+	if config.Window <= 0 {
+		config.Window = DefaultWindow
+	}
+	if config.Interval <= 0 {
+		config.Interval = DefaultInterval
+	}
+	if config.BounceThreshold <= 0 {
+		config.BounceThreshold = DefaultBounceThreshold
+	}
+	if config.ComplaintThreshold <= 0 {
+		config.ComplaintThreshold = DefaultComplaintThreshold
+	}
+	if config.MinVolume <= 0 {
+		config.MinVolume = DefaultMinVolume
+	}
+	return &Monitor{
+		store:   store,
+		bounces: bounces,
+		sends:   sends,
+		config:  config,
+		log:     log,
+		now:     time.Now,
+		paused:  make(map[string]*domain.TenantReputation),
+	}
+}
+
+// Start evaluates rates now and then every interval until ctx is cancelled
+func (m *Monitor) Start(ctx context.Context) {
+	m.log.Info("Starting bounce rate monitor", "interval", m.config.Interval, "window", m.config.Window,
+		"bounce_threshold", m.config.BounceThreshold, "complaint_threshold", m.config.ComplaintThreshold)
+
+	go func() {
+		m.evaluateAndLog(ctx)
+
+		ticker := time.NewTicker(m.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				m.log.Info("Bounce rate monitor stopped")
+				return
+			case <-ticker.C:
+				m.evaluateAndLog(ctx)
+			}
+		}
+	}()
+}
+
+// evaluateAndLog evaluates rates, logging a failure
+func (m *Monitor) evaluateAndLog(ctx context.Context) {
+	if err := m.Evaluate(ctx); err != nil && ctx.Err() == nil {
+		m.log.Error("Failed to evaluate bounce rates", "error", err)
+	}
+}
+
+// Paused returns the reputation of the tenant when its email is paused, or nil
+func (m *Monitor) Paused(tenantID string) *domain.TenantReputation {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.paused[tenantID]
+}
+
+// Status returns the tenant's latest rates and pause state
+func (m *Monitor) Status(ctx context.Context, tenantID string) (*domain.TenantReputation, error) {
+	reputation, err := m.store.Find(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if reputation == nil {
+		reputation = &domain.TenantReputation{TenantID: tenantID}
+	}
+	return reputation, nil
+}
+
+// List returns every tenant's latest rates and pause state
+func (m *Monitor) List(ctx context.Context) ([]*domain.TenantReputation, error) {
+	return m.store.FindAll(ctx)
+}
+
+// Override pauses or resumes the tenant's email for duration whatever its rates, or with
+// action clear returns it to automatic control. The change applies at once on this instance
+// and on the others at their next evaluation.
+func (m *Monitor) Override(ctx context.Context, tenantID, action, actor string, duration time.Duration) error {
+	var err error
+	switch action {
+	case domain.ReputationOverridePause, domain.ReputationOverrideResume:
+		if duration <= 0 {
+			duration = DefaultOverrideDuration
+		}
+		err = m.store.SetOverride(ctx, tenantID, action, actor, m.now().Add(duration))
+	case "clear":
+		err = m.store.ClearOverride(ctx, tenantID)
+	default:
+		return fmt.Errorf("unknown override action %q", action)
+	}
+	if err != nil {
+		return err
+	}
+	m.log.Info("Overrode tenant bounce pause", "tenant_id", tenantID, "action", action, "actor", actor, "duration", duration)
+	return m.Evaluate(ctx)
+}
+
+// Evaluate recomputes every tenant's rates over the window, pausing tenants over a threshold
+// and resuming those that recovered, and stores the results
+func (m *Monitor) Evaluate(ctx context.Context) error {
+	now := m.now()
+	since := now.Add(-m.config.Window)
+
+	bounces, err := m.bounces.CountByTenant(ctx, since)
+	if err != nil {
+		return fmt.Errorf("failed to count bounces: %w", err)
+	}
+	sent, err := m.sends.CountSentByTenant(ctx, domain.NotificationTypeEmail, since)
+	if err != nil {
+		return fmt.Errorf("failed to count sent emails: %w", err)
+	}
+	stored, err := m.store.FindAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load tenant reputations: %w", err)
+	}
+
+	reputations := make(map[string]*domain.TenantReputation)
+	previous := make(map[string]*domain.TenantReputation, len(stored))
+	for _, reputation := range stored {
+		previous[reputation.TenantID] = reputation
+		reputations[reputation.TenantID] = &domain.TenantReputation{
+			TenantID:      reputation.TenantID,
+			Override:      reputation.Override,
+			OverrideBy:    reputation.OverrideBy,
+			OverrideUntil: reputation.OverrideUntil,
+		}
+	}
+	get := func(tenantID string) *domain.TenantReputation {
+		if reputations[tenantID] == nil {
+			reputations[tenantID] = &domain.TenantReputation{TenantID: tenantID}
+		}
+		return reputations[tenantID]
+	}
+	for _, counts := range bounces {
+		reputation := get(counts.TenantID)
+		reputation.Bounces, reputation.Complaints = counts.Bounces, counts.Complaints
+	}
+	for tenantID, count := range sent {
+		get(tenantID).Sent = count
+	}
+
+	paused := make(map[string]*domain.TenantReputation)
+	for tenantID, reputation := range reputations {
+		m.decide(reputation, previous[tenantID], now)
+		if err := m.store.SaveRates(ctx, reputation); err != nil {
+			return fmt.Errorf("failed to save reputation of tenant %s: %w", tenantID, err)
+		}
+
+		if reputation.Paused {
+			paused[tenantID] = reputation
+		}
+	}
+	recordReputations(reputations)
+
+	m.mu.Lock()
+	m.paused = paused
+	m.mu.Unlock()
+	return nil
+}
+
+// recordReputations replaces the reputation gauges with the given tenants' rates and pauses.
+// Several tenants may share a label value, so each label reports the highest rate among its
+// tenants and how many of them are paused.
+func recordReputations(reputations map[string]*domain.TenantReputation) {
+	type labelStats struct {
+		bounceRate, complaintRate float64
+		paused                    int
+	}
+	byLabel := make(map[string]*labelStats)
+	for tenantID, reputation := range reputations {
+		label := metrics.TenantLabel(tenantID)
+		stats := byLabel[label]
+		if stats == nil {
+			stats = &labelStats{}
+			byLabel[label] = stats
+		}
+		stats.bounceRate = max(stats.bounceRate, reputation.BounceRate)
+		stats.complaintRate = max(stats.complaintRate, reputation.ComplaintRate)
+		if reputation.Paused {
+			stats.paused++
+		}
+	}
+
+	// Reset so tenants that are gone, or whose label changed, drop out of the gauges
+	metrics.TenantBounceRate.Reset()
+	metrics.TenantEmailPaused.Reset()
+	for label, stats := range byLabel {
+		metrics.TenantBounceRate.WithLabelValues(label, "bounce").Set(stats.bounceRate)
+		metrics.TenantBounceRate.WithLabelValues(label, "complaint").Set(stats.complaintRate)
+		metrics.TenantEmailPaused.WithLabelValues(label).Set(float64(stats.paused))
+	}
+}
+
+// decide computes a tenant's rates and whether it is paused, logging a change from previous
+func (m *Monitor) decide(reputation, previous *domain.TenantReputation, now time.Time) {
+	if reputation.Sent > 0 {
+		reputation.BounceRate = float64(reputation.Bounces) / float64(reputation.Sent)
+		reputation.ComplaintRate = float64(reputation.Complaints) / float64(reputation.Sent)
+	}
+	wasPaused := previous != nil && previous.Paused
+
+	// A paused tenant resumes only once its rates are well under the thresholds
+	bounceThreshold, complaintThreshold := m.config.BounceThreshold, m.config.ComplaintThreshold
+	if wasPaused {
+		bounceThreshold *= recoveryRatio
+		complaintThreshold *= recoveryRatio
+	}
+	switch {
+	case reputation.Sent < m.config.MinVolume:
+	case reputation.BounceRate >= bounceThreshold:
+		reputation.Paused = true
+		reputation.PausedReason = fmt.Sprintf("hard bounce rate %.2f%% over %s is at or above %.2f%%",
+			reputation.BounceRate*100, m.config.Window, m.config.BounceThreshold*100)
+	case reputation.ComplaintRate >= complaintThreshold:
+		reputation.Paused = true
+		reputation.PausedReason = fmt.Sprintf("complaint rate %.3f%% over %s is at or above %.3f%%",
+			reputation.ComplaintRate*100, m.config.Window, m.config.ComplaintThreshold*100)
+	}
+
+	// An unexpired operator override wins over the rates
+	if reputation.Override != "" && reputation.OverrideUntil != nil && now.Before(*reputation.OverrideUntil) {
+		reputation.Paused = reputation.Override == domain.ReputationOverridePause
+		if reputation.Paused {
+			reputation.PausedReason = "paused by " + reputation.OverrideBy
+		} else {
+			reputation.PausedReason = ""
+		}
+	}
+
+	switch {
+	case reputation.Paused && wasPaused && previous.PausedAt != nil:
+		reputation.PausedAt = previous.PausedAt
+	case reputation.Paused:
+		reputation.PausedAt = &now
+		metrics.ReputationPauses.WithLabelValues(metrics.TenantLabel(reputation.TenantID)).Inc()
+		m.log.Error("Paused tenant email for its bounce or complaint rate", "tenant_id", reputation.TenantID, "reason", reputation.PausedReason,
+			"sent", reputation.Sent, "bounces", reputation.Bounces, "complaints", reputation.Complaints)
+	case wasPaused:
+		m.log.Info("Resumed tenant email", "tenant_id", reputation.TenantID, "bounce_rate", reputation.BounceRate, "complaint_rate", reputation.ComplaintRate)
+	}
+}
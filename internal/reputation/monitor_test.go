@@ -0,0 +1,231 @@
+package reputation
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/metrics"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// memoryStore keeps reputations in memory
+type memoryStore struct {
+	reputations map[string]*domain.TenantReputation
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{reputations: make(map[string]*domain.TenantReputation)}
+}
+
+func (s *memoryStore) FindAll(ctx context.Context) ([]*domain.TenantReputation, error) {
+	var reputations []*domain.TenantReputation
+	for _, reputation := range s.reputations {
+		copied := *reputation
+		reputations = append(reputations, &copied)
+	}
+	return reputations, nil
+}
+
+func (s *memoryStore) Find(ctx context.Context, tenantID string) (*domain.TenantReputation, error) {
+	return s.reputations[tenantID], nil
+}
+
+func (s *memoryStore) SaveRates(ctx context.Context, reputation *domain.TenantReputation) error {
+	copied := *reputation
+	s.reputations[reputation.TenantID] = &copied
+	return nil
+}
+
+func (s *memoryStore) SetOverride(ctx context.Context, tenantID, override, actor string, until time.Time) error {
+	reputation := s.reputations[tenantID]
+	if reputation == nil {
+		reputation = &domain.TenantReputation{TenantID: tenantID}
+		s.reputations[tenantID] = reputation
+	}
+	reputation.Override, reputation.OverrideBy, reputation.OverrideUntil = override, actor, &until
+	return nil
+}
+
+func (s *memoryStore) ClearOverride(ctx context.Context, tenantID string) error {
+	if reputation := s.reputations[tenantID]; reputation != nil {
+		reputation.Override, reputation.OverrideBy, reputation.OverrideUntil = "", "", nil
+	}
+	return nil
+}
+
+// fixedCounts reports set bounce and send counts
+type fixedCounts struct {
+	bounces map[string]domain.BounceCounts
+	sent    map[string]int64
+}
+
+func (c *fixedCounts) CountByTenant(ctx context.Context, since time.Time) ([]domain.BounceCounts, error) {
+	var counts []domain.BounceCounts
+	for tenantID, count := range c.bounces {
+		count.TenantID = tenantID
+		counts = append(counts, count)
+	}
+	return counts, nil
+}
+
+func (c *fixedCounts) CountSentByTenant(ctx context.Context, channel domain.NotificationType, since time.Time) (map[string]int64, error) {
+	return c.sent, nil
+}
+
+// countingSender counts the emails that reach it
+type countingSender struct {
+	emails int
+}
+
+func (s *countingSender) SendEmail(ctx context.Context, req *domain.SendEmailRequest) error {
+	s.emails++
+	return nil
+}
+
+func (s *countingSender) SendSMS(ctx context.Context, req *domain.SendSMSRequest) error {
+	return nil
+}
+
+func (s *countingSender) SendWebhook(ctx context.Context, req *domain.SendWebhookRequest) error {
+	return nil
+}
+
+func newTestMonitor(counts *fixedCounts, config Config) (*Monitor, *memoryStore) {
+	store := newMemoryStore()
+	return NewMonitor(store, counts, counts, config, logger.NewLogger()), store
+}
+
+func TestEvaluatePausesAndResumes(t *testing.T) {
+	counts := &fixedCounts{
+		bounces: map[string]domain.BounceCounts{
+			"bad":   {Bounces: 10},
+			"spam":  {Complaints: 2},
+			"small": {Bounces: 5},
+			"good":  {Bounces: 1},
+		},
+		sent: map[string]int64{"bad": 100, "spam": 1000, "small": 10, "good": 100},
+	}
+	monitor, store := newTestMonitor(counts, Config{})
+	ctx := context.Background()
+
+	if err := monitor.Evaluate(ctx); err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	for tenantID, want := range map[string]bool{"bad": true, "spam": true, "small": false, "good": false} {
+		if paused := monitor.Paused(tenantID) != nil; paused != want {
+			t.Errorf("Paused(%s) = %v, want %v", tenantID, paused, want)
+		}
+	}
+	if rate := store.reputations["bad"].BounceRate; rate != 0.1 {
+		t.Errorf("stored bounce rate = %v, want 0.1", rate)
+	}
+
+	// A rate just under the threshold isn't enough to resume
+	counts.bounces["bad"] = domain.BounceCounts{Bounces: 45}
+	counts.sent["bad"] = 1000
+	if err := monitor.Evaluate(ctx); err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	if monitor.Paused("bad") == nil {
+		t.Error("tenant resumed at 4.5%, want it paused until under 4%")
+	}
+
+	counts.bounces["bad"] = domain.BounceCounts{Bounces: 30}
+	if err := monitor.Evaluate(ctx); err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	if monitor.Paused("bad") != nil {
+		t.Error("tenant still paused at 3%")
+	}
+}
+
+func TestOverride(t *testing.T) {
+	counts := &fixedCounts{
+		bounces: map[string]domain.BounceCounts{"bad": {Bounces: 50}},
+		sent:    map[string]int64{"bad": 100, "good": 100},
+	}
+	monitor, _ := newTestMonitor(counts, Config{})
+	ctx := context.Background()
+
+	if err := monitor.Override(ctx, "bad", domain.ReputationOverrideResume, "ops", time.Hour); err != nil {
+		t.Fatalf("Override() error = %v", err)
+	}
+	if monitor.Paused("bad") != nil {
+		t.Error("tenant paused despite a resume override")
+	}
+	if err := monitor.Override(ctx, "good", domain.ReputationOverridePause, "ops", time.Hour); err != nil {
+		t.Fatalf("Override() error = %v", err)
+	}
+	if monitor.Paused("good") == nil {
+		t.Error("tenant sending despite a pause override")
+	}
+
+	// An expired override no longer applies
+	monitor.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if err := monitor.Evaluate(ctx); err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	if monitor.Paused("bad") == nil || monitor.Paused("good") != nil {
+		t.Error("expired overrides still applied")
+	}
+
+	if err := monitor.Override(ctx, "bad", "clear", "ops", 0); err != nil {
+		t.Fatalf("Override() clear error = %v", err)
+	}
+}
+
+func TestPauseSender(t *testing.T) {
+	counts := &fixedCounts{
+		bounces: map[string]domain.BounceCounts{"bad": {Bounces: 50}},
+		sent:    map[string]int64{"bad": 100},
+	}
+	monitor, _ := newTestMonitor(counts, Config{AllowCritical: true})
+	if err := monitor.Evaluate(context.Background()); err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	next := &countingSender{}
+	sender := NewSender(monitor, next)
+	ctx := context.Background()
+
+	err := sender.SendEmail(ctx, &domain.SendEmailRequest{TenantID: "bad"})
+	if !errors.Is(err, ErrTenantPaused) {
+		t.Errorf("SendEmail() for a paused tenant error = %v, want ErrTenantPaused", err)
+	}
+	if pausedErr, ok := AsPaused(err); !ok || pausedErr.Reputation.TenantID != "bad" {
+		t.Errorf("AsPaused() = %v, %v, want the tenant's reputation", pausedErr, ok)
+	}
+	if err := sender.SendEmail(ctx, &domain.SendEmailRequest{TenantID: "bad", Priority: domain.NotificationPriorityCritical}); err != nil {
+		t.Errorf("SendEmail() critical error = %v", err)
+	}
+	if err := sender.SendEmail(ctx, &domain.SendEmailRequest{TenantID: "good"}); err != nil {
+		t.Errorf("SendEmail() for another tenant error = %v", err)
+	}
+	if next.emails != 2 {
+		t.Errorf("emails passed on = %d, want 2", next.emails)
+	}
+}
+
+func TestRecordReputationsAggregatesSharedLabels(t *testing.T) {
+	metrics.SetTenantLabelMode(metrics.TenantLabelDrop)
+	defer metrics.SetTenantLabelMode(metrics.TenantLabelRaw)
+
+	recordReputations(map[string]*domain.TenantReputation{
+		"tenant-a": {TenantID: "tenant-a", BounceRate: 0.12, ComplaintRate: 0.001, Paused: true},
+		"tenant-b": {TenantID: "tenant-b", BounceRate: 0.01, ComplaintRate: 0.004},
+		"tenant-c": {TenantID: "tenant-c", BounceRate: 0.2, Paused: true},
+	})
+
+	if got := testutil.ToFloat64(metrics.TenantBounceRate.WithLabelValues("", "bounce")); got != 0.2 {
+		t.Errorf("bounce rate = %v, want the highest, 0.2", got)
+	}
+	if got := testutil.ToFloat64(metrics.TenantBounceRate.WithLabelValues("", "complaint")); got != 0.004 {
+		t.Errorf("complaint rate = %v, want the highest, 0.004", got)
+	}
+	if got := testutil.ToFloat64(metrics.TenantEmailPaused.WithLabelValues("")); got != 2 {
+		t.Errorf("paused = %v, want 2 paused tenants", got)
+	}
+}
@@ -0,0 +1,44 @@
+package reputation
+
+import (
+	"context"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/sender"
+)
+
+// PauseSender rejects the emails of tenants the monitor has paused before passing the rest to next
+type PauseSender struct {
+	monitor       *Monitor
+	allowCritical bool
+	next          sender.Sender
+}
+
+// NewSender creates a sender that enforces the monitor's bounce pauses
+func NewSender(monitor *Monitor, next sender.Sender) *PauseSender {
+	return &PauseSender{
+		monitor:       monitor,
+		allowCritical: monitor.config.AllowCritical,
+		next:          next,
+	}
+}
+
+// SendEmail sends an email unless its tenant is paused
+func (s *PauseSender) SendEmail(ctx context.Context, req *domain.SendEmailRequest) error {
+	if reputation := s.monitor.Paused(req.TenantID); reputation != nil {
+		if !s.allowCritical || req.Priority != domain.NotificationPriorityCritical {
+			return &PausedError{Reputation: reputation}
+		}
+	}
+	return s.next.SendEmail(ctx, req)
+}
+
+// SendSMS sends an SMS; bounce pauses only apply to email
+func (s *PauseSender) SendSMS(ctx context.Context, req *domain.SendSMSRequest) error {
+	return s.next.SendSMS(ctx, req)
+}
+
+// SendWebhook sends a webhook; bounce pauses only apply to email
+func (s *PauseSender) SendWebhook(ctx context.Context, req *domain.SendWebhookRequest) error {
+	return s.next.SendWebhook(ctx, req)
+}
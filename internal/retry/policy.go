@@ -0,0 +1,140 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	apperrors "github.com/vhvplatform/go-notification-service/internal/shared/errors"
+)
+
+// ErrPermanent marks a send failure that retrying cannot fix, e.g. an invalid recipient
+var ErrPermanent = errors.New("permanent failure")
+
+// permanentError wraps an error so it matches ErrPermanent
+type permanentError struct {
+	err error
+}
+
+// Error implements the error interface
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the wrapped error and ErrPermanent
+func (e *permanentError) Unwrap() []error {
+	return []error{e.err, ErrPermanent}
+}
+
+// Permanent marks err as not retryable
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// Retryable is the default classifier. Cancellation, errors marked Permanent and client
+// errors (4xx application errors other than rate limiting) are not retried; anything else is.
+func Retryable(err error) bool {
+	if err == nil || errors.Is(err, ErrPermanent) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var appErr *apperrors.AppError
+	if errors.As(err, &appErr) {
+		status := appErr.HTTPStatus()
+		return status >= http.StatusInternalServerError || status == http.StatusTooManyRequests
+	}
+	return true
+}
+
+// Policy controls how many times a send is attempted and how long to wait between attempts.
+// The delay after attempt n is BaseDelay * Multiplier^(n-1), capped at MaxDelay, with up to
+// Jitter of it randomized so retries from many sends spread out.
+type Policy struct {
+	MaxAttempts int // Including the first attempt; 1 disables retries
+	BaseDelay   time.Duration
+	Multiplier  float64
+	MaxDelay    time.Duration
+	Jitter      float64          // Fraction of each delay randomized, 0 to 1
+	Retryable   func(error) bool // Classifies errors worth retrying; nil uses Retryable
+}
+
+// DefaultPolicy returns the policy used for channels without a configured default
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Second,
+		Multiplier:  2,
+		MaxDelay:    30 * time.Second,
+		Jitter:      0.2,
+	}
+}
+
+// Delay returns how long to wait after a failed attempt (1-based) before the next one
+func (p Policy) Delay(attempt int) time.Duration {
+	multiplier := max(p.Multiplier, 1)
+	delay := float64(p.BaseDelay) * math.Pow(multiplier, float64(max(attempt-1, 0)))
+	if p.MaxDelay > 0 {
+		delay = math.Min(delay, float64(p.MaxDelay))
+	}
+	if jitter := math.Min(math.Max(p.Jitter, 0), 1); jitter > 0 {
+		delay *= 1 - jitter + 2*jitter*rand.Float64()
+	}
+	return time.Duration(delay)
+}
+
+// ShouldRetry reports whether a send that failed with err on attempt (1-based) should be retried
+func (p Policy) ShouldRetry(attempt int, err error) bool {
+	if attempt >= p.MaxAttempts {
+		return false
+	}
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return Retryable(err)
+}
+
+// Do calls fn until it succeeds, fails with an error that should not be retried, or the
+// policy's attempts are used up. It returns early with the last error if ctx is done.
+func (p Policy) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || !p.ShouldRetry(attempt, err) {
+			return err
+		}
+
+		timer := time.NewTimer(p.Delay(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// withOverride returns the policy with a tenant's non-zero override fields applied
+func (p Policy) withOverride(override *domain.TenantRetryPolicy) Policy {
+	if override.MaxAttempts > 0 {
+		p.MaxAttempts = override.MaxAttempts
+	}
+	if override.BaseDelayMs > 0 {
+		p.BaseDelay = time.Duration(override.BaseDelayMs) * time.Millisecond
+	}
+	if override.Multiplier > 0 {
+		p.Multiplier = override.Multiplier
+	}
+	if override.MaxDelayMs > 0 {
+		p.MaxDelay = time.Duration(override.MaxDelayMs) * time.Millisecond
+	}
+	if override.Jitter > 0 {
+		p.Jitter = override.Jitter
+	}
+	return p
+}
@@ -0,0 +1,149 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	apperrors "github.com/vhvplatform/go-notification-service/internal/shared/errors"
+)
+
+// memoryStore is an in-memory Store for tests
+type memoryStore struct {
+	policies map[domain.NotificationType]*domain.TenantRetryPolicy
+	err      error
+}
+
+func (s *memoryStore) FindRetryPolicy(ctx context.Context, tenantID string, channel domain.NotificationType) (*domain.TenantRetryPolicy, error) {
+	return s.policies[channel], s.err
+}
+
+func TestDelay(t *testing.T) {
+	p := Policy{MaxAttempts: 5, BaseDelay: 100 * time.Millisecond, Multiplier: 2, MaxDelay: 300 * time.Millisecond}
+
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond, 300 * time.Millisecond}
+	for i, w := range want {
+		if got := p.Delay(i + 1); got != w {
+			t.Errorf("Delay(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+}
+
+func TestDelayJitter(t *testing.T) {
+	p := Policy{MaxAttempts: 3, BaseDelay: time.Second, Multiplier: 2, MaxDelay: time.Minute, Jitter: 0.5}
+
+	for i := 0; i < 100; i++ {
+		if got := p.Delay(1); got < 500*time.Millisecond || got > 1500*time.Millisecond {
+			t.Fatalf("Delay(1) = %v, want within 50%% of 1s", got)
+		}
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"plain error", errors.New("connection reset"), true},
+		{"permanent", Permanent(errors.New("mailbox does not exist")), false},
+		{"canceled", context.Canceled, false},
+		{"validation", apperrors.NewValidationError("bad recipient", nil), false},
+		{"rate limited", apperrors.NewRateLimitedError("slow down", nil), true},
+		{"internal", apperrors.NewInternalError("boom", nil), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Retryable(tt.err); got != tt.want {
+				t.Errorf("Retryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDoRetriesUntilSuccess(t *testing.T) {
+	p := Policy{MaxAttempts: 3, BaseDelay: time.Millisecond, Multiplier: 1, MaxDelay: time.Millisecond}
+
+	calls := 0
+	err := p.Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return errors.New("temporary")
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Fatalf("Do() error = %v after %d calls, want nil after 3", err, calls)
+	}
+}
+
+func TestDoStopsOnPermanentError(t *testing.T) {
+	p := Policy{MaxAttempts: 5, BaseDelay: time.Millisecond, Multiplier: 1, MaxDelay: time.Millisecond}
+
+	calls := 0
+	err := p.Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		return Permanent(errors.New("rejected"))
+	})
+	if !errors.Is(err, ErrPermanent) || calls != 1 {
+		t.Fatalf("Do() error = %v after %d calls, want ErrPermanent after 1", err, calls)
+	}
+}
+
+func TestDoGivesUpAfterMaxAttempts(t *testing.T) {
+	p := Policy{MaxAttempts: 2, BaseDelay: time.Millisecond, Multiplier: 1, MaxDelay: time.Millisecond}
+
+	calls := 0
+	err := p.Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		return errors.New("temporary")
+	})
+	if err == nil || calls != 2 {
+		t.Fatalf("Do() error = %v after %d calls, want an error after 2", err, calls)
+	}
+}
+
+func TestResolverAppliesOverride(t *testing.T) {
+	store := &memoryStore{policies: map[domain.NotificationType]*domain.TenantRetryPolicy{
+		domain.NotificationTypeWebhook: {MaxAttempts: 6, MaxDelayMs: 60000},
+	}}
+	r := NewResolver(store, map[domain.NotificationType]Policy{
+		domain.NotificationTypeWebhook: {MaxAttempts: 3, BaseDelay: time.Second, Multiplier: 2, MaxDelay: 10 * time.Second},
+	})
+
+	p, err := r.Policy(context.Background(), "tenant-1", domain.NotificationTypeWebhook)
+	if err != nil {
+		t.Fatalf("Policy() error = %v", err)
+	}
+	if p.MaxAttempts != 6 || p.MaxDelay != time.Minute || p.BaseDelay != time.Second || p.Multiplier != 2 {
+		t.Errorf("Policy() = %+v, want override fields over the default", p)
+	}
+
+	report, err := r.Report(context.Background(), "tenant-1")
+	if err != nil {
+		t.Fatalf("Report() error = %v", err)
+	}
+	if len(report) != len(Channels) {
+		t.Fatalf("Report() returned %d channels, want %d", len(report), len(Channels))
+	}
+	for _, entry := range report {
+		if entry.Overridden != (entry.Channel == domain.NotificationTypeWebhook) {
+			t.Errorf("Report() %s overridden = %v", entry.Channel, entry.Overridden)
+		}
+		if entry.Channel == domain.NotificationTypeEmail && entry.MaxAttempts != DefaultPolicy().MaxAttempts {
+			t.Errorf("Report() email max_attempts = %d, want the built-in default", entry.MaxAttempts)
+		}
+	}
+}
+
+func TestResolverStoreError(t *testing.T) {
+	r := NewResolver(&memoryStore{err: errors.New("mongo down")}, nil)
+
+	if _, err := r.Policy(context.Background(), "tenant-1", domain.NotificationTypeEmail); err == nil {
+		t.Fatal("Policy() error = nil, want the store error")
+	}
+}
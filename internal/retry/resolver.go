@@ -0,0 +1,82 @@
+package retry
+
+import (
+	"context"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+)
+
+// Channels are the notification types with their own retry policy
+var Channels = []domain.NotificationType{
+	domain.NotificationTypeEmail,
+	domain.NotificationTypeSMS,
+	domain.NotificationTypeWebhook,
+}
+
+// Store interface for per-tenant retry policy storage
+type Store interface {
+	FindRetryPolicy(ctx context.Context, tenantID string, channel domain.NotificationType) (*domain.TenantRetryPolicy, error)
+}
+
+// Resolver resolves the retry policy for a tenant and channel: the tenant's override when one
+// exists, applied over the channel default
+type Resolver struct {
+	store    Store // Optional; nil applies the defaults to every tenant
+	defaults map[domain.NotificationType]Policy
+}
+
+// NewResolver creates a new retry policy resolver
+// Channels missing from defaults use DefaultPolicy.
+func NewResolver(store Store, defaults map[domain.NotificationType]Policy) *Resolver {
+	return &Resolver{
+		store:    store,
+		defaults: defaults,
+	}
+}
+
+// Policy returns the retry policy in effect for a tenant and channel
+func (r *Resolver) Policy(ctx context.Context, tenantID string, channel domain.NotificationType) (Policy, error) {
+	policy, _, err := r.resolve(ctx, tenantID, channel)
+	return policy, err
+}
+
+// Report describes the tenant's effective retry policy for every channel
+func (r *Resolver) Report(ctx context.Context, tenantID string) ([]domain.RetryPolicyReport, error) {
+	reports := make([]domain.RetryPolicyReport, 0, len(Channels))
+	for _, channel := range Channels {
+		policy, overridden, err := r.resolve(ctx, tenantID, channel)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, domain.RetryPolicyReport{
+			Channel:     channel,
+			MaxAttempts: policy.MaxAttempts,
+			BaseDelayMs: policy.BaseDelay.Milliseconds(),
+			Multiplier:  policy.Multiplier,
+			MaxDelayMs:  policy.MaxDelay.Milliseconds(),
+			Jitter:      policy.Jitter,
+			Overridden:  overridden,
+		})
+	}
+	return reports, nil
+}
+
+// resolve returns the effective policy and whether a tenant override applies
+func (r *Resolver) resolve(ctx context.Context, tenantID string, channel domain.NotificationType) (Policy, bool, error) {
+	policy, ok := r.defaults[channel]
+	if !ok {
+		policy = DefaultPolicy()
+	}
+	if r.store == nil || tenantID == "" {
+		return policy, false, nil
+	}
+
+	override, err := r.store.FindRetryPolicy(ctx, tenantID, channel)
+	if err != nil {
+		return Policy{}, false, err
+	}
+	if override == nil {
+		return policy, false, nil
+	}
+	return policy.withOverride(override), true, nil
+}
@@ -0,0 +1,116 @@
+package retry
+
+import (
+	"context"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/metrics"
+	"github.com/vhvplatform/go-notification-service/internal/sender"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+	"github.com/vhvplatform/go-notification-service/internal/webhook"
+)
+
+// RetryingSender sends through next, retrying failures according to the tenant's policy for the channel
+type RetryingSender struct {
+	policies *Resolver
+	next     sender.Sender
+	webhooks WebhookStore // Optional; when set, failed webhooks are queued instead of retried inline
+	log      *logger.Logger
+}
+
+// NewSender creates a sender that retries failed sends
+func NewSender(policies *Resolver, next sender.Sender, log *logger.Logger) *RetryingSender {
+	return &RetryingSender{
+		policies: policies,
+		next:     next,
+		log:      log,
+	}
+}
+
+// SetWebhookStore queues failed webhooks in store for a WebhookDispatcher to retry, rather than
+// retrying them while the caller waits
+func (s *RetryingSender) SetWebhookStore(store WebhookStore) {
+	s.webhooks = store
+}
+
+// SendEmail sends an email, retrying failures
+func (s *RetryingSender) SendEmail(ctx context.Context, req *domain.SendEmailRequest) error {
+	return s.do(ctx, req.TenantID, domain.NotificationTypeEmail, func(ctx context.Context) error {
+		return s.next.SendEmail(ctx, req)
+	})
+}
+
+// SendSMS sends an SMS, retrying failures
+func (s *RetryingSender) SendSMS(ctx context.Context, req *domain.SendSMSRequest) error {
+	return s.do(ctx, req.TenantID, domain.NotificationTypeSMS, func(ctx context.Context) error {
+		return s.next.SendSMS(ctx, req)
+	})
+}
+
+// SendWebhook sends a webhook, retrying failures. Every attempt carries the same delivery ID,
+// so receivers can tell a retry from a new delivery.
+// With a webhook store, only the first attempt is made here; a failure that should be retried
+// is queued and nil is returned, so the caller doesn't wait out the backoff.
+func (s *RetryingSender) SendWebhook(ctx context.Context, req *domain.SendWebhookRequest) error {
+	webhook.EnsureDeliveryID(req)
+	if s.webhooks != nil {
+		return s.sendQueued(ctx, req)
+	}
+	return s.do(ctx, req.TenantID, domain.NotificationTypeWebhook, func(ctx context.Context) error {
+		return s.next.SendWebhook(ctx, req)
+	})
+}
+
+// sendQueued attempts a webhook once and queues it for a retry if it fails, marking the request
+// RetryQueued so callers report it pending. If the retry can't be queued, the failure is
+// returned as it would be without retries.
+func (s *RetryingSender) sendQueued(ctx context.Context, req *domain.SendWebhookRequest) error {
+	sendErr := s.next.SendWebhook(ctx, req)
+	if sendErr == nil {
+		return nil
+	}
+	policy := webhookPolicy(s.policy(ctx, req.TenantID, domain.NotificationTypeWebhook), req)
+	if !policy.ShouldRetry(1, sendErr) {
+		return sendErr
+	}
+
+	retry, err := newWebhookRetry(req, policy, time.Now(), sendErr)
+	if err == nil {
+		err = s.webhooks.Create(ctx, retry)
+	}
+	if err != nil {
+		s.log.Error("Failed to queue webhook retry", "error", err, "tenant_id", req.TenantID, "url", req.URL)
+		return sendErr
+	}
+	req.RetryQueued = true
+	metrics.WebhookRetries.WithLabelValues("queued").Inc()
+	s.log.Warn("Webhook failed, retry queued", "error", sendErr, "tenant_id", req.TenantID, "url", req.URL, "due_at", retry.DueAt)
+	return nil
+}
+
+// policy returns the tenant's retry policy for channel
+// If the policy can't be loaded the channel default is used rather than failing the send.
+func (s *RetryingSender) policy(ctx context.Context, tenantID string, channel domain.NotificationType) Policy {
+	policy, err := s.policies.Policy(ctx, tenantID, channel)
+	if err != nil {
+		s.log.Error("Failed to load retry policy, using default", "error", err, "tenant_id", tenantID, "type", channel)
+		policy, _ = NewResolver(nil, s.policies.defaults).Policy(ctx, tenantID, channel)
+	}
+	return policy
+}
+
+// do runs send under the tenant's retry policy for channel
+func (s *RetryingSender) do(ctx context.Context, tenantID string, channel domain.NotificationType, send func(ctx context.Context) error) error {
+	policy := s.policy(ctx, tenantID, channel)
+
+	attempt := 0
+	return policy.Do(ctx, func(ctx context.Context) error {
+		attempt++
+		if attempt > 1 {
+			metrics.SendRetries.WithLabelValues(string(channel)).Inc()
+			s.log.Warn("Retrying send", "type", channel, "tenant_id", tenantID, "attempt", attempt)
+		}
+		return send(ctx)
+	})
+}
@@ -0,0 +1,236 @@
+package retry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/metrics"
+	"github.com/vhvplatform/go-notification-service/internal/middleware"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+	"github.com/vhvplatform/go-notification-service/internal/webhook"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// DefaultWebhookPollInterval is how often due webhook retries are looked for when no interval is configured
+	DefaultWebhookPollInterval = time.Second
+
+	// DefaultWebhookRate is how many webhook retries are attempted per second when no rate is configured
+	DefaultWebhookRate = 20
+
+	// webhookClaimTimeout is how long a claimed retry may go unfinished before it is claimed again
+	webhookClaimTimeout = 5 * time.Minute
+)
+
+// WebhookStore persists failed webhooks until their next attempt is due
+type WebhookStore interface {
+	Create(ctx context.Context, retry *domain.WebhookRetry) error
+	ClaimDue(ctx context.Context, now time.Time, staleAfter time.Duration) (*domain.WebhookRetry, error)
+	Reschedule(ctx context.Context, id primitive.ObjectID, dueAt time.Time, failure string) error
+	Complete(ctx context.Context, id primitive.ObjectID, status domain.WebhookRetryStatus, failure string) error
+}
+
+// WebhookSender sends one webhook attempt
+type WebhookSender interface {
+	SendWebhook(ctx context.Context, req *domain.SendWebhookRequest) error
+}
+
+// PauseChecker reports whether sends on a channel are paused, such as pause.Controller
+type PauseChecker interface {
+	Paused(channel domain.NotificationType, priority domain.NotificationPriority) bool
+}
+
+// WebhookDispatcherConfig holds webhook retry dispatcher settings
+type WebhookDispatcherConfig struct {
+	PollInterval time.Duration // How often due retries are looked for; 0 uses DefaultWebhookPollInterval
+	Rate         float64       // Retries attempted per second across all tenants; 0 uses DefaultWebhookRate
+}
+
+// WebhookDispatcher attempts queued webhook retries once they are due, rescheduling them with
+// the tenant's backoff until they succeed or their attempts are used up. Attempts are rate
+// limited, so a receiver recovering from an outage isn't flooded with retries.
+type WebhookDispatcher struct {
+	store     WebhookStore
+	policies  *Resolver
+	sender    WebhookSender
+	exhausted func(ctx context.Context, req *domain.SendWebhookRequest, cause error)
+	pauses    PauseChecker
+	interval  time.Duration
+	limiter   *rate.Limiter
+	log       *logger.Logger
+}
+
+// NewWebhookDispatcher creates a dispatcher that retries queued webhooks through sender
+func NewWebhookDispatcher(store WebhookStore, policies *Resolver, sender WebhookSender, config WebhookDispatcherConfig, log *logger.Logger) *WebhookDispatcher {
+	interval := config.PollInterval
+	if interval <= 0 {
+		interval = DefaultWebhookPollInterval
+	}
+	limit := config.Rate
+	if limit <= 0 {
+		limit = DefaultWebhookRate
+	}
+	return &WebhookDispatcher{
+		store:    store,
+		policies: policies,
+		sender:   sender,
+		interval: interval,
+		limiter:  rate.NewLimiter(rate.Limit(limit), max(int(limit), 1)),
+		log:      log,
+	}
+}
+
+// SetExhaustedHandler calls fn for each webhook that fails its last attempt, e.g. to notify
+// the webhook's fallback channel
+func (d *WebhookDispatcher) SetExhaustedHandler(fn func(ctx context.Context, req *domain.SendWebhookRequest, cause error)) {
+	d.exhausted = fn
+}
+
+// SetPauses holds queued retries while webhooks are paused; they stay queued and are attempted
+// once webhooks resume. Retries aren't claimed while paused, so critical webhooks wait too.
+func (d *WebhookDispatcher) SetPauses(pauses PauseChecker) {
+	d.pauses = pauses
+}
+
+// Start attempts due webhook retries until ctx is cancelled
+func (d *WebhookDispatcher) Start(ctx context.Context) {
+	d.log.Info("Starting webhook retry dispatcher", "interval", d.interval, "rate", float64(d.limiter.Limit()))
+
+	go func() {
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				d.log.Info("Webhook retry dispatcher stopped")
+				return
+			case <-ticker.C:
+				d.dispatchDue(ctx)
+			}
+		}
+	}()
+}
+
+// dispatchDue attempts retries until none are due or webhooks are paused
+func (d *WebhookDispatcher) dispatchDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		if d.pauses != nil && d.pauses.Paused(domain.NotificationTypeWebhook, domain.NotificationPriorityNormal) {
+			return
+		}
+		if err := d.limiter.Wait(ctx); err != nil {
+			return
+		}
+		retry, err := d.store.ClaimDue(ctx, time.Now(), webhookClaimTimeout)
+		if err != nil {
+			if ctx.Err() == nil {
+				d.log.Error("Failed to claim due webhook retry", "error", err)
+			}
+			return
+		}
+		if retry == nil {
+			return
+		}
+		d.attempt(ctx, retry, time.Now())
+	}
+}
+
+// attempt sends a claimed retry, then completes or reschedules it
+func (d *WebhookDispatcher) attempt(ctx context.Context, retry *domain.WebhookRetry, now time.Time) {
+	req, err := decodeWebhookRequest(retry)
+	if err != nil {
+		// A request that can't be decoded won't decode on a later attempt either
+		d.log.Error("Failed to decode queued webhook", "error", err, "retry_id", retry.ID.Hex(), "tenant_id", retry.TenantID)
+		d.complete(ctx, retry, domain.WebhookRetryStatusFailed, err)
+		return
+	}
+
+	attempt := retry.Attempts + 1
+	metrics.SendRetries.WithLabelValues(string(domain.NotificationTypeWebhook)).Inc()
+	d.log.Warn("Retrying send", "type", domain.NotificationTypeWebhook, "tenant_id", retry.TenantID, "attempt", attempt, "retry_id", retry.ID.Hex())
+
+	sendErr := d.sender.SendWebhook(middleware.WithTenantID(ctx, retry.TenantID), req)
+	if sendErr == nil {
+		d.complete(ctx, retry, domain.WebhookRetryStatusSent, nil)
+		d.log.Info("Sent queued webhook", "retry_id", retry.ID.Hex(), "tenant_id", retry.TenantID, "url", retry.URL, "attempt", attempt)
+		return
+	}
+
+	if errors.Is(sendErr, webhook.ErrDestinationNotAllowed) {
+		// The destination was removed from the tenant's allowlist while the retry was queued
+		sendErr = Permanent(sendErr)
+	}
+	policy, err := d.policies.Policy(ctx, retry.TenantID, domain.NotificationTypeWebhook)
+	if err != nil {
+		d.log.Error("Failed to load retry policy, using default", "error", err, "tenant_id", retry.TenantID, "type", domain.NotificationTypeWebhook)
+		policy, _ = NewResolver(nil, d.policies.defaults).Policy(ctx, retry.TenantID, domain.NotificationTypeWebhook)
+	}
+	// The attempts are fixed when the retry is queued, so a policy change doesn't strand it
+	policy.MaxAttempts = retry.MaxAttempts
+	if !policy.ShouldRetry(attempt, sendErr) {
+		d.log.Error("Queued webhook failed after its last attempt", "error", sendErr, "retry_id", retry.ID.Hex(), "tenant_id", retry.TenantID, "url", retry.URL, "attempts", attempt)
+		d.complete(ctx, retry, domain.WebhookRetryStatusFailed, sendErr)
+		if d.exhausted != nil {
+			d.exhausted(ctx, req, sendErr)
+		}
+		return
+	}
+
+	metrics.WebhookRetries.WithLabelValues("rescheduled").Inc()
+	if err := d.store.Reschedule(ctx, retry.ID, now.Add(policy.Delay(attempt)), sendErr.Error()); err != nil {
+		d.log.Error("Failed to reschedule webhook retry", "error", err, "retry_id", retry.ID.Hex())
+	}
+}
+
+// complete marks a claimed retry sent, or failed with cause
+func (d *WebhookDispatcher) complete(ctx context.Context, retry *domain.WebhookRetry, status domain.WebhookRetryStatus, cause error) {
+	metrics.WebhookRetries.WithLabelValues(string(status)).Inc()
+	failure := ""
+	if cause != nil {
+		failure = cause.Error()
+	}
+	if err := d.store.Complete(ctx, retry.ID, status, failure); err != nil {
+		d.log.Error("Failed to complete webhook retry", "error", err, "retry_id", retry.ID.Hex(), "status", status)
+	}
+}
+
+// webhookPolicy applies a request's retry_attempts, when set, over the tenant's policy
+func webhookPolicy(policy Policy, req *domain.SendWebhookRequest) Policy {
+	if req.RetryAttempts > 0 {
+		policy.MaxAttempts = req.RetryAttempts + 1
+	}
+	return policy
+}
+
+// newWebhookRetry queues req, whose first attempt failed with cause at now, for its second attempt
+func newWebhookRetry(req *domain.SendWebhookRequest, policy Policy, now time.Time, cause error) (*domain.WebhookRetry, error) {
+	request, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode webhook request: %w", err)
+	}
+	return &domain.WebhookRetry{
+		TenantID:    req.TenantID,
+		URL:         req.URL,
+		DeliveryID:  req.DeliveryID,
+		Request:     request,
+		DueAt:       now.Add(policy.Delay(1)),
+		Attempts:    1,
+		MaxAttempts: policy.MaxAttempts,
+		LastError:   cause.Error(),
+	}, nil
+}
+
+// decodeWebhookRequest returns the request a retry was queued for, with its delivery ID
+func decodeWebhookRequest(retry *domain.WebhookRetry) (*domain.SendWebhookRequest, error) {
+	var req domain.SendWebhookRequest
+	if err := json.Unmarshal(retry.Request, &req); err != nil {
+		return nil, fmt.Errorf("failed to decode webhook request: %w", err)
+	}
+	req.DeliveryID = retry.DeliveryID
+	return &req, nil
+}
@@ -0,0 +1,252 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/sender"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+	"github.com/vhvplatform/go-notification-service/internal/webhook"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryWebhookStore is an in-memory WebhookStore for tests
+type memoryWebhookStore struct {
+	retries map[primitive.ObjectID]*domain.WebhookRetry
+}
+
+func newMemoryWebhookStore() *memoryWebhookStore {
+	return &memoryWebhookStore{retries: make(map[primitive.ObjectID]*domain.WebhookRetry)}
+}
+
+func (s *memoryWebhookStore) Create(ctx context.Context, retry *domain.WebhookRetry) error {
+	retry.ID = primitive.NewObjectID()
+	retry.Status = domain.WebhookRetryStatusPending
+	s.retries[retry.ID] = retry
+	return nil
+}
+
+func (s *memoryWebhookStore) ClaimDue(ctx context.Context, now time.Time, staleAfter time.Duration) (*domain.WebhookRetry, error) {
+	for _, retry := range s.retries {
+		if retry.Status == domain.WebhookRetryStatusPending && !retry.DueAt.After(now) {
+			retry.Status = domain.WebhookRetryStatusSending
+			return retry, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *memoryWebhookStore) Reschedule(ctx context.Context, id primitive.ObjectID, dueAt time.Time, failure string) error {
+	retry := s.retries[id]
+	retry.Status, retry.DueAt, retry.LastError = domain.WebhookRetryStatusPending, dueAt, failure
+	retry.Attempts++
+	return nil
+}
+
+func (s *memoryWebhookStore) Complete(ctx context.Context, id primitive.ObjectID, status domain.WebhookRetryStatus, failure string) error {
+	retry := s.retries[id]
+	retry.Status = status
+	if failure != "" {
+		retry.LastError = failure
+		retry.Attempts++
+	}
+	return nil
+}
+
+// only returns the single queued retry
+func (s *memoryWebhookStore) only(t *testing.T) *domain.WebhookRetry {
+	t.Helper()
+	if len(s.retries) != 1 {
+		t.Fatalf("%d retries queued, want 1", len(s.retries))
+	}
+	for _, retry := range s.retries {
+		return retry
+	}
+	return nil
+}
+
+// failingSender fails its first failures webhook sends with err
+type failingSender struct {
+	sender.Sender
+	failures int
+	err      error
+	sent     []*domain.SendWebhookRequest
+}
+
+func (s *failingSender) SendWebhook(ctx context.Context, req *domain.SendWebhookRequest) error {
+	s.sent = append(s.sent, req)
+	if len(s.sent) <= s.failures {
+		return s.err
+	}
+	return nil
+}
+
+func webhookPolicies() *Resolver {
+	return NewResolver(nil, map[domain.NotificationType]Policy{
+		domain.NotificationTypeWebhook: {MaxAttempts: 3, BaseDelay: time.Minute, Multiplier: 2, MaxDelay: time.Hour},
+	})
+}
+
+func TestSendWebhookQueuesRetry(t *testing.T) {
+	store := newMemoryWebhookStore()
+	next := &failingSender{failures: 1, err: errors.New("connection refused")}
+	sender := NewSender(webhookPolicies(), next, logger.NewLogger())
+	sender.SetWebhookStore(store)
+
+	req := &domain.SendWebhookRequest{TenantID: "tenant-1", URL: "https://example.com/hook", Payload: map[string]any{"event": "signup"}}
+	before := time.Now()
+	if err := sender.SendWebhook(context.Background(), req); err != nil {
+		t.Fatalf("SendWebhook() error = %v, want nil once the retry is queued", err)
+	}
+	if !req.RetryQueued {
+		t.Error("request not marked RetryQueued, so the caller would report it sent")
+	}
+	if len(next.sent) != 1 {
+		t.Errorf("%d attempts made inline, want 1", len(next.sent))
+	}
+
+	retry := store.only(t)
+	if retry.Attempts != 1 || retry.MaxAttempts != 3 || retry.DeliveryID != req.DeliveryID || retry.DueAt.Before(before.Add(time.Minute)) {
+		t.Errorf("queued retry = %+v, want 1 of 3 attempts made, due in a minute", retry)
+	}
+	decoded, err := decodeWebhookRequest(retry)
+	if err != nil || decoded.URL != req.URL || decoded.Payload["event"] != "signup" || decoded.DeliveryID != req.DeliveryID {
+		t.Errorf("decoded request = %+v, %v; want the original request", decoded, err)
+	}
+}
+
+func TestSendWebhookPermanentFailureNotQueued(t *testing.T) {
+	store := newMemoryWebhookStore()
+	next := &failingSender{failures: 1, err: Permanent(errors.New("404 Not Found"))}
+	sender := NewSender(webhookPolicies(), next, logger.NewLogger())
+	sender.SetWebhookStore(store)
+
+	if err := sender.SendWebhook(context.Background(), &domain.SendWebhookRequest{TenantID: "tenant-1", URL: "https://example.com/hook"}); !errors.Is(err, ErrPermanent) {
+		t.Errorf("SendWebhook() error = %v, want the permanent failure", err)
+	}
+	if len(store.retries) != 0 {
+		t.Errorf("%d retries queued for a permanent failure", len(store.retries))
+	}
+}
+
+func TestWebhookDispatcherRetriesUntilSent(t *testing.T) {
+	store := newMemoryWebhookStore()
+	next := &failingSender{failures: 2, err: errors.New("503 Service Unavailable")}
+	sender := NewSender(webhookPolicies(), next, logger.NewLogger())
+	sender.SetWebhookStore(store)
+	dispatcher := NewWebhookDispatcher(store, webhookPolicies(), next, WebhookDispatcherConfig{Rate: 1000}, logger.NewLogger())
+
+	req := &domain.SendWebhookRequest{TenantID: "tenant-1", URL: "https://example.com/hook"}
+	if err := sender.SendWebhook(context.Background(), req); err != nil {
+		t.Fatalf("SendWebhook() error = %v", err)
+	}
+	retry := store.only(t)
+
+	now := time.Now().Add(time.Minute + time.Second)
+	dispatcher.attempt(context.Background(), retry, now)
+	if retry.Status != domain.WebhookRetryStatusPending || retry.Attempts != 2 || retry.DueAt.Before(now.Add(2*time.Minute)) {
+		t.Errorf("retry after second attempt = %+v, want pending, due after the second backoff", retry)
+	}
+
+	retry.Status = domain.WebhookRetryStatusSending
+	dispatcher.attempt(context.Background(), retry, now.Add(2*time.Minute))
+	if retry.Status != domain.WebhookRetryStatusSent {
+		t.Errorf("retry status = %s, want sent", retry.Status)
+	}
+	if len(next.sent) != 3 || next.sent[2].DeliveryID != req.DeliveryID {
+		t.Errorf("%d attempts made, want 3 with the same delivery ID", len(next.sent))
+	}
+}
+
+func TestWebhookDispatcherExhausted(t *testing.T) {
+	store := newMemoryWebhookStore()
+	next := &failingSender{failures: 10, err: errors.New("timeout")}
+	sender := NewSender(webhookPolicies(), next, logger.NewLogger())
+	sender.SetWebhookStore(store)
+	dispatcher := NewWebhookDispatcher(store, webhookPolicies(), next, WebhookDispatcherConfig{Rate: 1000}, logger.NewLogger())
+	var exhausted []*domain.SendWebhookRequest
+	dispatcher.SetExhaustedHandler(func(ctx context.Context, req *domain.SendWebhookRequest, cause error) {
+		exhausted = append(exhausted, req)
+	})
+
+	// The request's retry_attempts overrides the policy's 3 attempts
+	req := &domain.SendWebhookRequest{TenantID: "tenant-1", URL: "https://example.com/hook", RetryAttempts: 1}
+	if err := sender.SendWebhook(context.Background(), req); err != nil {
+		t.Fatalf("SendWebhook() error = %v", err)
+	}
+	retry := store.only(t)
+	if retry.MaxAttempts != 2 {
+		t.Fatalf("max attempts = %d, want retry_attempts + 1", retry.MaxAttempts)
+	}
+
+	dispatcher.dispatchDue(context.Background()) // Not due yet
+	if len(next.sent) != 1 {
+		t.Fatalf("retry attempted before it was due")
+	}
+	retry.DueAt = time.Now()
+	dispatcher.dispatchDue(context.Background())
+	if retry.Status != domain.WebhookRetryStatusFailed || retry.Attempts != 2 {
+		t.Errorf("retry = %+v, want failed after 2 attempts", retry)
+	}
+	if len(exhausted) != 1 || exhausted[0].URL != req.URL {
+		t.Errorf("exhausted handler called with %v, want the failed webhook", exhausted)
+	}
+}
+
+// pausedChannels is a PauseChecker with a fixed set of paused channels
+type pausedChannels map[domain.NotificationType]bool
+
+func (p pausedChannels) Paused(channel domain.NotificationType, priority domain.NotificationPriority) bool {
+	return p[channel]
+}
+
+func TestWebhookDispatcherHoldsRetriesWhilePaused(t *testing.T) {
+	store := newMemoryWebhookStore()
+	next := &failingSender{failures: 1, err: errors.New("503 Service Unavailable")}
+	sender := NewSender(webhookPolicies(), next, logger.NewLogger())
+	sender.SetWebhookStore(store)
+	dispatcher := NewWebhookDispatcher(store, webhookPolicies(), next, WebhookDispatcherConfig{Rate: 1000}, logger.NewLogger())
+	pauses := pausedChannels{domain.NotificationTypeWebhook: true}
+	dispatcher.SetPauses(pauses)
+
+	if err := sender.SendWebhook(context.Background(), &domain.SendWebhookRequest{TenantID: "tenant-1", URL: "https://example.com/hook"}); err != nil {
+		t.Fatalf("SendWebhook() error = %v", err)
+	}
+	retry := store.only(t)
+	retry.DueAt = time.Now()
+
+	dispatcher.dispatchDue(context.Background())
+	if len(next.sent) != 1 || retry.Status != domain.WebhookRetryStatusPending {
+		t.Fatalf("retry = %+v after %d attempts, want it left pending while webhooks are paused", retry, len(next.sent))
+	}
+
+	delete(pauses, domain.NotificationTypeWebhook)
+	dispatcher.dispatchDue(context.Background())
+	if len(next.sent) != 2 || retry.Status != domain.WebhookRetryStatusSent {
+		t.Errorf("retry = %+v after %d attempts, want it sent once webhooks resume", retry, len(next.sent))
+	}
+}
+
+func TestWebhookDispatcherFailsDisallowedDestination(t *testing.T) {
+	store := newMemoryWebhookStore()
+	next := &failingSender{failures: 10, err: errors.New("503 Service Unavailable")}
+	sender := NewSender(webhookPolicies(), next, logger.NewLogger())
+	sender.SetWebhookStore(store)
+	dispatcher := NewWebhookDispatcher(store, webhookPolicies(), next, WebhookDispatcherConfig{Rate: 1000}, logger.NewLogger())
+
+	if err := sender.SendWebhook(context.Background(), &domain.SendWebhookRequest{TenantID: "tenant-1", URL: "https://example.com/hook"}); err != nil {
+		t.Fatalf("SendWebhook() error = %v", err)
+	}
+	retry := store.only(t)
+
+	// The destination was removed from the allowlist while the retry was queued
+	next.err = &webhook.DestinationError{URL: retry.URL, Host: "example.com"}
+	retry.Status = domain.WebhookRetryStatusSending
+	dispatcher.attempt(context.Background(), retry, time.Now())
+	if retry.Status != domain.WebhookRetryStatusFailed || retry.Attempts != 2 {
+		t.Errorf("retry = %+v, want failed without further attempts", retry)
+	}
+}
@@ -0,0 +1,111 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Cron expression formats
+const (
+	FormatFiveField  = "5-field"    // minute hour day-of-month month day-of-week
+	FormatSixField   = "6-field"    // second minute hour day-of-month month day-of-week
+	FormatDescriptor = "descriptor" // @hourly, @daily, @every 10m, ...
+)
+
+// Syntax selects the schedule expression formats accepted on top of standard 5-field cron
+type Syntax struct {
+	Seconds     bool // Allow a leading seconds field (6-field expressions)
+	Descriptors bool // Allow descriptors such as @daily and @every 10m
+}
+
+// parser returns the cron parser for the syntax
+// The scheduler registers schedules with this parser, so an expression that parses will run.
+func (s Syntax) parser() cron.Parser {
+	options := cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow
+	if s.Seconds {
+		options |= cron.SecondOptional
+	}
+	if s.Descriptors {
+		options |= cron.Descriptor
+	}
+	return cron.NewParser(options)
+}
+
+// Expression is a parsed schedule expression
+type Expression struct {
+	Schedule cron.Schedule
+	Format   string
+	Location *time.Location
+}
+
+// ParseExpression parses a schedule expression, evaluated in timezone (an IANA name such as
+// Europe/Berlin). An empty timezone means the server's local time.
+func (s Syntax) ParseExpression(expr, timezone string) (*Expression, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, errors.New("expression is empty")
+	}
+	if strings.HasPrefix(expr, "TZ=") || strings.HasPrefix(expr, "CRON_TZ=") {
+		return nil, errors.New("set the timezone separately instead of with a TZ= prefix")
+	}
+
+	location := time.Local
+	if timezone != "" {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, fmt.Errorf("unknown timezone %q", timezone)
+		}
+		location = loc
+	}
+
+	format := FormatDescriptor
+	if strings.HasPrefix(expr, "@") {
+		if !s.Descriptors {
+			return nil, errors.New("descriptors such as @daily are not enabled")
+		}
+	} else {
+		switch fields := len(strings.Fields(expr)); {
+		case fields == 5:
+			format = FormatFiveField
+		case fields == 6 && s.Seconds:
+			format = FormatSixField
+		case s.Seconds:
+			return nil, fmt.Errorf("expected 5 fields, or 6 with seconds, got %d", fields)
+		default:
+			return nil, fmt.Errorf("expected 5 fields, got %d (a seconds field is not enabled)", fields)
+		}
+	}
+
+	schedule, err := s.parser().Parse(Spec(expr, timezone))
+	if err != nil {
+		return nil, err
+	}
+	return &Expression{Schedule: schedule, Format: format, Location: location}, nil
+}
+
+// Spec returns expr with timezone applied as a CRON_TZ prefix, as registered with cron
+func Spec(expr, timezone string) string {
+	expr = strings.TrimSpace(expr)
+	if timezone == "" {
+		return expr
+	}
+	return "CRON_TZ=" + timezone + " " + expr
+}
+
+// Next returns the next count run times after from, in the expression's timezone
+func (e *Expression) Next(from time.Time, count int) []time.Time {
+	runs := make([]time.Time, 0, count)
+	next := from
+	for len(runs) < count {
+		next = e.Schedule.Next(next)
+		if next.IsZero() {
+			break // The schedule never fires again, e.g. February 30th
+		}
+		runs = append(runs, next.In(e.Location))
+	}
+	return runs
+}
@@ -1,208 +1,432 @@
-package scheduler
-
-import (
-	"context"
-	"encoding/json"
-	"time"
-
-	"github.com/robfig/cron/v3"
-	"github.com/vhvplatform/go-notification-service/internal/domain"
-	"github.com/vhvplatform/go-notification-service/internal/repository"
-	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
-)
-
-// NotificationScheduler manages scheduled notifications
-type NotificationScheduler struct {
-	cron    *cron.Cron
-	service SchedulerService
-	repo    *repository.ScheduledNotificationRepository
-	log     *logger.Logger
-	entries map[string]cron.EntryID // Maps notification ID to cron entry ID
-}
-
-// SchedulerService interface for notification operations
-type SchedulerService interface {
-	SendEmail(ctx context.Context, req *domain.SendEmailRequest) error
-	SendSMS(ctx context.Context, req *domain.SendSMSRequest) error
-	SendWebhook(ctx context.Context, req *domain.SendWebhookRequest) error
-}
-
-// NewNotificationScheduler creates a new notification scheduler
-func NewNotificationScheduler(service SchedulerService, repo *repository.ScheduledNotificationRepository, log *logger.Logger) *NotificationScheduler {
-	return &NotificationScheduler{
-		cron:    cron.New(),
-		service: service,
-		repo:    repo,
-		log:     log,
-		entries: make(map[string]cron.EntryID),
-	}
-}
-
-// Start starts the scheduler and loads active schedules
-func (s *NotificationScheduler) Start() error {
-	s.log.Info("Starting notification scheduler")
-
-	// Load all active scheduled notifications
-	ctx := context.Background()
-	scheduled, err := s.repo.FindActive(ctx)
-	if err != nil {
-		return err
-	}
-
-	// Register each scheduled notification
-	for _, sched := range scheduled {
-		if err := s.registerSchedule(sched); err != nil {
-			s.log.Error("Failed to register schedule", "error", err, "id", sched.ID)
-		}
-	}
-
-	s.cron.Start()
-	s.log.Info("Notification scheduler started", "active_schedules", len(scheduled))
-	return nil
-}
-
-// Stop stops the scheduler
-func (s *NotificationScheduler) Stop() {
-	s.log.Info("Stopping notification scheduler")
-	s.cron.Stop()
-}
-
-// registerSchedule registers a scheduled notification with cron
-func (s *NotificationScheduler) registerSchedule(sched *domain.ScheduledNotification) error {
-	entryID, err := s.cron.AddFunc(sched.Schedule, func() {
-		s.executeSchedule(sched)
-	})
-
-	if err != nil {
-		return err
-	}
-
-	s.entries[sched.ID.Hex()] = entryID
-	s.log.Info("Registered schedule", "id", sched.ID.Hex(), "schedule", sched.Schedule, "type", sched.Type)
-	return nil
-}
-
-// executeSchedule executes a scheduled notification
-func (s *NotificationScheduler) executeSchedule(sched *domain.ScheduledNotification) {
-	ctx := context.Background()
-	s.log.Info("Executing scheduled notification", "id", sched.ID.Hex(), "type", sched.Type)
-
-	var err error
-	switch sched.Type {
-	case domain.NotificationTypeEmail:
-		req, parseErr := s.parseEmailRequest(sched.Request)
-		if parseErr != nil {
-			s.log.Error("Failed to parse email request", "error", parseErr, "id", sched.ID.Hex())
-			return
-		}
-		err = s.service.SendEmail(ctx, req)
-
-	case domain.NotificationTypeSMS:
-		req, parseErr := s.parseSMSRequest(sched.Request)
-		if parseErr != nil {
-			s.log.Error("Failed to parse SMS request", "error", parseErr, "id", sched.ID.Hex())
-			return
-		}
-		err = s.service.SendSMS(ctx, req)
-
-	case domain.NotificationTypeWebhook:
-		req, parseErr := s.parseWebhookRequest(sched.Request)
-		if parseErr != nil {
-			s.log.Error("Failed to parse webhook request", "error", parseErr, "id", sched.ID.Hex())
-			return
-		}
-		err = s.service.SendWebhook(ctx, req)
-
-	default:
-		s.log.Warn("Unknown notification type", "type", sched.Type, "id", sched.ID.Hex())
-		return
-	}
-
-	if err != nil {
-		s.log.Error("Failed to send scheduled notification", "error", err, "id", sched.ID.Hex())
-		return
-	}
-
-	// Update last run time
-	now := time.Now()
-	sched.LastRunAt = &now
-	if err := s.repo.Update(ctx, sched); err != nil {
-		s.log.Error("Failed to update schedule", "error", err, "id", sched.ID.Hex())
-	}
-
-	s.log.Info("Successfully executed scheduled notification", "id", sched.ID.Hex())
-}
-
-// parseEmailRequest converts interface{} to SendEmailRequest
-func (s *NotificationScheduler) parseEmailRequest(data interface{}) (*domain.SendEmailRequest, error) {
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return nil, err
-	}
-
-	var req domain.SendEmailRequest
-	if err := json.Unmarshal(jsonData, &req); err != nil {
-		return nil, err
-	}
-
-	return &req, nil
-}
-
-// parseSMSRequest converts interface{} to SendSMSRequest
-func (s *NotificationScheduler) parseSMSRequest(data interface{}) (*domain.SendSMSRequest, error) {
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return nil, err
-	}
-
-	var req domain.SendSMSRequest
-	if err := json.Unmarshal(jsonData, &req); err != nil {
-		return nil, err
-	}
-
-	return &req, nil
-}
-
-// parseWebhookRequest converts interface{} to SendWebhookRequest
-func (s *NotificationScheduler) parseWebhookRequest(data interface{}) (*domain.SendWebhookRequest, error) {
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return nil, err
-	}
-
-	var req domain.SendWebhookRequest
-	if err := json.Unmarshal(jsonData, &req); err != nil {
-		return nil, err
-	}
-
-	return &req, nil
-}
-
-// AddSchedule adds a new schedule
-func (s *NotificationScheduler) AddSchedule(sched *domain.ScheduledNotification) error {
-	ctx := context.Background()
-
-	// Save to database
-	if err := s.repo.Create(ctx, sched); err != nil {
-		return err
-	}
-
-	// Register with cron if active
-	if sched.IsActive {
-		return s.registerSchedule(sched)
-	}
-
-	return nil
-}
-
-// RemoveSchedule removes a schedule
-func (s *NotificationScheduler) RemoveSchedule(id string) error {
-	// Remove from cron
-	if entryID, exists := s.entries[id]; exists {
-		s.cron.Remove(entryID)
-		delete(s.entries, id)
-	}
-
-	// Delete from database
-	return s.repo.Delete(context.Background(), id)
-}
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/metrics"
+	"github.com/vhvplatform/go-notification-service/internal/repository"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// NotificationScheduler manages scheduled notifications
+type NotificationScheduler struct {
+	cron    *cron.Cron
+	service SchedulerService
+	repo    *repository.ScheduledNotificationRepository
+	log     *logger.Logger
+	syntax  Syntax
+	entries map[string]cron.EntryID // Maps notification ID to cron entry ID
+
+	executions    ExecutionRecorder
+	notifications NotificationFinder
+	slots         chan struct{} // Limits concurrent executions; nil means unlimited
+
+	execCtx   context.Context    // Context for scheduled executions, survives shutdown until the drain deadline
+	abortExec context.CancelFunc // Cancels in-flight executions when the drain deadline passes
+	inFlight  atomic.Int64
+}
+
+// SchedulerService interface for notification operations
+type SchedulerService interface {
+	SendEmail(ctx context.Context, req *domain.SendEmailRequest) error
+	SendSMS(ctx context.Context, req *domain.SendSMSRequest) error
+	SendWebhook(ctx context.Context, req *domain.SendWebhookRequest) error
+}
+
+// pauseChecker is implemented by services that hold sends while a channel is paused
+type pauseChecker interface {
+	Paused(channel domain.NotificationType, priority domain.NotificationPriority) bool
+}
+
+// ExecutionRecorder interface for storing schedule run history
+type ExecutionRecorder interface {
+	Record(ctx context.Context, execution *domain.ScheduleExecution) error
+}
+
+// NotificationFinder interface for finding the notifications a schedule run created
+type NotificationFinder interface {
+	FindIDsByScheduleExecution(ctx context.Context, tenantID, executionID string) ([]string, error)
+}
+
+// NewNotificationScheduler creates a new notification scheduler
+func NewNotificationScheduler(service SchedulerService, repo *repository.ScheduledNotificationRepository, executions ExecutionRecorder, notifications NotificationFinder, syntax Syntax, log *logger.Logger) *NotificationScheduler {
+	return &NotificationScheduler{
+		cron:          cron.New(cron.WithParser(syntax.parser())),
+		service:       service,
+		repo:          repo,
+		executions:    executions,
+		notifications: notifications,
+		log:           log,
+		syntax:        syntax,
+		entries:       make(map[string]cron.EntryID),
+		execCtx:       context.Background(),
+	}
+}
+
+// SetMaxConcurrent limits how many schedule executions run at once
+// Executions due while all slots are busy wait for one, so a burst of schedules firing at the
+// same time is spread out rather than sent at once. Must be called before Start.
+func (s *NotificationScheduler) SetMaxConcurrent(n int) {
+	if n > 0 {
+		s.slots = make(chan struct{}, n)
+	}
+}
+
+// Start starts the scheduler and loads active schedules
+func (s *NotificationScheduler) Start(ctx context.Context) error {
+	s.log.Info("Starting notification scheduler")
+
+	// Load all active scheduled notifications
+	scheduled, err := s.repo.FindActive(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.execCtx, s.abortExec = context.WithCancel(context.WithoutCancel(ctx))
+
+	// Register each scheduled notification
+	for _, sched := range scheduled {
+		if err := s.registerSchedule(sched); err != nil {
+			s.log.Error("Failed to register schedule", "error", err, "id", sched.ID)
+		}
+	}
+
+	s.cron.Start()
+	s.log.Info("Notification scheduler started", "active_schedules", len(scheduled))
+	return nil
+}
+
+// Shutdown stops triggering new executions and waits for running ones to finish
+// If ctx expires first, running executions are cancelled and ctx.Err() is returned.
+func (s *NotificationScheduler) Shutdown(ctx context.Context) error {
+	s.log.Info("Stopping notification scheduler", "in_flight", s.inFlight.Load())
+	stopped := s.cron.Stop()
+
+	select {
+	case <-stopped.Done():
+		s.log.Info("Notification scheduler stopped")
+		return nil
+	case <-ctx.Done():
+		s.log.Warn("Notification scheduler drain deadline exceeded", "in_flight", s.inFlight.Load())
+		if s.abortExec != nil {
+			s.abortExec()
+		}
+		return ctx.Err()
+	}
+}
+
+// registerSchedule registers a scheduled notification with cron
+func (s *NotificationScheduler) registerSchedule(sched *domain.ScheduledNotification) error {
+	entryID, err := s.cron.AddFunc(Spec(sched.Schedule, sched.Timezone), func() {
+		s.executeSchedule(sched)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	s.entries[sched.ID.Hex()] = entryID
+	s.log.Info("Registered schedule", "id", sched.ID.Hex(), "schedule", sched.Schedule, "type", sched.Type)
+	return nil
+}
+
+// executeSchedule executes a scheduled notification and records the run in its history
+func (s *NotificationScheduler) executeSchedule(sched *domain.ScheduledNotification) {
+	s.inFlight.Add(1)
+	defer s.inFlight.Add(-1)
+
+	ctx := s.execCtx
+	s.log.Info("Executing scheduled notification", "id", sched.ID.Hex(), "type", sched.Type)
+
+	execution := &domain.ScheduleExecution{
+		ID:         primitive.NewObjectID(),
+		ScheduleID: sched.ID.Hex(),
+		TenantID:   sched.TenantID,
+		Type:       sched.Type,
+	}
+
+	var held bool
+	delayed, delay, err := s.acquire(ctx)
+	execution.Delayed = delayed
+	execution.DelayMs = delay.Milliseconds()
+	execution.StartedAt = time.Now()
+	if err != nil {
+		err = fmt.Errorf("cancelled while waiting for an execution slot: %w", err)
+	} else {
+		defer s.release()
+		if delayed {
+			s.log.Info("Scheduled notification delayed by concurrency limit", "id", sched.ID.Hex(), "delay", delay)
+		}
+		held, err = s.send(ctx, sched, execution.ID.Hex())
+	}
+	execution.DurationMs = time.Since(execution.StartedAt).Milliseconds()
+
+	switch {
+	case err != nil:
+		execution.Status = domain.ScheduleExecutionFailed
+		execution.Error = err.Error()
+		s.log.Error("Failed to send scheduled notification", "error", err, "id", sched.ID.Hex())
+	case held:
+		// The notification is created when the channel resumes, so there is no ID to record yet
+		execution.Status = domain.ScheduleExecutionHeld
+		s.log.Info("Scheduled notification held while channel is paused", "id", sched.ID.Hex())
+	default:
+		execution.Status = domain.ScheduleExecutionSucceeded
+		ids, err := s.notifications.FindIDsByScheduleExecution(ctx, sched.TenantID, execution.ID.Hex())
+		if err != nil {
+			s.log.Error("Failed to look up notifications sent by schedule", "error", err, "id", sched.ID.Hex())
+		}
+		execution.NotificationIDs = ids
+		s.log.Info("Successfully executed scheduled notification", "id", sched.ID.Hex())
+	}
+
+	// Record the run even if the drain deadline cancelled it
+	if err := s.executions.Record(context.WithoutCancel(ctx), execution); err != nil {
+		s.log.Error("Failed to record schedule execution", "error", err, "id", sched.ID.Hex())
+	}
+
+	sched.LastRunAt = &execution.StartedAt
+	sched.LastStatus = execution.Status
+	sched.LastError = execution.Error
+	sched.RunCount++
+	if execution.Status == domain.ScheduleExecutionFailed {
+		sched.FailureCount++
+	}
+}
+
+// acquire takes an execution slot, waiting for one if all are busy
+// It reports whether it had to wait and for how long.
+func (s *NotificationScheduler) acquire(ctx context.Context) (bool, time.Duration, error) {
+	if s.slots == nil {
+		return false, 0, nil
+	}
+	select {
+	case s.slots <- struct{}{}:
+		return false, 0, nil
+	default:
+	}
+
+	metrics.ScheduleExecutionsWaiting.Inc()
+	defer metrics.ScheduleExecutionsWaiting.Dec()
+	metrics.ScheduleExecutionsDelayed.Inc()
+
+	start := time.Now()
+	select {
+	case s.slots <- struct{}{}:
+		return true, time.Since(start), nil
+	case <-ctx.Done():
+		return true, time.Since(start), ctx.Err()
+	}
+}
+
+// release frees the slot taken by acquire
+func (s *NotificationScheduler) release() {
+	if s.slots != nil {
+		<-s.slots
+	}
+}
+
+// send parses a schedule's stored request, tags it with executionID and sends it
+// It reports whether the send was held because the channel is paused.
+func (s *NotificationScheduler) send(ctx context.Context, sched *domain.ScheduledNotification, executionID string) (bool, error) {
+	var priority domain.NotificationPriority
+	var send func() error
+	switch sched.Type {
+	case domain.NotificationTypeEmail:
+		req, err := s.parseEmailRequest(sched.Request)
+		if err != nil {
+			return false, fmt.Errorf("failed to parse email request: %w", err)
+		}
+		req.TenantID = sched.TenantID
+		req.Metadata = withExecutionID(req.Metadata, executionID)
+		priority = req.Priority
+		send = func() error { return s.service.SendEmail(ctx, req) }
+
+	case domain.NotificationTypeSMS:
+		req, err := s.parseSMSRequest(sched.Request)
+		if err != nil {
+			return false, fmt.Errorf("failed to parse SMS request: %w", err)
+		}
+		req.TenantID = sched.TenantID
+		req.Metadata = withExecutionID(req.Metadata, executionID)
+		priority = req.Priority
+		send = func() error { return s.service.SendSMS(ctx, req) }
+
+	case domain.NotificationTypeWebhook:
+		req, err := s.parseWebhookRequest(sched.Request)
+		if err != nil {
+			return false, fmt.Errorf("failed to parse webhook request: %w", err)
+		}
+		req.TenantID = sched.TenantID
+		req.Metadata = withExecutionID(req.Metadata, executionID)
+		priority = req.Priority
+		send = func() error { return s.service.SendWebhook(ctx, req) }
+
+	default:
+		return false, fmt.Errorf("unknown notification type %q", sched.Type)
+	}
+
+	held := false
+	if gate, ok := s.service.(pauseChecker); ok {
+		held = gate.Paused(sched.Type, priority)
+	}
+
+	start := time.Now()
+	err := send()
+	metrics.ObserveSend(string(sched.Type), sched.TenantID, start, err)
+	return held && err == nil, err
+}
+
+// withExecutionID returns metadata with the schedule execution ID added
+func withExecutionID(metadata map[string]string, executionID string) map[string]string {
+	tagged := make(map[string]string, len(metadata)+1)
+	for key, value := range metadata {
+		tagged[key] = value
+	}
+	tagged[domain.ScheduleExecutionMetadataKey] = executionID
+	return tagged
+}
+
+// parseEmailRequest converts a stored request to a SendEmailRequest
+func (s *NotificationScheduler) parseEmailRequest(data interface{}) (*domain.SendEmailRequest, error) {
+	return parseRequest[domain.SendEmailRequest](data)
+}
+
+// parseSMSRequest converts a stored request to a SendSMSRequest
+func (s *NotificationScheduler) parseSMSRequest(data interface{}) (*domain.SendSMSRequest, error) {
+	return parseRequest[domain.SendSMSRequest](data)
+}
+
+// parseWebhookRequest converts a stored request to a SendWebhookRequest
+func (s *NotificationScheduler) parseWebhookRequest(data interface{}) (*domain.SendWebhookRequest, error) {
+	return parseRequest[domain.SendWebhookRequest](data)
+}
+
+// parseRequest converts a stored request to T through its JSON form.
+// Requests read back from MongoDB hold BSON documents, arrays, binaries and dates,
+// which are normalized first so CC/BCC lists, attachments and timestamps survive.
+func parseRequest[T any](data interface{}) (*T, error) {
+	jsonData, err := json.Marshal(normalizeBSON(data))
+	if err != nil {
+		return nil, err
+	}
+
+	var req T
+	if err := json.Unmarshal(jsonData, &req); err != nil {
+		return nil, err
+	}
+
+	return &req, nil
+}
+
+// normalizeBSON converts BSON values decoded into interface{} to the types encoding/json
+// marshals the same way as the original request: documents to maps, arrays to slices,
+// binaries to []byte and dates to time.Time
+func normalizeBSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case primitive.D:
+		out := make(map[string]interface{}, len(v))
+		for _, elem := range v {
+			out[elem.Key] = normalizeBSON(elem.Value)
+		}
+		return out
+	case primitive.M:
+		return normalizeBSON(map[string]interface{}(v))
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, nested := range v {
+			out[key] = normalizeBSON(nested)
+		}
+		return out
+	case primitive.A:
+		return normalizeBSON([]interface{}(v))
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, nested := range v {
+			out[i] = normalizeBSON(nested)
+		}
+		return out
+	case primitive.Binary:
+		return v.Data
+	case primitive.DateTime:
+		return v.Time()
+	default:
+		return v
+	}
+}
+
+// EraseRecipient removes recipient from the tenant's schedules and returns how many changed.
+// Changed schedules are registered again, since their cron entries hold the old request.
+func (s *NotificationScheduler) EraseRecipient(ctx context.Context, tenantID, recipient string) (int64, error) {
+	updated, deleted, err := s.repo.EraseRecipient(ctx, tenantID, recipient)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, id := range deleted {
+		s.unregisterSchedule(id.Hex())
+	}
+	for _, id := range updated {
+		s.unregisterSchedule(id.Hex())
+		sched, err := s.repo.FindByID(ctx, id.Hex(), tenantID)
+		if err == mongo.ErrNoDocuments {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		if sched.IsActive {
+			if err := s.registerSchedule(sched); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return int64(len(updated) + len(deleted)), nil
+}
+
+// unregisterSchedule removes a schedule's cron entry, if it has one
+func (s *NotificationScheduler) unregisterSchedule(id string) {
+	if entryID, exists := s.entries[id]; exists {
+		s.cron.Remove(entryID)
+		delete(s.entries, id)
+	}
+}
+
+// ParseExpression parses a schedule expression with the syntax schedules are registered with
+func (s *NotificationScheduler) ParseExpression(expr, timezone string) (*Expression, error) {
+	return s.syntax.ParseExpression(expr, timezone)
+}
+
+// AddSchedule adds a new schedule
+func (s *NotificationScheduler) AddSchedule(sched *domain.ScheduledNotification) error {
+	ctx := context.Background()
+
+	// Save to database
+	if err := s.repo.Create(ctx, sched); err != nil {
+		return err
+	}
+
+	// Register with cron if active
+	if sched.IsActive {
+		return s.registerSchedule(sched)
+	}
+
+	return nil
+}
+
+// RemoveSchedule removes a schedule
+func (s *NotificationScheduler) RemoveSchedule(id string) error {
+	// Remove from cron
+	s.unregisterSchedule(id)
+
+	// Delete from database
+	return s.repo.Delete(context.Background(), id)
+}
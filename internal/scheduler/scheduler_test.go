@@ -0,0 +1,427 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// roundTrip stores a schedule created from a JSON body the way the schedule handler does
+// and reads it back from its BSON form, as the scheduler sees it after a restart
+func roundTrip(t *testing.T, body string) *domain.ScheduledNotification {
+	t.Helper()
+
+	var sched domain.ScheduledNotification
+	if err := json.Unmarshal([]byte(body), &sched); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	raw, err := bson.Marshal(&sched)
+	if err != nil {
+		t.Fatalf("bson.Marshal() error = %v", err)
+	}
+	var stored domain.ScheduledNotification
+	if err := bson.Unmarshal(raw, &stored); err != nil {
+		t.Fatalf("bson.Unmarshal() error = %v", err)
+	}
+	return &stored
+}
+
+func TestParseEmailRequestPreservesRecipientsAndAttachments(t *testing.T) {
+	expiresAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	want := domain.SendEmailRequest{
+		To:      []string{"a@example.com", "b@example.com"},
+		CC:      []string{"cc@example.com"},
+		BCC:     []string{"audit@example.com", "archive@example.com"},
+		Subject: "Weekly report",
+		Body:    `<img src="cid:logo">`,
+		IsHTML:  true,
+		Attachments: []domain.Attachment{
+			{Filename: "report.pdf", Content: []byte("%PDF-1.7"), MimeType: "application/pdf"},
+			{Filename: "logo.png", Content: []byte{0x89, 'P', 'N', 'G'}, MimeType: "image/png", Inline: true, ContentID: "logo"},
+		},
+		Priority:  domain.NotificationPriorityHigh,
+		Headers:   map[string]string{"X-Report": "weekly"},
+		ExpiresAt: &expiresAt,
+	}
+	request, err := json.Marshal(want)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+
+	sched := roundTrip(t, `{"type":"email","schedule":"0 9 * * 1","request":`+string(request)+`}`)
+	s := &NotificationScheduler{}
+	got, err := s.parseEmailRequest(sched.Request)
+	if err != nil {
+		t.Fatalf("parseEmailRequest() error = %v", err)
+	}
+	if !reflect.DeepEqual(*got, want) {
+		t.Errorf("parseEmailRequest() = %+v, want %+v", *got, want)
+	}
+}
+
+func TestParseWebhookRequestPreservesNestedPayload(t *testing.T) {
+	sched := roundTrip(t, `{"type":"webhook","schedule":"* * * * *","request":{
+		"url":"https://hooks.example.com/events",
+		"payload":{"event":"digest","items":[{"id":1},{"id":2}],"meta":{"source":"scheduler"}},
+		"headers":{"X-Signature":"abc"}
+	}}`)
+
+	s := &NotificationScheduler{}
+	got, err := s.parseWebhookRequest(sched.Request)
+	if err != nil {
+		t.Fatalf("parseWebhookRequest() error = %v", err)
+	}
+	wantPayload := map[string]any{
+		"event": "digest",
+		"items": []any{map[string]any{"id": float64(1)}, map[string]any{"id": float64(2)}},
+		"meta":  map[string]any{"source": "scheduler"},
+	}
+	if !reflect.DeepEqual(got.Payload, wantPayload) {
+		t.Errorf("Payload = %#v, want %#v", got.Payload, wantPayload)
+	}
+	if got.URL != "https://hooks.example.com/events" || got.Headers["X-Signature"] != "abc" {
+		t.Errorf("parseWebhookRequest() = %+v", got)
+	}
+}
+
+func TestParseExpression(t *testing.T) {
+	syntax := Syntax{Seconds: true, Descriptors: true}
+	tests := []struct {
+		expr   string
+		format string
+	}{
+		{"0 9 * * 1-5", FormatFiveField},
+		{"30 0 9 * * 1-5", FormatSixField},
+		{"@daily", FormatDescriptor},
+		{"@every 90s", FormatDescriptor},
+	}
+	for _, tt := range tests {
+		expr, err := syntax.ParseExpression(tt.expr, "")
+		if err != nil {
+			t.Errorf("ParseExpression(%q) error = %v", tt.expr, err)
+			continue
+		}
+		if expr.Format != tt.format {
+			t.Errorf("ParseExpression(%q).Format = %q, want %q", tt.expr, expr.Format, tt.format)
+		}
+	}
+
+	for _, invalid := range []string{"", "* * * *", "0 0 0 9 * * 1", "61 * * * *", "CRON_TZ=UTC 0 9 * * *"} {
+		if _, err := syntax.ParseExpression(invalid, ""); err == nil {
+			t.Errorf("ParseExpression(%q) error = nil, want error", invalid)
+		}
+	}
+	if _, err := syntax.ParseExpression("0 9 * * *", "Mars/Olympus"); err == nil {
+		t.Error("ParseExpression() with an unknown timezone error = nil, want error")
+	}
+
+	standard := Syntax{}
+	if _, err := standard.ParseExpression("0 9 * * 1-5", ""); err != nil {
+		t.Errorf("standard ParseExpression() error = %v", err)
+	}
+	for _, disabled := range []string{"30 0 9 * * 1-5", "@daily"} {
+		if _, err := standard.ParseExpression(disabled, ""); err == nil {
+			t.Errorf("standard ParseExpression(%q) error = nil, want error", disabled)
+		}
+	}
+}
+
+func TestExpressionNextUsesTimezone(t *testing.T) {
+	syntax := Syntax{Seconds: true}
+	expr, err := syntax.ParseExpression("30 0 9 * * *", "Asia/Tokyo")
+	if err != nil {
+		t.Fatalf("ParseExpression() error = %v", err)
+	}
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC) // 09:00 in Tokyo
+	runs := expr.Next(from, 2)
+	want := []string{"2024-03-01T09:00:30+09:00", "2024-03-02T09:00:30+09:00"}
+	if len(runs) != len(want) {
+		t.Fatalf("Next() = %v, want %v", runs, want)
+	}
+	for i := range want {
+		if got := runs[i].Format(time.RFC3339); got != want[i] {
+			t.Errorf("Next()[%d] = %s, want %s", i, got, want[i])
+		}
+	}
+
+	never, err := syntax.ParseExpression("0 0 30 2 *", "UTC")
+	if err != nil {
+		t.Fatalf("ParseExpression() error = %v", err)
+	}
+	if runs := never.Next(from, 3); len(runs) != 0 {
+		t.Errorf("Next() for February 30th = %v, want none", runs)
+	}
+}
+
+type fakeService struct {
+	paused  bool
+	err     error
+	sms     []*domain.SendSMSRequest
+	started chan struct{} // Signalled as each SMS send starts, if set
+	unblock chan struct{} // Sends wait for this, if set
+}
+
+func (s *fakeService) SendEmail(ctx context.Context, req *domain.SendEmailRequest) error {
+	return s.err
+}
+
+func (s *fakeService) SendSMS(ctx context.Context, req *domain.SendSMSRequest) error {
+	if s.started != nil {
+		s.started <- struct{}{}
+		<-s.unblock
+		return s.err
+	}
+	s.sms = append(s.sms, req)
+	return s.err
+}
+
+func (s *fakeService) SendWebhook(ctx context.Context, req *domain.SendWebhookRequest) error {
+	return s.err
+}
+
+func (s *fakeService) Paused(channel domain.NotificationType, priority domain.NotificationPriority) bool {
+	return s.paused
+}
+
+type fakeHistory struct {
+	mu         sync.Mutex
+	executions []*domain.ScheduleExecution
+}
+
+func (h *fakeHistory) Record(ctx context.Context, execution *domain.ScheduleExecution) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.executions = append(h.executions, execution)
+	return nil
+}
+
+func (h *fakeHistory) FindIDsByScheduleExecution(ctx context.Context, tenantID, executionID string) ([]string, error) {
+	return []string{"notification-for-" + executionID}, nil
+}
+
+func TestExecuteScheduleRecordsHistory(t *testing.T) {
+	service := &fakeService{}
+	history := &fakeHistory{}
+	s := &NotificationScheduler{
+		service:       service,
+		executions:    history,
+		notifications: history,
+		log:           logger.NewLogger(),
+		execCtx:       context.Background(),
+	}
+	sched := &domain.ScheduledNotification{
+		ID:       primitive.NewObjectID(),
+		TenantID: "tenant-a",
+		Type:     domain.NotificationTypeSMS,
+		Request:  map[string]interface{}{"to": "+15550100", "message": "Daily digest", "metadata": map[string]interface{}{"source": "digest"}},
+	}
+
+	s.executeSchedule(sched)
+	service.err = errors.New("provider unavailable")
+	s.executeSchedule(sched)
+	service.err = nil
+	service.paused = true
+	s.executeSchedule(sched)
+	sched.Request = "not a request"
+	s.executeSchedule(sched)
+
+	wantStatuses := []domain.ScheduleExecutionStatus{
+		domain.ScheduleExecutionSucceeded,
+		domain.ScheduleExecutionFailed,
+		domain.ScheduleExecutionHeld,
+		domain.ScheduleExecutionFailed,
+	}
+	if len(history.executions) != len(wantStatuses) {
+		t.Fatalf("recorded %d executions, want %d", len(history.executions), len(wantStatuses))
+	}
+	for i, want := range wantStatuses {
+		if got := history.executions[i].Status; got != want {
+			t.Errorf("execution %d status = %q, want %q", i, got, want)
+		}
+	}
+
+	first := history.executions[0]
+	if got := service.sms[0].Metadata; got[domain.ScheduleExecutionMetadataKey] != first.ID.Hex() || got["source"] != "digest" {
+		t.Errorf("sent metadata = %v, want the execution ID added to the stored metadata", got)
+	}
+	if want := []string{"notification-for-" + first.ID.Hex()}; !reflect.DeepEqual(first.NotificationIDs, want) {
+		t.Errorf("NotificationIDs = %v, want %v", first.NotificationIDs, want)
+	}
+	if history.executions[1].Error != "provider unavailable" || len(history.executions[2].NotificationIDs) != 0 {
+		t.Errorf("failed execution = %+v, held execution = %+v", history.executions[1], history.executions[2])
+	}
+
+	if sched.RunCount != 4 || sched.FailureCount != 2 || sched.LastStatus != domain.ScheduleExecutionFailed || sched.LastError == "" {
+		t.Errorf("schedule after runs = %+v", sched)
+	}
+}
+
+func TestExecuteScheduleLimitsConcurrency(t *testing.T) {
+	service := &fakeService{started: make(chan struct{}), unblock: make(chan struct{})}
+	history := &fakeHistory{}
+	s := &NotificationScheduler{
+		service:       service,
+		executions:    history,
+		notifications: history,
+		log:           logger.NewLogger(),
+		execCtx:       context.Background(),
+	}
+	s.SetMaxConcurrent(1)
+	newSchedule := func() *domain.ScheduledNotification {
+		return &domain.ScheduledNotification{
+			ID:       primitive.NewObjectID(),
+			TenantID: "tenant-a",
+			Type:     domain.NotificationTypeSMS,
+			Request:  map[string]interface{}{"to": "+15550100", "message": "Good morning"},
+		}
+	}
+
+	var wg sync.WaitGroup
+	run := func(sched *domain.ScheduledNotification) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.executeSchedule(sched)
+		}()
+	}
+
+	first, second := newSchedule(), newSchedule()
+	run(first)
+	<-service.started
+	run(second)
+
+	// The second execution must wait while the first holds the only slot
+	select {
+	case <-service.started:
+		t.Fatal("second execution started while the first was running")
+	case <-time.After(50 * time.Millisecond):
+	}
+	service.unblock <- struct{}{}
+	<-service.started
+	service.unblock <- struct{}{}
+	wg.Wait()
+
+	if len(history.executions) != 2 {
+		t.Fatalf("recorded %d executions, want 2", len(history.executions))
+	}
+	if got := history.executions[0]; got.ScheduleID != first.ID.Hex() || got.Delayed {
+		t.Errorf("first execution = %+v, want not delayed", got)
+	}
+	if got := history.executions[1]; got.ScheduleID != second.ID.Hex() || !got.Delayed || got.DelayMs < 50 {
+		t.Errorf("second execution = %+v, want delayed by at least 50ms", got)
+	}
+}
+
+// blockingService blocks SMS sends until their context is cancelled
+type blockingService struct {
+	fakeService
+}
+
+func (s *blockingService) SendSMS(ctx context.Context, req *domain.SendSMSRequest) error {
+	s.started <- struct{}{}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// runningScheduler returns a scheduler running an SMS schedule that fires every second, as
+// Start leaves it
+func runningScheduler(t *testing.T, service SchedulerService, history *fakeHistory) *NotificationScheduler {
+	t.Helper()
+	s := NewNotificationScheduler(service, nil, history, history, Syntax{Seconds: true}, logger.NewLogger())
+	s.execCtx, s.abortExec = context.WithCancel(context.Background())
+	err := s.registerSchedule(&domain.ScheduledNotification{
+		ID:       primitive.NewObjectID(),
+		TenantID: "tenant-a",
+		Type:     domain.NotificationTypeSMS,
+		Schedule: "* * * * * *",
+		Request:  map[string]interface{}{"to": "+15550100", "message": "Good morning"},
+	})
+	if err != nil {
+		t.Fatalf("registerSchedule() error = %v", err)
+	}
+	s.cron.Start()
+	return s
+}
+
+func TestShutdownWaitsForRunningExecutions(t *testing.T) {
+	service := &fakeService{started: make(chan struct{}, 10), unblock: make(chan struct{})}
+	history := &fakeHistory{}
+	s := runningScheduler(t, service, history)
+	select {
+	case <-service.started:
+	case <-time.After(3 * time.Second):
+		t.Fatal("schedule did not run")
+	}
+
+	result := make(chan error, 1)
+	go func() { result <- s.Shutdown(context.Background()) }()
+	select {
+	case err := <-result:
+		t.Fatalf("Shutdown() = %v while an execution was running", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(service.unblock)
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Errorf("Shutdown() error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Shutdown() did not return after the execution finished")
+	}
+
+	history.mu.Lock()
+	defer history.mu.Unlock()
+	if len(history.executions) == 0 || history.executions[0].Status != domain.ScheduleExecutionSucceeded {
+		t.Errorf("executions = %+v, want the running execution to finish", history.executions)
+	}
+}
+
+func TestShutdownCancelsExecutionsAfterDeadline(t *testing.T) {
+	service := &blockingService{fakeService{started: make(chan struct{}, 10)}}
+	history := &fakeHistory{}
+	s := runningScheduler(t, service, history)
+	select {
+	case <-service.started:
+	case <-time.After(3 * time.Second):
+		t.Fatal("schedule did not run")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := s.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	// The cancelled execution is recorded as failed
+	deadline := time.Now().Add(time.Second)
+	for {
+		history.mu.Lock()
+		recorded := len(history.executions)
+		var status domain.ScheduleExecutionStatus
+		if recorded > 0 {
+			status = history.executions[0].Status
+		}
+		history.mu.Unlock()
+		if recorded > 0 {
+			if status != domain.ScheduleExecutionFailed {
+				t.Errorf("cancelled execution status = %q, want %q", status, domain.ScheduleExecutionFailed)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("cancelled execution was not recorded")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,185 @@
+// Package selftest sends a test email through the real email provider, step by step, so
+// operators can check the send path after a deploy without crafting a real request.
+package selftest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/email"
+	"github.com/vhvplatform/go-notification-service/internal/templates"
+)
+
+// DefaultTenantID is the tenant self-test notifications are recorded under when none is configured
+const DefaultTenantID = "selftest"
+
+// errSkipped marks a step that doesn't apply, such as a connection check the provider lacks
+var errSkipped = errors.New("step skipped")
+
+// testTemplate is rendered like a tenant's email template, exercising the template engine
+var testTemplate = &domain.Template{
+	Name:      "selftest",
+	Channel:   domain.NotificationTypeEmail,
+	Subject:   "Notification service self-test {{.run_id}}",
+	Preheader: "Sent by POST /admin/selftest",
+	Body:      "<p>This is a self-test email from the notification service, run {{.run_id}} at {{.sent_at}}.</p><p>No action is needed.</p>",
+	IsHTML:    true,
+}
+
+// Config holds self-test settings
+type Config struct {
+	To        string // Address the test email is sent to; the self-test fails without one
+	TenantID  string // Tenant the provider is chosen for and the notification recorded under
+	FromEmail string
+	FromName  string
+}
+
+// Transport picks the email provider for a tenant, as email.Router does
+type Transport interface {
+	For(tenantID string) (string, email.Sender)
+}
+
+// Store interface for recording the self-test notification
+type Store interface {
+	Create(ctx context.Context, notification *domain.Notification) error
+	UpdateStatus(ctx context.Context, id string, tenantID string, status domain.NotificationStatus, errorMsg string, sentAt *time.Time) error
+	SetProviderMessageID(ctx context.Context, id, tenantID, provider, providerMessageID string) error
+}
+
+// Runner runs the send path self-test. It sends directly through the provider, so the test
+// email is never deduplicated, throttled, held by a pause or counted against a quota.
+type Runner struct {
+	config    Config
+	transport Transport
+	store     Store
+}
+
+// NewRunner creates a new self-test runner
+func NewRunner(config Config, transport Transport, store Store) *Runner {
+	if config.TenantID == "" {
+		config.TenantID = DefaultTenantID
+	}
+	return &Runner{
+		config:    config,
+		transport: transport,
+		store:     store,
+	}
+}
+
+// Run sends a test email, reporting each step: resolving the configuration, rendering a
+// template, recording the notification, connecting to the provider and having the message
+// accepted. Steps after a failed one are skipped. The notification is tagged SelfTestTag.
+func (r *Runner) Run(ctx context.Context) *domain.SelfTestReport {
+	report := &domain.SelfTestReport{Steps: []domain.SelfTestStep{}}
+	runID := time.Now().UTC().Format("20060102T150405Z")
+	failed := false
+	step := func(name string, fn func() (string, error)) {
+		result := domain.SelfTestStep{Name: name, Status: domain.SelfTestSkipped}
+		if !failed {
+			start := time.Now()
+			detail, err := fn()
+			result.DurationMs = time.Since(start).Milliseconds()
+			result.Detail = detail
+			switch {
+			case errors.Is(err, errSkipped):
+				result.Status = domain.SelfTestSkipped
+			case err != nil:
+				result.Status = domain.SelfTestFailed
+				result.Error = err.Error()
+				failed = true
+			default:
+				result.Status = domain.SelfTestPassed
+			}
+		}
+		report.Steps = append(report.Steps, result)
+	}
+
+	var sender email.Sender
+	step(domain.SelfTestStepConfig, func() (string, error) {
+		if r.config.To == "" {
+			return "", fmt.Errorf("no self-test recipient is configured")
+		}
+		if _, err := mail.ParseAddress(r.config.To); err != nil {
+			return "", fmt.Errorf("invalid self-test recipient: %w", err)
+		}
+		if r.config.FromEmail == "" {
+			return "", fmt.Errorf("no from address is configured")
+		}
+		report.Provider, sender = r.transport.For(r.config.TenantID)
+		if sender == nil {
+			return "", fmt.Errorf("email provider %q is not available", report.Provider)
+		}
+		return fmt.Sprintf("provider %s for tenant %s", report.Provider, r.config.TenantID), nil
+	})
+
+	var subject, body, preheader string
+	step(domain.SelfTestStepTemplate, func() (string, error) {
+		var err error
+		subject, body, preheader, err = templates.RenderEmail(testTemplate, map[string]string{
+			"run_id":  runID,
+			"sent_at": time.Now().UTC().Format(time.RFC3339),
+		}, "")
+		return subject, err
+	})
+
+	notification := &domain.Notification{
+		TenantID:  r.config.TenantID,
+		Type:      domain.NotificationTypeEmail,
+		Status:    domain.NotificationStatusPending,
+		Priority:  domain.NotificationPriorityNormal,
+		Recipient: r.config.To,
+		Subject:   subject,
+		Body:      body,
+		IsHTML:    true,
+		Tags:      []string{domain.SelfTestTag},
+		Category:  domain.SelfTestTag,
+		Metadata:  map[string]string{domain.SelfTestTag: runID},
+	}
+	step(domain.SelfTestStepDatabase, func() (string, error) {
+		if err := r.store.Create(ctx, notification); err != nil {
+			return "", err
+		}
+		report.NotificationID = notification.ID.Hex()
+		return report.NotificationID, nil
+	})
+
+	step(domain.SelfTestStepConnect, func() (string, error) {
+		checker, ok := sender.(email.Checker)
+		if !ok {
+			return "provider has no connection check", errSkipped
+		}
+		return "", checker.Check(ctx)
+	})
+
+	step(domain.SelfTestStepSend, func() (string, error) {
+		msg, err := email.NewMessage(mail.Address{Name: r.config.FromName, Address: r.config.FromEmail}, &domain.SendEmailRequest{
+			To:        []string{r.config.To},
+			Subject:   subject,
+			Body:      body,
+			IsHTML:    true,
+			Preheader: preheader,
+			Category:  domain.SelfTestTag,
+			Headers:   map[string]string{"X-Notification-Self-Test": runID},
+		}, report.NotificationID)
+		if err != nil {
+			return "", err
+		}
+		providerID, err := sender.Send(ctx, msg)
+		if err != nil {
+			r.store.UpdateStatus(context.WithoutCancel(ctx), report.NotificationID, r.config.TenantID, domain.NotificationStatusFailed, err.Error(), nil)
+			return "", err
+		}
+		now := time.Now()
+		if err := r.store.UpdateStatus(ctx, report.NotificationID, r.config.TenantID, domain.NotificationStatusSent, "", &now); err != nil {
+			return providerID, fmt.Errorf("message accepted, but recording it failed: %w", err)
+		}
+		return providerID, r.store.SetProviderMessageID(ctx, report.NotificationID, r.config.TenantID, report.Provider, providerID)
+	})
+
+	report.Passed = !failed
+	return report
+}
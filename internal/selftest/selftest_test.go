@@ -0,0 +1,106 @@
+package selftest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/email"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fakeSender records sent messages, failing its connection check with checkErr
+type fakeSender struct {
+	sent     []*email.Message
+	checkErr error
+}
+
+func (s *fakeSender) Send(ctx context.Context, msg *email.Message) (string, error) {
+	s.sent = append(s.sent, msg)
+	return "provider-id", nil
+}
+
+func (s *fakeSender) Check(ctx context.Context) error {
+	return s.checkErr
+}
+
+type fakeTransport struct {
+	sender email.Sender
+}
+
+func (t fakeTransport) For(tenantID string) (string, email.Sender) {
+	return "smtp", t.sender
+}
+
+// fakeStore records the notification and its final status
+type fakeStore struct {
+	created    *domain.Notification
+	status     domain.NotificationStatus
+	providerID string
+}
+
+func (s *fakeStore) Create(ctx context.Context, notification *domain.Notification) error {
+	notification.ID = primitive.NewObjectID()
+	s.created = notification
+	return nil
+}
+
+func (s *fakeStore) UpdateStatus(ctx context.Context, id string, tenantID string, status domain.NotificationStatus, errorMsg string, sentAt *time.Time) error {
+	s.status = status
+	return nil
+}
+
+func (s *fakeStore) SetProviderMessageID(ctx context.Context, id, tenantID, provider, providerMessageID string) error {
+	s.providerID = providerMessageID
+	return nil
+}
+
+func TestRun(t *testing.T) {
+	sender := &fakeSender{}
+	store := &fakeStore{}
+	runner := NewRunner(Config{To: "ops@example.com", FromEmail: "noreply@example.com"}, fakeTransport{sender: sender}, store)
+
+	report := runner.Run(context.Background())
+	if !report.Passed || len(report.Steps) != 5 {
+		t.Fatalf("report = %+v, want 5 passed steps", report)
+	}
+	for _, step := range report.Steps {
+		if step.Status != domain.SelfTestPassed {
+			t.Errorf("step %s = %s (%s), want passed", step.Name, step.Status, step.Error)
+		}
+	}
+	if len(sender.sent) != 1 || !strings.HasPrefix(sender.sent[0].Subject, "Notification service self-test ") {
+		t.Errorf("sent = %+v, want one rendered test email", sender.sent)
+	}
+	if store.created.TenantID != DefaultTenantID || store.created.Tags[0] != domain.SelfTestTag {
+		t.Errorf("recorded notification = %+v, want it tagged under the self-test tenant", store.created)
+	}
+	if store.status != domain.NotificationStatusSent || store.providerID != "provider-id" {
+		t.Errorf("status = %s, provider id = %q; want sent with the provider's id", store.status, store.providerID)
+	}
+}
+
+func TestRunStopsAtFailedStep(t *testing.T) {
+	sender := &fakeSender{checkErr: errors.New("535 authentication failed")}
+	runner := NewRunner(Config{To: "ops@example.com", FromEmail: "noreply@example.com"}, fakeTransport{sender: sender}, &fakeStore{})
+
+	report := runner.Run(context.Background())
+	if report.Passed {
+		t.Fatal("report passed, want the connection failure reported")
+	}
+	connect, send := report.Steps[3], report.Steps[4]
+	if connect.Status != domain.SelfTestFailed || !strings.Contains(connect.Error, "535") {
+		t.Errorf("connect step = %+v, want the authentication failure", connect)
+	}
+	if send.Status != domain.SelfTestSkipped || len(sender.sent) != 0 {
+		t.Errorf("send step = %+v, want it skipped without sending", send)
+	}
+
+	report = NewRunner(Config{FromEmail: "noreply@example.com"}, fakeTransport{sender: sender}, &fakeStore{}).Run(context.Background())
+	if report.Steps[0].Status != domain.SelfTestFailed {
+		t.Errorf("config step = %+v, want a missing recipient to fail", report.Steps[0])
+	}
+}
@@ -0,0 +1,15 @@
+// Package sender defines the interface shared by the links of the notification send chain.
+package sender
+
+import (
+	"context"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+)
+
+// Sender interface for notification send operations
+type Sender interface {
+	SendEmail(ctx context.Context, req *domain.SendEmailRequest) error
+	SendSMS(ctx context.Context, req *domain.SendSMSRequest) error
+	SendWebhook(ctx context.Context, req *domain.SendWebhookRequest) error
+}
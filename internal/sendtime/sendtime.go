@@ -0,0 +1,248 @@
+package sendtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/sender"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// ErrInvalidOptions is returned when an email can't be scheduled for a local time of day
+var ErrInvalidOptions = errors.New("invalid local send time")
+
+// PreferenceStore interface for looking up recipients' timezones
+type PreferenceStore interface {
+	FindByUserIDs(ctx context.Context, tenantID string, userIDs []string) ([]*domain.NotificationPreferences, error)
+}
+
+// OptionsError describes local send options that can't be used
+type OptionsError struct {
+	Reason string
+}
+
+// Error implements the error interface
+func (e *OptionsError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrInvalidOptions, e.Reason)
+}
+
+// Unwrap allows errors.Is(err, ErrInvalidOptions)
+func (e *OptionsError) Unwrap() error {
+	return ErrInvalidOptions
+}
+
+// AsOptionsError returns the *OptionsError in err's chain, if any
+func AsOptionsError(err error) (*OptionsError, bool) {
+	var optionsErr *OptionsError
+	ok := errors.As(err, &optionsErr)
+	return optionsErr, ok
+}
+
+// Slot is a send time and the recipients it is local time of day for
+type Slot struct {
+	At         time.Time
+	Recipients []string
+}
+
+// Planner works out when each recipient's local time of day next comes round
+type Planner struct {
+	preferences     PreferenceStore
+	defaultLocation *time.Location
+	now             func() time.Time
+}
+
+// NewPlanner creates a planner; recipients without a known timezone use defaultLocation
+// unless the request names its own default
+func NewPlanner(preferences PreferenceStore, defaultLocation *time.Location) *Planner {
+	if defaultLocation == nil {
+		defaultLocation = time.UTC
+	}
+	return &Planner{
+		preferences:     preferences,
+		defaultLocation: defaultLocation,
+		now:             time.Now,
+	}
+}
+
+// Plan groups recipients by the next time options.Time comes round in their timezone,
+// earliest first. Timezones are read from the preferences of the users in options.UserIDs.
+func (p *Planner) Plan(ctx context.Context, tenantID string, recipients []string, options *domain.LocalSendOptions) ([]Slot, error) {
+	hour, minute, err := parseClock(options.Time)
+	if err != nil {
+		return nil, err
+	}
+	fallback := p.defaultLocation
+	if options.DefaultTimezone != "" {
+		if fallback, err = time.LoadLocation(options.DefaultTimezone); err != nil {
+			return nil, &OptionsError{Reason: fmt.Sprintf("unknown default timezone %q", options.DefaultTimezone)}
+		}
+	}
+
+	// Addresses are matched case-insensitively
+	users := make(map[string]string, len(options.UserIDs))
+	for address, userID := range options.UserIDs {
+		users[strings.ToLower(strings.TrimSpace(address))] = userID
+	}
+	timezones, err := p.timezones(ctx, tenantID, users)
+	if err != nil {
+		return nil, err
+	}
+
+	now := p.now()
+	slots := make(map[time.Time]*Slot)
+	for _, recipient := range recipients {
+		location := fallback
+		if name, ok := timezones[users[strings.ToLower(strings.TrimSpace(recipient))]]; ok {
+			if loc, err := time.LoadLocation(name); err == nil {
+				location = loc
+			}
+		}
+
+		at := NextLocal(now, hour, minute, location)
+		slot, ok := slots[at]
+		if !ok {
+			slot = &Slot{At: at}
+			slots[at] = slot
+		}
+		slot.Recipients = append(slot.Recipients, recipient)
+	}
+
+	planned := make([]Slot, 0, len(slots))
+	for _, slot := range slots {
+		planned = append(planned, *slot)
+	}
+	slices.SortFunc(planned, func(a, b Slot) int { return a.At.Compare(b.At) })
+	return planned, nil
+}
+
+// timezones returns the timezone set in each user's preferences, by user ID
+func (p *Planner) timezones(ctx context.Context, tenantID string, userIDs map[string]string) (map[string]string, error) {
+	if p.preferences == nil || len(userIDs) == 0 {
+		return nil, nil
+	}
+	ids := make([]string, 0, len(userIDs))
+	for _, userID := range userIDs {
+		if userID != "" && !slices.Contains(ids, userID) {
+			ids = append(ids, userID)
+		}
+	}
+
+	prefs, err := p.preferences.FindByUserIDs(ctx, tenantID, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load recipient timezones: %w", err)
+	}
+	timezones := make(map[string]string, len(prefs))
+	for _, pref := range prefs {
+		if pref.Timezone != "" {
+			timezones[pref.UserID] = pref.Timezone
+		}
+	}
+	return timezones, nil
+}
+
+// NextLocal returns the first time after now that the clock reads hour:minute in location
+func NextLocal(now time.Time, hour, minute int, location *time.Location) time.Time {
+	local := now.In(location)
+	at := time.Date(local.Year(), local.Month(), local.Day(), hour, minute, 0, 0, location)
+	if !at.After(local) {
+		at = time.Date(local.Year(), local.Month(), local.Day()+1, hour, minute, 0, 0, location)
+	}
+	return at.UTC()
+}
+
+// parseClock parses an HH:MM time of day
+func parseClock(clock string) (int, int, error) {
+	parsed, err := time.Parse("15:04", strings.TrimSpace(clock))
+	if err != nil {
+		return 0, 0, &OptionsError{Reason: fmt.Sprintf("time must be HH:MM, got %q", clock)}
+	}
+	return parsed.Hour(), parsed.Minute(), nil
+}
+
+// Check rejects an email whose send_at_local options can't be used
+func Check(req *domain.SendEmailRequest) error {
+	switch {
+	case req.SendAtLocal == nil:
+		return nil
+	case req.ScheduledFor != nil:
+		return &OptionsError{Reason: "send_at_local cannot be combined with scheduled_for"}
+	case len(req.CC) > 0 || len(req.BCC) > 0:
+		return &OptionsError{Reason: "cc and bcc cannot be sent at local time"}
+	}
+	return CheckOptions(req.SendAtLocal)
+}
+
+// CheckOptions rejects send_at_local options with a malformed time or unknown default timezone
+func CheckOptions(options *domain.LocalSendOptions) error {
+	if _, _, err := parseClock(options.Time); err != nil {
+		return err
+	}
+	if options.DefaultTimezone != "" {
+		if _, err := time.LoadLocation(options.DefaultTimezone); err != nil {
+			return &OptionsError{Reason: fmt.Sprintf("unknown default timezone %q", options.DefaultTimezone)}
+		}
+	}
+	return nil
+}
+
+// LocalTimeSender schedules emails marked with send_at_local for each recipient's local time
+// of day, sending one email per distinct send time through next. Other sends pass straight through.
+type LocalTimeSender struct {
+	planner *Planner
+	next    sender.Sender
+	log     *logger.Logger
+}
+
+// NewSender creates a sender that schedules emails by recipient timezone
+func NewSender(planner *Planner, next sender.Sender, log *logger.Logger) *LocalTimeSender {
+	return &LocalTimeSender{
+		planner: planner,
+		next:    next,
+		log:     log,
+	}
+}
+
+// SendEmail schedules the email for each recipient's local time of day if it asks for one, or sends it
+func (s *LocalTimeSender) SendEmail(ctx context.Context, req *domain.SendEmailRequest) error {
+	if req.SendAtLocal == nil {
+		return s.next.SendEmail(ctx, req)
+	}
+	// Held and scheduled emails are checked again here
+	if err := Check(req); err != nil {
+		return err
+	}
+
+	slots, err := s.planner.Plan(ctx, req.TenantID, req.To, req.SendAtLocal)
+	if err != nil {
+		return err
+	}
+	for i, slot := range slots {
+		scheduled := *req
+		scheduled.To = slot.Recipients
+		scheduled.ScheduledFor = &slot.At
+		scheduled.SendAtLocal = nil
+		if req.IdempotencyKey != "" && len(slots) > 1 {
+			scheduled.IdempotencyKey = fmt.Sprintf("%s:%d", req.IdempotencyKey, slot.At.Unix())
+		}
+		if err := s.next.SendEmail(ctx, &scheduled); err != nil {
+			return fmt.Errorf("failed to schedule email for %s (%d of %d send times): %w", slot.At.Format(time.RFC3339), i+1, len(slots), err)
+		}
+	}
+	s.log.Debug("Scheduled email for local send time", "tenant_id", req.TenantID, "time", req.SendAtLocal.Time, "recipients", len(req.To), "send_times", len(slots))
+	return nil
+}
+
+// SendSMS sends an SMS
+func (s *LocalTimeSender) SendSMS(ctx context.Context, req *domain.SendSMSRequest) error {
+	return s.next.SendSMS(ctx, req)
+}
+
+// SendWebhook sends a webhook
+func (s *LocalTimeSender) SendWebhook(ctx context.Context, req *domain.SendWebhookRequest) error {
+	return s.next.SendWebhook(ctx, req)
+}
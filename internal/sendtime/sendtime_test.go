@@ -0,0 +1,109 @@
+package sendtime
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+type preferenceStore []*domain.NotificationPreferences
+
+func (s preferenceStore) FindByUserIDs(ctx context.Context, tenantID string, userIDs []string) ([]*domain.NotificationPreferences, error) {
+	return s, nil
+}
+
+type recordingSender struct {
+	emails []*domain.SendEmailRequest
+}
+
+func (s *recordingSender) SendEmail(ctx context.Context, req *domain.SendEmailRequest) error {
+	s.emails = append(s.emails, req)
+	return nil
+}
+
+func (s *recordingSender) SendSMS(ctx context.Context, req *domain.SendSMSRequest) error {
+	return nil
+}
+
+func (s *recordingSender) SendWebhook(ctx context.Context, req *domain.SendWebhookRequest) error {
+	return nil
+}
+
+func TestNextLocal(t *testing.T) {
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	tests := []struct {
+		name     string
+		now      time.Time
+		location *time.Location
+		want     string
+	}{
+		{"later today", time.Date(2024, 3, 10, 6, 0, 0, 0, time.UTC), time.UTC, "2024-03-10T09:00:00Z"},
+		{"already passed", time.Date(2024, 3, 10, 9, 0, 0, 0, time.UTC), time.UTC, "2024-03-11T09:00:00Z"},
+		{"ahead of UTC", time.Date(2024, 3, 10, 6, 0, 0, 0, time.UTC), tokyo, "2024-03-11T00:00:00Z"},
+		{"across daylight saving", time.Date(2024, 3, 30, 12, 0, 0, 0, time.UTC), berlin, "2024-03-31T07:00:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NextLocal(tt.now, 9, 0, tt.location).Format(time.RFC3339); got != tt.want {
+				t.Errorf("NextLocal() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalTimeSender(t *testing.T) {
+	planner := NewPlanner(preferenceStore{
+		{UserID: "u-tokyo", Timezone: "Asia/Tokyo"},
+		{UserID: "u-broken", Timezone: "Nowhere/Special"},
+	}, time.UTC)
+	planner.now = func() time.Time { return time.Date(2024, 3, 10, 6, 0, 0, 0, time.UTC) }
+	next := &recordingSender{}
+	sender := NewSender(planner, next, logger.NewLogger())
+
+	req := &domain.SendEmailRequest{
+		TenantID:       "tenant-1",
+		To:             []string{"ann@example.com", "Ken@Example.com", "bob@example.com"},
+		Subject:        "Good morning",
+		Body:           "Hello",
+		IdempotencyKey: "campaign-1",
+		SendAtLocal: &domain.LocalSendOptions{
+			Time:    "09:00",
+			UserIDs: map[string]string{"ken@example.com": "u-tokyo", "bob@example.com": "u-broken"},
+		},
+	}
+	if err := sender.SendEmail(context.Background(), req); err != nil {
+		t.Fatalf("SendEmail() error = %v", err)
+	}
+	if len(next.emails) != 2 {
+		t.Fatalf("sent %d emails, want one per send time", len(next.emails))
+	}
+	utc, tokyo := next.emails[0], next.emails[1]
+	if len(utc.To) != 2 || utc.ScheduledFor.Format(time.RFC3339) != "2024-03-10T09:00:00Z" || utc.SendAtLocal != nil {
+		t.Errorf("UTC email to %v at %v", utc.To, utc.ScheduledFor)
+	}
+	if tokyo.To[0] != "Ken@Example.com" || tokyo.ScheduledFor.Format(time.RFC3339) != "2024-03-11T00:00:00Z" {
+		t.Errorf("Tokyo email to %v at %v", tokyo.To, tokyo.ScheduledFor)
+	}
+	if utc.IdempotencyKey == tokyo.IdempotencyKey {
+		t.Errorf("send times share idempotency key %q", utc.IdempotencyKey)
+	}
+
+	for name, change := range map[string]func(*domain.SendEmailRequest){
+		"time":          func(r *domain.SendEmailRequest) { r.SendAtLocal.Time = "9am" },
+		"timezone":      func(r *domain.SendEmailRequest) { r.SendAtLocal.DefaultTimezone = "Mars/Olympus" },
+		"cc":            func(r *domain.SendEmailRequest) { r.CC = []string{"cc@example.com"} },
+		"scheduled_for": func(r *domain.SendEmailRequest) { r.ScheduledFor = &time.Time{} },
+	} {
+		invalid := *req
+		options := *req.SendAtLocal
+		invalid.SendAtLocal = &options
+		change(&invalid)
+		if _, ok := AsOptionsError(sender.SendEmail(context.Background(), &invalid)); !ok {
+			t.Errorf("SendEmail() with invalid %s did not return an OptionsError", name)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfigDefaults(t *testing.T) {
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	if cfg.SMTP.PoolSize != 10 || cfg.BulkEmail.Workers != 5 || cfg.RateLimit.Burst != 200 {
+		t.Errorf("unexpected defaults: %+v", cfg)
+	}
+	if cfg.Outbox.MonitorInterval != 30*time.Second {
+		t.Errorf("Outbox.MonitorInterval = %v, want 30s", cfg.Outbox.MonitorInterval)
+	}
+	if len(cfg.Attachments.DeniedExtensions) == 0 || len(cfg.Attachments.AllowedMIMETypes) != 0 {
+		t.Errorf("Attachments = %+v, want default denylist and no allowlist", cfg.Attachments)
+	}
+}
+
+func TestLoadConfigOverrides(t *testing.T) {
+	t.Setenv("SMTP_POOL_SIZE", "4")
+	t.Setenv("RATE_LIMIT_PER_TENANT", "12.5")
+	t.Setenv("MAINTENANCE_SOFT_DELETE_PURGE_DRY_RUN", "true")
+	t.Setenv("MAINTENANCE_SOFT_DELETE_TENANT_RETENTION_DAYS", "tenant-a=90, tenant-b=0")
+	t.Setenv("MAINTENANCE_CONTENT_REDACTION_CATEGORY_RETENTION_DAYS", "marketing=7")
+	t.Setenv("MAINTENANCE_CONTENT_REDACTION_MODE", "drop")
+	t.Setenv("METRICS_TENANT_ALLOWLIST", "tenant-a,,tenant-b ")
+	t.Setenv("ATTACHMENT_DENIED_EXTENSIONS", ".exe")
+	t.Setenv("EMAIL_TENANT_PROVIDERS", "tenant-a=sendgrid")
+	t.Setenv("DEDUP_TENANT_WINDOW_SECONDS", "tenant-a=60")
+	t.Setenv("MAINTENANCE_STUCK_NOTIFICATIONS_ACTION", "requeue")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	if cfg.SMTP.PoolSize != 4 {
+		t.Errorf("SMTP.PoolSize = %d, want 4", cfg.SMTP.PoolSize)
+	}
+	if cfg.RateLimit.PerTenant != 12.5 {
+		t.Errorf("RateLimit.PerTenant = %g, want 12.5", cfg.RateLimit.PerTenant)
+	}
+	if !cfg.Maintenance.SoftDeletePurgeDryRun {
+		t.Error("Maintenance.SoftDeletePurgeDryRun = false, want true")
+	}
+	days := cfg.Maintenance.SoftDeleteTenantDays
+	if len(days) != 2 || days["tenant-a"] != 90 || days["tenant-b"] != 0 {
+		t.Errorf("Maintenance.SoftDeleteTenantDays = %v", days)
+	}
+	if got := cfg.Maintenance.ContentCategoryDays; len(got) != 1 || got["marketing"] != 7 {
+		t.Errorf("Maintenance.ContentCategoryDays = %v", got)
+	}
+	if cfg.Maintenance.ContentRedactionMode != "drop" {
+		t.Errorf("Maintenance.ContentRedactionMode = %q, want drop", cfg.Maintenance.ContentRedactionMode)
+	}
+	if cfg.Maintenance.StuckAction != "requeue" || cfg.Maintenance.StuckTimeout != 15*time.Minute {
+		t.Errorf("Maintenance stuck notifications = %q after %v, want requeue after 15m", cfg.Maintenance.StuckAction, cfg.Maintenance.StuckTimeout)
+	}
+	if got := strings.Join(cfg.Metrics.TenantAllowlist, ","); got != "tenant-a,tenant-b" {
+		t.Errorf("Metrics.TenantAllowlist = %q", got)
+	}
+	if got := strings.Join(cfg.Attachments.DeniedExtensions, ","); got != ".exe" {
+		t.Errorf("Attachments.DeniedExtensions = %q", got)
+	}
+	if cfg.Email.Provider != "smtp" || cfg.Email.TenantProviders["tenant-a"] != "sendgrid" {
+		t.Errorf("Email = %+v", cfg.Email)
+	}
+	if cfg.Dedup.Window != 0 || cfg.Dedup.TenantWindows["tenant-a"] != time.Minute {
+		t.Errorf("Dedup = %+v", cfg.Dedup)
+	}
+}
+
+func TestLoadConfigBulkDefaultPriority(t *testing.T) {
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.BulkEmail.DefaultPriority != "normal" {
+		t.Errorf("BulkEmail.DefaultPriority = %q, want normal", cfg.BulkEmail.DefaultPriority)
+	}
+
+	t.Setenv("BULK_EMAIL_DEFAULT_PRIORITY", "urgent")
+	_, err = LoadConfig()
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || !strings.Contains(err.Error(), "BULK_EMAIL_DEFAULT_PRIORITY") {
+		t.Errorf("LoadConfig() error = %v, want a BULK_EMAIL_DEFAULT_PRIORITY problem", err)
+	}
+}
+
+func TestLoadConfigReportsAllProblems(t *testing.T) {
+	t.Setenv("SMTP_POOL_SIZE", "ten")
+	t.Setenv("SMTP_PORT", "70000")
+	t.Setenv("RABBITMQ_QUEUE_TYPE", "stream")
+	t.Setenv("MAINTENANCE_OUTBOX_PURGE_SCHEDULE", "every day")
+	t.Setenv("MAINTENANCE_SOFT_DELETE_TENANT_RETENTION_DAYS", "tenant-a")
+	t.Setenv("METRICS_BASIC_AUTH_USERNAME", "prometheus")
+
+	_, err := LoadConfig()
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("LoadConfig() error = %v, want *ValidationError", err)
+	}
+
+	wantKeys := []string{
+		"SMTP_POOL_SIZE",
+		"SMTP_PORT",
+		"RABBITMQ_QUEUE_TYPE",
+		"MAINTENANCE_OUTBOX_PURGE_SCHEDULE",
+		"MAINTENANCE_SOFT_DELETE_TENANT_RETENTION_DAYS",
+		"METRICS_BASIC_AUTH_PASSWORD",
+	}
+	if len(validationErr.Problems) != len(wantKeys) {
+		t.Errorf("got %d problems, want %d:\n%v", len(validationErr.Problems), len(wantKeys), err)
+	}
+	for _, key := range wantKeys {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("error does not mention %s:\n%v", key, err)
+		}
+	}
+}
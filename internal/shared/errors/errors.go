@@ -1,54 +1,150 @@
-package errors
-
-import "fmt"
-
-// AppError represents an application error
-type AppError struct {
-	Code    string
-	Message string
-	Err     error
-}
-
-// Error implements the error interface
-func (e *AppError) Error() string {
-	if e.Err != nil {
-		return fmt.Sprintf("%s: %s - %v", e.Code, e.Message, e.Err)
-	}
-	return fmt.Sprintf("%s: %s", e.Code, e.Message)
-}
-
-// NewValidationError creates a new validation error
-func NewValidationError(message string, err error) *AppError {
-	return &AppError{
-		Code:    "VALIDATION_ERROR",
-		Message: message,
-		Err:     err,
-	}
-}
-
-// NewInternalError creates a new internal error
-func NewInternalError(message string, err error) *AppError {
-	return &AppError{
-		Code:    "INTERNAL_ERROR",
-		Message: message,
-		Err:     err,
-	}
-}
-
-// NewNotFoundError creates a new not found error
-func NewNotFoundError(message string, err error) *AppError {
-	return &AppError{
-		Code:    "NOT_FOUND",
-		Message: message,
-		Err:     err,
-	}
-}
-
-// NewUnauthorizedError creates a new unauthorized error
-func NewUnauthorizedError(message string, err error) *AppError {
-	return &AppError{
-		Code:    "UNAUTHORIZED",
-		Message: message,
-		Err:     err,
-	}
-}
+package errors
+
+import (
+	stderrors "errors"
+	"fmt"
+	"net/http"
+)
+
+// Error codes
+const (
+	CodeValidation   = "VALIDATION_ERROR"
+	CodeNotFound     = "NOT_FOUND"
+	CodeUnauthorized = "UNAUTHORIZED"
+	CodeForbidden    = "FORBIDDEN"
+	CodeRateLimited  = "RATE_LIMITED"
+	CodeConflict     = "CONFLICT"
+	CodeGone         = "GONE"
+	CodeInternal     = "INTERNAL_ERROR"
+)
+
+// httpStatuses maps error codes to HTTP status codes
+var httpStatuses = map[string]int{
+	CodeValidation:   http.StatusBadRequest,
+	CodeNotFound:     http.StatusNotFound,
+	CodeUnauthorized: http.StatusUnauthorized,
+	CodeForbidden:    http.StatusForbidden,
+	CodeRateLimited:  http.StatusTooManyRequests,
+	CodeConflict:     http.StatusConflict,
+	CodeGone:         http.StatusGone,
+	CodeInternal:     http.StatusInternalServerError,
+}
+
+// AppError represents an application error
+type AppError struct {
+	Code    string
+	Message string
+	Err     error
+	Fields  map[string]any // Extra values returned to the client alongside the error
+}
+
+// Error implements the error interface
+func (e *AppError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s - %v", e.Code, e.Message, e.Err)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// Unwrap returns the underlying error
+func (e *AppError) Unwrap() error {
+	return e.Err
+}
+
+// WithField attaches a value to be returned to the client with the error
+func (e *AppError) WithField(key string, value any) *AppError {
+	if e.Fields == nil {
+		e.Fields = make(map[string]any)
+	}
+	e.Fields[key] = value
+	return e
+}
+
+// HTTPStatus returns the HTTP status code for the error's code (500 for unknown codes)
+func (e *AppError) HTTPStatus() int {
+	if status, ok := httpStatuses[e.Code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// FromError returns the AppError in err's chain, or wraps err in an internal error with message
+func FromError(err error, message string) *AppError {
+	var appErr *AppError
+	if stderrors.As(err, &appErr) {
+		return appErr
+	}
+	return NewInternalError(message, err)
+}
+
+// NewValidationError creates a new validation error
+func NewValidationError(message string, err error) *AppError {
+	return &AppError{
+		Code:    CodeValidation,
+		Message: message,
+		Err:     err,
+	}
+}
+
+// NewInternalError creates a new internal error
+func NewInternalError(message string, err error) *AppError {
+	return &AppError{
+		Code:    CodeInternal,
+		Message: message,
+		Err:     err,
+	}
+}
+
+// NewNotFoundError creates a new not found error
+func NewNotFoundError(message string, err error) *AppError {
+	return &AppError{
+		Code:    CodeNotFound,
+		Message: message,
+		Err:     err,
+	}
+}
+
+// NewUnauthorizedError creates a new unauthorized error
+func NewUnauthorizedError(message string, err error) *AppError {
+	return &AppError{
+		Code:    CodeUnauthorized,
+		Message: message,
+		Err:     err,
+	}
+}
+
+// NewForbiddenError creates a new error for an authenticated caller that may not make the request
+func NewForbiddenError(message string, err error) *AppError {
+	return &AppError{
+		Code:    CodeForbidden,
+		Message: message,
+		Err:     err,
+	}
+}
+
+// NewRateLimitedError creates a new rate limited error
+func NewRateLimitedError(message string, err error) *AppError {
+	return &AppError{
+		Code:    CodeRateLimited,
+		Message: message,
+		Err:     err,
+	}
+}
+
+// NewConflictError creates a new conflict error
+func NewConflictError(message string, err error) *AppError {
+	return &AppError{
+		Code:    CodeConflict,
+		Message: message,
+		Err:     err,
+	}
+}
+
+// NewGoneError creates a new error for a resource that existed but was permanently removed
+func NewGoneError(message string, err error) *AppError {
+	return &AppError{
+		Code:    CodeGone,
+		Message: message,
+		Err:     err,
+	}
+}
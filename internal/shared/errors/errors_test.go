@@ -1,114 +1,153 @@
-package errors
-
-import (
-	"testing"
-)
-
-func TestNewValidationError(t *testing.T) {
-	tests := []struct {
-		name    string
-		message string
-		err     error
-		want    string
-	}{
-		{
-			name:    "validation error with underlying error",
-			message: "Invalid input",
-			err:     NewValidationError("field required", nil),
-			want:    "VALIDATION_ERROR: Invalid input",
-		},
-		{
-			name:    "validation error without underlying error",
-			message: "Invalid input",
-			err:     nil,
-			want:    "VALIDATION_ERROR: Invalid input",
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			err := NewValidationError(tt.message, tt.err)
-			if err == nil {
-				t.Error("NewValidationError() returned nil")
-			}
-			if err.Code != "VALIDATION_ERROR" {
-				t.Errorf("Code = %v, want VALIDATION_ERROR", err.Code)
-			}
-			if err.Message != tt.message {
-				t.Errorf("Message = %v, want %v", err.Message, tt.message)
-			}
-		})
-	}
-}
-
-func TestNewInternalError(t *testing.T) {
-	message := "Database connection failed"
-	err := NewInternalError(message, nil)
-
-	if err.Code != "INTERNAL_ERROR" {
-		t.Errorf("Code = %v, want INTERNAL_ERROR", err.Code)
-	}
-	if err.Message != message {
-		t.Errorf("Message = %v, want %v", err.Message, message)
-	}
-}
-
-func TestNewNotFoundError(t *testing.T) {
-	message := "Resource not found"
-	err := NewNotFoundError(message, nil)
-
-	if err.Code != "NOT_FOUND" {
-		t.Errorf("Code = %v, want NOT_FOUND", err.Code)
-	}
-	if err.Message != message {
-		t.Errorf("Message = %v, want %v", err.Message, message)
-	}
-}
-
-func TestNewUnauthorizedError(t *testing.T) {
-	message := "Invalid credentials"
-	err := NewUnauthorizedError(message, nil)
-
-	if err.Code != "UNAUTHORIZED" {
-		t.Errorf("Code = %v, want UNAUTHORIZED", err.Code)
-	}
-	if err.Message != message {
-		t.Errorf("Message = %v, want %v", err.Message, message)
-	}
-}
-
-func TestAppError_Error(t *testing.T) {
-	tests := []struct {
-		name    string
-		appErr  *AppError
-		wantStr string
-	}{
-		{
-			name: "error with underlying error",
-			appErr: &AppError{
-				Code:    "TEST_ERROR",
-				Message: "Test message",
-				Err:     NewValidationError("underlying", nil),
-			},
-			wantStr: "TEST_ERROR: Test message",
-		},
-		{
-			name: "error without underlying error",
-			appErr: &AppError{
-				Code:    "TEST_ERROR",
-				Message: "Test message",
-				Err:     nil,
-			},
-			wantStr: "TEST_ERROR: Test message",
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := tt.appErr.Error()
-			if len(got) == 0 {
-				t.Error("Error() returned empty string")
-			}
-		})
-	}
-}
+package errors
+
+import (
+	stderrors "errors"
+	"fmt"
+	"testing"
+)
+
+func TestNewValidationError(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		err     error
+		want    string
+	}{
+		{
+			name:    "validation error with underlying error",
+			message: "Invalid input",
+			err:     NewValidationError("field required", nil),
+			want:    "VALIDATION_ERROR: Invalid input",
+		},
+		{
+			name:    "validation error without underlying error",
+			message: "Invalid input",
+			err:     nil,
+			want:    "VALIDATION_ERROR: Invalid input",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewValidationError(tt.message, tt.err)
+			if err == nil {
+				t.Error("NewValidationError() returned nil")
+			}
+			if err.Code != "VALIDATION_ERROR" {
+				t.Errorf("Code = %v, want VALIDATION_ERROR", err.Code)
+			}
+			if err.Message != tt.message {
+				t.Errorf("Message = %v, want %v", err.Message, tt.message)
+			}
+		})
+	}
+}
+
+func TestNewInternalError(t *testing.T) {
+	message := "Database connection failed"
+	err := NewInternalError(message, nil)
+
+	if err.Code != "INTERNAL_ERROR" {
+		t.Errorf("Code = %v, want INTERNAL_ERROR", err.Code)
+	}
+	if err.Message != message {
+		t.Errorf("Message = %v, want %v", err.Message, message)
+	}
+}
+
+func TestNewNotFoundError(t *testing.T) {
+	message := "Resource not found"
+	err := NewNotFoundError(message, nil)
+
+	if err.Code != "NOT_FOUND" {
+		t.Errorf("Code = %v, want NOT_FOUND", err.Code)
+	}
+	if err.Message != message {
+		t.Errorf("Message = %v, want %v", err.Message, message)
+	}
+}
+
+func TestNewUnauthorizedError(t *testing.T) {
+	message := "Invalid credentials"
+	err := NewUnauthorizedError(message, nil)
+
+	if err.Code != "UNAUTHORIZED" {
+		t.Errorf("Code = %v, want UNAUTHORIZED", err.Code)
+	}
+	if err.Message != message {
+		t.Errorf("Message = %v, want %v", err.Message, message)
+	}
+}
+
+func TestAppError_Error(t *testing.T) {
+	tests := []struct {
+		name    string
+		appErr  *AppError
+		wantStr string
+	}{
+		{
+			name: "error with underlying error",
+			appErr: &AppError{
+				Code:    "TEST_ERROR",
+				Message: "Test message",
+				Err:     NewValidationError("underlying", nil),
+			},
+			wantStr: "TEST_ERROR: Test message",
+		},
+		{
+			name: "error without underlying error",
+			appErr: &AppError{
+				Code:    "TEST_ERROR",
+				Message: "Test message",
+				Err:     nil,
+			},
+			wantStr: "TEST_ERROR: Test message",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.appErr.Error()
+			if len(got) == 0 {
+				t.Error("Error() returned empty string")
+			}
+		})
+	}
+}
+
+func TestAppError_HTTPStatus(t *testing.T) {
+	tests := []struct {
+		err  *AppError
+		want int
+	}{
+		{NewValidationError("bad", nil), 400},
+		{NewUnauthorizedError("no", nil), 401},
+		{NewForbiddenError("not yours", nil), 403},
+		{NewNotFoundError("missing", nil), 404},
+		{NewConflictError("stale", nil), 409},
+		{NewRateLimitedError("slow down", nil), 429},
+		{NewInternalError("boom", nil), 500},
+		{&AppError{Code: "SOMETHING_ELSE"}, 500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.err.Code, func(t *testing.T) {
+			if got := tt.err.HTTPStatus(); got != tt.want {
+				t.Errorf("HTTPStatus() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromError(t *testing.T) {
+	notFound := NewNotFoundError("Notification not found", nil)
+	if got := FromError(fmt.Errorf("lookup: %w", notFound), "Failed"); got != notFound {
+		t.Errorf("FromError() = %v, want the wrapped AppError", got)
+	}
+
+	plain := stderrors.New("connection refused")
+	got := FromError(plain, "Failed to send email")
+	if got.Code != CodeInternal || got.Message != "Failed to send email" || !stderrors.Is(got, plain) {
+		t.Errorf("FromError() = %v, want internal error wrapping the original", got)
+	}
+}
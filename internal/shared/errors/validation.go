@@ -0,0 +1,141 @@
+package errors
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldsKey is the response field listing a validation error's failed fields
+const FieldsKey = "fields"
+
+// FieldError describes one request field that failed validation
+type FieldError struct {
+	Field   string `json:"field"`           // JSON path of the field, e.g. items[0].email.to
+	Rule    string `json:"rule"`            // Rule that failed, e.g. required, max or type
+	Param   string `json:"param,omitempty"` // The rule's parameter, e.g. 100 for max=100
+	Message string `json:"message"`
+}
+
+// NewBindingError creates a validation error for a request body that failed to bind.
+// Failed validation rules and mistyped JSON values are listed field by field under FieldsKey.
+func NewBindingError(err error) *AppError {
+	appErr := NewValidationError("Invalid request", err)
+	if fields := FieldErrors(err); len(fields) > 0 {
+		appErr.WithField(FieldsKey, fields)
+	}
+	return appErr
+}
+
+// FieldErrors returns the field-level failures in err, or nil when err has none
+func FieldErrors(err error) []FieldError {
+	var validationErrs validator.ValidationErrors
+	if stderrors.As(err, &validationErrs) {
+		fields := make([]FieldError, 0, len(validationErrs))
+		for _, fieldErr := range validationErrs {
+			field := fieldPath(fieldErr.Namespace())
+			fields = append(fields, FieldError{
+				Field:   field,
+				Rule:    fieldErr.Tag(),
+				Param:   fieldErr.Param(),
+				Message: field + " " + ruleMessage(fieldErr),
+			})
+		}
+		return fields
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if stderrors.As(err, &typeErr) && typeErr.Field != "" {
+		return []FieldError{{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Param:   typeErr.Type.String(),
+			Message: fmt.Sprintf("%s must be %s, not %s", typeErr.Field, jsonType(typeErr.Type), typeErr.Value),
+		}}
+	}
+	return nil
+}
+
+// fieldPath drops the request type from a validator namespace: SendEmailRequest.to[0] is to[0]
+func fieldPath(namespace string) string {
+	if _, path, ok := strings.Cut(namespace, "."); ok {
+		return path
+	}
+	return namespace
+}
+
+// ruleMessage describes a failed rule, to follow the field's name
+func ruleMessage(fieldErr validator.FieldError) string {
+	param := fieldErr.Param()
+	counted := "" // Numbers are compared by value
+	switch fieldErr.Kind() {
+	case reflect.String:
+		counted = "characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		counted = "items"
+	}
+
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "required_without":
+		return "is required when " + param + " is not set"
+	case "required_with":
+		return "is required when " + param + " is set"
+	case "oneof":
+		return "must be one of: " + strings.Join(strings.Fields(param), ", ")
+	case "email":
+		return "must be a valid email address"
+	case "url", "http_url":
+		return "must be a valid URL"
+	case "e164":
+		return "must be a phone number in E.164 format"
+	case "hexadecimal":
+		return "must be hexadecimal"
+	case "min", "gte":
+		if counted == "" {
+			return "must be at least " + param
+		}
+		return fmt.Sprintf("must have at least %s %s", param, counted)
+	case "max", "lte":
+		if counted == "" {
+			return "must be at most " + param
+		}
+		return fmt.Sprintf("must have at most %s %s", param, counted)
+	case "len":
+		if counted == "" {
+			return "must be " + param
+		}
+		return fmt.Sprintf("must have exactly %s %s", param, counted)
+	case "gt":
+		return "must be greater than " + param
+	case "lt":
+		return "must be less than " + param
+	}
+	if param != "" {
+		return fmt.Sprintf("failed the %s=%s rule", fieldErr.Tag(), param)
+	}
+	return fmt.Sprintf("failed the %s rule", fieldErr.Tag())
+}
+
+// jsonType names the JSON type a Go type is decoded from
+func jsonType(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	}
+	return "a number"
+}
@@ -0,0 +1,76 @@
+package errors
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type testItem struct {
+	Channel string `json:"channel" validate:"required,oneof=email sms"`
+}
+
+type testRequest struct {
+	To       []string   `json:"to" validate:"required,min=1"`
+	Subject  string     `json:"subject" validate:"max=5"`
+	Priority int        `json:"priority" validate:"gte=1"`
+	Items    []testItem `json:"items" validate:"dive"`
+}
+
+func newTestValidator() *validator.Validate {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		return name
+	})
+	return validate
+}
+
+func TestFieldErrorsFromValidation(t *testing.T) {
+	err := newTestValidator().Struct(testRequest{
+		Subject: "too long",
+		Items:   []testItem{{Channel: "email"}, {Channel: "fax"}},
+	})
+
+	want := []FieldError{
+		{Field: "to", Rule: "required", Message: "to is required"},
+		{Field: "subject", Rule: "max", Param: "5", Message: "subject must have at most 5 characters"},
+		{Field: "priority", Rule: "gte", Param: "1", Message: "priority must be at least 1"},
+		{Field: "items[1].channel", Rule: "oneof", Param: "email sms", Message: "items[1].channel must be one of: email, sms"},
+	}
+	if got := FieldErrors(err); !reflect.DeepEqual(got, want) {
+		t.Errorf("FieldErrors() = %+v, want %+v", got, want)
+	}
+}
+
+func TestFieldErrorsFromJSONType(t *testing.T) {
+	var req testRequest
+	err := json.Unmarshal([]byte(`{"priority": "high"}`), &req)
+
+	fields := FieldErrors(err)
+	if len(fields) != 1 || fields[0].Field != "priority" || fields[0].Rule != "type" {
+		t.Fatalf("FieldErrors() = %+v, want a type error for priority", fields)
+	}
+	if fields[0].Message != "priority must be a number, not string" {
+		t.Errorf("Message = %q", fields[0].Message)
+	}
+}
+
+func TestNewBindingError(t *testing.T) {
+	err := NewBindingError(stderrors.New("EOF"))
+	if err.Code != CodeValidation || err.Message != "Invalid request" {
+		t.Errorf("NewBindingError() = %v", err)
+	}
+	if _, ok := err.Fields[FieldsKey]; ok {
+		t.Errorf("Fields = %v, want none for a non-field error", err.Fields)
+	}
+
+	err = NewBindingError(newTestValidator().Struct(testRequest{Priority: 1}))
+	if fields, _ := err.Fields[FieldsKey].([]FieldError); len(fields) != 1 || fields[0].Field != "to" {
+		t.Errorf("Fields = %v, want the missing to field", err.Fields)
+	}
+}
@@ -0,0 +1,163 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RedactionMode controls how PII is masked in log fields
+type RedactionMode int32
+
+const (
+	// RedactionPartial masks most of the value but keeps enough to correlate (default)
+	RedactionPartial RedactionMode = iota
+	// RedactionFull replaces PII values entirely
+	RedactionFull
+	// RedactionOff logs PII values as-is (local development only)
+	RedactionOff
+)
+
+const redactedPlaceholder = "[REDACTED]"
+
+// piiKeys are log field names whose values contain recipient identifiers
+var piiKeys = map[string]struct{}{
+	"email":     {},
+	"recipient": {},
+	"to":        {},
+	"cc":        {},
+	"bcc":       {},
+	"from":      {},
+	"reply_to":  {},
+	"phone":     {},
+}
+
+// contentKeys are log field names holding message content, never logged above debug level
+var contentKeys = map[string]struct{}{
+	"subject": {},
+	"body":    {},
+}
+
+// ParseRedactionMode parses a redaction mode name (full, partial, off)
+func ParseRedactionMode(value string) (RedactionMode, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "partial":
+		return RedactionPartial, nil
+	case "full":
+		return RedactionFull, nil
+	case "off":
+		return RedactionOff, nil
+	default:
+		return RedactionPartial, fmt.Errorf("invalid redaction mode: %s (must be full, partial or off)", value)
+	}
+}
+
+// String returns the name of the redaction mode
+func (m RedactionMode) String() string {
+	switch m {
+	case RedactionFull:
+		return "full"
+	case RedactionOff:
+		return "off"
+	default:
+		return "partial"
+	}
+}
+
+// RedactEmail masks the local part of an email address, e.g. "j***@example.com"
+func RedactEmail(email string, mode RedactionMode) string {
+	switch mode {
+	case RedactionOff:
+		return email
+	case RedactionFull:
+		return redactedPlaceholder
+	}
+
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return maskValue(email)
+	}
+	return email[:1] + "***" + email[at:]
+}
+
+// RedactPhone masks all but the last four digits of a phone number, e.g. "+*******2671"
+func RedactPhone(phone string, mode RedactionMode) string {
+	switch mode {
+	case RedactionOff:
+		return phone
+	case RedactionFull:
+		return redactedPlaceholder
+	}
+
+	digits := 0
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			digits++
+		}
+	}
+
+	var b strings.Builder
+	seen := 0
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			seen++
+			if digits-seen >= 4 {
+				b.WriteRune('*')
+				continue
+			}
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// RedactRecipient masks an email address or phone number based on its shape
+func RedactRecipient(value string, mode RedactionMode) string {
+	if strings.Contains(value, "@") {
+		return RedactEmail(value, mode)
+	}
+	return RedactPhone(value, mode)
+}
+
+// maskValue keeps only the first character of a value
+func maskValue(value string) string {
+	if value == "" {
+		return value
+	}
+	return value[:1] + "***"
+}
+
+// redactFields returns a copy of keysAndValues with PII and content values masked
+func redactFields(mode RedactionMode, allowContent bool, keysAndValues []interface{}) []interface{} {
+	out := make([]interface{}, len(keysAndValues))
+	copy(out, keysAndValues)
+
+	for i := 0; i+1 < len(out); i += 2 {
+		key, ok := out[i].(string)
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(key)
+
+		if _, isContent := contentKeys[key]; isContent && !allowContent {
+			out[i+1] = redactedPlaceholder
+			continue
+		}
+
+		if _, isPII := piiKeys[key]; !isPII || mode == RedactionOff {
+			continue
+		}
+
+		switch v := out[i+1].(type) {
+		case string:
+			out[i+1] = RedactRecipient(v, mode)
+		case []string:
+			masked := make([]string, len(v))
+			for j, s := range v {
+				masked[j] = RedactRecipient(s, mode)
+			}
+			out[i+1] = masked
+		}
+	}
+
+	return out
+}
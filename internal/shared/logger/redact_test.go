@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRedactRecipient(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		mode  RedactionMode
+		want  string
+	}{
+		{name: "email partial", value: "john.doe@example.com", mode: RedactionPartial, want: "j***@example.com"},
+		{name: "email full", value: "john.doe@example.com", mode: RedactionFull, want: "[REDACTED]"},
+		{name: "email off", value: "john.doe@example.com", mode: RedactionOff, want: "john.doe@example.com"},
+		{name: "phone partial", value: "+14155552671", mode: RedactionPartial, want: "+*******2671"},
+		{name: "short phone partial", value: "123", mode: RedactionPartial, want: "123"},
+		{name: "phone full", value: "+14155552671", mode: RedactionFull, want: "[REDACTED]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RedactRecipient(tt.value, tt.mode); got != tt.want {
+				t.Errorf("RedactRecipient() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactFields(t *testing.T) {
+	fields := []interface{}{
+		"id", "abc",
+		"email", "jane@example.com",
+		"to", []string{"a@example.com", "+14155552671"},
+		"subject", "Your password reset code",
+	}
+
+	got := redactFields(RedactionPartial, false, fields)
+	want := []interface{}{
+		"id", "abc",
+		"email", "j***@example.com",
+		"to", []string{"a***@example.com", "+*******2671"},
+		"subject", "[REDACTED]",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("redactFields() = %v, want %v", got, want)
+	}
+
+	// Original slice must not be modified
+	if fields[3] != "jane@example.com" {
+		t.Error("redactFields() modified its input")
+	}
+
+	// Content is retained at debug level
+	debug := redactFields(RedactionOff, true, fields)
+	if debug[7] != "Your password reset code" || debug[3] != "jane@example.com" {
+		t.Errorf("redactFields() with redaction off = %v", debug)
+	}
+}
+
+func TestParseRedactionMode(t *testing.T) {
+	for _, value := range []string{"", "partial", "FULL", "off"} {
+		if _, err := ParseRedactionMode(value); err != nil {
+			t.Errorf("ParseRedactionMode(%q) error = %v", value, err)
+		}
+	}
+	if _, err := ParseRedactionMode("mask"); err == nil {
+		t.Error("Expected error for unknown redaction mode")
+	}
+}
@@ -0,0 +1,126 @@
+package mongodb
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/event"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+// Connection pool defaults used when a Config field is left zero
+const (
+	DefaultMaxPoolSize            = 100
+	DefaultMinPoolSize            = 10
+	DefaultMaxConnIdleTime        = 30 * time.Second
+	DefaultConnectTimeout         = 10 * time.Second
+	DefaultServerSelectionTimeout = 10 * time.Second
+)
+
+// maxPoolSizeLimit bounds MaxPoolSize; more connections than this per instance is a misconfiguration
+const maxPoolSizeLimit = 10000
+
+// Config holds MongoDB connection settings
+// Zero pool sizes and timeouts use the defaults above.
+type Config struct {
+	URI                    string
+	Database               string
+	MaxPoolSize            uint64
+	MinPoolSize            uint64
+	MaxConnIdleTime        time.Duration
+	ConnectTimeout         time.Duration
+	ServerSelectionTimeout time.Duration
+	ReadConcern            string                // local, available, majority, linearizable or snapshot; empty keeps the URI or server default
+	WriteConcern           string                // majority or a node count; empty keeps the URI or server default
+	CriticalReadConcern    string                // Read concern for CriticalCollection and transactions: local, majority or snapshot; empty uses ReadConcern
+	CriticalWriteConcern   string                // Write concern for CriticalCollection and transactions; empty uses WriteConcern
+	Monitor                *event.CommandMonitor // Optional; receives command events (e.g. for dependency health tracking)
+	ServerMonitor          *event.ServerMonitor  // Optional; receives server heartbeat events
+}
+
+// withDefaults returns the config with zero pool sizes and timeouts replaced by the defaults
+func (c Config) withDefaults() Config {
+	if c.MaxPoolSize == 0 {
+		c.MaxPoolSize = DefaultMaxPoolSize
+	}
+	if c.MinPoolSize == 0 {
+		c.MinPoolSize = min(DefaultMinPoolSize, c.MaxPoolSize)
+	}
+	if c.MaxConnIdleTime == 0 {
+		c.MaxConnIdleTime = DefaultMaxConnIdleTime
+	}
+	if c.ConnectTimeout == 0 {
+		c.ConnectTimeout = DefaultConnectTimeout
+	}
+	if c.ServerSelectionTimeout == 0 {
+		c.ServerSelectionTimeout = DefaultServerSelectionTimeout
+	}
+	return c
+}
+
+// validate checks pool sizes, timeouts and concerns after defaults are applied
+func (c Config) validate() error {
+	if c.MaxPoolSize > maxPoolSizeLimit {
+		return fmt.Errorf("max pool size must be at most %d, got %d", maxPoolSizeLimit, c.MaxPoolSize)
+	}
+	if c.MinPoolSize > c.MaxPoolSize {
+		return fmt.Errorf("min pool size %d must not exceed max pool size %d", c.MinPoolSize, c.MaxPoolSize)
+	}
+	if c.MaxConnIdleTime < 0 || c.ConnectTimeout < 0 || c.ServerSelectionTimeout < 0 {
+		return errors.New("timeouts must not be negative")
+	}
+	if _, err := ParseReadConcern(c.ReadConcern); err != nil {
+		return err
+	}
+	if _, err := ParseWriteConcern(c.WriteConcern); err != nil {
+		return err
+	}
+	if _, err := ParseTransactionReadConcern(c.CriticalReadConcern); err != nil {
+		return err
+	}
+	if _, err := ParseWriteConcern(c.CriticalWriteConcern); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ParseReadConcern parses a read concern level; empty returns nil to keep the default
+func ParseReadConcern(level string) (*readconcern.ReadConcern, error) {
+	switch level {
+	case "":
+		return nil, nil
+	case "local", "available", "majority", "linearizable", "snapshot":
+		return &readconcern.ReadConcern{Level: level}, nil
+	default:
+		return nil, fmt.Errorf("invalid read concern: %s (must be local, available, majority, linearizable or snapshot)", level)
+	}
+}
+
+// ParseTransactionReadConcern parses a read concern level that is valid inside a transaction
+// Empty returns nil to keep the default.
+func ParseTransactionReadConcern(level string) (*readconcern.ReadConcern, error) {
+	switch level {
+	case "available", "linearizable":
+		return nil, fmt.Errorf("invalid transaction read concern: %s (must be local, majority or snapshot)", level)
+	default:
+		return ParseReadConcern(level)
+	}
+}
+
+// ParseWriteConcern parses a write concern "w" value; empty returns nil to keep the default
+func ParseWriteConcern(w string) (*writeconcern.WriteConcern, error) {
+	if w == "" {
+		return nil, nil
+	}
+	if w == "majority" {
+		return writeconcern.Majority(), nil
+	}
+	nodes, err := strconv.Atoi(w)
+	if err != nil || nodes < 0 {
+		return nil, fmt.Errorf("invalid write concern: %s (must be majority or a node count)", w)
+	}
+	return &writeconcern.WriteConcern{W: nodes}, nil
+}
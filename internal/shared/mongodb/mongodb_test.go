@@ -1,108 +1,146 @@
-package mongodb
-
-import (
-	"testing"
-)
-
-func TestValidateMongoURI(t *testing.T) {
-	tests := []struct {
-		name    string
-		uri     string
-		wantErr bool
-	}{
-		{
-			name:    "valid mongodb URI",
-			uri:     "mongodb://localhost:27017",
-			wantErr: false,
-		},
-		{
-			name:    "valid mongodb+srv URI",
-			uri:     "mongodb+srv://cluster.mongodb.net",
-			wantErr: false,
-		},
-		{
-			name:    "empty URI",
-			uri:     "",
-			wantErr: true,
-		},
-		{
-			name:    "invalid scheme",
-			uri:     "http://localhost:27017",
-			wantErr: true,
-		},
-		{
-			name:    "invalid scheme - postgres",
-			uri:     "postgres://localhost:5432",
-			wantErr: true,
-		},
-		{
-			name:    "missing host",
-			uri:     "mongodb://",
-			wantErr: true,
-		},
-		{
-			name:    "malformed URI",
-			uri:     "not-a-valid-uri",
-			wantErr: true,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			err := validateMongoURI(tt.uri)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("validateMongoURI() error = %v, wantErr %v", err, tt.wantErr)
-			}
-		})
-	}
-}
-
-func TestNewMongoClient_SecurityValidation(t *testing.T) {
-	tests := []struct {
-		name     string
-		uri      string
-		database string
-		wantErr  bool
-	}{
-		{
-			name:     "empty database name",
-			uri:      "mongodb://localhost:27017",
-			database: "",
-			wantErr:  true,
-		},
-		{
-			name:     "invalid database name with slash",
-			uri:      "mongodb://localhost:27017",
-			database: "test/db",
-			wantErr:  true,
-		},
-		{
-			name:     "invalid database name with backslash",
-			uri:      "mongodb://localhost:27017",
-			database: "test\\db",
-			wantErr:  true,
-		},
-		{
-			name:     "invalid database name with dot",
-			uri:      "mongodb://localhost:27017",
-			database: "test.db",
-			wantErr:  true,
-		},
-		{
-			name:     "invalid database name with special chars",
-			uri:      "mongodb://localhost:27017",
-			database: "test$db",
-			wantErr:  true,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			// Note: This will fail to connect but we're testing validation
-			_, err := NewMongoClient(tt.uri, tt.database)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("NewMongoClient() error = %v, wantErr %v", err, tt.wantErr)
-			}
-		})
-	}
-}
+package mongodb
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidateMongoURI(t *testing.T) {
+	tests := []struct {
+		name    string
+		uri     string
+		wantErr bool
+	}{
+		{
+			name:    "valid mongodb URI",
+			uri:     "mongodb://localhost:27017",
+			wantErr: false,
+		},
+		{
+			name:    "valid mongodb+srv URI",
+			uri:     "mongodb+srv://cluster.mongodb.net",
+			wantErr: false,
+		},
+		{
+			name:    "empty URI",
+			uri:     "",
+			wantErr: true,
+		},
+		{
+			name:    "invalid scheme",
+			uri:     "http://localhost:27017",
+			wantErr: true,
+		},
+		{
+			name:    "invalid scheme - postgres",
+			uri:     "postgres://localhost:5432",
+			wantErr: true,
+		},
+		{
+			name:    "missing host",
+			uri:     "mongodb://",
+			wantErr: true,
+		},
+		{
+			name:    "malformed URI",
+			uri:     "not-a-valid-uri",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateMongoURI(tt.uri)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateMongoURI() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewMongoClient_SecurityValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		uri      string
+		database string
+		wantErr  bool
+	}{
+		{
+			name:     "empty database name",
+			uri:      "mongodb://localhost:27017",
+			database: "",
+			wantErr:  true,
+		},
+		{
+			name:     "invalid database name with slash",
+			uri:      "mongodb://localhost:27017",
+			database: "test/db",
+			wantErr:  true,
+		},
+		{
+			name:     "invalid database name with backslash",
+			uri:      "mongodb://localhost:27017",
+			database: "test\\db",
+			wantErr:  true,
+		},
+		{
+			name:     "invalid database name with dot",
+			uri:      "mongodb://localhost:27017",
+			database: "test.db",
+			wantErr:  true,
+		},
+		{
+			name:     "invalid database name with special chars",
+			uri:      "mongodb://localhost:27017",
+			database: "test$db",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Note: This will fail to connect but we're testing validation
+			_, err := NewMongoClient(&Config{URI: tt.uri, Database: tt.database})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewMongoClient() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{name: "defaults", config: Config{}},
+		{name: "custom pool", config: Config{MaxPoolSize: 20, MinPoolSize: 5, ReadConcern: "majority", WriteConcern: "2"}},
+		{name: "min above max", config: Config{MaxPoolSize: 5, MinPoolSize: 10}, wantErr: true},
+		{name: "max too large", config: Config{MaxPoolSize: 20000}, wantErr: true},
+		{name: "negative timeout", config: Config{ConnectTimeout: -time.Second}, wantErr: true},
+		{name: "unknown read concern", config: Config{ReadConcern: "eventual"}, wantErr: true},
+		{name: "unknown write concern", config: Config{WriteConcern: "all"}, wantErr: true},
+		{name: "critical majority", config: Config{CriticalReadConcern: "majority", CriticalWriteConcern: "majority"}},
+		{name: "critical read concern invalid in transactions", config: Config{CriticalReadConcern: "linearizable"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.withDefaults().validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigWithDefaults(t *testing.T) {
+	cfg := Config{MaxPoolSize: 4}.withDefaults()
+	if cfg.MinPoolSize != 4 {
+		t.Errorf("MinPoolSize = %d, want it capped at MaxPoolSize 4", cfg.MinPoolSize)
+	}
+	if cfg.ConnectTimeout != DefaultConnectTimeout || cfg.MaxConnIdleTime != DefaultMaxConnIdleTime {
+		t.Errorf("timeouts = %v/%v, want defaults", cfg.ConnectTimeout, cfg.MaxConnIdleTime)
+	}
+}
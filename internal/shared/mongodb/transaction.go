@@ -0,0 +1,107 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Error labels attached by the server to retryable transaction failures
+const (
+	labelTransientTransactionError      = "TransientTransactionError"
+	labelUnknownTransactionCommitResult = "UnknownTransactionCommitResult"
+)
+
+// maxTransactionAttempts bounds retries of a whole transaction and of its commit
+const maxTransactionAttempts = 5
+
+// ErrTransactionsNotSupported is returned when the server is a standalone instance
+// Multi-document transactions require a replica set or sharded cluster.
+var ErrTransactionsNotSupported = errors.New("mongodb transactions require a replica set or sharded cluster")
+
+// SupportsTransactions reports whether the connected deployment supports multi-document transactions
+func (c *MongoClient) SupportsTransactions() bool {
+	return c.transactions
+}
+
+// WithTransaction runs fn inside a transaction, committing on success and aborting on error
+// The transaction is retried on TransientTransactionError and the commit on
+// UnknownTransactionCommitResult, so fn may be called more than once and must be idempotent.
+func (c *MongoClient) WithTransaction(ctx context.Context, fn func(sessCtx mongo.SessionContext) error) error {
+	if !c.transactions {
+		return ErrTransactionsNotSupported
+	}
+
+	session, err := c.client.StartSession()
+	if err != nil {
+		return fmt.Errorf("failed to start session: %w", err)
+	}
+	defer session.EndSession(context.WithoutCancel(ctx))
+
+	return mongo.WithSession(ctx, session, func(sessCtx mongo.SessionContext) error {
+		var err error
+		for attempt := 1; attempt <= maxTransactionAttempts; attempt++ {
+			err = runTransaction(sessCtx, c.transaction, fn)
+			if err == nil || !hasErrorLabel(err, labelTransientTransactionError) || ctx.Err() != nil {
+				return err
+			}
+		}
+		return fmt.Errorf("transaction failed after %d attempts: %w", maxTransactionAttempts, err)
+	})
+}
+
+// runTransaction runs a single transaction attempt
+func runTransaction(sessCtx mongo.SessionContext, opts *options.TransactionOptions, fn func(sessCtx mongo.SessionContext) error) error {
+	if err := sessCtx.StartTransaction(opts); err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+
+	if err := fn(sessCtx); err != nil {
+		// Abort must run even if the caller's context is already cancelled
+		_ = sessCtx.AbortTransaction(context.WithoutCancel(sessCtx))
+		return err
+	}
+
+	return commitWithRetry(sessCtx)
+}
+
+// commitWithRetry commits the active transaction, retrying when the outcome is unknown
+func commitWithRetry(sessCtx mongo.SessionContext) error {
+	var err error
+	for attempt := 1; attempt <= maxTransactionAttempts; attempt++ {
+		err = sessCtx.CommitTransaction(sessCtx)
+		if err == nil || !hasErrorLabel(err, labelUnknownTransactionCommitResult) || sessCtx.Err() != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// hasErrorLabel reports whether err carries a server error label
+func hasErrorLabel(err error, label string) bool {
+	var labeled mongo.LabeledError
+	return errors.As(err, &labeled) && labeled.HasErrorLabel(label)
+}
+
+// detectTransactionSupport checks whether the server is a replica set member or mongos
+func detectTransactionSupport(ctx context.Context, client *mongo.Client) bool {
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+
+	admin := client.Database("admin")
+	err := admin.RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello)
+	if err != nil {
+		// Servers older than 4.4.2 only understand isMaster
+		if err = admin.RunCommand(ctx, bson.D{{Key: "isMaster", Value: 1}}).Decode(&hello); err != nil {
+			return false
+		}
+	}
+
+	return hello.SetName != "" || hello.Msg == "isdbgrid"
+}
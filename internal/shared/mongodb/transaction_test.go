@@ -0,0 +1,51 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestWithTransactionNotSupported(t *testing.T) {
+	client := &MongoClient{}
+
+	called := false
+	err := client.WithTransaction(context.Background(), func(sessCtx mongo.SessionContext) error {
+		called = true
+		return nil
+	})
+
+	if !errors.Is(err, ErrTransactionsNotSupported) {
+		t.Errorf("WithTransaction() error = %v, want %v", err, ErrTransactionsNotSupported)
+	}
+	if called {
+		t.Error("WithTransaction() ran fn without transaction support")
+	}
+}
+
+func TestHasErrorLabel(t *testing.T) {
+	transient := mongo.CommandError{Code: 112, Labels: []string{labelTransientTransactionError}}
+
+	tests := []struct {
+		name  string
+		err   error
+		label string
+		want  bool
+	}{
+		{name: "labeled", err: transient, label: labelTransientTransactionError, want: true},
+		{name: "wrapped", err: fmt.Errorf("insert: %w", transient), label: labelTransientTransactionError, want: true},
+		{name: "other label", err: transient, label: labelUnknownTransactionCommitResult, want: false},
+		{name: "unlabeled", err: errors.New("boom"), label: labelTransientTransactionError, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hasErrorLabel(tt.err, tt.label); got != tt.want {
+				t.Errorf("hasErrorLabel() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,140 +1,236 @@
-package rabbitmq
-
-import (
-	"github.com/rabbitmq/amqp091-go"
-)
-
-// RabbitMQClient wraps the RabbitMQ connection
-type RabbitMQClient struct {
-	conn    *amqp091.Connection
-	channel *amqp091.Channel
-}
-
-// Message represents a RabbitMQ message
-type Message struct {
-	Body       []byte
-	RoutingKey string
-	delivery   amqp091.Delivery
-}
-
-// Ack acknowledges a message
-func (m *Message) Ack(multiple bool) error {
-	return m.delivery.Ack(multiple)
-}
-
-// Nack negative acknowledges a message
-func (m *Message) Nack(multiple, requeue bool) error {
-	return m.delivery.Nack(multiple, requeue)
-}
-
-// NewRabbitMQClient creates a new RabbitMQ client
-func NewRabbitMQClient(url string) (*RabbitMQClient, error) {
-	conn, err := amqp091.Dial(url)
-	if err != nil {
-		return nil, err
-	}
-
-	channel, err := conn.Channel()
-	if err != nil {
-		conn.Close()
-		return nil, err
-	}
-
-	return &RabbitMQClient{
-		conn:    conn,
-		channel: channel,
-	}, nil
-}
-
-// DeclareExchange declares an exchange
-func (c *RabbitMQClient) DeclareExchange(name, kind string) error {
-	return c.channel.ExchangeDeclare(
-		name,
-		kind,
-		true,  // durable
-		false, // auto-deleted
-		false, // internal
-		false, // no-wait
-		nil,   // arguments
-	)
-}
-
-// DeclareQueue declares a queue
-func (c *RabbitMQClient) DeclareQueue(name string) error {
-	_, err := c.channel.QueueDeclare(
-		name,
-		true,  // durable
-		false, // delete when unused
-		false, // exclusive
-		false, // no-wait
-		nil,   // arguments
-	)
-	return err
-}
-
-// BindQueue binds a queue to an exchange
-func (c *RabbitMQClient) BindQueue(queue, routingKey, exchange string) error {
-	return c.channel.QueueBind(
-		queue,
-		routingKey,
-		exchange,
-		false, // no-wait
-		nil,   // arguments
-	)
-}
-
-// Consume starts consuming messages from a queue
-func (c *RabbitMQClient) Consume(queue, consumerTag string) (<-chan Message, error) {
-	msgs, err := c.channel.Consume(
-		queue,
-		consumerTag,
-		false, // auto-ack
-		false, // exclusive
-		false, // no-local
-		false, // no-wait
-		nil,   // arguments
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	// Convert to our Message type
-	messageChan := make(chan Message)
-	go func() {
-		for d := range msgs {
-			messageChan <- Message{
-				Body:       d.Body,
-				RoutingKey: d.RoutingKey,
-				delivery:   d,
-			}
-		}
-		close(messageChan)
-	}()
-
-	return messageChan, nil
-}
-
-// Publish publishes a message to an exchange
-func (c *RabbitMQClient) Publish(exchange, routingKey string, body []byte) error {
-	return c.channel.Publish(
-		exchange,
-		routingKey,
-		false, // mandatory
-		false, // immediate
-		amqp091.Publishing{
-			ContentType: "application/json",
-			Body:        body,
-		},
-	)
-}
-
-// Close closes the RabbitMQ connection
-func (c *RabbitMQClient) Close() error {
-	if c.channel != nil {
-		c.channel.Close()
-	}
-	if c.conn != nil {
-		return c.conn.Close()
-	}
-	return nil
-}
+package rabbitmq
+
+import (
+	"context"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// RabbitMQClient wraps the RabbitMQ connection
+type RabbitMQClient struct {
+	conn    *amqp091.Connection
+	channel *amqp091.Channel
+}
+
+// RetryCountHeader counts the failed attempts of a message republished with Republish.
+// Classic queues don't count redeliveries, so consumers republish failed messages instead of
+// requeueing them.
+const RetryCountHeader = "x-retry-count"
+
+// Message represents a RabbitMQ message
+type Message struct {
+	Body          []byte
+	RoutingKey    string
+	DeliveryCount int64 // Previous delivery attempts, counted by quorum queues or RetryCountHeader
+	delivery      amqp091.Delivery
+}
+
+// Ack acknowledges a message
+func (m *Message) Ack(multiple bool) error {
+	return m.delivery.Ack(multiple)
+}
+
+// Nack negative acknowledges a message
+func (m *Message) Nack(multiple, requeue bool) error {
+	return m.delivery.Nack(multiple, requeue)
+}
+
+// NewRabbitMQClient creates a new RabbitMQ client
+func NewRabbitMQClient(url string) (*RabbitMQClient, error) {
+	conn, err := amqp091.Dial(url)
+	if err != nil {
+		return nil, err
+	}
+
+	channel, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &RabbitMQClient{
+		conn:    conn,
+		channel: channel,
+	}, nil
+}
+
+// DeclareExchange declares an exchange
+func (c *RabbitMQClient) DeclareExchange(name, kind string) error {
+	return c.channel.ExchangeDeclare(
+		name,
+		kind,
+		true,  // durable
+		false, // auto-deleted
+		false, // internal
+		false, // no-wait
+		nil,   // arguments
+	)
+}
+
+// DeclareQueue declares a queue
+// args carries optional x-arguments such as x-dead-letter-exchange; it may be nil.
+func (c *RabbitMQClient) DeclareQueue(name string, args map[string]interface{}) error {
+	_, err := c.channel.QueueDeclare(
+		name,
+		true,  // durable
+		false, // delete when unused
+		false, // exclusive
+		false, // no-wait
+		amqp091.Table(args),
+	)
+	return err
+}
+
+// BindQueue binds a queue to an exchange
+func (c *RabbitMQClient) BindQueue(queue, routingKey, exchange string) error {
+	return c.channel.QueueBind(
+		queue,
+		routingKey,
+		exchange,
+		false, // no-wait
+		nil,   // arguments
+	)
+}
+
+// SetQoS limits the number of unacknowledged deliveries sent to consumers on this channel
+func (c *RabbitMQClient) SetQoS(prefetchCount int) error {
+	return c.channel.Qos(
+		prefetchCount,
+		0,     // prefetch size
+		false, // global
+	)
+}
+
+// Consume starts consuming messages from a queue
+// When ctx is cancelled the broker consumer is cancelled, prefetched deliveries
+// that were not handed out are requeued, and the returned channel is closed.
+func (c *RabbitMQClient) Consume(ctx context.Context, queue, consumerTag string) (<-chan Message, error) {
+	msgs, err := c.channel.Consume(
+		queue,
+		consumerTag,
+		false, // auto-ack
+		false, // exclusive
+		false, // no-local
+		false, // no-wait
+		nil,   // arguments
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert to our Message type
+	messageChan := make(chan Message)
+	go func() {
+		defer close(messageChan)
+		for {
+			select {
+			case <-ctx.Done():
+				c.cancelConsumer(consumerTag, msgs)
+				return
+			case d, ok := <-msgs:
+				if !ok {
+					return
+				}
+				select {
+				case messageChan <- Message{
+					Body:          d.Body,
+					RoutingKey:    d.RoutingKey,
+					DeliveryCount: deliveryCount(d.Headers),
+					delivery:      d,
+				}:
+				case <-ctx.Done():
+					d.Nack(false, true)
+					c.cancelConsumer(consumerTag, msgs)
+					return
+				}
+			}
+		}
+	}()
+
+	return messageChan, nil
+}
+
+// cancelConsumer stops deliveries for a consumer and requeues any already prefetched
+func (c *RabbitMQClient) cancelConsumer(consumerTag string, msgs <-chan amqp091.Delivery) {
+	if err := c.channel.Cancel(consumerTag, false); err != nil {
+		return
+	}
+	// The delivery channel must be drained until the broker closes it
+	for d := range msgs {
+		d.Nack(false, true)
+	}
+}
+
+// deliveryCount reads the x-delivery-count header set by quorum queues on redelivery, or
+// RetryCountHeader set by Republish, whichever is higher
+func deliveryCount(headers amqp091.Table) int64 {
+	return max(headerInt(headers, "x-delivery-count"), headerInt(headers, RetryCountHeader))
+}
+
+// headerInt reads an integer header, returning 0 when it is missing
+func headerInt(headers amqp091.Table, name string) int64 {
+	switch v := headers[name].(type) {
+	case int64:
+		return v
+	case int32:
+		return int64(v)
+	case int:
+		return int64(v)
+	default:
+		return 0
+	}
+}
+
+// Republish publishes a copy of msg to the back of queue, with RetryCountHeader set to its
+// delivery count plus one, and then acknowledges msg. The copy is published through the
+// default exchange, so it only reaches queue. If the acknowledgment is lost the message is
+// delivered twice.
+func (c *RabbitMQClient) Republish(queue string, msg Message) error {
+	headers := amqp091.Table{}
+	for name, value := range msg.delivery.Headers {
+		headers[name] = value
+	}
+	headers[RetryCountHeader] = msg.DeliveryCount + 1
+
+	err := c.channel.Publish(
+		"",    // default exchange
+		queue, // routing key
+		false, // mandatory
+		false, // immediate
+		amqp091.Publishing{
+			Headers:      headers,
+			ContentType:  msg.delivery.ContentType,
+			DeliveryMode: msg.delivery.DeliveryMode,
+			Body:         msg.Body,
+		},
+	)
+	if err != nil {
+		return err
+	}
+	return msg.Ack(false)
+}
+
+// Publish publishes a message to an exchange
+func (c *RabbitMQClient) Publish(exchange, routingKey string, body []byte) error {
+	return c.channel.Publish(
+		exchange,
+		routingKey,
+		false, // mandatory
+		false, // immediate
+		amqp091.Publishing{
+			ContentType: "application/json",
+			Body:        body,
+		},
+	)
+}
+
+// Close closes the RabbitMQ connection
+func (c *RabbitMQClient) Close() error {
+	if c.channel != nil {
+		c.channel.Close()
+	}
+	if c.conn != nil {
+		return c.conn.Close()
+	}
+	return nil
+}
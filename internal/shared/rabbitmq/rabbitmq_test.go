@@ -0,0 +1,29 @@
+package rabbitmq
+
+import (
+	"testing"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+func TestDeliveryCount(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers amqp091.Table
+		want    int64
+	}{
+		{"first delivery", nil, 0},
+		{"quorum redelivery", amqp091.Table{"x-delivery-count": int64(2)}, 2},
+		{"republished", amqp091.Table{RetryCountHeader: int32(3)}, 3},
+		{"higher count wins", amqp091.Table{"x-delivery-count": int64(1), RetryCountHeader: int64(4)}, 4},
+		{"unexpected type", amqp091.Table{RetryCountHeader: "2"}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := deliveryCount(tt.headers); got != tt.want {
+				t.Errorf("deliveryCount() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
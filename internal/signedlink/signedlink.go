@@ -0,0 +1,84 @@
+// Package signedlink signs and verifies links that let a recipient act on one notification
+// without API credentials
+package signedlink
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignatureParam is the query parameter that carries a link's signature
+const SignatureParam = "signature"
+
+var (
+	// ErrInvalidSignature is returned when a link wasn't signed by this service for its purpose
+	ErrInvalidSignature = errors.New("invalid link signature")
+	// ErrLinkExpired is returned when a link is used after it expires
+	ErrLinkExpired = errors.New("link expired")
+)
+
+// Signer signs and verifies links to one kind of notification page. A link is only valid for
+// the tenant and notification it names, and only for the signer's purpose.
+type Signer struct {
+	secret  []byte
+	purpose string
+	path    string
+	ttl     time.Duration
+	baseURL string
+	now     func() time.Time
+}
+
+// NewSigner creates a signer for links under path, such as "/ack", whose signatures only verify
+// for purpose. Links expire after ttl and are relative paths unless baseURL, the service's
+// public address, is set.
+func NewSigner(secret, purpose, path string, ttl time.Duration, baseURL string) *Signer {
+	return &Signer{
+		secret:  []byte(secret),
+		purpose: purpose,
+		path:    path,
+		ttl:     ttl,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		now:     time.Now,
+	}
+}
+
+// Link returns a signed URL for the notification, and when it expires
+func (s *Signer) Link(tenantID, notificationID string) (string, time.Time) {
+	expires := s.now().Add(s.ttl).Truncate(time.Second)
+	query := url.Values{
+		"tenant_id":    {tenantID},
+		"expires":      {strconv.FormatInt(expires.Unix(), 10)},
+		SignatureParam: {s.sign(tenantID, notificationID, expires.Unix())},
+	}
+	return s.baseURL + s.path + "/" + url.PathEscape(notificationID) + "?" + query.Encode(), expires.UTC()
+}
+
+// Verify checks a link's signature and expiry, given as Unix seconds
+func (s *Signer) Verify(tenantID, notificationID string, expires int64, signature string) error {
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	want, _ := hex.DecodeString(s.sign(tenantID, notificationID, expires))
+	if !hmac.Equal(got, want) {
+		return ErrInvalidSignature
+	}
+	if s.now().Unix() > expires {
+		return ErrLinkExpired
+	}
+	return nil
+}
+
+// sign returns the hex HMAC-SHA256 of the link's purpose, tenant, notification and expiry.
+// The purpose keeps one kind of link from verifying as another signed with the same secret.
+func (s *Signer) sign(tenantID, notificationID string, expires int64) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(s.purpose + "\n" + tenantID + "\n" + notificationID + "\n" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,81 @@
+package signedlink
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSigner(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	signer := NewSigner("test-secret", "ack", "/ack", time.Hour, "https://notify.example.com/")
+	signer.now = func() time.Time { return now }
+
+	link, expiresAt := signer.Link("tenant-1", "65f0c0ffee0000000000abcd")
+	if !strings.HasPrefix(link, "https://notify.example.com/ack/65f0c0ffee0000000000abcd?") || !expiresAt.Equal(now.Add(time.Hour)) {
+		t.Fatalf("Link() = %s, expires %v", link, expiresAt)
+	}
+	parsed, err := url.Parse(link)
+	if err != nil {
+		t.Fatalf("Link() is not a URL: %v", err)
+	}
+	query := parsed.Query()
+	expires, _ := strconv.ParseInt(query.Get("expires"), 10, 64)
+	signature := query.Get(SignatureParam)
+	if query.Get("tenant_id") != "tenant-1" {
+		t.Errorf("Link() tenant_id = %q, want tenant-1", query.Get("tenant_id"))
+	}
+
+	if err := signer.Verify("tenant-1", "65f0c0ffee0000000000abcd", expires, signature); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		tenantID     string
+		notification string
+		expires      int64
+		signature    string
+		want         error
+	}{
+		{"other tenant", "tenant-2", "65f0c0ffee0000000000abcd", expires, signature, ErrInvalidSignature},
+		{"other notification", "tenant-1", "65f0c0ffee0000000000abce", expires, signature, ErrInvalidSignature},
+		{"extended expiry", "tenant-1", "65f0c0ffee0000000000abcd", expires + 3600, signature, ErrInvalidSignature},
+		{"malformed signature", "tenant-1", "65f0c0ffee0000000000abcd", expires, "not-hex", ErrInvalidSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := signer.Verify(tt.tenantID, tt.notification, tt.expires, tt.signature); !errors.Is(err, tt.want) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	now = now.Add(2 * time.Hour)
+	if err := signer.Verify("tenant-1", "65f0c0ffee0000000000abcd", expires, signature); !errors.Is(err, ErrLinkExpired) {
+		t.Errorf("Verify() after expiry error = %v, want %v", err, ErrLinkExpired)
+	}
+}
+
+func TestSignerPurpose(t *testing.T) {
+	ack := NewSigner("test-secret", "ack", "/ack", time.Hour, "")
+	view := NewSigner("test-secret", "view", "/view", time.Hour, "")
+
+	link, _ := view.Link("tenant-1", "65f0c0ffee0000000000abcd")
+	if !strings.HasPrefix(link, "/view/65f0c0ffee0000000000abcd?") {
+		t.Fatalf("Link() = %s, want a relative /view link", link)
+	}
+	parsed, _ := url.Parse(link)
+	expires, _ := strconv.ParseInt(parsed.Query().Get("expires"), 10, 64)
+	signature := parsed.Query().Get(SignatureParam)
+
+	if err := view.Verify("tenant-1", "65f0c0ffee0000000000abcd", expires, signature); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+	if err := ack.Verify("tenant-1", "65f0c0ffee0000000000abcd", expires, signature); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify() of a view link for ack error = %v, want %v", err, ErrInvalidSignature)
+	}
+}
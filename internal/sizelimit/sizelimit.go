@@ -0,0 +1,160 @@
+// Package sizelimit rejects notifications whose content is larger than its channel allows.
+// Oversized content is always rejected with a *TooLargeError, never truncated, so a
+// recipient never receives a cut-off email, SMS or webhook payload.
+package sizelimit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/sender"
+)
+
+// Default limits, used when a Limits field is not positive
+const (
+	DefaultEmailBodyBytes      = 10 * 1024 * 1024
+	DefaultSMSMessageLength    = 1600 // Characters; most providers reject longer messages
+	DefaultWebhookPayloadBytes = 1024 * 1024
+)
+
+// ErrTooLarge is returned when a notification's content exceeds its channel's limit
+var ErrTooLarge = errors.New("notification content too large")
+
+// Limits holds the largest content accepted per channel
+type Limits struct {
+	EmailBodyBytes      int // Body and AMP part, each, in bytes
+	SMSMessageLength    int // Message, in characters
+	WebhookPayloadBytes int // Payload encoded as JSON, in bytes
+}
+
+// withDefaults fills unset limits with their defaults
+func (l Limits) withDefaults() Limits {
+	if l.EmailBodyBytes <= 0 {
+		l.EmailBodyBytes = DefaultEmailBodyBytes
+	}
+	if l.SMSMessageLength <= 0 {
+		l.SMSMessageLength = DefaultSMSMessageLength
+	}
+	if l.WebhookPayloadBytes <= 0 {
+		l.WebhookPayloadBytes = DefaultWebhookPayloadBytes
+	}
+	return l
+}
+
+// TooLargeError describes content over its channel's limit
+type TooLargeError struct {
+	Channel domain.NotificationType
+	Field   string // Request field that is too large, e.g. body or payload
+	Size    int
+	Limit   int
+	Unit    string // bytes or characters
+}
+
+// Error implements the error interface
+func (e *TooLargeError) Error() string {
+	return fmt.Sprintf("%s: %s %s is %d %s, limit %d", ErrTooLarge, e.Channel, e.Field, e.Size, e.Unit, e.Limit)
+}
+
+// Unwrap allows errors.Is(err, ErrTooLarge)
+func (e *TooLargeError) Unwrap() error {
+	return ErrTooLarge
+}
+
+// AsTooLarge returns the *TooLargeError in err's chain, if any
+func AsTooLarge(err error) (*TooLargeError, bool) {
+	var tooLarge *TooLargeError
+	ok := errors.As(err, &tooLarge)
+	return tooLarge, ok
+}
+
+// Checker checks notification content against the configured limits
+type Checker struct {
+	limits Limits
+}
+
+// NewChecker creates a content size checker
+func NewChecker(limits Limits) *Checker {
+	return &Checker{limits: limits.withDefaults()}
+}
+
+// Limits returns the limits in effect, defaults included
+func (c *Checker) Limits() Limits {
+	return c.limits
+}
+
+// CheckEmail checks an email's body and AMP part. Bodies rendered from a template are
+// checked by the email service once rendered.
+func (c *Checker) CheckEmail(req *domain.SendEmailRequest) error {
+	if len(req.Body) > c.limits.EmailBodyBytes {
+		return &TooLargeError{Channel: domain.NotificationTypeEmail, Field: "body", Size: len(req.Body), Limit: c.limits.EmailBodyBytes, Unit: "bytes"}
+	}
+	if len(req.AMPHTML) > c.limits.EmailBodyBytes {
+		return &TooLargeError{Channel: domain.NotificationTypeEmail, Field: "amp_html", Size: len(req.AMPHTML), Limit: c.limits.EmailBodyBytes, Unit: "bytes"}
+	}
+	return nil
+}
+
+// CheckSMS checks an SMS message's length in characters
+func (c *Checker) CheckSMS(req *domain.SendSMSRequest) error {
+	if length := utf8.RuneCountInString(req.Message); length > c.limits.SMSMessageLength {
+		return &TooLargeError{Channel: domain.NotificationTypeSMS, Field: "message", Size: length, Limit: c.limits.SMSMessageLength, Unit: "characters"}
+	}
+	return nil
+}
+
+// CheckWebhook checks a webhook's payload, measured as JSON whatever its encoding
+func (c *Checker) CheckWebhook(req *domain.SendWebhookRequest) error {
+	if req.Payload == nil {
+		return nil
+	}
+	encoded, err := json.Marshal(req.Payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+	if len(encoded) > c.limits.WebhookPayloadBytes {
+		return &TooLargeError{Channel: domain.NotificationTypeWebhook, Field: "payload", Size: len(encoded), Limit: c.limits.WebhookPayloadBytes, Unit: "bytes"}
+	}
+	return nil
+}
+
+// LimitSender rejects oversized notifications before passing the rest to next
+type LimitSender struct {
+	checker *Checker
+	next    sender.Sender
+}
+
+// NewSender creates a sender that enforces content size limits at send time
+func NewSender(checker *Checker, next sender.Sender) *LimitSender {
+	return &LimitSender{
+		checker: checker,
+		next:    next,
+	}
+}
+
+// SendEmail checks the email's size and sends it
+func (s *LimitSender) SendEmail(ctx context.Context, req *domain.SendEmailRequest) error {
+	if err := s.checker.CheckEmail(req); err != nil {
+		return err
+	}
+	return s.next.SendEmail(ctx, req)
+}
+
+// SendSMS checks the SMS's length and sends it
+func (s *LimitSender) SendSMS(ctx context.Context, req *domain.SendSMSRequest) error {
+	if err := s.checker.CheckSMS(req); err != nil {
+		return err
+	}
+	return s.next.SendSMS(ctx, req)
+}
+
+// SendWebhook checks the webhook payload's size and sends it
+func (s *LimitSender) SendWebhook(ctx context.Context, req *domain.SendWebhookRequest) error {
+	if err := s.checker.CheckWebhook(req); err != nil {
+		return err
+	}
+	return s.next.SendWebhook(ctx, req)
+}
@@ -0,0 +1,72 @@
+package sizelimit
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+)
+
+// countingSender counts the sends that reach it
+type countingSender struct {
+	sends int
+}
+
+func (s *countingSender) SendEmail(ctx context.Context, req *domain.SendEmailRequest) error {
+	s.sends++
+	return nil
+}
+
+func (s *countingSender) SendSMS(ctx context.Context, req *domain.SendSMSRequest) error {
+	s.sends++
+	return nil
+}
+
+func (s *countingSender) SendWebhook(ctx context.Context, req *domain.SendWebhookRequest) error {
+	s.sends++
+	return nil
+}
+
+func TestLimitSender(t *testing.T) {
+	next := &countingSender{}
+	sender := NewSender(NewChecker(Limits{EmailBodyBytes: 10, SMSMessageLength: 5, WebhookPayloadBytes: 20}), next)
+	ctx := context.Background()
+
+	if err := sender.SendEmail(ctx, &domain.SendEmailRequest{Body: "0123456789"}); err != nil {
+		t.Errorf("SendEmail() at the limit error = %v", err)
+	}
+	err := sender.SendEmail(ctx, &domain.SendEmailRequest{Body: "0123456789!"})
+	tooLarge, ok := AsTooLarge(err)
+	if !ok || tooLarge.Field != "body" || tooLarge.Size != 11 || tooLarge.Limit != 10 {
+		t.Errorf("SendEmail() over the limit error = %v, want a body TooLargeError", err)
+	}
+
+	// SMS length counts characters, not bytes
+	if err := sender.SendSMS(ctx, &domain.SendSMSRequest{Message: "héllo"}); err != nil {
+		t.Errorf("SendSMS() at the limit error = %v", err)
+	}
+	if err := sender.SendSMS(ctx, &domain.SendSMSRequest{Message: "hello!"}); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("SendSMS() over the limit error = %v, want ErrTooLarge", err)
+	}
+
+	err = sender.SendWebhook(ctx, &domain.SendWebhookRequest{Payload: map[string]any{"data": strings.Repeat("x", 20)}})
+	if tooLarge, ok := AsTooLarge(err); !ok || tooLarge.Channel != domain.NotificationTypeWebhook {
+		t.Errorf("SendWebhook() over the limit error = %v, want a webhook TooLargeError", err)
+	}
+	if err := sender.SendWebhook(ctx, &domain.SendWebhookRequest{TemplateID: "t1"}); err != nil {
+		t.Errorf("SendWebhook() from a template error = %v", err)
+	}
+
+	if next.sends != 3 {
+		t.Errorf("sends passed on = %d, want 3", next.sends)
+	}
+}
+
+func TestDefaultLimits(t *testing.T) {
+	limits := NewChecker(Limits{}).Limits()
+	if limits.EmailBodyBytes != DefaultEmailBodyBytes || limits.SMSMessageLength != DefaultSMSMessageLength || limits.WebhookPayloadBytes != DefaultWebhookPayloadBytes {
+		t.Errorf("Limits() = %+v, want the defaults", limits)
+	}
+}
@@ -0,0 +1,107 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrUnknownProvider is returned when the configured SMS provider has not been registered
+var ErrUnknownProvider = errors.New("unknown sms provider")
+
+// defaultTimeout bounds a single provider API call when Config.Timeout is unset
+const defaultTimeout = 10 * time.Second
+
+// Sender interface for SMS provider transports
+// Send delivers message to the E.164 number to and returns the provider's message id.
+type Sender interface {
+	Send(ctx context.Context, to, message string) (providerID string, err error)
+}
+
+// Config holds the settings of every built-in provider; only the selected provider's are used
+type Config struct {
+	Provider string
+	Timeout  time.Duration
+	Twilio   TwilioConfig
+	SNS      SNSConfig
+}
+
+// Factory builds a Sender from config, sending provider API calls through client
+type Factory func(cfg Config, client *http.Client) (Sender, error)
+
+// ProviderError describes a send the provider's API rejected
+type ProviderError struct {
+	Provider   string
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+// Error implements the error interface
+func (e *ProviderError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("%s: send failed with status %d (%s): %s", e.Provider, e.StatusCode, e.Code, e.Message)
+	}
+	return fmt.Sprintf("%s: send failed with status %d: %s", e.Provider, e.StatusCode, e.Message)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{
+		"twilio": newTwilioSender,
+		"sns":    newSNSSender,
+	}
+)
+
+// Register makes a provider available under name, so it can be selected with SMS_PROVIDER
+// It panics if name is empty or already registered.
+func Register(name string, factory Factory) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if name == "" || factory == nil {
+		panic("sms: Register called with an empty name or nil factory")
+	}
+	if _, exists := registry[name]; exists {
+		panic(fmt.Sprintf("sms: provider %q already registered", name))
+	}
+	registry[name] = factory
+}
+
+// Providers returns the names of the registered providers in sorted order
+func Providers() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New creates the Sender for the provider selected by cfg.Provider
+func New(cfg Config) (Sender, error) {
+	name := strings.ToLower(strings.TrimSpace(cfg.Provider))
+	registryMu.RLock()
+	factory, ok := registry[name]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q (available: %s)", ErrUnknownProvider, cfg.Provider, strings.Join(Providers(), ", "))
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	sender, err := factory(cfg, &http.Client{Timeout: timeout})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return sender, nil
+}
@@ -0,0 +1,131 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeSender struct {
+	id string
+}
+
+func (s fakeSender) Send(ctx context.Context, to, message string) (string, error) {
+	return s.id, nil
+}
+
+func TestNewSelectsRegisteredProvider(t *testing.T) {
+	Register("fake-test", func(cfg Config, client *http.Client) (Sender, error) {
+		return fakeSender{id: "fake-1"}, nil
+	})
+
+	sender, err := New(Config{Provider: " Fake-Test "})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	id, err := sender.Send(context.Background(), "+15550100", "hello")
+	if err != nil || id != "fake-1" {
+		t.Errorf("Send() = %q, %v, want fake-1", id, err)
+	}
+
+	if _, err := New(Config{Provider: "pigeon"}); !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("New(pigeon) error = %v, want ErrUnknownProvider", err)
+	}
+	if _, err := New(Config{Provider: "twilio"}); err == nil {
+		t.Error("New(twilio) without credentials error = nil, want error")
+	}
+}
+
+func TestTwilioSender(t *testing.T) {
+	var gotPath, gotTo, gotFrom, gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		if user != "AC123" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		r.ParseForm()
+		gotPath, gotTo, gotFrom, gotBody = r.URL.Path, r.PostForm.Get("To"), r.PostForm.Get("From"), r.PostForm.Get("Body")
+		if gotTo == "+1invalid" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":21211,"message":"The 'To' number is not a valid phone number.","status":400}`))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"sid":"SM42","status":"queued"}`))
+	}))
+	defer server.Close()
+
+	sender, err := NewTwilioSender(TwilioConfig{AccountSID: "AC123", AuthToken: "secret", From: "+15550000"}, server.Client())
+	if err != nil {
+		t.Fatalf("NewTwilioSender() error = %v", err)
+	}
+	sender.baseURL = server.URL
+
+	id, err := sender.Send(context.Background(), "+15550100", "hello")
+	if err != nil || id != "SM42" {
+		t.Fatalf("Send() = %q, %v, want SM42", id, err)
+	}
+	if gotPath != "/Accounts/AC123/Messages.json" || gotTo != "+15550100" || gotFrom != "+15550000" || gotBody != "hello" {
+		t.Errorf("request = %s to=%s from=%s body=%s", gotPath, gotTo, gotFrom, gotBody)
+	}
+
+	_, err = sender.Send(context.Background(), "+1invalid", "hello")
+	var providerErr *ProviderError
+	if !errors.As(err, &providerErr) || providerErr.StatusCode != http.StatusBadRequest || providerErr.Code != "21211" {
+		t.Errorf("Send() error = %v, want twilio 400 (21211)", err)
+	}
+}
+
+func TestSNSSender(t *testing.T) {
+	var gotAuth, gotPhone, gotAction string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		r.ParseForm()
+		gotPhone, gotAction = r.PostForm.Get("PhoneNumber"), r.PostForm.Get("Action")
+		if gotPhone == "+1invalid" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`<ErrorResponse><Error><Type>Sender</Type><Code>InvalidParameter</Code><Message>Invalid parameter: PhoneNumber</Message></Error></ErrorResponse>`))
+			return
+		}
+		w.Write([]byte(`<PublishResponse><PublishResult><MessageId>msg-7</MessageId></PublishResult></PublishResponse>`))
+	}))
+	defer server.Close()
+
+	sender, err := NewSNSSender(SNSConfig{
+		TopicARN:        "arn:aws:sns:eu-west-1:123456789012:sms",
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "secret",
+	}, server.Client())
+	if err != nil {
+		t.Fatalf("NewSNSSender() error = %v", err)
+	}
+	sender.endpoint = server.URL + "/"
+	sender.now = func() time.Time { return time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC) }
+
+	id, err := sender.Send(context.Background(), "+15550100", "hello")
+	if err != nil || id != "msg-7" {
+		t.Fatalf("Send() = %q, %v, want msg-7", id, err)
+	}
+	if gotAction != "Publish" || gotPhone != "+15550100" {
+		t.Errorf("request action=%s phone=%s", gotAction, gotPhone)
+	}
+	wantPrefix := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20240301/eu-west-1/sns/aws4_request, SignedHeaders=content-type;host;x-amz-date, Signature="
+	if !strings.HasPrefix(gotAuth, wantPrefix) {
+		t.Errorf("Authorization = %q, want prefix %q", gotAuth, wantPrefix)
+	}
+
+	_, err = sender.Send(context.Background(), "+1invalid", "hello")
+	var providerErr *ProviderError
+	if !errors.As(err, &providerErr) || providerErr.Code != "InvalidParameter" {
+		t.Errorf("Send() error = %v, want sns InvalidParameter", err)
+	}
+
+	if _, err := NewSNSSender(SNSConfig{AccessKeyID: "a", SecretAccessKey: "b"}, nil); err == nil {
+		t.Error("NewSNSSender() without a region error = nil, want error")
+	}
+}
@@ -0,0 +1,194 @@
+package sms
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// snsAPIVersion is the SNS query API version used for Publish
+const snsAPIVersion = "2010-03-31"
+
+// SNSConfig holds AWS SNS settings
+// Credentials use the standard AWS environment variables.
+type SNSConfig struct {
+	Region          string
+	TopicARN        string // Only used for its region when Region is unset
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// SNSSender sends SMS directly to phone numbers through the SNS Publish API
+type SNSSender struct {
+	config   SNSConfig
+	endpoint string
+	client   *http.Client
+	now      func() time.Time
+}
+
+// NewSNSSender creates an SNS sender
+func NewSNSSender(config SNSConfig, client *http.Client) (*SNSSender, error) {
+	if config.Region == "" {
+		config.Region = arnRegion(config.TopicARN)
+	}
+	if config.Region == "" {
+		return nil, errors.New("sns requires AWS_REGION or a regional AWS_SNS_ARN")
+	}
+	if config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, errors.New("sns requires AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+	return &SNSSender{
+		config:   config,
+		endpoint: fmt.Sprintf("https://sns.%s.amazonaws.com/", config.Region),
+		client:   client,
+		now:      time.Now,
+	}, nil
+}
+
+func newSNSSender(cfg Config, client *http.Client) (Sender, error) {
+	return NewSNSSender(cfg.SNS, client)
+}
+
+// arnRegion returns the region field of an ARN such as arn:aws:sns:eu-west-1:123456789012:topic
+func arnRegion(arn string) string {
+	parts := strings.Split(arn, ":")
+	if len(parts) < 6 || parts[0] != "arn" {
+		return ""
+	}
+	return parts[3]
+}
+
+// Send publishes message to the phone number and returns the SNS message id
+func (s *SNSSender) Send(ctx context.Context, to, message string) (string, error) {
+	form := url.Values{}
+	form.Set("Action", "Publish")
+	form.Set("Version", snsAPIVersion)
+	form.Set("PhoneNumber", to)
+	form.Set("Message", message)
+	body := form.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, strings.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("sns: failed to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	s.sign(req, body)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("sns: request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return "", fmt.Errorf("sns: failed to read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var failure struct {
+			Error struct {
+				Code    string `xml:"Code"`
+				Message string `xml:"Message"`
+			} `xml:"Error"`
+		}
+		_ = xml.Unmarshal(data, &failure)
+		providerErr := &ProviderError{
+			Provider:   "sns",
+			StatusCode: resp.StatusCode,
+			Code:       failure.Error.Code,
+			Message:    failure.Error.Message,
+		}
+		if providerErr.Message == "" {
+			providerErr.Message = http.StatusText(resp.StatusCode)
+		}
+		return "", providerErr
+	}
+
+	var result struct {
+		MessageID string `xml:"PublishResult>MessageId"`
+	}
+	if err := xml.Unmarshal(data, &result); err != nil {
+		return "", fmt.Errorf("sns: failed to decode response: %w", err)
+	}
+	if result.MessageID == "" {
+		return "", errors.New("sns: response has no message id")
+	}
+	return result.MessageID, nil
+}
+
+// sign adds AWS Signature Version 4 headers to req
+func (s *SNSSender) sign(req *http.Request, body string) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if s.config.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.config.SessionToken)
+	}
+
+	signedHeaders := []string{"content-type", "host", "x-amz-date"}
+	if s.config.SessionToken != "" {
+		signedHeaders = append(signedHeaders, "x-amz-security-token")
+	}
+	var canonicalHeaders strings.Builder
+	for _, name := range signedHeaders {
+		value := req.Header.Get(name)
+		if name == "host" {
+			value = req.URL.Host
+		}
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		strings.Join(signedHeaders, ";"),
+		sha256Hex(body),
+	}, "\n")
+
+	scope := date + "/" + s.config.Region + "/sns/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex(canonicalRequest),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.config.SecretAccessKey), date)
+	key = hmacSHA256(key, s.config.Region)
+	key = hmacSHA256(key, "sns")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.config.AccessKeyID, scope, strings.Join(signedHeaders, ";"), signature))
+}
+
+func sha256Hex(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,106 @@
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// twilioBaseURL is the Twilio REST API root
+const twilioBaseURL = "https://api.twilio.com/2010-04-01"
+
+// maxResponseBytes bounds how much of a provider response is read
+const maxResponseBytes = 64 << 10
+
+// TwilioConfig holds Twilio credentials and the sending number
+type TwilioConfig struct {
+	AccountSID string
+	AuthToken  string
+	From       string // Sending number or messaging service SID (MG...)
+}
+
+// TwilioSender sends SMS through the Twilio Messages API
+type TwilioSender struct {
+	config  TwilioConfig
+	baseURL string
+	client  *http.Client
+}
+
+// NewTwilioSender creates a Twilio sender
+func NewTwilioSender(config TwilioConfig, client *http.Client) (*TwilioSender, error) {
+	if config.AccountSID == "" || config.AuthToken == "" || config.From == "" {
+		return nil, errors.New("twilio requires TWILIO_SID, TWILIO_TOKEN and TWILIO_FROM")
+	}
+	return &TwilioSender{
+		config:  config,
+		baseURL: twilioBaseURL,
+		client:  client,
+	}, nil
+}
+
+func newTwilioSender(cfg Config, client *http.Client) (Sender, error) {
+	return NewTwilioSender(cfg.Twilio, client)
+}
+
+// Send creates a Twilio message and returns its SID
+func (s *TwilioSender) Send(ctx context.Context, to, message string) (string, error) {
+	form := url.Values{}
+	form.Set("To", to)
+	form.Set("Body", message)
+	if strings.HasPrefix(s.config.From, "MG") {
+		form.Set("MessagingServiceSid", s.config.From)
+	} else {
+		form.Set("From", s.config.From)
+	}
+
+	endpoint := fmt.Sprintf("%s/Accounts/%s/Messages.json", s.baseURL, url.PathEscape(s.config.AccountSID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("twilio: failed to build request: %w", err)
+	}
+	req.SetBasicAuth(s.config.AccountSID, s.config.AuthToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("twilio: request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return "", fmt.Errorf("twilio: failed to read response: %w", err)
+	}
+
+	var result struct {
+		SID     string `json:"sid"`
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	decodeErr := json.Unmarshal(body, &result)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		providerErr := &ProviderError{Provider: "twilio", StatusCode: resp.StatusCode, Message: result.Message}
+		if result.Code != 0 {
+			providerErr.Code = strconv.Itoa(result.Code)
+		}
+		if providerErr.Message == "" {
+			providerErr.Message = http.StatusText(resp.StatusCode)
+		}
+		return "", providerErr
+	}
+	if decodeErr != nil {
+		return "", fmt.Errorf("twilio: failed to decode response: %w", decodeErr)
+	}
+	if result.SID == "" {
+		return "", errors.New("twilio: response has no message sid")
+	}
+	return result.SID, nil
+}
@@ -0,0 +1,32 @@
+package smtp
+
+import (
+	"regexp"
+	"strings"
+)
+
+// bodyCloseRegex matches an HTML document's closing body tag
+var bodyCloseRegex = regexp.MustCompile(`(?i)</body\s*>`)
+
+// AppendFooter adds footer markup to the end of an HTML body: right before the closing body
+// tag, or at the very end of a fragment without one. An empty footer leaves the body unchanged.
+func AppendFooter(htmlBody, footer string) string {
+	if footer == "" {
+		return htmlBody
+	}
+	locs := bodyCloseRegex.FindAllStringIndex(htmlBody, -1)
+	if len(locs) == 0 {
+		return htmlBody + footer
+	}
+	end := locs[len(locs)-1][0]
+	return htmlBody[:end] + footer + htmlBody[end:]
+}
+
+// AppendTextFooter adds footer to the end of a plain text body, after a blank line.
+// An empty footer or body leaves the body unchanged.
+func AppendTextFooter(text, footer string) string {
+	if footer == "" || text == "" {
+		return text
+	}
+	return strings.TrimRight(text, "\r\n") + "\n\n" + footer
+}
@@ -0,0 +1,47 @@
+package smtp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAppendFooter(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{name: "document", html: `<html><body><p>Hi</p></BODY ></html>`, want: `<html><body><p>Hi</p><p>Acme Inc.</p></BODY ></html>`},
+		{name: "fragment", html: `<p>Hi</p>`, want: `<p>Hi</p><p>Acme Inc.</p>`},
+		{name: "last closing tag", html: `<body><p>&lt;/body&gt;</p></body><!-- </body> -->`, want: `<body><p>&lt;/body&gt;</p></body><!-- <p>Acme Inc.</p></body> -->`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AppendFooter(tt.html, "<p>Acme Inc.</p>"); got != tt.want {
+				t.Errorf("AppendFooter() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if got := AppendFooter("<p>Hi</p>", ""); got != "<p>Hi</p>" {
+		t.Errorf("empty footer changed the body: %s", got)
+	}
+}
+
+func TestMessageFooter(t *testing.T) {
+	msg := &Message{
+		Text:       "Shipped\n",
+		HTML:       "<body><p>Shipped</p></body>",
+		FooterHTML: "<p>Acme Inc.</p>",
+		FooterText: "Acme Inc.",
+		ViewURL:    "https://notify.example.com/view/abc",
+	}
+
+	if got := msg.HTMLBody(); !strings.HasSuffix(got, "<p>Shipped</p><p>Acme Inc.</p></body>") {
+		t.Errorf("HTMLBody() = %s, want the footer before the closing body tag", got)
+	}
+	if got := msg.TextBody(); !strings.HasSuffix(got, "Shipped\n\nAcme Inc.") || !strings.HasPrefix(got, "View in browser: ") {
+		t.Errorf("TextBody() = %q, want the view link, the text, a blank line and the footer", got)
+	}
+}
@@ -0,0 +1,145 @@
+package smtp
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/textproto"
+	"sort"
+	"strings"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+)
+
+// Header names set by the service on every outgoing email
+const (
+	HeaderReplyTo     = "Reply-To"
+	HeaderEntityRefID = "X-Entity-Ref-ID" // Maps provider logs back to our notification ID
+	HeaderCategory    = "X-Category"      // Used by providers for per-category analytics
+)
+
+// Security limits for caller-supplied headers
+const (
+	maxCustomHeaders     = 20
+	maxHeaderNameLength  = 76
+	maxHeaderValueLength = 998 // RFC 5322 line length limit
+)
+
+// protectedHeaders are built by the service and cannot be overridden by callers
+var protectedHeaders = map[string]struct{}{
+	"From":                      {},
+	"To":                        {},
+	"Cc":                        {},
+	"Bcc":                       {},
+	"Subject":                   {},
+	"Date":                      {},
+	"Message-Id":                {},
+	"Mime-Version":              {},
+	"Content-Type":              {},
+	"Content-Transfer-Encoding": {},
+	"Return-Path":               {},
+	"Sender":                    {},
+	"Reply-To":                  {},
+	"X-Entity-Ref-Id":           {},
+	"X-Category":                {},
+}
+
+// Header represents a single email header
+type Header struct {
+	Name  string
+	Value string
+}
+
+// SanitizeHeaderValue removes CR, LF and other control characters from a header value
+func SanitizeHeaderValue(value string) string {
+	return strings.TrimSpace(strings.Map(func(r rune) rune {
+		if r == '\t' {
+			return ' '
+		}
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, value))
+}
+
+// validateHeaderName checks a header field name against RFC 5322 (printable ASCII, no colon)
+func validateHeaderName(name string) error {
+	if name == "" {
+		return errors.New("header name cannot be empty")
+	}
+	if len(name) > maxHeaderNameLength {
+		return fmt.Errorf("header name %q exceeds maximum length", name)
+	}
+	for _, r := range name {
+		if r < 33 || r > 126 || r == ':' {
+			return fmt.Errorf("header name %q contains invalid characters", name)
+		}
+	}
+	return nil
+}
+
+// ValidateHeaders validates caller-supplied headers, rejecting protected names and CR/LF injection
+func ValidateHeaders(headers map[string]string) error {
+	if len(headers) > maxCustomHeaders {
+		return fmt.Errorf("too many custom headers (max %d)", maxCustomHeaders)
+	}
+
+	for name, value := range headers {
+		if err := validateHeaderName(name); err != nil {
+			return err
+		}
+		if _, protected := protectedHeaders[textproto.CanonicalMIMEHeaderKey(name)]; protected {
+			return fmt.Errorf("header %q cannot be overridden", name)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("header %q contains line breaks", name)
+		}
+		if len(value) > maxHeaderValueLength {
+			return fmt.Errorf("header %q exceeds maximum length", name)
+		}
+	}
+
+	return nil
+}
+
+// BuildExtraHeaders returns the Reply-To, tracking, category and custom headers for an email.
+// Headers are validated and sanitized, and returned in a deterministic order.
+func BuildExtraHeaders(req *domain.SendEmailRequest, notificationID string) ([]Header, error) {
+	if err := ValidateHeaders(req.Headers); err != nil {
+		return nil, err
+	}
+
+	headers := make([]Header, 0, len(req.Headers)+3)
+
+	if req.ReplyTo != "" {
+		addr, err := mail.ParseAddress(SanitizeHeaderValue(req.ReplyTo))
+		if err != nil {
+			return nil, fmt.Errorf("invalid reply-to address: %w", err)
+		}
+		headers = append(headers, Header{Name: HeaderReplyTo, Value: addr.String()})
+	}
+
+	if notificationID != "" {
+		headers = append(headers, Header{Name: HeaderEntityRefID, Value: SanitizeHeaderValue(notificationID)})
+	}
+
+	if category := SanitizeHeaderValue(req.Category); category != "" {
+		headers = append(headers, Header{Name: HeaderCategory, Value: category})
+	}
+
+	names := make([]string, 0, len(req.Headers))
+	for name := range req.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		headers = append(headers, Header{
+			Name:  textproto.CanonicalMIMEHeaderKey(name),
+			Value: SanitizeHeaderValue(req.Headers[name]),
+		})
+	}
+
+	return headers, nil
+}
@@ -0,0 +1,103 @@
+package smtp
+
+import (
+	"testing"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+)
+
+func TestValidateHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		wantErr bool
+	}{
+		{
+			name:    "valid custom header",
+			headers: map[string]string{"X-Campaign": "spring-sale"},
+			wantErr: false,
+		},
+		{
+			name:    "override protected header",
+			headers: map[string]string{"subject": "Injected"},
+			wantErr: true,
+		},
+		{
+			name:    "override entity ref header",
+			headers: map[string]string{"x-entity-ref-id": "other"},
+			wantErr: true,
+		},
+		{
+			name:    "CRLF injection in value",
+			headers: map[string]string{"X-Campaign": "a\r\nBcc: victim@example.com"},
+			wantErr: true,
+		},
+		{
+			name:    "colon in name",
+			headers: map[string]string{"X-Bad:Name": "value"},
+			wantErr: true,
+		},
+		{
+			name:    "space in name",
+			headers: map[string]string{"X Bad": "value"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateHeaders(tt.headers)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateHeaders() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBuildExtraHeaders(t *testing.T) {
+	req := &domain.SendEmailRequest{
+		ReplyTo:  "support@example.com",
+		Category: "billing",
+		Headers: map[string]string{
+			"x-trace":    "abc\x00123",
+			"X-Campaign": "spring",
+		},
+	}
+
+	headers, err := BuildExtraHeaders(req, "65a1b2c3d4e5f6a7b8c9d0e1")
+	if err != nil {
+		t.Fatalf("BuildExtraHeaders() error = %v", err)
+	}
+
+	want := []Header{
+		{Name: HeaderReplyTo, Value: "<support@example.com>"},
+		{Name: HeaderEntityRefID, Value: "65a1b2c3d4e5f6a7b8c9d0e1"},
+		{Name: HeaderCategory, Value: "billing"},
+		{Name: "X-Campaign", Value: "spring"},
+		{Name: "X-Trace", Value: "abc123"},
+	}
+
+	if len(headers) != len(want) {
+		t.Fatalf("got %d headers, want %d: %v", len(headers), len(want), headers)
+	}
+	for i := range want {
+		if headers[i] != want[i] {
+			t.Errorf("header[%d] = %v, want %v", i, headers[i], want[i])
+		}
+	}
+}
+
+func TestBuildExtraHeaders_InvalidReplyTo(t *testing.T) {
+	req := &domain.SendEmailRequest{ReplyTo: "not an address"}
+
+	if _, err := BuildExtraHeaders(req, ""); err == nil {
+		t.Error("Expected error for invalid reply-to address")
+	}
+}
+
+func TestSanitizeHeaderValue(t *testing.T) {
+	got := SanitizeHeaderValue(" value\r\nBcc: x@example.com\t")
+	if got != "valueBcc: x@example.com" {
+		t.Errorf("SanitizeHeaderValue() = %q", got)
+	}
+}
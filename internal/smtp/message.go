@@ -0,0 +1,171 @@
+package smtp
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/mail"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+)
+
+// maxLineLength is the recommended header line length before folding (RFC 5322 section 2.1.1)
+const maxLineLength = 78
+
+// Message is an outgoing email. Bytes renders it as RFC 5322 with RFC 2047 encoded-words for
+// non-ASCII header text, so callers never assemble headers by hand.
+type Message struct {
+	From        mail.Address
+	To          []string
+	CC          []string // Bcc recipients are passed to the SMTP envelope only, never written
+	Subject     string
+	Date        time.Time
+	MessageID   string   // Without angle brackets; omitted when empty
+	Headers     []Header // Additional headers, e.g. from BuildExtraHeaders
+	Text        string
+	HTML        string
+	Preheader   string // Inbox preview text, added hidden at the top of the HTML part
+	ViewURL     string // "View in browser" link added to the top of HTML emails
+	FooterHTML  string // Tenant footer added to the end of the HTML part
+	FooterText  string // Tenant footer added to the end of the plain text part
+	AMP         string // text/x-amp-html alternative, only sent with HTML
+	Charset     string // Charset of the text and HTML parts, e.g. iso-8859-1; empty is UTF-8
+	Attachments []domain.Attachment
+}
+
+// Bytes renders the message headers and MIME body. Header values have CR, LF and control
+// characters removed, display names are quoted or encoded, and long headers are folded.
+func (m *Message) Bytes() ([]byte, error) {
+	from, err := formatAddress(m.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from address: %w", err)
+	}
+	to, err := formatAddressList(m.To)
+	if err != nil {
+		return nil, fmt.Errorf("invalid to address: %w", err)
+	}
+	cc, err := formatAddressList(m.CC)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cc address: %w", err)
+	}
+
+	bodyHeader, body, err := BuildMIMEBody(m.TextBody(), m.HTMLBody(), m.AMP, m.Charset, m.Attachments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build message body: %w", err)
+	}
+
+	var buf bytes.Buffer
+	date := m.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+	writeHeader(&buf, "Date", date.Format(time.RFC1123Z))
+	if id := SanitizeHeaderValue(m.MessageID); id != "" {
+		writeHeader(&buf, "Message-ID", "<"+strings.Trim(id, "<>")+">")
+	}
+	writeHeader(&buf, "From", from)
+	if to != "" {
+		writeHeader(&buf, "To", to)
+	}
+	if cc != "" {
+		writeHeader(&buf, "Cc", cc)
+	}
+	writeHeader(&buf, "Subject", EncodeHeaderText(m.Subject))
+	for _, header := range m.Headers {
+		if err := validateHeaderName(header.Name); err != nil {
+			return nil, err
+		}
+		writeHeader(&buf, textproto.CanonicalMIMEHeaderKey(header.Name), EncodeHeaderText(header.Value))
+	}
+	writeHeader(&buf, "MIME-Version", "1.0")
+
+	names := make([]string, 0, len(bodyHeader))
+	for name := range bodyHeader {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		writeHeader(&buf, name, bodyHeader.Get(name))
+	}
+
+	buf.WriteString("\r\n")
+	buf.Write(body)
+	return buf.Bytes(), nil
+}
+
+// HTMLBody returns the HTML part as sent, with the view link and preheader injected and the
+// footer appended. The preheader comes first, so inbox previews show it rather than the link.
+func (m *Message) HTMLBody() string {
+	if m.HTML == "" {
+		return ""
+	}
+	return InjectPreheader(InjectViewLink(AppendFooter(m.HTML, m.FooterHTML), m.ViewURL), m.Preheader)
+}
+
+// TextBody returns the plain text part as sent, ending with the footer. The text alternative
+// of an HTML email starts with the view link, for clients that can't render the HTML.
+func (m *Message) TextBody() string {
+	text := AppendTextFooter(m.Text, m.FooterText)
+	if m.ViewURL == "" || m.HTML == "" || text == "" {
+		return text
+	}
+	return "View in browser: " + m.ViewURL + "\n\n" + text
+}
+
+// EncodeHeaderText sanitizes unstructured header text and encodes it as RFC 2047 encoded-words
+// when it contains non-ASCII characters. Long text is split into several space-separated words
+// of at most 75 characters each, which writeHeader can fold between.
+func EncodeHeaderText(value string) string {
+	return mime.QEncoding.Encode("utf-8", SanitizeHeaderValue(value))
+}
+
+// formatAddress sanitizes an address and renders it with a quoted or encoded display name
+func formatAddress(address mail.Address) (string, error) {
+	address.Name = SanitizeHeaderValue(address.Name)
+	parsed, err := mail.ParseAddress(SanitizeHeaderValue(address.Address))
+	if err != nil {
+		return "", err
+	}
+	address.Address = parsed.Address
+	return address.String(), nil
+}
+
+// formatAddressList parses and formats a list of addresses as a comma-separated header value
+func formatAddressList(addresses []string) (string, error) {
+	formatted := make([]string, 0, len(addresses))
+	for _, raw := range addresses {
+		address, err := mail.ParseAddress(SanitizeHeaderValue(raw))
+		if err != nil {
+			return "", fmt.Errorf("%q: %w", raw, err)
+		}
+		value, err := formatAddress(*address)
+		if err != nil {
+			return "", err
+		}
+		formatted = append(formatted, value)
+	}
+	return strings.Join(formatted, ", "), nil
+}
+
+// writeHeader writes "Name: value", folding at spaces so lines stay within 78 characters
+// where possible. Unfolding (removing the CRLFs) restores the original value. The first word
+// may also be folded onto its own line, which keeps a 75-character encoded-word within limits.
+func writeHeader(buf *bytes.Buffer, name, value string) {
+	buf.WriteString(name)
+	buf.WriteString(":")
+	lineLength := len(name) + 1
+	for _, word := range strings.Split(value, " ") {
+		if word != "" && lineLength+1+len(word) > maxLineLength {
+			buf.WriteString("\r\n")
+			lineLength = 0
+		}
+		buf.WriteString(" ")
+		buf.WriteString(word)
+		lineLength += 1 + len(word)
+	}
+	buf.WriteString("\r\n")
+}
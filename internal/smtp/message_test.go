@@ -0,0 +1,161 @@
+package smtp
+
+import (
+	"bytes"
+	"mime"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+)
+
+// parseMessage renders msg and parses it back with net/mail
+func parseMessage(t *testing.T, msg *Message) (*mail.Message, []byte) {
+	t.Helper()
+	raw, err := msg.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes() error = %v", err)
+	}
+	parsed, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("ReadMessage() error = %v\n%s", err, raw)
+	}
+	return parsed, raw
+}
+
+func TestMessageUnicodeHeaders(t *testing.T) {
+	subject := "Xác nhận đơn hàng #1234 – cảm ơn bạn đã mua sắm tại cửa hàng của chúng tôi hôm nay"
+	parsed, raw := parseMessage(t, &Message{
+		From:    mail.Address{Name: "Cửa hàng Việt", Address: "shop@example.com"},
+		To:      []string{"khach@example.com"},
+		Subject: subject,
+		Text:    "Xin chào",
+	})
+
+	header := raw[:bytes.Index(raw, []byte("\r\n\r\n"))]
+	for _, line := range strings.Split(string(header), "\r\n") {
+		if len(line) > maxLineLength+2 {
+			t.Errorf("header line exceeds %d characters: %q", maxLineLength, line)
+		}
+		for _, r := range line {
+			if r >= 0x80 {
+				t.Fatalf("header line contains non-ASCII text: %q", line)
+			}
+		}
+	}
+
+	decoded, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || decoded != subject {
+		t.Errorf("decoded Subject = %q (%v), want %q", decoded, err, subject)
+	}
+	from, err := parsed.Header.AddressList("From")
+	if err != nil || len(from) != 1 || from[0].Name != "Cửa hàng Việt" {
+		t.Errorf("From = %v (%v), want Cửa hàng Việt", from, err)
+	}
+}
+
+func TestMessageDisplayNameQuoting(t *testing.T) {
+	name := `Acme, Inc. "Support" <team>`
+	parsed, _ := parseMessage(t, &Message{
+		From:    mail.Address{Name: name, Address: "support@acme.example"},
+		To:      []string{`"Doe, Jane" <jane@example.com>`, "bob@example.com"},
+		Subject: "Hello",
+		Text:    "Hi",
+	})
+
+	from, err := parsed.Header.AddressList("From")
+	if err != nil || len(from) != 1 || from[0].Name != name || from[0].Address != "support@acme.example" {
+		t.Errorf("From = %v (%v), want %q <support@acme.example>", from, err, name)
+	}
+	to, err := parsed.Header.AddressList("To")
+	if err != nil || len(to) != 2 || to[0].Name != "Doe, Jane" {
+		t.Errorf("To = %v (%v), want two recipients", to, err)
+	}
+}
+
+func TestMessageHeaderInjection(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  Message
+	}{
+		{
+			name: "subject",
+			msg:  Message{Subject: "Hello\r\nBcc: victim@example.com"},
+		},
+		{
+			name: "subject with bare LF",
+			msg:  Message{Subject: "Hello\nBcc: victim@example.com\n\nInjected body"},
+		},
+		{
+			name: "display name",
+			msg:  Message{From: mail.Address{Name: "Shop\r\nBcc: victim@example.com", Address: "shop@example.com"}},
+		},
+		{
+			name: "custom header value",
+			msg:  Message{Headers: []Header{{Name: "X-Campaign", Value: "spring\r\nBcc: victim@example.com"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := tt.msg
+			if msg.From.Address == "" {
+				msg.From = mail.Address{Address: "shop@example.com"}
+			}
+			msg.To = []string{"jane@example.com"}
+			msg.Text = "Body"
+
+			parsed, _ := parseMessage(t, &msg)
+			if bcc := parsed.Header.Get("Bcc"); bcc != "" {
+				t.Errorf("injected Bcc header = %q", bcc)
+			}
+			body := new(bytes.Buffer)
+			body.ReadFrom(parsed.Body)
+			if body.String() != "Body" {
+				t.Errorf("body = %q, want Body", body.String())
+			}
+		})
+	}
+}
+
+func TestMessageRejectsInvalidInput(t *testing.T) {
+	base := Message{From: mail.Address{Address: "shop@example.com"}, To: []string{"jane@example.com"}}
+
+	invalidTo := base
+	invalidTo.To = []string{"jane@example.com, eve@example.com"}
+	if _, err := invalidTo.Bytes(); err == nil {
+		t.Error("Expected error for an address list passed as a single recipient")
+	}
+
+	invalidHeader := base
+	invalidHeader.Headers = []Header{{Name: "X-Bad\r\nBcc", Value: "x"}}
+	if _, err := invalidHeader.Bytes(); err == nil {
+		t.Error("Expected error for an invalid header name")
+	}
+}
+
+func TestMessageOmitsBccAndSetsIDs(t *testing.T) {
+	date := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	parsed, _ := parseMessage(t, &Message{
+		From:      mail.Address{Address: "shop@example.com"},
+		To:        []string{"jane@example.com"},
+		CC:        []string{"bob@example.com"},
+		Subject:   "Hello",
+		Date:      date,
+		MessageID: "abc123@example.com",
+		HTML:      "<p>Hi</p>",
+	})
+
+	if got := parsed.Header.Get("Message-Id"); got != "<abc123@example.com>" {
+		t.Errorf("Message-ID = %q, want <abc123@example.com>", got)
+	}
+	if got, err := parsed.Header.Date(); err != nil || !got.Equal(date) {
+		t.Errorf("Date = %v (%v), want %v", got, err, date)
+	}
+	if got := parsed.Header.Get("Cc"); got != "<bob@example.com>" {
+		t.Errorf("Cc = %q, want <bob@example.com>", got)
+	}
+	if got := parsed.Header.Get("Content-Type"); got != "text/html; charset=utf-8" {
+		t.Errorf("Content-Type = %q, want text/html; charset=utf-8", got)
+	}
+}
@@ -0,0 +1,293 @@
+package smtp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"regexp"
+	"strings"
+
+	"github.com/vhvplatform/go-notification-service/internal/charset"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+)
+
+const (
+	// base64LineLength is the maximum encoded line length for base64 bodies (RFC 2045)
+	base64LineLength = 76
+
+	// MaxAMPSize is the largest AMP part accepted, matching Gmail's limit for AMP emails
+	MaxAMPSize = 200 * 1024
+)
+
+var (
+	// ErrInvalidInlineImage is returned when inline attachments and cid: references don't match
+	ErrInvalidInlineImage = errors.New("invalid inline image")
+
+	// ErrInvalidAMP is returned when an AMP part is too large, not an AMP document, or has no HTML fallback
+	ErrInvalidAMP = errors.New("invalid AMP part")
+)
+
+// ampDocument matches the <html ⚡4email> or <html amp4email> tag required of AMP emails
+var ampDocument = regexp.MustCompile(`(?i)<html[^>]*\s(⚡4email|amp4email)[\s=>]`)
+
+// cidReference matches cid: URLs in HTML attributes and CSS, e.g. src="cid:logo"
+var cidReference = regexp.MustCompile(`(?i)cid:([^"'\s>)]+)`)
+
+// ValidateInlineImages checks that inline attachments have unique Content-IDs, are only used
+// with HTML bodies, and that every cid: reference in the HTML matches an inline attachment.
+func ValidateInlineImages(html string, isHTML bool, attachments []domain.Attachment) error {
+	contentIDs := make(map[string]struct{})
+	for _, attachment := range attachments {
+		if !attachment.Inline {
+			continue
+		}
+		if !isHTML {
+			return fmt.Errorf("%w: inline attachment %q requires an HTML body", ErrInvalidInlineImage, attachment.Filename)
+		}
+
+		id := normalizeContentID(attachment.ContentID)
+		if id == "" {
+			return fmt.Errorf("%w: inline attachment %q has no content_id", ErrInvalidInlineImage, attachment.Filename)
+		}
+		if strings.ContainsAny(id, "<>\r\n \t") {
+			return fmt.Errorf("%w: content_id %q contains invalid characters", ErrInvalidInlineImage, attachment.ContentID)
+		}
+		if _, duplicate := contentIDs[id]; duplicate {
+			return fmt.Errorf("%w: duplicate content_id %q", ErrInvalidInlineImage, attachment.ContentID)
+		}
+		contentIDs[id] = struct{}{}
+	}
+
+	if !isHTML {
+		return nil
+	}
+	for _, match := range cidReference.FindAllStringSubmatch(html, -1) {
+		if _, ok := contentIDs[normalizeContentID(match[1])]; !ok {
+			return fmt.Errorf("%w: cid:%s does not match any inline attachment", ErrInvalidInlineImage, match[1])
+		}
+	}
+	return nil
+}
+
+// ValidateAMP checks that an AMP part is an AMP email document within MaxAMPSize and is only
+// sent with an HTML body, which clients without AMP support display instead
+func ValidateAMP(amp string, isHTML bool) error {
+	if amp == "" {
+		return nil
+	}
+	if !isHTML {
+		return fmt.Errorf("%w: an HTML body is required as the fallback for the AMP part", ErrInvalidAMP)
+	}
+	if len(amp) > MaxAMPSize {
+		return fmt.Errorf("%w: %d bytes exceeds the %d byte limit", ErrInvalidAMP, len(amp), MaxAMPSize)
+	}
+	if !ampDocument.MatchString(amp) {
+		return fmt.Errorf("%w: the document must start with <html ⚡4email> or <html amp4email>", ErrInvalidAMP)
+	}
+	return nil
+}
+
+// BuildMIMEBody renders an email body and its attachments as MIME, returning the top-level
+// Content-Type and Content-Transfer-Encoding headers and the encoded body. Parts are nested as
+// multipart/mixed > multipart/related > multipart/alternative, omitting levels that aren't needed,
+// so HTML can reference inline images with cid: URLs and regular attachments appear as downloads.
+// An AMP part (text/x-amp-html) is only included alongside HTML. The text and HTML parts are
+// encoded in bodyCharset, UTF-8 when empty, and fail with charset.ErrUnrepresentable when they
+// contain characters it lacks; the AMP part is always UTF-8.
+func BuildMIMEBody(text, html, amp, bodyCharset string, attachments []domain.Attachment) (textproto.MIMEHeader, []byte, error) {
+	bodyCharset, err := charset.Canonical(bodyCharset)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var inline, regular []domain.Attachment
+	for _, attachment := range attachments {
+		if attachment.Inline {
+			inline = append(inline, attachment)
+		} else {
+			regular = append(regular, attachment)
+		}
+	}
+
+	root := alternativePart(text, html, amp, bodyCharset)
+	if len(inline) > 0 {
+		// RFC 2387 requires the type of the root part on multipart/related
+		rootType, _, _ := mime.ParseMediaType(root.contentType)
+		root = multipartPart("related", map[string]string{"type": rootType}, append([]mimePart{root}, attachmentParts(inline, "inline")...))
+	}
+	if len(regular) > 0 {
+		root = multipartPart("mixed", nil, append([]mimePart{root}, attachmentParts(regular, "attachment")...))
+	}
+
+	var buf bytes.Buffer
+	if err := root.write(&buf); err != nil {
+		return nil, nil, err
+	}
+	return root.mimeHeader(), buf.Bytes(), nil
+}
+
+// mimePart is a MIME entity: its Content-Type, any other headers and a function writing its body
+type mimePart struct {
+	contentType string
+	header      textproto.MIMEHeader
+	write       func(buf *bytes.Buffer) error
+}
+
+// mimeHeader returns the part's headers including Content-Type
+func (p mimePart) mimeHeader() textproto.MIMEHeader {
+	header := textproto.MIMEHeader{"Content-Type": {p.contentType}}
+	for name, values := range p.header {
+		header[name] = values
+	}
+	return header
+}
+
+// alternativePart returns the text, AMP and HTML bodies, as multipart/alternative when more
+// than one is set. AMP is ignored without HTML.
+func alternativePart(text, html, amp, bodyCharset string) mimePart {
+	textPart := textBodyPart("text/plain", text, bodyCharset)
+	if html == "" {
+		return textPart
+	}
+
+	var parts []mimePart
+	if text != "" {
+		parts = append(parts, textPart)
+	}
+	if amp != "" {
+		parts = append(parts, textBodyPart("text/x-amp-html", amp, charset.UTF8))
+	}
+	parts = append(parts, textBodyPart("text/html", html, bodyCharset))
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	// Some clients render only the last part, so HTML goes last with AMP before it (as Gmail recommends)
+	return multipartPart("alternative", nil, parts)
+}
+
+// textBodyPart returns a text part encoded in bodyCharset, a canonical charset name. Its
+// Content-Type names that charset, and its transfer encoding suits its bytes.
+func textBodyPart(mediaType, body, bodyCharset string) mimePart {
+	data, err := charset.Encode(body, bodyCharset)
+	transferEncoding := textTransferEncoding(data)
+	return mimePart{
+		contentType: mediaType + "; charset=" + bodyCharset,
+		header:      textproto.MIMEHeader{"Content-Transfer-Encoding": {transferEncoding}},
+		write: func(buf *bytes.Buffer) error {
+			if err != nil {
+				return err
+			}
+			if transferEncoding == "base64" {
+				writeBase64(buf, data)
+				return nil
+			}
+			w := quotedprintable.NewWriter(buf)
+			if _, err := w.Write(data); err != nil {
+				return err
+			}
+			return w.Close()
+		},
+	}
+}
+
+// textTransferEncoding returns quoted-printable for mostly ASCII text, which stays readable,
+// and base64 when more than a third of the bytes are 8-bit, where it is the shorter of the two
+func textTransferEncoding(data []byte) string {
+	eightBit := 0
+	for _, b := range data {
+		if b >= 0x80 {
+			eightBit++
+		}
+	}
+	if eightBit*3 > len(data) {
+		return "base64"
+	}
+	return "quoted-printable"
+}
+
+// attachmentParts returns base64-encoded parts for attachments with the given disposition
+func attachmentParts(attachments []domain.Attachment, disposition string) []mimePart {
+	parts := make([]mimePart, 0, len(attachments))
+	for _, attachment := range attachments {
+		content := attachment.Content
+		mimeType := attachment.MimeType
+		if mimeType == "" {
+			mimeType = "application/octet-stream"
+		}
+
+		header := textproto.MIMEHeader{
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType(disposition, map[string]string{"filename": SanitizeHeaderValue(attachment.Filename)})},
+		}
+		if id := normalizeContentID(attachment.ContentID); id != "" {
+			header.Set("Content-ID", "<"+id+">")
+		}
+
+		parts = append(parts, mimePart{
+			contentType: mimeType,
+			header:      header,
+			write: func(buf *bytes.Buffer) error {
+				writeBase64(buf, content)
+				return nil
+			},
+		})
+	}
+	return parts
+}
+
+// multipartPart returns a multipart/<subtype> part containing parts
+func multipartPart(subtype string, params map[string]string, parts []mimePart) mimePart {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	if params == nil {
+		params = make(map[string]string)
+	}
+	params["boundary"] = writer.Boundary()
+
+	return mimePart{
+		contentType: mime.FormatMediaType("multipart/"+subtype, params),
+		write: func(buf *bytes.Buffer) error {
+			for _, part := range parts {
+				w, err := writer.CreatePart(part.mimeHeader())
+				if err != nil {
+					return err
+				}
+
+				var content bytes.Buffer
+				if err := part.write(&content); err != nil {
+					return err
+				}
+				if _, err := w.Write(content.Bytes()); err != nil {
+					return err
+				}
+			}
+			if err := writer.Close(); err != nil {
+				return err
+			}
+			_, err := buf.Write(body.Bytes())
+			return err
+		},
+	}
+}
+
+// writeBase64 writes data as base64 wrapped at 76 characters per line
+func writeBase64(buf *bytes.Buffer, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > base64LineLength {
+		buf.WriteString(encoded[:base64LineLength])
+		buf.WriteString("\r\n")
+		encoded = encoded[base64LineLength:]
+	}
+	buf.WriteString(encoded)
+}
+
+// normalizeContentID strips surrounding whitespace and angle brackets from a Content-ID
+func normalizeContentID(id string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(id), "<"), ">")
+}
@@ -0,0 +1,267 @@
+package smtp
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"strings"
+	"testing"
+
+	"github.com/vhvplatform/go-notification-service/internal/charset"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+)
+
+var logo = domain.Attachment{Filename: "logo.png", MimeType: "image/png", Content: []byte("\x89PNG\r\n\x1a\n"), Inline: true, ContentID: "logo"}
+
+func TestValidateInlineImages(t *testing.T) {
+	tests := []struct {
+		name        string
+		html        string
+		isHTML      bool
+		attachments []domain.Attachment
+		wantErr     bool
+	}{
+		{
+			name:        "referenced inline image",
+			html:        `<img src="cid:logo">`,
+			isHTML:      true,
+			attachments: []domain.Attachment{logo},
+		},
+		{
+			name:        "content id in angle brackets",
+			html:        `<img src='CID:logo'>`,
+			isHTML:      true,
+			attachments: []domain.Attachment{{Filename: "logo.png", Inline: true, ContentID: "<logo>"}},
+		},
+		{
+			name:    "unknown cid reference",
+			html:    `<img src="cid:banner">`,
+			isHTML:  true,
+			wantErr: true,
+		},
+		{
+			name:        "cid referencing a regular attachment",
+			html:        `<div style="background: url(cid:logo)"></div>`,
+			isHTML:      true,
+			attachments: []domain.Attachment{{Filename: "logo.png", ContentID: "logo"}},
+			wantErr:     true,
+		},
+		{
+			name:        "inline without content id",
+			html:        `<p>Hi</p>`,
+			isHTML:      true,
+			attachments: []domain.Attachment{{Filename: "logo.png", Inline: true}},
+			wantErr:     true,
+		},
+		{
+			name:        "duplicate content id",
+			html:        `<img src="cid:logo">`,
+			isHTML:      true,
+			attachments: []domain.Attachment{logo, logo},
+			wantErr:     true,
+		},
+		{
+			name:        "inline image in plain text email",
+			html:        "Hi",
+			attachments: []domain.Attachment{logo},
+			wantErr:     true,
+		},
+		{
+			name:   "cid text in plain text email",
+			html:   "Use cid:anything",
+			isHTML: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateInlineImages(tt.html, tt.isHTML, tt.attachments)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateInlineImages() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidInlineImage) {
+				t.Errorf("ValidateInlineImages() error = %v, want ErrInvalidInlineImage", err)
+			}
+		})
+	}
+}
+
+// readParts parses a multipart body and returns its parts with their decoded content
+func readParts(t *testing.T, contentType string, body []byte) (string, []*multipart.Part, [][]byte) {
+	t.Helper()
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatalf("ParseMediaType(%q) error = %v", contentType, err)
+	}
+
+	var parts []*multipart.Part
+	var contents [][]byte
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	for {
+		part, err := reader.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("NextRawPart() error = %v", err)
+		}
+		content, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatalf("ReadAll() error = %v", err)
+		}
+		parts = append(parts, part)
+		contents = append(contents, content)
+	}
+	return mediaType, parts, contents
+}
+
+func TestBuildMIMEBodyInlineImages(t *testing.T) {
+	invoice := domain.Attachment{Filename: "invoice.pdf", MimeType: "application/pdf", Content: []byte("%PDF-1.7")}
+	header, body, err := BuildMIMEBody("Hello", `<p>Hello</p><img src="cid:logo">`, "", "", []domain.Attachment{logo, invoice})
+	if err != nil {
+		t.Fatalf("BuildMIMEBody() error = %v", err)
+	}
+
+	mediaType, mixed, contents := readParts(t, header.Get("Content-Type"), body)
+	if mediaType != "multipart/mixed" || len(mixed) != 2 {
+		t.Fatalf("top level = %s with %d parts, want multipart/mixed with 2", mediaType, len(mixed))
+	}
+	if disposition := mixed[1].Header.Get("Content-Disposition"); !strings.HasPrefix(disposition, "attachment") {
+		t.Errorf("regular attachment disposition = %q", disposition)
+	}
+
+	relatedType := mixed[0].Header.Get("Content-Type")
+	mediaType, related, relatedContents := readParts(t, relatedType, contents[0])
+	if mediaType != "multipart/related" || len(related) != 2 {
+		t.Fatalf("first part = %s with %d parts, want multipart/related with 2", mediaType, len(related))
+	}
+	if _, params, _ := mime.ParseMediaType(relatedType); params["type"] != "multipart/alternative" {
+		t.Errorf("multipart/related type = %q, want multipart/alternative", params["type"])
+	}
+
+	image := related[1]
+	if image.Header.Get("Content-ID") != "<logo>" {
+		t.Errorf("Content-ID = %q, want <logo>", image.Header.Get("Content-ID"))
+	}
+	if disposition := image.Header.Get("Content-Disposition"); !strings.HasPrefix(disposition, "inline") {
+		t.Errorf("inline image disposition = %q", disposition)
+	}
+
+	mediaType, alternative, _ := readParts(t, related[0].Header.Get("Content-Type"), relatedContents[0])
+	if mediaType != "multipart/alternative" || len(alternative) != 2 {
+		t.Fatalf("root part = %s with %d parts, want multipart/alternative with 2", mediaType, len(alternative))
+	}
+	if !strings.HasPrefix(alternative[1].Header.Get("Content-Type"), "text/html") {
+		t.Errorf("last alternative = %q, want text/html", alternative[1].Header.Get("Content-Type"))
+	}
+}
+
+func TestValidateAMP(t *testing.T) {
+	valid := `<!doctype html><html ⚡4email data-css-strict><head></head><body>Hi</body></html>`
+	tests := []struct {
+		name   string
+		amp    string
+		isHTML bool
+		valid  bool
+	}{
+		{name: "no AMP part", amp: "", isHTML: false, valid: true},
+		{name: "AMP with HTML", amp: valid, isHTML: true, valid: true},
+		{name: "amp4email attribute", amp: `<html amp4email><body>Hi</body></html>`, isHTML: true, valid: true},
+		{name: "AMP without HTML", amp: valid, isHTML: false, valid: false},
+		{name: "not an AMP document", amp: `<html><body>Hi</body></html>`, isHTML: true, valid: false},
+		{name: "too large", amp: valid + strings.Repeat(" ", MaxAMPSize), isHTML: true, valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAMP(tt.amp, tt.isHTML)
+			if tt.valid && err != nil {
+				t.Errorf("ValidateAMP() error = %v, want nil", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidAMP) {
+				t.Errorf("ValidateAMP() error = %v, want ErrInvalidAMP", err)
+			}
+		})
+	}
+}
+
+func TestBuildMIMEBodyAMP(t *testing.T) {
+	amp := `<html amp4email><body>Interactive</body></html>`
+	header, body, err := BuildMIMEBody("Hello", "<p>Hello</p>", amp, "", nil)
+	if err != nil {
+		t.Fatalf("BuildMIMEBody() error = %v", err)
+	}
+
+	mediaType, parts, contents := readParts(t, header.Get("Content-Type"), body)
+	if mediaType != "multipart/alternative" || len(parts) != 3 {
+		t.Fatalf("top level = %s with %d parts, want multipart/alternative with 3", mediaType, len(parts))
+	}
+	for i, want := range []string{"text/plain", "text/x-amp-html", "text/html"} {
+		if got := parts[i].Header.Get("Content-Type"); !strings.HasPrefix(got, want) {
+			t.Errorf("part %d Content-Type = %q, want %s", i, got, want)
+		}
+	}
+	if !bytes.Contains(contents[1], []byte("Interactive")) {
+		t.Errorf("AMP part = %q", contents[1])
+	}
+
+	// Without HTML there is nothing for non-AMP clients to fall back to, so AMP is dropped
+	header, _, err = BuildMIMEBody("Hello", "", amp, "", nil)
+	if err != nil {
+		t.Fatalf("BuildMIMEBody() error = %v", err)
+	}
+	if contentType := header.Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain") {
+		t.Errorf("Content-Type without HTML = %q, want text/plain", contentType)
+	}
+}
+
+func TestBuildMIMEBodySinglePart(t *testing.T) {
+	header, body, err := BuildMIMEBody("Héllo", "", "", "", nil)
+	if err != nil {
+		t.Fatalf("BuildMIMEBody() error = %v", err)
+	}
+	if contentType := header.Get("Content-Type"); contentType != "text/plain; charset=utf-8" {
+		t.Errorf("Content-Type = %q, want text/plain; charset=utf-8", contentType)
+	}
+	if encoding := header.Get("Content-Transfer-Encoding"); encoding != "quoted-printable" {
+		t.Errorf("Content-Transfer-Encoding = %q, want quoted-printable", encoding)
+	}
+	if string(body) != "H=C3=A9llo" {
+		t.Errorf("body = %q, want quoted-printable", body)
+	}
+}
+
+func TestBuildMIMEBodyCharset(t *testing.T) {
+	header, body, err := BuildMIMEBody("Grüße aus Köln", "", "", "latin1", nil)
+	if err != nil {
+		t.Fatalf("BuildMIMEBody() error = %v", err)
+	}
+	if contentType := header.Get("Content-Type"); contentType != "text/plain; charset=iso-8859-1" {
+		t.Errorf("Content-Type = %q, want text/plain; charset=iso-8859-1", contentType)
+	}
+	if string(body) != "Gr=FC=DFe aus K=F6ln" {
+		t.Errorf("body = %q, want Latin-1 quoted-printable", body)
+	}
+
+	if _, _, err := BuildMIMEBody("日本語", "", "", "iso-8859-1", nil); !errors.Is(err, charset.ErrUnrepresentable) {
+		t.Errorf("BuildMIMEBody() error = %v, want ErrUnrepresentable", err)
+	}
+	if _, _, err := BuildMIMEBody("Hello", "", "", "klingon", nil); !errors.Is(err, charset.ErrUnsupported) {
+		t.Errorf("BuildMIMEBody() error = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestBuildMIMEBodyBase64(t *testing.T) {
+	header, body, err := BuildMIMEBody("こんにちは、世界", "", "", "", nil)
+	if err != nil {
+		t.Fatalf("BuildMIMEBody() error = %v", err)
+	}
+	if encoding := header.Get("Content-Transfer-Encoding"); encoding != "base64" {
+		t.Errorf("Content-Transfer-Encoding = %q, want base64 for mostly non-ASCII text", encoding)
+	}
+	if string(body) != "44GT44KT44Gr44Gh44Gv44CB5LiW55WM" {
+		t.Errorf("body = %q, want base64", body)
+	}
+}
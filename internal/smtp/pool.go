@@ -1,164 +1,348 @@
-package smtp
-
-import (
-	"crypto/tls"
-	"fmt"
-	"net/smtp"
-	"sync"
-)
-
-// SMTPConfig holds SMTP configuration
-type SMTPConfig struct {
-	Host     string
-	Port     int
-	Username string
-	Password string
-	UseTLS   bool
-}
-
-// SMTPPool manages a pool of SMTP connections
-type SMTPPool struct {
-	connections chan *smtp.Client
-	config      SMTPConfig
-	size        int
-	mu          sync.Mutex
-	closed      bool
-}
-
-// NewSMTPPool creates a new SMTP connection pool
-func NewSMTPPool(config SMTPConfig, size int) (*SMTPPool, error) {
-	pool := &SMTPPool{
-		connections: make(chan *smtp.Client, size),
-		config:      config,
-		size:        size,
-		closed:      false,
-	}
-
-	// Initialize pool with connections
-	for i := 0; i < size; i++ {
-		client, err := pool.createConnection()
-		if err != nil {
-			// Close any already created connections
-			pool.Close()
-			return nil, fmt.Errorf("failed to initialize connection pool: %w", err)
-		}
-		pool.connections <- client
-	}
-
-	return pool, nil
-}
-
-// createConnection creates a new SMTP connection
-func (p *SMTPPool) createConnection() (*smtp.Client, error) {
-	addr := fmt.Sprintf("%s:%d", p.config.Host, p.config.Port)
-
-	var client *smtp.Client
-	var err error
-
-	if p.config.UseTLS {
-		tlsConfig := &tls.Config{
-			ServerName:         p.config.Host,
-			InsecureSkipVerify: false, // Always verify certificates in production
-			MinVersion:         tls.VersionTLS12,
-		}
-		conn, err := tls.Dial("tcp", addr, tlsConfig)
-		if err != nil {
-			return nil, fmt.Errorf("failed to dial TLS: %w", err)
-		}
-		client, err = smtp.NewClient(conn, p.config.Host)
-		if err != nil {
-			conn.Close()
-			return nil, fmt.Errorf("failed to create SMTP client: %w", err)
-		}
-	} else {
-		client, err = smtp.Dial(addr)
-		if err != nil {
-			return nil, fmt.Errorf("failed to dial SMTP: %w", err)
-		}
-	}
-
-	// Authenticate if credentials are provided
-	if p.config.Username != "" && p.config.Password != "" {
-		auth := smtp.PlainAuth("", p.config.Username, p.config.Password, p.config.Host)
-		if err := client.Auth(auth); err != nil {
-			client.Quit()
-			return nil, fmt.Errorf("SMTP authentication failed: %w", err)
-		}
-	}
-
-	return client, nil
-}
-
-// Get retrieves a connection from the pool
-func (p *SMTPPool) Get() (*smtp.Client, error) {
-	p.mu.Lock()
-	if p.closed {
-		p.mu.Unlock()
-		return nil, fmt.Errorf("connection pool is closed")
-	}
-	p.mu.Unlock()
-
-	select {
-	case client := <-p.connections:
-		// Test connection with NOOP
-		if err := client.Noop(); err != nil {
-			// Connection dead, close it and create new one
-			client.Quit()
-			newClient, err := p.createConnection()
-			if err != nil {
-				return nil, fmt.Errorf("failed to create new connection: %w", err)
-			}
-			return newClient, nil
-		}
-		return client, nil
-	default:
-		// Pool empty, create new connection temporarily
-		return p.createConnection()
-	}
-}
-
-// Put returns a connection to the pool
-func (p *SMTPPool) Put(client *smtp.Client) {
-	if client == nil {
-		return
-	}
-
-	p.mu.Lock()
-	if p.closed {
-		p.mu.Unlock()
-		client.Quit()
-		return
-	}
-	p.mu.Unlock()
-
-	select {
-	case p.connections <- client:
-		// Successfully returned to pool
-	default:
-		// Pool full, close connection
-		client.Quit()
-	}
-}
-
-// Close closes all connections in the pool
-func (p *SMTPPool) Close() {
-	p.mu.Lock()
-	if p.closed {
-		p.mu.Unlock()
-		return
-	}
-	p.closed = true
-	p.mu.Unlock()
-
-	close(p.connections)
-	for client := range p.connections {
-		if client != nil {
-			client.Quit()
-		}
-	}
-}
-
-// Size returns the pool size
-func (p *SMTPPool) Size() int {
-	return p.size
-}
+package smtp
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/smtp"
+	"strconv"
+	"sync"
+
+	"github.com/vhvplatform/go-notification-service/internal/metrics"
+)
+
+// SMTPConfig holds SMTP configuration
+type SMTPConfig struct {
+	Host        string
+	Port        int
+	Username    string
+	Password    string
+	UseTLS      bool
+	MaxMessages int // Messages sent on a connection before it is replaced; 0 never replaces it
+}
+
+// Reasons a pooled connection is replaced
+const (
+	RecycleMaxMessages = "max_messages"
+	RecycleBroken      = "broken"
+	RecycleResized     = "resized"
+)
+
+// Conn is a pooled SMTP connection
+type Conn struct {
+	*smtp.Client
+	slot     int // Index of the connection's pool slot, or -1 for a temporary connection
+	messages int // Messages delivered since the connection was opened
+}
+
+// Delivered records a message delivered on the connection
+func (c *Conn) Delivered() {
+	c.messages++
+	if c.slot >= 0 {
+		label := strconv.Itoa(c.slot)
+		metrics.SMTPConnectionSends.WithLabelValues(label).Inc()
+		metrics.SMTPConnectionMessages.WithLabelValues(label).Set(float64(c.messages))
+	}
+}
+
+// Messages returns how many messages were delivered on the connection
+func (c *Conn) Messages() int {
+	return c.messages
+}
+
+// SMTPPool manages a set of SMTP connection slots. Sends are spread round-robin across
+// the slots so no one connection runs hot, and a connection is replaced once it has sent
+// MaxMessages messages, since many providers throttle or drop long-lived connections.
+// The number of slots can be changed at runtime with Resize.
+type SMTPPool struct {
+	config   SMTPConfig
+	resizeMu sync.Mutex // Serializes Resize calls
+
+	mu   sync.Mutex
+	size int // Slots Get hands out; idle and busy may be longer after a shrink
+	// Idle connection per slot; nil while the slot is in use or has no open connection.
+	// Slots at or past size are retired: their connections are closed when returned.
+	idle   []*Conn
+	busy   []bool
+	next   int // Slot the next Get tries first
+	closed bool
+}
+
+// NewSMTPPool creates a new SMTP connection pool
+func NewSMTPPool(config SMTPConfig, size int) (*SMTPPool, error) {
+	pool := &SMTPPool{
+		config: config,
+		size:   size,
+		idle:   make([]*Conn, size),
+		busy:   make([]bool, size),
+	}
+
+	// Initialize pool with connections
+	for i := 0; i < size; i++ {
+		client, err := pool.createConnection()
+		if err != nil {
+			// Close any already created connections
+			pool.Close()
+			return nil, fmt.Errorf("failed to initialize connection pool: %w", err)
+		}
+		pool.idle[i] = pool.opened(i, client)
+	}
+
+	return pool, nil
+}
+
+// createConnection creates a new SMTP connection
+func (p *SMTPPool) createConnection() (*smtp.Client, error) {
+	addr := fmt.Sprintf("%s:%d", p.config.Host, p.config.Port)
+
+	var client *smtp.Client
+	var err error
+
+	if p.config.UseTLS {
+		tlsConfig := &tls.Config{
+			ServerName:         p.config.Host,
+			InsecureSkipVerify: false, // Always verify certificates in production
+			MinVersion:         tls.VersionTLS12,
+		}
+		conn, err := tls.Dial("tcp", addr, tlsConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to dial TLS: %w", err)
+		}
+		client, err = smtp.NewClient(conn, p.config.Host)
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to create SMTP client: %w", err)
+		}
+	} else {
+		client, err = smtp.Dial(addr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to dial SMTP: %w", err)
+		}
+	}
+
+	// Authenticate if credentials are provided
+	if p.config.Username != "" && p.config.Password != "" {
+		auth := smtp.PlainAuth("", p.config.Username, p.config.Password, p.config.Host)
+		if err := client.Auth(auth); err != nil {
+			client.Quit()
+			return nil, fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+
+	return client, nil
+}
+
+// opened wraps a new connection for a slot and resets the slot's usage
+func (p *SMTPPool) opened(slot int, client *smtp.Client) *Conn {
+	metrics.SMTPConnectionPool.Inc()
+	metrics.SMTPConnectionMessages.WithLabelValues(strconv.Itoa(slot)).Set(0)
+	return &Conn{Client: client, slot: slot}
+}
+
+// Get takes the connection of the next free slot in round-robin order. A slot whose
+// connection was closed or doesn't answer a NOOP opens a new one. When every slot is in use
+// a temporary connection is opened, which Put closes.
+func (p *SMTPPool) Get() (*Conn, error) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil, fmt.Errorf("connection pool is closed")
+	}
+	slot := -1
+	for i := 0; i < p.size; i++ {
+		if s := (p.next + i) % p.size; !p.busy[s] {
+			slot = s
+			break
+		}
+	}
+	if slot < 0 {
+		p.mu.Unlock()
+		client, err := p.createConnection()
+		if err != nil {
+			return nil, err
+		}
+		return &Conn{Client: client, slot: -1}, nil
+	}
+	conn := p.idle[slot]
+	p.idle[slot] = nil
+	p.busy[slot] = true
+	p.next = (slot + 1) % p.size
+	p.mu.Unlock()
+
+	if conn != nil {
+		// Test connection with NOOP
+		if err := conn.Noop(); err == nil {
+			return conn, nil
+		}
+		// Connection dead, close it and create new one
+		p.recycle(conn, RecycleBroken)
+	}
+	client, err := p.createConnection()
+	if err != nil {
+		p.release(slot, nil)
+		return nil, fmt.Errorf("failed to create new connection: %w", err)
+	}
+	return p.opened(slot, client), nil
+}
+
+// Put returns a connection to its slot. Once the connection has sent MaxMessages messages it
+// is closed instead, and the slot opens a new one on its next Get.
+func (p *SMTPPool) Put(conn *Conn) {
+	if conn == nil {
+		return
+	}
+	if conn.slot < 0 {
+		conn.Quit()
+		return
+	}
+	if p.config.MaxMessages > 0 && conn.messages >= p.config.MaxMessages {
+		p.recycle(conn, RecycleMaxMessages)
+		p.release(conn.slot, nil)
+		return
+	}
+	p.release(conn.slot, conn)
+}
+
+// Discard closes a connection that is no longer usable, freeing its slot
+func (p *SMTPPool) Discard(conn *Conn) {
+	if conn == nil {
+		return
+	}
+	conn.Close()
+	if conn.slot < 0 {
+		return
+	}
+	metrics.SMTPConnectionPool.Dec()
+	metrics.SMTPConnectionsRecycled.WithLabelValues(RecycleBroken).Inc()
+	p.release(conn.slot, nil)
+}
+
+// recycle closes a pooled connection so its slot can open a new one
+func (p *SMTPPool) recycle(conn *Conn, reason string) {
+	conn.Quit()
+	metrics.SMTPConnectionPool.Dec()
+	metrics.SMTPConnectionsRecycled.WithLabelValues(reason).Inc()
+}
+
+// release frees a slot, keeping conn as its idle connection when it is not nil
+func (p *SMTPPool) release(slot int, conn *Conn) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		if conn != nil {
+			conn.Quit()
+			metrics.SMTPConnectionPool.Dec()
+		}
+		return
+	}
+	if slot >= p.size {
+		// The pool shrank while the connection was in use
+		p.busy[slot] = false
+		p.mu.Unlock()
+		if conn != nil {
+			p.recycle(conn, RecycleResized)
+		}
+		return
+	}
+	p.idle[slot] = conn
+	p.busy[slot] = false
+	p.mu.Unlock()
+}
+
+// Resize changes the number of slots to size. Growing opens a connection for each new slot;
+// a slot whose connection fails to open opens one on its first Get instead, and the first
+// failure is returned. Shrinking closes the idle connections of the removed slots at once,
+// and connections in use when they are returned, so in-flight sends are never interrupted.
+func (p *SMTPPool) Resize(size int) error {
+	if size < 1 {
+		return fmt.Errorf("pool size must be at least 1, got %d", size)
+	}
+	p.resizeMu.Lock()
+	defer p.resizeMu.Unlock()
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return fmt.Errorf("connection pool is closed")
+	}
+	old := p.size
+	if size == old {
+		p.mu.Unlock()
+		return nil
+	}
+	for len(p.idle) < size {
+		p.idle = append(p.idle, nil)
+		p.busy = append(p.busy, false)
+	}
+
+	if size < old {
+		var retired []*Conn
+		for slot := size; slot < old; slot++ {
+			if conn := p.idle[slot]; conn != nil {
+				retired = append(retired, conn)
+				p.idle[slot] = nil
+			}
+		}
+		p.size = size
+		p.next %= size
+		p.mu.Unlock()
+
+		for _, conn := range retired {
+			p.recycle(conn, RecycleResized)
+		}
+		return nil
+	}
+
+	// Reserve the new slots while their connections open, so Get doesn't race to open them too.
+	// A slot still busy with a connection from before an earlier shrink keeps that connection.
+	var opening []int
+	for slot := old; slot < size; slot++ {
+		if !p.busy[slot] && p.idle[slot] == nil {
+			p.busy[slot] = true
+			opening = append(opening, slot)
+		}
+	}
+	p.size = size
+	p.mu.Unlock()
+
+	var firstErr error
+	for _, slot := range opening {
+		client, err := p.createConnection()
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("pool resized to %d, but failed to open a connection: %w", size, err)
+			}
+			p.release(slot, nil)
+			continue
+		}
+		p.release(slot, p.opened(slot, client))
+	}
+	return firstErr
+}
+
+// Close closes all connections in the pool
+// Connections in use are closed when they are returned.
+func (p *SMTPPool) Close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	idle := p.idle
+	p.idle = make([]*Conn, len(idle))
+	p.mu.Unlock()
+
+	for _, conn := range idle {
+		if conn != nil {
+			conn.Quit()
+			metrics.SMTPConnectionPool.Dec()
+		}
+	}
+}
+
+// Size returns the pool size
+func (p *SMTPPool) Size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.size
+}
@@ -0,0 +1,234 @@
+package smtp
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// fakeServer is a loopback SMTP server that accepts every command
+type fakeServer struct {
+	listener net.Listener
+	accepted atomic.Int32
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	s := &fakeServer{listener: listener}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			s.accepted.Add(1)
+			go s.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { listener.Close() })
+	return s
+}
+
+func (s *fakeServer) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	conn.Write([]byte("220 fake ESMTP\r\n"))
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		verb, _, _ := strings.Cut(strings.TrimSpace(line), " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			conn.Write([]byte("250 fake\r\n"))
+		case "DATA":
+			conn.Write([]byte("354 go ahead\r\n"))
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+			}
+			conn.Write([]byte("250 queued\r\n"))
+		case "QUIT":
+			conn.Write([]byte("221 bye\r\n"))
+			return
+		default:
+			conn.Write([]byte("250 ok\r\n"))
+		}
+	}
+}
+
+func (s *fakeServer) config(maxMessages int) SMTPConfig {
+	host, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	p, _ := strconv.Atoi(port)
+	return SMTPConfig{Host: host, Port: p, MaxMessages: maxMessages}
+}
+
+// send delivers one message on a pooled connection
+func send(t *testing.T, pool *SMTPPool) int {
+	t.Helper()
+	conn, err := pool.Get()
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if err := conn.Mail("from@example.com"); err != nil {
+		t.Fatalf("Mail() error = %v", err)
+	}
+	if err := conn.Rcpt("to@example.com"); err != nil {
+		t.Fatalf("Rcpt() error = %v", err)
+	}
+	w, err := conn.Data()
+	if err != nil {
+		t.Fatalf("Data() error = %v", err)
+	}
+	w.Write([]byte("Subject: hi\r\n\r\nhello\r\n"))
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	conn.Delivered()
+	slot := conn.slot
+	pool.Put(conn)
+	return slot
+}
+
+func TestPoolRoundRobin(t *testing.T) {
+	server := newFakeServer(t)
+	pool, err := NewSMTPPool(server.config(0), 3)
+	if err != nil {
+		t.Fatalf("NewSMTPPool() error = %v", err)
+	}
+	defer pool.Close()
+
+	var slots []int
+	for i := 0; i < 6; i++ {
+		slots = append(slots, send(t, pool))
+	}
+	want := []int{0, 1, 2, 0, 1, 2}
+	for i := range want {
+		if slots[i] != want[i] {
+			t.Fatalf("slots = %v, want %v", slots, want)
+		}
+	}
+	if got := server.accepted.Load(); got != 3 {
+		t.Errorf("connections opened = %d, want 3", got)
+	}
+}
+
+func TestPoolRecyclesAfterMaxMessages(t *testing.T) {
+	server := newFakeServer(t)
+	pool, err := NewSMTPPool(server.config(2), 1)
+	if err != nil {
+		t.Fatalf("NewSMTPPool() error = %v", err)
+	}
+	defer pool.Close()
+
+	for i := 0; i < 5; i++ {
+		send(t, pool)
+	}
+	// Messages 1-2 and 3-4 each used a connection, and message 5 a third
+	if got := server.accepted.Load(); got != 3 {
+		t.Errorf("connections opened = %d, want 3", got)
+	}
+}
+
+func TestPoolExhaustedOpensTemporaryConnection(t *testing.T) {
+	server := newFakeServer(t)
+	pool, err := NewSMTPPool(server.config(0), 1)
+	if err != nil {
+		t.Fatalf("NewSMTPPool() error = %v", err)
+	}
+	defer pool.Close()
+
+	first, err := pool.Get()
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	second, err := pool.Get()
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if first.slot != 0 || second.slot != -1 {
+		t.Errorf("slots = %d, %d, want 0, -1", first.slot, second.slot)
+	}
+	pool.Put(second)
+	pool.Put(first)
+
+	if got := send(t, pool); got != 0 {
+		t.Errorf("slot after exhaustion = %d, want 0", got)
+	}
+}
+
+func TestPoolClosed(t *testing.T) {
+	server := newFakeServer(t)
+	pool, err := NewSMTPPool(server.config(0), 1)
+	if err != nil {
+		t.Fatalf("NewSMTPPool() error = %v", err)
+	}
+	pool.Close()
+
+	if _, err := pool.Get(); err == nil {
+		t.Error("Get() on a closed pool error = nil, want an error")
+	}
+}
+
+func TestPoolResize(t *testing.T) {
+	server := newFakeServer(t)
+	pool, err := NewSMTPPool(server.config(0), 2)
+	if err != nil {
+		t.Fatalf("NewSMTPPool() error = %v", err)
+	}
+	defer pool.Close()
+
+	if err := pool.Resize(4); err != nil {
+		t.Fatalf("Resize(4) error = %v", err)
+	}
+	if got := server.accepted.Load(); got != 4 {
+		t.Errorf("connections opened after growing = %d, want 4", got)
+	}
+	var slots []int
+	for i := 0; i < 4; i++ {
+		slots = append(slots, send(t, pool))
+	}
+	if slots[0] != 0 || slots[3] != 3 {
+		t.Errorf("slots after growing = %v, want 0 to 3", slots)
+	}
+
+	// Take slot 0 and slot 1 so a connection on a removed slot is in flight
+	first, _ := pool.Get()
+	inFlight, _ := pool.Get()
+	if err := pool.Resize(1); err != nil {
+		t.Fatalf("Resize(1) error = %v", err)
+	}
+	if pool.Size() != 1 {
+		t.Errorf("Size() = %d, want 1", pool.Size())
+	}
+	if pool.idle[2] != nil || pool.idle[3] != nil {
+		t.Error("idle connections of removed slots were not closed")
+	}
+	pool.Put(inFlight)
+	if pool.idle[1] != nil || pool.busy[1] {
+		t.Error("connection returned to a removed slot was kept")
+	}
+	pool.Put(first)
+	for i := 0; i < 3; i++ {
+		if got := send(t, pool); got != 0 {
+			t.Errorf("slot after shrinking = %d, want 0", got)
+		}
+	}
+
+	if err := pool.Resize(0); err == nil {
+		t.Error("Resize(0) error = nil, want an error")
+	}
+}
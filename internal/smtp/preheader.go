@@ -0,0 +1,49 @@
+package smtp
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// preheaderStyle hides the preheader in the rendered email while inbox previews still show it
+const preheaderStyle = "display:none !important;visibility:hidden;mso-hide:all;font-size:1px;line-height:1px;" +
+	"color:transparent;max-height:0;max-width:0;opacity:0;overflow:hidden;"
+
+// preheaderFiller follows the preheader so inbox previews don't continue into the body text
+var preheaderFiller = strings.Repeat("&#847;&zwnj;&nbsp;", 30)
+
+// bodyTagRegex matches an HTML document's opening body tag
+var bodyTagRegex = regexp.MustCompile(`(?i)<body(\s[^>]*)?>`)
+
+// viewLinkStyle keeps the view link small and out of the way above the email's content
+const viewLinkStyle = "margin:0;padding:8px 0;font-size:12px;line-height:16px;text-align:center;"
+
+// InjectViewLink adds a "View in browser" link to viewURL at the top of an HTML body, placed
+// as InjectPreheader places the preheader. An empty URL leaves the body unchanged.
+func InjectViewLink(htmlBody, viewURL string) string {
+	if viewURL == "" {
+		return htmlBody
+	}
+	return insertAtTop(htmlBody, `<p style="`+viewLinkStyle+`"><a href="`+html.EscapeString(viewURL)+`">View in browser</a></p>`)
+}
+
+// InjectPreheader adds preheader as hidden preview text at the top of an HTML body: right
+// after the opening body tag, or at the very start of a fragment without one. An empty
+// preheader leaves the body unchanged.
+func InjectPreheader(htmlBody, preheader string) string {
+	preheader = strings.Join(strings.Fields(preheader), " ")
+	if preheader == "" {
+		return htmlBody
+	}
+	return insertAtTop(htmlBody, `<span style="`+preheaderStyle+`">`+html.EscapeString(preheader)+preheaderFiller+`</span>`)
+}
+
+// insertAtTop inserts markup right after the opening body tag, or at the very start of a
+// fragment without one
+func insertAtTop(htmlBody, markup string) string {
+	if loc := bodyTagRegex.FindStringIndex(htmlBody); loc != nil {
+		return htmlBody[:loc[1]] + markup + htmlBody[loc[1]:]
+	}
+	return markup + htmlBody
+}
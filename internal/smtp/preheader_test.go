@@ -0,0 +1,85 @@
+package smtp
+
+import (
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+func TestInjectPreheader(t *testing.T) {
+	tests := []struct {
+		name   string
+		html   string
+		prefix string // Expected text before the preheader span
+	}{
+		{name: "document", html: `<html><BODY class="main"><p>Hi</p></BODY></html>`, prefix: `<html><BODY class="main">`},
+		{name: "fragment", html: `<p>Hi</p>`, prefix: ``},
+		{name: "tag starting with body", html: `<bodyguard><p>Hi</p>`, prefix: ``},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := InjectPreheader(tt.html, "Your order <shipped>\n today")
+			if !strings.HasPrefix(got, tt.prefix+`<span style="display:none`) {
+				t.Errorf("preheader not injected after %q: %s", tt.prefix, got)
+			}
+			if !strings.Contains(got, "Your order &lt;shipped&gt; today&#847;") {
+				t.Errorf("preheader text not escaped and collapsed: %s", got)
+			}
+			if !strings.HasSuffix(got, strings.TrimPrefix(tt.html, tt.prefix)) {
+				t.Errorf("body changed: %s", got)
+			}
+		})
+	}
+
+	if got := InjectPreheader("<p>Hi</p>", "  "); got != "<p>Hi</p>" {
+		t.Errorf("empty preheader changed the body: %s", got)
+	}
+}
+
+func TestMessagePreheader(t *testing.T) {
+	msg := &Message{
+		From:      mail.Address{Address: "noreply@example.com"},
+		To:        []string{"user@example.com"},
+		Subject:   "Order",
+		Text:      "Shipped",
+		HTML:      "<p>Shipped</p>",
+		Preheader: "Arrives Friday",
+	}
+	raw, err := msg.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes() error = %v", err)
+	}
+	if strings.Count(string(raw), "Arrives Friday") != 1 {
+		t.Errorf("preheader should appear once, in the HTML part:\n%s", raw)
+	}
+
+	msg.HTML = ""
+	if msg.HTMLBody() != "" {
+		t.Error("HTMLBody() of a text-only message should be empty")
+	}
+}
+
+func TestMessageViewLink(t *testing.T) {
+	msg := &Message{
+		Text:      "Shipped",
+		HTML:      "<body><p>Shipped</p></body>",
+		Preheader: "Arrives Friday",
+		ViewURL:   "https://notify.example.com/view/abc?expires=1&signature=ff",
+	}
+
+	got := msg.HTMLBody()
+	preheader := strings.Index(got, "Arrives Friday")
+	link := strings.Index(got, `href="https://notify.example.com/view/abc?expires=1&amp;signature=ff"`)
+	if preheader < 0 || link < 0 || preheader > link {
+		t.Errorf("HTMLBody() should have the preheader, then the escaped view link: %s", got)
+	}
+	if !strings.HasPrefix(msg.TextBody(), "View in browser: "+msg.ViewURL+"\n\n") {
+		t.Errorf("TextBody() = %q, want the view link first", msg.TextBody())
+	}
+
+	msg.HTML = ""
+	if msg.TextBody() != "Shipped" {
+		t.Errorf("TextBody() of a text-only message = %q, want the text unchanged", msg.TextBody())
+	}
+}
@@ -0,0 +1,63 @@
+package smtp
+
+import (
+	"net/mail"
+	"strings"
+)
+
+// NormalizeAddress trims an address and lowercases its domain. The local-part is left as is:
+// RFC 5321 allows mail servers to treat it case-sensitively, while domains never are.
+// Display names are kept; input that doesn't parse is returned trimmed for validation to reject.
+func NormalizeAddress(address string) string {
+	address = strings.TrimSpace(address)
+	parsed, err := mail.ParseAddress(address)
+	if err != nil {
+		return address
+	}
+
+	at := strings.LastIndex(parsed.Address, "@")
+	if at < 0 {
+		return address
+	}
+	parsed.Address = parsed.Address[:at] + "@" + strings.ToLower(parsed.Address[at+1:])
+
+	if parsed.Name == "" {
+		return parsed.Address
+	}
+	return parsed.String()
+}
+
+// DedupeRecipients normalizes the To, CC and BCC lists and removes duplicates across all three,
+// keeping each address at its first occurrence in To, then CC, then BCC order. Addresses are
+// compared by mailbox, so "Jane <jane@Example.com>" and "jane@example.com" are duplicates,
+// but "Jane@example.com" is not. Empty entries are dropped.
+func DedupeRecipients(to, cc, bcc []string) ([]string, []string, []string) {
+	seen := make(map[string]struct{}, len(to)+len(cc)+len(bcc))
+	dedupe := func(addresses []string) []string {
+		if addresses == nil {
+			return nil
+		}
+		unique := make([]string, 0, len(addresses))
+		for _, address := range addresses {
+			normalized := NormalizeAddress(address)
+			if normalized == "" {
+				continue
+			}
+			key := normalized
+			if parsed, err := mail.ParseAddress(normalized); err == nil {
+				key = parsed.Address
+			}
+			if _, duplicate := seen[key]; duplicate {
+				continue
+			}
+			seen[key] = struct{}{}
+			unique = append(unique, normalized)
+		}
+		return unique
+	}
+
+	to = dedupe(to)
+	cc = dedupe(cc)
+	bcc = dedupe(bcc)
+	return to, cc, bcc
+}
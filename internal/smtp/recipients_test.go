@@ -0,0 +1,66 @@
+package smtp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		want    string
+	}{
+		{address: "  jane@Example.COM ", want: "jane@example.com"},
+		{address: "Jane.Doe@example.com", want: "Jane.Doe@example.com"},
+		{address: "Jane <jane@EXAMPLE.com>", want: `"Jane" <jane@example.com>`},
+		{address: "not-an-address", want: "not-an-address"},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeAddress(tt.address); got != tt.want {
+			t.Errorf("NormalizeAddress(%q) = %q, want %q", tt.address, got, tt.want)
+		}
+	}
+}
+
+func TestDedupeRecipients(t *testing.T) {
+	tests := []struct {
+		name                    string
+		to, cc, bcc             []string
+		wantTo, wantCC, wantBCC []string
+	}{
+		{
+			name:   "mixed case domains",
+			to:     []string{"jane@example.com", "jane@EXAMPLE.com", " bob@Example.org"},
+			wantTo: []string{"jane@example.com", "bob@example.org"},
+		},
+		{
+			name:   "local-part case is significant",
+			to:     []string{"Jane@example.com", "jane@example.com"},
+			wantTo: []string{"Jane@example.com", "jane@example.com"},
+		},
+		{
+			name:    "duplicates across lists keep the first occurrence",
+			to:      []string{"jane@example.com"},
+			cc:      []string{"bob@example.com", "JANE@example.com", "jane@example.COM"},
+			bcc:     []string{"bob@example.com", "audit@example.com"},
+			wantTo:  []string{"jane@example.com"},
+			wantCC:  []string{"bob@example.com", "JANE@example.com"},
+			wantBCC: []string{"audit@example.com"},
+		},
+		{
+			name:   "display name and bare address",
+			to:     []string{"Jane <jane@example.com>", "jane@example.com", ""},
+			wantTo: []string{`"Jane" <jane@example.com>`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			to, cc, bcc := DedupeRecipients(tt.to, tt.cc, tt.bcc)
+			if !reflect.DeepEqual(to, tt.wantTo) || !reflect.DeepEqual(cc, tt.wantCC) || !reflect.DeepEqual(bcc, tt.wantBCC) {
+				t.Errorf("DedupeRecipients() = %v, %v, %v, want %v, %v, %v", to, cc, bcc, tt.wantTo, tt.wantCC, tt.wantBCC)
+			}
+		})
+	}
+}
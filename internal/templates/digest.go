@@ -0,0 +1,48 @@
+package templates
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+)
+
+// DefaultDigest is the template used for digests that don't name one
+var DefaultDigest = &domain.Template{
+	Name:    "default_digest",
+	Channel: domain.NotificationTypeEmail,
+	Subject: "You have {{.Count}} new notification{{if ne .Count 1}}s{{end}}",
+	Body: "{{range .Items}}{{.Subject}}\n" +
+		"{{.CreatedAt.Format \"2006-01-02 15:04 MST\"}}\n\n" +
+		"{{.Body}}\n\n" +
+		"{{end}}",
+}
+
+// RenderDigest renders an email template's subject and body with a digest's collected items
+// The template iterates the items with {{range .Items}}, and can use .Count, .Key and .Recipient.
+func RenderDigest(t *domain.Template, data *domain.DigestData) (subject, body string, err error) {
+	if ChannelOf(t) != domain.NotificationTypeEmail {
+		return "", "", fmt.Errorf("%w: %s template used for email digest", ErrChannelMismatch, ChannelOf(t))
+	}
+	if subject, err = renderData(t.Subject, data); err != nil {
+		return "", "", fmt.Errorf("subject: %w", err)
+	}
+	if body, err = renderData(t.Body, data); err != nil {
+		return "", "", fmt.Errorf("body: %w", err)
+	}
+	return subject, body, nil
+}
+
+// renderData executes a template string with arbitrary data
+func renderData(text string, data any) (string, error) {
+	tmpl, err := parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,36 @@
+package templates
+
+import (
+	"context"
+	"errors"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/mjml"
+)
+
+// CompileMJML compiles an email template's MJML source into its HTML body, replacing any
+// body it had. Template actions in the source pass through to the body. Templates without
+// MJML are left as they are; with a nil compiler, as when MJML is disabled, templates with
+// MJML are invalid.
+func CompileMJML(ctx context.Context, compiler mjml.Compiler, t *domain.Template) error {
+	if t.MJML == "" {
+		return nil
+	}
+	if compiler == nil {
+		return invalid("MJML templates are not enabled")
+	}
+	if ChannelOf(t) != domain.NotificationTypeEmail {
+		return invalid("only email templates can use MJML")
+	}
+
+	html, err := compiler.Compile(ctx, t.MJML)
+	if err != nil {
+		if errors.Is(err, mjml.ErrInvalidMJML) {
+			return invalid(err.Error())
+		}
+		return err
+	}
+	t.Body = html
+	t.IsHTML = true
+	return nil
+}
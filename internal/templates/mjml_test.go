@@ -0,0 +1,62 @@
+package templates
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/mjml"
+)
+
+// mjmlCompiler compiles MJML by wrapping it, failing when err is set
+type mjmlCompiler struct {
+	err error
+}
+
+func (c mjmlCompiler) Compile(ctx context.Context, source string) (string, error) {
+	if c.err != nil {
+		return "", c.err
+	}
+	return "<html>" + source + "</html>", nil
+}
+
+func TestCompileMJML(t *testing.T) {
+	ctx := context.Background()
+	tmpl := &domain.Template{Name: "welcome", Subject: "Hi", Body: "stale", MJML: "<mjml>{{.Name}}</mjml>"}
+	if err := CompileMJML(ctx, mjmlCompiler{}, tmpl); err != nil {
+		t.Fatalf("CompileMJML() error = %v", err)
+	}
+	if tmpl.Body != "<html><mjml>{{.Name}}</mjml></html>" || !tmpl.IsHTML {
+		t.Errorf("compiled template = %+v, want the HTML body", tmpl)
+	}
+	if err := Validate(tmpl); err != nil {
+		t.Errorf("Validate() error = %v for a compiled template", err)
+	}
+
+	plain := &domain.Template{Name: "plain", Subject: "Hi", Body: "Hello"}
+	if err := CompileMJML(ctx, nil, plain); err != nil || plain.Body != "Hello" {
+		t.Errorf("CompileMJML() changed a template without MJML: %+v, %v", plain, err)
+	}
+
+	tests := []struct {
+		name     string
+		compiler mjml.Compiler
+		template *domain.Template
+	}{
+		{"disabled", nil, &domain.Template{Name: "t", MJML: "<mjml/>"}},
+		{"sms template", mjmlCompiler{}, &domain.Template{Name: "t", Channel: domain.NotificationTypeSMS, MJML: "<mjml/>"}},
+		{"invalid source", mjmlCompiler{err: mjml.ErrInvalidMJML}, &domain.Template{Name: "t", MJML: "<mjml><mj-txt/></mjml>"}},
+	}
+	for _, tt := range tests {
+		if err := CompileMJML(ctx, tt.compiler, tt.template); !errors.Is(err, ErrInvalidTemplate) {
+			t.Errorf("%s: CompileMJML() error = %v, want ErrInvalidTemplate", tt.name, err)
+		}
+	}
+
+	// Failures to run the compiler aren't the template's fault
+	runErr := errors.New("mjml not found")
+	if err := CompileMJML(ctx, mjmlCompiler{err: runErr}, &domain.Template{Name: "t", MJML: "<mjml/>"}); !errors.Is(err, runErr) || errors.Is(err, ErrInvalidTemplate) {
+		t.Errorf("CompileMJML() error = %v, want the compiler's error", err)
+	}
+}
@@ -0,0 +1,235 @@
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"github.com/vhvplatform/go-notification-service/internal/charset"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidTemplate is returned when a template's fields don't suit its channel
+var ErrInvalidTemplate = errors.New("invalid template")
+
+// ErrChannelMismatch is returned when a template is rendered for a different channel
+var ErrChannelMismatch = errors.New("template channel mismatch")
+
+// localeRegex matches a BCP 47 style locale such as de, pt-BR or zh-Hant-TW
+var localeRegex = regexp.MustCompile(`^[A-Za-z]{2,8}(-[A-Za-z0-9]{1,8})*$`)
+
+// ChannelOf returns the template's channel. Templates stored before channels existed are email templates.
+func ChannelOf(t *domain.Template) domain.NotificationType {
+	if t.Channel == "" {
+		return domain.NotificationTypeEmail
+	}
+	return t.Channel
+}
+
+// Validate checks that a template sets the fields its channel uses, and only those,
+// and that every template string parses.
+func Validate(t *domain.Template) error {
+	if t.Name == "" {
+		return invalid("name is required")
+	}
+
+	switch ChannelOf(t) {
+	case domain.NotificationTypeEmail:
+		if t.Subject == "" || t.Body == "" {
+			return invalid("email templates require subject and body")
+		}
+		if len(t.Payload) > 0 {
+			return invalid("email templates cannot have a payload")
+		}
+		if err := validateEmailExtras(t); err != nil {
+			return err
+		}
+	case domain.NotificationTypeSMS:
+		if t.Body == "" {
+			return invalid("sms templates require a body")
+		}
+		if t.Subject != "" || t.IsHTML || len(t.Payload) > 0 || hasEmailExtras(t) {
+			return invalid("sms templates only support a plain text body")
+		}
+	case domain.NotificationTypeWebhook:
+		if len(t.Payload) == 0 {
+			return invalid("webhook templates require a payload")
+		}
+		if t.Subject != "" || t.Body != "" || t.IsHTML || hasEmailExtras(t) {
+			return invalid("webhook templates only support a payload")
+		}
+	default:
+		return invalid(fmt.Sprintf("unsupported channel %q (must be email, sms or webhook)", t.Channel))
+	}
+
+	for _, text := range []string{t.Subject, t.Preheader, t.Body} {
+		if _, err := parse(text); err != nil {
+			return invalid(err.Error())
+		}
+	}
+	for locale, subject := range t.LocalizedSubjects {
+		if _, err := parse(subject); err != nil {
+			return invalid(fmt.Sprintf("subject for %s: %v", locale, err))
+		}
+	}
+	if _, err := renderValue(t.Payload, nil, false); err != nil {
+		return invalid(err.Error())
+	}
+	return nil
+}
+
+// validateEmailExtras checks an email template's localized subjects, preheader and charset.
+// The body and preheader text must be representable in the charset; the subject is always
+// sent as UTF-8 encoded-words.
+func validateEmailExtras(t *domain.Template) error {
+	for locale, subject := range t.LocalizedSubjects {
+		if !localeRegex.MatchString(locale) {
+			return invalid(fmt.Sprintf("invalid subject locale %q (expected e.g. de or pt-BR)", locale))
+		}
+		if subject == "" {
+			return invalid(fmt.Sprintf("subject for %s is empty", locale))
+		}
+	}
+	if n := utf8.RuneCountInString(t.Preheader); n > domain.MaxPreheaderLength {
+		return invalid(fmt.Sprintf("preheader is %d characters, more than %d", n, domain.MaxPreheaderLength))
+	}
+	if t.Charset != "" {
+		for _, text := range []string{t.Body, t.Preheader} {
+			if _, err := charset.Encode(text, t.Charset); err != nil {
+				return invalid(err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+// hasEmailExtras reports whether a template sets fields only email templates use
+func hasEmailExtras(t *domain.Template) bool {
+	return len(t.LocalizedSubjects) > 0 || t.Preheader != "" || t.Charset != ""
+}
+
+// SubjectFor returns the template's subject text for locale: the subject for that exact
+// locale, then for its language (de for de-AT), then the default Subject. Locales are
+// compared case-insensitively.
+func SubjectFor(t *domain.Template, locale string) string {
+	if locale == "" || len(t.LocalizedSubjects) == 0 {
+		return t.Subject
+	}
+	for candidate := locale; candidate != ""; {
+		for key, subject := range t.LocalizedSubjects {
+			if strings.EqualFold(key, candidate) {
+				return subject
+			}
+		}
+		cut := strings.LastIndex(candidate, "-")
+		if cut < 0 {
+			break
+		}
+		candidate = candidate[:cut]
+	}
+	return t.Subject
+}
+
+// RenderEmail renders an email template's subject for locale (see SubjectFor), body and
+// preheader with the given variables. A rendered preheader longer than MaxPreheaderLength
+// is an error.
+func RenderEmail(t *domain.Template, variables map[string]string, locale string) (subject, body, preheader string, err error) {
+	if ChannelOf(t) != domain.NotificationTypeEmail {
+		return "", "", "", fmt.Errorf("%w: %s template used for email", ErrChannelMismatch, ChannelOf(t))
+	}
+	if subject, err = render(SubjectFor(t, locale), variables); err != nil {
+		return "", "", "", fmt.Errorf("subject: %w", err)
+	}
+	if body, err = render(t.Body, variables); err != nil {
+		return "", "", "", fmt.Errorf("body: %w", err)
+	}
+	if preheader, err = render(t.Preheader, variables); err != nil {
+		return "", "", "", fmt.Errorf("preheader: %w", err)
+	}
+	if n := utf8.RuneCountInString(preheader); n > domain.MaxPreheaderLength {
+		return "", "", "", fmt.Errorf("preheader: rendered to %d characters, more than %d", n, domain.MaxPreheaderLength)
+	}
+	return subject, body, preheader, nil
+}
+
+// RenderSMS renders an SMS template's body with the given variables
+func RenderSMS(t *domain.Template, variables map[string]string) (string, error) {
+	if ChannelOf(t) != domain.NotificationTypeSMS {
+		return "", fmt.Errorf("%w: %s template used for sms", ErrChannelMismatch, ChannelOf(t))
+	}
+	return render(t.Body, variables)
+}
+
+// RenderWebhookPayload renders every string value in a webhook template's payload with the
+// given variables. Nested maps and arrays are rendered recursively; other values are copied.
+func RenderWebhookPayload(t *domain.Template, variables map[string]string) (map[string]any, error) {
+	if ChannelOf(t) != domain.NotificationTypeWebhook {
+		return nil, fmt.Errorf("%w: %s template used for webhook", ErrChannelMismatch, ChannelOf(t))
+	}
+
+	rendered, err := renderValue(t.Payload, variables, true)
+	if err != nil {
+		return nil, err
+	}
+	return rendered.(map[string]any), nil
+}
+
+// renderValue walks a payload value, parsing each string and executing it when execute is set.
+// Documents decoded from MongoDB use the primitive map and array types, which are normalized here.
+func renderValue(value any, variables map[string]string, execute bool) (any, error) {
+	switch v := value.(type) {
+	case string:
+		if !execute {
+			_, err := parse(v)
+			return v, err
+		}
+		return render(v, variables)
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, nested := range v {
+			rendered, err := renderValue(nested, variables, execute)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			out[key] = rendered
+		}
+		return out, nil
+	case primitive.M:
+		return renderValue(map[string]any(v), variables, execute)
+	case primitive.D:
+		return renderValue(map[string]any(v.Map()), variables, execute)
+	case []any:
+		out := make([]any, len(v))
+		for i, nested := range v {
+			rendered, err := renderValue(nested, variables, execute)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+			out[i] = rendered
+		}
+		return out, nil
+	case primitive.A:
+		return renderValue([]any(v), variables, execute)
+	default:
+		return v, nil
+	}
+}
+
+// render executes a template string; referencing a variable that wasn't supplied is an error
+func render(text string, variables map[string]string) (string, error) {
+	return renderData(text, variables)
+}
+
+// parse parses a template string
+func parse(text string) (*template.Template, error) {
+	return template.New("").Option("missingkey=error").Parse(text)
+}
+
+// invalid wraps a validation message in ErrInvalidTemplate
+func invalid(message string) error {
+	return fmt.Errorf("%w: %s", ErrInvalidTemplate, message)
+}
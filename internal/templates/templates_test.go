@@ -0,0 +1,247 @@
+package templates
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template domain.Template
+		wantErr  bool
+	}{
+		{
+			name:     "legacy email template without channel",
+			template: domain.Template{Name: "welcome", Subject: "Hi {{.name}}", Body: "<p>Welcome</p>", IsHTML: true},
+		},
+		{
+			name:     "email missing subject",
+			template: domain.Template{Name: "welcome", Channel: domain.NotificationTypeEmail, Body: "Welcome"},
+			wantErr:  true,
+		},
+		{
+			name:     "sms",
+			template: domain.Template{Name: "otp", Channel: domain.NotificationTypeSMS, Body: "Your code is {{.code}}"},
+		},
+		{
+			name:     "sms with subject",
+			template: domain.Template{Name: "otp", Channel: domain.NotificationTypeSMS, Subject: "Code", Body: "{{.code}}"},
+			wantErr:  true,
+		},
+		{
+			name:     "webhook",
+			template: domain.Template{Name: "order", Channel: domain.NotificationTypeWebhook, Payload: map[string]any{"id": "{{.order_id}}"}},
+		},
+		{
+			name:     "webhook without payload",
+			template: domain.Template{Name: "order", Channel: domain.NotificationTypeWebhook, Body: "{{.order_id}}"},
+			wantErr:  true,
+		},
+		{
+			name:     "unparsable payload value",
+			template: domain.Template{Name: "order", Channel: domain.NotificationTypeWebhook, Payload: map[string]any{"id": "{{.order_id"}},
+			wantErr:  true,
+		},
+		{
+			name: "email with localized subjects and preheader",
+			template: domain.Template{Name: "welcome", Subject: "Welcome", Body: "<p>Hi</p>", IsHTML: true,
+				LocalizedSubjects: map[string]string{"de": "Willkommen", "pt-BR": "Bem-vindo {{.name}}"}, Preheader: "Your account is ready"},
+		},
+		{
+			name:     "preheader too long",
+			template: domain.Template{Name: "welcome", Subject: "Welcome", Body: "Hi", Preheader: strings.Repeat("é", domain.MaxPreheaderLength+1)},
+			wantErr:  true,
+		},
+		{
+			name:     "invalid subject locale",
+			template: domain.Template{Name: "welcome", Subject: "Welcome", Body: "Hi", LocalizedSubjects: map[string]string{"de_DE": "Willkommen"}},
+			wantErr:  true,
+		},
+		{
+			name:     "unparsable localized subject",
+			template: domain.Template{Name: "welcome", Subject: "Welcome", Body: "Hi", LocalizedSubjects: map[string]string{"de": "{{.name"}},
+			wantErr:  true,
+		},
+		{
+			name:     "email in a legacy charset",
+			template: domain.Template{Name: "welcome", Subject: "Welcome", Body: "Grüße {{.name}}", Charset: "iso-8859-1"},
+		},
+		{
+			name:     "body not representable in charset",
+			template: domain.Template{Name: "welcome", Subject: "Welcome", Body: "こんにちは", Charset: "iso-8859-1"},
+			wantErr:  true,
+		},
+		{
+			name:     "unsupported charset",
+			template: domain.Template{Name: "welcome", Subject: "Welcome", Body: "Hi", Charset: "klingon"},
+			wantErr:  true,
+		},
+		{
+			name:     "sms with preheader",
+			template: domain.Template{Name: "otp", Channel: domain.NotificationTypeSMS, Body: "{{.code}}", Preheader: "Code"},
+			wantErr:  true,
+		},
+		{
+			name:     "unknown channel",
+			template: domain.Template{Name: "push", Channel: "push", Body: "hello"},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(&tt.template)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidTemplate) {
+				t.Errorf("Validate() error = %v, want ErrInvalidTemplate", err)
+			}
+		})
+	}
+}
+
+func TestSubjectFor(t *testing.T) {
+	tmpl := &domain.Template{Subject: "Welcome", LocalizedSubjects: map[string]string{"de": "Willkommen", "pt-BR": "Bem-vindo"}}
+
+	tests := map[string]string{
+		"":      "Welcome",
+		"de":    "Willkommen",
+		"de-AT": "Willkommen",
+		"pt-br": "Bem-vindo",
+		"pt-PT": "Welcome",
+		"fr":    "Welcome",
+	}
+	for locale, want := range tests {
+		if got := SubjectFor(tmpl, locale); got != want {
+			t.Errorf("SubjectFor(%q) = %q, want %q", locale, got, want)
+		}
+	}
+}
+
+func TestRenderEmail(t *testing.T) {
+	tmpl := &domain.Template{
+		Name:              "welcome",
+		Subject:           "Welcome {{.name}}",
+		LocalizedSubjects: map[string]string{"de": "Willkommen {{.name}}"},
+		Preheader:         "Finish setting up, {{.name}}",
+		Body:              "<p>Hi {{.name}}</p>",
+		IsHTML:            true,
+	}
+
+	subject, body, preheader, err := RenderEmail(tmpl, map[string]string{"name": "Ana"}, "de-DE")
+	if err != nil {
+		t.Fatalf("RenderEmail() error = %v", err)
+	}
+	if subject != "Willkommen Ana" || body != "<p>Hi Ana</p>" || preheader != "Finish setting up, Ana" {
+		t.Errorf("RenderEmail() = %q, %q, %q", subject, body, preheader)
+	}
+
+	long := map[string]string{"name": strings.Repeat("a", domain.MaxPreheaderLength)}
+	if _, _, _, err := RenderEmail(tmpl, long, ""); err == nil {
+		t.Error("RenderEmail() with an overlong rendered preheader succeeded")
+	}
+
+	sms := &domain.Template{Name: "otp", Channel: domain.NotificationTypeSMS, Body: "{{.code}}"}
+	if _, _, _, err := RenderEmail(sms, nil, ""); !errors.Is(err, ErrChannelMismatch) {
+		t.Errorf("RenderEmail() with an sms template error = %v, want ErrChannelMismatch", err)
+	}
+}
+
+func TestRenderSMS(t *testing.T) {
+	tmpl := &domain.Template{Name: "otp", Channel: domain.NotificationTypeSMS, Body: "Your code is {{.code}}"}
+
+	got, err := RenderSMS(tmpl, map[string]string{"code": "123456"})
+	if err != nil {
+		t.Fatalf("RenderSMS() error = %v", err)
+	}
+	if got != "Your code is 123456" {
+		t.Errorf("RenderSMS() = %q", got)
+	}
+
+	if _, err := RenderSMS(tmpl, nil); err == nil {
+		t.Error("Expected error for missing variable")
+	}
+
+	email := &domain.Template{Name: "welcome", Subject: "Hi", Body: "Welcome"}
+	if _, err := RenderSMS(email, nil); !errors.Is(err, ErrChannelMismatch) {
+		t.Errorf("RenderSMS() with email template error = %v, want ErrChannelMismatch", err)
+	}
+}
+
+func TestRenderWebhookPayload(t *testing.T) {
+	// Payloads read back from MongoDB contain primitive maps and arrays
+	tmpl := &domain.Template{
+		Name:    "order",
+		Channel: domain.NotificationTypeWebhook,
+		Payload: map[string]any{
+			"event": "order.{{.status}}",
+			"order": primitive.M{"id": "{{.order_id}}", "total": 12.5},
+			"tags":  primitive.A{"{{.status}}", true},
+		},
+	}
+
+	got, err := RenderWebhookPayload(tmpl, map[string]string{"status": "paid", "order_id": "A1"})
+	if err != nil {
+		t.Fatalf("RenderWebhookPayload() error = %v", err)
+	}
+
+	want := map[string]any{
+		"event": "order.paid",
+		"order": map[string]any{"id": "A1", "total": 12.5},
+		"tags":  []any{"paid", true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RenderWebhookPayload() = %v, want %v", got, want)
+	}
+
+	// The stored template is not modified
+	if tmpl.Payload["event"] != "order.{{.status}}" {
+		t.Error("RenderWebhookPayload() modified the template")
+	}
+}
+
+func TestRenderDigest(t *testing.T) {
+	created := time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+	data := &domain.DigestData{
+		Key:   "comments",
+		Count: 2,
+		Items: []domain.DigestItem{
+			{Subject: "Ann commented", Body: "Looks good", Variables: map[string]string{"post": "Roadmap"}, CreatedAt: created},
+			{Subject: "Bob commented", Body: "+1", Variables: map[string]string{"post": "Roadmap"}, CreatedAt: created},
+		},
+	}
+	tmpl := &domain.Template{
+		Name:    "comments_digest",
+		Subject: "{{.Count}} new {{.Key}}",
+		Body:    "{{range $i, $item := .Items}}{{if $i}}, {{end}}{{$item.Subject}} on {{$item.Variables.post}}{{end}}",
+	}
+
+	subject, body, err := RenderDigest(tmpl, data)
+	if err != nil {
+		t.Fatalf("RenderDigest() error = %v", err)
+	}
+	if subject != "2 new comments" || body != "Ann commented on Roadmap, Bob commented on Roadmap" {
+		t.Errorf("RenderDigest() = %q, %q", subject, body)
+	}
+
+	subject, body, err = RenderDigest(DefaultDigest, data)
+	if err != nil {
+		t.Fatalf("RenderDigest(DefaultDigest) error = %v", err)
+	}
+	if subject != "You have 2 new notifications" || !strings.Contains(body, "Ann commented\n2024-05-01 09:30 UTC\n\nLooks good") {
+		t.Errorf("RenderDigest(DefaultDigest) = %q, %q", subject, body)
+	}
+
+	sms := &domain.Template{Name: "otp", Channel: domain.NotificationTypeSMS, Body: "{{.Count}}"}
+	if _, _, err := RenderDigest(sms, data); !errors.Is(err, ErrChannelMismatch) {
+		t.Errorf("RenderDigest(sms) error = %v, want ErrChannelMismatch", err)
+	}
+}
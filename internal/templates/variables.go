@@ -0,0 +1,103 @@
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrUnresolvedPlaceholders is returned in strict mode when a placeholder has no matching variable
+var ErrUnresolvedPlaceholders = errors.New("unresolved template placeholders")
+
+// placeholderPattern matches a {{name}} placeholder, allowing spaces inside the braces
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([^{}]*?)\s*\}\}`)
+
+// UnknownVariables selects how ApplyVariables handles placeholders without a matching variable
+type UnknownVariables string
+
+const (
+	// UnknownVariablesKeep leaves unknown placeholders in the output
+	UnknownVariablesKeep UnknownVariables = "keep"
+	// UnknownVariablesBlank replaces unknown placeholders with empty strings
+	UnknownVariablesBlank UnknownVariables = "blank"
+	// UnknownVariablesStrict fails rendering when any placeholder is unknown
+	UnknownVariablesStrict UnknownVariables = "strict"
+)
+
+// ParseUnknownVariables parses an unknown-variable mode; empty selects UnknownVariablesKeep
+func ParseUnknownVariables(s string) (UnknownVariables, error) {
+	switch mode := UnknownVariables(strings.ToLower(strings.TrimSpace(s))); mode {
+	case "":
+		return UnknownVariablesKeep, nil
+	case UnknownVariablesKeep, UnknownVariablesBlank, UnknownVariablesStrict:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid unknown variables mode: %s (must be keep, blank or strict)", s)
+	}
+}
+
+// UnresolvedError lists the placeholders left without a variable
+type UnresolvedError struct {
+	Placeholders []string
+}
+
+// Error implements the error interface
+func (e *UnresolvedError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrUnresolvedPlaceholders, strings.Join(e.Placeholders, ", "))
+}
+
+// Unwrap allows errors.Is(err, ErrUnresolvedPlaceholders)
+func (e *UnresolvedError) Unwrap() error {
+	return ErrUnresolvedPlaceholders
+}
+
+// ApplyVariables replaces each {{name}} placeholder in text with its variable.
+// Substitution is a single left-to-right pass, so the result does not depend on map
+// iteration order and variable values are never themselves expanded. Placeholders
+// without a variable are handled according to mode.
+func ApplyVariables(text string, variables map[string]string, mode UnknownVariables) (string, error) {
+	var unresolved []string
+	seen := make(map[string]bool)
+
+	var out strings.Builder
+	last := 0
+	for _, match := range placeholderPattern.FindAllStringSubmatchIndex(text, -1) {
+		out.WriteString(text[last:match[0]])
+		last = match[1]
+
+		name := text[match[2]:match[3]]
+		if value, ok := variables[name]; ok {
+			out.WriteString(value)
+			continue
+		}
+		if mode == UnknownVariablesBlank {
+			continue
+		}
+		out.WriteString(text[match[0]:match[1]])
+		if !seen[name] {
+			seen[name] = true
+			unresolved = append(unresolved, name)
+		}
+	}
+	out.WriteString(text[last:])
+
+	if mode == UnknownVariablesStrict && len(unresolved) > 0 {
+		return "", &UnresolvedError{Placeholders: unresolved}
+	}
+	return out.String(), nil
+}
+
+// FindPlaceholders returns the names of {{...}} placeholders remaining in rendered
+// output, in order of first appearance and without duplicates
+func FindPlaceholders(rendered string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, match := range placeholderPattern.FindAllStringSubmatch(rendered, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			names = append(names, match[1])
+		}
+	}
+	return names
+}
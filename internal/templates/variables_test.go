@@ -0,0 +1,70 @@
+package templates
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestApplyVariables(t *testing.T) {
+	variables := map[string]string{"name": "Ada", "code": "{{name}}"}
+	tests := []struct {
+		name    string
+		text    string
+		mode    UnknownVariables
+		want    string
+		wantErr []string
+	}{
+		{name: "substitutes", text: "Hi {{name}}, {{ name }}!", mode: UnknownVariablesKeep, want: "Hi Ada, Ada!"},
+		{name: "values are not expanded", text: "Code: {{code}}", mode: UnknownVariablesStrict, want: "Code: {{name}}"},
+		{name: "keep leaves unknowns", text: "Hi {{name}} {{surname}}", mode: UnknownVariablesKeep, want: "Hi Ada {{surname}}"},
+		{name: "blank removes unknowns", text: "Hi {{name}}{{ surname }}", mode: UnknownVariablesBlank, want: "Hi Ada"},
+		{name: "strict reports unknowns once", text: "{{a}} {{name}} {{b}} {{a}}", mode: UnknownVariablesStrict, wantErr: []string{"a", "b"}},
+		{name: "no placeholders", text: "plain text", mode: UnknownVariablesStrict, want: "plain text"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ApplyVariables(tt.text, variables, tt.mode)
+			if tt.wantErr != nil {
+				if !errors.Is(err, ErrUnresolvedPlaceholders) {
+					t.Fatalf("ApplyVariables() error = %v, want ErrUnresolvedPlaceholders", err)
+				}
+				var unresolved *UnresolvedError
+				if !errors.As(err, &unresolved) || !reflect.DeepEqual(unresolved.Placeholders, tt.wantErr) {
+					t.Errorf("ApplyVariables() unresolved = %v, want %v", unresolved, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ApplyVariables() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ApplyVariables() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindPlaceholders(t *testing.T) {
+	got := FindPlaceholders("Hello {{ first }} {{last}}, {{first}} {not} {{}}")
+	want := []string{"first", "last", ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindPlaceholders() = %q, want %q", got, want)
+	}
+	if got := FindPlaceholders("Hello Ada"); got != nil {
+		t.Errorf("FindPlaceholders() = %q, want nil", got)
+	}
+}
+
+func TestParseUnknownVariables(t *testing.T) {
+	for input, want := range map[string]UnknownVariables{"": UnknownVariablesKeep, "Blank": UnknownVariablesBlank, " strict ": UnknownVariablesStrict} {
+		got, err := ParseUnknownVariables(input)
+		if err != nil || got != want {
+			t.Errorf("ParseUnknownVariables(%q) = %q, %v, want %q", input, got, err, want)
+		}
+	}
+	if _, err := ParseUnknownVariables("ignore"); err == nil {
+		t.Error("Expected error for unknown mode")
+	}
+}
@@ -0,0 +1,62 @@
+// Package tenancy gives every send one source for its tenant. Sends from HTTP requests carry
+// the authenticated tenant in their context, which wins over the request's TenantID; sends
+// from events, schedules and other background work carry the tenant only in the request, and
+// have it copied into their context so everything downstream reads it the same way.
+package tenancy
+
+import (
+	"context"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/middleware"
+	"github.com/vhvplatform/go-notification-service/internal/sender"
+)
+
+// TenantSender resolves each send's tenant before passing it to next. A request naming a
+// tenant other than its context's fails with middleware.ErrTenantMismatch, and one without
+// any tenant with middleware.ErrTenantMissing.
+type TenantSender struct {
+	next sender.Sender
+}
+
+// NewSender creates a sender that resolves each send's tenant
+func NewSender(next sender.Sender) *TenantSender {
+	return &TenantSender{next: next}
+}
+
+// SendEmail sends an email for its resolved tenant
+func (s *TenantSender) SendEmail(ctx context.Context, req *domain.SendEmailRequest) error {
+	ctx, err := resolve(ctx, &req.TenantID)
+	if err != nil {
+		return err
+	}
+	return s.next.SendEmail(ctx, req)
+}
+
+// SendSMS sends an SMS for its resolved tenant
+func (s *TenantSender) SendSMS(ctx context.Context, req *domain.SendSMSRequest) error {
+	ctx, err := resolve(ctx, &req.TenantID)
+	if err != nil {
+		return err
+	}
+	return s.next.SendSMS(ctx, req)
+}
+
+// SendWebhook sends a webhook for its resolved tenant
+func (s *TenantSender) SendWebhook(ctx context.Context, req *domain.SendWebhookRequest) error {
+	ctx, err := resolve(ctx, &req.TenantID)
+	if err != nil {
+		return err
+	}
+	return s.next.SendWebhook(ctx, req)
+}
+
+// resolve sets both the request's tenant and ctx's to the resolved tenant
+func resolve(ctx context.Context, tenantID *string) (context.Context, error) {
+	resolved, err := middleware.ResolveTenantID(ctx, *tenantID)
+	if err != nil {
+		return ctx, err
+	}
+	*tenantID = resolved
+	return middleware.WithTenantID(ctx, resolved), nil
+}
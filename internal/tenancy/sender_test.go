@@ -0,0 +1,80 @@
+package tenancy
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/middleware"
+)
+
+// recordingSender records the context tenant and request tenant of each send
+type recordingSender struct {
+	contextTenants []string
+	requestTenants []string
+}
+
+func (s *recordingSender) record(ctx context.Context, tenantID string) error {
+	s.contextTenants = append(s.contextTenants, middleware.GetTenantIDFromContext(ctx))
+	s.requestTenants = append(s.requestTenants, tenantID)
+	return nil
+}
+
+func (s *recordingSender) SendEmail(ctx context.Context, req *domain.SendEmailRequest) error {
+	return s.record(ctx, req.TenantID)
+}
+
+func (s *recordingSender) SendSMS(ctx context.Context, req *domain.SendSMSRequest) error {
+	return s.record(ctx, req.TenantID)
+}
+
+func (s *recordingSender) SendWebhook(ctx context.Context, req *domain.SendWebhookRequest) error {
+	return s.record(ctx, req.TenantID)
+}
+
+func TestTenantSender(t *testing.T) {
+	next := &recordingSender{}
+	sender := NewSender(next)
+	authenticated := middleware.WithTenantID(context.Background(), "tenant-1")
+
+	// The context tenant fills in a request without one
+	if err := sender.SendEmail(authenticated, &domain.SendEmailRequest{}); err != nil {
+		t.Errorf("SendEmail() error = %v", err)
+	}
+	// Background sends get the request's tenant in their context
+	if err := sender.SendSMS(context.Background(), &domain.SendSMSRequest{TenantID: "tenant-2"}); err != nil {
+		t.Errorf("SendSMS() error = %v", err)
+	}
+	if err := sender.SendWebhook(authenticated, &domain.SendWebhookRequest{TenantID: "tenant-1"}); err != nil {
+		t.Errorf("SendWebhook() error = %v", err)
+	}
+
+	wantTenants := []string{"tenant-1", "tenant-2", "tenant-1"}
+	for i, want := range wantTenants {
+		if next.contextTenants[i] != want || next.requestTenants[i] != want {
+			t.Errorf("send %d tenants = %q in context, %q in request, want %q", i, next.contextTenants[i], next.requestTenants[i], want)
+		}
+	}
+}
+
+func TestTenantSenderMismatch(t *testing.T) {
+	next := &recordingSender{}
+	sender := NewSender(next)
+	authenticated := middleware.WithTenantID(context.Background(), "tenant-1")
+
+	err := sender.SendEmail(authenticated, &domain.SendEmailRequest{TenantID: "tenant-2"})
+	if !errors.Is(err, middleware.ErrTenantMismatch) {
+		t.Errorf("SendEmail() for another tenant error = %v, want ErrTenantMismatch", err)
+	}
+	err = sender.SendWebhook(authenticated, &domain.SendWebhookRequest{TenantID: "tenant-2"})
+	if !errors.Is(err, middleware.ErrTenantMismatch) {
+		t.Errorf("SendWebhook() for another tenant error = %v, want ErrTenantMismatch", err)
+	}
+	if err := sender.SendSMS(context.Background(), &domain.SendSMSRequest{}); !errors.Is(err, middleware.ErrTenantMissing) {
+		t.Errorf("SendSMS() without a tenant error = %v, want ErrTenantMissing", err)
+	}
+	if len(next.requestTenants) != 0 {
+		t.Errorf("%d rejected sends were passed on", len(next.requestTenants))
+	}
+}
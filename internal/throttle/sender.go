@@ -0,0 +1,142 @@
+package throttle
+
+import (
+	"context"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/metrics"
+	"github.com/vhvplatform/go-notification-service/internal/sender"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// RecipientSender counts each email and SMS recipient's sends before passing them to next.
+// Recipients over their channel's limit are dropped from an email; a send left with no
+// recipients is rejected with a *ThrottledError. Webhooks are not limited.
+type RecipientSender struct {
+	store  Store
+	config Config
+	next   sender.Sender
+	log    *logger.Logger
+	now    func() time.Time
+}
+
+// NewSender creates a sender that enforces per-recipient limits
+func NewSender(store Store, config Config, next sender.Sender, log *logger.Logger) *RecipientSender {
+	return &RecipientSender{
+		store:  store,
+		config: config,
+		next:   next,
+		log:    log,
+		now:    time.Now,
+	}
+}
+
+// SendEmail sends an email to the recipients under the limit
+func (s *RecipientSender) SendEmail(ctx context.Context, req *domain.SendEmailRequest) error {
+	if s.exempt(domain.NotificationTypeEmail, req.Priority) {
+		return s.next.SendEmail(ctx, req)
+	}
+
+	// Whether each mailbox is over the limit, so one listed twice is only counted once
+	over := make(map[string]bool)
+	var throttled *ThrottledError
+	for _, recipients := range [][]string{req.To, req.CC, req.BCC} {
+		for _, recipient := range recipients {
+			key := Key(req.TenantID, domain.NotificationTypeEmail, recipient)
+			if _, counted := over[key]; counted {
+				continue
+			}
+			err := s.acquire(ctx, req.TenantID, domain.NotificationTypeEmail, key)
+			over[key] = err != nil
+			if err != nil {
+				throttled = merge(throttled, err)
+			}
+		}
+	}
+	if throttled == nil {
+		return s.next.SendEmail(ctx, req)
+	}
+
+	allowed := *req
+	allowed.To = without(req.TenantID, req.To, over)
+	allowed.CC = without(req.TenantID, req.CC, over)
+	allowed.BCC = without(req.TenantID, req.BCC, over)
+	if len(allowed.To)+len(allowed.CC)+len(allowed.BCC) == 0 {
+		return throttled
+	}
+	s.log.Warn("Dropped throttled recipients from email", "tenant_id", req.TenantID, "throttled", throttled.Recipients)
+	return s.next.SendEmail(ctx, &allowed)
+}
+
+// SendSMS sends an SMS unless its recipient is over the limit
+func (s *RecipientSender) SendSMS(ctx context.Context, req *domain.SendSMSRequest) error {
+	if s.exempt(domain.NotificationTypeSMS, req.Priority) {
+		return s.next.SendSMS(ctx, req)
+	}
+	if err := s.acquire(ctx, req.TenantID, domain.NotificationTypeSMS, Key(req.TenantID, domain.NotificationTypeSMS, req.To)); err != nil {
+		return err
+	}
+	return s.next.SendSMS(ctx, req)
+}
+
+// SendWebhook sends a webhook
+func (s *RecipientSender) SendWebhook(ctx context.Context, req *domain.SendWebhookRequest) error {
+	return s.next.SendWebhook(ctx, req)
+}
+
+// exempt reports whether sends on channel at priority skip the limits
+func (s *RecipientSender) exempt(channel domain.NotificationType, priority domain.NotificationPriority) bool {
+	return s.config.Limit(channel) <= 0 || (s.config.AllowCritical && priority == domain.NotificationPriorityCritical)
+}
+
+// acquire counts a send to the recipient with store key, returning a *ThrottledError when it is over the limit.
+// If the store is unavailable the send goes ahead: the limit is a safety net, not a reason to
+// drop notifications.
+func (s *RecipientSender) acquire(ctx context.Context, tenantID string, channel domain.NotificationType, key string) error {
+	limit := s.config.Limit(channel)
+	now := s.now()
+	allowed, retryAt, err := s.store.Acquire(ctx, key, now, s.config.Window, limit)
+	if err != nil {
+		s.log.Error("Failed to check recipient rate limit, sending anyway", "error", err, "tenant_id", tenantID, "type", channel)
+		return nil
+	}
+	if allowed {
+		return nil
+	}
+
+	metrics.RecipientsThrottled.WithLabelValues(string(channel), metrics.TenantLabel(tenantID)).Inc()
+	s.log.Warn("Recipient rate limit reached", "tenant_id", tenantID, "type", channel, "limit", limit, "window", s.config.Window)
+	return &ThrottledError{
+		Channel:    channel,
+		Recipients: 1,
+		Limit:      limit,
+		Window:     s.config.Window,
+		RetryAfter: max(retryAt.Sub(now), 0),
+	}
+}
+
+// merge adds err's recipient to throttled, keeping the earliest retry
+func merge(throttled *ThrottledError, err error) *ThrottledError {
+	next, _ := AsThrottled(err)
+	if throttled == nil {
+		return next
+	}
+	throttled.Recipients++
+	throttled.RetryAfter = min(throttled.RetryAfter, next.RetryAfter)
+	return throttled
+}
+
+// without returns the email recipients that aren't over the limit
+func without(tenantID string, recipients []string, over map[string]bool) []string {
+	if recipients == nil {
+		return nil
+	}
+	kept := make([]string, 0, len(recipients))
+	for _, recipient := range recipients {
+		if !over[Key(tenantID, domain.NotificationTypeEmail, recipient)] {
+			kept = append(kept, recipient)
+		}
+	}
+	return kept
+}
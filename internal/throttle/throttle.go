@@ -0,0 +1,84 @@
+// Package throttle limits how many notifications one recipient receives per channel within
+// a sliding window, so a buggy or abusive flow can't flood an individual
+package throttle
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+)
+
+// ErrThrottled is returned when a recipient has received their limit of notifications
+var ErrThrottled = errors.New("recipient rate limit exceeded")
+
+// Store counts recent sends per key
+// Acquire records a send at now when fewer than limit sends were recorded after now-window.
+// Otherwise it records nothing and returns false with the time the oldest counted send
+// leaves the window.
+type Store interface {
+	Acquire(ctx context.Context, key string, now time.Time, window time.Duration, limit int) (bool, time.Time, error)
+}
+
+// Config sets the per-recipient limits
+type Config struct {
+	Limits        map[domain.NotificationType]int // Sends one recipient can receive per window, by channel; 0 or missing disables the limit
+	Window        time.Duration                   // Length of the sliding window
+	AllowCritical bool                            // Critical notifications bypass the limits and aren't counted
+}
+
+// Limit returns the channel's limit, or 0 when the channel isn't limited
+func (c Config) Limit(channel domain.NotificationType) int {
+	if c.Window <= 0 {
+		return 0
+	}
+	return c.Limits[channel]
+}
+
+// ThrottledError describes a send rejected because its recipients reached their limit
+type ThrottledError struct {
+	Channel    domain.NotificationType
+	Recipients int // Recipients over the limit; the send had no others
+	Limit      int
+	Window     time.Duration
+	RetryAfter time.Duration // Until the first recipient can receive another notification
+}
+
+// Error implements the error interface
+func (e *ThrottledError) Error() string {
+	who := "the recipient has"
+	if e.Recipients > 1 {
+		who = fmt.Sprintf("all %d recipients have", e.Recipients)
+	}
+	return fmt.Sprintf("%v: %s received %d %s notifications within the last %s; retry after %s",
+		ErrThrottled, who, e.Limit, e.Channel, e.Window, e.RetryAfter.Round(time.Second))
+}
+
+// Unwrap allows errors.Is(err, ErrThrottled)
+func (e *ThrottledError) Unwrap() error {
+	return ErrThrottled
+}
+
+// AsThrottled returns the *ThrottledError in err's chain, if any
+func AsThrottled(err error) (*ThrottledError, bool) {
+	var throttled *ThrottledError
+	ok := errors.As(err, &throttled)
+	return throttled, ok
+}
+
+// Key returns the store key of a recipient's sends on channel for the tenant. Email addresses
+// are compared by mailbox, ignoring case. The recipient is hashed, so keys hold no addresses.
+func Key(tenantID string, channel domain.NotificationType, recipient string) string {
+	recipient = strings.TrimSpace(recipient)
+	if parsed, err := mail.ParseAddress(recipient); err == nil && channel == domain.NotificationTypeEmail {
+		recipient = parsed.Address
+	}
+	sum := sha256.Sum256([]byte(strings.ToLower(recipient)))
+	return tenantID + ":" + string(channel) + ":" + hex.EncodeToString(sum[:])
+}
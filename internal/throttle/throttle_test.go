@@ -0,0 +1,148 @@
+package throttle
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/sender"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+// memoryStore keeps a sliding log of send times per key
+type memoryStore struct {
+	sends map[string][]time.Time
+	err   error
+}
+
+func (s *memoryStore) Acquire(ctx context.Context, key string, now time.Time, window time.Duration, limit int) (bool, time.Time, error) {
+	if s.err != nil {
+		return false, time.Time{}, s.err
+	}
+	var recent []time.Time
+	for _, sent := range s.sends[key] {
+		if sent.After(now.Add(-window)) {
+			recent = append(recent, sent)
+		}
+	}
+	if len(recent) >= limit {
+		s.sends[key] = recent
+		return false, recent[0].Add(window), nil
+	}
+	s.sends[key] = append(recent, now)
+	return true, time.Time{}, nil
+}
+
+// recordingSender records the requests passed to it
+type recordingSender struct {
+	emails []*domain.SendEmailRequest
+	sms    int
+}
+
+func (s *recordingSender) SendEmail(ctx context.Context, req *domain.SendEmailRequest) error {
+	s.emails = append(s.emails, req)
+	return nil
+}
+
+func (s *recordingSender) SendSMS(ctx context.Context, req *domain.SendSMSRequest) error {
+	s.sms++
+	return nil
+}
+
+func (s *recordingSender) SendWebhook(ctx context.Context, req *domain.SendWebhookRequest) error {
+	return nil
+}
+
+func newTestSender(next sender.Sender, store Store) (*RecipientSender, *time.Time) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	sender := NewSender(store, Config{
+		Limits:        map[domain.NotificationType]int{domain.NotificationTypeEmail: 2, domain.NotificationTypeSMS: 1},
+		Window:        time.Hour,
+		AllowCritical: true,
+	}, next, logger.NewLogger())
+	sender.now = func() time.Time { return now }
+	return sender, &now
+}
+
+func TestRecipientSenderSMS(t *testing.T) {
+	next := &recordingSender{}
+	sender, now := newTestSender(next, &memoryStore{sends: map[string][]time.Time{}})
+	ctx := context.Background()
+	req := &domain.SendSMSRequest{TenantID: "tenant-1", To: "+15550100", Message: "Your code is 1234"}
+
+	if err := sender.SendSMS(ctx, req); err != nil {
+		t.Fatalf("first SendSMS() error = %v", err)
+	}
+	*now = now.Add(10 * time.Minute)
+	err := sender.SendSMS(ctx, req)
+	throttled, ok := AsThrottled(err)
+	if !ok || !errors.Is(err, ErrThrottled) {
+		t.Fatalf("second SendSMS() error = %v, want %v", err, ErrThrottled)
+	}
+	if throttled.RetryAfter != 50*time.Minute {
+		t.Errorf("RetryAfter = %s, want 50m", throttled.RetryAfter)
+	}
+
+	// Other tenants, critical messages and sends after the window aren't limited
+	other := *req
+	other.TenantID = "tenant-2"
+	critical := *req
+	critical.Priority = domain.NotificationPriorityCritical
+	for _, r := range []*domain.SendSMSRequest{&other, &critical} {
+		if err := sender.SendSMS(ctx, r); err != nil {
+			t.Errorf("SendSMS(%s, %s) error = %v", r.TenantID, r.Priority, err)
+		}
+	}
+	*now = now.Add(time.Hour)
+	if err := sender.SendSMS(ctx, req); err != nil {
+		t.Errorf("SendSMS() after the window error = %v", err)
+	}
+	if next.sms != 4 {
+		t.Errorf("sent %d SMS, want 4", next.sms)
+	}
+}
+
+func TestRecipientSenderEmail(t *testing.T) {
+	next := &recordingSender{}
+	sender, _ := newTestSender(next, &memoryStore{sends: map[string][]time.Time{}})
+	ctx := context.Background()
+
+	// Listing a mailbox twice counts it once
+	first := &domain.SendEmailRequest{TenantID: "tenant-1", To: []string{"a@example.com"}, CC: []string{"A@Example.com"}}
+	for i := 0; i < 2; i++ {
+		if err := sender.SendEmail(ctx, first); err != nil {
+			t.Fatalf("SendEmail() error = %v", err)
+		}
+	}
+
+	// Recipients over the limit are dropped; the others still get the email
+	mixed := &domain.SendEmailRequest{TenantID: "tenant-1", To: []string{"a@example.com", "b@example.com"}, BCC: []string{"Jane <a@example.com>"}}
+	if err := sender.SendEmail(ctx, mixed); err != nil {
+		t.Fatalf("SendEmail() error = %v", err)
+	}
+	sent := next.emails[len(next.emails)-1]
+	if len(sent.To) != 1 || sent.To[0] != "b@example.com" || len(sent.BCC) != 0 {
+		t.Errorf("sent to %v, bcc %v; want only b@example.com", sent.To, sent.BCC)
+	}
+	if len(mixed.To) != 2 {
+		t.Error("the caller's request was modified")
+	}
+
+	if err := sender.SendEmail(ctx, first); !errors.Is(err, ErrThrottled) {
+		t.Errorf("SendEmail() to only throttled recipients error = %v, want %v", err, ErrThrottled)
+	}
+}
+
+func TestRecipientSenderStoreUnavailable(t *testing.T) {
+	next := &recordingSender{}
+	sender, _ := newTestSender(next, &memoryStore{err: errors.New("connection refused")})
+
+	if err := sender.SendSMS(context.Background(), &domain.SendSMSRequest{TenantID: "tenant-1", To: "+15550100"}); err != nil {
+		t.Errorf("SendSMS() error = %v, want the SMS sent while the store is down", err)
+	}
+	if next.sms != 1 {
+		t.Errorf("sent %d SMS, want 1", next.sms)
+	}
+}
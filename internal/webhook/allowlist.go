@@ -0,0 +1,116 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+)
+
+// ErrDestinationNotAllowed is returned when a webhook URL's host is not on the tenant's allowlist
+var ErrDestinationNotAllowed = errors.New("webhook destination not allowed")
+
+// domainPattern matches a lowercase hostname, optionally prefixed with a "*." subdomain wildcard
+var domainPattern = regexp.MustCompile(`^(\*\.)?([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)*[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// AllowlistStore interface for per-tenant webhook allowlist storage
+type AllowlistStore interface {
+	FindWebhookAllowlist(ctx context.Context, tenantID string) (*domain.WebhookAllowlist, error)
+}
+
+// DestinationError describes a webhook URL rejected by the allowlist
+type DestinationError struct {
+	URL  string
+	Host string
+}
+
+// Error implements the error interface
+func (e *DestinationError) Error() string {
+	if e.Host == "" {
+		return fmt.Sprintf("webhook url %q has no host", e.URL)
+	}
+	return fmt.Sprintf("webhook destination %q is not in the tenant's allowed domains", e.Host)
+}
+
+// Unwrap allows errors.Is(err, ErrDestinationNotAllowed)
+func (e *DestinationError) Unwrap() error {
+	return ErrDestinationNotAllowed
+}
+
+// AsDestinationError returns the *DestinationError in err's chain, if any
+func AsDestinationError(err error) (*DestinationError, bool) {
+	var rejected *DestinationError
+	ok := errors.As(err, &rejected)
+	return rejected, ok
+}
+
+// NormalizeDomains lowercases and de-duplicates allowlist entries, rejecting entries
+// that are not a hostname or a "*." wildcard followed by a hostname
+func NormalizeDomains(domains []string) ([]string, error) {
+	normalized := make([]string, 0, len(domains))
+	seen := make(map[string]bool, len(domains))
+	for _, entry := range domains {
+		pattern := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(entry)), ".")
+		if !domainPattern.MatchString(pattern) {
+			return nil, fmt.Errorf("invalid domain: %q (must be a hostname such as example.com or *.example.com)", entry)
+		}
+		if !seen[pattern] {
+			seen[pattern] = true
+			normalized = append(normalized, pattern)
+		}
+	}
+	return normalized, nil
+}
+
+// MatchHost reports whether host is allowed by any of the patterns.
+// A "*.example.com" pattern matches any subdomain of example.com but not example.com itself.
+func MatchHost(patterns []string, host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	for _, pattern := range patterns {
+		if suffix, ok := strings.CutPrefix(pattern, "*"); ok {
+			if strings.HasSuffix(host, suffix) && len(host) > len(suffix) {
+				return true
+			}
+			continue
+		}
+		if host == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// Allowlist checks webhook destinations against each tenant's approved domains
+type Allowlist struct {
+	store AllowlistStore
+}
+
+// NewAllowlist creates a new webhook destination allowlist
+func NewAllowlist(store AllowlistStore) *Allowlist {
+	return &Allowlist{store: store}
+}
+
+// Check returns a *DestinationError when the tenant has an allowlist and rawURL's host
+// is not on it. Tenants without an allowlist may send to any destination.
+func (a *Allowlist) Check(ctx context.Context, tenantID, rawURL string) error {
+	allowlist, err := a.store.FindWebhookAllowlist(ctx, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to load webhook allowlist: %w", err)
+	}
+	if allowlist == nil || len(allowlist.Domains) == 0 {
+		return nil
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Hostname() == "" {
+		return &DestinationError{URL: rawURL}
+	}
+	if !MatchHost(allowlist.Domains, parsed.Hostname()) {
+		return &DestinationError{URL: rawURL, Host: parsed.Hostname()}
+	}
+	return nil
+}
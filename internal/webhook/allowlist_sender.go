@@ -0,0 +1,40 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/sender"
+)
+
+// AllowlistSender rejects webhooks to destinations outside the tenant's allowlist before passing them to next
+type AllowlistSender struct {
+	allowlist *Allowlist
+	next      sender.Sender
+}
+
+// NewAllowlistSender creates a sender that enforces webhook destination allowlists at send time
+func NewAllowlistSender(allowlist *Allowlist, next sender.Sender) *AllowlistSender {
+	return &AllowlistSender{
+		allowlist: allowlist,
+		next:      next,
+	}
+}
+
+// SendEmail sends an email
+func (s *AllowlistSender) SendEmail(ctx context.Context, req *domain.SendEmailRequest) error {
+	return s.next.SendEmail(ctx, req)
+}
+
+// SendSMS sends an SMS
+func (s *AllowlistSender) SendSMS(ctx context.Context, req *domain.SendSMSRequest) error {
+	return s.next.SendSMS(ctx, req)
+}
+
+// SendWebhook checks the webhook's destination against the tenant's allowlist and sends it
+func (s *AllowlistSender) SendWebhook(ctx context.Context, req *domain.SendWebhookRequest) error {
+	if err := s.allowlist.Check(ctx, req.TenantID, req.URL); err != nil {
+		return err
+	}
+	return s.next.SendWebhook(ctx, req)
+}
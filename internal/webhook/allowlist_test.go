@@ -0,0 +1,88 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+)
+
+type fakeAllowlistStore struct {
+	allowlist *domain.WebhookAllowlist
+	err       error
+}
+
+func (s fakeAllowlistStore) FindWebhookAllowlist(ctx context.Context, tenantID string) (*domain.WebhookAllowlist, error) {
+	return s.allowlist, s.err
+}
+
+func TestNormalizeDomains(t *testing.T) {
+	got, err := NormalizeDomains([]string{" Example.COM. ", "*.hooks.example.com", "example.com"})
+	if err != nil {
+		t.Fatalf("NormalizeDomains() error = %v", err)
+	}
+	want := []string{"example.com", "*.hooks.example.com"}
+	if len(got) != len(want) {
+		t.Fatalf("NormalizeDomains() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("NormalizeDomains()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	for _, invalid := range []string{"", "*", "*.", "https://example.com", "example.com:8443", "example.com/path", "api.*.example.com", "-bad.example.com"} {
+		if _, err := NormalizeDomains([]string{invalid}); err == nil {
+			t.Errorf("NormalizeDomains(%q) error = nil, want error", invalid)
+		}
+	}
+}
+
+func TestMatchHost(t *testing.T) {
+	patterns := []string{"example.com", "*.hooks.example.org"}
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"example.com", true},
+		{"EXAMPLE.com.", true},
+		{"api.example.com", false},
+		{"hooks.example.org", false},
+		{"a.hooks.example.org", true},
+		{"a.b.hooks.example.org", true},
+		{"evilhooks.example.org", false},
+		{"example.com.evil.net", false},
+	}
+	for _, tt := range tests {
+		if got := MatchHost(patterns, tt.host); got != tt.want {
+			t.Errorf("MatchHost(%q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestAllowlistCheck(t *testing.T) {
+	ctx := context.Background()
+	allowlist := NewAllowlist(fakeAllowlistStore{allowlist: &domain.WebhookAllowlist{Domains: []string{"*.example.com"}}})
+
+	if err := allowlist.Check(ctx, "tenant-1", "https://hooks.example.com/events"); err != nil {
+		t.Errorf("Check() allowed host error = %v", err)
+	}
+
+	err := allowlist.Check(ctx, "tenant-1", "https://attacker.net/collect")
+	if !errors.Is(err, ErrDestinationNotAllowed) {
+		t.Fatalf("Check() error = %v, want ErrDestinationNotAllowed", err)
+	}
+	if rejected, ok := AsDestinationError(err); !ok || rejected.Host != "attacker.net" {
+		t.Errorf("AsDestinationError() = %+v, %v", rejected, ok)
+	}
+
+	if err := NewAllowlist(fakeAllowlistStore{}).Check(ctx, "tenant-1", "https://attacker.net"); err != nil {
+		t.Errorf("Check() without allowlist error = %v, want nil", err)
+	}
+
+	storeErr := errors.New("mongo unavailable")
+	if err := NewAllowlist(fakeAllowlistStore{err: storeErr}).Check(ctx, "tenant-1", "https://hooks.example.com"); !errors.Is(err, storeErr) {
+		t.Errorf("Check() store failure error = %v, want %v", err, storeErr)
+	}
+}
@@ -0,0 +1,68 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/metrics"
+	"github.com/vhvplatform/go-notification-service/pkg/webhooksig"
+)
+
+// EnsureDeliveryID gives req a delivery ID unless it already has one, and returns the ID.
+// Calling it before the first attempt of a delivery makes every retry of that delivery,
+// which reuses req, send the same ID.
+func EnsureDeliveryID(req *domain.SendWebhookRequest) string {
+	if req.DeliveryID == "" {
+		req.DeliveryID = uuid.NewString()
+	}
+	return req.DeliveryID
+}
+
+// SetDeliveryHeader sets the delivery ID header on an outgoing webhook request.
+// Receivers deduplicate deliveries by it; an empty ID leaves the header unset.
+func SetDeliveryHeader(httpReq *http.Request, deliveryID string) {
+	if deliveryID != "" {
+		httpReq.Header.Set(webhooksig.DeliveryIDHeader, deliveryID)
+	}
+}
+
+// Deliver makes one attempt at the webhook in req with client. The payload is encoded with
+// EncodePayload and sent with req.Method (POST by default), req.Headers and the delivery ID
+// header. The time to the receiver's response, or to the connection failing, is recorded in
+// metrics.WebhookResponseDuration by status class. The response is read with CheckResponse,
+// so a non-2xx status returns a *DeliveryError.
+func Deliver(ctx context.Context, client *http.Client, req *domain.SendWebhookRequest, maxResponseBytes int64) (*domain.WebhookResponse, error) {
+	body, contentType, err := EncodePayload(req.Encoding, req.ContentType, req.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	method := strings.ToUpper(req.Method)
+	if method == "" {
+		method = http.MethodPost
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, req.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	for name, value := range req.Headers {
+		httpReq.Header.Set(name, value)
+	}
+	httpReq.Header.Set("Content-Type", contentType)
+	SetDeliveryHeader(httpReq, req.DeliveryID)
+
+	start := time.Now()
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		metrics.ObserveWebhookResponse(time.Since(start), 0)
+		return nil, fmt.Errorf("failed to send webhook: %w", err)
+	}
+	metrics.ObserveWebhookResponse(time.Since(start), resp.StatusCode)
+	return CheckResponse(resp, maxResponseBytes)
+}
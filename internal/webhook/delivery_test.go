@@ -0,0 +1,132 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/pkg/webhooksig"
+)
+
+func TestEnsureDeliveryID(t *testing.T) {
+	req := &domain.SendWebhookRequest{URL: "https://hooks.example.com"}
+	id := EnsureDeliveryID(req)
+	if id == "" || req.DeliveryID != id {
+		t.Fatalf("EnsureDeliveryID() = %q, request has %q; want a new ID stored on the request", id, req.DeliveryID)
+	}
+	if again := EnsureDeliveryID(req); again != id {
+		t.Errorf("EnsureDeliveryID() on a retry = %q, want the original %q", again, id)
+	}
+
+	httpReq, _ := http.NewRequest(http.MethodPost, req.URL, nil)
+	SetDeliveryHeader(httpReq, id)
+	if got := httpReq.Header.Get(webhooksig.DeliveryIDHeader); got != id {
+		t.Errorf("%s = %q, want %q", webhooksig.DeliveryIDHeader, got, id)
+	}
+}
+
+func TestDeliver(t *testing.T) {
+	var got *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("queued"))
+	}))
+	defer server.Close()
+
+	req := &domain.SendWebhookRequest{
+		URL:        server.URL,
+		Method:     "put",
+		Headers:    map[string]string{"X-Source": "orders"},
+		Payload:    map[string]any{"order_id": "42"},
+		Encoding:   EncodingXML,
+		DeliveryID: "delivery-1",
+	}
+	resp, err := Deliver(context.Background(), server.Client(), req, 0)
+	if err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	if resp.StatusCode != http.StatusAccepted || resp.Body != "queued" {
+		t.Errorf("Deliver() = %+v, want the receiver's 202 response", resp)
+	}
+	if got.Method != http.MethodPut || got.Header.Get("Content-Type") != "application/xml" || got.Header.Get("X-Source") != "orders" || got.Header.Get(webhooksig.DeliveryIDHeader) != "delivery-1" {
+		t.Errorf("request = %s with headers %v, want a PUT of XML with the custom and delivery ID headers", got.Method, got.Header)
+	}
+	if !strings.Contains(string(body), "<payload><order_id>42</order_id></payload>") {
+		t.Errorf("body = %s, want the payload encoded as XML", body)
+	}
+}
+
+func TestDeliverFailures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	_, err := Deliver(context.Background(), server.Client(), &domain.SendWebhookRequest{URL: server.URL, Payload: map[string]any{"event": "x"}}, 0)
+	var deliveryErr *DeliveryError
+	if !errors.As(err, &deliveryErr) || deliveryErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Deliver() error = %v, want a *DeliveryError with status 503", err)
+	}
+
+	// A payload that can't be encoded is never sent
+	_, err = Deliver(context.Background(), server.Client(), &domain.SendWebhookRequest{URL: server.URL, Encoding: EncodingXML, Payload: map[string]any{"1st": "x"}}, 0)
+	if err == nil {
+		t.Error("Deliver() error = nil, want the payload's invalid XML name rejected")
+	}
+}
+
+// responseCount returns how many webhook responses of statusClass have been observed
+func responseCount(t *testing.T, statusClass string) uint64 {
+	t.Helper()
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != "notification_service_webhook_response_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "status_class" && label.GetValue() == statusClass {
+					return metric.GetHistogram().GetSampleCount()
+				}
+			}
+		}
+	}
+	return 0
+}
+
+func TestDeliverObservesResponseTime(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+		}
+	}))
+	ok, missing, unreachable := responseCount(t, "2xx"), responseCount(t, "4xx"), responseCount(t, "error")
+
+	payload := map[string]any{"event": "order.created"}
+	Deliver(context.Background(), server.Client(), &domain.SendWebhookRequest{URL: server.URL, Payload: payload}, 0)
+	Deliver(context.Background(), server.Client(), &domain.SendWebhookRequest{URL: server.URL + "/missing", Payload: payload}, 0)
+	server.Close()
+	Deliver(context.Background(), server.Client(), &domain.SendWebhookRequest{URL: server.URL, Payload: payload}, 0)
+
+	if got := responseCount(t, "2xx") - ok; got != 1 {
+		t.Errorf("observed %d 2xx responses, want 1", got)
+	}
+	if got := responseCount(t, "4xx") - missing; got != 1 {
+		t.Errorf("observed %d 4xx responses, want 1", got)
+	}
+	if got := responseCount(t, "error") - unreachable; got != 1 {
+		t.Errorf("observed %d failed connections, want 1", got)
+	}
+}
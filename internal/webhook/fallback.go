@@ -0,0 +1,237 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/metrics"
+	"github.com/vhvplatform/go-notification-service/internal/sender"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+	"golang.org/x/time/rate"
+)
+
+// FallbackStore interface for per-tenant webhook fallback storage
+type FallbackStore interface {
+	FindWebhookFallback(ctx context.Context, tenantID string) (*domain.WebhookFallback, error)
+}
+
+// FallbackConfig holds webhook fallback settings
+type FallbackConfig struct {
+	MaxPerHour int // Fallback notifications per tenant per hour; more are dropped
+}
+
+// fallbackKey marks a context in which a fallback notification is being sent
+type fallbackKey struct{}
+
+// NormalizeFallback trims a fallback's recipients and checks that they suit its channel
+func NormalizeFallback(channel *domain.FallbackChannel) error {
+	if channel.Type != domain.NotificationTypeEmail && channel.Type != domain.NotificationTypeSMS {
+		return fmt.Errorf("fallback type must be email or sms, got %q", channel.Type)
+	}
+	if len(channel.To) == 0 {
+		return fmt.Errorf("fallback needs at least one recipient")
+	}
+	for i, to := range channel.To {
+		to = strings.TrimSpace(to)
+		if to == "" {
+			return fmt.Errorf("fallback recipient %d is empty", i)
+		}
+		if channel.Type == domain.NotificationTypeEmail {
+			if _, err := mail.ParseAddress(to); err != nil {
+				return fmt.Errorf("invalid fallback email address %q", to)
+			}
+		}
+		channel.To[i] = to
+	}
+	return nil
+}
+
+// FallbackSender notifies a fallback channel when a webhook sent through next fails after its
+// retries. Fallback notifications go through alerts, which is next unless SetAlertSender is
+// called, and never trigger a fallback of their own.
+type FallbackSender struct {
+	store    FallbackStore
+	next     sender.Sender
+	alerts   sender.Sender
+	limit    rate.Limit
+	burst    int
+	limiters map[string]*rate.Limiter
+	mu       sync.Mutex
+	log      *logger.Logger
+}
+
+// NewFallbackSender creates a sender that notifies a fallback channel of failed webhooks
+func NewFallbackSender(store FallbackStore, config FallbackConfig, next sender.Sender, log *logger.Logger) *FallbackSender {
+	return &FallbackSender{
+		store:    store,
+		next:     next,
+		alerts:   next,
+		limit:    rate.Every(time.Hour / time.Duration(max(config.MaxPerHour, 1))),
+		burst:    max(config.MaxPerHour, 1),
+		limiters: make(map[string]*rate.Limiter),
+		log:      log,
+	}
+}
+
+// SetAlertSender sends fallback notifications through alerts, such as the full send path
+// with pauses and deduplication, instead of straight to next
+func (s *FallbackSender) SetAlertSender(alerts sender.Sender) {
+	s.alerts = alerts
+}
+
+// SendEmail sends an email
+func (s *FallbackSender) SendEmail(ctx context.Context, req *domain.SendEmailRequest) error {
+	return s.next.SendEmail(ctx, req)
+}
+
+// SendSMS sends an SMS
+func (s *FallbackSender) SendSMS(ctx context.Context, req *domain.SendSMSRequest) error {
+	return s.next.SendSMS(ctx, req)
+}
+
+// SendWebhook sends a webhook, notifying the fallback channel if it fails
+// The webhook's error is returned whether or not the fallback is sent.
+func (s *FallbackSender) SendWebhook(ctx context.Context, req *domain.SendWebhookRequest) error {
+	err := s.next.SendWebhook(ctx, req)
+	if err == nil || ctx.Err() != nil || ctx.Value(fallbackKey{}) != nil {
+		return err
+	}
+	s.fallback(ctx, req, err)
+	return err
+}
+
+// Notify sends the fallback for a webhook that failed after retries made elsewhere, such as by
+// the durable retry queue, as SendWebhook does when next fails
+func (s *FallbackSender) Notify(ctx context.Context, req *domain.SendWebhookRequest, cause error) {
+	if ctx.Value(fallbackKey{}) != nil {
+		return
+	}
+	s.fallback(ctx, req, cause)
+}
+
+// fallback sends a summary of the failed webhook to the request's or the tenant's fallback channel
+func (s *FallbackSender) fallback(ctx context.Context, req *domain.SendWebhookRequest, cause error) {
+	channel, err := s.channel(ctx, req)
+	if err != nil {
+		s.log.Error("Failed to load webhook fallback", "error", err, "tenant_id", req.TenantID)
+		return
+	}
+	if channel == nil {
+		return
+	}
+	if !s.allow(req.TenantID) {
+		metrics.WebhookFallbacks.WithLabelValues("rate_limited").Inc()
+		s.log.Warn("Webhook fallback rate limited", "tenant_id", req.TenantID, "url", req.URL)
+		return
+	}
+
+	ctx = context.WithValue(ctx, fallbackKey{}, true)
+	if err := s.send(ctx, req, channel, cause); err != nil {
+		metrics.WebhookFallbacks.WithLabelValues("failed").Inc()
+		s.log.Error("Failed to send webhook fallback", "error", err, "tenant_id", req.TenantID, "url", req.URL, "type", channel.Type)
+		return
+	}
+	metrics.WebhookFallbacks.WithLabelValues("sent").Inc()
+	s.log.Info("Sent webhook fallback", "tenant_id", req.TenantID, "url", req.URL, "type", channel.Type)
+}
+
+// channel returns the request's fallback channel, or the tenant's if the request sets none
+func (s *FallbackSender) channel(ctx context.Context, req *domain.SendWebhookRequest) (*domain.FallbackChannel, error) {
+	if req.Fallback != nil {
+		return req.Fallback, nil
+	}
+	fallback, err := s.store.FindWebhookFallback(ctx, req.TenantID)
+	if err != nil || fallback == nil {
+		return nil, err
+	}
+	return &fallback.Channel, nil
+}
+
+// allow reports whether the tenant may send another fallback notification now
+func (s *FallbackSender) allow(tenantID string) bool {
+	s.mu.Lock()
+	limiter, ok := s.limiters[tenantID]
+	if !ok {
+		limiter = rate.NewLimiter(s.limit, s.burst)
+		s.limiters[tenantID] = limiter
+	}
+	s.mu.Unlock()
+	return limiter.Allow()
+}
+
+// send notifies channel that req failed with cause
+func (s *FallbackSender) send(ctx context.Context, req *domain.SendWebhookRequest, channel *domain.FallbackChannel, cause error) error {
+	metadata := map[string]string{
+		"fallback_for": string(domain.NotificationTypeWebhook),
+		"webhook_url":  req.URL,
+	}
+	// A retried request whose webhook fails again doesn't notify twice
+	var key string
+	if req.IdempotencyKey != "" {
+		key = "webhook-fallback:" + req.IdempotencyKey
+	}
+
+	if channel.Type == domain.NotificationTypeSMS {
+		message := fmt.Sprintf("Webhook to %s failed: %s", req.URL, cause)
+		var errs []error
+		for i, to := range channel.To {
+			sms := &domain.SendSMSRequest{
+				TenantID: req.TenantID,
+				To:       to,
+				Message:  message,
+				Priority: req.Priority,
+				Category: req.Category,
+				Tags:     []string{"webhook-fallback"},
+				Metadata: metadata,
+			}
+			if key != "" {
+				sms.IdempotencyKey = fmt.Sprintf("%s:%d", key, i)
+			}
+			if err := s.alerts.SendSMS(ctx, sms); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if len(errs) > 0 {
+			return fmt.Errorf("%d of %d fallback SMS failed: %w", len(errs), len(channel.To), errs[0])
+		}
+		return nil
+	}
+
+	return s.alerts.SendEmail(ctx, &domain.SendEmailRequest{
+		TenantID:       req.TenantID,
+		To:             channel.To,
+		Subject:        fmt.Sprintf("Webhook delivery failed: %s", req.URL),
+		Body:           summary(req, cause),
+		Priority:       req.Priority,
+		Category:       req.Category,
+		Tags:           []string{"webhook-fallback"},
+		Metadata:       metadata,
+		IdempotencyKey: key,
+	})
+}
+
+// summary describes a failed webhook for a fallback email
+func summary(req *domain.SendWebhookRequest, cause error) string {
+	var b strings.Builder
+	b.WriteString("A webhook could not be delivered after all retries.\n\n")
+	fmt.Fprintf(&b, "URL: %s\n", req.URL)
+	fmt.Fprintf(&b, "Tenant: %s\n", req.TenantID)
+	for _, field := range []struct{ name, value string }{
+		{"Method", req.Method},
+		{"Category", req.Category},
+		{"Template", req.TemplateID},
+		{"Group", req.GroupID},
+		{"Idempotency key", req.IdempotencyKey},
+	} {
+		if field.value != "" {
+			fmt.Fprintf(&b, "%s: %s\n", field.name, field.value)
+		}
+	}
+	fmt.Fprintf(&b, "Error: %s\n", cause)
+	return b.String()
+}
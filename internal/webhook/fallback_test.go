@@ -0,0 +1,108 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/shared/logger"
+)
+
+type fakeFallbackStore struct {
+	fallback *domain.WebhookFallback
+}
+
+func (s fakeFallbackStore) FindWebhookFallback(ctx context.Context, tenantID string) (*domain.WebhookFallback, error) {
+	return s.fallback, nil
+}
+
+// recordingSender fails webhooks with err and records the emails and SMS it is asked to send
+type recordingSender struct {
+	err    error
+	emails []*domain.SendEmailRequest
+	sms    []*domain.SendSMSRequest
+}
+
+func (s *recordingSender) SendEmail(ctx context.Context, req *domain.SendEmailRequest) error {
+	s.emails = append(s.emails, req)
+	return nil
+}
+
+func (s *recordingSender) SendSMS(ctx context.Context, req *domain.SendSMSRequest) error {
+	s.sms = append(s.sms, req)
+	return nil
+}
+
+func (s *recordingSender) SendWebhook(ctx context.Context, req *domain.SendWebhookRequest) error {
+	return s.err
+}
+
+func TestNormalizeFallback(t *testing.T) {
+	channel := &domain.FallbackChannel{Type: domain.NotificationTypeEmail, To: []string{" ops@example.com "}}
+	if err := NormalizeFallback(channel); err != nil || channel.To[0] != "ops@example.com" {
+		t.Errorf("NormalizeFallback() = %v, to %q", err, channel.To[0])
+	}
+
+	for name, invalid := range map[string]*domain.FallbackChannel{
+		"webhook type":  {Type: domain.NotificationTypeWebhook, To: []string{"https://example.com"}},
+		"no recipients": {Type: domain.NotificationTypeSMS},
+		"blank":         {Type: domain.NotificationTypeSMS, To: []string{" "}},
+		"bad email":     {Type: domain.NotificationTypeEmail, To: []string{"not-an-address"}},
+	} {
+		if err := NormalizeFallback(invalid); err == nil {
+			t.Errorf("NormalizeFallback() with %s error = nil, want error", name)
+		}
+	}
+}
+
+func TestFallbackSender(t *testing.T) {
+	ctx := context.Background()
+	failure := errors.New("endpoint returned 503")
+	next := &recordingSender{err: failure}
+	store := fakeFallbackStore{fallback: &domain.WebhookFallback{
+		Channel: domain.FallbackChannel{Type: domain.NotificationTypeEmail, To: []string{"ops@example.com"}},
+	}}
+	sender := NewFallbackSender(store, FallbackConfig{MaxPerHour: 2}, next, logger.NewLogger())
+
+	req := &domain.SendWebhookRequest{TenantID: "tenant-1", URL: "https://hooks.example.com/orders", IdempotencyKey: "order-42"}
+	if err := sender.SendWebhook(ctx, req); !errors.Is(err, failure) {
+		t.Fatalf("SendWebhook() error = %v, want the webhook's error", err)
+	}
+	if len(next.emails) != 1 {
+		t.Fatalf("sent %d fallback emails, want 1", len(next.emails))
+	}
+	if email := next.emails[0]; email.To[0] != "ops@example.com" || !strings.Contains(email.Body, "endpoint returned 503") || email.IdempotencyKey != "webhook-fallback:order-42" {
+		t.Errorf("fallback email = %+v", email)
+	}
+
+	// The request's own fallback wins over the tenant's
+	req.Fallback = &domain.FallbackChannel{Type: domain.NotificationTypeSMS, To: []string{"+15550100", "+15550101"}}
+	sender.SendWebhook(ctx, req)
+	if len(next.sms) != 2 || len(next.emails) != 1 {
+		t.Errorf("sent %d fallback SMS and %d emails, want 2 and 1", len(next.sms), len(next.emails))
+	}
+
+	// The tenant's hourly allowance is used up
+	sender.SendWebhook(ctx, req)
+	if len(next.sms) != 2 {
+		t.Errorf("sent %d fallback SMS after the rate limit, want 2", len(next.sms))
+	}
+	// Other tenants have their own allowance
+	sender.SendWebhook(ctx, &domain.SendWebhookRequest{TenantID: "tenant-2", URL: req.URL})
+	if len(next.emails) != 2 {
+		t.Errorf("sent %d fallback emails for another tenant, want 2", len(next.emails))
+	}
+
+	// A webhook failing while a fallback is sent doesn't trigger another fallback
+	sender.SendWebhook(context.WithValue(ctx, fallbackKey{}, true), &domain.SendWebhookRequest{TenantID: "tenant-3", URL: req.URL})
+	if len(next.emails) != 2 {
+		t.Errorf("webhook sent from a fallback triggered a fallback")
+	}
+
+	next.err = nil
+	if err := sender.SendWebhook(ctx, &domain.SendWebhookRequest{TenantID: "tenant-4", URL: req.URL}); err != nil || len(next.emails) != 2 {
+		t.Errorf("successful webhook error = %v, sent %d fallback emails", err, len(next.emails))
+	}
+}
@@ -0,0 +1,196 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+)
+
+// Supported webhook payload encodings
+const (
+	EncodingJSON = "json"
+	EncodingForm = "form"
+	EncodingXML  = "xml"
+)
+
+// xmlRootElement wraps XML-encoded payloads
+const xmlRootElement = "payload"
+
+// defaultContentTypes maps each encoding to the Content-Type sent with it
+var defaultContentTypes = map[string]string{
+	EncodingJSON: "application/json",
+	EncodingForm: "application/x-www-form-urlencoded",
+	EncodingXML:  "application/xml",
+}
+
+// EncodePayload serializes a webhook payload using the given encoding (json by default)
+// and returns the body with its Content-Type. A non-empty contentType overrides the default.
+func EncodePayload(encoding, contentType string, payload map[string]any) ([]byte, string, error) {
+	if encoding == "" {
+		encoding = EncodingJSON
+	}
+
+	defaultContentType, ok := defaultContentTypes[encoding]
+	if !ok {
+		return nil, "", fmt.Errorf("unsupported webhook encoding: %s (must be json, form or xml)", encoding)
+	}
+
+	if contentType == "" {
+		contentType = defaultContentType
+	} else if _, _, err := mime.ParseMediaType(contentType); err != nil {
+		return nil, "", fmt.Errorf("invalid content type %q: %w", contentType, err)
+	}
+
+	var (
+		body []byte
+		err  error
+	)
+	switch encoding {
+	case EncodingForm:
+		body = []byte(encodeForm(payload))
+	case EncodingXML:
+		body, err = encodeXML(payload)
+	default:
+		body, err = json.Marshal(payload)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	return body, contentType, nil
+}
+
+// CheckPayload returns the error EncodePayload would return for req's payload, so a payload
+// that can't be delivered, such as one whose keys aren't XML names, is rejected with the request.
+// Template payloads are rendered at send time and aren't checked.
+func CheckPayload(req *domain.SendWebhookRequest) error {
+	if req.TemplateID != "" {
+		return nil
+	}
+	_, _, err := EncodePayload(req.Encoding, req.ContentType, req.Payload)
+	return err
+}
+
+// encodeForm flattens a payload into URL-encoded key=value pairs.
+// Nested maps use bracket notation (a[b]=1) and slices use indexes (a[0]=1).
+func encodeForm(payload map[string]any) string {
+	values := url.Values{}
+	for key, value := range payload {
+		flattenFormValue(values, key, value)
+	}
+	return values.Encode()
+}
+
+// flattenFormValue adds a value and any nested values to the form under key
+func flattenFormValue(values url.Values, key string, value any) {
+	switch v := value.(type) {
+	case map[string]any:
+		for k, nested := range v {
+			flattenFormValue(values, key+"["+k+"]", nested)
+		}
+	case []any:
+		for i, nested := range v {
+			flattenFormValue(values, key+"["+strconv.Itoa(i)+"]", nested)
+		}
+	case nil:
+		values.Add(key, "")
+	default:
+		values.Add(key, scalarString(v))
+	}
+}
+
+// encodeXML renders a payload as XML under a <payload> root element.
+// Map keys become elements in sorted order and slice items repeat their parent element.
+func encodeXML(payload map[string]any) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+
+	enc := xml.NewEncoder(&buf)
+	if err := writeXMLElement(enc, xmlRootElement, payload); err != nil {
+		return nil, err
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeXMLElement writes value as one or more elements with the given name
+func writeXMLElement(enc *xml.Encoder, name string, value any) error {
+	if !isValidXMLName(name) {
+		return fmt.Errorf("payload key %q is not a valid XML element name", name)
+	}
+
+	// Slices repeat the element once per item
+	if items, ok := value.([]any); ok {
+		for _, item := range items {
+			if err := writeXMLElement(enc, name, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if err := writeXMLElement(enc, k, v[k]); err != nil {
+				return err
+			}
+		}
+	case nil:
+	default:
+		if err := enc.EncodeToken(xml.CharData(scalarString(v))); err != nil {
+			return err
+		}
+	}
+
+	return enc.EncodeToken(start.End())
+}
+
+// isValidXMLName reports whether name can be used as an XML element name
+func isValidXMLName(name string) bool {
+	if name == "" || strings.HasPrefix(strings.ToLower(name), "xml") {
+		return false
+	}
+	for i, r := range name {
+		isLetter := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r == '_'
+		isOther := (r >= '0' && r <= '9') || r == '-' || r == '.'
+		if !isLetter && (i == 0 || !isOther) {
+			return false
+		}
+	}
+	return true
+}
+
+// scalarString formats a scalar JSON value as text
+func scalarString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,93 @@
+package webhook
+
+import (
+	"testing"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+)
+
+func TestEncodePayload(t *testing.T) {
+	payload := map[string]any{
+		"event": "order.created",
+		"order": map[string]any{
+			"id":    "A1",
+			"total": 12.5,
+		},
+		"items": []any{"x", "y"},
+	}
+
+	tests := []struct {
+		name            string
+		encoding        string
+		contentType     string
+		wantBody        string
+		wantContentType string
+		wantErr         bool
+	}{
+		{
+			name:            "default json",
+			wantBody:        `{"event":"order.created","items":["x","y"],"order":{"id":"A1","total":12.5}}`,
+			wantContentType: "application/json",
+		},
+		{
+			name:            "form",
+			encoding:        EncodingForm,
+			wantBody:        "event=order.created&items%5B0%5D=x&items%5B1%5D=y&order%5Bid%5D=A1&order%5Btotal%5D=12.5",
+			wantContentType: "application/x-www-form-urlencoded",
+		},
+		{
+			name:            "xml with custom content type",
+			encoding:        EncodingXML,
+			contentType:     "text/xml; charset=utf-8",
+			wantBody:        `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<payload><event>order.created</event><items>x</items><items>y</items><order><id>A1</id><total>12.5</total></order></payload>`,
+			wantContentType: "text/xml; charset=utf-8",
+		},
+		{
+			name:     "unsupported encoding",
+			encoding: "yaml",
+			wantErr:  true,
+		},
+		{
+			name:        "invalid content type",
+			contentType: "not a type",
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, contentType, err := EncodePayload(tt.encoding, tt.contentType, payload)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EncodePayload() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if string(body) != tt.wantBody {
+				t.Errorf("EncodePayload() body = %s, want %s", body, tt.wantBody)
+			}
+			if contentType != tt.wantContentType {
+				t.Errorf("EncodePayload() content type = %q, want %q", contentType, tt.wantContentType)
+			}
+		})
+	}
+}
+
+func TestEncodePayload_InvalidXMLKey(t *testing.T) {
+	if _, _, err := EncodePayload(EncodingXML, "", map[string]any{"1st": "x"}); err == nil {
+		t.Error("Expected error for key that is not a valid XML name")
+	}
+}
+
+func TestCheckPayload(t *testing.T) {
+	if err := CheckPayload(&domain.SendWebhookRequest{Encoding: EncodingXML, Payload: map[string]any{"order_id": "42"}}); err != nil {
+		t.Errorf("CheckPayload() error = %v, want nil", err)
+	}
+	if err := CheckPayload(&domain.SendWebhookRequest{Encoding: EncodingXML, Payload: map[string]any{"1st": "x"}}); err == nil {
+		t.Error("CheckPayload() error = nil, want the invalid XML name rejected")
+	}
+	// Template payloads are rendered, and encoded, at send time
+	if err := CheckPayload(&domain.SendWebhookRequest{Encoding: EncodingXML, TemplateID: "tmpl-1"}); err != nil {
+		t.Errorf("CheckPayload() for a template error = %v, want nil", err)
+	}
+}
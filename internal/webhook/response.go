@@ -0,0 +1,86 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+)
+
+// DefaultMaxResponseBodyBytes caps how much of a receiver's response body is read for diagnostics
+const DefaultMaxResponseBodyBytes int64 = 4 * 1024
+
+// truncatedSuffix marks a response snippet that was cut at the size cap
+const truncatedSuffix = "...[truncated]"
+
+// DeliveryError is returned when a webhook receiver responds with a non-2xx status.
+// It carries a bounded snippet of the response body to help explain the failure.
+type DeliveryError struct {
+	StatusCode int
+	Body       string
+	Truncated  bool
+}
+
+// Error implements the error interface
+func (e *DeliveryError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("webhook returned status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("webhook returned status %d: %s", e.StatusCode, e.Body)
+}
+
+// ReadResponse reads at most maxBytes of a webhook response body and closes it.
+// Reading stays bounded by the request's context and the client timeout, so the
+// caller must not cancel the request context until ReadResponse returns.
+// A non-positive maxBytes uses DefaultMaxResponseBodyBytes.
+func ReadResponse(resp *http.Response, maxBytes int64) (*domain.WebhookResponse, error) {
+	defer resp.Body.Close()
+
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxResponseBodyBytes
+	}
+
+	// Read one extra byte to tell whether the body was longer than the cap
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("failed to read webhook response: %w", err)
+	}
+
+	truncated := int64(len(data)) > maxBytes
+	if truncated {
+		data = data[:maxBytes]
+	}
+
+	return &domain.WebhookResponse{
+		StatusCode: resp.StatusCode,
+		Body:       strings.ToValidUTF8(string(data), ""),
+		Truncated:  truncated,
+	}, nil
+}
+
+// CheckResponse reads a bounded snippet of the response and returns a *DeliveryError
+// for non-2xx statuses. The captured response is returned in either case so it can be
+// stored on the delivery record.
+func CheckResponse(resp *http.Response, maxBytes int64) (*domain.WebhookResponse, error) {
+	captured, err := ReadResponse(resp, maxBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	if captured.StatusCode < 200 || captured.StatusCode >= 300 {
+		body := captured.Body
+		if captured.Truncated {
+			body += truncatedSuffix
+		}
+		return captured, &DeliveryError{
+			StatusCode: captured.StatusCode,
+			Body:       body,
+			Truncated:  captured.Truncated,
+		}
+	}
+
+	return captured, nil
+}
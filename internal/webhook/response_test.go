@@ -0,0 +1,106 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckResponse(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		body          string
+		maxBytes      int64
+		wantBody      string
+		wantTruncated bool
+		wantErr       string
+	}{
+		{name: "success", status: http.StatusOK, body: "ok", maxBytes: 16, wantBody: "ok"},
+		{
+			name:     "failure includes body",
+			status:   http.StatusBadRequest,
+			body:     `{"error":"missing signature"}`,
+			maxBytes: 64,
+			wantBody: `{"error":"missing signature"}`,
+			wantErr:  `webhook returned status 400: {"error":"missing signature"}`,
+		},
+		{
+			name:          "failure body truncated at cap",
+			status:        http.StatusInternalServerError,
+			body:          strings.Repeat("x", 100),
+			maxBytes:      10,
+			wantBody:      "xxxxxxxxxx",
+			wantTruncated: true,
+			wantErr:       "webhook returned status 500: xxxxxxxxxx...[truncated]",
+		},
+		{name: "failure without body", status: http.StatusBadGateway, maxBytes: 16, wantErr: "webhook returned status 502"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			resp, err := http.Get(server.URL)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+
+			captured, err := CheckResponse(resp, tt.maxBytes)
+			if captured == nil {
+				t.Fatalf("CheckResponse() returned no response, error = %v", err)
+			}
+			if captured.StatusCode != tt.status || captured.Body != tt.wantBody || captured.Truncated != tt.wantTruncated {
+				t.Errorf("CheckResponse() response = %+v", captured)
+			}
+
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("CheckResponse() error = %v", err)
+				}
+				return
+			}
+
+			var deliveryErr *DeliveryError
+			if !errors.As(err, &deliveryErr) {
+				t.Fatalf("CheckResponse() error = %v, want *DeliveryError", err)
+			}
+			if err.Error() != tt.wantErr {
+				t.Errorf("CheckResponse() error = %q, want %q", err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadResponse_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte("partial"))
+		w.(http.Flusher).Flush()
+		<-release // Never finish the body
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, server.URL, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+
+	if _, err := ReadResponse(resp, 1024); err == nil {
+		t.Error("Expected error when the body read outlives the request timeout")
+	}
+}
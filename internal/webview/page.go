@@ -0,0 +1,65 @@
+package webview
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+)
+
+var (
+	// ErrNotEmail is returned for notifications that have no email body to show
+	ErrNotEmail = errors.New("notification is not an email")
+	// ErrContentRemoved is returned when the body was redacted by the content retention job
+	ErrContentRemoved = errors.New("notification content was removed")
+)
+
+// ContentSecurityPolicy confines a hosted email: scripts, forms and frames are blocked and the
+// page runs in a sandbox with an opaque origin, so tenant HTML can't act as the service.
+// Images, styles and fonts may load, since emails commonly reference them remotely.
+const ContentSecurityPolicy = "sandbox allow-popups allow-popups-to-escape-sandbox; default-src 'none'; " +
+	"img-src https: http: data:; style-src 'unsafe-inline' https:; font-src https: data:"
+
+// Page is the hosted copy of a sent email
+type Page struct {
+	ContentType string
+	Body        []byte
+}
+
+// Render returns the hosted copy of an email notification's stored body. HTML bodies are
+// served as HTML and anything else as plain text. Notifications whose content was redacted
+// return ErrContentRemoved, so the page never outlives the tenant's retention policy.
+func Render(notification *domain.Notification) (*Page, error) {
+	if notification.Type != domain.NotificationTypeEmail {
+		return nil, ErrNotEmail
+	}
+	if notification.ContentRedactedAt != nil || notification.Body == "" {
+		return nil, ErrContentRemoved
+	}
+
+	contentType := "text/plain; charset=utf-8"
+	if isHTML(notification) {
+		contentType = "text/html; charset=utf-8"
+	}
+	return &Page{ContentType: contentType, Body: []byte(notification.Body)}, nil
+}
+
+// SetHeaders sets the headers a hosted page is served with. Responses aren't cached and
+// no referrer is sent, so the signed link doesn't leak to the hosts of remote images.
+func SetHeaders(header http.Header) {
+	header.Set("Content-Security-Policy", ContentSecurityPolicy)
+	header.Set("X-Content-Type-Options", "nosniff")
+	header.Set("Referrer-Policy", "no-referrer")
+	header.Set("Cache-Control", "private, no-store")
+	header.Set("X-Robots-Tag", "noindex, nofollow")
+}
+
+// isHTML reports whether the notification's body is HTML. Notifications stored before IsHTML
+// was recorded are sniffed.
+func isHTML(notification *domain.Notification) bool {
+	if notification.IsHTML {
+		return true
+	}
+	return strings.HasPrefix(http.DetectContentType([]byte(notification.Body)), "text/html")
+}
@@ -0,0 +1,54 @@
+package webview
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+)
+
+func TestRender(t *testing.T) {
+	redactedAt := time.Now()
+	tests := []struct {
+		name         string
+		notification domain.Notification
+		contentType  string
+		wantErr      error
+	}{
+		{"html", domain.Notification{Type: domain.NotificationTypeEmail, Body: "Hi <b>there</b>", IsHTML: true}, "text/html", nil},
+		{"sniffed html", domain.Notification{Type: domain.NotificationTypeEmail, Body: "<html><body>Hi</body></html>"}, "text/html", nil},
+		{"plain text", domain.Notification{Type: domain.NotificationTypeEmail, Body: "Hi there"}, "text/plain", nil},
+		{"redacted", domain.Notification{Type: domain.NotificationTypeEmail, Body: "Hi", ContentRedactedAt: &redactedAt}, "", ErrContentRemoved},
+		{"empty body", domain.Notification{Type: domain.NotificationTypeEmail}, "", ErrContentRemoved},
+		{"sms", domain.Notification{Type: domain.NotificationTypeSMS, Body: "Hi"}, "", ErrNotEmail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := Render(&tt.notification)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Render() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !strings.HasPrefix(page.ContentType, tt.contentType) {
+				t.Errorf("ContentType = %s, want %s", page.ContentType, tt.contentType)
+			}
+			if string(page.Body) != tt.notification.Body {
+				t.Errorf("Body = %q, want the stored body", page.Body)
+			}
+		})
+	}
+}
+
+func TestSetHeaders(t *testing.T) {
+	header := http.Header{}
+	SetHeaders(header)
+	if !strings.Contains(header.Get("Content-Security-Policy"), "sandbox") || header.Get("Referrer-Policy") != "no-referrer" {
+		t.Errorf("headers = %v, want a sandboxed CSP and no referrer", header)
+	}
+}
@@ -0,0 +1,41 @@
+// Package webview signs "view in browser" links to a hosted copy of a sent email
+package webview
+
+import (
+	"context"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/email"
+	"github.com/vhvplatform/go-notification-service/internal/signedlink"
+)
+
+// Signer signs and verifies view links, so a recipient can open an email's hosted copy
+// without API credentials
+type Signer = signedlink.Signer
+
+// NewSigner creates a signer of /view links that expire after ttl. Links are relative paths
+// unless baseURL, the service's public address, is set.
+func NewSigner(secret string, ttl time.Duration, baseURL string) *Signer {
+	return signedlink.NewSigner(secret, "view", "/view", ttl, baseURL)
+}
+
+// LinkComposer adds a signed view link to each HTML email the email router sends for a
+// notification, shown as "View in browser" at the top of the email
+type LinkComposer struct {
+	signer *Signer
+}
+
+// NewLinkComposer creates an email.Composer that adds view links signed by signer
+func NewLinkComposer(signer *Signer) *LinkComposer {
+	return &LinkComposer{signer: signer}
+}
+
+// Compose sets msg's view link. Plain text emails and emails without a notification have no
+// hosted copy to link to, so they are left unchanged.
+func (c *LinkComposer) Compose(ctx context.Context, tenantID string, msg *email.Message) error {
+	if msg.HTML == "" || msg.NotificationID == "" {
+		return nil
+	}
+	msg.ViewURL, _ = c.signer.Link(tenantID, msg.NotificationID)
+	return nil
+}
@@ -0,0 +1,79 @@
+package webview
+
+import (
+	"context"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/internal/domain"
+	"github.com/vhvplatform/go-notification-service/internal/email"
+	"github.com/vhvplatform/go-notification-service/internal/signedlink"
+)
+
+func TestSigner(t *testing.T) {
+	signer := NewSigner("test-secret", time.Hour, "https://notify.example.com/")
+
+	link, _ := signer.Link("tenant-1", "65f0c0ffee0000000000abcd")
+	if !strings.HasPrefix(link, "https://notify.example.com/view/65f0c0ffee0000000000abcd?") {
+		t.Fatalf("Link() = %s", link)
+	}
+	parsed, err := url.Parse(link)
+	if err != nil {
+		t.Fatalf("Link() is not a URL: %v", err)
+	}
+	query := parsed.Query()
+	expires, _ := strconv.ParseInt(query.Get("expires"), 10, 64)
+
+	if err := signer.Verify("tenant-1", "65f0c0ffee0000000000abcd", expires, query.Get(signedlink.SignatureParam)); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+}
+
+// capturingSender records each message it sends
+type capturingSender struct {
+	sent []*email.Message
+}
+
+func (s *capturingSender) Send(ctx context.Context, msg *email.Message) (string, error) {
+	s.sent = append(s.sent, msg)
+	return "provider-1", nil
+}
+
+func TestRouterSendsViewLink(t *testing.T) {
+	provider := &capturingSender{}
+	email.Register("view-capture", func(cfg email.Config, client *http.Client) (email.Sender, error) {
+		return provider, nil
+	})
+	router, err := email.NewRouter(email.Config{Provider: "view-capture"})
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+	router.Use(NewLinkComposer(NewSigner("test-secret", time.Hour, "https://notify.example.com")))
+
+	for _, isHTML := range []bool{true, false} {
+		req := &domain.SendEmailRequest{TenantID: "tenant-1", To: []string{"user@example.com"}, Subject: "Hi", Body: "<p>Hello</p>", IsHTML: isHTML}
+		msg, err := email.NewMessage(mail.Address{Address: "noreply@example.com"}, req, "65f0c0ffee0000000000abcd")
+		if err != nil {
+			t.Fatalf("NewMessage() error = %v", err)
+		}
+		if _, _, err := router.Send(context.Background(), req.TenantID, msg); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+	}
+
+	html := provider.sent[0]
+	if !strings.HasPrefix(html.ViewURL, "https://notify.example.com/view/65f0c0ffee0000000000abcd?") || !strings.Contains(html.ViewURL, "tenant_id=tenant-1") {
+		t.Errorf("ViewURL = %q, want a signed link to the notification", html.ViewURL)
+	}
+	if body := html.HTMLBody(); !strings.Contains(body, "https://notify.example.com/view/65f0c0ffee0000000000abcd") {
+		t.Errorf("HTML body has no view link:\n%s", body)
+	}
+	if plain := provider.sent[1]; plain.ViewURL != "" {
+		t.Errorf("ViewURL = %q for a plain text email, want none", plain.ViewURL)
+	}
+}
@@ -0,0 +1,37 @@
+package webhooksig_test
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/vhvplatform/go-notification-service/pkg/webhooksig"
+)
+
+func ExampleVerifySignature() {
+	http.HandleFunc("/webhooks/notifications", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+		err = webhooksig.VerifySignature("whsec_...", body,
+			r.Header.Get(webhooksig.SignatureHeader), r.Header.Get(webhooksig.TimestampHeader), 5*time.Minute)
+		if err != nil {
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+		// Handle the verified body
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func ExampleMiddleware() {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Only verified deliveries get here; r.Body is the original body
+		w.WriteHeader(http.StatusNoContent)
+	})
+	http.Handle("/webhooks/notifications", webhooksig.Middleware("whsec_...", 0)(handler))
+	log.Fatal(http.ListenAndServe(":8080", nil))
+}
@@ -0,0 +1,152 @@
+// Package webhooksig signs and verifies the notification service's webhook deliveries.
+//
+// A delivery carries two headers: TimestampHeader, the Unix time in seconds it was signed at,
+// and SignatureHeader, one or more comma-separated "v1=<hex>" signatures. Each signature is
+// the HMAC-SHA256, keyed with the webhook secret, of the timestamp, a period and the raw
+// request body. Several signatures are sent while a secret is being rotated; a delivery is
+// valid when any of them matches.
+//
+// Receivers call VerifySignature with the raw body before parsing it, or wrap their handler
+// with Middleware.
+//
+// Each delivery also carries DeliveryIDHeader, an ID that stays the same when a delivery is
+// retried, whether automatically or from the dead-letter queue. A signature only rejects old
+// deliveries; receivers that must process a delivery once should record its ID and ignore
+// deliveries whose ID they have already processed.
+package webhooksig
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers set on signed webhook deliveries
+const (
+	SignatureHeader = "X-Notification-Signature"
+	TimestampHeader = "X-Notification-Timestamp"
+)
+
+// DeliveryIDHeader identifies a webhook delivery, staying the same across its retries
+const DeliveryIDHeader = "X-Webhook-Id"
+
+// signatureVersion prefixes each signature in SignatureHeader
+const signatureVersion = "v1"
+
+// DefaultTolerance is how far a delivery's timestamp may be from the receiver's clock when
+// no tolerance is given
+const DefaultTolerance = 5 * time.Minute
+
+// DefaultMaxBodyBytes bounds the body Middleware reads
+const DefaultMaxBodyBytes int64 = 10 * 1024 * 1024
+
+var (
+	// ErrMissingSignature is returned when a delivery has no signature or timestamp
+	ErrMissingSignature = errors.New("webhook signature or timestamp missing")
+
+	// ErrInvalidSignature is returned when no signature matches the body and timestamp
+	ErrInvalidSignature = errors.New("webhook signature does not match")
+
+	// ErrTimestampOutOfTolerance is returned when a delivery was signed too long ago, or too far
+	// in the future, which may be a replay
+	ErrTimestampOutOfTolerance = errors.New("webhook timestamp outside tolerance")
+)
+
+// Sign returns the SignatureHeader value for body signed with secret at timestamp
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	return signatureVersion + "=" + hex.EncodeToString(compute(secret, strconv.FormatInt(timestamp.Unix(), 10), body))
+}
+
+// SignRequest sets the signature headers on req for body signed with secret at timestamp
+func SignRequest(req *http.Request, secret string, timestamp time.Time, body []byte) {
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp.Unix(), 10))
+	req.Header.Set(SignatureHeader, Sign(secret, timestamp, body))
+}
+
+// VerifySignature checks that body was signed with secret, given the values of the
+// SignatureHeader and TimestampHeader headers, and that the timestamp is within tolerance
+// of the current time. A non-positive tolerance uses DefaultTolerance.
+// Signatures are compared in constant time.
+func VerifySignature(secret string, body []byte, signatureHeader, timestampHeader string, tolerance time.Duration) error {
+	return verify(time.Now(), secret, body, signatureHeader, timestampHeader, tolerance)
+}
+
+// verify is VerifySignature against the clock reading now
+func verify(now time.Time, secret string, body []byte, signatureHeader, timestampHeader string, tolerance time.Duration) error {
+	timestampHeader = strings.TrimSpace(timestampHeader)
+	if signatureHeader == "" || timestampHeader == "" {
+		return ErrMissingSignature
+	}
+	if tolerance <= 0 {
+		tolerance = DefaultTolerance
+	}
+
+	seconds, err := strconv.ParseInt(timestampHeader, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: malformed timestamp %q", ErrInvalidSignature, timestampHeader)
+	}
+	if age := now.Sub(time.Unix(seconds, 0)); age > tolerance || age < -tolerance {
+		return fmt.Errorf("%w: signed %s ago, tolerance %s", ErrTimestampOutOfTolerance, age.Round(time.Second), tolerance)
+	}
+
+	expected := compute(secret, timestampHeader, body)
+	for _, part := range strings.Split(signatureHeader, ",") {
+		version, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok || version != signatureVersion {
+			continue
+		}
+		signature, err := hex.DecodeString(value)
+		if err != nil {
+			continue
+		}
+		if hmac.Equal(signature, expected) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+// compute returns the HMAC-SHA256 of timestamp, a period and body
+func compute(secret, timestamp string, body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// Middleware rejects requests whose signature doesn't verify with 401 Unauthorized before
+// they reach next. Bodies over DefaultMaxBodyBytes are rejected with 413. The body is
+// restored, so next can read it as usual. A non-positive tolerance uses DefaultTolerance.
+func Middleware(secret string, tolerance time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(io.LimitReader(r.Body, DefaultMaxBodyBytes+1))
+			r.Body.Close()
+			if err != nil {
+				http.Error(w, "failed to read request body", http.StatusBadRequest)
+				return
+			}
+			if int64(len(body)) > DefaultMaxBodyBytes {
+				http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+
+			if err := VerifySignature(secret, body, r.Header.Get(SignatureHeader), r.Header.Get(TimestampHeader), tolerance); err != nil {
+				http.Error(w, "invalid webhook signature", http.StatusUnauthorized)
+				return
+			}
+
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package webhooksig
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "whsec_test"
+
+var signedAt = time.Unix(1700000000, 0)
+
+func TestSignMatchesSpec(t *testing.T) {
+	body := []byte(`{"event":"order.shipped"}`)
+
+	// Computed independently from the documented scheme: HMAC-SHA256(secret, "<ts>.<body>")
+	mac := hmac.New(sha256.New, []byte(testSecret))
+	mac.Write([]byte("1700000000." + string(body)))
+	want := "v1=" + hex.EncodeToString(mac.Sum(nil))
+
+	if got := Sign(testSecret, signedAt, body); got != want {
+		t.Errorf("Sign() = %q, want %q", got, want)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"event":"order.shipped"}`)
+	timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+	signature := Sign(testSecret, signedAt, body)
+	otherSignature := Sign("whsec_old", signedAt, body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		body      []byte
+		signature string
+		timestamp string
+		now       time.Time
+		tolerance time.Duration
+		wantErr   error
+	}{
+		{name: "valid", secret: testSecret, body: body, signature: signature, timestamp: timestamp, now: signedAt},
+		{name: "valid within tolerance", secret: testSecret, body: body, signature: signature, timestamp: timestamp, now: signedAt.Add(4 * time.Minute)},
+		{name: "clock skew within tolerance", secret: testSecret, body: body, signature: signature, timestamp: timestamp, now: signedAt.Add(-4 * time.Minute)},
+		{name: "any of several signatures", secret: testSecret, body: body, signature: otherSignature + ", " + signature, timestamp: timestamp, now: signedAt},
+		{name: "unknown versions are skipped", secret: testSecret, body: body, signature: "v0=abc," + signature, timestamp: timestamp, now: signedAt},
+		{name: "custom tolerance", secret: testSecret, body: body, signature: signature, timestamp: timestamp, now: signedAt.Add(time.Hour), tolerance: 2 * time.Hour},
+		{name: "tampered body", secret: testSecret, body: []byte(`{"event":"order.refunded"}`), signature: signature, timestamp: timestamp, now: signedAt, wantErr: ErrInvalidSignature},
+		{name: "wrong secret", secret: "whsec_other", body: body, signature: signature, timestamp: timestamp, now: signedAt, wantErr: ErrInvalidSignature},
+		{name: "timestamp changed", secret: testSecret, body: body, signature: signature, timestamp: "1700000001", now: signedAt, wantErr: ErrInvalidSignature},
+		{name: "malformed timestamp", secret: testSecret, body: body, signature: signature, timestamp: "yesterday", now: signedAt, wantErr: ErrInvalidSignature},
+		{name: "malformed signature", secret: testSecret, body: body, signature: "v1=not-hex", timestamp: timestamp, now: signedAt, wantErr: ErrInvalidSignature},
+		{name: "signature without version", secret: testSecret, body: body, signature: strings.TrimPrefix(signature, "v1="), timestamp: timestamp, now: signedAt, wantErr: ErrInvalidSignature},
+		{name: "replayed", secret: testSecret, body: body, signature: signature, timestamp: timestamp, now: signedAt.Add(6 * time.Minute), wantErr: ErrTimestampOutOfTolerance},
+		{name: "from the future", secret: testSecret, body: body, signature: signature, timestamp: timestamp, now: signedAt.Add(-6 * time.Minute), wantErr: ErrTimestampOutOfTolerance},
+		{name: "missing signature", secret: testSecret, body: body, timestamp: timestamp, now: signedAt, wantErr: ErrMissingSignature},
+		{name: "missing timestamp", secret: testSecret, body: body, signature: signature, now: signedAt, wantErr: ErrMissingSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verify(tt.now, tt.secret, tt.body, tt.signature, tt.timestamp, tt.tolerance)
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("verify() error = %v, want nil", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("verify() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifySignatureUsesCurrentTime(t *testing.T) {
+	body := []byte("{}")
+	now := time.Now()
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	SignRequest(req, testSecret, now, body)
+
+	if err := VerifySignature(testSecret, body, req.Header.Get(SignatureHeader), req.Header.Get(TimestampHeader), 0); err != nil {
+		t.Errorf("VerifySignature() error = %v, want nil", err)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	body := `{"event":"order.shipped"}`
+	var received string
+	handler := Middleware(testSecret, 0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		received = string(data)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/hooks", strings.NewReader(body))
+	SignRequest(req, testSecret, time.Now(), []byte(body))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent || received != body {
+		t.Errorf("signed request: status = %d, body = %q; want 204 and the original body", w.Code, received)
+	}
+
+	received = ""
+	req = httptest.NewRequest(http.MethodPost, "/hooks", strings.NewReader(body))
+	SignRequest(req, "whsec_other", time.Now(), []byte(body))
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized || received != "" {
+		t.Errorf("badly signed request: status = %d, reached handler = %v; want 401", w.Code, received != "")
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/hooks", strings.NewReader(body))
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("unsigned request: status = %d, want 401", w.Code)
+	}
+}